@@ -47,6 +47,7 @@ func Bootstrap(ctx context.Context, rootCrdClient apiextensionsclient.Interface,
 		{Group: tenancy.GroupName, Resource: "clusterworkspaces"},
 		{Group: tenancy.GroupName, Resource: "clusterworkspacetypes"},
 		{Group: tenancy.GroupName, Resource: "workspaceshards"},
+		{Group: tenancy.GroupName, Resource: "workspacequotas"},
 	}, confighelpers.ReplaceOption(
 		"SHARD_NAME", shardName,
 		"SHARD_KUBECONFIG", base64.StdEncoding.EncodeToString(kubeconfigRaw),
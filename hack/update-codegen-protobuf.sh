@@ -0,0 +1,53 @@
+#!/usr/bin/env bash
+
+# Copyright 2022 The KCP Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+set -o xtrace
+
+export GOPATH=$(go env GOPATH)
+
+SCRIPT_ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+CODEGEN_PKG=${CODEGEN_PKG:-$(cd "${SCRIPT_ROOT}"; go list -f '{{.Dir}}' -m k8s.io/code-generator)}
+
+if ! command -v protoc > /dev/null; then
+  echo "protoc is required to generate the protobuf marshalling, see https://github.com/protocolbuffers/protobuf/releases"
+  exit 1
+fi
+
+go install "${CODEGEN_PKG}"/cmd/go-to-protobuf "${CODEGEN_PKG}"/cmd/go-to-protobuf/protoc-gen-gogo
+export PATH="${GOPATH}/bin:${PATH}"
+
+# go-to-protobuf writes its output and resolves the .proto imports below a GOPATH-like
+# directory, so lay out kcp and the imported modules in a temporary one.
+OUTPUT_BASE=$(mktemp -d)
+trap 'rm -rf "${OUTPUT_BASE}"' EXIT
+
+mkdir -p "${OUTPUT_BASE}/github.com/kcp-dev" "${OUTPUT_BASE}/github.com/gogo" "${OUTPUT_BASE}/k8s.io"
+ln -s "${SCRIPT_ROOT}" "${OUTPUT_BASE}/github.com/kcp-dev/kcp"
+ln -s "$(cd "${SCRIPT_ROOT}"; go list -f '{{.Dir}}' -m github.com/gogo/protobuf)" "${OUTPUT_BASE}/github.com/gogo/protobuf"
+ln -s "$(cd "${SCRIPT_ROOT}"; go list -f '{{.Dir}}' -m k8s.io/apimachinery)" "${OUTPUT_BASE}/k8s.io/apimachinery"
+ln -s "$(cd "${SCRIPT_ROOT}"; go list -f '{{.Dir}}' -m k8s.io/api)" "${OUTPUT_BASE}/k8s.io/api"
+
+cd "${SCRIPT_ROOT}"
+
+"${GOPATH}"/bin/go-to-protobuf \
+  --output-base "${OUTPUT_BASE}" \
+  --proto-import "${OUTPUT_BASE}/github.com/gogo/protobuf/protobuf" \
+  --go-header-file "${SCRIPT_ROOT}"/hack/boilerplate/boilerplate.generatego.txt \
+  --apimachinery-packages "-k8s.io/apimachinery/pkg/util/intstr,-k8s.io/apimachinery/pkg/api/resource,-k8s.io/apimachinery/pkg/runtime/schema,-k8s.io/apimachinery/pkg/runtime,-k8s.io/apimachinery/pkg/apis/meta/v1,-k8s.io/api/core/v1,-k8s.io/api/authorization/v1" \
+  --packages "+github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1,+github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
@@ -24,6 +24,7 @@ if [[ -z "${MAKELEVEL:-}" ]]; then
 fi
 
 "$(dirname "${BASH_SOURCE[0]}")/update-codegen-clients.sh"
+"$(dirname "${BASH_SOURCE[0]}")/update-codegen-protobuf.sh"
 
 # Update generated CRD YAML
 ${CONTROLLER_GEN} \
@@ -21,19 +21,36 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
 
 	kcpadmissionhelpers "github.com/kcp-dev/kcp/pkg/admission/helpers"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/features"
 )
 
+// Mutate ClusterWorkspace creation to
+// - stamp the created-by annotation with the authenticated user, unless set by a privileged user
+// - stamp the created-at annotation with the time of the server.
+//
 // Validate ClusterWorkspace creation and updates for
 // - immutability of fields like type
-// - valid phase transitions fulfilling pre-conditions
-// - status.location.current and status.baseURL cannot be unset.
+// - valid phase transitions fulfilling pre-conditions, without skipping phases
+// - status.location.current and status.baseURL cannot be unset
+// - the owner annotations can only be changed by the owner, or by privileged users transferring the ownership
+// - the created-by and created-at annotations are immutable
+// - the shard selector annotation is a valid label selector
+// - the initial members annotation is valid
+// - the bootstrap bundle annotations are valid
+// - the tags are valid, and system tags can only be set or changed by privileged users
+// - the feature gates are known to the shard on creation.
 
 const (
 	PluginName = "tenancy.kcp.dev/ClusterWorkspace"
@@ -44,28 +61,73 @@ func Register(plugins *admission.Plugins) {
 		func(_ io.Reader) (admission.Interface, error) {
 			return &clusterWorkspace{
 				Handler: admission.NewHandler(admission.Create, admission.Update),
+				now:     time.Now,
 			}, nil
 		})
 }
 
 type clusterWorkspace struct {
 	*admission.Handler
+
+	now func() time.Time
 }
 
 // Ensure that the required admission interfaces are implemented.
+var _ = admission.MutationInterface(&clusterWorkspace{})
 var _ = admission.ValidationInterface(&clusterWorkspace{})
 
+// phaseOrdinal orders the phases a workspace goes through before becoming ready. Terminating
+// is deliberately missing: it can be entered from any phase and carries no requirements. Failed
+// has the requirements of Initializing, which it is entered from and can go back to.
 var phaseOrdinal = map[tenancyv1alpha1.ClusterWorkspacePhaseType]int{
 	tenancyv1alpha1.ClusterWorkspacePhaseType(""):     1,
 	tenancyv1alpha1.ClusterWorkspacePhaseScheduling:   2,
 	tenancyv1alpha1.ClusterWorkspacePhaseInitializing: 3,
+	tenancyv1alpha1.ClusterWorkspacePhaseFailed:       3,
 	tenancyv1alpha1.ClusterWorkspacePhaseReady:        4,
 }
 
+// Admit stamps a created ClusterWorkspace with the user who created it and the time of the creation. Values
+// sent by the client are overridden, except a created-by set by a privileged user creating the workspace on
+// behalf of another user.
+func (o *clusterWorkspace) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaces") {
+		return nil
+	}
+	if a.GetOperation() != admission.Create {
+		return nil
+	}
+
+	accessor, err := meta.Accessor(a.GetObject())
+	if err != nil {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if createdBy := annotations[tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation]; createdBy == "" || !isPrivileged(a.GetUserInfo()) {
+		annotations[tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation] = a.GetUserInfo().GetName()
+	}
+	annotations[tenancyv1alpha1.ClusterWorkspaceCreatedAtAnnotation] = o.now().UTC().Format(time.RFC3339)
+	accessor.SetAnnotations(annotations)
+
+	return nil
+}
+
 // Validate ensures that
-// - the workspace only does a valid phase transition
+// - the workspace only does a valid phase transition, see tenancyhelper.IsValidPhaseTransition
 // - has a valid type
 // - has valid initializers when transitioning to initializing
+// - keeps its owner annotations unless updated by the owner or a privileged user
+// - keeps its created-by and created-at annotations
+// - has a valid shard selector annotation when created or when the annotation changes
+// - has a valid initial members annotation when created
+// - has a valid default namespace labels annotation when created or when the annotation changes
+// - has valid bootstrap bundle annotations when created
+// - has a display name and a description of bounded length
+// - has valid tags, and keeps its system tags unless created or updated by a privileged user
+// - has only known feature gates when created. Later changes are validated by the workspace scheduler.
 func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaces") {
 		return nil
@@ -82,6 +144,49 @@ func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes,
 		return nil // only work on unstructured ClusterWorkspaces
 	}
 
+	if selector, found := cw.Annotations[tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation]; found && a.GetOperation() == admission.Create {
+		if err := validateShardSelector(selector); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	}
+
+	if members, found := cw.Annotations[tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation]; found && a.GetOperation() == admission.Create {
+		if _, err := tenancyhelper.ParseInitialMembers(members); err != nil {
+			return admission.NewForbidden(a, field.Invalid(field.NewPath("metadata", "annotations").Key(tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation), members, err.Error()))
+		}
+	}
+
+	if namespaceLabels, found := cw.Annotations[tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation]; found && a.GetOperation() == admission.Create {
+		if err := validateDefaultNamespaceLabels(namespaceLabels); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	}
+
+	if a.GetOperation() == admission.Create {
+		if errs := tenancyhelper.ValidateBootstrapBundle(cw.Annotations, field.NewPath("metadata", "annotations")); len(errs) > 0 {
+			return admission.NewForbidden(a, errs.ToAggregate())
+		}
+	}
+
+	if errs := tenancyhelper.ValidateDisplayMetadata(cw.Spec.DisplayName, cw.Spec.Description, field.NewPath("spec")); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+
+	if errs := tenancyhelper.ValidateTags(cw.Spec.Tags, field.NewPath("spec", "tags")); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+	if a.GetOperation() == admission.Create && !isPrivileged(a.GetUserInfo()) {
+		if errs := tenancyhelper.ValidateSystemTagsUnchanged(nil, cw.Spec.Tags, field.NewPath("spec", "tags")); len(errs) > 0 {
+			return admission.NewForbidden(a, errs.ToAggregate())
+		}
+	}
+
+	if a.GetOperation() == admission.Create {
+		if errs := tenancyhelper.ValidateFeatureGates(cw.Spec.FeatureGates, features.WorkspaceFeatureGates(), field.NewPath("spec", "featureGates")); len(errs) > 0 {
+			return admission.NewForbidden(a, errs.ToAggregate())
+		}
+	}
+
 	if a.GetOperation() == admission.Update {
 		obj, err = kcpadmissionhelpers.NativeObject(a.GetOldObject())
 		if err != nil {
@@ -107,7 +212,39 @@ func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes,
 			return admission.NewForbidden(a, errors.New("status.baseURL cannot be unset"))
 		}
 
-		if phaseOrdinal[old.Status.Phase] > phaseOrdinal[cw.Status.Phase] {
+		if owner := old.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation]; owner != "" && a.GetUserInfo().GetName() != owner && !isPrivileged(a.GetUserInfo()) {
+			for _, key := range []string{tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation, tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation} {
+				if old.Annotations[key] != cw.Annotations[key] {
+					return admission.NewForbidden(a, fmt.Errorf("annotation %s can only be changed by the owner %q", key, owner))
+				}
+			}
+		}
+
+		if !isPrivileged(a.GetUserInfo()) {
+			if errs := tenancyhelper.ValidateSystemTagsUnchanged(old.Spec.Tags, cw.Spec.Tags, field.NewPath("spec", "tags")); len(errs) > 0 {
+				return admission.NewForbidden(a, errs.ToAggregate())
+			}
+		}
+
+		for _, key := range []string{tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation, tenancyv1alpha1.ClusterWorkspaceCreatedAtAnnotation} {
+			if old.Annotations[key] != cw.Annotations[key] {
+				return admission.NewForbidden(a, fmt.Errorf("annotation %s is immutable", key))
+			}
+		}
+
+		if selector, found := cw.Annotations[tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation]; found && selector != old.Annotations[tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation] {
+			if err := validateShardSelector(selector); err != nil {
+				return admission.NewForbidden(a, err)
+			}
+		}
+
+		if namespaceLabels, found := cw.Annotations[tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation]; found && namespaceLabels != old.Annotations[tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation] {
+			if err := validateDefaultNamespaceLabels(namespaceLabels); err != nil {
+				return admission.NewForbidden(a, err)
+			}
+		}
+
+		if !tenancyhelper.IsValidPhaseTransition(old.Status.Phase, cw.Status.Phase) {
 			return admission.NewForbidden(a, fmt.Errorf("cannot transition from %q to %q", old.Status.Phase, cw.Status.Phase))
 		}
 	}
@@ -127,3 +264,30 @@ func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes,
 
 	return nil
 }
+
+// isPrivileged returns whether the user is a member of the system:masters group, like the workspaces
+// virtual workspace transferring the ownership of workspaces or managing system tags.
+func isPrivileged(userInfo user.Info) bool {
+	for _, group := range userInfo.GetGroups() {
+		if group == user.SystemPrivilegedGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// validateShardSelector returns an error if the value of the shard selector annotation is no valid label selector.
+func validateShardSelector(selector string) error {
+	if _, err := labels.Parse(selector); err != nil {
+		return field.Invalid(field.NewPath("metadata", "annotations").Key(tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation), selector, err.Error())
+	}
+	return nil
+}
+
+// validateDefaultNamespaceLabels returns an error if the value of the default namespace labels annotation cannot be parsed.
+func validateDefaultNamespaceLabels(value string) error {
+	if _, err := tenancyhelper.ParseDefaultNamespaceLabels(value); err != nil {
+		return field.Invalid(field.NewPath("metadata", "annotations").Key(tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation), value, err.Error())
+	}
+	return nil
+}
@@ -18,7 +18,11 @@ package clusterworkspace
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/admission"
@@ -29,6 +33,10 @@ import (
 )
 
 func createAttr(ws *tenancyv1alpha1.ClusterWorkspace) admission.Attributes {
+	return createAttrAs(ws, &user.DefaultInfo{})
+}
+
+func createAttrAs(ws *tenancyv1alpha1.ClusterWorkspace, userInfo user.Info) admission.Attributes {
 	return admission.NewAttributesRecord(
 		ws,
 		nil,
@@ -40,11 +48,15 @@ func createAttr(ws *tenancyv1alpha1.ClusterWorkspace) admission.Attributes {
 		admission.Create,
 		&metav1.CreateOptions{},
 		false,
-		&user.DefaultInfo{},
+		userInfo,
 	)
 }
 
 func updateAttr(ws, old *tenancyv1alpha1.ClusterWorkspace) admission.Attributes {
+	return updateAttrAs(ws, old, &user.DefaultInfo{})
+}
+
+func updateAttrAs(ws, old *tenancyv1alpha1.ClusterWorkspace, userInfo user.Info) admission.Attributes {
 	return admission.NewAttributesRecord(
 		ws,
 		old,
@@ -56,7 +68,7 @@ func updateAttr(ws, old *tenancyv1alpha1.ClusterWorkspace) admission.Attributes
 		admission.Update,
 		&metav1.CreateOptions{},
 		false,
-		&user.DefaultInfo{},
+		userInfo,
 	)
 }
 
@@ -201,7 +213,38 @@ func TestValidate(t *testing.T) {
 				}),
 		},
 		{
-			name: "allows transition to ready directly when valid",
+			name: "allows transition from Initializing to Failed with non-empty initializers",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:        tenancyv1alpha1.ClusterWorkspacePhaseFailed,
+					Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a"},
+					Location:     tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:      "https://kcp.bigcorp.com/clusters/org:test",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Type: "Foo",
+					},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+						Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a"},
+						Location:     tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+						BaseURL:      "https://kcp.bigcorp.com/clusters/org:test",
+					},
+				}),
+		},
+		{
+			name: "rejects transition to ready directly even when valid",
 			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test",
@@ -228,6 +271,7 @@ func TestValidate(t *testing.T) {
 						Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a"},
 					},
 				}),
+			wantErr: true,
 		},
 		{
 			name: "allows creation to ready directly when valid",
@@ -307,6 +351,503 @@ func TestValidate(t *testing.T) {
 				}),
 			wantErr: true,
 		},
+		{
+			name: "allows transition to scheduling on first reconciliation",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+				}),
+		},
+		{
+			name: "allows transition from scheduling to initializing when scheduled",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						Phase:    tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+						Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+						BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+					},
+				}),
+		},
+		{
+			name: "rejects transition to initializing skipping scheduling",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+				}),
+			wantErr: true,
+		},
+		{
+			name: "rejects clearing the owner by another user",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+					},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+							tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+						},
+					},
+				}, &user.DefaultInfo{Name: "user-2"}),
+			wantErr: true,
+		},
+		{
+			name: "rejects changing the owner groups by a member of the groups",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+						tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-2",
+					},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+							tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+						},
+					},
+				}, &user.DefaultInfo{Name: "user-2", Groups: []string{"team-1"}}),
+			wantErr: true,
+		},
+		{
+			name: "allows other updates preserving the owner by another user",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+						tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+					},
+					Labels: map[string]string{"env": "prod"},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+							tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+						},
+					},
+				}, &user.DefaultInfo{Name: "user-2"}),
+		},
+		{
+			name: "allows changing the owner by a privileged user",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-2",
+						tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-2",
+					},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+							tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+						},
+					},
+				}, &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}),
+		},
+		{
+			name: "allows clearing the owner by the owner",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+							tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+						},
+					},
+				}, &user.DefaultInfo{Name: "user-1"}),
+		},
+		{
+			name: "rejects overwriting created-by by the owner",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:     "user-1",
+						tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation: "user-2",
+					},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:     "user-1",
+							tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation: "user-1",
+						},
+					},
+				}, &user.DefaultInfo{Name: "user-1"}),
+			wantErr: true,
+		},
+		{
+			name: "rejects clearing created-at by a privileged user",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceCreatedAtAnnotation: "2022-05-01T10:00:00Z",
+						},
+					},
+				}, &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}),
+			wantErr: true,
+		},
+		{
+			name: "allows creation with a valid shard selector",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation: "region in (eu, us),tier=gold",
+					},
+				},
+			}),
+		},
+		{
+			name: "rejects creation with an invalid shard selector",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation: "region in eu",
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "rejects changing the shard selector to an invalid one",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation: "region==eu==us",
+					},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation: "region=eu",
+						},
+					},
+				}),
+			wantErr: true,
+		},
+		{
+			name: "allows creation with valid initial members",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation: "user:user-2=edit,group:team-1=view",
+					},
+				},
+			}),
+		},
+		{
+			name: "rejects creation with an initial member role out of the allowlist",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation: "user:user-2=cluster-admin",
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "allows creation with valid default namespace labels",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team=payments,network.example.com/policy=strict",
+					},
+				},
+			}),
+		},
+		{
+			name: "rejects creation with invalid default namespace labels",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team:payments",
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "rejects changing the default namespace labels to invalid ones",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team=pay ments",
+					},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team=payments",
+						},
+					},
+				}),
+			wantErr: true,
+		},
+		{
+			name: "rejects creation with a bootstrap bundle without checksum",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation: "https://bundles.example.com/team.tar.gz",
+					},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "allows creation with a bootstrap bundle and its checksum",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation:    "https://bundles.example.com/team.tar.gz",
+						tenancyv1alpha1.ClusterWorkspaceBootstrapBundleSHA256Annotation: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+					},
+				},
+			}),
+		},
+		{
+			name: "allows creation with a display name and a description",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					DisplayName: "Team A staging",
+					Description: "Where team A tries things before production.",
+				},
+			}),
+		},
+		{
+			name: "rejects a display name longer than allowed",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					DisplayName: strings.Repeat("a", tenancyv1alpha1.ClusterWorkspaceDisplayNameMaxLength+1),
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "allows a user tag set by a normal user",
+			a: createAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Tags: map[string]string{"cost-center": "cc-42"},
+				},
+			}, &user.DefaultInfo{Name: "user-1"}),
+		},
+		{
+			name: "rejects a system tag set by a normal user",
+			a: createAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Tags: map[string]string{tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"},
+				},
+			}, &user.DefaultInfo{Name: "user-1"}),
+			wantErr: true,
+		},
+		{
+			name: "allows a system tag set by a privileged user",
+			a: createAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Tags: map[string]string{tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"},
+				},
+			}, &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}),
+		},
+		{
+			name: "rejects a tag with an invalid key",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Tags: map[string]string{"cost center": "cc-42"},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "allows changing a user tag and keeping the system tags by a normal user",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Tags: map[string]string{"cost-center": "cc-43", tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Tags: map[string]string{"cost-center": "cc-42", tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"},
+					},
+				}, &user.DefaultInfo{Name: "user-1"}),
+		},
+		{
+			name: "rejects changing a system tag by a normal user",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Tags: map[string]string{tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "platinum"},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Tags: map[string]string{tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"},
+					},
+				}, &user.DefaultInfo{Name: "user-1"}),
+			wantErr: true,
+		},
+		{
+			name: "allows removing a system tag by a privileged user",
+			a: updateAttrAs(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Tags: map[string]string{tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"},
+					},
+				}, &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}),
+		},
+		{
+			name: "allows a known feature gate on creation",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					FeatureGates: map[string]bool{"ServerSideApply": false},
+				},
+			}),
+		},
+		{
+			name: "rejects an unknown feature gate on creation",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					FeatureGates: map[string]bool{"Teleportation": true},
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "allows changing the feature gates, validated by the scheduler",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					FeatureGates: map[string]bool{"Teleportation": true},
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+				}),
+		},
 		{
 			name: "ignores different resources",
 			a: admission.NewAttributesRecord(
@@ -340,3 +881,83 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestAdmit(t *testing.T) {
+	now := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		ws       *tenancyv1alpha1.ClusterWorkspace
+		userInfo user.Info
+		want     map[string]string
+	}{
+		{
+			name:     "stamps the creator and the creation time",
+			ws:       &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+			userInfo: &user.DefaultInfo{Name: "user-1"},
+			want: map[string]string{
+				tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation: "user-1",
+				tenancyv1alpha1.ClusterWorkspaceCreatedAtAnnotation: "2022-05-01T10:00:00Z",
+			},
+		},
+		{
+			name: "overrides the values given by a user",
+			ws: &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation: "user-2",
+						tenancyv1alpha1.ClusterWorkspaceCreatedAtAnnotation: "2000-01-01T00:00:00Z",
+						"other": "value",
+					},
+				},
+			},
+			userInfo: &user.DefaultInfo{Name: "user-1"},
+			want: map[string]string{
+				tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation: "user-1",
+				tenancyv1alpha1.ClusterWorkspaceCreatedAtAnnotation: "2022-05-01T10:00:00Z",
+				"other": "value",
+			},
+		},
+		{
+			name: "keeps the creator given by a privileged user",
+			ws: &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation: "user-1",
+					},
+				},
+			},
+			userInfo: &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}},
+			want: map[string]string{
+				tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation: "user-1",
+				tenancyv1alpha1.ClusterWorkspaceCreatedAtAnnotation: "2022-05-01T10:00:00Z",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &clusterWorkspace{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				now:     func() time.Time { return now },
+			}
+			a := admission.NewAttributesRecord(
+				tt.ws,
+				nil,
+				tenancyv1alpha1.Kind("ClusterWorkspace").WithVersion("v1alpha1"),
+				"",
+				tt.ws.Name,
+				tenancyv1alpha1.Resource("clusterworkspaces").WithVersion("v1alpha1"),
+				"",
+				admission.Create,
+				&metav1.CreateOptions{},
+				false,
+				tt.userInfo,
+			)
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: "root:org"})
+			require.NoError(t, o.Admit(ctx, a, nil))
+			require.Equal(t, tt.want, tt.ws.Annotations)
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/admission"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 
@@ -32,6 +33,7 @@ import (
 
 // Validate ClusterWorkspaceTypes creation and updates for
 //  - "organization" type is only created in root workspace.
+//  - the template only holds objects a template can create.
 
 const (
 	PluginName = "tenancy.kcp.dev/ClusterWorkspaceType"
@@ -77,5 +79,9 @@ func (o *clusterWorkspaceType) Validate(ctx context.Context, a admission.Attribu
 		return errors.New("organization type can only be created in root workspace")
 	}
 
+	if errs := helper.ValidateClusterWorkspaceTemplate(cwt.Spec.Template, field.NewPath("spec", "template")); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+
 	return nil
 }
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
@@ -98,6 +99,37 @@ func TestValidate(t *testing.T) {
 			clusterName: "foo:bar",
 			wantErr:     true,
 		},
+		{
+			name: "allow template with namespaces and RBAC",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspaceType{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "team",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+					Template: &tenancyv1alpha1.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+						{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"team"}}`)},
+						{Raw: []byte(`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRoleBinding","metadata":{"name":"admins"}}`)},
+					}},
+				},
+			}),
+			clusterName: "foo:bar",
+			wantErr:     false,
+		},
+		{
+			name: "deny template with other objects",
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspaceType{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "team",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+					Template: &tenancyv1alpha1.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+						{Raw: []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"token"}}`)},
+					}},
+				},
+			}),
+			clusterName: "foo:bar",
+			wantErr:     true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
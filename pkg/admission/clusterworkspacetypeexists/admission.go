@@ -48,19 +48,26 @@ func Register(plugins *admission.Plugins) {
 			return &clusterWorkspaceTypeExists{
 				Handler:          admission.NewHandler(admission.Create, admission.Update),
 				createAuthorizer: kcpadmissionhelpers.NewAdmissionAuthorizer,
+				defaultType:      "universal",
 			}, nil
 		})
 }
 
 // clusterWorkspaceTypeExists  does the following
-// - it checks existence of ClusterWorkspaceType in the same workspace,
+// - it sets the default type on creation of ClusterWorkspaces without type,
+// - it checks existence of ClusterWorkspaceType in the same workspace, except for the default type,
 // - it applies the ClusterWorkspaceType initializers to the ClusterWorkspace when it
-//   transitions to the Initializing state.
+//   transitions to the Initializing state, including the template initializer if the
+//   type has a template with objects.
 type clusterWorkspaceTypeExists struct {
 	*admission.Handler
 	typeLister        tenancyv1alpha1lister.ClusterWorkspaceTypeLister
 	kubeClusterClient *kubernetes.Cluster
 
+	// defaultType is the lower-cased type of ClusterWorkspaces created without type. It is valid
+	// without a ClusterWorkspaceType object of that name.
+	defaultType string
+
 	createAuthorizer kcpadmissionhelpers.AdmissionAuthorizerFactory
 }
 
@@ -70,15 +77,13 @@ var _ = admission.ValidationInterface(&clusterWorkspaceTypeExists{})
 var _ = admission.InitializationValidator(&clusterWorkspaceTypeExists{})
 var _ = kcpinitializers.WantsKcpInformers(&clusterWorkspaceTypeExists{})
 var _ = kcpinitializers.WantsKubeClusterClient(&clusterWorkspaceTypeExists{})
+var _ = kcpinitializers.WantsDefaultWorkspaceType(&clusterWorkspaceTypeExists{})
 
-// Admit adds type initializer on transition to initializing phase.
+// Admit sets the default type on creation, and adds type initializer on transition to initializing phase.
 func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaces") {
 		return nil
 	}
-	if a.GetOperation() != admission.Update {
-		return nil
-	}
 
 	u, ok := a.GetObject().(*unstructured.Unstructured)
 	if !ok {
@@ -95,6 +100,14 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 		return nil // only work on unstructured ClusterWorkspaces
 	}
 
+	if a.GetOperation() == admission.Create {
+		if cw.Spec.Type != "" {
+			return nil
+		}
+		cw.Spec.Type = o.defaultType
+		return kcpadmissionhelpers.EncodeIntoUnstructured(u, cw)
+	}
+
 	obj, err = kcpadmissionhelpers.NativeObject(a.GetOldObject())
 	if err != nil {
 		return fmt.Errorf("unexpected unknown old object, got %v, expected ClusterWorkspace", a.GetOldObject().GetObjectKind().GroupVersionKind().Kind)
@@ -121,10 +134,14 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 		return apierrors.NewInternalError(err)
 	}
 
-	cwt, err := o.typeLister.Get(clusters.ToClusterAwareKey(clusterName, strings.ToLower(cw.Spec.Type)))
+	typeName := strings.ToLower(cw.Spec.Type)
+	if typeName == "" {
+		typeName = o.defaultType
+	}
+	cwt, err := o.typeLister.Get(clusters.ToClusterAwareKey(clusterName, typeName))
 	if err != nil && apierrors.IsNotFound(err) {
-		if cw.Spec.Type == "Universal" {
-			return nil // Universal is always valid
+		if typeName == o.defaultType {
+			return nil // the default type is always valid
 		}
 		return admission.NewForbidden(a, fmt.Errorf("spec.type %q does not exist", cw.Spec.Type))
 	} else if err != nil {
@@ -136,7 +153,7 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 	for _, i := range cw.Status.Initializers {
 		existing.Insert(string(i))
 	}
-	for _, i := range cwt.Spec.Initializers {
+	for _, i := range typeInitializers(cwt) {
 		if !existing.Has(string(i)) {
 			cw.Status.Initializers = append(cw.Status.Initializers, i)
 		}
@@ -201,10 +218,14 @@ func (o *clusterWorkspaceTypeExists) Validate(ctx context.Context, a admission.A
 			return apierrors.NewInternalError(err)
 		}
 
-		cwt, err = o.typeLister.Get(clusters.ToClusterAwareKey(clusterName, strings.ToLower(cw.Spec.Type)))
+		typeName := strings.ToLower(cw.Spec.Type)
+		if typeName == "" {
+			typeName = o.defaultType
+		}
+		cwt, err = o.typeLister.Get(clusters.ToClusterAwareKey(clusterName, typeName))
 		if err != nil && apierrors.IsNotFound(err) {
-			if cw.Spec.Type == "Universal" {
-				return nil // Universal is always valid
+			if typeName == o.defaultType {
+				return nil // the default type is always valid
 			}
 			return admission.NewForbidden(a, fmt.Errorf("spec.type %q does not exist", cw.Spec.Type))
 		} else if err != nil {
@@ -220,7 +241,7 @@ func (o *clusterWorkspaceTypeExists) Validate(ctx context.Context, a admission.A
 		for _, initializer := range cw.Status.Initializers {
 			existing.Insert(string(initializer))
 		}
-		for _, initializer := range cwt.Spec.Initializers {
+		for _, initializer := range typeInitializers(cwt) {
 			if !existing.Has(string(initializer)) {
 				return admission.NewForbidden(a, fmt.Errorf("spec.initializers %q does not exist", initializer))
 			}
@@ -268,3 +289,17 @@ func (o *clusterWorkspaceTypeExists) SetKcpInformers(informers kcpinformers.Shar
 func (o *clusterWorkspaceTypeExists) SetKubeClusterClient(kubeClusterClient *kubernetes.Cluster) {
 	o.kubeClusterClient = kubeClusterClient
 }
+
+func (o *clusterWorkspaceTypeExists) SetDefaultWorkspaceType(defaultType string) {
+	o.defaultType = defaultType
+}
+
+// typeInitializers returns the initializers of a ClusterWorkspaceType, followed by the template initializer
+// if the type has a template with objects to create.
+func typeInitializers(cwt *tenancyv1alpha1.ClusterWorkspaceType) []tenancyv1alpha1.ClusterWorkspaceInitializer {
+	if cwt.Spec.Template == nil || len(cwt.Spec.Template.Objects) == 0 {
+		return cwt.Spec.Initializers
+	}
+	initializers := append([]tenancyv1alpha1.ClusterWorkspaceInitializer{}, cwt.Spec.Initializers...)
+	return append(initializers, tenancyv1alpha1.ClusterWorkspaceTemplateInitializer)
+}
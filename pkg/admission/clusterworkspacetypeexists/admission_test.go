@@ -22,8 +22,11 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/admission"
@@ -254,8 +257,9 @@ func TestAdmit(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			o := &clusterWorkspaceTypeExists{
-				Handler:    admission.NewHandler(admission.Create, admission.Update),
-				typeLister: fakeClusterWorkspaceTypeLister(tt.types),
+				Handler:     admission.NewHandler(admission.Create, admission.Update),
+				typeLister:  fakeClusterWorkspaceTypeLister(tt.types),
+				defaultType: "universal",
 			}
 			ctx := request.WithCluster(context.Background(), request.Cluster{Name: "root:org"})
 			if err := o.Admit(ctx, tt.a, nil); (err != nil) != tt.wantErr {
@@ -268,6 +272,97 @@ func TestAdmit(t *testing.T) {
 	}
 }
 
+func TestAdmitTemplateInitializer(t *testing.T) {
+	toUnstructured := func(ws *tenancyv1alpha1.ClusterWorkspace) *unstructured.Unstructured {
+		raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ws)
+		require.NoError(t, err)
+		u := &unstructured.Unstructured{Object: raw}
+		u.SetGroupVersionKind(tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"))
+		return u
+	}
+	ws := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+			Type: "Foo",
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+		},
+	}
+	old := toUnstructured(ws)
+	ws.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseInitializing
+	u := toUnstructured(ws)
+
+	o := &clusterWorkspaceTypeExists{
+		Handler: admission.NewHandler(admission.Create, admission.Update),
+		typeLister: fakeClusterWorkspaceTypeLister([]*tenancyv1alpha1.ClusterWorkspaceType{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "root:org#$#foo",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+					Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a"},
+					Template: &tenancyv1alpha1.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+						{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"team"}}`)},
+					}},
+				},
+			},
+		}),
+		defaultType: "universal",
+	}
+	ctx := request.WithCluster(context.Background(), request.Cluster{Name: "root:org"})
+	a := admission.NewAttributesRecord(u, old, tenancyv1alpha1.Kind("ClusterWorkspace").WithVersion("v1alpha1"), "", "test",
+		tenancyv1alpha1.Resource("clusterworkspaces").WithVersion("v1alpha1"), "", admission.Update, &metav1.UpdateOptions{}, false, &user.DefaultInfo{})
+	require.NoError(t, o.Admit(ctx, a, nil))
+
+	initializers, _, err := unstructured.NestedStringSlice(u.Object, "status", "initializers")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", string(tenancyv1alpha1.ClusterWorkspaceTemplateInitializer)}, initializers)
+}
+
+func TestAdmitDefaultType(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		typ         string
+		defaultType string
+		wantType    string
+	}{
+		{name: "empty type defaulted", defaultType: "universal", wantType: "universal"},
+		{name: "empty type defaulted to configured type", defaultType: "team", wantType: "team"},
+		{name: "explicit type kept", typ: "Organization", defaultType: "universal", wantType: "Organization"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tc.typ,
+				},
+			})
+			require.NoError(t, err)
+			u := &unstructured.Unstructured{Object: raw}
+			u.SetGroupVersionKind(tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"))
+
+			o := &clusterWorkspaceTypeExists{
+				Handler:     admission.NewHandler(admission.Create, admission.Update),
+				typeLister:  fakeClusterWorkspaceTypeLister(nil),
+				defaultType: tc.defaultType,
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: "root:org"})
+			a := admission.NewAttributesRecord(u, nil, tenancyv1alpha1.Kind("ClusterWorkspace").WithVersion("v1alpha1"), "", "test",
+				tenancyv1alpha1.Resource("clusterworkspaces").WithVersion("v1alpha1"), "", admission.Create, &metav1.CreateOptions{}, false, &user.DefaultInfo{})
+			require.NoError(t, o.Admit(ctx, a, nil))
+
+			typ, _, err := unstructured.NestedString(u.Object, "spec", "type")
+			require.NoError(t, err)
+			require.Equal(t, tc.wantType, typ)
+		})
+	}
+}
+
 func TestValidate(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -276,6 +371,7 @@ func TestValidate(t *testing.T) {
 
 		authzDecision authorizer.Decision
 		authzError    error
+		defaultType   string
 
 		wantErr bool
 	}{
@@ -411,6 +507,42 @@ func TestValidate(t *testing.T) {
 			}),
 			authzDecision: authorizer.DecisionAllow,
 		},
+		{
+			name: "empty type is the default type",
+			attr: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+			}),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "configured default type always exists implicitly",
+			attr: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Team",
+				},
+			}),
+			defaultType:   "team",
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name: "Universal fails if not the default type and missing",
+			attr: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Universal",
+				},
+			}),
+			defaultType:   "team",
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
 		{
 			name: "validates initializers on phase transition",
 			types: []*tenancyv1alpha1.ClusterWorkspaceType{
@@ -448,6 +580,46 @@ func TestValidate(t *testing.T) {
 				}),
 			wantErr: true,
 		},
+		{
+			name: "validates the template initializer on phase transition",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "root:org#$#foo",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+						Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a"},
+						Template: &tenancyv1alpha1.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+							{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"team"}}`)},
+						}},
+					},
+				},
+			},
+			attr: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a"}, // template initializer missing
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Type: "Foo",
+					},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+					},
+				}),
+			wantErr: true,
+		},
 		{
 			name: "passes with all initializers or more on phase transition",
 			types: []*tenancyv1alpha1.ClusterWorkspaceType{
@@ -511,9 +683,13 @@ func TestValidate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.defaultType == "" {
+				tt.defaultType = "universal"
+			}
 			o := &clusterWorkspaceTypeExists{
-				Handler:    admission.NewHandler(admission.Create, admission.Update),
-				typeLister: fakeClusterWorkspaceTypeLister(tt.types),
+				Handler:     admission.NewHandler(admission.Create, admission.Update),
+				typeLister:  fakeClusterWorkspaceTypeLister(tt.types),
+				defaultType: tt.defaultType,
 				createAuthorizer: func(clusterName string, client *kubernetes.Cluster) (authorizer.Authorizer, error) {
 					return &fakeAuthorizer{
 						tt.authzDecision,
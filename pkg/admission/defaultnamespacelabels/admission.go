@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultnamespacelabels
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	tenancyv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+// Add the labels of the default namespace labels annotation of a ClusterWorkspace to the namespaces created
+// inside of it.
+
+const (
+	PluginName = "tenancy.kcp.dev/DefaultNamespaceLabels"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &defaultNamespaceLabels{
+				Handler: admission.NewHandler(admission.Create),
+			}, nil
+		})
+}
+
+type defaultNamespaceLabels struct {
+	*admission.Handler
+	workspaceLister tenancyv1alpha1lister.ClusterWorkspaceLister
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.MutationInterface(&defaultNamespaceLabels{})
+var _ = admission.InitializationValidator(&defaultNamespaceLabels{})
+var _ = kcpinitializers.WantsKcpInformers(&defaultNamespaceLabels{})
+
+// Admit adds the default namespace labels of the ClusterWorkspace in the parent workspace to a namespace
+// created inside of the workspace. Labels set on the namespace are kept.
+func (o *defaultNamespaceLabels) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if a.GetResource().GroupResource() != corev1.Resource("namespaces") || a.GetSubresource() != "" {
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if strings.HasPrefix(clusterName, helper.LocalSystemClusterPrefix) {
+		return nil
+	}
+	parent, name, err := helper.ParseLogicalClusterName(clusterName)
+	if err != nil || parent == "" {
+		// nolint: nilerr
+		return nil // root and system workspaces have no ClusterWorkspace
+	}
+	parentClusterName, err := helper.ParentClusterName(clusterName)
+	if err != nil {
+		// nolint: nilerr
+		return nil
+	}
+
+	if !o.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+
+	workspace, err := o.workspaceLister.Get(clusters.ToClusterAwareKey(parentClusterName, name))
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	value, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation]
+	if !found {
+		return nil
+	}
+	defaults, err := helper.ParseDefaultNamespaceLabels(value)
+	if err != nil {
+		// the annotation is validated on the ClusterWorkspace, this is not the fault of the namespace creator
+		klog.Errorf("Ignoring invalid %s annotation of workspace %s: %v", tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation, clusterName, err)
+		return nil
+	}
+
+	namespace, err := meta.Accessor(a.GetObject())
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	labels := namespace.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range defaults {
+		if _, found := labels[key]; !found {
+			labels[key] = value
+		}
+	}
+	namespace.SetLabels(labels)
+	return nil
+}
+
+func (o *defaultNamespaceLabels) ValidateInitialization() error {
+	if o.workspaceLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspace lister")
+	}
+	return nil
+}
+
+func (o *defaultNamespaceLabels) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	o.SetReadyFunc(informers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().HasSynced)
+	o.workspaceLister = informers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+}
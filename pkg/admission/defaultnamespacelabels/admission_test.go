@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultnamespacelabels
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func createAttr(obj runtime.Object, resource schema.GroupVersionResource, kind schema.GroupVersionKind) admission.Attributes {
+	return admission.NewAttributesRecord(
+		obj,
+		nil,
+		kind,
+		"",
+		"test",
+		resource,
+		"",
+		admission.Create,
+		nil,
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func namespaceAttr(namespace *corev1.Namespace) admission.Attributes {
+	return createAttr(namespace, corev1.SchemeGroupVersion.WithResource("namespaces"), corev1.SchemeGroupVersion.WithKind("Namespace"))
+}
+
+func TestAdmit(t *testing.T) {
+	labeled := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "labeled",
+			ClusterName: "root:org",
+			Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team=payments,policy=strict"},
+		},
+	}
+	invalid := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "invalid",
+			ClusterName: "root:org",
+			Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team"},
+		},
+	}
+	plain := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "plain", ClusterName: "root:org"},
+	}
+
+	tests := []struct {
+		name        string
+		clusterName string
+		a           admission.Attributes
+		wantLabels  map[string]string
+	}{
+		{
+			name:        "adds the default labels to a new namespace",
+			clusterName: "org:labeled",
+			a:           namespaceAttr(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}),
+			wantLabels:  map[string]string{"team": "payments", "policy": "strict"},
+		},
+		{
+			name:        "keeps the labels set on the namespace",
+			clusterName: "org:labeled",
+			a:           namespaceAttr(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{"team": "billing", "app": "shop"}}}),
+			wantLabels:  map[string]string{"team": "billing", "policy": "strict", "app": "shop"},
+		},
+		{
+			name:        "ignores an invalid annotation",
+			clusterName: "org:invalid",
+			a:           namespaceAttr(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}),
+		},
+		{
+			name:        "ignores workspaces without annotation",
+			clusterName: "org:plain",
+			a:           namespaceAttr(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}),
+		},
+		{
+			name:        "ignores unknown workspaces",
+			clusterName: "org:unknown",
+			a:           namespaceAttr(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}),
+		},
+		{
+			name:        "ignores the root workspace",
+			clusterName: "root",
+			a:           namespaceAttr(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &defaultNamespaceLabels{
+				Handler:         admission.NewHandler(admission.Create),
+				workspaceLister: fakeClusterWorkspaceLister{labeled, invalid, plain},
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: tt.clusterName})
+			require.NoError(t, o.Admit(ctx, tt.a, nil))
+			require.Equal(t, tt.wantLabels, tt.a.GetObject().(*corev1.Namespace).Labels)
+		})
+	}
+
+	t.Run("ignores other resources", func(t *testing.T) {
+		o := &defaultNamespaceLabels{
+			Handler:         admission.NewHandler(admission.Create),
+			workspaceLister: fakeClusterWorkspaceLister{labeled},
+		}
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+		a := createAttr(configMap, corev1.SchemeGroupVersion.WithResource("configmaps"), corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+		ctx := request.WithCluster(context.Background(), request.Cluster{Name: "org:labeled"})
+		require.NoError(t, o.Admit(ctx, a, nil))
+		require.Empty(t, configMap.Labels)
+	})
+}
+
+type fakeClusterWorkspaceLister []*tenancyv1alpha1.ClusterWorkspace
+
+func (l fakeClusterWorkspaceLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.ClusterWorkspace, err error) {
+	return l.ListWithContext(context.Background(), selector)
+}
+
+func (l fakeClusterWorkspaceLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyv1alpha1.ClusterWorkspace, err error) {
+	return l, nil
+}
+
+func (l fakeClusterWorkspaceLister) Get(name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	return l.GetWithContext(context.Background(), name)
+}
+
+func (l fakeClusterWorkspaceLister) GetWithContext(ctx context.Context, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	for _, ws := range l {
+		if clusters.ToClusterAwareKey(ws.ClusterName, ws.Name) == name {
+			return ws, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frozenworkspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	tenancyv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+// Reject mutating requests inside of workspaces whose ClusterWorkspace has the frozen annotation.
+
+const (
+	PluginName = "tenancy.kcp.dev/FrozenWorkspace"
+)
+
+// reviewGroups are the API groups of the access and token reviews. Creating them does not mutate
+// anything, so they are allowed in frozen workspaces.
+var reviewGroups = sets.NewString("authorization.k8s.io", "authentication.k8s.io")
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &frozenWorkspace{
+				Handler: admission.NewHandler(admission.Create, admission.Update, admission.Delete, admission.Connect),
+			}, nil
+		})
+}
+
+type frozenWorkspace struct {
+	*admission.Handler
+	workspaceLister tenancyv1alpha1lister.ClusterWorkspaceLister
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&frozenWorkspace{})
+var _ = admission.InitializationValidator(&frozenWorkspace{})
+var _ = kcpinitializers.WantsKcpInformers(&frozenWorkspace{})
+
+// Validate rejects mutating requests inside of a workspace whose ClusterWorkspace in the parent workspace
+// is frozen. The root workspace and system workspaces cannot be frozen.
+func (o *frozenWorkspace) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if reviewGroups.Has(a.GetResource().Group) {
+		return nil
+	}
+
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if strings.HasPrefix(clusterName, helper.LocalSystemClusterPrefix) {
+		return nil
+	}
+	parent, name, err := helper.ParseLogicalClusterName(clusterName)
+	if err != nil || parent == "" {
+		// nolint: nilerr
+		return nil // root and system workspaces have no ClusterWorkspace
+	}
+	parentClusterName, err := helper.ParentClusterName(clusterName)
+	if err != nil {
+		// nolint: nilerr
+		return nil
+	}
+
+	if !o.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+
+	workspace, err := o.workspaceLister.Get(clusters.ToClusterAwareKey(parentClusterName, name))
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if reason, frozen := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation]; frozen {
+		return admission.NewForbidden(a, fmt.Errorf("workspace %s is frozen (%s), only reads are allowed", clusterName, reason))
+	}
+	return nil
+}
+
+func (o *frozenWorkspace) ValidateInitialization() error {
+	if o.workspaceLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspace lister")
+	}
+	return nil
+}
+
+func (o *frozenWorkspace) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	o.SetReadyFunc(informers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().HasSynced)
+	o.workspaceLister = informers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package frozenworkspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func attr(obj runtime.Object, resource schema.GroupVersionResource, kind schema.GroupVersionKind, op admission.Operation) admission.Attributes {
+	return admission.NewAttributesRecord(
+		obj,
+		nil,
+		kind,
+		"default",
+		"test",
+		resource,
+		"",
+		op,
+		nil,
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func configMapAttr(op admission.Operation) admission.Attributes {
+	return attr(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		corev1.SchemeGroupVersion.WithResource("configmaps"), corev1.SchemeGroupVersion.WithKind("ConfigMap"), op)
+}
+
+func TestValidate(t *testing.T) {
+	frozen := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "frozen",
+			ClusterName: "root:org",
+			Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation: "audit"},
+		},
+	}
+	thawed := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "thawed", ClusterName: "root:org"},
+	}
+	frozenOrg := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "frozenorg",
+			ClusterName: "root",
+			Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation: "true"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		clusterName string
+		a           admission.Attributes
+		wantErr     bool
+	}{
+		{
+			name:        "rejects creations in a frozen workspace",
+			clusterName: "org:frozen",
+			a:           configMapAttr(admission.Create),
+			wantErr:     true,
+		},
+		{
+			name:        "rejects updates in a frozen workspace",
+			clusterName: "org:frozen",
+			a:           configMapAttr(admission.Update),
+			wantErr:     true,
+		},
+		{
+			name:        "rejects deletions in a frozen workspace",
+			clusterName: "org:frozen",
+			a:           configMapAttr(admission.Delete),
+			wantErr:     true,
+		},
+		{
+			name:        "rejects creations in a frozen organization",
+			clusterName: "root:frozenorg",
+			a:           configMapAttr(admission.Create),
+			wantErr:     true,
+		},
+		{
+			name:        "accepts access reviews in a frozen workspace",
+			clusterName: "org:frozen",
+			a: attr(&authorizationv1.SelfSubjectAccessReview{},
+				authorizationv1.SchemeGroupVersion.WithResource("selfsubjectaccessreviews"), authorizationv1.SchemeGroupVersion.WithKind("SelfSubjectAccessReview"), admission.Create),
+		},
+		{
+			name:        "accepts creations in a workspace that is not frozen",
+			clusterName: "org:thawed",
+			a:           configMapAttr(admission.Create),
+		},
+		{
+			name:        "accepts creations in an unknown workspace",
+			clusterName: "org:unknown",
+			a:           configMapAttr(admission.Create),
+		},
+		{
+			name:        "accepts creations in the root workspace",
+			clusterName: "root",
+			a:           configMapAttr(admission.Create),
+		},
+		{
+			name:        "accepts creations in system workspaces",
+			clusterName: "system:admin",
+			a:           configMapAttr(admission.Create),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &frozenWorkspace{
+				Handler:         admission.NewHandler(admission.Create, admission.Update, admission.Delete, admission.Connect),
+				workspaceLister: fakeClusterWorkspaceLister{frozen, thawed, frozenOrg},
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: tt.clusterName})
+			err := o.Validate(ctx, tt.a, nil)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, apierrors.IsForbidden(err), "expected a forbidden error, got %v", err)
+		})
+	}
+}
+
+type fakeClusterWorkspaceLister []*tenancyv1alpha1.ClusterWorkspace
+
+func (l fakeClusterWorkspaceLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.ClusterWorkspace, err error) {
+	return l.ListWithContext(context.Background(), selector)
+}
+
+func (l fakeClusterWorkspaceLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyv1alpha1.ClusterWorkspace, err error) {
+	return l, nil
+}
+
+func (l fakeClusterWorkspaceLister) Get(name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	return l.GetWithContext(context.Background(), name)
+}
+
+func (l fakeClusterWorkspaceLister) GetWithContext(ctx context.Context, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	for _, ws := range l {
+		if clusters.ToClusterAwareKey(ws.ClusterName, ws.Name) == name {
+			return ws, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+}
@@ -83,3 +83,23 @@ func (i *kcpClusterClientInitializer) Initialize(plugin admission.Interface) {
 		wants.SetKcpClusterClient(i.kcpClusterClient)
 	}
 }
+
+// NewDefaultWorkspaceTypeInitializer returns an admission plugin initializer that injects
+// the default ClusterWorkspaceType name into admission plugins.
+func NewDefaultWorkspaceTypeInitializer(
+	defaultType string,
+) *defaultWorkspaceTypeInitializer {
+	return &defaultWorkspaceTypeInitializer{
+		defaultType: defaultType,
+	}
+}
+
+type defaultWorkspaceTypeInitializer struct {
+	defaultType string
+}
+
+func (i *defaultWorkspaceTypeInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsDefaultWorkspaceType); ok {
+		wants.SetDefaultWorkspaceType(i.defaultType)
+	}
+}
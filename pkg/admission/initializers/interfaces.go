@@ -40,3 +40,9 @@ type WantsKubeClusterClient interface {
 type WantsKcpClusterClient interface {
 	SetKcpClusterClient(kubeClusterClient *kcpclientset.Cluster)
 }
+
+// WantsDefaultWorkspaceType interface should be implemented by admission plugins
+// that want to have the default ClusterWorkspaceType name injected.
+type WantsDefaultWorkspaceType interface {
+	SetDefaultWorkspaceType(defaultType string)
+}
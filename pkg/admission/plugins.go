@@ -43,6 +43,9 @@ import (
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspace"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacetype"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacetypeexists"
+	"github.com/kcp-dev/kcp/pkg/admission/defaultnamespacelabels"
+	"github.com/kcp-dev/kcp/pkg/admission/frozenworkspace"
+	"github.com/kcp-dev/kcp/pkg/admission/workspaceshard"
 )
 
 // AllOrderedPlugins is the list of all the plugins in order.
@@ -51,6 +54,9 @@ var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	clusterworkspace.PluginName,
 	clusterworkspacetype.PluginName,
 	clusterworkspacetypeexists.PluginName,
+	defaultnamespacelabels.PluginName,
+	frozenworkspace.PluginName,
+	workspaceshard.PluginName,
 )
 
 func beforeWebhooks(recommended []string, plugins ...string) []string {
@@ -72,6 +78,9 @@ func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	clusterworkspacetype.Register(plugins)
 	clusterworkspacetypeexists.Register(plugins)
 	apiresourceschema.Register(plugins)
+	defaultnamespacelabels.Register(plugins)
+	frozenworkspace.Register(plugins)
+	workspaceshard.Register(plugins)
 }
 
 var defaultOnPluginsInKcp = sets.NewString(
@@ -88,6 +97,9 @@ var defaultOnPluginsInKcp = sets.NewString(
 	clusterworkspacetype.PluginName,
 	clusterworkspacetypeexists.PluginName,
 	apiresourceschema.PluginName,
+	defaultnamespacelabels.PluginName,
+	frozenworkspace.PluginName,
+	workspaceshard.PluginName,
 )
 
 // defaultOnKubePluginsInKube is a copy of kubeapiserveroptions.defaultOnKubePlugins.
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceshard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/admission"
+	"sigs.k8s.io/yaml"
+
+	kcpadmissionhelpers "github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// Validate WorkspaceShard creation and updates for
+// - status.connectionInfo.host being a URL with a http or https scheme and a host
+// - optionally, status.connectionInfo.host being reachable when set or changed.
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceShard"
+
+	defaultReachabilityTimeout = 5 * time.Second
+)
+
+// Configuration is the configuration of the plugin, read from the admission control config file.
+type Configuration struct {
+	// CheckReachability enables dialing the shard address when it is set or changed. It is disabled
+	// by default, such that air-gapped setups where kcp cannot reach its shards keep working.
+	CheckReachability bool `json:"checkReachability,omitempty"`
+	// ReachabilityTimeout bounds the time spent dialing the shard address. Defaults to 5s.
+	ReachabilityTimeout metav1.Duration `json:"reachabilityTimeout,omitempty"`
+}
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(config io.Reader) (admission.Interface, error) {
+			cfg, err := loadConfiguration(config)
+			if err != nil {
+				return nil, err
+			}
+			dialer := &net.Dialer{}
+			return &workspaceShard{
+				Handler:             admission.NewHandler(admission.Create, admission.Update),
+				checkReachability:   cfg.CheckReachability,
+				reachabilityTimeout: cfg.ReachabilityTimeout.Duration,
+				dial:                dialer.DialContext,
+			}, nil
+		})
+}
+
+func loadConfiguration(config io.Reader) (*Configuration, error) {
+	cfg := &Configuration{}
+	if config != nil {
+		data, err := ioutil.ReadAll(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s admission configuration: %w", PluginName, err)
+		}
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to decode %s admission configuration: %w", PluginName, err)
+		}
+	}
+	if cfg.ReachabilityTimeout.Duration < 0 {
+		return nil, fmt.Errorf("%s admission configuration: reachabilityTimeout must not be negative", PluginName)
+	}
+	if cfg.ReachabilityTimeout.Duration == 0 {
+		cfg.ReachabilityTimeout.Duration = defaultReachabilityTimeout
+	}
+	return cfg, nil
+}
+
+type workspaceShard struct {
+	*admission.Handler
+
+	checkReachability   bool
+	reachabilityTimeout time.Duration
+	dial                func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&workspaceShard{})
+
+// Validate ensures that
+// - the connection info host is a URL with a http or https scheme and a host
+// - the connection info host is reachable when it is set or changed, if enabled.
+func (o *workspaceShard) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("workspaceshards") {
+		return nil
+	}
+
+	obj, err := kcpadmissionhelpers.NativeObject(a.GetObject())
+	if err != nil {
+		// nolint: nilerr
+		return nil // only work on unstructured WorkspaceShards
+	}
+	shard, ok := obj.(*tenancyv1alpha1.WorkspaceShard)
+	if !ok {
+		// nolint: nilerr
+		return nil // only work on unstructured WorkspaceShards
+	}
+	if shard.Status.ConnectionInfo == nil {
+		return nil
+	}
+
+	hostPath := field.NewPath("status", "connectionInfo", "host")
+	host := shard.Status.ConnectionInfo.Host
+	address, errs := validateHost(host, hostPath)
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(tenancyv1alpha1.Kind("WorkspaceShard"), shard.Name, errs)
+	}
+
+	if !o.checkReachability {
+		return nil
+	}
+	if a.GetOperation() == admission.Update {
+		obj, err = kcpadmissionhelpers.NativeObject(a.GetOldObject())
+		if err != nil {
+			return fmt.Errorf("unexpected unknown old object, got %v, expected WorkspaceShard", a.GetOldObject().GetObjectKind().GroupVersionKind().Kind)
+		}
+		old, ok := obj.(*tenancyv1alpha1.WorkspaceShard)
+		if !ok {
+			return fmt.Errorf("unexpected unknown old object, got %v, expected WorkspaceShard", obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+		if old.Status.ConnectionInfo != nil && old.Status.ConnectionInfo.Host == host {
+			// only check new addresses, not every status update of the shard
+			return nil
+		}
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, o.reachabilityTimeout)
+	defer cancel()
+	conn, err := o.dial(dialCtx, "tcp", address)
+	if err != nil {
+		return apierrors.NewInvalid(tenancyv1alpha1.Kind("WorkspaceShard"), shard.Name, field.ErrorList{
+			field.Invalid(hostPath, host, fmt.Sprintf("shard is not reachable at %s: %v", address, err)),
+		})
+	}
+	conn.Close()
+	return nil
+}
+
+// validateHost checks that host is a URL with a http or https scheme and a host, and returns the
+// host:port address to dial, defaulting the port from the scheme.
+func validateHost(host string, fldPath *field.Path) (string, field.ErrorList) {
+	if host == "" {
+		return "", field.ErrorList{field.Required(fldPath, "the URL of the shard, e.g. https://shard.example.com:6443, is required")}
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", field.ErrorList{field.Invalid(fldPath, host, fmt.Sprintf("must be a URL, e.g. https://shard.example.com:6443: %v", err))}
+	}
+	var errs field.ErrorList
+	if u.Scheme != "http" && u.Scheme != "https" {
+		errs = append(errs, field.Invalid(fldPath, host, "must be a URL with a http or https scheme, e.g. https://shard.example.com:6443"))
+	}
+	if u.Host == "" || u.Hostname() == "" {
+		errs = append(errs, field.Invalid(fldPath, host, "must be a URL with a host, e.g. https://shard.example.com:6443"))
+	}
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceshard
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func createAttr(shard *tenancyv1alpha1.WorkspaceShard) admission.Attributes {
+	return admission.NewAttributesRecord(
+		shard,
+		nil,
+		tenancyv1alpha1.Kind("WorkspaceShard").WithVersion("v1alpha1"),
+		"",
+		shard.Name,
+		tenancyv1alpha1.Resource("workspaceshards").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func updateAttr(shard, old *tenancyv1alpha1.WorkspaceShard) admission.Attributes {
+	return admission.NewAttributesRecord(
+		shard,
+		old,
+		tenancyv1alpha1.Kind("WorkspaceShard").WithVersion("v1alpha1"),
+		"",
+		shard.Name,
+		tenancyv1alpha1.Resource("workspaceshards").WithVersion("v1alpha1"),
+		"status",
+		admission.Update,
+		&metav1.UpdateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func newShard(host string) *tenancyv1alpha1.WorkspaceShard {
+	return &tenancyv1alpha1.WorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{Name: "boston"},
+		Status: tenancyv1alpha1.WorkspaceShardStatus{
+			ConnectionInfo: &tenancyv1alpha1.ConnectionInfo{Host: host},
+		},
+	}
+}
+
+// unreachableAddress returns the address of a listener that has been closed again.
+func unreachableAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+	return listener.Addr().String()
+}
+
+func TestValidate(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	reachable := "https://" + listener.Addr().String()
+	unreachable := "https://" + unreachableAddress(t)
+
+	tests := []struct {
+		name              string
+		a                 admission.Attributes
+		checkReachability bool
+		wantErr           string
+	}{
+		{
+			name: "accepts a URL",
+			a:    createAttr(newShard("https://boston.kcp.dev:6443")),
+		},
+		{
+			name: "accepts a URL with a path",
+			a:    createAttr(newShard("http://boston.kcp.dev/shards/boston")),
+		},
+		{
+			name: "accepts shards without connection info",
+			a:    createAttr(&tenancyv1alpha1.WorkspaceShard{ObjectMeta: metav1.ObjectMeta{Name: "boston"}}),
+		},
+		{
+			name:    "rejects a scheme-less address",
+			a:       createAttr(newShard("boston.kcp.dev:6443")),
+			wantErr: "must be a URL with a http or https scheme",
+		},
+		{
+			name:    "rejects a scheme-less host",
+			a:       createAttr(newShard("boston.kcp.dev")),
+			wantErr: "must be a URL with a http or https scheme",
+		},
+		{
+			name:    "rejects a URL without host",
+			a:       createAttr(newShard("https:///clusters")),
+			wantErr: "must be a URL with a host",
+		},
+		{
+			name:    "rejects an empty host",
+			a:       createAttr(newShard("")),
+			wantErr: "Required value",
+		},
+		{
+			name:    "rejects a scheme-less address on update",
+			a:       updateAttr(newShard("boston.kcp.dev:6443"), newShard("https://boston.kcp.dev:6443")),
+			wantErr: "must be a URL with a http or https scheme",
+		},
+		{
+			name:              "accepts a reachable address",
+			a:                 createAttr(newShard(reachable)),
+			checkReachability: true,
+		},
+		{
+			name:              "rejects an unreachable address",
+			a:                 createAttr(newShard(unreachable)),
+			checkReachability: true,
+			wantErr:           "shard is not reachable",
+		},
+		{
+			name:              "rejects changing to an unreachable address",
+			a:                 updateAttr(newShard(unreachable), newShard(reachable)),
+			checkReachability: true,
+			wantErr:           "shard is not reachable",
+		},
+		{
+			name:              "does not check an unchanged address",
+			a:                 updateAttr(newShard(unreachable), newShard(unreachable)),
+			checkReachability: true,
+		},
+		{
+			name: "accepts an unreachable address without reachability check",
+			a:    createAttr(newShard(unreachable)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := &net.Dialer{}
+			o := &workspaceShard{
+				Handler:             admission.NewHandler(admission.Create, admission.Update),
+				checkReachability:   tt.checkReachability,
+				reachabilityTimeout: time.Second,
+				dial:                dialer.DialContext,
+			}
+			err := o.Validate(context.Background(), tt.a, nil)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, apierrors.IsInvalid(err), "expected an invalid error, got %v", err)
+			require.Contains(t, err.Error(), "status.connectionInfo.host")
+			require.True(t, strings.Contains(err.Error(), tt.wantErr), "expected error to contain %q, got %v", tt.wantErr, err)
+		})
+	}
+}
+
+func TestLoadConfiguration(t *testing.T) {
+	cfg, err := loadConfiguration(nil)
+	require.NoError(t, err)
+	require.False(t, cfg.CheckReachability, "reachability checks must be disabled by default")
+	require.Equal(t, defaultReachabilityTimeout, cfg.ReachabilityTimeout.Duration)
+
+	cfg, err = loadConfiguration(strings.NewReader("checkReachability: true\nreachabilityTimeout: 2s\n"))
+	require.NoError(t, err)
+	require.True(t, cfg.CheckReachability)
+	require.Equal(t, 2*time.Second, cfg.ReachabilityTimeout.Duration)
+
+	_, err = loadConfiguration(strings.NewReader("checkReachabilty: true\n"))
+	require.Error(t, err, "expected unknown fields to be rejected")
+}
@@ -17,13 +17,55 @@ limitations under the License.
 package projection
 
 import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 )
 
+// ProjectClusterWorkspaceToWorkspace projects a ClusterWorkspace onto the Workspace users see,
+// see v1beta1.Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace.
+//
+// The managed fields of the Workspace are the ones recorded in the ClusterWorkspaceManagedFieldsAnnotation,
+// which is not projected.
 func ProjectClusterWorkspaceToWorkspace(from *v1alpha1.ClusterWorkspace, to *v1beta1.Workspace) {
-	to.ObjectMeta = from.ObjectMeta
-	to.Spec.Type = from.Spec.Type
-	to.Status.URL = from.Status.BaseURL
-	to.Status.Phase = from.Status.Phase
+	// the conversion cannot fail
+	_ = v1beta1.Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace(from, to, nil)
+
+	to.ManagedFields = nil
+	encoded, found := from.Annotations[v1alpha1.ClusterWorkspaceManagedFieldsAnnotation]
+	if !found {
+		return
+	}
+	to.Annotations = make(map[string]string, len(from.Annotations)-1)
+	for k, v := range from.Annotations {
+		if k != v1alpha1.ClusterWorkspaceManagedFieldsAnnotation {
+			to.Annotations[k] = v
+		}
+	}
+	var managedFields []metav1.ManagedFieldsEntry
+	if err := json.Unmarshal([]byte(encoded), &managedFields); err == nil {
+		// the field managers start over from the next update otherwise
+		to.ManagedFields = managedFields
+	}
+}
+
+// ProjectWorkspaceManagedFields records the managed fields of a Workspace in the
+// ClusterWorkspaceManagedFieldsAnnotation of its ClusterWorkspace, which must have its own annotations map.
+func ProjectWorkspaceManagedFields(from *v1beta1.Workspace, to *v1alpha1.ClusterWorkspace) error {
+	if len(from.ManagedFields) == 0 {
+		delete(to.Annotations, v1alpha1.ClusterWorkspaceManagedFieldsAnnotation)
+		return nil
+	}
+	encoded, err := json.Marshal(from.ManagedFields)
+	if err != nil {
+		return err
+	}
+	if to.Annotations == nil {
+		to.Annotations = map[string]string{}
+	}
+	to.Annotations[v1alpha1.ClusterWorkspaceManagedFieldsAnnotation] = string(encoded)
+	return nil
 }
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	tenancyapi "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// ValidateBootstrapBundle checks the bootstrap bundle annotations of a workspace, found under the given annotations
+// path: the URL must be valid, and comes with a valid checksum.
+func ValidateBootstrapBundle(annotations map[string]string, annotationsPath *field.Path) field.ErrorList {
+	bundleURL, found := annotations[tenancyapi.ClusterWorkspaceBootstrapBundleURLAnnotation]
+	if !found {
+		return nil
+	}
+	var errs field.ErrorList
+	if _, err := ParseBootstrapBundleURL(bundleURL); err != nil {
+		errs = append(errs, field.Invalid(annotationsPath.Key(tenancyapi.ClusterWorkspaceBootstrapBundleURLAnnotation), bundleURL, err.Error()))
+	}
+	if sum, found := annotations[tenancyapi.ClusterWorkspaceBootstrapBundleSHA256Annotation]; !found {
+		errs = append(errs, field.Required(annotationsPath.Key(tenancyapi.ClusterWorkspaceBootstrapBundleSHA256Annotation), "the checksum of the bootstrap bundle is required"))
+	} else if _, err := ParseBootstrapBundleSHA256(sum); err != nil {
+		errs = append(errs, field.Invalid(annotationsPath.Key(tenancyapi.ClusterWorkspaceBootstrapBundleSHA256Annotation), sum, err.Error()))
+	}
+	return errs
+}
+
+// ParseBootstrapBundleURL parses the value of the bootstrap bundle URL annotation, an absolute http or https URL.
+func ParseBootstrapBundleURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("expected http or https scheme, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("expected a host in %q", value)
+	}
+	return u, nil
+}
+
+// ParseBootstrapBundleSHA256 parses the value of the bootstrap bundle checksum annotation, a hex encoded SHA-256 sum.
+func ParseBootstrapBundleSHA256(value string) ([]byte, error) {
+	sum, err := hex.DecodeString(strings.ToLower(value))
+	if err != nil {
+		return nil, fmt.Errorf("expected a hex encoded SHA-256 checksum: %w", err)
+	}
+	if len(sum) != 32 {
+		return nil, fmt.Errorf("expected a SHA-256 checksum of 64 hex characters, got %d", len(value))
+	}
+	return sum, nil
+}
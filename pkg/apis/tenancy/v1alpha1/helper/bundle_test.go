@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	tenancyapi "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestParseBootstrapBundleURL(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		value       string
+		expectedErr string
+	}{
+		{name: "https", value: "https://bundles.example.com/team.tar.gz"},
+		{name: "http with port", value: "http://127.0.0.1:8080/team.tar"},
+		{name: "no scheme", value: "bundles.example.com/team.tar.gz", expectedErr: `expected http or https scheme, got ""`},
+		{name: "file", value: "file:///etc/passwd", expectedErr: `expected http or https scheme, got "file"`},
+		{name: "no host", value: "https:///team.tar.gz", expectedErr: `expected a host in "https:///team.tar.gz"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := ParseBootstrapBundleURL(tc.value)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.value, u.String())
+		})
+	}
+}
+
+func TestParseBootstrapBundleSHA256(t *testing.T) {
+	sum, err := ParseBootstrapBundleSHA256(strings.Repeat("aB", 32))
+	require.NoError(t, err)
+	require.Equal(t, []byte(strings.Repeat("\xab", 32)), sum)
+
+	_, err = ParseBootstrapBundleSHA256("abc")
+	require.Error(t, err)
+	_, err = ParseBootstrapBundleSHA256(strings.Repeat("ab", 16))
+	require.EqualError(t, err, "expected a SHA-256 checksum of 64 hex characters, got 32")
+}
+
+func TestValidateBootstrapBundle(t *testing.T) {
+	path := field.NewPath("metadata", "annotations")
+	require.Empty(t, ValidateBootstrapBundle(nil, path))
+	require.Empty(t, ValidateBootstrapBundle(map[string]string{
+		tenancyapi.ClusterWorkspaceBootstrapBundleURLAnnotation:    "https://bundles.example.com/team.tar.gz",
+		tenancyapi.ClusterWorkspaceBootstrapBundleSHA256Annotation: strings.Repeat("ab", 32),
+	}, path))
+
+	errs := ValidateBootstrapBundle(map[string]string{tenancyapi.ClusterWorkspaceBootstrapBundleURLAnnotation: "ftp://bundles.example.com/team.tar.gz"}, path)
+	require.Len(t, errs, 2)
+	require.Equal(t, "metadata.annotations["+tenancyapi.ClusterWorkspaceBootstrapBundleURLAnnotation+"]", errs[0].Field)
+	require.Equal(t, field.ErrorTypeRequired, errs[1].Type)
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	tenancyapi "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// ValidateDisplayMetadata checks that the display name and description of a workspace, found under the given
+// spec path, are not longer than allowed. Like the maxLength of the OpenAPI schema, lengths are counted in characters.
+func ValidateDisplayMetadata(displayName, description string, specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if utf8.RuneCountInString(displayName) > tenancyapi.ClusterWorkspaceDisplayNameMaxLength {
+		errs = append(errs, field.TooLong(specPath.Child("displayName"), displayName, tenancyapi.ClusterWorkspaceDisplayNameMaxLength))
+	}
+	if utf8.RuneCountInString(description) > tenancyapi.ClusterWorkspaceDescriptionMaxLength {
+		errs = append(errs, field.TooLong(specPath.Child("description"), description, tenancyapi.ClusterWorkspaceDescriptionMaxLength))
+	}
+	return errs
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestValidateDisplayMetadata(t *testing.T) {
+	specPath := field.NewPath("spec")
+	for _, tc := range []struct {
+		name                     string
+		displayName, description string
+		wantFields               []string
+	}{
+		{name: "empty"},
+		{name: "free-form", displayName: "Team A's staging 🚀", description: "Where we try things.\nNot for production."},
+		{name: "display name at the limit counted in characters", displayName: strings.Repeat("é", tenancyv1alpha1.ClusterWorkspaceDisplayNameMaxLength)},
+		{name: "display name too long", displayName: strings.Repeat("a", tenancyv1alpha1.ClusterWorkspaceDisplayNameMaxLength+1), wantFields: []string{"spec.displayName"}},
+		{
+			name:        "both too long",
+			displayName: strings.Repeat("a", tenancyv1alpha1.ClusterWorkspaceDisplayNameMaxLength+1),
+			description: strings.Repeat("a", tenancyv1alpha1.ClusterWorkspaceDescriptionMaxLength+1),
+			wantFields:  []string{"spec.displayName", "spec.description"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var fields []string
+			for _, err := range ValidateDisplayMetadata(tc.displayName, tc.description, specPath) {
+				require.Equal(t, field.ErrorTypeTooLong, err.Type)
+				fields = append(fields, err.Field)
+			}
+			require.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
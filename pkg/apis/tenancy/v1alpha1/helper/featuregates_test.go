@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateFeatureGates(t *testing.T) {
+	gatesPath := field.NewPath("spec", "featureGates")
+	known := sets.NewString("ServerSideApply", "APIListChunking")
+	for _, tc := range []struct {
+		name       string
+		gates      map[string]bool
+		wantValues []string
+	}{
+		{name: "none"},
+		{name: "known gates", gates: map[string]bool{"ServerSideApply": false, "APIListChunking": true}},
+		{name: "unknown gates", gates: map[string]bool{"ServerSideApply": true, "Teleportation": true, "Levitation": false}, wantValues: []string{"Levitation", "Teleportation"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var values []string
+			for _, err := range ValidateFeatureGates(tc.gates, known, gatesPath) {
+				require.Equal(t, field.ErrorTypeInvalid, err.Type)
+				require.Equal(t, "spec.featureGates", err.Field)
+				values = append(values, err.BadValue.(string))
+			}
+			require.Equal(t, tc.wantValues, values)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/clusters"
 
 	tenancyapi "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -97,3 +98,74 @@ func ParentClusterName(name string) (string, error) {
 	}
 	return EncodeOrganizationAndClusterWorkspace(RootCluster, parent), nil
 }
+
+// PathToClusterName returns the logical cluster name of the workspace with the given path, i.e. the names of
+// the workspaces from the root workspace down to it, separated by colons. For example root:default:teamA is the
+// logical cluster default:teamA, and root:default is root:default. Workspaces can be nested at most in
+// organizations. Local system clusters are their own path.
+func PathToClusterName(path string) (string, error) {
+	if strings.HasPrefix(path, LocalSystemClusterPrefix) {
+		return path, validateSystemClusterName(path)
+	}
+	segments := strings.Split(path, separator)
+	if err := validatePathSegments(path, segments); err != nil {
+		return "", err
+	}
+	switch len(segments) {
+	case 1, 2:
+		return path, nil
+	case 3:
+		return EncodeOrganizationAndClusterWorkspace(segments[1], segments[2]), nil
+	default:
+		return "", fmt.Errorf("invalid workspace path %q: workspaces can be nested at most in organizations", path)
+	}
+}
+
+// ClusterNameToPath returns the path of the workspace with the given logical cluster name. It is the inverse
+// of PathToClusterName.
+func ClusterNameToPath(clusterName string) (string, error) {
+	if strings.HasPrefix(clusterName, LocalSystemClusterPrefix) {
+		return clusterName, validateSystemClusterName(clusterName)
+	}
+	parent, name, err := ParseLogicalClusterName(clusterName)
+	if err != nil {
+		return "", err
+	}
+	var path string
+	switch parent {
+	case "":
+		path = name
+	case RootCluster:
+		path = EncodeOrganizationAndClusterWorkspace(RootCluster, name)
+	default:
+		path = strings.Join([]string{RootCluster, parent, name}, separator)
+	}
+	if err := validatePathSegments(path, strings.Split(path, separator)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// validatePathSegments checks that the path starts with the root workspace, followed by the names of workspaces.
+// The root name is reserved, such that the paths and the logical cluster names map one to one.
+func validatePathSegments(path string, segments []string) error {
+	if segments[0] != RootCluster {
+		return fmt.Errorf("invalid workspace path %q: expected it to start with %s", path, RootCluster)
+	}
+	for _, segment := range segments[1:] {
+		if segment == RootCluster {
+			return fmt.Errorf("invalid workspace path %q: the workspace name %s is reserved", path, RootCluster)
+		}
+		if msgs := validation.IsDNS1123Label(segment); len(msgs) > 0 {
+			return fmt.Errorf("invalid workspace path %q: invalid workspace name %q: %s", path, segment, strings.Join(msgs, ", "))
+		}
+	}
+	return nil
+}
+
+func validateSystemClusterName(clusterName string) error {
+	if clusterName == LocalSystemClusterPrefix {
+		return fmt.Errorf("invalid workspace path %q: expected a name after %s", clusterName, LocalSystemClusterPrefix)
+	}
+	return nil
+}
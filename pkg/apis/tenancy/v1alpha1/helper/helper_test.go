@@ -19,6 +19,8 @@ package helper
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -210,3 +212,61 @@ func TestParentClusterName(t *testing.T) {
 		})
 	}
 }
+
+func TestPathToClusterNameRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		path        string
+		clusterName string
+	}{
+		{path: "root", clusterName: "root"},
+		{path: "root:default", clusterName: "root:default"},
+		{path: "root:default:teama", clusterName: "default:teama"},
+		{path: "root:my-org:team-a--1", clusterName: "my-org:team-a--1"},
+		{path: "system:admin", clusterName: "system:admin"},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			clusterName, err := PathToClusterName(tc.path)
+			require.NoError(t, err)
+			require.Equal(t, tc.clusterName, clusterName)
+
+			path, err := ClusterNameToPath(clusterName)
+			require.NoError(t, err)
+			require.Equal(t, tc.path, path)
+		})
+	}
+}
+
+func TestPathToClusterNameInvalid(t *testing.T) {
+	for _, path := range []string{
+		"",
+		"default",
+		"default:teama",
+		"root:",
+		"root:default:",
+		"root:default:teamA",
+		"root:root:teama",
+		"root:default:teama:child",
+		"system:",
+	} {
+		t.Run(path, func(t *testing.T) {
+			_, err := PathToClusterName(path)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestClusterNameToPathInvalid(t *testing.T) {
+	for _, clusterName := range []string{
+		"",
+		"default",
+		"root:",
+		"default:teamA",
+		"root:default:teama",
+		"system:",
+	} {
+		t.Run(clusterName, func(t *testing.T) {
+			_, err := ClusterNameToPath(clusterName)
+			require.Error(t, err)
+		})
+	}
+}
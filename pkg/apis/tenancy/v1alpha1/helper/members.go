@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// InitialMemberRoles are the roles members can be given through the initial members annotation. They
+// are the verbs on the clusterworkspaces/content subresource granting access to a workspace.
+var InitialMemberRoles = sets.NewString("admin", "edit", "view")
+
+// InitialMember is an entry of the initial members annotation.
+type InitialMember struct {
+	// Kind is rbacv1.UserKind or rbacv1.GroupKind.
+	Kind string
+	Name string
+	Role string
+}
+
+// ParseInitialMembers parses the value of the initial members annotation, a comma separated
+// list of user:<name>=<role> and group:<name>=<role> entries. The role must be one of
+// InitialMemberRoles.
+func ParseInitialMembers(value string) ([]InitialMember, error) {
+	var members []InitialMember
+	seen := sets.NewString()
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("entry %q must be of the form user:<name>=<role> or group:<name>=<role>", entry)
+		}
+		kind, rest := parts[0], parts[1]
+		separator := strings.LastIndex(rest, "=")
+		if separator < 0 {
+			return nil, fmt.Errorf("entry %q must be of the form user:<name>=<role> or group:<name>=<role>", entry)
+		}
+		member := InitialMember{Name: rest[:separator], Role: rest[separator+1:]}
+		switch kind {
+		case "user":
+			member.Kind = rbacv1.UserKind
+		case "group":
+			member.Kind = rbacv1.GroupKind
+		default:
+			return nil, fmt.Errorf("entry %q must start with user: or group:", entry)
+		}
+		if member.Name == "" {
+			return nil, fmt.Errorf("entry %q has an empty name", entry)
+		}
+		if !InitialMemberRoles.Has(member.Role) {
+			return nil, fmt.Errorf("entry %q has an unsupported role %q, must be one of %s", entry, member.Role, strings.Join(InitialMemberRoles.List(), ", "))
+		}
+		key := member.Kind + "/" + member.Name
+		if seen.Has(key) {
+			return nil, fmt.Errorf("entry %q lists %s %q more than once", entry, kind, member.Name)
+		}
+		seen.Insert(key)
+		members = append(members, member)
+	}
+	return members, nil
+}
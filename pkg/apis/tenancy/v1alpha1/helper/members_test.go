@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInitialMembers(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		value       string
+		expected    []InitialMember
+		expectedErr string
+	}{
+		{name: "empty"},
+		{
+			name:  "users and groups",
+			value: "user:user-2=edit, group:team-1=view,user:system:serviceaccount:default:bot=admin",
+			expected: []InitialMember{
+				{Kind: "User", Name: "user-2", Role: "edit"},
+				{Kind: "Group", Name: "team-1", Role: "view"},
+				{Kind: "User", Name: "system:serviceaccount:default:bot", Role: "admin"},
+			},
+		},
+		{name: "missing kind", value: "user-2=edit", expectedErr: `entry "user-2=edit" must be of the form user:<name>=<role> or group:<name>=<role>`},
+		{name: "missing role", value: "user:user-2", expectedErr: `entry "user:user-2" must be of the form user:<name>=<role> or group:<name>=<role>`},
+		{name: "unknown kind", value: "serviceaccount:bot=edit", expectedErr: `entry "serviceaccount:bot=edit" must start with user: or group:`},
+		{name: "empty name", value: "group:=view", expectedErr: `entry "group:=view" has an empty name`},
+		{name: "unsupported role", value: "user:user-2=cluster-admin", expectedErr: `entry "user:user-2=cluster-admin" has an unsupported role "cluster-admin", must be one of admin, edit, view`},
+		{name: "duplicate", value: "user:user-2=edit,user:user-2=view", expectedErr: `entry "user:user-2=view" lists user "user-2" more than once`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			members, err := ParseInitialMembers(tc.value)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, members)
+		})
+	}
+}
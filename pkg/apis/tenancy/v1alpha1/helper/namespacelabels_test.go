@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefaultNamespaceLabels(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		value       string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{name: "empty", expected: map[string]string{}},
+		{
+			name:     "labels",
+			value:    "team=payments, network.example.com/policy=strict,empty=",
+			expected: map[string]string{"team": "payments", "network.example.com/policy": "strict", "empty": ""},
+		},
+		{name: "missing value", value: "team", expectedErr: `entry "team" must be of the form <key>=<value>`},
+		{name: "invalid key", value: "-team=payments", expectedErr: `entry "-team=payments" has an invalid key: name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')`},
+		{name: "invalid value", value: "team=pay ments", expectedErr: `entry "team=pay ments" has an invalid value: a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`},
+		{name: "duplicate", value: "team=payments,team=billing", expectedErr: `entry "team=billing" sets label "team" more than once`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			labels, err := ParseDefaultNamespaceLabels(tc.value)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, labels)
+		})
+	}
+}
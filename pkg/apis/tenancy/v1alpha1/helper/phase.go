@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	tenancyapi "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// phaseTransitions is the table of legal ClusterWorkspace phase transitions:
+//
+//	""           -> Scheduling, Terminating
+//	Scheduling   -> Initializing, Terminating
+//	Initializing -> Ready, Failed, Terminating
+//	Ready        -> Terminating
+//	Failed       -> Initializing, Terminating
+//	Terminating  -> (none)
+//
+// Staying in the same phase is always legal.
+var phaseTransitions = map[tenancyapi.ClusterWorkspacePhaseType]sets.String{
+	"": sets.NewString(
+		string(tenancyapi.ClusterWorkspacePhaseScheduling),
+		string(tenancyapi.ClusterWorkspacePhaseTerminating),
+	),
+	tenancyapi.ClusterWorkspacePhaseScheduling: sets.NewString(
+		string(tenancyapi.ClusterWorkspacePhaseInitializing),
+		string(tenancyapi.ClusterWorkspacePhaseTerminating),
+	),
+	tenancyapi.ClusterWorkspacePhaseInitializing: sets.NewString(
+		string(tenancyapi.ClusterWorkspacePhaseReady),
+		string(tenancyapi.ClusterWorkspacePhaseFailed),
+		string(tenancyapi.ClusterWorkspacePhaseTerminating),
+	),
+	tenancyapi.ClusterWorkspacePhaseReady: sets.NewString(
+		string(tenancyapi.ClusterWorkspacePhaseTerminating),
+	),
+	tenancyapi.ClusterWorkspacePhaseFailed: sets.NewString(
+		string(tenancyapi.ClusterWorkspacePhaseInitializing),
+		string(tenancyapi.ClusterWorkspacePhaseTerminating),
+	),
+	tenancyapi.ClusterWorkspacePhaseTerminating: sets.NewString(),
+}
+
+// Phases returns the known ClusterWorkspace phases in the order they are gone through. The Failed phase
+// is only entered when the initialization times out.
+func Phases() []tenancyapi.ClusterWorkspacePhaseType {
+	return []tenancyapi.ClusterWorkspacePhaseType{
+		tenancyapi.ClusterWorkspacePhaseScheduling,
+		tenancyapi.ClusterWorkspacePhaseInitializing,
+		tenancyapi.ClusterWorkspacePhaseReady,
+		tenancyapi.ClusterWorkspacePhaseFailed,
+		tenancyapi.ClusterWorkspacePhaseTerminating,
+	}
+}
+
+// IsValidPhaseTransition returns whether a ClusterWorkspace may go from phase from to phase to.
+// Unknown phases never take part in a valid transition.
+func IsValidPhaseTransition(from, to tenancyapi.ClusterWorkspacePhaseType) bool {
+	next, known := phaseTransitions[from]
+	if !known {
+		return false
+	}
+	if _, known := phaseTransitions[to]; !known {
+		return false
+	}
+	return from == to || next.Has(string(to))
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestIsValidPhaseTransition(t *testing.T) {
+	const (
+		none         = tenancyv1alpha1.ClusterWorkspacePhaseType("")
+		scheduling   = tenancyv1alpha1.ClusterWorkspacePhaseScheduling
+		initializing = tenancyv1alpha1.ClusterWorkspacePhaseInitializing
+		ready        = tenancyv1alpha1.ClusterWorkspacePhaseReady
+		failed       = tenancyv1alpha1.ClusterWorkspacePhaseFailed
+		terminating  = tenancyv1alpha1.ClusterWorkspacePhaseTerminating
+		unknown      = tenancyv1alpha1.ClusterWorkspacePhaseType("Unknown")
+	)
+
+	for _, testCase := range []struct {
+		from, to tenancyv1alpha1.ClusterWorkspacePhaseType
+		valid    bool
+	}{
+		{from: none, to: none, valid: true},
+		{from: none, to: scheduling, valid: true},
+		{from: none, to: initializing, valid: false},
+		{from: none, to: ready, valid: false},
+		{from: none, to: terminating, valid: true},
+		{from: none, to: unknown, valid: false},
+
+		{from: scheduling, to: none, valid: false},
+		{from: scheduling, to: scheduling, valid: true},
+		{from: scheduling, to: initializing, valid: true},
+		{from: scheduling, to: ready, valid: false},
+		{from: scheduling, to: terminating, valid: true},
+		{from: scheduling, to: unknown, valid: false},
+
+		{from: initializing, to: none, valid: false},
+		{from: initializing, to: scheduling, valid: false},
+		{from: initializing, to: initializing, valid: true},
+		{from: initializing, to: ready, valid: true},
+		{from: initializing, to: failed, valid: true},
+		{from: initializing, to: terminating, valid: true},
+		{from: initializing, to: unknown, valid: false},
+
+		{from: ready, to: none, valid: false},
+		{from: ready, to: scheduling, valid: false},
+		{from: ready, to: initializing, valid: false},
+		{from: ready, to: ready, valid: true},
+		{from: ready, to: failed, valid: false},
+		{from: ready, to: terminating, valid: true},
+		{from: ready, to: unknown, valid: false},
+
+		{from: failed, to: none, valid: false},
+		{from: failed, to: scheduling, valid: false},
+		{from: failed, to: initializing, valid: true},
+		{from: failed, to: ready, valid: false},
+		{from: failed, to: failed, valid: true},
+		{from: failed, to: terminating, valid: true},
+
+		{from: terminating, to: none, valid: false},
+		{from: terminating, to: scheduling, valid: false},
+		{from: terminating, to: initializing, valid: false},
+		{from: terminating, to: ready, valid: false},
+		{from: terminating, to: failed, valid: false},
+		{from: terminating, to: terminating, valid: true},
+		{from: terminating, to: unknown, valid: false},
+
+		{from: unknown, to: none, valid: false},
+		{from: unknown, to: scheduling, valid: false},
+		{from: unknown, to: terminating, valid: false},
+		{from: unknown, to: unknown, valid: false},
+	} {
+		t.Run(string(testCase.from)+"->"+string(testCase.to), func(t *testing.T) {
+			if got := IsValidPhaseTransition(testCase.from, testCase.to); got != testCase.valid {
+				t.Errorf("expected transition from %q to %q to be valid=%v, got %v", testCase.from, testCase.to, testCase.valid, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	tenancyapi "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// IsSystemTag returns whether the tag with the given key is managed by the system, and can only be set or
+// changed by privileged users.
+func IsSystemTag(key string) bool {
+	return strings.HasPrefix(key, tenancyapi.ClusterWorkspaceSystemTagPrefix)
+}
+
+// ValidateTags checks that the keys of the tags of a workspace, found under the given path, are qualified
+// names and their values label values, such that tags can be mirrored as labels.
+func ValidateTags(tags map[string]string, tagsPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, key := range sortedKeys(tags) {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, field.Invalid(tagsPath, key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(tags[key]) {
+			errs = append(errs, field.Invalid(tagsPath.Key(key), tags[key], msg))
+		}
+	}
+	return errs
+}
+
+// ValidateSystemTagsUnchanged checks that no system tag is added, changed or removed from the old tags of a
+// workspace, found under the given path. The old tags are nil on creation.
+func ValidateSystemTagsUnchanged(oldTags, tags map[string]string, tagsPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, key := range sortedKeys(tags) {
+		if old, found := oldTags[key]; IsSystemTag(key) && (!found || old != tags[key]) {
+			errs = append(errs, field.Forbidden(tagsPath.Key(key), "system tags can only be set or changed by privileged users"))
+		}
+	}
+	for _, key := range sortedKeys(oldTags) {
+		if _, found := tags[key]; IsSystemTag(key) && !found {
+			errs = append(errs, field.Forbidden(tagsPath.Key(key), "system tags can only be removed by privileged users"))
+		}
+	}
+	return errs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
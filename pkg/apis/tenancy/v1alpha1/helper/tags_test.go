@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateTags(t *testing.T) {
+	tagsPath := field.NewPath("spec", "tags")
+	for _, tc := range []struct {
+		name       string
+		tags       map[string]string
+		wantFields []string
+	}{
+		{name: "none"},
+		{name: "user and system tags", tags: map[string]string{"cost-center": "cc-42", "system.tenancy.kcp.dev/compliance-tier": "gold", "empty": ""}},
+		{name: "invalid key", tags: map[string]string{"cost center": "cc-42"}, wantFields: []string{"spec.tags"}},
+		{name: "invalid value", tags: map[string]string{"cost-center": strings.Repeat("a", 64), "owner": "team a"}, wantFields: []string{"spec.tags[cost-center]", "spec.tags[owner]"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var fields []string
+			for _, err := range ValidateTags(tc.tags, tagsPath) {
+				require.Equal(t, field.ErrorTypeInvalid, err.Type)
+				fields = append(fields, err.Field)
+			}
+			require.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
+
+func TestValidateSystemTagsUnchanged(t *testing.T) {
+	tagsPath := field.NewPath("spec", "tags")
+	for _, tc := range []struct {
+		name       string
+		old, tags  map[string]string
+		wantFields []string
+	}{
+		{name: "create with user tags", tags: map[string]string{"cost-center": "cc-42"}},
+		{name: "create with a system tag", tags: map[string]string{"cost-center": "cc-42", "system.tenancy.kcp.dev/compliance-tier": "gold"}, wantFields: []string{"spec.tags[system.tenancy.kcp.dev/compliance-tier]"}},
+		{
+			name: "change user tags and keep system tags",
+			old:  map[string]string{"cost-center": "cc-42", "system.tenancy.kcp.dev/compliance-tier": "gold"},
+			tags: map[string]string{"cost-center": "cc-43", "system.tenancy.kcp.dev/compliance-tier": "gold"},
+		},
+		{
+			name:       "change a system tag",
+			old:        map[string]string{"system.tenancy.kcp.dev/compliance-tier": "gold"},
+			tags:       map[string]string{"system.tenancy.kcp.dev/compliance-tier": "platinum"},
+			wantFields: []string{"spec.tags[system.tenancy.kcp.dev/compliance-tier]"},
+		},
+		{
+			name:       "remove a system tag",
+			old:        map[string]string{"cost-center": "cc-42", "system.tenancy.kcp.dev/compliance-tier": "gold"},
+			tags:       map[string]string{"cost-center": "cc-42"},
+			wantFields: []string{"spec.tags[system.tenancy.kcp.dev/compliance-tier]"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var fields []string
+			for _, err := range ValidateSystemTagsUnchanged(tc.old, tc.tags, tagsPath) {
+				require.Equal(t, field.ErrorTypeForbidden, err.Type)
+				fields = append(fields, err.Field)
+			}
+			require.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
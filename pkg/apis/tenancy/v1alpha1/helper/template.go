@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	tenancyapi "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// templateKinds are the kinds of objects a ClusterWorkspaceType template can create.
+var templateKinds = map[schema.GroupKind]bool{
+	{Kind: "Namespace"}: true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:         true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:  true,
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"}:                true,
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:         true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: true,
+}
+
+// ValidateClusterWorkspaceTemplate checks the template of a ClusterWorkspaceType, found under the given template
+// path: every object must be a named object of one of the kinds a template can create.
+func ValidateClusterWorkspaceTemplate(template *tenancyapi.ClusterWorkspaceTemplate, templatePath *field.Path) field.ErrorList {
+	if template == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	for i := range template.Objects {
+		if _, err := templateObject(template, i); err != nil {
+			errs = append(errs, field.Invalid(templatePath.Child("objects").Index(i), string(template.Objects[i].Raw), err.Error()))
+		}
+	}
+	return errs
+}
+
+// ClusterWorkspaceTemplateObjects decodes the objects of a ClusterWorkspaceType template, in order.
+func ClusterWorkspaceTemplateObjects(template *tenancyapi.ClusterWorkspaceTemplate) ([]*unstructured.Unstructured, error) {
+	if template == nil {
+		return nil, nil
+	}
+	objs := make([]*unstructured.Unstructured, 0, len(template.Objects))
+	for i := range template.Objects {
+		obj, err := templateObject(template, i)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %w", i, err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// ValidateClusterWorkspaceTemplateKind fails if objects of the given kind can be created neither by a
+// ClusterWorkspaceType template nor by a bootstrap bundle.
+func ValidateClusterWorkspaceTemplateKind(gk schema.GroupKind) error {
+	if !templateKinds[gk] {
+		return fmt.Errorf("%s is not allowed, only namespaces, RBAC objects and custom resource definitions are", gk)
+	}
+	return nil
+}
+
+func templateObject(template *tenancyapi.ClusterWorkspaceTemplate, i int) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(template.Objects[i].Raw); err != nil {
+		return nil, err
+	}
+	if err := ValidateClusterWorkspaceTemplateKind(obj.GroupVersionKind().GroupKind()); err != nil {
+		return nil, err
+	}
+	if obj.GetName() == "" {
+		return nil, fmt.Errorf("a name is required")
+	}
+	return obj, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	tenancyapi "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestValidateClusterWorkspaceTemplate(t *testing.T) {
+	path := field.NewPath("spec", "template")
+	require.Empty(t, ValidateClusterWorkspaceTemplate(nil, path))
+	require.Empty(t, ValidateClusterWorkspaceTemplate(&tenancyapi.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+		{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"team"}}`)},
+		{Raw: []byte(`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"RoleBinding","metadata":{"name":"admins","namespace":"team"}}`)},
+		{Raw: []byte(`{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition","metadata":{"name":"widgets.example.com"}}`)},
+	}}, path))
+
+	errs := ValidateClusterWorkspaceTemplate(&tenancyapi.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+		{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"team"}}`)},
+		{Raw: []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"token"}}`)},
+		{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace"}`)},
+		{Raw: []byte(`{"metadata":{"name":"team"}}`)},
+	}}, path)
+	require.Len(t, errs, 3)
+	require.Equal(t, "spec.template.objects[1]", errs[0].Field)
+	require.Contains(t, errs[0].Detail, "Secret is not allowed")
+	require.Equal(t, "spec.template.objects[2]", errs[1].Field)
+	require.Equal(t, "a name is required", errs[1].Detail)
+	require.Equal(t, "spec.template.objects[3]", errs[2].Field)
+}
+
+func TestClusterWorkspaceTemplateObjects(t *testing.T) {
+	objs, err := ClusterWorkspaceTemplateObjects(nil)
+	require.NoError(t, err)
+	require.Empty(t, objs)
+
+	objs, err = ClusterWorkspaceTemplateObjects(&tenancyapi.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+		{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"team"}}`)},
+		{Raw: []byte(`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRole","metadata":{"name":"viewer"}}`)},
+	}})
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+	require.Equal(t, "Namespace", objs[0].GetKind())
+	require.Equal(t, "viewer", objs[1].GetName())
+
+	_, err = ClusterWorkspaceTemplateObjects(&tenancyapi.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+		{Raw: []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"token"}}`)},
+	}})
+	require.EqualError(t, err, "object 0: Secret is not allowed, only namespaces, RBAC objects and custom resource definitions are")
+}
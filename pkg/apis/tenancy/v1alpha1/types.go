@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
@@ -37,7 +38,7 @@ import (
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories=kcp
 // +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`,description="Type of the workspace"
-// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The current phase (e.g. Scheduling, Initializing, Ready)"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The current phase (e.g. Scheduling, Initializing, Ready, Terminating)"
 type ClusterWorkspace struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
@@ -73,17 +74,55 @@ type ClusterWorkspaceSpec struct {
 	// resources and initially installed APIs) and during runtime (e.g. permissions).
 	//
 	// The type is a reference to a ClusterWorkspaceType in the same workspace
-	// with the same name, but lower-cased. The ClusterWorkspaceType existence is
-	// validated at admission during creation, with the exception of the
-	// "Universal" type whose existence is not required but respected if it exists.
-	// The type is immutable after creation. The use of a type is gated via
-	// the RBAC clusterworkspacetypes/use resource permission.
+	// with the same name, but lower-cased. If empty, it is set on creation to the
+	// --default-workspace-type of the server, "universal" by default. The
+	// ClusterWorkspaceType existence is validated at admission during creation,
+	// with the exception of the default type whose existence is not required but
+	// respected if it exists. The type is immutable after creation. The use of a
+	// type is gated via the RBAC clusterworkspacetypes/use resource permission.
 	//
 	// +optional
-	// +kubebuilder:default:="Universal"
 	Type string `json:"type,omitempty"`
+
+	// displayName is a human readable name of the workspace, e.g. "Team A staging". Unlike the
+	// name, it is free-form and can be changed. It is only informational and not used for routing.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=128
+	DisplayName string `json:"displayName,omitempty"`
+
+	// description is a free-form description of the purpose of the workspace. It is only informational.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	Description string `json:"description,omitempty"`
+
+	// tags are free-form key-value pairs classifying the workspace, e.g. its cost center or compliance tier.
+	// Tags whose key is prefixed with system.tenancy.kcp.dev/ are managed by the system, and can only be
+	// set or changed by privileged users.
+	//
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// featureGates enables or disables the named feature gates of the hosting shard for this workspace only.
+	// Only the feature gates known to the shard can be set. Changes are propagated to the shard by the
+	// workspace scheduler, which sets the WorkspaceFeaturesChanging condition meanwhile.
+	//
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
 }
 
+const (
+	// ClusterWorkspaceDisplayNameMaxLength is the maximal length of the display name of a ClusterWorkspace.
+	ClusterWorkspaceDisplayNameMaxLength = 128
+	// ClusterWorkspaceDescriptionMaxLength is the maximal length of the description of a ClusterWorkspace.
+	ClusterWorkspaceDescriptionMaxLength = 1024
+
+	// ClusterWorkspaceSystemTagPrefix is the prefix of the keys of the tags of a ClusterWorkspace which
+	// only privileged users can set or change.
+	ClusterWorkspaceSystemTagPrefix = "system.tenancy.kcp.dev/"
+)
+
 // ClusterWorkspaceType specifies behaviour of workspaces of this type.
 //
 // +crd
@@ -107,6 +146,24 @@ type ClusterWorkspaceTypeSpec struct {
 	//
 	// +optional
 	Initializers []ClusterWorkspaceInitializer `json:"initializers,omitempty"`
+
+	// template is the content every ClusterWorkspace of this type is bootstrapped
+	// with while it initializes.
+	//
+	// +optional
+	Template *ClusterWorkspaceTemplate `json:"template,omitempty"`
+}
+
+// ClusterWorkspaceTemplate is the content ClusterWorkspaces of a type are created with.
+type ClusterWorkspaceTemplate struct {
+	// objects are created in order in the workspace. Only Namespaces, RBAC objects
+	// (ClusterRoles, ClusterRoleBindings, Roles and RoleBindings) and
+	// CustomResourceDefinitions are allowed. Objects which already exist are left alone.
+	//
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:EmbeddedResource
+	Objects []runtime.RawExtension `json:"objects,omitempty"`
 }
 
 // ClusterWorkspaceTypeList is a list of cluster workspace types
@@ -130,11 +187,119 @@ const (
 	ClusterWorkspacePhaseScheduling   ClusterWorkspacePhaseType = "Scheduling"
 	ClusterWorkspacePhaseInitializing ClusterWorkspacePhaseType = "Initializing"
 	ClusterWorkspacePhaseReady        ClusterWorkspacePhaseType = "Ready"
+	// ClusterWorkspacePhaseFailed is entered from the Initializing phase when the initialization does not
+	// complete within the initialization timeout of the workspace scheduler. The initialization is retried
+	// when the ClusterWorkspaceRetryInitializationAnnotation is set.
+	ClusterWorkspacePhaseFailed ClusterWorkspacePhaseType = "Failed"
+	// ClusterWorkspacePhaseTerminating is entered from any other phase when the workspace is deleted,
+	// and is never left.
+	ClusterWorkspacePhaseTerminating ClusterWorkspacePhaseType = "Terminating"
+)
+
+// ClusterWorkspaceCleanupFinalizer is set on every ClusterWorkspace by the workspace scheduler. It
+// blocks the removal of the ClusterWorkspace until all child ClusterWorkspaces inside of it are deleted.
+const ClusterWorkspaceCleanupFinalizer = "tenancy.kcp.dev/cleanup"
+
+const (
+	// ClusterWorkspaceCloneFromAnnotation names a sibling ClusterWorkspace, i.e. one with the same parent, whose
+	// CustomResourceDefinitions, ClusterRoles and ClusterRoleBindings are copied into the annotated
+	// ClusterWorkspace while it is initializing. Workload objects are never copied.
+	ClusterWorkspaceCloneFromAnnotation = "tenancy.kcp.dev/clone-from"
+	// ClusterWorkspaceCloneIncludeAnnotation is a comma separated list of additional resources to copy from
+	// the source of a clone. The only supported value is "secrets".
+	ClusterWorkspaceCloneIncludeAnnotation = "tenancy.kcp.dev/clone-include"
+	// ClusterWorkspaceCloneIncludeSecrets opts into copying the secrets of the source of a clone.
+	ClusterWorkspaceCloneIncludeSecrets = "secrets"
+
+	// ClusterWorkspaceBootstrapBundleURLAnnotation is the http or https URL of a tar archive, optionally gzipped,
+	// of YAML manifests which are created inside of the annotated ClusterWorkspace while it is initializing. Like
+	// the template of a ClusterWorkspaceType, it can only hold namespaces, RBAC objects and CRDs. The host of the
+	// URL must be allowed by the workspace scheduler. Objects that already exist are left alone.
+	ClusterWorkspaceBootstrapBundleURLAnnotation = "tenancy.kcp.dev/bootstrap-bundle-url"
+	// ClusterWorkspaceBootstrapBundleSHA256Annotation is the hex encoded SHA-256 checksum of the bundle referenced
+	// by the ClusterWorkspaceBootstrapBundleURLAnnotation. It is required along with the URL, and a downloaded
+	// bundle with another checksum is never applied.
+	ClusterWorkspaceBootstrapBundleSHA256Annotation = "tenancy.kcp.dev/bootstrap-bundle-sha256"
+
+	// ClusterWorkspaceShardSelectorAnnotation is a label selector over the labels of WorkspaceShards. The
+	// annotated ClusterWorkspace is only scheduled or moved onto matching shards.
+	ClusterWorkspaceShardSelectorAnnotation = "tenancy.kcp.dev/shard-selector"
+
+	// ClusterWorkspaceFrozenAnnotation makes the content of the annotated ClusterWorkspace read-only: mutating
+	// requests inside of it are rejected with 403 while it is set, reads keep working. Its value is the reason
+	// the workspace is frozen. It is managed through the freeze and unfreeze subresources of the Workspace.
+	ClusterWorkspaceFrozenAnnotation = "tenancy.kcp.dev/frozen"
+
+	// ClusterWorkspaceSoftDeletedUntilAnnotation marks a ClusterWorkspace deleted through the workspaces virtual
+	// workspace while soft-deletion is enabled. The ClusterWorkspace and its content are retained, but hidden from
+	// lists of workspaces, until the RFC 3339 time of the value, when the workspace scheduler deletes it. Removing
+	// the annotation, e.g. through the restore subresource of the Workspace, recovers the workspace.
+	ClusterWorkspaceSoftDeletedUntilAnnotation = "tenancy.kcp.dev/soft-deleted-until"
+
+	// ClusterWorkspaceNamePrefixAnnotation is set on the ClusterWorkspace of an organization to a prefix, e.g.
+	// team-a-, that the personal workspaces virtual workspace adds to the name of every workspace created in
+	// that organization, unless the requested name already starts with it.
+	ClusterWorkspaceNamePrefixAnnotation = "tenancy.kcp.dev/workspace-name-prefix"
+
+	// ClusterWorkspaceManagedFieldsAnnotation holds the managed fields of the Workspace projected from the
+	// ClusterWorkspace, encoded as JSON. The personal workspaces virtual workspace keeps them up to date, such
+	// that server-side apply of Workspaces tracks the field managers of the Workspace, not of the ClusterWorkspace.
+	ClusterWorkspaceManagedFieldsAnnotation = "tenancy.kcp.dev/workspace-managed-fields"
+
+	// ClusterWorkspaceInitialMembersAnnotation is a comma separated list of user:<name>=<role> and
+	// group:<name>=<role> entries, set on creation to give other users access to the new ClusterWorkspace.
+	// The role is one of admin, edit or view. Once the ClusterWorkspace is initialized, the workspace
+	// scheduler grants each member the matching verb on the clusterworkspaces/content subresource.
+	ClusterWorkspaceInitialMembersAnnotation = "tenancy.kcp.dev/initial-members"
+
+	// ClusterWorkspaceDefaultNamespaceLabelsAnnotation is a comma separated list of <key>=<value> labels, which are
+	// added to the namespaces created inside of the annotated ClusterWorkspace unless they set the key themselves.
+	ClusterWorkspaceDefaultNamespaceLabelsAnnotation = "tenancy.kcp.dev/default-namespace-labels"
+
+	// ClusterWorkspaceRetryInitializationAnnotation is set on a ClusterWorkspace in the Failed phase to move it
+	// back to the Initializing phase, such that the initializers of its type run again within a new
+	// initialization timeout. The workspace scheduler removes it.
+	ClusterWorkspaceRetryInitializationAnnotation = "tenancy.kcp.dev/retry-initialization"
+
+	// ClusterWorkspaceRefreshURLAnnotation is set on a scheduled ClusterWorkspace, e.g. through the refresh-url
+	// subresource of the Workspace, to make the workspace scheduler recompute its URLs from the current address of
+	// its shard, without reassigning the shard. The workspace scheduler removes it.
+	ClusterWorkspaceRefreshURLAnnotation = "tenancy.kcp.dev/refresh-url"
+
+	// ClusterWorkspaceCloneInitializer is set by the workspace scheduler on ClusterWorkspaces carrying the
+	// ClusterWorkspaceCloneFromAnnotation. It is removed once the content of the source has been copied.
+	ClusterWorkspaceCloneInitializer ClusterWorkspaceInitializer = "tenancy.kcp.dev/clone"
+	// ClusterWorkspaceBootstrapBundleInitializer is set by the workspace scheduler on ClusterWorkspaces carrying
+	// the ClusterWorkspaceBootstrapBundleURLAnnotation. It is removed once the bundle has been applied.
+	ClusterWorkspaceBootstrapBundleInitializer ClusterWorkspaceInitializer = "tenancy.kcp.dev/bootstrap-bundle"
+	// ClusterWorkspaceTemplateInitializer is set on ClusterWorkspaces whose ClusterWorkspaceType has a template
+	// with objects. It is removed once the objects of the template have been created.
+	ClusterWorkspaceTemplateInitializer ClusterWorkspaceInitializer = "tenancy.kcp.dev/template"
+)
+
+const (
+	// ClusterWorkspaceOwnerAnnotation is set to the name of the user who requested the ClusterWorkspace, e.g.
+	// through the personal workspaces virtual workspace. Once set, only the owner can change or remove it, or
+	// privileged users like the virtual workspace when transferring the ownership of the workspace.
+	ClusterWorkspaceOwnerAnnotation = "tenancy.kcp.dev/owner"
+	// ClusterWorkspaceOwnerGroupsAnnotation is a comma separated list of groups, e.g. team-1, owning the
+	// ClusterWorkspace along with the owner. It is requested on creation through the personal workspaces virtual
+	// workspace by a member of the groups. Any member of them sees the workspace in the personal scope and can
+	// manage it. Like the owner annotation, only the owner can change or remove it.
+	ClusterWorkspaceOwnerGroupsAnnotation = "tenancy.kcp.dev/owner-groups"
+
+	// ClusterWorkspaceCreatedByAnnotation is set on creation to the name of the authenticated user who created the
+	// ClusterWorkspace. Privileged users, like the personal workspaces virtual workspace acting on behalf of a
+	// user, can set it themselves. It cannot be changed afterwards, not even by the owner.
+	ClusterWorkspaceCreatedByAnnotation = "tenancy.kcp.dev/created-by"
+	// ClusterWorkspaceCreatedAtAnnotation is set on creation to the RFC 3339 time of the creation, taken from the
+	// clock of the server. It cannot be changed afterwards.
+	ClusterWorkspaceCreatedAtAnnotation = "tenancy.kcp.dev/created-at"
 )
 
 // ClusterWorkspaceStatus communicates the observed state of the ClusterWorkspace.
 type ClusterWorkspaceStatus struct {
-	// Phase of the workspace  (Scheduling / Initializing / Ready)
+	// Phase of the workspace  (Scheduling / Initializing / Ready / Failed / Terminating)
 	Phase ClusterWorkspacePhaseType `json:"phase,omitempty"`
 
 	// Current processing state of the ClusterWorkspace.
@@ -149,11 +314,24 @@ type ClusterWorkspaceStatus struct {
 	// +optional
 	BaseURL string `json:"baseURL,omitempty"`
 
+	// InternalBaseURL is the URL of this ClusterWorkspace on its shard, used by controllers to talk to
+	// the shard directly. It differs from BaseURL when shards are exposed through an external URL,
+	// e.g. behind an ingress.
+	//
+	// +optional
+	InternalBaseURL string `json:"internalBaseURL,omitempty"`
+
 	// Contains workspace placement information.
 	//
 	// +optional
 	Location ClusterWorkspaceLocation `json:"location,omitempty"`
 
+	// type is the lower-cased name of the ClusterWorkspaceType spec.type was resolved to by the
+	// workspace scheduler.
+	//
+	// +optional
+	Type string `json:"type,omitempty"`
+
 	// initializers are set on creation by the system and must be cleared
 	// by a controller before the workspace can be used. The workspace will
 	// stay in the phase "Initializing" state until all initializers are cleared.
@@ -163,6 +341,38 @@ type ClusterWorkspaceStatus struct {
 	//
 	// +optional
 	Initializers []ClusterWorkspaceInitializer `json:"initializers,omitempty"`
+
+	// InitializationStartTime is the time the ClusterWorkspace last entered the Initializing phase.
+	//
+	// +optional
+	InitializationStartTime *metav1.Time `json:"initializationStartTime,omitempty"`
+
+	// Owner is the identity which requested the ClusterWorkspace, as recorded in the owner annotations.
+	//
+	// +optional
+	Owner *ClusterWorkspaceOwner `json:"owner,omitempty"`
+
+	// LastActivityTime is the last time a request was observed inside of the workspace, as reported
+	// by the activity source of the workspace scheduler. It is not set while activity tracking is disabled.
+	//
+	// +optional
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
+
+	// FeatureGates are the feature gates last propagated to the shard for this workspace.
+	//
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// ClusterWorkspaceOwner identifies the user owning a ClusterWorkspace.
+type ClusterWorkspaceOwner struct {
+	// User is the name of the owning user.
+	User string `json:"user"`
+
+	// Groups are the groups the owning user belonged to when requesting the ClusterWorkspace.
+	//
+	// +optional
+	Groups []string `json:"groups,omitempty"`
 }
 
 // These are valid conditions of workspace.
@@ -190,6 +400,128 @@ const (
 	// WorkspaceShardValidReasonMissingConnectionInfo reason in WorkspaceShardValid condition means that the
 	// referenced WorkspaceShard object lacks connection info.
 	WorkspaceShardValidReasonMissingConnectionInfo = "MissingConnectionInfo"
+	// WorkspaceShardValidReasonNoCapacity reason in WorkspaceShardValid condition means that
+	// all valid WorkspaceShards are at their capacity.
+	WorkspaceShardValidReasonNoCapacity = "NoCapacity"
+	// WorkspaceShardValidReasonUnreachable reason in WorkspaceShardValid condition means that
+	// the referenced WorkspaceShard failed its health checks.
+	WorkspaceShardValidReasonUnreachable = "ShardUnreachable"
+	// WorkspaceShardValidReasonNoMatchingShard reason in WorkspaceShardValid condition means that no
+	// valid shard matches the ClusterWorkspaceShardSelectorAnnotation of the workspace.
+	WorkspaceShardValidReasonNoMatchingShard = "NoMatchingShard"
+
+	// WorkspaceTypeValid tells whether the ClusterWorkspaceType referenced by spec.type exists. It is false
+	// while it does not, and the workspace is not scheduled meanwhile.
+	WorkspaceTypeValid conditionsv1alpha1.ConditionType = "WorkspaceTypeValid"
+	// WorkspaceTypeValidReasonTypeNotFound reason in WorkspaceTypeValid condition means that no
+	// ClusterWorkspaceType of the name of spec.type exists in the workspace of the ClusterWorkspace.
+	WorkspaceTypeValidReasonTypeNotFound = "TypeNotFound"
+
+	// WorkspaceDeletionContentRemoved represents the progress of removing the child ClusterWorkspaces of
+	// a ClusterWorkspace that is being deleted.
+	WorkspaceDeletionContentRemoved conditionsv1alpha1.ConditionType = "WorkspaceDeletionContentRemoved"
+	// WorkspaceDeletionContentRemovedReasonChildrenRemaining reason in WorkspaceDeletionContentRemoved condition
+	// means that child ClusterWorkspaces are still being deleted.
+	WorkspaceDeletionContentRemovedReasonChildrenRemaining = "ChildWorkspacesRemaining"
+
+	// WorkspaceMigrating is set while a ClusterWorkspace is moved to another shard.
+	WorkspaceMigrating conditionsv1alpha1.ConditionType = "WorkspaceMigrating"
+	// WorkspaceMigratingReasonShardDraining reason in WorkspaceMigrating condition means that the
+	// ClusterWorkspace is moved off its shard because the shard is draining.
+	WorkspaceMigratingReasonShardDraining = "ShardDraining"
+
+	// WorkspaceMoving is set on a ClusterWorkspace while it is moved to another organization through the
+	// move subresource of the workspaces virtual workspace. The ClusterWorkspace is deleted once its copy
+	// exists in the target organization.
+	WorkspaceMoving conditionsv1alpha1.ConditionType = "WorkspaceMoving"
+	// WorkspaceMovingReasonMoveRequested reason in WorkspaceMoving condition means that a user requested
+	// to move the ClusterWorkspace to the organization named in the message.
+	WorkspaceMovingReasonMoveRequested = "MoveRequested"
+
+	// WorkspaceCloneComplete represents the progress of copying the content of the source ClusterWorkspace
+	// named in the ClusterWorkspaceCloneFromAnnotation.
+	WorkspaceCloneComplete conditionsv1alpha1.ConditionType = "WorkspaceCloneComplete"
+	// WorkspaceCloneReasonSourceNotFound reason in WorkspaceCloneComplete condition means that the
+	// source ClusterWorkspace does not exist.
+	WorkspaceCloneReasonSourceNotFound = "SourceNotFound"
+	// WorkspaceCloneReasonSourceNotReady reason in WorkspaceCloneComplete condition means that the
+	// source ClusterWorkspace is not ready yet.
+	WorkspaceCloneReasonSourceNotReady = "SourceNotReady"
+	// WorkspaceCloneReasonCopyFailed reason in WorkspaceCloneComplete condition means that copying
+	// the content of the source ClusterWorkspace failed. It is retried.
+	WorkspaceCloneReasonCopyFailed = "CopyFailed"
+
+	// WorkspaceBootstrapFailed is true while the template of the ClusterWorkspaceType or the bundle named in the
+	// ClusterWorkspaceBootstrapBundleURLAnnotation cannot be applied to the ClusterWorkspace. It is false once
+	// they have been applied.
+	WorkspaceBootstrapFailed conditionsv1alpha1.ConditionType = "WorkspaceBootstrapFailed"
+	// WorkspaceBootstrapFailedReasonHostNotAllowed reason in WorkspaceBootstrapFailed condition means that the
+	// host of the bundle URL is not allowed by the workspace scheduler.
+	WorkspaceBootstrapFailedReasonHostNotAllowed = "HostNotAllowed"
+	// WorkspaceBootstrapFailedReasonDownloadFailed reason in WorkspaceBootstrapFailed condition means that
+	// the bundle could not be downloaded. It is retried.
+	WorkspaceBootstrapFailedReasonDownloadFailed = "DownloadFailed"
+	// WorkspaceBootstrapFailedReasonChecksumMismatch reason in WorkspaceBootstrapFailed condition means that
+	// the checksum of the downloaded bundle does not match the ClusterWorkspaceBootstrapBundleSHA256Annotation.
+	WorkspaceBootstrapFailedReasonChecksumMismatch = "ChecksumMismatch"
+	// WorkspaceBootstrapFailedReasonInvalidBundle reason in WorkspaceBootstrapFailed condition means that the
+	// bundle is no tar archive of YAML manifests.
+	WorkspaceBootstrapFailedReasonInvalidBundle = "InvalidBundle"
+	// WorkspaceBootstrapFailedReasonKindNotAllowed reason in WorkspaceBootstrapFailed condition means that the
+	// bundle holds objects of kinds a bundle cannot create. None of its objects are created.
+	WorkspaceBootstrapFailedReasonKindNotAllowed = "KindNotAllowed"
+	// WorkspaceBootstrapFailedReasonApplyFailed reason in WorkspaceBootstrapFailed condition means that
+	// creating the objects of the bundle failed. It is retried.
+	WorkspaceBootstrapFailedReasonApplyFailed = "ApplyFailed"
+	// WorkspaceBootstrapFailedReasonTypeNotFound reason in WorkspaceBootstrapFailed condition means that the
+	// ClusterWorkspaceType holding the template was deleted. It is retried.
+	WorkspaceBootstrapFailedReasonTypeNotFound = "TypeNotFound"
+	// WorkspaceBootstrapFailedReasonInvalidTemplate reason in WorkspaceBootstrapFailed condition means that the
+	// template of the ClusterWorkspaceType holds objects a template cannot create.
+	WorkspaceBootstrapFailedReasonInvalidTemplate = "InvalidTemplate"
+
+	// WorkspaceQuotaBootstrapped represents the creation of the default ResourceQuota in the default namespace
+	// of a new ClusterWorkspace by the workspace scheduler. It is only set when a default quota is configured.
+	WorkspaceQuotaBootstrapped conditionsv1alpha1.ConditionType = "WorkspaceQuotaBootstrapped"
+	// WorkspaceQuotaBootstrappedReasonDefinedByType reason in WorkspaceQuotaBootstrapped condition means that
+	// no default quota was created because the workspace type already created a ResourceQuota.
+	WorkspaceQuotaBootstrappedReasonDefinedByType = "QuotaDefinedByType"
+
+	// WorkspaceInitialMembersBootstrapped represents the creation by the workspace scheduler of the RBAC
+	// granting the members listed in the ClusterWorkspaceInitialMembersAnnotation access to the ClusterWorkspace.
+	WorkspaceInitialMembersBootstrapped conditionsv1alpha1.ConditionType = "WorkspaceInitialMembersBootstrapped"
+	// WorkspaceInitialMembersBootstrappedReasonInvalid reason in WorkspaceInitialMembersBootstrapped condition
+	// means that the ClusterWorkspaceInitialMembersAnnotation could not be parsed. Nobody is granted access.
+	WorkspaceInitialMembersBootstrappedReasonInvalid = "InvalidInitialMembers"
+
+	// WorkspaceInitializationTimedOut is set to true by the workspace scheduler, along with the Failed phase, on
+	// a ClusterWorkspace whose initializers were not all cleared within the initialization timeout.
+	WorkspaceInitializationTimedOut conditionsv1alpha1.ConditionType = "WorkspaceInitializationTimedOut"
+	// WorkspaceInitializationTimedOutReasonInitializersPending reason in WorkspaceInitializationTimedOut condition
+	// means that the initializers named in the message were still pending when the timeout expired.
+	WorkspaceInitializationTimedOutReasonInitializersPending = "InitializersPending"
+
+	// WorkspaceOwnerMissing is set to true by the workspace scheduler, when the owner garbage collection is enabled,
+	// on a ClusterWorkspace whose owner is not known anymore. The ClusterWorkspace is deleted once the condition has
+	// been true for the grace period.
+	WorkspaceOwnerMissing conditionsv1alpha1.ConditionType = "WorkspaceOwnerMissing"
+	// WorkspaceOwnerMissingReasonUnknownOwner reason in WorkspaceOwnerMissing condition means that neither the
+	// owning user nor any of the owner groups are known anymore.
+	WorkspaceOwnerMissingReasonUnknownOwner = "UnknownOwner"
+
+	// WorkspaceFeaturesChanging is set to true by the workspace scheduler on a ready ClusterWorkspace whose
+	// feature gates changed, until they are propagated to the shard. It is removed afterwards.
+	WorkspaceFeaturesChanging conditionsv1alpha1.ConditionType = "WorkspaceFeaturesChanging"
+	// WorkspaceFeaturesChangingReasonInvalidFeatureGates reason in WorkspaceFeaturesChanging condition means
+	// that the feature gates are not known to the shard, and are not propagated.
+	WorkspaceFeaturesChangingReasonInvalidFeatureGates = "InvalidFeatureGates"
+
+	// WorkspaceURLRefreshed is set to true by the workspace scheduler on a ClusterWorkspace whose URLs were
+	// recomputed from the current address of its shard and changed. The message holds the previous base URL.
+	WorkspaceURLRefreshed conditionsv1alpha1.ConditionType = "WorkspaceURLRefreshed"
+	// WorkspaceURLRefreshedReasonShardAddressChanged reason in WorkspaceURLRefreshed condition means that the
+	// address of the shard, or the external URL template, changed since the URLs were computed.
+	WorkspaceURLRefreshedReasonShardAddressChanged = "ShardAddressChanged"
 )
 
 // ClusterWorkspaceLocation specifies workspace placement information, including current, desired (target), and
@@ -275,6 +607,27 @@ var _ conditions.Setter = &WorkspaceShard{}
 type WorkspaceShardSpec struct {
 	// Credentials is a reference to the administrative credentials for this shard.
 	Credentials corev1.SecretReference `json:"credentials"`
+
+	// Capacity is the maximal number of workspaces scheduled onto this shard.
+	// The number of workspaces is not limited if unset.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Capacity *int32 `json:"capacity,omitempty"`
+
+	// Draining takes the shard out of rotation, e.g. for maintenance. No new workspaces are
+	// scheduled onto a draining shard, and the workspaces scheduled onto it are moved to
+	// other valid shards. Alternatively, a true Draining condition drains the shard.
+	//
+	// +optional
+	Draining bool `json:"draining,omitempty"`
+
+	// CABundle is the PEM encoded bundle of the certificate authorities of the shard. It is handed
+	// to clients of the workspaces scheduled onto the shard, e.g. in workspace kubeconfigs. If unset,
+	// the certificate authorities of the shard credentials are used.
+	//
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
 }
 
 // WorkspaceShardStatus communicates the observed state of the WorkspaceShard.
@@ -294,6 +647,10 @@ type WorkspaceShardStatus struct {
 	// Version of credentials last successfully loaded.
 	// +optional
 	CredentialsHash string `json:"credentialsHash,omitempty"`
+
+	// CurrentWorkspaces is the number of workspaces currently scheduled onto this shard.
+	// +optional
+	CurrentWorkspaces int32 `json:"currentWorkspaces,omitempty"`
 }
 
 // ConnectionInfo holds the information necessary to connect to a shard.
@@ -320,6 +677,26 @@ const (
 	// WorkspaceShardCredentialsReasonInvalid reason in WorkspaceShardCredentialsValid condition means that the
 	// credentials referenced in the WorkspaceShard did not contain valid data in the correct key.
 	WorkspaceShardCredentialsReasonInvalid = "Invalid"
+
+	// ShardReachable represents the result of the health checks of the address of this workspace shard.
+	ShardReachable conditionsv1alpha1.ConditionType = "ShardReachable"
+	// ShardReachableReasonUnhealthy reason in ShardReachable condition means that the /healthz endpoint
+	// of the workspace shard failed or could not be reached several times in a row.
+	ShardReachableReasonUnhealthy = "Unhealthy"
+
+	// ShardUnavailable is true while the workspace scheduler short-circuits its calls to this workspace shard,
+	// after too many of them failed in a row. It is removed once a call succeeds again.
+	ShardUnavailable conditionsv1alpha1.ConditionType = "ShardUnavailable"
+	// ShardUnavailableReasonCircuitOpen reason in ShardUnavailable condition means that the circuit breaker of
+	// the workspace scheduler for this workspace shard is open.
+	ShardUnavailableReasonCircuitOpen = "CircuitOpen"
+
+	// WorkspaceShardSchedulingDisabled cordons the workspace shard when true, e.g. set by an operator.
+	// No new workspaces are scheduled onto it, but the workspaces scheduled onto it stay.
+	WorkspaceShardSchedulingDisabled conditionsv1alpha1.ConditionType = "SchedulingDisabled"
+	// WorkspaceShardDraining drains the workspace shard when true, like spec.draining: no new workspaces
+	// are scheduled onto it, and the workspaces scheduled onto it are moved to other valid shards.
+	WorkspaceShardDraining conditionsv1alpha1.ConditionType = "Draining"
 )
 
 // WorkspaceShardList is a list of workspace shards
@@ -331,3 +708,52 @@ type WorkspaceShardList struct {
 
 	Items []WorkspaceShard `json:"items"`
 }
+
+// WorkspaceQuota limits the number of workspaces the users it applies to can own
+// in the organization it is created in.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+type WorkspaceQuota struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec WorkspaceQuotaSpec `json:"spec,omitempty"`
+}
+
+// WorkspaceQuotaSpec holds the desired state of the WorkspaceQuota.
+type WorkspaceQuotaSpec struct {
+	// users are the names of the users the quota applies to.
+	//
+	// +optional
+	Users []string `json:"users,omitempty"`
+
+	// groups are the names of the groups the quota applies to. A user member
+	// of several of these groups is subject to the quota only once.
+	//
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// maxWorkspaces is the maximum number of workspaces each user the quota
+	// applies to can own. When several quotas apply to a user, the highest
+	// limit wins.
+	//
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	MaxWorkspaces int32 `json:"maxWorkspaces"`
+}
+
+// WorkspaceQuotaList is a list of workspace quotas
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkspaceQuota `json:"items"`
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/conversion"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace projects a ClusterWorkspace onto the Workspace
+// users see. The object metadata, including the owner annotations, is shared. The status fields only
+// relevant to the scheduling of the workspace, like its location and initializers, are dropped. The
+// conditions are summarized into a single Ready condition.
+func Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace(in *v1alpha1.ClusterWorkspace, out *Workspace, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1alpha1_ClusterWorkspaceSpec_To_v1beta1_WorkspaceSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	return Convert_v1alpha1_ClusterWorkspaceStatus_To_v1beta1_WorkspaceStatus(&in.Status, &out.Status, s)
+}
+
+// Convert_v1beta1_Workspace_To_v1alpha1_ClusterWorkspace is the inverse of
+// Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace. Converting a Workspace to a ClusterWorkspace
+// and back results in the same Workspace, except for the Ready condition which is recomputed.
+func Convert_v1beta1_Workspace_To_v1alpha1_ClusterWorkspace(in *Workspace, out *v1alpha1.ClusterWorkspace, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta1_WorkspaceSpec_To_v1alpha1_ClusterWorkspaceSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	return Convert_v1beta1_WorkspaceStatus_To_v1alpha1_ClusterWorkspaceStatus(&in.Status, &out.Status, s)
+}
+
+func Convert_v1alpha1_ClusterWorkspaceSpec_To_v1beta1_WorkspaceSpec(in *v1alpha1.ClusterWorkspaceSpec, out *WorkspaceSpec, s conversion.Scope) error {
+	out.Type = in.Type
+	out.DisplayName = in.DisplayName
+	out.Description = in.Description
+	out.Tags = in.Tags
+	out.FeatureGates = in.FeatureGates
+	return nil
+}
+
+func Convert_v1beta1_WorkspaceSpec_To_v1alpha1_ClusterWorkspaceSpec(in *WorkspaceSpec, out *v1alpha1.ClusterWorkspaceSpec, s conversion.Scope) error {
+	out.Type = in.Type
+	out.DisplayName = in.DisplayName
+	out.Description = in.Description
+	out.Tags = in.Tags
+	out.FeatureGates = in.FeatureGates
+	return nil
+}
+
+func Convert_v1alpha1_ClusterWorkspaceStatus_To_v1beta1_WorkspaceStatus(in *v1alpha1.ClusterWorkspaceStatus, out *WorkspaceStatus, s conversion.Scope) error {
+	out.URL = in.BaseURL
+	out.Phase = in.Phase
+	out.Shard = in.Location.Current
+	out.Conditions = conditionsv1alpha1.Conditions{*readyCondition(in)}
+	out.LastActivityTime = in.LastActivityTime
+	return nil
+}
+
+func Convert_v1beta1_WorkspaceStatus_To_v1alpha1_ClusterWorkspaceStatus(in *WorkspaceStatus, out *v1alpha1.ClusterWorkspaceStatus, s conversion.Scope) error {
+	out.BaseURL = in.URL
+	out.Phase = in.Phase
+	// the shard and the last activity are informational, the workspace scheduler owns them
+	return nil
+}
+
+// readyConditionTypes are the ClusterWorkspace conditions summarized into the Ready condition of the
+// Workspace. The others, like WorkspaceMigrating, do not tell whether the workspace can be used.
+var readyConditionTypes = []conditionsv1alpha1.ConditionType{
+	v1alpha1.WorkspaceScheduled,
+	v1alpha1.WorkspaceTypeValid,
+	v1alpha1.WorkspaceShardValid,
+	v1alpha1.WorkspaceCloneComplete,
+}
+
+// readyCondition summarizes the conditions of a ClusterWorkspace into a Ready condition. The reason
+// and message are the ones of the most severe false condition. A workspace which is not in the Ready
+// phase is not ready even if none of its conditions is false, and the reason is its phase then.
+//
+// The last transition time is the latest of the summarized conditions, such that a ClusterWorkspace
+// always projects onto the same Workspace.
+func readyCondition(in *v1alpha1.ClusterWorkspaceStatus) *conditionsv1alpha1.Condition {
+	summarized := &v1alpha1.ClusterWorkspace{Status: v1alpha1.ClusterWorkspaceStatus{Conditions: in.Conditions.DeepCopy()}}
+	conditions.SetSummary(summarized, conditions.WithConditions(readyConditionTypes...))
+	ready := conditions.Get(summarized, conditionsv1alpha1.ReadyCondition)
+
+	if in.Phase != v1alpha1.ClusterWorkspacePhaseReady && (ready == nil || ready.Status == corev1.ConditionTrue) {
+		phase := in.Phase
+		if phase == "" {
+			phase = v1alpha1.ClusterWorkspacePhaseScheduling
+		}
+		ready = conditions.FalseCondition(conditionsv1alpha1.ReadyCondition, string(phase), conditionsv1alpha1.ConditionSeverityInfo, "The workspace is in phase %s", phase)
+	} else if ready == nil {
+		ready = conditions.TrueCondition(conditionsv1alpha1.ReadyCondition)
+	}
+
+	ready.LastTransitionTime = metav1.Time{}
+	for _, c := range in.Conditions {
+		for _, t := range readyConditionTypes {
+			if c.Type == t && ready.LastTransitionTime.Before(&c.LastTransitionTime) {
+				ready.LastTransitionTime = c.LastTransitionTime
+			}
+		}
+	}
+	return ready
+}
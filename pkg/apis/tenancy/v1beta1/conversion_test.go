@@ -0,0 +1,269 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+	return scheme
+}
+
+func TestWorkspaceRoundTrip(t *testing.T) {
+	scheme := newScheme(t)
+	seed := rand.Int63()
+	f := fuzzer.FuzzerFor(metafuzzer.Funcs, rand.NewSource(seed), serializer.NewCodecFactory(scheme))
+
+	for i := 0; i < 1000; i++ {
+		var original Workspace
+		f.Fuzz(&original)
+		original.TypeMeta = metav1.TypeMeta{}
+
+		var clusterWorkspace v1alpha1.ClusterWorkspace
+		require.NoError(t, scheme.Convert(original.DeepCopy(), &clusterWorkspace, nil))
+		var roundTripped Workspace
+		require.NoError(t, scheme.Convert(&clusterWorkspace, &roundTripped, nil))
+
+		// the Ready condition is computed from the ClusterWorkspace conditions, which the Workspace does not carry
+		original.Status.Conditions = nil
+		roundTripped.Status.Conditions = nil
+		// the shard and the last activity are informational, and not converted back
+		original.Status.Shard = ""
+		original.Status.LastActivityTime = nil
+		if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+			t.Fatalf("round trip with seed %d changed the Workspace: %s", seed, diff.ObjectReflectDiff(original, roundTripped))
+		}
+	}
+}
+
+func TestClusterWorkspaceRoundTrip(t *testing.T) {
+	scheme := newScheme(t)
+	seed := rand.Int63()
+	f := fuzzer.FuzzerFor(metafuzzer.Funcs, rand.NewSource(seed), serializer.NewCodecFactory(scheme))
+
+	for i := 0; i < 1000; i++ {
+		var original v1alpha1.ClusterWorkspace
+		f.Fuzz(&original)
+		original.TypeMeta = metav1.TypeMeta{}
+
+		var workspace Workspace
+		require.NoError(t, scheme.Convert(original.DeepCopy(), &workspace, nil))
+		var roundTripped v1alpha1.ClusterWorkspace
+		require.NoError(t, scheme.Convert(&workspace, &roundTripped, nil))
+
+		// only the fields visible in the Workspace survive
+		expected := v1alpha1.ClusterWorkspace{
+			ObjectMeta: original.ObjectMeta,
+			Spec: v1alpha1.ClusterWorkspaceSpec{
+				Type:         original.Spec.Type,
+				DisplayName:  original.Spec.DisplayName,
+				Description:  original.Spec.Description,
+				Tags:         original.Spec.Tags,
+				FeatureGates: original.Spec.FeatureGates,
+			},
+			Status: v1alpha1.ClusterWorkspaceStatus{
+				BaseURL: original.Status.BaseURL,
+				Phase:   original.Status.Phase,
+			},
+		}
+		if !apiequality.Semantic.DeepEqual(expected, roundTripped) {
+			t.Fatalf("round trip with seed %d changed the ClusterWorkspace: %s", seed, diff.ObjectReflectDiff(expected, roundTripped))
+		}
+	}
+}
+
+func TestConvertOwnerAnnotations(t *testing.T) {
+	clusterWorkspace := &v1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+			Annotations: map[string]string{
+				v1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+				v1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1,team-2",
+			},
+		},
+		Spec: v1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+		Status: v1alpha1.ClusterWorkspaceStatus{
+			Phase:   v1alpha1.ClusterWorkspacePhaseReady,
+			BaseURL: "https://kcp.dev/clusters/org:foo",
+		},
+	}
+
+	var workspace Workspace
+	require.NoError(t, Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace(clusterWorkspace, &workspace, nil))
+	require.Equal(t, clusterWorkspace.Annotations, workspace.Annotations)
+	require.Equal(t, "Universal", workspace.Spec.Type)
+	require.Equal(t, v1alpha1.ClusterWorkspacePhaseReady, workspace.Status.Phase)
+	require.Equal(t, "https://kcp.dev/clusters/org:foo", workspace.Status.URL)
+}
+
+func TestConvertShard(t *testing.T) {
+	clusterWorkspace := &v1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Status: v1alpha1.ClusterWorkspaceStatus{
+			Location: v1alpha1.ClusterWorkspaceLocation{Current: "boston", Target: "chicago"},
+		},
+	}
+
+	var workspace Workspace
+	require.NoError(t, Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace(clusterWorkspace, &workspace, nil))
+	require.Equal(t, "boston", workspace.Status.Shard, "the shard currently hosting the workspace should be shown")
+
+	workspace.Status.Shard = "chicago"
+	var converted v1alpha1.ClusterWorkspace
+	require.NoError(t, Convert_v1beta1_Workspace_To_v1alpha1_ClusterWorkspace(&workspace, &converted, nil))
+	require.Empty(t, converted.Status.Location.Current, "the shard should not be settable")
+}
+
+func TestConvertReadyCondition(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(earlier.Add(time.Minute))
+
+	for _, tc := range []struct {
+		name     string
+		status   v1alpha1.ClusterWorkspaceStatus
+		expected conditionsv1alpha1.Condition
+	}{
+		{
+			name: "ready",
+			status: v1alpha1.ClusterWorkspaceStatus{
+				Phase: v1alpha1.ClusterWorkspacePhaseReady,
+				Conditions: conditionsv1alpha1.Conditions{
+					{Type: v1alpha1.WorkspaceScheduled, Status: corev1.ConditionTrue, LastTransitionTime: earlier},
+					{Type: v1alpha1.WorkspaceShardValid, Status: corev1.ConditionTrue, LastTransitionTime: later},
+				},
+			},
+			expected: conditionsv1alpha1.Condition{Type: conditionsv1alpha1.ReadyCondition, Status: corev1.ConditionTrue, LastTransitionTime: later},
+		},
+		{
+			name:     "ready without conditions",
+			status:   v1alpha1.ClusterWorkspaceStatus{Phase: v1alpha1.ClusterWorkspacePhaseReady},
+			expected: conditionsv1alpha1.Condition{Type: conditionsv1alpha1.ReadyCondition, Status: corev1.ConditionTrue},
+		},
+		{
+			name: "unscheduled",
+			status: v1alpha1.ClusterWorkspaceStatus{
+				Phase: v1alpha1.ClusterWorkspacePhaseScheduling,
+				Conditions: conditionsv1alpha1.Conditions{
+					{Type: v1alpha1.WorkspaceScheduled, Status: corev1.ConditionFalse, Severity: conditionsv1alpha1.ConditionSeverityError, Reason: v1alpha1.WorkspaceReasonUnschedulable, Message: "No available shards to schedule the workspace.", LastTransitionTime: earlier},
+				},
+			},
+			expected: conditionsv1alpha1.Condition{
+				Type:               conditionsv1alpha1.ReadyCondition,
+				Status:             corev1.ConditionFalse,
+				Severity:           conditionsv1alpha1.ConditionSeverityError,
+				Reason:             v1alpha1.WorkspaceReasonUnschedulable,
+				Message:            "No available shards to schedule the workspace.",
+				LastTransitionTime: earlier,
+			},
+		},
+		{
+			name: "invalid shard",
+			status: v1alpha1.ClusterWorkspaceStatus{
+				Phase: v1alpha1.ClusterWorkspacePhaseReady,
+				Conditions: conditionsv1alpha1.Conditions{
+					{Type: v1alpha1.WorkspaceScheduled, Status: corev1.ConditionTrue, LastTransitionTime: earlier},
+					{Type: v1alpha1.WorkspaceShardValid, Status: corev1.ConditionFalse, Severity: conditionsv1alpha1.ConditionSeverityError, Reason: v1alpha1.WorkspaceShardValidReasonShardNotFound, Message: "WorkspaceShard shard-1 does not exist", LastTransitionTime: later},
+				},
+			},
+			expected: conditionsv1alpha1.Condition{
+				Type:               conditionsv1alpha1.ReadyCondition,
+				Status:             corev1.ConditionFalse,
+				Severity:           conditionsv1alpha1.ConditionSeverityError,
+				Reason:             v1alpha1.WorkspaceShardValidReasonShardNotFound,
+				Message:            "WorkspaceShard shard-1 does not exist",
+				LastTransitionTime: later,
+			},
+		},
+		{
+			name: "missing type",
+			status: v1alpha1.ClusterWorkspaceStatus{
+				Phase: v1alpha1.ClusterWorkspacePhaseScheduling,
+				Conditions: conditionsv1alpha1.Conditions{
+					{Type: v1alpha1.WorkspaceTypeValid, Status: corev1.ConditionFalse, Severity: conditionsv1alpha1.ConditionSeverityError, Reason: v1alpha1.WorkspaceTypeValidReasonTypeNotFound, Message: `ClusterWorkspaceType "team" does not exist in workspace "root:org".`, LastTransitionTime: earlier},
+				},
+			},
+			expected: conditionsv1alpha1.Condition{
+				Type:               conditionsv1alpha1.ReadyCondition,
+				Status:             corev1.ConditionFalse,
+				Severity:           conditionsv1alpha1.ConditionSeverityError,
+				Reason:             v1alpha1.WorkspaceTypeValidReasonTypeNotFound,
+				Message:            `ClusterWorkspaceType "team" does not exist in workspace "root:org".`,
+				LastTransitionTime: earlier,
+			},
+		},
+		{
+			name: "initializing",
+			status: v1alpha1.ClusterWorkspaceStatus{
+				Phase: v1alpha1.ClusterWorkspacePhaseInitializing,
+				Conditions: conditionsv1alpha1.Conditions{
+					{Type: v1alpha1.WorkspaceScheduled, Status: corev1.ConditionTrue, LastTransitionTime: earlier},
+				},
+			},
+			expected: conditionsv1alpha1.Condition{
+				Type:               conditionsv1alpha1.ReadyCondition,
+				Status:             corev1.ConditionFalse,
+				Severity:           conditionsv1alpha1.ConditionSeverityInfo,
+				Reason:             string(v1alpha1.ClusterWorkspacePhaseInitializing),
+				Message:            "The workspace is in phase Initializing",
+				LastTransitionTime: earlier,
+			},
+		},
+		{
+			name: "migrating is not summarized",
+			status: v1alpha1.ClusterWorkspaceStatus{
+				Phase: v1alpha1.ClusterWorkspacePhaseReady,
+				Conditions: conditionsv1alpha1.Conditions{
+					{Type: v1alpha1.WorkspaceScheduled, Status: corev1.ConditionTrue, LastTransitionTime: earlier},
+					{Type: v1alpha1.WorkspaceMigrating, Status: corev1.ConditionFalse, Severity: conditionsv1alpha1.ConditionSeverityInfo, Reason: v1alpha1.WorkspaceMigratingReasonShardDraining, LastTransitionTime: later},
+				},
+			},
+			expected: conditionsv1alpha1.Condition{Type: conditionsv1alpha1.ReadyCondition, Status: corev1.ConditionTrue, LastTransitionTime: earlier},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clusterWorkspace := &v1alpha1.ClusterWorkspace{Status: tc.status}
+			original := clusterWorkspace.DeepCopy()
+
+			var workspace Workspace
+			require.NoError(t, Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace(clusterWorkspace, &workspace, nil))
+			require.Len(t, workspace.Status.Conditions, 1)
+			require.Equal(t, tc.expected, *conditions.Get(&workspace, conditionsv1alpha1.ReadyCondition))
+			require.Equal(t, original, clusterWorkspace, "the ClusterWorkspace should not be modified")
+		})
+	}
+}
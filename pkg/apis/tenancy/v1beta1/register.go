@@ -17,11 +17,16 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"net/url"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 )
 
 // SchemeGroupVersion is group version used to register these objects
@@ -38,7 +43,7 @@ func Resource(resource string) schema.GroupResource {
 }
 
 var (
-	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes, addFieldLabelConversionFuncs, addConversionFuncs)
 	AddToScheme   = SchemeBuilder.AddToScheme
 )
 
@@ -47,7 +52,83 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Workspace{},
 		&WorkspaceList{},
+		&WorkspaceAPIResources{},
+		&WorkspaceAuthorization{},
+		&WorkspaceBatch{},
+		&WorkspaceChildrenOptions{},
+		&WorkspaceExport{},
+		&WorkspaceExportOptions{},
+		&WorkspaceFreeze{},
+		&WorkspaceImport{},
+		&WorkspaceKubeconfigOptions{},
+		&WorkspaceMove{},
+		&WorkspaceOwnershipTransfer{},
+		&WorkspaceRename{},
+		&WorkspaceRestore{},
+		&WorkspaceURLRefresh{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
 }
+
+// addFieldLabelConversionFuncs registers the fields Workspaces can be selected on.
+func addFieldLabelConversionFuncs(scheme *runtime.Scheme) error {
+	return scheme.AddFieldLabelConversionFunc(SchemeGroupVersion.WithKind("Workspace"),
+		func(label, value string) (string, string, error) {
+			switch label {
+			case "metadata.name", "status.phase":
+				return label, value, nil
+			default:
+				return "", "", fmt.Errorf("field label not supported: %s", label)
+			}
+		},
+	)
+}
+
+// addConversionFuncs registers the conversion of query parameters into the options of the subresources,
+// and between ClusterWorkspaces and Workspaces.
+func addConversionFuncs(scheme *runtime.Scheme) error {
+	if err := scheme.AddConversionFunc((*url.Values)(nil), (*WorkspaceKubeconfigOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		in, out := a.(*url.Values), b.(*WorkspaceKubeconfigOptions)
+		if values, ok := (*in)["credentials"]; ok && len(values) > 0 {
+			return runtime.Convert_Slice_string_To_string(&values, &out.Credentials, scope)
+		}
+		out.Credentials = ""
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := scheme.AddConversionFunc((*url.Values)(nil), (*WorkspaceExportOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		in, out := a.(*url.Values), b.(*WorkspaceExportOptions)
+		if values, ok := (*in)["includeSecrets"]; ok && len(values) > 0 {
+			return runtime.Convert_Slice_string_To_bool(&values, &out.IncludeSecrets, scope)
+		}
+		out.IncludeSecrets = false
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := scheme.AddConversionFunc((*url.Values)(nil), (*WorkspaceChildrenOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		in, out := a.(*url.Values), b.(*WorkspaceChildrenOptions)
+		out.Limit, out.Continue = 0, ""
+		if values, ok := (*in)["limit"]; ok && len(values) > 0 {
+			if err := runtime.Convert_Slice_string_To_int64(&values, &out.Limit, scope); err != nil {
+				return err
+			}
+		}
+		if values, ok := (*in)["continue"]; ok && len(values) > 0 {
+			return runtime.Convert_Slice_string_To_string(&values, &out.Continue, scope)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := scheme.AddConversionFunc((*v1alpha1.ClusterWorkspace)(nil), (*Workspace)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ClusterWorkspace_To_v1beta1_Workspace(a.(*v1alpha1.ClusterWorkspace), b.(*Workspace), scope)
+	}); err != nil {
+		return err
+	}
+	return scheme.AddConversionFunc((*Workspace)(nil), (*v1alpha1.ClusterWorkspace)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Workspace_To_v1alpha1_ClusterWorkspace(a.(*Workspace), b.(*v1alpha1.ClusterWorkspace), scope)
+	})
+}
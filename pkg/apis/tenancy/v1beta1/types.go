@@ -17,9 +17,11 @@ limitations under the License.
 package v1beta1
 
 import (
+	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 )
 
 // Workspace defines a generic Kubernetes-cluster-like endpoint, with standard Kubernetes
@@ -36,37 +38,73 @@ import (
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Display Name",type=string,JSONPath=`.spec.displayName`,description="Human readable name of the workspace"
 // +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`,description="Type of the workspace"
-// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The current phase (e.g. Scheduling, Initializing, Ready)"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The current phase (e.g. Scheduling, Initializing, Ready, Terminating)"
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.URL`,description="URL to access the workspace",priority=1
+// +kubebuilder:printcolumn:name="Shard",type=string,JSONPath=`.status.shard`,description="Shard hosting the workspace",priority=1
+// +kubebuilder:printcolumn:name="Description",type=string,JSONPath=`.spec.description`,description="Description of the workspace",priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +protobuf=true
 type Workspace struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
-	metav1.ObjectMeta `json:"metadata,omitempty"`
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
 
-	Spec WorkspaceSpec `json:"spec"`
+	Spec WorkspaceSpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
 
 	// +optional
-	Status WorkspaceStatus `json:"status"`
+	Status WorkspaceStatus `json:"status" protobuf:"bytes,3,opt,name=status"`
 }
 
 // WorkspaceSpec holds the desired state of the ClusterWorkspace.
+//
+// +protobuf=true
 type WorkspaceSpec struct {
 	// type defines properties of the workspace both on creation (e.g. initial
 	// resources and initially installed APIs) and during runtime (e.g. permissions).
 	//
 	// The type is a reference to a ClusterWorkspaceType in the same workspace
-	// with the same name, but lower-cased. The ClusterWorkspaceType existence is
-	// validated at admission during creation, with the exception of the
-	// "Universal" type whose existence is not required but respected if it exists.
-	// The type is immutable after creation. The use of a type is gated via
-	// the RBAC clusterworkspacetypes/use resource permission.
+	// with the same name, but lower-cased. If empty, it is set on creation to the
+	// --default-workspace-type of the server, "universal" by default. The
+	// ClusterWorkspaceType existence is validated at admission during creation,
+	// with the exception of the default type whose existence is not required but
+	// respected if it exists. The type is immutable after creation. The use of a
+	// type is gated via the RBAC clusterworkspacetypes/use resource permission.
+	//
+	// +optional
+	Type string `json:"type,omitempty" protobuf:"bytes,1,opt,name=type"`
+
+	// displayName is a human readable name of the workspace, e.g. "Team A staging". Unlike the
+	// name, it is free-form and can be changed. It is only informational and not used for routing.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=128
+	DisplayName string `json:"displayName,omitempty" protobuf:"bytes,2,opt,name=displayName"`
+
+	// description is a free-form description of the purpose of the workspace. It is only informational.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	Description string `json:"description,omitempty" protobuf:"bytes,3,opt,name=description"`
+
+	// tags are free-form key-value pairs classifying the workspace, e.g. its cost center or compliance tier.
+	// Tags whose key is prefixed with system.tenancy.kcp.dev/ are managed by the system, and can only be
+	// set or changed by privileged users.
+	//
+	// +optional
+	Tags map[string]string `json:"tags,omitempty" protobuf:"bytes,4,rep,name=tags"`
+
+	// featureGates enables or disables the named feature gates of the hosting shard for this workspace only.
+	// Only the feature gates known to the shard can be set.
 	//
 	// +optional
-	// +kubebuilder:default:="Universal"
-	Type string `json:"type,omitempty"`
+	FeatureGates map[string]bool `json:"featureGates,omitempty" protobuf:"bytes,5,rep,name=featureGates"`
 }
 
 // WorkspaceStatus communicates the observed state of the Workspace.
+//
+// +protobuf=true
 type WorkspaceStatus struct {
 	// url is the address under which the Kubernetes-cluster-like endpoint
 	// can be found. This URL can be used to access the workspace with standard Kubernetes
@@ -74,18 +112,333 @@ type WorkspaceStatus struct {
 	//
 	// +required
 	// +kubebuilder:format:uri
-	URL string `json:"URL"`
+	URL string `json:"URL" protobuf:"bytes,1,opt,name=URL"`
+
+	// Phase of the workspace (Scheduling / Initializing / Ready / Terminating). This field is ALPHA.
+	Phase v1alpha1.ClusterWorkspacePhaseType `json:"phase,omitempty" protobuf:"bytes,2,opt,name=phase,casttype=github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1.ClusterWorkspacePhaseType"`
+
+	// shard is the name of the workspace shard hosting the workspace. It is informational only,
+	// and cannot be set.
+	//
+	// +optional
+	Shard string `json:"shard,omitempty" protobuf:"bytes,3,opt,name=shard"`
+
+	// conditions holds a single Ready condition summarizing the conditions of the workspace.
+	// When the workspace is not ready, its reason and message tell why, e.g. Unschedulable.
+	//
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty" protobuf:"bytes,4,rep,name=conditions"`
 
-	// Phase of the workspace (Initializing / Active / Terminating). This field is ALPHA.
-	Phase v1alpha1.ClusterWorkspacePhaseType `json:"phase,omitempty"`
+	// lastActivityTime is the last time a request was observed inside of the workspace. It is
+	// informational only, and cannot be set.
+	//
+	// +optional
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty" protobuf:"bytes,5,opt,name=lastActivityTime"`
+}
+
+func (in *Workspace) SetConditions(c conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = c
+}
+
+func (in *Workspace) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
 }
 
 // WorkspaceList is a list of Workspaces
 //
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +protobuf=true
 type WorkspaceList struct {
 	metav1.TypeMeta `json:",inline"`
-	metav1.ListMeta `json:"metadata"`
+	metav1.ListMeta `json:"metadata" protobuf:"bytes,1,opt,name=metadata"`
+
+	Items []Workspace `json:"items" protobuf:"bytes,2,rep,name=items"`
+}
+
+// WorkspaceAPIResources is returned by the apiresources subresource of a Workspace
+// and lists the APIs served inside of the workspace, as found by its discovery.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceAPIResources struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Groups are the API groups served inside of the workspace, including the legacy core group.
+	// +optional
+	Groups []metav1.APIGroup `json:"groups,omitempty"`
 
-	Items []Workspace `json:"items"`
+	// Resources are the resources served inside of the workspace, per group version.
+	// +optional
+	Resources []metav1.APIResourceList `json:"resources,omitempty"`
+}
+
+// WorkspaceAuthorization is returned by the authorization subresource of a Workspace
+// and lists the actions the requesting user can perform inside of the workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceAuthorization struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Status contains the rules of the requesting user, as computed from the RBAC of the workspace.
+	Status authorizationv1.SubjectRulesReviewStatus `json:"status"`
+}
+
+// WorkspaceBatch creates many workspaces in a single request. It is never persisted:
+// the response carries the result of the creation of each workspace of the batch,
+// the failure of some workspaces not failing the whole batch.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceBatch struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceBatchSpec `json:"spec"`
+
+	// +optional
+	Status WorkspaceBatchStatus `json:"status,omitempty"`
+}
+
+// WorkspaceBatchSpec holds the workspaces to create.
+type WorkspaceBatchSpec struct {
+	// workspaces are the workspaces to create, with unique names.
+	Workspaces []Workspace `json:"workspaces"`
+}
+
+// WorkspaceBatchStatus communicates the result of the creation of the workspaces of a batch.
+type WorkspaceBatchStatus struct {
+	// results holds the result of each workspace of the batch, in the order of the spec.
+	//
+	// +optional
+	Results []WorkspaceBatchResult `json:"results,omitempty"`
+}
+
+// WorkspaceBatchResult is the result of the creation of one workspace of a batch.
+// Exactly one of workspace and error is set.
+type WorkspaceBatchResult struct {
+	// name is the name of the workspace in the spec of the batch.
+	Name string `json:"name"`
+
+	// workspace is the created workspace.
+	//
+	// +optional
+	Workspace *Workspace `json:"workspace,omitempty"`
+
+	// error is the reason the workspace could not be created.
+	//
+	// +optional
+	Error *metav1.Status `json:"error,omitempty"`
+}
+
+// WorkspaceMove is posted to the move subresource of a Workspace to move it to another organization.
+// It is never persisted: the response is the moved workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceMove struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceMoveSpec `json:"spec"`
+}
+
+// WorkspaceMoveSpec holds the destination of a workspace move.
+type WorkspaceMoveSpec struct {
+	// targetOrg is the logical cluster name of the organization to move the workspace to, e.g. root:my-org.
+	TargetOrg string `json:"targetOrg"`
+}
+
+// WorkspaceRename is posted to the rename subresource of a Workspace to give it another name.
+// It is never persisted: the response is the renamed workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceRename struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceRenameSpec `json:"spec"`
+}
+
+// WorkspaceRenameSpec holds the new name of a renamed workspace.
+type WorkspaceRenameSpec struct {
+	// newName is the name the workspace is given.
+	NewName string `json:"newName"`
+}
+
+// WorkspaceOwnershipTransfer is posted to the transfer-ownership subresource of a Workspace to give it
+// another owner. It is never persisted: the response is the transferred workspace, as the new owner sees it.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceOwnershipTransfer struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceOwnershipTransferSpec `json:"spec"`
+}
+
+// WorkspaceOwnershipTransferSpec holds the new owner of a workspace.
+type WorkspaceOwnershipTransferSpec struct {
+	// user is the name of the user the workspace is transferred to.
+	User string `json:"user"`
+
+	// groups are the groups of the new owner, e.g. to find the workspaces owned by its groups in its personal scope.
+	// The groups owning the workspace are kept.
+	//
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+}
+
+// WorkspaceFreeze is posted to the freeze subresource of a Workspace to make it read-only, and to the
+// unfreeze subresource to make it writable again. It is never persisted: the response is the workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceFreeze struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec WorkspaceFreezeSpec `json:"spec,omitempty"`
+}
+
+// WorkspaceFreezeSpec holds why a workspace is frozen.
+type WorkspaceFreezeSpec struct {
+	// reason is recorded on the frozen workspace, e.g. the compliance snapshot it is frozen for.
+	// It is ignored when unfreezing.
+	//
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// WorkspaceRestore is posted to the restore subresource of a soft-deleted Workspace to recover it within its
+// retention window. It is never persisted: the response is the workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceRestore struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+// WorkspaceURLRefresh is posted to the refresh-url subresource of a Workspace to recompute its URL from the
+// current address of its shard, e.g. after a DNS change. It is never persisted: the response is the workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceURLRefresh struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+// WorkspaceChildrenOptions are the query parameters of the children subresource of a Workspace, which returns
+// the WorkspaceList of the workspaces created inside of it.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceChildrenOptions struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// limit is the maximum number of children to return. The list has a continue token if more children exist.
+	//
+	// +optional
+	Limit int64 `json:"limit,omitempty"`
+
+	// continue is the token returned with the previous page of children.
+	//
+	// +optional
+	Continue string `json:"continue,omitempty"`
+}
+
+// WorkspaceExport is returned by the export subresource of a Workspace. It holds the declarative content of
+// the workspace as a portable manifest, which the import subresource of another workspace accepts.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceExport struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// manifest is a YAML stream of the CustomResourceDefinitions, RBAC objects, namespaces and ConfigMaps
+	// of the workspace, without their status. It starts with a header comment holding the version of the
+	// manifest format.
+	Manifest string `json:"manifest"`
+}
+
+// WorkspaceExportOptions are the query parameters of the export subresource of a Workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceExportOptions struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// includeSecrets adds the secrets of the workspace to the manifest, except service account tokens.
+	// Secrets are excluded by default.
+	//
+	// +optional
+	IncludeSecrets bool `json:"includeSecrets,omitempty"`
+}
+
+// WorkspaceImport is posted to the import subresource of a Workspace to create the objects of a manifest
+// returned by the export subresource inside of the workspace. It is never persisted: the response is the
+// import with its status.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceImport struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceImportSpec `json:"spec"`
+
+	// +optional
+	Status WorkspaceImportStatus `json:"status,omitempty"`
+}
+
+// WorkspaceImportSpec holds the manifest to import.
+type WorkspaceImportSpec struct {
+	// manifest is a YAML stream as returned by the export subresource of a workspace.
+	Manifest string `json:"manifest"`
+}
+
+// WorkspaceImportStatus communicates the result of an import. Objects are referenced as
+// <resource>[.<group>]/[<namespace>/]<name>.
+type WorkspaceImportStatus struct {
+	// created are the objects of the manifest created in the workspace.
+	//
+	// +optional
+	Created []string `json:"created,omitempty"`
+
+	// existing are the objects of the manifest which already existed in the workspace. They are left alone.
+	//
+	// +optional
+	Existing []string `json:"existing,omitempty"`
+}
+
+// WorkspaceKubeconfigCredentialsToken requests a kubeconfig embedding a bearer token
+// that is only valid for the workspace.
+const WorkspaceKubeconfigCredentialsToken = "token"
+
+// WorkspaceKubeconfigExtensionName is the name of the extension of the context of the kubeconfigs returned by
+// the kubeconfig subresource of a Workspace. It holds a WorkspaceKubeconfigExtension.
+const WorkspaceKubeconfigExtensionName = "tenancy.kcp.dev/workspace"
+
+// WorkspaceKubeconfigExtension describes the workspace a kubeconfig returned by the kubeconfig subresource
+// of a Workspace points to.
+type WorkspaceKubeconfigExtension struct {
+	// type is the type of the workspace.
+	Type string `json:"type"`
+}
+
+// WorkspaceKubeconfigOptions are the query parameters of the kubeconfig subresource of a Workspace.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceKubeconfigOptions struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// credentials selects the credentials embedded in the returned kubeconfig. By default the
+	// kubeconfig has no user, and the caller's own credentials are expected to be used with it.
+	// With "token", a bearer token authenticating the caller in this workspace only is embedded.
+	// The token expires after a bounded time.
+	//
+	// +optional
+	Credentials string `json:"credentials,omitempty"`
 }
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workspacetoken mints and authenticates short-lived bearer tokens which are
+// only valid for the logical cluster of a single workspace. They are handed out by the
+// workspaces virtual workspace and accepted by the kcp server.
+package workspacetoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/kubernetes/pkg/serviceaccount"
+)
+
+// Issuer is the issuer of workspace tokens. Tokens of other issuers are ignored by the authenticator.
+const Issuer = "kcp.dev/workspaces"
+
+// privateClaims are the claims of a workspace token on top of the registered JWT claims.
+type privateClaims struct {
+	Kcp kcpClaims `json:"kcp.dev,omitempty"`
+}
+
+type kcpClaims struct {
+	UID    string   `json:"uid,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Audience returns the audience of tokens scoped to the given logical cluster.
+func Audience(clusterName string) string {
+	return Issuer + "/clusters/" + clusterName
+}
+
+// Generator mints workspace tokens.
+type Generator struct {
+	generator serviceaccount.TokenGenerator
+	now       func() time.Time
+}
+
+// NewGenerator returns a generator signing tokens with the given private key,
+// which must be a *rsa.PrivateKey or an *ecdsa.PrivateKey.
+func NewGenerator(privateKey interface{}) (*Generator, error) {
+	generator, err := serviceaccount.JWTTokenGenerator(Issuer, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{generator: generator, now: time.Now}, nil
+}
+
+// GenerateToken returns a token authenticating the given user in the given logical cluster only,
+// and the time it expires.
+func (g *Generator) GenerateToken(u user.Info, clusterName string, ttl time.Duration) (string, time.Time, error) {
+	if clusterName == "" || clusterName == "*" {
+		return "", time.Time{}, fmt.Errorf("invalid logical cluster %q", clusterName)
+	}
+	now := g.now()
+	expiresAt := now.Add(ttl)
+	token, err := g.generator.GenerateToken(&jwt.Claims{
+		Issuer:    Issuer,
+		Subject:   u.GetName(),
+		Audience:  jwt.Audience{Audience(clusterName)},
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Expiry:    jwt.NewNumericDate(expiresAt),
+	}, &privateClaims{Kcp: kcpClaims{UID: u.GetUID(), Groups: u.GetGroups()}})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+type tokenAuthenticator struct {
+	keys []interface{}
+	now  func() time.Time
+}
+
+// NewAuthenticator returns a token authenticator accepting workspace tokens signed by one of the
+// private keys matching the given public keys, for requests to the logical cluster they are scoped to.
+func NewAuthenticator(keys []interface{}) authenticator.Token {
+	return &tokenAuthenticator{keys: keys, now: time.Now}
+}
+
+func (a *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, false, nil // not a JWT, let other authenticators try
+	}
+	var unverified jwt.Claims
+	if err := tok.UnsafeClaimsWithoutVerification(&unverified); err != nil || unverified.Issuer != Issuer {
+		return nil, false, nil // not a workspace token
+	}
+
+	var public jwt.Claims
+	var private privateClaims
+	verified := false
+	for _, key := range a.keys {
+		if err := tok.Claims(key, &public, &private); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, false, errors.New("workspace token signature is invalid")
+	}
+
+	cluster := genericapirequest.ClusterFrom(ctx)
+	if cluster == nil || cluster.Name == "" || cluster.Wildcard {
+		return nil, false, errors.New("workspace token can only be used against a single logical cluster")
+	}
+	if err := public.ValidateWithLeeway(jwt.Expected{
+		Issuer:   Issuer,
+		Audience: jwt.Audience{Audience(cluster.Name)},
+		Time:     a.now(),
+	}, 0); err != nil {
+		return nil, false, fmt.Errorf("workspace token is invalid for logical cluster %s: %w", cluster.Name, err)
+	}
+	if public.Subject == "" {
+		return nil, false, errors.New("workspace token has no subject")
+	}
+
+	return &authenticator.Response{
+		User: &user.DefaultInfo{
+			Name:   public.Subject,
+			UID:    private.Kcp.UID,
+			Groups: private.Kcp.Groups,
+		},
+	}, true, nil
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacetoken
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestAuthenticateToken(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	generator, err := NewGenerator(key)
+	require.NoError(t, err)
+	now := time.Now()
+	generator.now = func() time.Time { return now }
+
+	token, expiresAt, err := generator.GenerateToken(&user.DefaultInfo{Name: "user-1", UID: "uid-1", Groups: []string{"team-1"}}, "root:org:ws", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(time.Hour), expiresAt)
+
+	for _, tc := range []struct {
+		name          string
+		token         string
+		cluster       *genericapirequest.Cluster
+		keys          []interface{}
+		at            time.Time
+		expectedUser  user.Info
+		expectedError bool
+	}{
+		{
+			name:         "token is valid in its logical cluster",
+			token:        token,
+			cluster:      &genericapirequest.Cluster{Name: "root:org:ws"},
+			keys:         []interface{}{otherKey.Public(), key.Public()},
+			at:           now,
+			expectedUser: &user.DefaultInfo{Name: "user-1", UID: "uid-1", Groups: []string{"team-1"}},
+		},
+		{
+			name:          "token is invalid in another logical cluster",
+			token:         token,
+			cluster:       &genericapirequest.Cluster{Name: "root:org:other"},
+			keys:          []interface{}{key.Public()},
+			at:            now,
+			expectedError: true,
+		},
+		{
+			name:          "token is invalid in the parent logical cluster",
+			token:         token,
+			cluster:       &genericapirequest.Cluster{Name: "root:org"},
+			keys:          []interface{}{key.Public()},
+			at:            now,
+			expectedError: true,
+		},
+		{
+			name:          "token is invalid for wildcard requests",
+			token:         token,
+			cluster:       &genericapirequest.Cluster{Name: "*", Wildcard: true},
+			keys:          []interface{}{key.Public()},
+			at:            now,
+			expectedError: true,
+		},
+		{
+			name:          "expired token is invalid",
+			token:         token,
+			cluster:       &genericapirequest.Cluster{Name: "root:org:ws"},
+			keys:          []interface{}{key.Public()},
+			at:            now.Add(time.Hour + time.Second),
+			expectedError: true,
+		},
+		{
+			name:          "token signed by an unknown key is invalid",
+			token:         token,
+			cluster:       &genericapirequest.Cluster{Name: "root:org:ws"},
+			keys:          []interface{}{otherKey.Public()},
+			at:            now,
+			expectedError: true,
+		},
+		{
+			name:    "other tokens are ignored",
+			token:   "d2a0e8c6-2e25-4aa8-a4b4-6e4bd5d3a0e9",
+			cluster: &genericapirequest.Cluster{Name: "root:org:ws"},
+			keys:    []interface{}{key.Public()},
+			at:      now,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewAuthenticator(tc.keys).(*tokenAuthenticator)
+			a.now = func() time.Time { return tc.at }
+
+			resp, ok, err := a.AuthenticateToken(genericapirequest.WithCluster(context.Background(), *tc.cluster), tc.token)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectedUser == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tc.expectedUser, resp.User)
+		})
+	}
+}
+
+func TestGenerateTokenRequiresLogicalCluster(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	generator, err := NewGenerator(key)
+	require.NoError(t, err)
+
+	for _, clusterName := range []string{"", "*"} {
+		_, _, err := generator.GenerateToken(&user.DefaultInfo{Name: "user-1"}, clusterName, time.Hour)
+		require.Error(t, err, "cluster %q", clusterName)
+	}
+}
@@ -24,10 +24,13 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/authorization/union"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	clientgoinformers "k8s.io/client-go/informers"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
 	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/clusters"
+	"k8s.io/kubernetes/pkg/genericcontrolplane"
 	"k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -123,13 +126,47 @@ func (a *OrgWorkspaceAuthorizer) Authorize(ctx context.Context, attr authorizer.
 		}
 	}
 
-	verbToGroupMembership := map[string]string{
-		"admin":  "system:kcp:clusterworkspace:admin",
-		"edit":   "system:kcp:clusterworkspace:edit",
-		"view":   "system:kcp:clusterworkspace:view",
-		"access": "system:kcp:authenticated",
+	extraGroups, reason, err := workspaceContentGroups(ctx, orgAuthorizer, attr.GetUser(), clusterWorkspace)
+	if err != nil {
+		return authorizer.DecisionNoOpinion, reason, err
+	}
+	if len(extraGroups) == 0 {
+		return authorizer.DecisionNoOpinion, "workspace access not permitted", nil
 	}
 
+	attrsWithExtraGroups := authorizer.AttributesRecord{
+		User: &user.DefaultInfo{
+			Name:   attr.GetUser().GetName(),
+			UID:    attr.GetUser().GetUID(),
+			Groups: append(attr.GetUser().GetGroups(), extraGroups...),
+			Extra:  attr.GetUser().GetExtra(),
+		},
+		Verb:            attr.GetVerb(),
+		Namespace:       attr.GetNamespace(),
+		APIGroup:        attr.GetAPIGroup(),
+		APIVersion:      attr.GetAPIVersion(),
+		Resource:        attr.GetResource(),
+		Subresource:     attr.GetSubresource(),
+		Name:            attr.GetName(),
+		ResourceRequest: attr.IsResourceRequest(),
+		Path:            attr.GetPath(),
+	}
+
+	return a.delegate.Authorize(ctx, attrsWithExtraGroups)
+}
+
+// verbToGroupMembership maps the verbs on the clusterworkspaces/content subresource in the parent workspace
+// to the groups a user is given inside of the workspace.
+var verbToGroupMembership = map[string]string{
+	"admin":  "system:kcp:clusterworkspace:admin",
+	"edit":   "system:kcp:clusterworkspace:edit",
+	"view":   "system:kcp:clusterworkspace:view",
+	"access": "system:kcp:authenticated",
+}
+
+// workspaceContentGroups returns the groups the given user is a member of inside of clusterWorkspace,
+// as granted by the RBAC of the parent workspace.
+func workspaceContentGroups(ctx context.Context, orgAuthorizer authorizer.Authorizer, u user.Info, clusterWorkspace string) ([]string, string, error) {
 	extraGroups := []string{}
 	var (
 		errList    []error
@@ -137,7 +174,7 @@ func (a *OrgWorkspaceAuthorizer) Authorize(ctx context.Context, attr authorizer.
 	)
 	for verb, group := range verbToGroupMembership {
 		workspaceAttr := authorizer.AttributesRecord{
-			User:            attr.GetUser(),
+			User:            u,
 			Verb:            verb,
 			APIGroup:        v1alpha1.SchemeGroupVersion.Group,
 			APIVersion:      v1alpha1.SchemeGroupVersion.Version,
@@ -158,29 +195,64 @@ func (a *OrgWorkspaceAuthorizer) Authorize(ctx context.Context, attr authorizer.
 		}
 	}
 	if len(errList) > 0 {
-		return authorizer.DecisionNoOpinion, strings.Join(reasonList, "\n"), utilerrors.NewAggregate(errList)
+		return nil, strings.Join(reasonList, "\n"), utilerrors.NewAggregate(errList)
 	}
-	if len(extraGroups) == 0 {
-		return authorizer.DecisionNoOpinion, "workspace access not permitted", nil
+	return extraGroups, "", nil
+}
+
+// NewWorkspaceContentRuleResolver returns a rule resolver for the rules a user has inside of the given logical
+// cluster. Like the workspace content authorizer, it adds the groups granted by the parent workspace to the user
+// and evaluates the bootstrap policy and the local RBAC of the logical cluster for them.
+func NewWorkspaceContentRuleResolver(rbacInformers rbacinformers.Interface, clusterName string) (authorizer.RuleResolver, error) {
+	parentClusterName, err := helper.ParentClusterName(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	_, clusterWorkspace, err := helper.ParseLogicalClusterName(clusterName)
+	if err != nil {
+		return nil, err
 	}
 
-	attrsWithExtraGroups := authorizer.AttributesRecord{
-		User: &user.DefaultInfo{
-			Name:   attr.GetUser().GetName(),
-			UID:    attr.GetUser().GetUID(),
-			Groups: append(attr.GetUser().GetGroups(), extraGroups...),
-			Extra:  attr.GetUser().GetExtra(),
-		},
-		Verb:            attr.GetVerb(),
-		Namespace:       attr.GetNamespace(),
-		APIGroup:        attr.GetAPIGroup(),
-		APIVersion:      attr.GetAPIVersion(),
-		Resource:        attr.GetResource(),
-		Subresource:     attr.GetSubresource(),
-		Name:            attr.GetName(),
-		ResourceRequest: attr.IsResourceRequest(),
-		Path:            attr.GetPath(),
+	newRBACAuthorizer := func(clusterName string) *rbac.RBACAuthorizer {
+		filteredInformer := rbacwrapper.FilterInformers(clusterName, rbacInformers)
+		return rbac.New(
+			&rbac.RoleGetter{Lister: filteredInformer.Roles().Lister()},
+			&rbac.RoleBindingLister{Lister: filteredInformer.RoleBindings().Lister()},
+			&rbac.ClusterRoleGetter{Lister: filteredInformer.ClusterRoles().Lister()},
+			&rbac.ClusterRoleBindingLister{Lister: filteredInformer.ClusterRoleBindings().Lister()},
+		)
 	}
 
-	return a.delegate.Authorize(ctx, attrsWithExtraGroups)
+	return &workspaceContentRuleResolver{
+		orgAuthorizer:    newRBACAuthorizer(parentClusterName),
+		clusterWorkspace: clusterWorkspace,
+		delegate:         union.NewRuleResolvers(newRBACAuthorizer(genericcontrolplane.LocalAdminCluster), newRBACAuthorizer(clusterName)),
+	}, nil
+}
+
+type workspaceContentRuleResolver struct {
+	orgAuthorizer    authorizer.Authorizer
+	clusterWorkspace string
+
+	// union of local and bootstrap rule resolvers
+	delegate authorizer.RuleResolver
+}
+
+func (r *workspaceContentRuleResolver) RulesFor(u user.Info, namespace string) ([]authorizer.ResourceRuleInfo, []authorizer.NonResourceRuleInfo, bool, error) {
+	// TODO: wire context in RulesFor interface
+	extraGroups, _, err := workspaceContentGroups(context.TODO(), r.orgAuthorizer, u, r.clusterWorkspace)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if len(extraGroups) == 0 {
+		// without workspace access, no rule applies
+		return nil, nil, false, nil
+	}
+
+	return r.delegate.RulesFor(&user.DefaultInfo{
+		Name:   u.GetName(),
+		UID:    u.GetUID(),
+		Groups: append(u.GetGroups(), extraGroups...),
+		Extra:  u.GetExtra(),
+	}, namespace)
 }
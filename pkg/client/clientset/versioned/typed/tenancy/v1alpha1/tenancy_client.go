@@ -31,6 +31,7 @@ type TenancyV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	ClusterWorkspacesGetter
 	ClusterWorkspaceTypesGetter
+	WorkspaceQuotasGetter
 	WorkspaceShardsGetter
 }
 
@@ -48,6 +49,10 @@ func (c *TenancyV1alpha1Client) ClusterWorkspaceTypes() ClusterWorkspaceTypeInte
 	return newClusterWorkspaceTypes(c)
 }
 
+func (c *TenancyV1alpha1Client) WorkspaceQuotas() WorkspaceQuotaInterface {
+	return newWorkspaceQuotas(c)
+}
+
 func (c *TenancyV1alpha1Client) WorkspaceShards() WorkspaceShardInterface {
 	return newWorkspaceShards(c)
 }
@@ -28,6 +28,8 @@ type Interface interface {
 	ClusterWorkspaces() ClusterWorkspaceInformer
 	// ClusterWorkspaceTypes returns a ClusterWorkspaceTypeInformer.
 	ClusterWorkspaceTypes() ClusterWorkspaceTypeInformer
+	// WorkspaceQuotas returns a WorkspaceQuotaInformer.
+	WorkspaceQuotas() WorkspaceQuotaInformer
 	// WorkspaceShards returns a WorkspaceShardInformer.
 	WorkspaceShards() WorkspaceShardInformer
 }
@@ -53,6 +55,11 @@ func (v *version) ClusterWorkspaceTypes() ClusterWorkspaceTypeInformer {
 	return &clusterWorkspaceTypeInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// WorkspaceQuotas returns a WorkspaceQuotaInformer.
+func (v *version) WorkspaceQuotas() WorkspaceQuotaInformer {
+	return &workspaceQuotaInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // WorkspaceShards returns a WorkspaceShardInformer.
 func (v *version) WorkspaceShards() WorkspaceShardInformer {
 	return &workspaceShardInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client holds helpers on top of the generated kcp clientset.
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+// WorkspaceKubeconfig returns the kubeconfig of the workspace with the given name, as served by the kubeconfig
+// subresource of the workspaces virtual workspace the client talks to. Errors of the subresource are wrapped,
+// such that they can still be checked with the helpers of k8s.io/apimachinery/pkg/api/errors.
+func WorkspaceKubeconfig(ctx context.Context, client kcpclient.Interface, name string) (*clientcmdapi.Config, error) {
+	data, err := client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(name).SubResource("kubeconfig").Do(ctx).Raw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the kubeconfig of workspace %q: %w", name, err)
+	}
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig of workspace %q: %w", name, err)
+	}
+	return config, nil
+}
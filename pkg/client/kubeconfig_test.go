@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+const cannedKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: personal/steve
+  cluster:
+    server: https://boston.kcp.dev/clusters/org:steve
+contexts:
+- name: personal/steve
+  context:
+    cluster: personal/steve
+current-context: personal/steve
+`
+
+func TestWorkspaceKubeconfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apis/tenancy.kcp.dev/v1beta1/workspaces/steve/kubeconfig":
+			w.Header().Set("Content-Type", "application/yaml")
+			_, _ = w.Write([]byte(cannedKubeconfig))
+		case "/apis/tenancy.kcp.dev/v1beta1/workspaces/invalid/kubeconfig":
+			w.Header().Set("Content-Type", "application/yaml")
+			_, _ = w.Write([]byte("clusters: 42"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404,"message":"workspaces.tenancy.kcp.dev \"unknown\" not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := kcpclient.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+
+	config, err := WorkspaceKubeconfig(context.Background(), client, "steve")
+	require.NoError(t, err)
+	require.Equal(t, "personal/steve", config.CurrentContext)
+	require.Equal(t, "personal/steve", config.Contexts["personal/steve"].Cluster)
+	require.Equal(t, "https://boston.kcp.dev/clusters/org:steve", config.Clusters["personal/steve"].Server)
+
+	_, err = WorkspaceKubeconfig(context.Background(), client, "unknown")
+	require.Error(t, err)
+	require.True(t, kerrors.IsNotFound(err), "expected the error of the subresource to be surfaced, got %v", err)
+
+	_, err = WorkspaceKubeconfig(context.Background(), client, "invalid")
+	require.Error(t, err)
+	require.False(t, kerrors.IsNotFound(err))
+}
@@ -26,6 +26,10 @@ type ClusterWorkspaceListerExpansion interface{}
 // ClusterWorkspaceTypeLister.
 type ClusterWorkspaceTypeListerExpansion interface{}
 
+// WorkspaceQuotaListerExpansion allows custom methods to be added to
+// WorkspaceQuotaLister.
+type WorkspaceQuotaListerExpansion interface{}
+
 // WorkspaceShardListerExpansion allows custom methods to be added to
 // WorkspaceShardLister.
 type WorkspaceShardListerExpansion interface{}
@@ -34,6 +34,7 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/client"
 	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	workspacecmd "github.com/kcp-dev/kcp/pkg/virtual/framework/cmd"
 	workspacebuilder "github.com/kcp-dev/kcp/pkg/virtual/workspaces/builder"
@@ -193,12 +194,7 @@ func (kc *KubeConfig) UseWorkspace(ctx context.Context, opts *Options, workspace
 		}
 	}
 
-	workspaceKubeConfigBytes, err := tenancyClient.TenancyV1beta1().RESTClient().Get().Resource("workspaces").SubResource("kubeconfig").Name(workspaceName).Do(ctx).Raw()
-	if err != nil {
-		return err
-	}
-
-	workspaceConfig, err := clientcmd.Load(workspaceKubeConfigBytes)
+	workspaceConfig, err := client.WorkspaceKubeconfig(ctx, tenancyClient, workspaceName)
 	if err != nil {
 		return err
 	}
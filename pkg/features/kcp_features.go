@@ -18,6 +18,7 @@ package features
 
 import (
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	genericfeatures "k8s.io/apiserver/pkg/features"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/component-base/featuregate"
@@ -51,3 +52,15 @@ var defaultGenericControlPlaneFeatureGates = map[featuregate.Feature]featuregate
 	genericfeatures.APIPriorityAndFairness:  {Default: true, PreRelease: featuregate.Beta},
 	genericfeatures.WarningHeaders:          {Default: true, PreRelease: featuregate.GA, LockToDefault: true}, // remove in 1.24
 }
+
+// WorkspaceFeatureGates returns the names of the feature gates of the server which can be set per workspace,
+// i.e. the known feature gates which are not locked to their default.
+func WorkspaceFeatureGates() sets.String {
+	gates := sets.NewString()
+	for name, spec := range utilfeature.DefaultMutableFeatureGate.GetAll() {
+		if !spec.LockToDefault {
+			gates.Insert(string(name))
+		}
+	}
+	return gates
+}
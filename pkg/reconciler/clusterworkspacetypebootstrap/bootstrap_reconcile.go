@@ -35,7 +35,7 @@ func (c *controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing {
 		return nil
 	}
-	if workspace.Spec.Type != c.workspaceType {
+	if !strings.EqualFold(workspace.Spec.Type, c.workspaceType) {
 		return nil
 	}
 
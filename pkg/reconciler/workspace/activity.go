@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+)
+
+// ActivitySource tells when a workspace was last used. The workspace scheduler probes it periodically and
+// records the result in status.lastActivityTime of the ClusterWorkspaces.
+type ActivitySource interface {
+	// LastActivity returns the time of the last activity in the given logical cluster, or the zero time if
+	// none is known.
+	LastActivity(ctx context.Context, clusterName string) (time.Time, error)
+}
+
+// RequestActivityRecorder is an ActivitySource fed with the requests served by kcp.
+// The activity is kept in memory, i.e. it is lost on restart and only covers the requests of this shard.
+type RequestActivityRecorder struct {
+	clock clock.Clock
+
+	lock         sync.RWMutex
+	lastActivity map[string]time.Time
+}
+
+var _ ActivitySource = &RequestActivityRecorder{}
+
+// NewRequestActivityRecorder returns an empty RequestActivityRecorder.
+func NewRequestActivityRecorder() *RequestActivityRecorder {
+	return &RequestActivityRecorder{
+		clock:        clock.RealClock{},
+		lastActivity: map[string]time.Time{},
+	}
+}
+
+// Record marks the given logical cluster as active now.
+func (r *RequestActivityRecorder) Record(clusterName string) {
+	now := r.clock.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.lastActivity[clusterName] = now
+}
+
+func (r *RequestActivityRecorder) LastActivity(_ context.Context, clusterName string) (time.Time, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.lastActivity[clusterName], nil
+}
+
+// recordActivity copies the last activity of ready workspaces from the activity source into their status,
+// and queues them for the next probe.
+func (c *Controller) recordActivity(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	if c.activitySource == nil || workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return nil
+	}
+
+	logicalCluster, err := tenancyhelper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		// nolint: nilerr
+		return nil // there cannot be any activity in a logical cluster we cannot even name
+	}
+	var last time.Time
+	if err := c.shardBreakers.call(ctx, workspace.Status.Location.Current, func(ctx context.Context) (err error) {
+		last, err = c.activitySource.LastActivity(ctx, logicalCluster)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	// the status has a precision of seconds, only update it when the activity is newer than that
+	if last = last.UTC().Truncate(time.Second); !last.IsZero() {
+		if previous := workspace.Status.LastActivityTime; previous == nil || last.After(previous.Time) {
+			logr.FromContextOrDiscard(ctx).V(4).Info("Recording workspace activity", "lastActivityTime", last)
+			workspace.Status.LastActivityTime = &metav1.Time{Time: last}
+		}
+	}
+
+	c.enqueueAfter(workspace, c.activityProbeInterval)
+	return nil
+}
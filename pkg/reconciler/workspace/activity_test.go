@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcileActivity(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	fakeClock := clocktesting.NewFakeClock(time.Date(2022, 3, 1, 12, 0, 0, 500, time.UTC))
+	recorder := NewRequestActivityRecorder()
+	recorder.clock = fakeClock
+	c.activitySource = recorder
+	c.activityProbeInterval = time.Minute
+	queue := &delayRecordingQueue{delays: map[interface{}]time.Duration{}}
+	c.queue = queue
+
+	workspace := newWorkspace("steve")
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	workspace.Status.Location.Current = "boston"
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Nil(t, workspace.Status.LastActivityTime, "no activity was recorded yet")
+	require.Equal(t, time.Minute, queue.delays[key], "expected the workspace to be queued for the next probe")
+
+	recorder.Record("org:other")
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Nil(t, workspace.Status.LastActivityTime, "the activity of other workspaces should be ignored")
+
+	recorder.Record("org:steve")
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.NotNil(t, workspace.Status.LastActivityTime)
+	require.Equal(t, time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC), workspace.Status.LastActivityTime.Time.UTC())
+
+	fakeClock.Step(time.Hour)
+	recorder.Record("org:steve")
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, time.Date(2022, 3, 1, 13, 0, 0, 0, time.UTC), workspace.Status.LastActivityTime.Time.UTC())
+}
+
+func TestReconcileActivityNotReady(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	recorder := NewRequestActivityRecorder()
+	c.activitySource = recorder
+	c.activityProbeInterval = time.Minute
+	queue := &delayRecordingQueue{delays: map[interface{}]time.Duration{}}
+	c.queue = queue
+
+	workspace := newWorkspace("steve")
+	recorder.Record("org:steve")
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Nil(t, workspace.Status.LastActivityTime, "the activity should only be tracked for ready workspaces")
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// shardUnavailableError is returned for calls to a workspace shard short-circuited by its circuit breaker.
+type shardUnavailableError struct {
+	shard string
+	until time.Time
+}
+
+func (e *shardUnavailableError) Error() string {
+	return fmt.Sprintf("calls to workspace shard %q are short-circuited until %s", e.shard, e.until.UTC().Format(time.RFC3339))
+}
+
+// circuitBreakers guard the calls of the workspace scheduler to workspace shards, such that a flaky shard cannot
+// stall the queue. Every call is bounded by the call timeout. After failureThreshold consecutive failed calls to a
+// shard, the circuit of the shard opens: further calls fail immediately for the cooldown. Then a single call is let
+// through, which closes the circuit if it succeeds and opens it again for another cooldown otherwise.
+//
+// A nil *circuitBreakers lets all calls through without timeout.
+type circuitBreakers struct {
+	clock            clock.Clock
+	failureThreshold int
+	cooldown         time.Duration
+	callTimeout      time.Duration
+
+	// onChange is called with the name of the shard whose circuit opened or closed.
+	onChange func(shard string)
+
+	lock     sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// circuitBreaker is the state of the circuit of a single shard.
+type circuitBreaker struct {
+	failures  int
+	openUntil time.Time
+	// probing is set while the call let through after the cooldown is in flight.
+	probing bool
+}
+
+// newCircuitBreakers returns the circuit breakers of the shards, or nil if the failure threshold is not positive.
+func newCircuitBreakers(clock clock.Clock, failureThreshold int, cooldown, callTimeout time.Duration, onChange func(shard string)) *circuitBreakers {
+	if failureThreshold <= 0 {
+		return nil
+	}
+	return &circuitBreakers{
+		clock:            clock,
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		callTimeout:      callTimeout,
+		onChange:         onChange,
+		breakers:         map[string]*circuitBreaker{},
+	}
+}
+
+// call calls fn with a context bounded by the call timeout, unless the circuit of the shard is open.
+func (b *circuitBreakers) call(ctx context.Context, shard string, fn func(ctx context.Context) error) error {
+	if b == nil {
+		return fn(ctx)
+	}
+	if err := b.admit(shard); err != nil {
+		return err
+	}
+
+	if b.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.callTimeout)
+		defer cancel()
+	}
+	err := fn(ctx)
+	b.record(shard, err)
+	return err
+}
+
+// admit returns a shardUnavailableError if the circuit of the shard is open, or if the call after the cooldown
+// is already in flight.
+func (b *circuitBreakers) admit(shard string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	breaker, found := b.breakers[shard]
+	if !found || breaker.failures < b.failureThreshold {
+		return nil
+	}
+	if now := b.clock.Now(); now.Before(breaker.openUntil) || breaker.probing {
+		return &shardUnavailableError{shard: shard, until: breaker.openUntil}
+	}
+	breaker.probing = true
+	return nil
+}
+
+// record counts the outcome of a call to the shard and opens or closes its circuit accordingly.
+func (b *circuitBreakers) record(shard string, err error) {
+	b.lock.Lock()
+	breaker, found := b.breakers[shard]
+	if !found {
+		breaker = &circuitBreaker{}
+		b.breakers[shard] = breaker
+	}
+	wasOpen := breaker.failures >= b.failureThreshold
+	breaker.probing = false
+	if err == nil {
+		delete(b.breakers, shard)
+	} else {
+		breaker.failures++
+		if breaker.failures >= b.failureThreshold {
+			breaker.openUntil = b.clock.Now().Add(b.cooldown)
+		}
+	}
+	isOpen := err != nil && breaker.failures >= b.failureThreshold
+	b.lock.Unlock()
+
+	if wasOpen != isOpen && b.onChange != nil {
+		b.onChange(shard)
+	}
+}
+
+// isOpen returns whether the circuit of the shard is open, including while the call after the cooldown is tried.
+func (b *circuitBreakers) isOpen(shard string) bool {
+	if b == nil {
+		return false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	breaker, found := b.breakers[shard]
+	return found && breaker.failures >= b.failureThreshold
+}
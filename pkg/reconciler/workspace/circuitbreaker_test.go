@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestCircuitBreakers(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	var changed []string
+	breakers := newCircuitBreakers(fakeClock, 3, time.Minute, time.Second, func(shard string) { changed = append(changed, shard) })
+
+	ctx := context.Background()
+	calls := 0
+	failing := func(ctx context.Context) error { calls++; return errors.New("connection refused") }
+	succeeding := func(ctx context.Context) error { calls++; return nil }
+
+	for i := 0; i < 2; i++ {
+		require.EqualError(t, breakers.call(ctx, "boston", failing), "connection refused")
+	}
+	require.False(t, breakers.isOpen("boston"), "the circuit should stay closed below the threshold")
+	require.NoError(t, breakers.call(ctx, "boston", succeeding), "a success should reset the failures")
+	for i := 0; i < 3; i++ {
+		require.EqualError(t, breakers.call(ctx, "boston", failing), "connection refused")
+	}
+	require.True(t, breakers.isOpen("boston"))
+	require.Equal(t, []string{"boston"}, changed)
+	require.Equal(t, 6, calls)
+
+	err := breakers.call(ctx, "boston", succeeding)
+	require.EqualError(t, err, `calls to workspace shard "boston" are short-circuited until 2022-03-01T12:01:00Z`)
+	require.Equal(t, 6, calls, "calls should be short-circuited while the circuit is open")
+	require.NoError(t, breakers.call(ctx, "paris", succeeding), "other shards should not be affected")
+
+	fakeClock.Step(time.Minute)
+	require.Error(t, breakers.call(ctx, "boston", failing), "a call should be tried after the cooldown")
+	require.Equal(t, 8, calls)
+	require.Error(t, breakers.call(ctx, "boston", succeeding), "a failed try should open the circuit for another cooldown")
+	require.Equal(t, 8, calls)
+
+	fakeClock.Step(time.Minute)
+	require.NoError(t, breakers.call(ctx, "boston", succeeding))
+	require.False(t, breakers.isOpen("boston"), "a successful try should close the circuit")
+	require.Equal(t, []string{"boston", "boston"}, changed)
+	require.NoError(t, breakers.call(ctx, "boston", succeeding))
+}
+
+func TestCircuitBreakersBoundCalls(t *testing.T) {
+	breakers := newCircuitBreakers(clocktesting.NewFakeClock(time.Now()), 1, time.Minute, time.Millisecond, nil)
+	err := breakers.call(context.Background(), "boston", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.True(t, breakers.isOpen("boston"), "a hanging call should count as failure")
+}
+
+func TestCircuitBreakersDisabled(t *testing.T) {
+	breakers := newCircuitBreakers(clocktesting.NewFakeClock(time.Now()), 0, time.Minute, time.Second, nil)
+	require.Nil(t, breakers)
+	for i := 0; i < 10; i++ {
+		require.Error(t, breakers.call(context.Background(), "boston", func(context.Context) error { return errors.New("connection refused") }))
+	}
+	require.False(t, breakers.isOpen("boston"))
+}
+
+func TestProcessShardSurfacesOpenCircuit(t *testing.T) {
+	shard := newShard("boston", "https://boston.kcp.dev")
+	kcpClient := kcpfake.NewSimpleClientset(shard)
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{shard})
+	c.kcpClient = fakeClusterClient{kcpClient}
+	c.shardBreakers = newCircuitBreakers(c.clock, 1, time.Minute, time.Second, nil)
+
+	ctx := context.Background()
+	require.Error(t, c.shardBreakers.call(ctx, "boston", func(context.Context) error { return errors.New("connection refused") }))
+	require.NoError(t, c.processShard(ctx, "boston"))
+	updated, err := kcpClient.TenancyV1alpha1().WorkspaceShards().Get(ctx, "boston", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, conditions.IsTrue(updated, tenancyv1alpha1.ShardUnavailable))
+	require.Equal(t, tenancyv1alpha1.ShardUnavailableReasonCircuitOpen, conditions.GetReason(updated, tenancyv1alpha1.ShardUnavailable))
+	require.True(t, conditions.IsTrue(updated, tenancyv1alpha1.WorkspaceShardCredentialsValid), "other conditions should be kept")
+}
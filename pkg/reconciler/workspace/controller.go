@@ -22,24 +22,34 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
-	"k8s.io/klog/v2"
+	"k8s.io/klog/v2/klogr"
+	"k8s.io/utils/clock"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
@@ -53,28 +63,111 @@ import (
 const (
 	currentShardIndex  = "shard"
 	unschedulableIndex = "unschedulable"
+	clusterNameIndex   = "clusterName"
 	controllerName     = "workspace"
+
+	// shardDrainingReason is reported for draining shards skipped when scheduling a workspace.
+	shardDrainingReason = "Draining"
+	// shardCordonedReason is reported for cordoned shards skipped when scheduling a workspace.
+	shardCordonedReason = "SchedulingDisabled"
+
+	// maxDeletionDepth bounds how deep in the workspace hierarchy child ClusterWorkspaces are
+	// removed on deletion. Deeper workspaces are released without cleaning up their content.
+	maxDeletionDepth = 5
 )
 
 func NewController(
 	kcpClient kcpclient.ClusterInterface,
+	kubeClient kubernetes.ClusterInterface,
 	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
 	rootWorkspaceShardInformer tenancyinformer.WorkspaceShardInformer,
+	workspaceTypeInformer tenancyinformer.ClusterWorkspaceTypeInformer,
+	recorder record.EventRecorder,
+	ownerChecker OwnerChecker,
+	activitySource ActivitySource,
+	options Options,
 ) (*Controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	registerMetrics()
+
+	if options.EnableOwnerGC && ownerChecker == nil {
+		return nil, fmt.Errorf("the workspace owner garbage collection requires an owner checker")
+	}
+	if !options.EnableOwnerGC {
+		ownerChecker = nil
+	}
+	if options.ActivityProbeInterval > 0 && activitySource == nil {
+		return nil, fmt.Errorf("the workspace activity tracking requires an activity source")
+	}
+	if options.ActivityProbeInterval <= 0 {
+		activitySource = nil
+	}
+
+	shardSelector, err := newShardSelector(options.ShardSelectionStrategy)
+	if err != nil {
+		return nil, err
+	}
+	defaultQuota, err := parseResourceList(options.DefaultWorkspaceQuota)
+	if err != nil {
+		return nil, err
+	}
+
+	var leaderElection *leaderelection.LeaderElectionConfig
+	if options.LeaderElect {
+		if leaderElection, err = newLeaderElectionConfig(kubeClient.Cluster(tenancyhelper.RootCluster).CoordinationV1(), options); err != nil {
+			return nil, err
+		}
+	}
+
+	queue := workqueue.NewNamedRateLimitingQueue(newRateLimiter(options.BaseBackoff, options.MaxBackoff), controllerName)
+	shardQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName+"-shards")
 
 	c := &Controller{
 		queue:                     queue,
+		shardQueue:                shardQueue,
+		migrationQueue:            newMigrationQueue(options.MigrationQPS),
+		shardSelector:             shardSelector,
 		kcpClient:                 kcpClient,
+		kubeClient:                kubeClient,
 		workspaceIndexer:          workspaceInformer.Informer().GetIndexer(),
 		workspaceLister:           workspaceInformer.Lister(),
 		rootWorkspaceShardIndexer: rootWorkspaceShardInformer.Informer().GetIndexer(),
 		rootWorkspaceShardLister:  rootWorkspaceShardInformer.Lister(),
+		workspaceTypeLister:       workspaceTypeInformer.Lister(),
+		recorder:                  recorder,
+		defaultWorkspaceType:      options.DefaultWorkspaceType,
+		externalShardURLTemplate:  options.ExternalShardURLTemplate,
+		defaultQuota:              defaultQuota,
+		initializationTimeout:     options.InitializationTimeout,
+		ownerChecker:              ownerChecker,
+		ownerGCGracePeriod:        options.OwnerGCGracePeriod,
+		activitySource:            activitySource,
+		activityProbeInterval:     options.ActivityProbeInterval,
+		resyncJitter:              options.ResyncJitter,
+		leaderElection:            leaderElection,
+		clock:                     clock.RealClock{},
+		logger:                    klogr.NewWithOptions(klogr.WithFormat(klogr.FormatKlog)).WithName(controllerName),
 	}
+	c.shardBreakers = newCircuitBreakers(c.clock, options.ShardCircuitBreakerFailures, options.ShardCircuitBreakerCooldown, options.ShardCallTimeout, func(shard string) {
+		// the shard queue surfaces the state of the circuit as ShardUnavailable condition
+		c.shardQueue.Add(shard)
+	})
 
 	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
-		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		AddFunc: func(obj interface{}) {
+			c.enqueue(obj)
+			c.enqueueShardOf(obj)
+			c.enqueueMigration(obj)
+		},
+		UpdateFunc: func(old, obj interface{}) {
+			c.enqueueUpdated(old, obj)
+			c.enqueueShardOf(old)
+			c.enqueueShardOf(obj)
+			c.enqueueMigration(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueParent(obj)
+			c.enqueueShardOf(obj)
+		},
 	})
 	if err := c.workspaceIndexer.AddIndexers(map[string]cache.IndexFunc{
 		currentShardIndex: func(obj interface{}) ([]string, error) {
@@ -91,30 +184,104 @@ func NewController(
 			}
 			return []string{}, nil
 		},
+		clusterNameIndex: func(obj interface{}) ([]string, error) {
+			if workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace); ok {
+				return []string{workspace.ClusterName}, nil
+			}
+			return []string{}, nil
+		},
 	}); err != nil {
 		return nil, fmt.Errorf("failed to add indexer for ClusterWorkspace: %w", err)
 	}
 
 	rootWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueUpsertedShard(obj, "add") },
-		UpdateFunc: func(obj, _ interface{}) { c.enqueueUpsertedShard(obj, "update") },
+		AddFunc: func(obj interface{}) {
+			c.enqueueUpsertedShard(obj, "add")
+			c.enqueueWorkspacesOfDrainingShard(nil, obj)
+		},
+		UpdateFunc: func(old, obj interface{}) {
+			c.enqueueUpsertedShard(obj, "update")
+			c.enqueueWorkspacesOfChangedShard(old, obj)
+			c.enqueueWorkspacesOfDrainingShard(old, obj)
+		},
 		DeleteFunc: func(obj interface{}) { c.enqueueDeletedShard(obj) },
 	})
 
+	// only the existence of a type matters, its changes don't
+	workspaceTypeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkspacesOfType(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueWorkspacesOfType(obj) },
+	})
+
 	return c, nil
 }
 
+// newRateLimiter returns the rate limiter of the workspace queue. It is workqueue.DefaultControllerRateLimiter
+// with a configurable per-item exponential backoff.
+func newRateLimiter(baseBackoff, maxBackoff time.Duration) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseBackoff, maxBackoff),
+		// 10 qps, 100 bucket size, only for retry speed, not the overall rate
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
 // Controller watches Workspaces and WorkspaceShards in order to make sure every ClusterWorkspace
 // is scheduled to a valid WorkspaceShard.
 type Controller struct {
 	queue workqueue.RateLimitingInterface
+	// shardQueue holds the names of root WorkspaceShards whose status.currentWorkspaces has to be recomputed.
+	shardQueue workqueue.RateLimitingInterface
+	// migrationQueue holds the keys of the ClusterWorkspaces to move off draining or deleted shards.
+	migrationQueue workqueue.RateLimitingInterface
 
 	kcpClient        kcpclient.ClusterInterface
+	kubeClient       kubernetes.ClusterInterface
 	workspaceIndexer cache.Indexer
 	workspaceLister  tenancylister.ClusterWorkspaceLister
 
 	rootWorkspaceShardIndexer cache.Indexer
 	rootWorkspaceShardLister  tenancylister.WorkspaceShardLister
+
+	workspaceTypeLister tenancylister.ClusterWorkspaceTypeLister
+	// defaultWorkspaceType is the type of workspaces without spec.type. It is valid without a ClusterWorkspaceType.
+	defaultWorkspaceType string
+
+	recorder record.EventRecorder
+
+	// shardSelector picks the shard among the valid ones to schedule or move a workspace onto.
+	shardSelector ShardSelector
+
+	// externalShardURLTemplate, if set, is the template of the shard URLs written into status.baseURL.
+	externalShardURLTemplate string
+
+	// defaultQuota, if not empty, holds the limits of the ResourceQuota created in the default namespace
+	// of new workspaces.
+	defaultQuota corev1.ResourceList
+
+	// initializationTimeout, if positive, is the time after which a workspace still initializing fails.
+	initializationTimeout time.Duration
+	// ownerChecker, if set, tells whether the owners of workspaces are still known. Workspaces whose owner
+	// is missing for ownerGCGracePeriod are deleted.
+	ownerChecker       OwnerChecker
+	ownerGCGracePeriod time.Duration
+	// activitySource, if set, is probed every activityProbeInterval for the last activity of ready workspaces.
+	activitySource        ActivitySource
+	activityProbeInterval time.Duration
+	clock                 clock.Clock
+
+	// shardBreakers, if set, guard the calls to the shards the workspaces are scheduled onto.
+	shardBreakers *circuitBreakers
+
+	// resyncJitter, if positive, bounds the random delay of the reconciliations triggered by informer resyncs,
+	// which otherwise queue every workspace at once.
+	resyncJitter time.Duration
+	// leaderElection, if set, is the election between the replicas of the scheduler. Only the leader processes
+	// the queues, the others keep them filled by their informers to take over quickly.
+	leaderElection *leaderelection.LeaderElectionConfig
+
+	// logger is the base of the loggers of the controller. Reconciliations derive theirs with reconcileLogger.
+	logger logr.Logger
 }
 
 func (c *Controller) enqueue(obj interface{}) {
@@ -123,8 +290,78 @@ func (c *Controller) enqueue(obj interface{}) {
 		runtime.HandleError(err)
 		return
 	}
-	klog.Infof("Queueing workspace %q", key)
+	c.logger.V(4).Info("Queueing workspace", "key", key)
 	c.queue.Add(key)
+	workqueueDepth.Set(float64(c.queue.Len()))
+}
+
+// enqueueAfter queues the given workspace once the given duration has passed.
+func (c *Controller) enqueueAfter(obj interface{}, duration time.Duration) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.logger.V(2).Info("Queueing workspace later", "key", key, "after", duration)
+	c.queue.AddAfter(key, duration)
+}
+
+// enqueueUpdated queues an updated workspace. Workspaces seen again on an informer resync are queued after
+// a random delay bounded by resyncJitter, such that they are not all reconciled at the same time.
+func (c *Controller) enqueueUpdated(old, obj interface{}) {
+	oldMeta, oldErr := meta.Accessor(old)
+	newMeta, newErr := meta.Accessor(obj)
+	if c.resyncJitter <= 0 || oldErr != nil || newErr != nil || oldMeta.GetResourceVersion() != newMeta.GetResourceVersion() {
+		c.enqueue(obj)
+		return
+	}
+	c.enqueueAfter(obj, time.Duration(rand.Int63nRange(0, int64(c.resyncJitter))))
+}
+
+// enqueueParent queues the ClusterWorkspace owning the logical cluster a deleted ClusterWorkspace
+// lived in, such that a pending cleanup of the parent can make progress.
+func (c *Controller) enqueueParent(obj interface{}) {
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			c.logger.V(2).Info("Couldn't get object from tombstone", "object", obj)
+			return
+		}
+		workspace, ok = tombstone.Obj.(*tenancyv1alpha1.ClusterWorkspace)
+		if !ok {
+			c.logger.V(2).Info("Tombstone contained object that is not a ClusterWorkspace", "object", obj)
+			return
+		}
+	}
+	if workspace.ClusterName == tenancyhelper.RootCluster {
+		return
+	}
+	parentClusterName, err := tenancyhelper.ParentClusterName(workspace.ClusterName)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	_, parentName, err := tenancyhelper.ParseLogicalClusterName(workspace.ClusterName)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	key := clusters.ToClusterAwareKey(parentClusterName, parentName)
+	c.logger.Info("Queueing parent workspace of deleted workspace", "key", key, "clusterWorkspace", workspace.ClusterName+"|"+workspace.Name)
+	c.queue.Add(key)
+}
+
+// enqueueShardOf queues the shard the given ClusterWorkspace is scheduled onto for recounting its workspaces.
+func (c *Controller) enqueueShardOf(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok || workspace.Status.Location.Current == "" {
+		return
+	}
+	c.shardQueue.Add(workspace.Status.Location.Current)
 }
 
 func (c *Controller) enqueueUpsertedShard(obj interface{}, verb string) {
@@ -133,7 +370,7 @@ func (c *Controller) enqueueUpsertedShard(obj interface{}, verb string) {
 		runtime.HandleError(fmt.Errorf("got %T when handling added WorkspaceShard", obj))
 		return
 	}
-	klog.Infof("Handling %sed shard %q", verb, shard.Name)
+	c.logger.Info("Handling "+verb+"ed shard", "shard", shard.Name)
 	workspaces, err := c.workspaceIndexer.ByIndex(unschedulableIndex, "true")
 	if err != nil {
 		runtime.HandleError(err)
@@ -145,7 +382,41 @@ func (c *Controller) enqueueUpsertedShard(obj interface{}, verb string) {
 			runtime.HandleError(err)
 			return
 		}
-		klog.Infof("Queuing unschedulable workspace %q", key)
+		c.logger.Info("Queueing unschedulable workspace", "key", key)
+		c.queue.Add(key)
+	}
+}
+
+// enqueueWorkspacesOfChangedShard queues the workspaces scheduled onto the given shard
+// if its validity changed, e.g. when it became unreachable, or if its address changed.
+func (c *Controller) enqueueWorkspacesOfChangedShard(old, obj interface{}) {
+	oldShard, ok := old.(*tenancyv1alpha1.WorkspaceShard)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("got %T when handling updated WorkspaceShard", old))
+		return
+	}
+	shard, ok := obj.(*tenancyv1alpha1.WorkspaceShard)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("got %T when handling updated WorkspaceShard", obj))
+		return
+	}
+	oldValid, oldReason, _ := isValidShard(oldShard)
+	valid, reason, _ := isValidShard(shard)
+	if oldValid == valid && oldReason == reason && equality.Semantic.DeepEqual(oldShard.Status.ConnectionInfo, shard.Status.ConnectionInfo) {
+		return
+	}
+	workspaces, err := c.workspaceIndexer.ByIndex(currentShardIndex, shard.Name)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, workspace := range workspaces {
+		key, err := cache.MetaNamespaceKeyFunc(workspace)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		c.logger.Info("Queueing workspace scheduled onto changed shard", "key", key, "shard", shard.Name)
 		c.queue.Add(key)
 	}
 }
@@ -155,16 +426,16 @@ func (c *Controller) enqueueDeletedShard(obj interface{}) {
 	if !ok {
 		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
 		if !ok {
-			klog.V(2).Infof("Couldn't get object from tombstone %#v", obj)
+			c.logger.V(2).Info("Couldn't get object from tombstone", "object", obj)
 			return
 		}
 		shard, ok = tombstone.Obj.(*tenancyv1alpha1.WorkspaceShard)
 		if !ok {
-			klog.V(2).Infof("Tombstone contained object that is not a WorkspaceShard: %#v", obj)
+			c.logger.V(2).Info("Tombstone contained object that is not a WorkspaceShard", "object", obj)
 			return
 		}
 	}
-	klog.Infof("handling removed shard %q", shard.Name)
+	c.logger.Info("Handling removed shard", "shard", shard.Name)
 	workspaces, err := c.workspaceIndexer.ByIndex(currentShardIndex, shard.Name)
 	if err != nil {
 		runtime.HandleError(err)
@@ -176,23 +447,37 @@ func (c *Controller) enqueueDeletedShard(obj interface{}) {
 			runtime.HandleError(err)
 			return
 		}
-		klog.Infof("queuing orphaned workspace %q", key)
+		c.logger.Info("Queueing orphaned workspace", "key", key, "shard", shard.Name)
 		c.queue.Add(key)
+		c.migrationQueue.AddRateLimited(key)
 	}
 }
 
 func (c *Controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
 	defer c.queue.ShutDown()
+	defer c.shardQueue.ShutDown()
+	defer c.migrationQueue.ShutDown()
 
-	klog.Info("Starting ClusterWorkspace controller")
-	defer klog.Info("Shutting down ClusterWorkspace controller")
+	c.logger.Info("Starting ClusterWorkspace controller")
+	defer c.logger.Info("Shutting down ClusterWorkspace controller")
 
+	if c.leaderElection == nil {
+		c.startWorkers(ctx, numThreads)
+		<-ctx.Done()
+		return
+	}
+	// campaign again after losing the leadership, until shutdown
+	wait.UntilWithContext(ctx, func(ctx context.Context) { c.runLeaderElection(ctx, numThreads) }, leaderElectionRetryPeriod)
+}
+
+// startWorkers starts the workers of the queues. They stop when the given context is done.
+func (c *Controller) startWorkers(ctx context.Context, numThreads int) {
 	for i := 0; i < numThreads; i++ {
 		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
 	}
-
-	<-ctx.Done()
+	go wait.Until(func() { c.startShardWorker(ctx) }, time.Second, ctx.Done())
+	go wait.Until(func() { c.startMigrationWorker(ctx) }, time.Second, ctx.Done())
 }
 
 func (c *Controller) startWorker(ctx context.Context) {
@@ -207,26 +492,118 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 		return false
 	}
 	key := k.(string)
+	workqueueDepth.Set(float64(c.queue.Len()))
+	if ctx.Err() != nil {
+		// the leadership was lost while waiting, leave the workspace to the next leader
+		c.queue.Done(key)
+		c.queue.Add(key)
+		return false
+	}
 
-	klog.Infof("processing key %q", key)
+	c.logger.V(4).Info("Processing workspace", "key", key)
 
 	// No matter what, tell the queue we're done with this key, to unblock
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, key); err != nil {
+	start := time.Now()
+	err := c.process(ctx, key)
+	reconcileDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
 		c.queue.AddRateLimited(key)
+		workqueueRetries.Inc()
 		return true
 	}
 	c.queue.Forget(key)
 	return true
 }
 
+func (c *Controller) startShardWorker(ctx context.Context) {
+	for c.processNextShard(ctx) {
+	}
+}
+
+func (c *Controller) processNextShard(ctx context.Context) bool {
+	k, quit := c.shardQueue.Get()
+	if quit {
+		return false
+	}
+	name := k.(string)
+	if ctx.Err() != nil {
+		// the leadership was lost while waiting, leave the shard to the next leader
+		c.shardQueue.Done(name)
+		c.shardQueue.Add(name)
+		return false
+	}
+	defer c.shardQueue.Done(name)
+
+	if err := c.processShard(ctx, name); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to count workspaces of shard %q, err: %w", controllerName, name, err))
+		c.shardQueue.AddRateLimited(name)
+		return true
+	}
+	c.shardQueue.Forget(name)
+	return true
+}
+
+// processShard updates status.currentWorkspaces of a root WorkspaceShard, and its ShardUnavailable condition
+// to the state of the circuit breaker of the shard.
+func (c *Controller) processShard(ctx context.Context, name string) error {
+	shard, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyhelper.RootCluster, name))
+	if errors.IsNotFound(err) {
+		return nil // shard deleted before we handled it
+	} else if err != nil {
+		return err
+	}
+
+	load, err := c.shardLoad(name)
+	if err != nil {
+		return err
+	}
+
+	status := map[string]interface{}{}
+	if int(shard.Status.CurrentWorkspaces) != load {
+		status["currentWorkspaces"] = load
+	}
+	updated := shard.DeepCopy()
+	if c.shardBreakers.isOpen(name) {
+		conditions.Set(updated, &conditionsv1alpha1.Condition{
+			Type:     tenancyv1alpha1.ShardUnavailable,
+			Status:   corev1.ConditionTrue,
+			Severity: conditionsv1alpha1.ConditionSeverityWarning,
+			Reason:   tenancyv1alpha1.ShardUnavailableReasonCircuitOpen,
+			Message:  fmt.Sprintf("%d or more calls of the workspace scheduler failed in a row. Calls are short-circuited for %s at a time.", c.shardBreakers.failureThreshold, c.shardBreakers.cooldown),
+		})
+	} else {
+		conditions.Delete(updated, tenancyv1alpha1.ShardUnavailable)
+	}
+	if !equality.Semantic.DeepEqual(shard.Status.Conditions, updated.Status.Conditions) {
+		status["conditions"] = updated.Status.Conditions
+	}
+	if len(status) == 0 {
+		return nil
+	}
+
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			// the conditions are replaced as a whole, do not overwrite those of other controllers
+			"resourceVersion": shard.ResourceVersion,
+		},
+		"status": status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create patch for workspace shard %s: %w", name, err)
+	}
+	c.logger.Info("Updating status of shard", "shard", name, "currentWorkspaces", load, "unavailable", c.shardBreakers.isOpen(name))
+	_, err = c.kcpClient.Cluster(tenancyhelper.RootCluster).TenancyV1alpha1().WorkspaceShards().Patch(ctx, name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
+
 func (c *Controller) process(ctx context.Context, key string) error {
 	namespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
-		klog.Errorf("invalid key: %q: %v", key, err)
+		c.logger.Error(err, "Invalid key", "key", key)
 		return nil
 	}
 	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
@@ -245,7 +622,8 @@ func (c *Controller) process(ctx context.Context, key string) error {
 		return err
 	}
 
-	// If the object being reconciled changed as a result, update it.
+	// If the object being reconciled changed as a result, update it. A reconciliation recomputing the same
+	// URLs and shard assignment, and not changing any condition, does not write at all.
 	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
 		oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
 			Status: previous.Status,
@@ -269,89 +647,119 @@ func (c *Controller) process(ctx context.Context, key string) error {
 		if err != nil {
 			return fmt.Errorf("failed to create patch for workspace %s|%s/%s: %w", clusterName, namespace, name, err)
 		}
-		_, uerr := c.kcpClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		updated, uerr := c.kcpClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		if uerr != nil {
+			return uerr
+		}
+		previous = updated
+	}
+
+	// If the finalizers or annotations changed as a result, update them. This happens after the status update
+	// because removing the last finalizer of a deleted object makes it disappear.
+	finalizersChanged := !equality.Semantic.DeepEqual(previous.Finalizers, obj.Finalizers)
+	annotationsChanged := !equality.Semantic.DeepEqual(previous.Annotations, obj.Annotations)
+	if finalizersChanged || annotationsChanged {
+		metadata := map[string]interface{}{
+			"uid":             previous.UID,
+			"resourceVersion": previous.ResourceVersion,
+		}
+		if finalizersChanged {
+			metadata["finalizers"] = obj.Finalizers
+		}
+		if annotationsChanged {
+			annotations := map[string]interface{}{}
+			for k := range previous.Annotations {
+				if _, found := obj.Annotations[k]; !found {
+					annotations[k] = nil
+				}
+			}
+			for k, v := range obj.Annotations {
+				if previous.Annotations[k] != v {
+					annotations[k] = v
+				}
+			}
+			metadata["annotations"] = annotations
+		}
+		patchBytes, err := json.Marshal(map[string]interface{}{
+			"metadata": metadata,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create metadata patch for workspace %s|%s/%s: %w", clusterName, namespace, name, err)
+		}
+		_, uerr := c.kcpClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
 		return uerr
 	}
 
 	return nil
 }
 
+// reconcileLogger returns the logger of a single reconciliation of the given workspace. All its lines
+// carry the workspace, its org, the shard it is scheduled onto, and an ID telling reconciliations apart.
+func (c *Controller) reconcileLogger(workspace *tenancyv1alpha1.ClusterWorkspace) logr.Logger {
+	return c.logger.WithValues(
+		"reconcileID", rand.String(8),
+		"clusterWorkspace", workspace.ClusterName+"|"+workspace.Name,
+		"org", workspace.ClusterName,
+		"shard", workspace.Status.Location.Current,
+	)
+}
+
 func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	logger := c.reconcileLogger(workspace)
+	ctx = logr.NewContext(ctx, logger)
+
+	if !workspace.DeletionTimestamp.IsZero() {
+		return c.reconcileDeletion(ctx, workspace)
+	}
+	if !sets.NewString(workspace.Finalizers...).Has(tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer) {
+		workspace.Finalizers = append(workspace.Finalizers, tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer)
+	}
+	workspace.Status.Owner = ownerFromAnnotations(workspace)
+
+	typeValid, err := c.resolveType(workspace)
+	if err != nil {
+		return err
+	}
+
 	switch workspace.Status.Phase {
 	case tenancyv1alpha1.ClusterWorkspacePhaseScheduling:
 		// possibly de-schedule while still in scheduling phase
 		if current := workspace.Status.Location.Current; current != "" {
 			// make sure current shard still exists
 			if shard, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyhelper.RootCluster, current)); errors.IsNotFound(err) {
-				klog.Infof("De-scheduling workspace %s|%s from nonexistent shard %q", tenancyhelper.RootCluster, workspace.Name, current)
+				logger.Info("De-scheduling workspace from nonexistent shard")
 				workspace.Status.Location.Current = ""
 				workspace.Status.BaseURL = ""
+				workspace.Status.InternalBaseURL = ""
 			} else if err != nil {
 				return err
-			} else if valid, _, _ := isValidShard(shard); !valid {
-				klog.Infof("De-scheduling workspace %s|%s from invalid shard %q", tenancyhelper.RootCluster, workspace.Name, current)
+			} else if schedulable, _, _ := isSchedulableShard(shard); !schedulable {
+				logger.Info("De-scheduling workspace from unschedulable shard")
 				workspace.Status.Location.Current = ""
 				workspace.Status.BaseURL = ""
+				workspace.Status.InternalBaseURL = ""
 			}
 		}
 
-		if workspace.Status.Location.Current == "" {
-			// find a shard for this workspace, randomly
-			shards, err := c.rootWorkspaceShardLister.List(labels.Everything())
-			if err != nil {
+		// workspaces of a missing type wait for it, see the WorkspaceTypeValid condition
+		if workspace.Status.Location.Current == "" && typeValid {
+			if err := c.schedule(ctx, workspace); err != nil {
 				return err
 			}
+		}
 
-			validShards := make([]*tenancyv1alpha1.WorkspaceShard, 0, len(shards))
-			invalidShards := map[string]struct {
-				reason, message string
-			}{}
-			for _, shard := range shards {
-				if valid, reason, message := isValidShard(shard); valid {
-					validShards = append(validShards, shard)
-				} else {
-					invalidShards[shard.Name] = struct {
-						reason, message string
-					}{
-						reason:  reason,
-						message: message,
-					}
-				}
+	case tenancyv1alpha1.ClusterWorkspacePhaseInitializing, tenancyv1alpha1.ClusterWorkspacePhaseReady, tenancyv1alpha1.ClusterWorkspacePhaseFailed:
+		// the assignment of workspaces orphaned by a deleted shard is cleared by the migration worker
+		if workspace.Status.Location.Current == "" {
+			if err := c.schedule(ctx, workspace); err != nil {
+				return err
 			}
-
-			if len(validShards) > 0 {
-				targetShard := shards[rand.Intn(len(shards))]
-
-				u, err := url.Parse(targetShard.Status.ConnectionInfo.Host)
-				if err != nil {
-					// shouldn't happen since we just checked in isValidShard
-					conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonReasonUnknown, conditionsv1alpha1.ConditionSeverityError, "Invalid connection information on target WorkspaceShard: %v.", err)
-					return err // requeue
-				}
-				logicalCluster, err := tenancyhelper.EncodeLogicalClusterName(workspace)
-				if err != nil {
-					// shouldn't happen since clusterName is supposed to be a valid name
-					conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonReasonUnknown, conditionsv1alpha1.ConditionSeverityError, "Invalid ClusterWorkspace cluster name %q: %v.", workspace.ClusterName, err)
-					return nil // no hope requeue fixes it
-				}
-				u.Path = path.Join(u.Path, targetShard.Status.ConnectionInfo.APIPath, "clusters", logicalCluster)
-
-				workspace.Status.BaseURL = u.String()
-				workspace.Status.Location.Current = targetShard.Name
-
-				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
-				klog.Infof("Scheduled workspace %s|%s to %s|%s", workspace.ClusterName, workspace.Name, targetShard.ClusterName, targetShard.Name)
-			} else {
-				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No available shards to schedule the workspace.")
-				failures := make([]string, 0, len(invalidShards))
-				for name, x := range invalidShards {
-					failures = append(failures, fmt.Sprintf("  %s: reason %q, message %q", name, x.reason, x.message))
-				}
-				klog.Infof("No valid shards found for workspace %s|%s, skipped:\n%s", workspace.ClusterName, workspace.Name, strings.Join(failures, "\n"))
+			if current := workspace.Status.Location.Current; current != "" {
+				logger.Info("Rescheduled orphaned workspace", "targetShard", current)
 			}
+			break
 		}
 
-	case tenancyv1alpha1.ClusterWorkspacePhaseInitializing, tenancyv1alpha1.ClusterWorkspacePhaseReady:
 		// movement can only happen after scheduling
 		if workspace.Status.Location.Target == "" {
 			break
@@ -365,16 +773,23 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 
 		targetShard, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyhelper.RootCluster, target))
 		if errors.IsNotFound(err) {
-			klog.Infof("Cannot move to nonexistent shard %q", tenancyhelper.RootCluster, workspace.Name, target)
+			logger.Info("Cannot move to nonexistent shard", "targetShard", target)
 		} else if err != nil {
 			return err
 		} else if !conditions.IsTrue(targetShard, tenancyv1alpha1.WorkspaceShardCredentialsValid) {
-			klog.Infof("Cannot move to shard %q with invalid credentials", tenancyhelper.RootCluster, workspace.Name, target)
+			logger.Info("Cannot move to shard with invalid credentials", "targetShard", target)
+		} else if baseURL, internalBaseURL, err := c.workspaceURLs(workspace, targetShard); err != nil {
+			logger.Error(err, "Cannot compute the URLs of the workspace on the target shard", "targetShard", target)
+		} else {
+			workspace.Status.BaseURL = baseURL
+			workspace.Status.InternalBaseURL = internalBaseURL
 		}
 
-		klog.Infof("Moving workspace %q to %q", workspace.Name, workspace.Status.Location.Target)
+		logger.Info("Moving workspace", "targetShard", workspace.Status.Location.Target)
 		workspace.Status.Location.Current = workspace.Status.Location.Target
 		workspace.Status.Location.Target = ""
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceMigrating)
+		c.event(workspace, corev1.EventTypeNormal, EventReasonMoved, "Moved from shard %q to shard %q", current, target)
 
 		// TODO: actually handle the RV resolution and double-sided accept we need for movement
 		if len(workspace.Status.Location.History) == 0 {
@@ -399,7 +814,7 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 		}
 	}
 
-	// check scheduled shard. This has no influence on the workspace baseURL or shard assignment. This might be a trigger for
+	// check scheduled shard. This has no influence on the shard assignment. This might be a trigger for
 	// a movement controller in the future (or a human intervention) to move workspaces off a shard.
 	if workspace.Status.Location.Current != "" {
 		if shard, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyhelper.RootCluster, workspace.Status.Location.Current)); errors.IsNotFound(err) {
@@ -408,27 +823,367 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 			return err
 		} else if valid, reason, message := isValidShard(shard); !valid {
 			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceShardValid, reason, conditionsv1alpha1.ConditionSeverityError, message)
+
+			// an explicit refresh also applies to an invalid shard, e.g. unreachable under its previous address
+			if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation]; found {
+				c.refreshURLs(ctx, workspace, shard)
+			}
 		} else {
 			conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceShardValid)
+
+			// keep the URLs in sync with the shard
+			c.refreshURLs(ctx, workspace, shard)
 		}
 	}
+	delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation)
 
 	switch workspace.Status.Phase {
 	case "":
-		workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseScheduling
+		setPhase(logger, workspace, tenancyv1alpha1.ClusterWorkspacePhaseScheduling)
 	case tenancyv1alpha1.ClusterWorkspacePhaseScheduling:
 		if workspace.Status.Location.Current != "" && workspace.Status.BaseURL != "" {
-			workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseInitializing
+			if c.startInitialization(logger, workspace) {
+				if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation]; found {
+					addInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceCloneInitializer)
+				}
+				if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation]; found {
+					addInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer)
+				}
+			}
 		}
 	case tenancyv1alpha1.ClusterWorkspacePhaseInitializing:
 		if len(workspace.Status.Initializers) == 0 {
-			workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+			if err := c.bootstrapQuota(ctx, workspace); err != nil {
+				return err
+			}
+			if err := c.bootstrapInitialMembers(ctx, workspace); err != nil {
+				return err
+			}
+			if err := c.reconcileFeatureGates(ctx, workspace); err != nil {
+				return err
+			}
+			setPhase(logger, workspace, tenancyv1alpha1.ClusterWorkspacePhaseReady)
+		} else {
+			c.checkInitializationTimeout(ctx, workspace)
+		}
+	case tenancyv1alpha1.ClusterWorkspacePhaseReady:
+		if err := c.reconcileFeatureGates(ctx, workspace); err != nil {
+			return err
+		}
+	case tenancyv1alpha1.ClusterWorkspacePhaseFailed:
+		if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceRetryInitializationAnnotation]; found {
+			c.retryInitialization(ctx, workspace)
 		}
 	}
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseFailed {
+		// the retry annotation only applies to failed workspaces
+		delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceRetryInitializationAnnotation)
+	}
 
+	if err := c.recordActivity(ctx, workspace); err != nil {
+		return err
+	}
+
+	if err := c.checkOwner(ctx, workspace); err != nil {
+		return err
+	}
+
+	return c.collectSoftDeleted(ctx, workspace)
+}
+
+// schedule assigns a shard among the schedulable ones matching the shard selector of the workspace to it, and
+// reflects in its conditions and events why none could be found otherwise.
+func (c *Controller) schedule(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	// find a shard for this workspace
+	shards, err := c.rootWorkspaceShardLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	validShards := make([]*tenancyv1alpha1.WorkspaceShard, 0, len(shards))
+	invalidShards := map[string]struct {
+		reason, message string
+	}{}
+	for _, shard := range shards {
+		if schedulable, reason, message := isSchedulableShard(shard); schedulable {
+			validShards = append(validShards, shard)
+		} else {
+			invalidShards[shard.Name] = struct {
+				reason, message string
+			}{
+				reason:  reason,
+				message: message,
+			}
+		}
+	}
+
+	selector, err := shardLabelSelector(workspace)
+	if err != nil {
+		// the annotation is validated on creation, this can only happen for older workspaces
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "Invalid shard selector: %v.", err)
+		return nil // no hope requeue fixes it
+	}
+	matchingShards := make([]*tenancyv1alpha1.WorkspaceShard, 0, len(validShards))
+	for _, shard := range validShards {
+		if selector.Matches(labels.Set(shard.Labels)) {
+			matchingShards = append(matchingShards, shard)
+		}
+	}
+
+	targetShard, err := c.selectShard(matchingShards)
+	if err != nil {
+		return err
+	}
+
+	if len(validShards) > 0 && len(matchingShards) == 0 {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No valid shard matches the shard selector.")
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceShardValid, tenancyv1alpha1.WorkspaceShardValidReasonNoMatchingShard, conditionsv1alpha1.ConditionSeverityError, "None of the %d valid shards matches the shard selector %q.", len(validShards), selector.String())
+		logger.Info("No valid shard matching the shard selector found for workspace", "shardSelector", selector.String(), "validShards", len(validShards))
+		c.event(workspace, corev1.EventTypeWarning, EventReasonUnschedulable, "No valid shard matches the shard selector %q among %d valid shards", selector.String(), len(validShards))
+	} else if targetShard != nil {
+		baseURL, internalBaseURL, err := c.workspaceURLs(workspace, targetShard)
+		if err != nil {
+			// shouldn't happen since we just checked in isValidShard, and the template is validated on startup
+			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonReasonUnknown, conditionsv1alpha1.ConditionSeverityError, "Invalid URL on target WorkspaceShard %q: %v.", targetShard.Name, err)
+			return nil // no hope requeue fixes it
+		}
+
+		workspace.Status.BaseURL = baseURL
+		workspace.Status.InternalBaseURL = internalBaseURL
+		workspace.Status.Location.Current = targetShard.Name
+
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
+		logger.Info("Scheduled workspace", "targetShard", targetShard.Name)
+		c.event(workspace, corev1.EventTypeNormal, EventReasonScheduled, "Scheduled onto shard %q", targetShard.Name)
+		c.event(workspace, corev1.EventTypeNormal, EventReasonBaseURLComputed, "Computed base URL %q on shard %q", workspace.Status.BaseURL, targetShard.Name)
+	} else if len(validShards) > 0 {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "All valid shards are at their capacity.")
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceShardValid, tenancyv1alpha1.WorkspaceShardValidReasonNoCapacity, conditionsv1alpha1.ConditionSeverityError, "No shard with free capacity among %d valid shards.", len(validShards))
+		logger.Info("No shard with free capacity found for workspace", "validShards", len(validShards))
+		c.event(workspace, corev1.EventTypeWarning, EventReasonUnschedulable, "No shard with free capacity among %d valid shards", len(validShards))
+	} else {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No available shards to schedule the workspace.")
+		failures := make([]string, 0, len(invalidShards))
+		for name, x := range invalidShards {
+			failures = append(failures, fmt.Sprintf("%s: reason %q, message %q", name, x.reason, x.message))
+		}
+		sort.Strings(failures)
+		logger.Info("No valid shards found for workspace", "skippedShards", failures)
+		c.event(workspace, corev1.EventTypeWarning, EventReasonUnschedulable, "No valid shard found among %d shards, skipped: %s", len(shards), strings.Join(failures, "; "))
+	}
 	return nil
 }
 
+// reconcileDeletion deletes the child ClusterWorkspaces of a ClusterWorkspace being deleted and removes
+// the cleanup finalizer once no child is left. Every child runs through the same logic itself when deleted,
+// such that the whole tree below the workspace is removed bottom-up. A ClusterWorkspace deleted with the
+// Orphan propagation policy keeps its content: the cleanup finalizer is removed right away.
+func (c *Controller) reconcileDeletion(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	logger := logr.FromContextOrDiscard(ctx)
+	setPhase(logger, workspace, tenancyv1alpha1.ClusterWorkspacePhaseTerminating)
+
+	if !sets.NewString(workspace.Finalizers...).Has(tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer) {
+		return nil
+	}
+
+	if sets.NewString(workspace.Finalizers...).Has(metav1.FinalizerOrphanDependents) {
+		logger.Info("Not removing the content of a workspace deleted with the Orphan propagation policy")
+		removeCleanupFinalizer(workspace)
+		return nil
+	}
+
+	if depth := workspaceDepth(workspace.ClusterName); depth > maxDeletionDepth {
+		logger.Info("Not removing the content of a workspace nested too deeply", "maxDeletionDepth", maxDeletionDepth)
+		removeCleanupFinalizer(workspace)
+		return nil
+	}
+
+	logicalCluster, err := tenancyhelper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		// there cannot be any children in a logical cluster we cannot even name
+		logger.Error(err, "Invalid ClusterWorkspace cluster name, not removing its content")
+		removeCleanupFinalizer(workspace)
+		return nil
+	}
+
+	children, err := c.workspaceIndexer.ByIndex(clusterNameIndex, logicalCluster)
+	if err != nil {
+		return err
+	}
+	if len(children) == 0 {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceDeletionContentRemoved)
+		removeCleanupFinalizer(workspace)
+		logger.Info("Removed content of workspace")
+		return nil
+	}
+
+	for _, obj := range children {
+		child, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+		if !ok || !child.DeletionTimestamp.IsZero() {
+			continue
+		}
+		logger.Info("Deleting child workspace", "child", child.ClusterName+"|"+child.Name)
+		if err := c.kcpClient.Cluster(logicalCluster).TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, child.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceDeletionContentRemoved, tenancyv1alpha1.WorkspaceDeletionContentRemovedReasonChildrenRemaining, conditionsv1alpha1.ConditionSeverityInfo, "Waiting for %d child workspaces to be deleted.", len(children))
+
+	return nil
+}
+
+func removeCleanupFinalizer(workspace *tenancyv1alpha1.ClusterWorkspace) {
+	finalizers := make([]string, 0, len(workspace.Finalizers))
+	for _, f := range workspace.Finalizers {
+		if f != tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	workspace.Finalizers = finalizers
+}
+
+// ownerFromAnnotations returns the owner recorded in the owner annotations of the workspace, or nil if it has none.
+func ownerFromAnnotations(workspace *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspaceOwner {
+	user := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation]
+	if user == "" {
+		return nil
+	}
+	owner := &tenancyv1alpha1.ClusterWorkspaceOwner{User: user}
+	if groups := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation]; groups != "" {
+		owner.Groups = strings.Split(groups, ",")
+	}
+	return owner
+}
+
+// setPhase moves the workspace to the given phase if the transition is legal according to
+// tenancyhelper.IsValidPhaseTransition, and logs and ignores it otherwise. It returns whether
+// the workspace is in the given phase afterwards.
+func setPhase(logger logr.Logger, workspace *tenancyv1alpha1.ClusterWorkspace, phase tenancyv1alpha1.ClusterWorkspacePhaseType) bool {
+	if workspace.Status.Phase == phase {
+		return true
+	}
+	if !tenancyhelper.IsValidPhaseTransition(workspace.Status.Phase, phase) {
+		logger.Info("Rejecting illegal phase transition", "from", workspace.Status.Phase, "to", phase)
+		return false
+	}
+	logger.V(2).Info("Transitioning workspace phase", "from", workspace.Status.Phase, "to", phase)
+	workspace.Status.Phase = phase
+	return true
+}
+
+// addInitializer adds the given initializer to the workspace unless it is already there.
+func addInitializer(workspace *tenancyv1alpha1.ClusterWorkspace, initializer tenancyv1alpha1.ClusterWorkspaceInitializer) {
+	for _, i := range workspace.Status.Initializers {
+		if i == initializer {
+			return
+		}
+	}
+	workspace.Status.Initializers = append(workspace.Status.Initializers, initializer)
+}
+
+// workspaceDepth returns the number of ancestors of the given logical cluster up to the root, counting at
+// most maxDeletionDepth+1 levels.
+func workspaceDepth(clusterName string) int {
+	depth := 0
+	for clusterName != tenancyhelper.RootCluster && depth <= maxDeletionDepth {
+		parent, err := tenancyhelper.ParentClusterName(clusterName)
+		if err != nil {
+			break
+		}
+		clusterName = parent
+		depth++
+	}
+	return depth
+}
+
+// selectShard returns the shard picked by the shard selector among those below their capacity.
+// It returns nil if all shards are at their capacity.
+func (c *Controller) selectShard(shards []*tenancyv1alpha1.WorkspaceShard) (*tenancyv1alpha1.WorkspaceShard, error) {
+	candidates := make([]*tenancyv1alpha1.WorkspaceShard, 0, len(shards))
+	for _, shard := range shards {
+		load, err := c.shardLoad(shard.Name)
+		if err != nil {
+			return nil, err
+		}
+		if shard.Spec.Capacity != nil && load >= int(*shard.Spec.Capacity) {
+			continue
+		}
+		candidates = append(candidates, shard)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	return c.shardSelector.SelectShard(candidates), nil
+}
+
+// shardLabelSelector returns the selector of the shards the workspace can be placed onto, parsed from
+// its shard selector annotation. Without the annotation, every shard matches.
+func shardLabelSelector(workspace *tenancyv1alpha1.ClusterWorkspace) (labels.Selector, error) {
+	value, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation]
+	if !found {
+		return labels.Everything(), nil
+	}
+	return labels.Parse(value)
+}
+
+// shardLoad returns the number of workspaces scheduled onto the given shard.
+func (c *Controller) shardLoad(shardName string) (int, error) {
+	workspaces, err := c.workspaceIndexer.ByIndex(currentShardIndex, shardName)
+	if err != nil {
+		return 0, err
+	}
+	return len(workspaces), nil
+}
+
+// workspaceURLs returns the base URL and the internal base URL of the given workspace on the given shard.
+func (c *Controller) workspaceURLs(workspace *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.WorkspaceShard) (baseURL, internalBaseURL string, err error) {
+	if shard.Status.ConnectionInfo == nil {
+		return "", "", fmt.Errorf("WorkspaceShard %q has no connection info", shard.Name)
+	}
+	u, err := url.Parse(shard.Status.ConnectionInfo.Host)
+	if err != nil {
+		return "", "", err
+	}
+	logicalCluster, err := tenancyhelper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return "", "", err
+	}
+	u.Path = path.Join(u.Path, shard.Status.ConnectionInfo.APIPath, "clusters", logicalCluster)
+	internalBaseURL = u.String()
+
+	if c.externalShardURLTemplate != "" {
+		u, err = externalShardURL(c.externalShardURLTemplate, shard.Name)
+		if err != nil {
+			return "", "", err
+		}
+		u.Path = path.Join(u.Path, "clusters", logicalCluster)
+	}
+	return u.String(), internalBaseURL, nil
+}
+
+// isSchedulableShard returns whether new workspaces can be scheduled onto the given shard,
+// i.e. whether it is valid, not draining and not cordoned.
+func isSchedulableShard(shard *tenancyv1alpha1.WorkspaceShard) (schedulable bool, reason, message string) {
+	if valid, reason, message := isValidShard(shard); !valid {
+		return false, reason, message
+	}
+	if isDrainingShard(shard) {
+		return false, shardDrainingReason, "WorkspaceShard is draining."
+	}
+	if conditions.IsTrue(shard, tenancyv1alpha1.WorkspaceShardSchedulingDisabled) {
+		return false, shardCordonedReason, "WorkspaceShard is cordoned."
+	}
+	return true, "", ""
+}
+
+// isDrainingShard returns whether the workspaces scheduled onto the given shard have to be moved off it,
+// either because of spec.draining or of a true Draining condition.
+func isDrainingShard(shard *tenancyv1alpha1.WorkspaceShard) bool {
+	return shard.Spec.Draining || conditions.IsTrue(shard, tenancyv1alpha1.WorkspaceShardDraining)
+}
+
 func isValidShard(shard *tenancyv1alpha1.WorkspaceShard) (valid bool, reason, message string) {
 	if !conditions.IsTrue(shard, tenancyv1alpha1.WorkspaceShardCredentialsValid) {
 		return false, tenancyv1alpha1.WorkspaceShardValidReasonMissingCredentials, "Invalid connection information on target WorkspaceShard."
@@ -442,5 +1197,9 @@ func isValidShard(shard *tenancyv1alpha1.WorkspaceShard) (valid bool, reason, me
 	if _, err := url.Parse(shard.Status.ConnectionInfo.Host); err != nil {
 		return false, tenancyv1alpha1.WorkspaceShardValidReasonURLInvalid, fmt.Sprintf("Invalid host on target WorkspaceShard: %v.", err)
 	}
+	// shards which have not been health-checked yet are considered reachable
+	if conditions.IsFalse(shard, tenancyv1alpha1.ShardReachable) {
+		return false, tenancyv1alpha1.WorkspaceShardValidReasonUnreachable, fmt.Sprintf("WorkspaceShard is unreachable: %s", conditions.GetMessage(shard, tenancyv1alpha1.ShardReachable))
+	}
 	return true, "", ""
 }
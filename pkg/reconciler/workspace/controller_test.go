@@ -0,0 +1,728 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/utils/clock"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func newShard(name, host string) *tenancyv1alpha1.WorkspaceShard {
+	shard := &tenancyv1alpha1.WorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			ClusterName: tenancyhelper.RootCluster,
+		},
+		Status: tenancyv1alpha1.WorkspaceShardStatus{
+			ConnectionInfo: &tenancyv1alpha1.ConnectionInfo{Host: host},
+		},
+	}
+	conditions.MarkTrue(shard, tenancyv1alpha1.WorkspaceShardCredentialsValid)
+	return shard
+}
+
+func newWorkspace(name string) *tenancyv1alpha1.ClusterWorkspace {
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			ClusterName: "root:org",
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+		},
+	}
+}
+
+func newTestController(t *testing.T, recorder record.EventRecorder, shards []*tenancyv1alpha1.WorkspaceShard, workspaces ...*tenancyv1alpha1.ClusterWorkspace) *Controller {
+	typeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	shardIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, shard := range shards {
+		require.NoError(t, shardIndexer.Add(shard))
+	}
+	workspaceIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		currentShardIndex: func(obj interface{}) ([]string, error) {
+			return []string{obj.(*tenancyv1alpha1.ClusterWorkspace).Status.Location.Current}, nil
+		},
+		clusterNameIndex: func(obj interface{}) ([]string, error) {
+			return []string{obj.(*tenancyv1alpha1.ClusterWorkspace).ClusterName}, nil
+		},
+	})
+	for _, workspace := range workspaces {
+		require.NoError(t, workspaceIndexer.Add(workspace))
+	}
+	return &Controller{
+		workspaceIndexer:          workspaceIndexer,
+		workspaceLister:           tenancylister.NewClusterWorkspaceLister(workspaceIndexer),
+		rootWorkspaceShardIndexer: shardIndexer,
+		rootWorkspaceShardLister:  tenancylister.NewWorkspaceShardLister(shardIndexer),
+		workspaceTypeLister:       tenancylister.NewClusterWorkspaceTypeLister(typeIndexer),
+		defaultWorkspaceType:      "universal",
+		recorder:                  recorder,
+		shardSelector:             NewLeastLoadedShardSelector(),
+		clock:                     clock.RealClock{},
+		logger:                    logr.Discard(),
+	}
+}
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestReconcileEvents(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		shards         []*tenancyv1alpha1.WorkspaceShard
+		expectedEvents []string
+	}{
+		{
+			name:   "scheduled onto the only valid shard",
+			shards: []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")},
+			expectedEvents: []string{
+				`Normal Scheduled Scheduled onto shard "boston"`,
+				`Normal BaseURLComputed Computed base URL "https://boston.kcp.dev/clusters/org:steve" on shard "boston"`,
+			},
+		},
+		{
+			name:   "no valid shard",
+			shards: []*tenancyv1alpha1.WorkspaceShard{{ObjectMeta: metav1.ObjectMeta{Name: "boston", ClusterName: tenancyhelper.RootCluster}}},
+			expectedEvents: []string{
+				`Warning Unschedulable No valid shard found among 1 shards, skipped: boston: reason "MissingShardCredentials"`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			c := newTestController(t, recorder, tc.shards)
+
+			err := c.reconcile(context.Background(), newWorkspace("steve"))
+			require.NoError(t, err)
+
+			events := drainEvents(recorder)
+			require.Len(t, events, len(tc.expectedEvents), "unexpected events: %v", events)
+			for i, expected := range tc.expectedEvents {
+				require.True(t, strings.HasPrefix(events[i], expected), "expected event %q to start with %q", events[i], expected)
+			}
+		})
+	}
+}
+
+func TestSelectShard(t *testing.T) {
+	capacity := func(shard *tenancyv1alpha1.WorkspaceShard, capacity int32) *tenancyv1alpha1.WorkspaceShard {
+		shard.Spec.Capacity = &capacity
+		return shard
+	}
+	loaded := func(shard *tenancyv1alpha1.WorkspaceShard, currentWorkspaces int32) *tenancyv1alpha1.WorkspaceShard {
+		shard.Status.CurrentWorkspaces = currentWorkspaces
+		return shard
+	}
+	scheduledOnto := func(name, shard string) *tenancyv1alpha1.ClusterWorkspace {
+		workspace := newWorkspace(name)
+		workspace.Status.Location.Current = shard
+		return workspace
+	}
+
+	for _, tc := range []struct {
+		name       string
+		shards     []*tenancyv1alpha1.WorkspaceShard
+		workspaces []*tenancyv1alpha1.ClusterWorkspace
+		expected   string
+	}{
+		{
+			name:       "least loaded shard without capacity",
+			shards:     []*tenancyv1alpha1.WorkspaceShard{loaded(newShard("boston", "https://boston.kcp.dev"), 2), loaded(newShard("paris", "https://paris.kcp.dev"), 1)},
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{scheduledOnto("a", "boston"), scheduledOnto("b", "boston"), scheduledOnto("c", "paris")},
+			expected:   "paris",
+		},
+		{
+			name:       "skip full shard even if less loaded",
+			shards:     []*tenancyv1alpha1.WorkspaceShard{loaded(capacity(newShard("boston", "https://boston.kcp.dev"), 1), 1), loaded(newShard("paris", "https://paris.kcp.dev"), 2)},
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{scheduledOnto("a", "boston"), scheduledOnto("b", "paris"), scheduledOnto("c", "paris")},
+			expected:   "paris",
+		},
+		{
+			name:       "all shards full",
+			shards:     []*tenancyv1alpha1.WorkspaceShard{capacity(newShard("boston", "https://boston.kcp.dev"), 1), capacity(newShard("paris", "https://paris.kcp.dev"), 0)},
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{scheduledOnto("a", "boston")},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestController(t, record.NewFakeRecorder(10), tc.shards, tc.workspaces...)
+
+			shard, err := c.selectShard(tc.shards)
+			require.NoError(t, err)
+			if tc.expected == "" {
+				require.Nil(t, shard, "expected no shard")
+				return
+			}
+			require.NotNil(t, shard, "expected a shard")
+			require.Equal(t, tc.expected, shard.Name)
+		})
+	}
+}
+
+func TestReconcileNoCapacity(t *testing.T) {
+	zero := int32(0)
+	shard := newShard("boston", "https://boston.kcp.dev")
+	shard.Spec.Capacity = &zero
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{shard})
+
+	workspace := newWorkspace("steve")
+	err := c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Empty(t, workspace.Status.Location.Current)
+	require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceShardValid))
+	require.Equal(t, tenancyv1alpha1.WorkspaceShardValidReasonNoCapacity, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceShardValid))
+}
+
+func TestReconcileShardSelector(t *testing.T) {
+	boston := newShard("boston", "https://boston.kcp.dev")
+	boston.Labels = map[string]string{"region": "us"}
+	paris := newShard("paris", "https://paris.kcp.dev")
+	paris.Labels = map[string]string{"region": "eu"}
+
+	for _, tc := range []struct {
+		name          string
+		selector      string
+		expectedShard string
+	}{
+		{name: "matching shard", selector: "region=eu", expectedShard: "paris"},
+		{name: "set-based selector", selector: "region in (us)", expectedShard: "boston"},
+		{name: "no matching shard", selector: "region=asia"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{boston, paris})
+
+			workspace := newWorkspace("steve")
+			workspace.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation: tc.selector}
+			require.NoError(t, c.reconcile(context.Background(), workspace))
+			require.Equal(t, tc.expectedShard, workspace.Status.Location.Current)
+			if tc.expectedShard != "" {
+				require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceScheduled))
+				return
+			}
+			require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceScheduled))
+			require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceShardValid))
+			require.Equal(t, tenancyv1alpha1.WorkspaceShardValidReasonNoMatchingShard, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceShardValid))
+			require.Contains(t, conditions.GetMessage(workspace, tenancyv1alpha1.WorkspaceShardValid), "region=asia")
+		})
+	}
+}
+
+func TestReconcileBaseURL(t *testing.T) {
+	for _, tc := range []struct {
+		name                     string
+		externalShardURLTemplate string
+		expectedBaseURL          string
+	}{
+		{
+			name:            "without template, the shard address is used",
+			expectedBaseURL: "https://boston.internal:6443/clusters/org:steve",
+		},
+		{
+			name:                     "with template, the external shard URL is used",
+			externalShardURLTemplate: "https://{shard}.kcp.example.com",
+			expectedBaseURL:          "https://boston.kcp.example.com/clusters/org:steve",
+		},
+		{
+			name:                     "with template with a path",
+			externalShardURLTemplate: "https://kcp.example.com/shards/{shard}",
+			expectedBaseURL:          "https://kcp.example.com/shards/boston/clusters/org:steve",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.internal:6443")})
+			c.externalShardURLTemplate = tc.externalShardURLTemplate
+
+			workspace := newWorkspace("steve")
+			err := c.reconcile(context.Background(), workspace)
+			require.NoError(t, err)
+			require.Equal(t, "boston", workspace.Status.Location.Current)
+			require.Equal(t, tc.expectedBaseURL, workspace.Status.BaseURL)
+			require.Equal(t, "https://boston.internal:6443/clusters/org:steve", workspace.Status.InternalBaseURL)
+		})
+	}
+}
+
+func TestReconcileUnreachableShard(t *testing.T) {
+	unreachable := newShard("boston", "https://boston.kcp.dev")
+	conditions.MarkFalse(unreachable, tenancyv1alpha1.ShardReachable, tenancyv1alpha1.ShardReachableReasonUnhealthy, conditionsv1alpha1.ConditionSeverityError, "Health check failed.")
+	reachable := newShard("paris", "https://paris.kcp.dev")
+	conditions.MarkTrue(reachable, tenancyv1alpha1.ShardReachable)
+
+	scheduled := newWorkspace("bob")
+	scheduled.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	scheduled.Status.Location.Current = "boston"
+	loaded := newWorkspace("alice")
+	loaded.Status.Location.Current = "paris"
+
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{unreachable, reachable}, scheduled, loaded)
+
+	t.Run("new workspaces avoid the unreachable shard even if less loaded", func(t *testing.T) {
+		workspace := newWorkspace("steve")
+		err := c.reconcile(context.Background(), workspace)
+		require.NoError(t, err)
+		require.Equal(t, "paris", workspace.Status.Location.Current)
+	})
+
+	t.Run("workspaces scheduled onto the unreachable shard get an invalid shard condition", func(t *testing.T) {
+		err := c.reconcile(context.Background(), scheduled)
+		require.NoError(t, err)
+		require.Equal(t, "boston", scheduled.Status.Location.Current)
+		require.True(t, conditions.IsFalse(scheduled, tenancyv1alpha1.WorkspaceShardValid))
+		require.Equal(t, tenancyv1alpha1.WorkspaceShardValidReasonUnreachable, conditions.GetReason(scheduled, tenancyv1alpha1.WorkspaceShardValid))
+	})
+}
+
+// fakeClusterClient returns the same fake clientset for every logical cluster.
+type fakeClusterClient struct {
+	*kcpfake.Clientset
+}
+
+func (c fakeClusterClient) Cluster(string) kcpclient.Interface {
+	return c.Clientset
+}
+
+func TestReconcileDrainingShard(t *testing.T) {
+	draining := newShard("boston", "https://boston.kcp.dev")
+	draining.Spec.Draining = true
+	loaded := newWorkspace("alice")
+	loaded.Status.Location.Current = "paris"
+
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{draining, newShard("paris", "https://paris.kcp.dev")}, loaded)
+
+	workspace := newWorkspace("steve")
+	err := c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Equal(t, "paris", workspace.Status.Location.Current, "new workspaces should avoid the draining shard even if less loaded")
+
+	c = newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{draining})
+	workspace = newWorkspace("steve")
+	err = c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Empty(t, workspace.Status.Location.Current, "workspaces should not be scheduled onto a draining shard")
+	require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceScheduled))
+}
+
+func TestMigrateWorkspaceOffDrainingShard(t *testing.T) {
+	draining := newShard("boston", "https://boston.kcp.dev")
+	draining.Spec.Draining = true
+	full := newShard("london", "https://london.kcp.dev")
+	zero := int32(0)
+	full.Spec.Capacity = &zero
+
+	workspace := newWorkspace("steve")
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	workspace.Status.Location.Current = "boston"
+	workspace.Status.BaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	workspace.Status.InternalBaseURL = "https://boston.kcp.dev/clusters/org:steve"
+
+	recorder := record.NewFakeRecorder(10)
+	c := newTestController(t, recorder, []*tenancyv1alpha1.WorkspaceShard{draining, full, newShard("paris", "https://paris.kcp.dev")}, workspace)
+	kcpClient := kcpfake.NewSimpleClientset(workspace)
+	c.kcpClient = fakeClusterClient{kcpClient}
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+	err = c.processMigration(context.Background(), key)
+	require.NoError(t, err)
+
+	migrating, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(context.Background(), "steve", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "paris", migrating.Status.Location.Target, "expected the only valid shard with free capacity as target")
+	require.Equal(t, "boston", migrating.Status.Location.Current)
+	require.True(t, conditions.IsTrue(migrating, tenancyv1alpha1.WorkspaceMigrating))
+	require.Equal(t, tenancyv1alpha1.WorkspaceMigratingReasonShardDraining, conditions.GetReason(migrating, tenancyv1alpha1.WorkspaceMigrating))
+
+	migrating.ClusterName = workspace.ClusterName
+	err = c.reconcile(context.Background(), migrating)
+	require.NoError(t, err)
+	require.Equal(t, "paris", migrating.Status.Location.Current)
+	require.Empty(t, migrating.Status.Location.Target)
+	require.Equal(t, "https://paris.kcp.dev/clusters/org:steve", migrating.Status.BaseURL)
+	require.Equal(t, "https://paris.kcp.dev/clusters/org:steve", migrating.Status.InternalBaseURL)
+	require.Nil(t, conditions.Get(migrating, tenancyv1alpha1.WorkspaceMigrating), "the WorkspaceMigrating condition should be removed after the move")
+	require.Equal(t, []string{`Normal Moved Moved from shard "boston" to shard "paris"`}, drainEvents(recorder))
+}
+
+func TestMigrateWorkspaceHonorsShardSelector(t *testing.T) {
+	draining := newShard("boston", "https://boston.kcp.dev")
+	draining.Spec.Draining = true
+	london := newShard("london", "https://london.kcp.dev")
+	paris := newShard("paris", "https://paris.kcp.dev")
+	paris.Labels = map[string]string{"region": "eu"}
+
+	workspace := newWorkspace("steve")
+	workspace.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation: "region=eu"}
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	workspace.Status.Location.Current = "boston"
+
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{draining, london, paris}, workspace)
+	kcpClient := kcpfake.NewSimpleClientset(workspace)
+	c.kcpClient = fakeClusterClient{kcpClient}
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+	require.NoError(t, c.processMigration(context.Background(), key))
+
+	migrating, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(context.Background(), "steve", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "paris", migrating.Status.Location.Target, "expected the only shard matching the selector as target")
+}
+
+func TestMigrateWorkspaceWithoutTarget(t *testing.T) {
+	draining := newShard("boston", "https://boston.kcp.dev")
+	draining.Spec.Draining = true
+	workspace := newWorkspace("steve")
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	workspace.Status.Location.Current = "boston"
+
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{draining}, workspace)
+	kcpClient := kcpfake.NewSimpleClientset(workspace)
+	c.kcpClient = fakeClusterClient{kcpClient}
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+	err = c.processMigration(context.Background(), key)
+	require.Error(t, err, "the migration should be retried while there is no other shard")
+	require.Empty(t, kcpClient.Actions(), "the workspace should not be updated")
+}
+
+func TestShardLifecycleConditions(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		cordoned, drained corev1.ConditionStatus
+		expectScheduled   bool
+		expectMigrated    bool
+	}{
+		{name: "no conditions", expectScheduled: true},
+		{name: "not cordoned, not draining", cordoned: corev1.ConditionFalse, drained: corev1.ConditionFalse, expectScheduled: true},
+		{name: "cordoned", cordoned: corev1.ConditionTrue},
+		{name: "draining", drained: corev1.ConditionTrue, expectMigrated: true},
+		{name: "cordoned and draining", cordoned: corev1.ConditionTrue, drained: corev1.ConditionTrue, expectMigrated: true},
+		{name: "draining unknown", drained: corev1.ConditionUnknown, expectScheduled: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			boston := newShard("boston", "https://boston.kcp.dev")
+			for conditionType, status := range map[conditionsv1alpha1.ConditionType]corev1.ConditionStatus{
+				tenancyv1alpha1.WorkspaceShardSchedulingDisabled: tc.cordoned,
+				tenancyv1alpha1.WorkspaceShardDraining:           tc.drained,
+			} {
+				if status != "" {
+					conditions.Set(boston, &conditionsv1alpha1.Condition{Type: conditionType, Status: status})
+				}
+			}
+
+			c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{boston})
+			workspace := newWorkspace("steve")
+			require.NoError(t, c.reconcile(context.Background(), workspace))
+			if tc.expectScheduled {
+				require.Equal(t, "boston", workspace.Status.Location.Current, "new workspaces should be scheduled onto the shard")
+			} else {
+				require.Empty(t, workspace.Status.Location.Current, "new workspaces should not be scheduled onto the shard")
+				require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceScheduled))
+			}
+
+			scheduled := newWorkspace("alice")
+			scheduled.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+			scheduled.Status.Location.Current = "boston"
+			c = newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{boston, newShard("paris", "https://paris.kcp.dev")}, scheduled)
+			kcpClient := kcpfake.NewSimpleClientset(scheduled)
+			c.kcpClient = fakeClusterClient{kcpClient}
+
+			key, err := cache.MetaNamespaceKeyFunc(scheduled)
+			require.NoError(t, err)
+			require.NoError(t, c.processMigration(context.Background(), key))
+			migrated, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(context.Background(), "alice", metav1.GetOptions{})
+			require.NoError(t, err)
+			if tc.expectMigrated {
+				require.Equal(t, "paris", migrated.Status.Location.Target, "existing workspaces should be moved off the shard")
+			} else {
+				require.Empty(t, migrated.Status.Location.Target, "existing workspaces should stay on the shard")
+			}
+		})
+	}
+}
+
+func TestRescheduleOrphanedWorkspace(t *testing.T) {
+	workspace := newWorkspace("steve")
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	workspace.Status.Location.Current = "boston"
+	workspace.Status.BaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	workspace.Status.InternalBaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceShardValid)
+
+	recorder := record.NewFakeRecorder(10)
+	c := newTestController(t, recorder, []*tenancyv1alpha1.WorkspaceShard{newShard("paris", "https://paris.kcp.dev")}, workspace)
+	kcpClient := kcpfake.NewSimpleClientset(workspace)
+	c.kcpClient = fakeClusterClient{kcpClient}
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+	require.NoError(t, c.processMigration(context.Background(), key))
+
+	released, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(context.Background(), "steve", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, released.Status.Location.Current)
+	require.Empty(t, released.Status.BaseURL)
+	require.Empty(t, released.Status.InternalBaseURL)
+	require.True(t, conditions.IsFalse(released, tenancyv1alpha1.WorkspaceShardValid))
+	require.Equal(t, tenancyv1alpha1.WorkspaceShardValidReasonShardNotFound, conditions.GetReason(released, tenancyv1alpha1.WorkspaceShardValid))
+	require.Equal(t, []string{`Warning Orphaned Released from deleted shard "boston"`}, drainEvents(recorder))
+
+	released.ClusterName = workspace.ClusterName
+	require.NoError(t, c.reconcile(context.Background(), released))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseReady, released.Status.Phase)
+	require.Equal(t, "paris", released.Status.Location.Current, "expected the workspace to be rescheduled onto the remaining shard")
+	require.Equal(t, "https://paris.kcp.dev/clusters/org:steve", released.Status.BaseURL)
+	require.True(t, conditions.IsTrue(released, tenancyv1alpha1.WorkspaceShardValid))
+}
+
+func TestReconcileCloneInitializer(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+
+	workspace := newWorkspace("steve")
+	workspace.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation: "bob"}
+	err := c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseInitializing, workspace.Status.Phase)
+	require.Equal(t, []tenancyv1alpha1.ClusterWorkspaceInitializer{tenancyv1alpha1.ClusterWorkspaceCloneInitializer}, workspace.Status.Initializers)
+
+	err = c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseInitializing, workspace.Status.Phase, "the workspace should stay initializing until cloned")
+}
+
+func TestReconcileBootstrapBundleInitializer(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+
+	workspace := newWorkspace("steve")
+	workspace.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation: "https://bundles.example.com/team.tar.gz"}
+	err := c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseInitializing, workspace.Status.Phase)
+	require.Equal(t, []tenancyv1alpha1.ClusterWorkspaceInitializer{tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer}, workspace.Status.Initializers)
+}
+
+func TestReconcileOwner(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+
+	workspace := newWorkspace("steve")
+	err := c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Nil(t, workspace.Status.Owner, "a workspace without owner annotations has no owner")
+
+	workspace.Annotations = map[string]string{
+		tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+		tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1,team-2",
+	}
+	err = c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Equal(t, &tenancyv1alpha1.ClusterWorkspaceOwner{User: "user-1", Groups: []string{"team-1", "team-2"}}, workspace.Status.Owner)
+	require.Equal(t, "user-1", workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation], "the owner annotations should be preserved")
+}
+
+func TestReconcileDeletionTerminates(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), nil)
+
+	workspace := newWorkspace("steve")
+	now := metav1.Now()
+	workspace.DeletionTimestamp = &now
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseTerminating, workspace.Status.Phase)
+
+	// a terminating workspace never leaves its phase, even if the deletion is not visible anymore
+	workspace.DeletionTimestamp = nil
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseTerminating, workspace.Status.Phase)
+}
+
+func TestReconcileDeletionOrphansContent(t *testing.T) {
+	workspace := newWorkspace("steve")
+	now := metav1.Now()
+	workspace.DeletionTimestamp = &now
+	workspace.Finalizers = []string{tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer, metav1.FinalizerOrphanDependents}
+	logicalCluster, err := tenancyhelper.EncodeLogicalClusterName(workspace)
+	require.NoError(t, err)
+	child := newWorkspace("child")
+	child.ClusterName = logicalCluster
+
+	kcpClient := kcpfake.NewSimpleClientset(child)
+	c := newTestController(t, record.NewFakeRecorder(10), nil, workspace, child)
+	c.kcpClient = fakeClusterClient{kcpClient}
+
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, []string{metav1.FinalizerOrphanDependents}, workspace.Finalizers)
+	require.Empty(t, kcpClient.Actions(), "expected the child workspace to be kept")
+}
+
+func TestProcessSkipsNoopUpdates(t *testing.T) {
+	shard := newShard("boston", "https://boston.kcp.dev")
+	workspace := newWorkspace("steve")
+	workspace.Finalizers = []string{tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer}
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	workspace.Status.Location.Current = "boston"
+	workspace.Status.BaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	workspace.Status.InternalBaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	workspace.Status.Type = "universal"
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceShardValid)
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceTypeValid)
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+
+	t.Run("unchanged", func(t *testing.T) {
+		kcpClient := kcpfake.NewSimpleClientset(workspace)
+		c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{shard}, workspace)
+		c.kcpClient = fakeClusterClient{kcpClient}
+
+		require.NoError(t, c.process(context.Background(), key))
+		require.Empty(t, kcpClient.Actions(), "expected no update on a no-op reconcile")
+	})
+
+	t.Run("condition changed", func(t *testing.T) {
+		invalid := shard.DeepCopy()
+		conditions.MarkFalse(invalid, tenancyv1alpha1.WorkspaceShardCredentialsValid, "InvalidCredentials", conditionsv1alpha1.ConditionSeverityError, "")
+		kcpClient := kcpfake.NewSimpleClientset(workspace)
+		c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{invalid}, workspace)
+		c.kcpClient = fakeClusterClient{kcpClient}
+
+		require.NoError(t, c.process(context.Background(), key))
+		require.Len(t, kcpClient.Actions(), 1, "expected the changed condition to be written")
+		patch := kcpClient.Actions()[0].(clienttesting.PatchAction)
+		require.Equal(t, "status", patch.GetSubresource())
+		require.Contains(t, string(patch.GetPatch()), string(tenancyv1alpha1.WorkspaceShardValid))
+		require.NotContains(t, string(patch.GetPatch()), "baseURL")
+	})
+
+	t.Run("URL changed", func(t *testing.T) {
+		moved := newShard("boston", "https://boston.example.com")
+		c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{moved}, workspace)
+
+		recomputed := workspace.DeepCopy()
+		require.NoError(t, c.reconcile(context.Background(), recomputed))
+		require.Equal(t, "https://boston.example.com/clusters/org:steve", recomputed.Status.BaseURL)
+		require.Equal(t, "https://boston.example.com/clusters/org:steve", recomputed.Status.InternalBaseURL)
+	})
+}
+
+func TestProcessRetriesWithBackoff(t *testing.T) {
+	registerMetrics()
+
+	workspace := newWorkspace("steve")
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")}, workspace)
+	kcpClient := kcpfake.NewSimpleClientset(workspace)
+	kcpClient.PrependReactor("patch", "clusterworkspaces", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("injected failure")
+	})
+	c.kcpClient = fakeClusterClient{kcpClient}
+	c.queue = workqueue.NewRateLimitingQueue(newRateLimiter(time.Millisecond, 10*time.Millisecond))
+	defer c.queue.ShutDown()
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+	c.queue.Add(key)
+
+	before, err := testutil.GetCounterMetricValue(workqueueRetries)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.True(t, c.processNextWorkItem(context.Background()))
+	}
+	after, err := testutil.GetCounterMetricValue(workqueueRetries)
+	require.NoError(t, err)
+
+	require.Equal(t, float64(3), after-before, "every failed reconciliation should count as a retry")
+	require.Equal(t, 3, c.queue.NumRequeues(key))
+}
+
+func TestReconcileLogContext(t *testing.T) {
+	deleted := newWorkspace("steve")
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	deleted.Finalizers = []string{tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer}
+
+	for _, tc := range []struct {
+		name          string
+		workspace     *tenancyv1alpha1.ClusterWorkspace
+		expectedLines []string
+	}{
+		{
+			name:      "scheduling",
+			workspace: newWorkspace("steve"),
+			expectedLines: []string{
+				`"level"=0 "msg"="Scheduled workspace" "reconcileID"="[a-z0-9]{8}" "clusterWorkspace"="root:org\|steve" "org"="root:org" "shard"="" "targetShard"="boston"`,
+			},
+		},
+		{
+			name:      "deletion",
+			workspace: deleted,
+			expectedLines: []string{
+				`"level"=0 "msg"="Removed content of workspace" "reconcileID"="[a-z0-9]{8}" "clusterWorkspace"="root:org\|steve" "org"="root:org" "shard"=""`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var lines []string
+			c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+			c.logger = funcr.New(func(prefix, args string) {
+				lines = append(lines, args)
+			}, funcr.Options{})
+
+			require.NoError(t, c.reconcile(context.Background(), tc.workspace))
+
+			require.Len(t, lines, len(tc.expectedLines), "unexpected log lines: %v", lines)
+			for i, expected := range tc.expectedLines {
+				require.Regexp(t, "^"+expected+"$", lines[i])
+			}
+		})
+	}
+}
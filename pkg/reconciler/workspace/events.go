@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// eventClusterAnnotation carries the logical cluster of the involved ClusterWorkspace from the
+	// recorder to the event sink, because event object references are not cluster-aware.
+	eventClusterAnnotation = "tenancy.kcp.dev/event-cluster"
+
+	EventReasonScheduled       = "Scheduled"
+	EventReasonUnschedulable   = "Unschedulable"
+	EventReasonBaseURLComputed = "BaseURLComputed"
+	EventReasonMoved           = "Moved"
+	EventReasonOrphaned        = "Orphaned"
+
+	EventReasonInitializationTimedOut = "InitializationTimedOut"
+	EventReasonInitializationRetried  = "InitializationRetried"
+
+	EventReasonOwnerMissing          = "OwnerMissing"
+	EventReasonOwnerGarbageCollected = "OwnerGarbageCollected"
+
+	EventReasonSoftDeletionExpired = "SoftDeletionExpired"
+)
+
+// NewEventSink returns an event sink writing the events emitted by the workspace scheduler into the
+// logical cluster of the ClusterWorkspace they are about.
+func NewEventSink(kubeClusterClient kubernetes.ClusterInterface) record.EventSink {
+	return &clusterAwareEventSink{kubeClusterClient: kubeClusterClient}
+}
+
+type clusterAwareEventSink struct {
+	kubeClusterClient kubernetes.ClusterInterface
+}
+
+func (s *clusterAwareEventSink) unwrap(event *corev1.Event) (*corev1.Event, string) {
+	event = event.DeepCopy()
+	clusterName := event.Annotations[eventClusterAnnotation]
+	delete(event.Annotations, eventClusterAnnotation)
+	return event, clusterName
+}
+
+func (s *clusterAwareEventSink) Create(event *corev1.Event) (*corev1.Event, error) {
+	event, clusterName := s.unwrap(event)
+	return s.kubeClusterClient.Cluster(clusterName).CoreV1().Events(event.Namespace).Create(context.TODO(), event, metav1.CreateOptions{})
+}
+
+func (s *clusterAwareEventSink) Update(event *corev1.Event) (*corev1.Event, error) {
+	event, clusterName := s.unwrap(event)
+	return s.kubeClusterClient.Cluster(clusterName).CoreV1().Events(event.Namespace).Update(context.TODO(), event, metav1.UpdateOptions{})
+}
+
+func (s *clusterAwareEventSink) Patch(event *corev1.Event, data []byte) (*corev1.Event, error) {
+	event, clusterName := s.unwrap(event)
+	return s.kubeClusterClient.Cluster(clusterName).CoreV1().Events(event.Namespace).Patch(context.TODO(), event.Name, types.StrategicMergePatchType, data, metav1.PatchOptions{})
+}
+
+// event records an event about the given workspace, annotated with its logical cluster.
+func (c *Controller) event(workspace *tenancyv1alpha1.ClusterWorkspace, eventType, reason, messageFmt string, args ...interface{}) {
+	c.recorder.AnnotatedEventf(workspace, map[string]string{eventClusterAnnotation: workspace.ClusterName}, eventType, reason, messageFmt, args...)
+}
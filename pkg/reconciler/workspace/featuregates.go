@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/features"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// featureGatesConfigMapName is the name of the ConfigMap in the kube-system namespace of a workspace holding
+// the feature gates of the workspace, read by its shard.
+const featureGatesConfigMapName = "workspace-feature-gates"
+
+// reconcileFeatureGates propagates the feature gates of the workspace to its shard, and records them in the
+// status once done. On creation they are propagated right away. Later changes first set the
+// WorkspaceFeaturesChanging condition, which is removed once the new feature gates are propagated. Feature
+// gates unknown to the shard are never propagated. The calls go to the shard of the workspace, through its
+// circuit breaker.
+func (c *Controller) reconcileFeatureGates(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	if equality.Semantic.DeepEqual(workspace.Spec.FeatureGates, workspace.Status.FeatureGates) {
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceFeaturesChanging)
+		return nil
+	}
+	logger := logr.FromContextOrDiscard(ctx)
+
+	if errs := tenancyhelper.ValidateFeatureGates(workspace.Spec.FeatureGates, features.WorkspaceFeatureGates(), field.NewPath("spec", "featureGates")); len(errs) > 0 {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceFeaturesChanging, tenancyv1alpha1.WorkspaceFeaturesChangingReasonInvalidFeatureGates, conditionsv1alpha1.ConditionSeverityError, "Feature gates are not propagated: %v.", errs.ToAggregate())
+		return nil
+	}
+
+	// let changes be observed before propagating them, the status update requeues the workspace
+	if workspace.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady && !conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceFeaturesChanging) {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceFeaturesChanging)
+		return nil
+	}
+
+	logicalCluster, err := tenancyhelper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return err
+	}
+	kubeClient := c.kubeClient.Cluster(logicalCluster)
+
+	data := make(map[string]string, len(workspace.Spec.FeatureGates))
+	for name, enabled := range workspace.Spec.FeatureGates {
+		data[name] = strconv.FormatBool(enabled)
+	}
+	if err := c.shardBreakers.call(ctx, workspace.Status.Location.Current, func(ctx context.Context) error {
+		existing, err := kubeClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(ctx, featureGatesConfigMapName, metav1.GetOptions{})
+		if err == nil {
+			existing.Data = data
+			_, err = kubeClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).Update(ctx, existing, metav1.UpdateOptions{})
+			return err
+		} else if !errors.IsNotFound(err) {
+			return err
+		}
+
+		if _, err := kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem},
+		}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		_, err = kubeClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: featureGatesConfigMapName},
+			Data:       data,
+		}, metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		return err
+	}
+
+	workspace.Status.FeatureGates = make(map[string]bool, len(workspace.Spec.FeatureGates))
+	for name, enabled := range workspace.Spec.FeatureGates {
+		workspace.Status.FeatureGates[name] = enabled
+	}
+	conditions.Delete(workspace, tenancyv1alpha1.WorkspaceFeaturesChanging)
+	logger.Info("Propagated the feature gates of workspace", "featureGates", workspace.Spec.FeatureGates)
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcileFeatureGates(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		phase         tenancyv1alpha1.ClusterWorkspacePhaseType
+		spec, status  map[string]bool
+		changing      bool
+		existing      []runtime.Object
+		wantCondition *bool
+		wantStatus    map[string]bool
+		wantConfigMap map[string]string
+	}{
+		{
+			name:  "no feature gates",
+			phase: tenancyv1alpha1.ClusterWorkspacePhaseReady,
+		},
+		{
+			name:          "propagated on creation",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			spec:          map[string]bool{"ServerSideApply": false},
+			wantStatus:    map[string]bool{"ServerSideApply": false},
+			wantConfigMap: map[string]string{"ServerSideApply": "false"},
+		},
+		{
+			name:          "changed after creation",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          map[string]bool{"ServerSideApply": true},
+			status:        map[string]bool{"ServerSideApply": false},
+			wantCondition: boolPtr(true),
+			wantStatus:    map[string]bool{"ServerSideApply": false},
+		},
+		{
+			name:     "propagated once changing",
+			phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:     map[string]bool{"ServerSideApply": true},
+			status:   map[string]bool{"ServerSideApply": false},
+			changing: true,
+			existing: []runtime.Object{&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: featureGatesConfigMapName, Namespace: metav1.NamespaceSystem},
+				Data:       map[string]string{"ServerSideApply": "false"},
+			}},
+			wantStatus:    map[string]bool{"ServerSideApply": true},
+			wantConfigMap: map[string]string{"ServerSideApply": "true"},
+		},
+		{
+			name:          "unknown feature gate",
+			phase:         tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			spec:          map[string]bool{"ServerSideApply": true, "Teleportation": true},
+			status:        map[string]bool{"ServerSideApply": false},
+			wantCondition: boolPtr(false),
+			wantStatus:    map[string]bool{"ServerSideApply": false},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			workspace := newWorkspace("steve")
+			workspace.Status.Phase = tc.phase
+			workspace.Spec.FeatureGates = tc.spec
+			workspace.Status.FeatureGates = tc.status
+			if tc.changing {
+				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceFeaturesChanging)
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(tc.existing...)
+			c := newTestController(t, record.NewFakeRecorder(10), nil, workspace)
+			c.kubeClient = fakeKubeClusterClient{kubeClient}
+
+			require.NoError(t, c.reconcile(context.Background(), workspace))
+			require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseReady, workspace.Status.Phase)
+			require.Equal(t, tc.wantStatus, workspace.Status.FeatureGates)
+
+			if tc.wantCondition == nil {
+				require.False(t, conditions.Has(workspace, tenancyv1alpha1.WorkspaceFeaturesChanging))
+			} else {
+				require.Equal(t, *tc.wantCondition, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceFeaturesChanging))
+			}
+
+			configMap, err := kubeClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(context.Background(), featureGatesConfigMapName, metav1.GetOptions{})
+			if tc.wantConfigMap == nil {
+				require.Error(t, err, "expected no feature gates to be propagated")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantConfigMap, configMap.Data)
+		})
+	}
+}
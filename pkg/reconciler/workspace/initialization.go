@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// startInitialization moves the workspace to the Initializing phase and records when it did. It returns
+// whether the workspace is initializing afterwards.
+func (c *Controller) startInitialization(logger logr.Logger, workspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	if !setPhase(logger, workspace, tenancyv1alpha1.ClusterWorkspacePhaseInitializing) {
+		return false
+	}
+	now := metav1.NewTime(c.clock.Now())
+	workspace.Status.InitializationStartTime = &now
+	return true
+}
+
+// checkInitializationTimeout moves the workspace to the Failed phase if its initializers were not all cleared
+// within the initialization timeout, and queues it again for when the timeout expires otherwise.
+func (c *Controller) checkInitializationTimeout(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) {
+	if workspace.Status.InitializationStartTime == nil {
+		// initializing since before the start time was recorded, the timeout starts now
+		now := metav1.NewTime(c.clock.Now())
+		workspace.Status.InitializationStartTime = &now
+	}
+	if c.initializationTimeout <= 0 {
+		return
+	}
+
+	if remaining := c.initializationTimeout - c.clock.Since(workspace.Status.InitializationStartTime.Time); remaining > 0 {
+		c.enqueueAfter(workspace, remaining)
+		return
+	}
+
+	logger := logr.FromContextOrDiscard(ctx)
+	if !setPhase(logger, workspace, tenancyv1alpha1.ClusterWorkspacePhaseFailed) {
+		return
+	}
+	pending := make([]string, 0, len(workspace.Status.Initializers))
+	for _, initializer := range workspace.Status.Initializers {
+		pending = append(pending, string(initializer))
+	}
+	conditions.Set(workspace, &conditionsv1alpha1.Condition{
+		Type:     tenancyv1alpha1.WorkspaceInitializationTimedOut,
+		Status:   corev1.ConditionTrue,
+		Severity: conditionsv1alpha1.ConditionSeverityError,
+		Reason:   tenancyv1alpha1.WorkspaceInitializationTimedOutReasonInitializersPending,
+		Message:  "Initializers not cleared within " + c.initializationTimeout.String() + ": " + strings.Join(pending, ", ") + ".",
+	})
+	logger.Info("Workspace initialization timed out", "timeout", c.initializationTimeout, "pendingInitializers", pending)
+	c.event(workspace, corev1.EventTypeWarning, EventReasonInitializationTimedOut, "Initializers not cleared within %s: %s", c.initializationTimeout, strings.Join(pending, ", "))
+}
+
+// retryInitialization moves a failed workspace back to the Initializing phase, with a new initialization timeout.
+// The retry annotation is removed by the caller.
+func (c *Controller) retryInitialization(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) {
+	logger := logr.FromContextOrDiscard(ctx)
+	if !c.startInitialization(logger, workspace) {
+		return
+	}
+	if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation]; found && !conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceCloneComplete) {
+		addInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceCloneInitializer)
+	}
+	if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation]; found && !conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceBootstrapFailed) {
+		addInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer)
+	}
+	conditions.Delete(workspace, tenancyv1alpha1.WorkspaceInitializationTimedOut)
+	logger.Info("Retrying workspace initialization")
+	c.event(workspace, corev1.EventTypeNormal, EventReasonInitializationRetried, "Retrying initialization")
+}
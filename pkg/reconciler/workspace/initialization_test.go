@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// delayRecordingQueue records the delays of the keys queued for later instead of queueing them.
+type delayRecordingQueue struct {
+	workqueue.RateLimitingInterface
+	delays map[interface{}]time.Duration
+}
+
+func (q *delayRecordingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.delays[item] = duration
+}
+
+func TestReconcileInitializationTimeout(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := newTestController(t, recorder, []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	fakeClock := clocktesting.NewFakeClock(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	c.clock = fakeClock
+	c.initializationTimeout = time.Minute
+	queue := &delayRecordingQueue{delays: map[interface{}]time.Duration{}}
+	c.queue = queue
+
+	workspace := newWorkspace("steve")
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseInitializing, workspace.Status.Phase)
+	require.NotNil(t, workspace.Status.InitializationStartTime)
+	require.True(t, workspace.Status.InitializationStartTime.Time.Equal(fakeClock.Now()))
+
+	// the bootstrap of the type never completes
+	workspace.Status.Initializers = []tenancyv1alpha1.ClusterWorkspaceInitializer{"bootstrapper"}
+	fakeClock.Step(20 * time.Second)
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseInitializing, workspace.Status.Phase)
+	require.Equal(t, 40*time.Second, queue.delays[key], "expected the workspace to be queued for when the timeout expires")
+	require.False(t, conditions.Has(workspace, tenancyv1alpha1.WorkspaceInitializationTimedOut))
+
+	drainEvents(recorder)
+	fakeClock.Step(40 * time.Second)
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseFailed, workspace.Status.Phase)
+	require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceInitializationTimedOut))
+	require.Equal(t, tenancyv1alpha1.WorkspaceInitializationTimedOutReasonInitializersPending, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceInitializationTimedOut))
+	require.Contains(t, conditions.GetMessage(workspace, tenancyv1alpha1.WorkspaceInitializationTimedOut), "bootstrapper")
+	require.Equal(t, []string{"Warning InitializationTimedOut Initializers not cleared within 1m0s: bootstrapper"}, drainEvents(recorder))
+
+	delete(queue.delays, key)
+	fakeClock.Step(time.Hour)
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseFailed, workspace.Status.Phase, "a failed workspace should not be retried without the annotation")
+	require.NotContains(t, queue.delays, key)
+
+	workspace.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceRetryInitializationAnnotation: "true"}
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseInitializing, workspace.Status.Phase)
+	require.True(t, workspace.Status.InitializationStartTime.Time.Equal(fakeClock.Now()), "expected the timeout to start over")
+	require.False(t, conditions.Has(workspace, tenancyv1alpha1.WorkspaceInitializationTimedOut))
+	require.NotContains(t, workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceRetryInitializationAnnotation)
+}
+
+func TestReconcileWithoutInitializationTimeout(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	fakeClock := clocktesting.NewFakeClock(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	c.clock = fakeClock
+
+	workspace := newWorkspace("steve")
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	workspace.Status.Initializers = []tenancyv1alpha1.ClusterWorkspaceInitializer{"bootstrapper"}
+
+	fakeClock.Step(24 * time.Hour)
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseInitializing, workspace.Status.Phase, "a zero timeout should never fail the workspace")
+}
+
+func TestProcessRemovesRetryAnnotation(t *testing.T) {
+	workspace := newWorkspace("steve")
+	workspace.Finalizers = []string{tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer}
+	workspace.Annotations = map[string]string{
+		tenancyv1alpha1.ClusterWorkspaceRetryInitializationAnnotation: "true",
+		tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:               "user-1",
+	}
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseFailed
+	workspace.Status.Location.Current = "boston"
+	workspace.Status.BaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	workspace.Status.InternalBaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	workspace.Status.Initializers = []tenancyv1alpha1.ClusterWorkspaceInitializer{"bootstrapper"}
+
+	kcpClient := kcpfake.NewSimpleClientset(workspace)
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")}, workspace)
+	c.kcpClient = fakeClusterClient{kcpClient}
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+	require.NoError(t, c.process(context.Background(), key))
+
+	var metadataPatch string
+	for _, action := range kcpClient.Actions() {
+		if patch, ok := action.(clienttesting.PatchAction); ok && patch.GetSubresource() == "" {
+			metadataPatch = string(patch.GetPatch())
+		}
+	}
+	require.Contains(t, metadataPatch, `"`+tenancyv1alpha1.ClusterWorkspaceRetryInitializationAnnotation+`":null`)
+	require.NotContains(t, metadataPatch, tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation, "expected only the removed annotation in the patch")
+	require.NotContains(t, metadataPatch, "finalizers")
+}
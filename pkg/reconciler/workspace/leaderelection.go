@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// the timings of the leader election are those of kube-controller-manager.
+	leaderElectionLeaseDuration = 15 * time.Second
+	leaderElectionRenewDeadline = 10 * time.Second
+	leaderElectionRetryPeriod   = 2 * time.Second
+)
+
+// newLeaderElectionConfig returns the leader election between the workspace scheduler replicas, over the Lease
+// of the options in the root workspace. Callbacks are set when the election is run.
+func newLeaderElectionConfig(coordinationClient coordinationv1client.CoordinationV1Interface, options Options) (*leaderelection.LeaderElectionConfig, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hostname for the leader election identity: %w", err)
+	}
+	// several replicas can run on the same host
+	identity := hostname + "_" + string(uuid.NewUUID())
+
+	return &leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{Namespace: options.LeaderElectionLeaseNamespace, Name: options.LeaderElectionLeaseName},
+			Client:    coordinationClient,
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: identity,
+			},
+		},
+		LeaseDuration:   leaderElectionLeaseDuration,
+		RenewDeadline:   leaderElectionRenewDeadline,
+		RetryPeriod:     leaderElectionRetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            controllerName,
+	}, nil
+}
+
+// runLeaderElection campaigns for the leadership, and processes the queues while leading. It returns when the
+// leadership is lost or the context is done.
+func (c *Controller) runLeaderElection(ctx context.Context, numThreads int) {
+	config := *c.leaderElection
+	config.Callbacks = leaderelection.LeaderCallbacks{
+		OnStartedLeading: func(leaderCtx context.Context) {
+			c.logger.Info("Started leading, processing the queues", "identity", config.Lock.Identity())
+			c.startWorkers(leaderCtx, numThreads)
+		},
+		OnStoppedLeading: func() {
+			c.logger.Info("Stopped leading, leaving the queues to the new leader", "identity", config.Lock.Identity())
+		},
+		OnNewLeader: func(identity string) {
+			if identity != config.Lock.Identity() {
+				c.logger.Info("Following the leader", "leader", identity)
+			}
+		},
+	}
+	elector, err := leaderelection.NewLeaderElector(config)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to run the leader election: %w", controllerName, err))
+		return
+	}
+	elector.Run(ctx)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/pointer"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+// newLeaderElectionTestController returns a controller electing its leader over the lease of the given fake
+// client, with a workspace to schedule in its queue.
+func newLeaderElectionTestController(t *testing.T, kubeClient *kubefake.Clientset) (*Controller, *kcpfake.Clientset) {
+	workspace := newWorkspace("steve")
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")}, workspace)
+	kcpClient := kcpfake.NewSimpleClientset(workspace)
+	c.kcpClient = fakeClusterClient{kcpClient}
+	c.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	c.shardQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	c.migrationQueue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+
+	leaderElection, err := newLeaderElectionConfig(kubeClient.CoordinationV1(), *DefaultOptions())
+	require.NoError(t, err)
+	leaderElection.LeaseDuration = time.Minute
+	leaderElection.RenewDeadline = time.Second
+	leaderElection.RetryPeriod = 10 * time.Millisecond
+	c.leaderElection = leaderElection
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+	c.queue.Add(key)
+	return c, kcpClient
+}
+
+func leaseGets(kubeClient *kubefake.Clientset) int {
+	gets := 0
+	for _, action := range kubeClient.Actions() {
+		if action.Matches("get", "leases") {
+			gets++
+		}
+	}
+	return gets
+}
+
+func TestNonLeaderDoesNotWrite(t *testing.T) {
+	options := DefaultOptions()
+	now := metav1.NewMicroTime(time.Now())
+	kubeClient := kubefake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: options.LeaderElectionLeaseNamespace, Name: options.LeaderElectionLeaseName},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       pointer.String("other-replica"),
+			LeaseDurationSeconds: pointer.Int32(3600),
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	})
+	c, kcpClient := newLeaderElectionTestController(t, kubeClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Start(ctx, 2)
+
+	err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return leaseGets(kubeClient) >= 5, nil
+	})
+	require.NoError(t, err, "expected the controller to campaign for the lease")
+
+	var writes []clienttesting.Action
+	for _, action := range kubeClient.Actions() {
+		if !action.Matches("get", "leases") {
+			writes = append(writes, action)
+		}
+	}
+	require.Empty(t, writes, "a non-leader should not take over a lease held by another replica")
+	require.Empty(t, kcpClient.Actions(), "a non-leader should not write workspaces")
+	require.Equal(t, 1, c.queue.Len(), "a non-leader should keep the workspace queued for when it leads")
+}
+
+func TestLeaderWrites(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	c, kcpClient := newLeaderElectionTestController(t, kubeClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Start(ctx, 2)
+
+	err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		for _, action := range kcpClient.Actions() {
+			if action.Matches("patch", "clusterworkspaces") {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	require.NoError(t, err, "expected the leader to schedule the workspace")
+
+	lease, err := kubeClient.CoordinationV1().Leases(DefaultOptions().LeaderElectionLeaseNamespace).Get(ctx, DefaultOptions().LeaderElectionLeaseName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, c.leaderElection.Lock.Identity(), *lease.Spec.HolderIdentity)
+}
+
+func TestEnqueueResyncWithJitter(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), nil)
+	c.resyncJitter = time.Minute
+	queue := &delayRecordingQueue{
+		RateLimitingInterface: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		delays:                map[interface{}]time.Duration{},
+	}
+	c.queue = queue
+
+	old := newWorkspace("steve")
+	old.ResourceVersion = "1"
+	key, err := cache.MetaNamespaceKeyFunc(old)
+	require.NoError(t, err)
+
+	c.enqueueUpdated(old, old.DeepCopy())
+	require.Equal(t, 0, queue.Len(), "a resync should not be queued immediately")
+	require.Contains(t, queue.delays, key)
+	require.Less(t, queue.delays[key], time.Minute)
+
+	updated := old.DeepCopy()
+	updated.ResourceVersion = "2"
+	c.enqueueUpdated(old, updated)
+	require.Equal(t, 1, queue.Len(), "an actual update should be queued immediately")
+}
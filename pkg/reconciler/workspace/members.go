@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// initialMembersRoleName is the name of the ClusterRole and ClusterRoleBinding granting a role on the
+// workspace to its initial members.
+func initialMembersRoleName(workspace *tenancyv1alpha1.ClusterWorkspace, role string) string {
+	return "initial-" + role + "-workspace-" + workspace.Name
+}
+
+// bootstrapInitialMembers grants the members listed in the initial members annotation of the workspace
+// their role on it, unless they were granted it before. Access to the content of a workspace is decided
+// by the RBAC of its parent, see the workspace content authorizer. Hence, for every role, a ClusterRole
+// allowing the role as verb on the clusterworkspaces/content subresource of the workspace is bound to
+// the members having that role in the parent workspace. They are owned by the workspace.
+func (c *Controller) bootstrapInitialMembers(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	value, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation]
+	if !found || conditions.Has(workspace, tenancyv1alpha1.WorkspaceInitialMembersBootstrapped) {
+		return nil
+	}
+	logger := logr.FromContextOrDiscard(ctx)
+
+	members, err := tenancyhelper.ParseInitialMembers(value)
+	if err != nil {
+		// admission rejects invalid annotations, but they might predate it
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceInitialMembersBootstrapped, tenancyv1alpha1.WorkspaceInitialMembersBootstrappedReasonInvalid, conditionsv1alpha1.ConditionSeverityWarning, "Invalid %s annotation: %v", tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation, err)
+		return nil
+	}
+
+	subjectsByRole := map[string][]rbacv1.Subject{}
+	for _, member := range members {
+		subject := rbacv1.Subject{Kind: member.Kind, APIGroup: rbacv1.GroupName, Name: member.Name}
+		subjectsByRole[member.Role] = append(subjectsByRole[member.Role], subject)
+	}
+
+	rbacClient := c.kubeClient.Cluster(workspace.ClusterName).RbacV1()
+	ownerReferences := []metav1.OwnerReference{
+		*metav1.NewControllerRef(workspace, tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace")),
+	}
+	for _, role := range tenancyhelper.InitialMemberRoles.List() {
+		subjects, found := subjectsByRole[role]
+		if !found {
+			continue
+		}
+		name := initialMembersRoleName(workspace, role)
+		if _, err := rbacClient.ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: name, OwnerReferences: ownerReferences},
+			Rules: []rbacv1.PolicyRule{
+				{
+					APIGroups:     []string{tenancyv1alpha1.SchemeGroupVersion.Group},
+					Resources:     []string{"clusterworkspaces/content"},
+					ResourceNames: []string{workspace.Name},
+					Verbs:         []string{role},
+				},
+				{
+					APIGroups:     []string{tenancyv1alpha1.SchemeGroupVersion.Group},
+					Resources:     []string{"workspaces"},
+					ResourceNames: []string{workspace.Name},
+					Verbs:         []string{"get"},
+				},
+			},
+		}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		if _, err := rbacClient.ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, OwnerReferences: ownerReferences},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+			Subjects:   subjects,
+		}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceInitialMembersBootstrapped)
+	logger.Info("Granted the initial members access to the workspace", "members", value)
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestBootstrapInitialMembers(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		members       *string
+		bootstrapped  bool
+		wantCondition *bool
+		wantBindings  map[string][]rbacv1.Subject
+	}{
+		{
+			name: "no initial members",
+		},
+		{
+			name:          "members granted their role",
+			members:       stringPtr("user:user-2=edit, group:team-1=view,user:user-3=edit"),
+			wantCondition: boolPtr(true),
+			wantBindings: map[string][]rbacv1.Subject{
+				"edit": {
+					{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "user-2"},
+					{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "user-3"},
+				},
+				"view": {
+					{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: "team-1"},
+				},
+			},
+		},
+		{
+			name:          "invalid annotation",
+			members:       stringPtr("user:user-2=cluster-admin"),
+			wantCondition: boolPtr(false),
+		},
+		{
+			name:          "already bootstrapped",
+			members:       stringPtr("user:user-2=edit"),
+			bootstrapped:  true,
+			wantCondition: boolPtr(true),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			workspace := newWorkspace("steve")
+			workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseInitializing
+			if tc.members != nil {
+				workspace.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation: *tc.members}
+			}
+			if tc.bootstrapped {
+				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceInitialMembersBootstrapped)
+			}
+
+			kubeClient := kubefake.NewSimpleClientset()
+			c := newTestController(t, record.NewFakeRecorder(10), nil, workspace)
+			c.kubeClient = fakeKubeClusterClient{kubeClient}
+
+			require.NoError(t, c.reconcile(context.Background(), workspace))
+			require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseReady, workspace.Status.Phase, "invalid members should not block the workspace")
+
+			if tc.wantCondition == nil {
+				require.False(t, conditions.Has(workspace, tenancyv1alpha1.WorkspaceInitialMembersBootstrapped))
+			} else {
+				require.Equal(t, *tc.wantCondition, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceInitialMembersBootstrapped))
+			}
+
+			bindings, err := kubeClient.RbacV1().ClusterRoleBindings().List(context.Background(), metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, bindings.Items, len(tc.wantBindings))
+			for role, subjects := range tc.wantBindings {
+				name := "initial-" + role + "-workspace-steve"
+				binding, err := kubeClient.RbacV1().ClusterRoleBindings().Get(context.Background(), name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, subjects, binding.Subjects)
+				require.Equal(t, name, binding.RoleRef.Name)
+
+				clusterRole, err := kubeClient.RbacV1().ClusterRoles().Get(context.Background(), name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Contains(t, clusterRole.Rules, rbacv1.PolicyRule{
+					APIGroups:     []string{"tenancy.kcp.dev"},
+					Resources:     []string{"clusterworkspaces/content"},
+					ResourceNames: []string{"steve"},
+					Verbs:         []string{role},
+				})
+				require.Equal(t, "steve", clusterRole.OwnerReferences[0].Name)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
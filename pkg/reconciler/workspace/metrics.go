@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsSubsystem = "workspace_scheduler"
+
+var (
+	// workqueueDepth is the number of ClusterWorkspace keys waiting in the scheduler queue.
+	workqueueDepth = metrics.NewGauge(&metrics.GaugeOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "workqueue_depth",
+		Help:           "Number of ClusterWorkspaces waiting to be reconciled by the workspace scheduler.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	// workqueueRetries counts the ClusterWorkspace keys requeued with backoff after a failed reconciliation.
+	workqueueRetries = metrics.NewCounter(&metrics.CounterOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "workqueue_retries_total",
+		Help:           "Number of ClusterWorkspaces requeued with backoff by the workspace scheduler after a failed reconciliation.",
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	// reconcileDuration observes how long a single ClusterWorkspace reconciliation takes, including status updates.
+	reconcileDuration = metrics.NewHistogram(&metrics.HistogramOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "reconcile_duration_seconds",
+		Help:           "Duration of the reconciliations of ClusterWorkspaces by the workspace scheduler.",
+		Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+		StabilityLevel: metrics.ALPHA,
+	})
+
+	registerMetricsOnce sync.Once
+)
+
+// registerMetrics registers the workspace scheduler metrics with the global registry. Metrics
+// are only recorded once registered. It is safe to call it multiple times.
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(workqueueDepth, workqueueRetries, reconcileDuration)
+	})
+}
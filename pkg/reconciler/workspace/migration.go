@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/time/rate"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// newMigrationQueue returns the queue of the ClusterWorkspaces to move off draining or deleted shards. Workspaces
+// are added rate-limited, such that at most qps of them are handed out per second.
+func newMigrationQueue(qps float64) workqueue.RateLimitingInterface {
+	return workqueue.NewNamedRateLimitingQueue(workqueue.NewMaxOfRateLimiter(
+		workqueue.DefaultItemBasedRateLimiter(),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), 1)},
+	), controllerName+"-migration")
+}
+
+// enqueueMigration queues the given ClusterWorkspace for migration if it is scheduled onto a draining or
+// nonexistent shard and not already moving.
+func (c *Controller) enqueueMigration(obj interface{}) {
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok || workspace.Status.Location.Current == "" || workspace.Status.Location.Target != "" {
+		return
+	}
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing && workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return
+	}
+	shard, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyhelper.RootCluster, workspace.Status.Location.Current))
+	if err != nil && !errors.IsNotFound(err) {
+		return
+	}
+	if err == nil && !isDrainingShard(shard) {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.migrationQueue.AddRateLimited(key)
+}
+
+// enqueueWorkspacesOfDrainingShard queues the workspaces scheduled onto the given shard for migration
+// when the shard starts draining.
+func (c *Controller) enqueueWorkspacesOfDrainingShard(old, obj interface{}) {
+	shard, ok := obj.(*tenancyv1alpha1.WorkspaceShard)
+	if !ok || !isDrainingShard(shard) {
+		return
+	}
+	if oldShard, ok := old.(*tenancyv1alpha1.WorkspaceShard); ok && isDrainingShard(oldShard) {
+		return
+	}
+	workspaces, err := c.workspaceIndexer.ByIndex(currentShardIndex, shard.Name)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.logger.Info("Draining shard", "shard", shard.Name, "workspaces", len(workspaces))
+	for _, workspace := range workspaces {
+		key, err := cache.MetaNamespaceKeyFunc(workspace)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		c.migrationQueue.AddRateLimited(key)
+	}
+}
+
+func (c *Controller) startMigrationWorker(ctx context.Context) {
+	for c.processNextMigration(ctx) {
+	}
+}
+
+func (c *Controller) processNextMigration(ctx context.Context) bool {
+	k, quit := c.migrationQueue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+	if ctx.Err() != nil {
+		// the leadership was lost while waiting, leave the workspace to the next leader
+		c.migrationQueue.Done(key)
+		c.migrationQueue.Add(key)
+		return false
+	}
+	defer c.migrationQueue.Done(key)
+
+	if err := c.processMigration(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to migrate %q, err: %w", controllerName, key, err))
+		c.migrationQueue.AddRateLimited(key)
+		return true
+	}
+	c.migrationQueue.Forget(key)
+	return true
+}
+
+// processMigration picks a new shard for a ClusterWorkspace scheduled onto a draining shard and sets it as
+// status.location.target. The move itself, including the new base URL, is done by the reconciliation of the
+// workspace. Workspaces still scheduling are left to the scheduler, which does not keep them on a draining shard.
+// Workspaces scheduled onto a deleted shard are released by releaseOrphan instead.
+func (c *Controller) processMigration(ctx context.Context, key string) error {
+	workspace, err := c.workspaceLister.Get(key)
+	if errors.IsNotFound(err) {
+		return nil // object deleted before we handled it
+	} else if err != nil {
+		return err
+	}
+	if !workspace.DeletionTimestamp.IsZero() || workspace.Status.Location.Target != "" {
+		return nil
+	}
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing && workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return nil
+	}
+
+	current := workspace.Status.Location.Current
+	shard, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyhelper.RootCluster, current))
+	if errors.IsNotFound(err) {
+		return c.releaseOrphan(ctx, workspace)
+	} else if err != nil {
+		return err
+	}
+	if !isDrainingShard(shard) {
+		return nil
+	}
+
+	targetShard, err := c.migrationTarget(workspace)
+	if err != nil {
+		return err
+	}
+	if targetShard == nil {
+		return fmt.Errorf("no shard to move workspace %s|%s off draining shard %q to", workspace.ClusterName, workspace.Name, current)
+	}
+
+	workspace = workspace.DeepCopy()
+	workspace.Status.Location.Target = targetShard.Name
+	conditions.Set(workspace, &conditionsv1alpha1.Condition{
+		Type:     tenancyv1alpha1.WorkspaceMigrating,
+		Status:   corev1.ConditionTrue,
+		Severity: conditionsv1alpha1.ConditionSeverityInfo,
+		Reason:   tenancyv1alpha1.WorkspaceMigratingReasonShardDraining,
+		Message:  fmt.Sprintf("Moving from draining shard %q to shard %q.", current, targetShard.Name),
+	})
+	c.reconcileLogger(workspace).Info("Migrating workspace off draining shard", "targetShard", targetShard.Name)
+	_, err = c.kcpClient.Cluster(workspace.ClusterName).TenancyV1alpha1().ClusterWorkspaces().UpdateStatus(ctx, workspace, metav1.UpdateOptions{})
+	return err
+}
+
+// migrationTarget returns the schedulable shard picked by the shard selector other than the current one of the
+// workspace, or nil if there is none. Only shards matching the shard selector annotation of the workspace are considered.
+func (c *Controller) migrationTarget(workspace *tenancyv1alpha1.ClusterWorkspace) (*tenancyv1alpha1.WorkspaceShard, error) {
+	selector, err := shardLabelSelector(workspace)
+	if err != nil {
+		// nolint: nilerr
+		return nil, nil // an invalid selector matches no shard
+	}
+	shards, err := c.rootWorkspaceShardLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]*tenancyv1alpha1.WorkspaceShard, 0, len(shards))
+	for _, shard := range shards {
+		if shard.Name == workspace.Status.Location.Current {
+			continue
+		}
+		if schedulable, _, _ := isSchedulableShard(shard); schedulable {
+			candidates = append(candidates, shard)
+		}
+	}
+	return c.selectShard(candidates)
+}
+
+// releaseOrphan clears the shard assignment of a ClusterWorkspace whose shard got deleted, such that its
+// reconciliation schedules it onto one of the remaining shards. Its content is not recovered.
+func (c *Controller) releaseOrphan(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	current := workspace.Status.Location.Current
+	workspace = workspace.DeepCopy()
+	workspace.Status.Location.Current = ""
+	workspace.Status.BaseURL = ""
+	workspace.Status.InternalBaseURL = ""
+	conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceShardValid, tenancyv1alpha1.WorkspaceShardValidReasonShardNotFound, conditionsv1alpha1.ConditionSeverityError, "WorkspaceShard %q got deleted.", current)
+	c.reconcileLogger(workspace).Info("Releasing workspace orphaned by deleted shard", "shard", current)
+	c.event(workspace, corev1.EventTypeWarning, EventReasonOrphaned, "Released from deleted shard %q", current)
+	_, err := c.kcpClient.Cluster(workspace.ClusterName).TenancyV1alpha1().ClusterWorkspaces().UpdateStatus(ctx, workspace, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// shardPlaceholder is replaced by the shard name in the external shard URL template.
+const shardPlaceholder = "{shard}"
+
+// DefaultOptions are the default options for the workspace scheduler.
+func DefaultOptions() *Options {
+	return &Options{
+		MigrationQPS:           1,
+		BaseBackoff:            5 * time.Millisecond,
+		MaxBackoff:             1000 * time.Second,
+		ShardSelectionStrategy: LeastLoadedShardSelection,
+		DefaultWorkspaceType:   "universal",
+		InitializationTimeout:  time.Hour,
+		OwnerGCGracePeriod:     24 * time.Hour,
+		ResyncJitter:           time.Minute,
+
+		ShardCircuitBreakerFailures: 5,
+		ShardCircuitBreakerCooldown: 30 * time.Second,
+		ShardCallTimeout:            10 * time.Second,
+
+		LeaderElectionLeaseName:      "workspace-scheduler",
+		LeaderElectionLeaseNamespace: "default",
+	}
+}
+
+// BindOptions binds the workspace scheduler options to the flag set.
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.ExternalShardURLTemplate, "external-shard-url-template", o.ExternalShardURLTemplate, "URL template under which clients reach a workspace shard, e.g. https://{shard}.kcp.example.com. "+shardPlaceholder+" is replaced by the shard name. If empty, the shard address is used.")
+	fs.Float64Var(&o.MigrationQPS, "workspace-migration-qps", o.MigrationQPS, "Maximal number of workspaces per second moved off draining or deleted workspace shards.")
+	fs.DurationVar(&o.BaseBackoff, "workspace-scheduler-base-backoff", o.BaseBackoff, "Delay before the workspace scheduler retries a ClusterWorkspace the first time after a failed reconciliation. The delay doubles with every further failure.")
+	fs.DurationVar(&o.MaxBackoff, "workspace-scheduler-max-backoff", o.MaxBackoff, "Maximal delay before the workspace scheduler retries a ClusterWorkspace after a failed reconciliation.")
+	fs.StringToStringVar(&o.DefaultWorkspaceQuota, "default-workspace-quota", o.DefaultWorkspaceQuota, "Limits of the ResourceQuota created in the default namespace of new workspaces, e.g. pods=100,requests.cpu=10. No quota is created if empty, or if the workspace type creates one.")
+	fs.DurationVar(&o.InitializationTimeout, "workspace-init-timeout", o.InitializationTimeout, "Time after which a workspace still initializing is moved to the Failed phase. Set the "+tenancyv1alpha1.ClusterWorkspaceRetryInitializationAnnotation+" annotation to retry. Zero disables the timeout.")
+	fs.BoolVar(&o.EnableOwnerGC, "enable-workspace-owner-gc", o.EnableOwnerGC, "Delete the workspaces whose owner is not known to the --token-auth-file anymore, after marking them with the "+string(tenancyv1alpha1.WorkspaceOwnerMissing)+" condition for --workspace-owner-gc-grace-period.")
+	fs.DurationVar(&o.OwnerGCGracePeriod, "workspace-owner-gc-grace-period", o.OwnerGCGracePeriod, "Time a workspace whose owner is missing is kept before being deleted, when --enable-workspace-owner-gc is set.")
+	fs.DurationVar(&o.ActivityProbeInterval, "workspace-activity-probe-interval", o.ActivityProbeInterval, "Interval at which the last request served in ready workspaces is recorded in status.lastActivityTime of their ClusterWorkspace. Zero disables the activity tracking.")
+	fs.DurationVar(&o.ResyncJitter, "workspace-scheduler-resync-jitter", o.ResyncJitter, "Maximal random delay of the reconciliation of a ClusterWorkspace on periodic resyncs, spreading them over time. Zero reconciles all workspaces at once.")
+	fs.IntVar(&o.ShardCircuitBreakerFailures, "workspace-shard-circuit-breaker-failures", o.ShardCircuitBreakerFailures, "Number of consecutive failed calls of the workspace scheduler to a workspace shard after which further calls are short-circuited for --workspace-shard-circuit-breaker-cooldown, and the shard is marked with the "+string(tenancyv1alpha1.ShardUnavailable)+" condition. Zero disables the circuit breaker.")
+	fs.DurationVar(&o.ShardCircuitBreakerCooldown, "workspace-shard-circuit-breaker-cooldown", o.ShardCircuitBreakerCooldown, "Time calls of the workspace scheduler to a workspace shard are short-circuited before a single call is tried again.")
+	fs.DurationVar(&o.ShardCallTimeout, "workspace-shard-call-timeout", o.ShardCallTimeout, "Maximal duration of a call of the workspace scheduler to a workspace shard. Zero disables the timeout.")
+	fs.BoolVar(&o.LeaderElect, "workspace-scheduler-leader-elect", o.LeaderElect, "Elect a leader among the workspace schedulers of replicated control planes through a Lease in the root workspace. Only the leader reconciles ClusterWorkspaces.")
+	fs.StringVar(&o.LeaderElectionLeaseName, "workspace-scheduler-leader-election-lease-name", o.LeaderElectionLeaseName, "Name of the Lease of the workspace scheduler leader election.")
+	fs.StringVar(&o.LeaderElectionLeaseNamespace, "workspace-scheduler-leader-election-lease-namespace", o.LeaderElectionLeaseNamespace, "Namespace in the root workspace of the Lease of the workspace scheduler leader election.")
+	fs.StringSliceVar(&o.BootstrapBundleAllowedHosts, "workspace-bootstrap-bundle-allowed-hosts", o.BootstrapBundleAllowedHosts, "Hosts, optionally with a port, from which the bundles of the "+tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation+" annotation are downloaded. Bundles from other hosts are never downloaded.")
+	fs.StringVar(&o.DefaultWorkspaceType, "default-workspace-type", o.DefaultWorkspaceType, "Name of the ClusterWorkspaceType of ClusterWorkspaces created without spec.type. Such workspaces are valid without a ClusterWorkspaceType of that name.")
+	fs.StringVar(&o.ShardSelectionStrategy, "shard-selection-strategy", o.ShardSelectionStrategy, "Strategy picking the workspace shard to schedule a workspace onto among the valid ones, one of: "+strings.Join(shardSelectorNames(), ", ")+".")
+	return o
+}
+
+// Options are the options for the workspace scheduler
+type Options struct {
+	ExternalShardURLTemplate string
+	MigrationQPS             float64
+
+	// BaseBackoff and MaxBackoff bound the exponential backoff of ClusterWorkspaces failing to reconcile.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// ShardSelectionStrategy is the name of the ShardSelector picking the shard of new workspaces.
+	ShardSelectionStrategy string
+
+	// DefaultWorkspaceType is the lower-cased ClusterWorkspaceType name of workspaces created without a type.
+	// It is valid without a ClusterWorkspaceType object of that name.
+	DefaultWorkspaceType string
+
+	// InitializationTimeout, if positive, bounds the time a workspace can stay in the Initializing phase.
+	InitializationTimeout time.Duration
+
+	// EnableOwnerGC enables the deletion of workspaces whose owner is missing for OwnerGCGracePeriod.
+	EnableOwnerGC      bool
+	OwnerGCGracePeriod time.Duration
+
+	// ActivityProbeInterval, if positive, enables the tracking of the last activity of workspaces.
+	ActivityProbeInterval time.Duration
+
+	// ResyncJitter, if positive, bounds the random delay of the reconciliations triggered by informer resyncs.
+	ResyncJitter time.Duration
+
+	// ShardCircuitBreakerFailures, if positive, is the number of consecutive failed calls to a shard after which
+	// calls to it are short-circuited for ShardCircuitBreakerCooldown. ShardCallTimeout, if positive, bounds every call.
+	ShardCircuitBreakerFailures int
+	ShardCircuitBreakerCooldown time.Duration
+	ShardCallTimeout            time.Duration
+
+	// LeaderElect enables the leader election between workspace schedulers over the Lease with the given name
+	// and namespace in the root workspace.
+	LeaderElect                  bool
+	LeaderElectionLeaseName      string
+	LeaderElectionLeaseNamespace string
+
+	// BootstrapBundleAllowedHosts are the hosts the bootstrap bundles of new workspaces are downloaded from.
+	BootstrapBundleAllowedHosts []string
+
+	// DefaultWorkspaceQuota maps resource names to the quantities of the default ResourceQuota of new workspaces.
+	DefaultWorkspaceQuota map[string]string
+}
+
+func (o *Options) Validate() error {
+	if o.MigrationQPS <= 0 {
+		return fmt.Errorf("--workspace-migration-qps must be positive, got %v", o.MigrationQPS)
+	}
+	if o.BaseBackoff <= 0 {
+		return fmt.Errorf("--workspace-scheduler-base-backoff must be positive, got %v", o.BaseBackoff)
+	}
+	if o.MaxBackoff < o.BaseBackoff {
+		return fmt.Errorf("--workspace-scheduler-max-backoff must not be smaller than --workspace-scheduler-base-backoff %v, got %v", o.BaseBackoff, o.MaxBackoff)
+	}
+	if o.InitializationTimeout < 0 {
+		return fmt.Errorf("--workspace-init-timeout must not be negative, got %v", o.InitializationTimeout)
+	}
+	if o.OwnerGCGracePeriod <= 0 {
+		return fmt.Errorf("--workspace-owner-gc-grace-period must be positive, got %v", o.OwnerGCGracePeriod)
+	}
+	if o.ActivityProbeInterval < 0 {
+		return fmt.Errorf("--workspace-activity-probe-interval must not be negative, got %v", o.ActivityProbeInterval)
+	}
+	if o.ResyncJitter < 0 {
+		return fmt.Errorf("--workspace-scheduler-resync-jitter must not be negative, got %v", o.ResyncJitter)
+	}
+	if o.ShardCircuitBreakerFailures < 0 {
+		return fmt.Errorf("--workspace-shard-circuit-breaker-failures must not be negative, got %d", o.ShardCircuitBreakerFailures)
+	}
+	if o.ShardCircuitBreakerFailures > 0 && o.ShardCircuitBreakerCooldown <= 0 {
+		return fmt.Errorf("--workspace-shard-circuit-breaker-cooldown must be positive, got %v", o.ShardCircuitBreakerCooldown)
+	}
+	if o.ShardCallTimeout < 0 {
+		return fmt.Errorf("--workspace-shard-call-timeout must not be negative, got %v", o.ShardCallTimeout)
+	}
+	if o.LeaderElect {
+		if errs := validation.IsDNS1123Subdomain(o.LeaderElectionLeaseName); len(errs) > 0 {
+			return fmt.Errorf("invalid --workspace-scheduler-leader-election-lease-name %q: %s", o.LeaderElectionLeaseName, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsDNS1123Label(o.LeaderElectionLeaseNamespace); len(errs) > 0 {
+			return fmt.Errorf("invalid --workspace-scheduler-leader-election-lease-namespace %q: %s", o.LeaderElectionLeaseNamespace, strings.Join(errs, ", "))
+		}
+	}
+	if errs := validation.IsDNS1123Subdomain(o.DefaultWorkspaceType); len(errs) > 0 {
+		return fmt.Errorf("invalid --default-workspace-type %q: %s", o.DefaultWorkspaceType, strings.Join(errs, ", "))
+	}
+	if _, err := newShardSelector(o.ShardSelectionStrategy); err != nil {
+		return fmt.Errorf("invalid --shard-selection-strategy: %w", err)
+	}
+	if _, err := parseResourceList(o.DefaultWorkspaceQuota); err != nil {
+		return fmt.Errorf("invalid --default-workspace-quota: %w", err)
+	}
+	for _, host := range o.BootstrapBundleAllowedHosts {
+		if host == "" || strings.ContainsAny(host, "/?#@") {
+			return fmt.Errorf("invalid --workspace-bootstrap-bundle-allowed-hosts: expected a host with an optional port, got %q", host)
+		}
+	}
+	if o.ExternalShardURLTemplate == "" {
+		return nil
+	}
+	if _, err := externalShardURL(o.ExternalShardURLTemplate, "shard"); err != nil {
+		return fmt.Errorf("invalid --external-shard-url-template: %w", err)
+	}
+	return nil
+}
+
+// externalShardURL renders the external URL of the given shard from the template.
+func externalShardURL(template, shardName string) (*url.URL, error) {
+	u, err := url.Parse(strings.ReplaceAll(template, shardPlaceholder, shardName))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("expected http or https scheme, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("expected a host in %q", template)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("expected no query or fragment in %q", template)
+	}
+	return u, nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateExternalShardURLTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		wantErr  bool
+	}{
+		{template: ""},
+		{template: "https://{shard}.kcp.example.com"},
+		{template: "http://kcp.example.com/{shard}"},
+		{template: "https://kcp.example.com"},
+		{template: "{shard}.kcp.example.com", wantErr: true},
+		{template: "ftp://{shard}.kcp.example.com", wantErr: true},
+		{template: "https:///{shard}", wantErr: true},
+		{template: "https://{shard}.kcp.example.com?foo=bar", wantErr: true},
+		{template: "https://{shard}.kcp.example.com#foo", wantErr: true},
+		{template: "https://kcp.example.com:port/{shard}", wantErr: true},
+	} {
+		t.Run(tc.template, func(t *testing.T) {
+			o := DefaultOptions()
+			o.ExternalShardURLTemplate = tc.template
+			err := o.Validate()
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateMigrationQPS(t *testing.T) {
+	require.NoError(t, DefaultOptions().Validate())
+	require.Error(t, (&Options{}).Validate())
+	require.Error(t, (&Options{MigrationQPS: -1}).Validate())
+}
+
+func TestValidateBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		base, max time.Duration
+		wantErr   bool
+	}{
+		{name: "default", base: DefaultOptions().BaseBackoff, max: DefaultOptions().MaxBackoff},
+		{name: "equal", base: time.Second, max: time.Second},
+		{name: "zero base", base: 0, max: time.Second, wantErr: true},
+		{name: "negative base", base: -time.Second, max: time.Second, wantErr: true},
+		{name: "max below base", base: time.Minute, max: time.Second, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultOptions()
+			o.BaseBackoff, o.MaxBackoff = tc.base, tc.max
+			err := o.Validate()
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateDefaultWorkspaceQuota(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		quota   map[string]string
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "valid", quota: map[string]string{"pods": "100", "requests.cpu": "500m"}},
+		{name: "invalid quantity", quota: map[string]string{"pods": "many"}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultOptions()
+			o.DefaultWorkspaceQuota = tc.quota
+			err := o.Validate()
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateDefaultWorkspaceType(t *testing.T) {
+	o := DefaultOptions()
+	o.DefaultWorkspaceType = "team"
+	require.NoError(t, o.Validate())
+	o.DefaultWorkspaceType = "Team"
+	require.Error(t, o.Validate(), "workspace type names are lower-cased")
+	o.DefaultWorkspaceType = ""
+	require.Error(t, o.Validate())
+}
+
+func TestValidateInitializationTimeout(t *testing.T) {
+	o := DefaultOptions()
+	o.InitializationTimeout = 0
+	require.NoError(t, o.Validate(), "a zero timeout disables it")
+	o.InitializationTimeout = -time.Minute
+	require.Error(t, o.Validate())
+}
+
+func TestValidateOwnerGCGracePeriod(t *testing.T) {
+	o := DefaultOptions()
+	o.EnableOwnerGC = true
+	require.NoError(t, o.Validate())
+	o.OwnerGCGracePeriod = 0
+	require.Error(t, o.Validate())
+}
+
+func TestValidateLeaderElection(t *testing.T) {
+	o := DefaultOptions()
+	o.LeaderElect = true
+	require.NoError(t, o.Validate())
+	o.LeaderElectionLeaseName = "Not_A_Name"
+	require.Error(t, o.Validate())
+	o.LeaderElectionLeaseName = "workspace-scheduler"
+	o.LeaderElectionLeaseNamespace = ""
+	require.Error(t, o.Validate())
+	o.LeaderElect = false
+	require.NoError(t, o.Validate(), "the lease is only validated with leader election enabled")
+}
+
+func TestValidateResyncJitter(t *testing.T) {
+	o := DefaultOptions()
+	o.ResyncJitter = 0
+	require.NoError(t, o.Validate(), "a zero jitter disables it")
+	o.ResyncJitter = -time.Second
+	require.Error(t, o.Validate())
+}
+
+func TestValidateBootstrapBundleAllowedHosts(t *testing.T) {
+	o := DefaultOptions()
+	o.BootstrapBundleAllowedHosts = []string{"bundles.example.com", "127.0.0.1:8080"}
+	require.NoError(t, o.Validate())
+	o.BootstrapBundleAllowedHosts = []string{"https://bundles.example.com"}
+	require.Error(t, o.Validate())
+}
+
+func TestValidateShardCircuitBreaker(t *testing.T) {
+	o := DefaultOptions()
+	o.ShardCircuitBreakerFailures = 0
+	o.ShardCircuitBreakerCooldown = 0
+	require.NoError(t, o.Validate(), "zero failures disable the circuit breaker")
+	o.ShardCircuitBreakerFailures = 3
+	require.Error(t, o.Validate())
+	o.ShardCircuitBreakerCooldown = time.Second
+	require.NoError(t, o.Validate())
+	o.ShardCircuitBreakerFailures = -1
+	require.Error(t, o.Validate())
+	o.ShardCircuitBreakerFailures = 3
+	o.ShardCallTimeout = -time.Second
+	require.Error(t, o.Validate())
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// OwnerChecker tells whether the owner of a ClusterWorkspace is still known to the authenticators of kcp.
+// Implementations should err on the side of reporting owners as known, since workspaces whose owner is
+// reported missing are deleted.
+type OwnerChecker interface {
+	OwnerExists(ctx context.Context, owner *tenancyv1alpha1.ClusterWorkspaceOwner) (bool, error)
+}
+
+// staticOwnerChecker knows a fixed set of users and groups.
+type staticOwnerChecker struct {
+	users  sets.String
+	groups sets.String
+}
+
+// NewStaticOwnerChecker returns an OwnerChecker knowing the given users and groups. An owner is known if
+// either the user or any of the owner groups is.
+func NewStaticOwnerChecker(users, groups []string) OwnerChecker {
+	return &staticOwnerChecker{users: sets.NewString(users...), groups: sets.NewString(groups...)}
+}
+
+func (c *staticOwnerChecker) OwnerExists(_ context.Context, owner *tenancyv1alpha1.ClusterWorkspaceOwner) (bool, error) {
+	return c.users.Has(owner.User) || c.groups.HasAny(owner.Groups...), nil
+}
+
+// NewTokenFileOwnerChecker returns an OwnerChecker knowing the users and groups of the given static token file,
+// in the token,user,uid,"group1,group2" format of --token-auth-file. The file is read once.
+func NewTokenFileOwnerChecker(path string) (OwnerChecker, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var users, groups []string
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file %s: %w", path, err)
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("token file %s must have at least 3 columns (token, user name, user uid), found %d on line %d", path, len(record), line)
+		}
+		users = append(users, record[1])
+		if len(record) > 3 {
+			for _, group := range strings.Split(record[3], ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					groups = append(groups, group)
+				}
+			}
+		}
+	}
+	return NewStaticOwnerChecker(users, groups), nil
+}
+
+// checkOwner marks the workspace with the WorkspaceOwnerMissing condition if its owner is not known anymore,
+// and deletes it once the condition has been true for the owner grace period. The condition is removed if the
+// owner becomes known again. Workspaces without owner are left alone.
+func (c *Controller) checkOwner(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	if c.ownerChecker == nil {
+		return nil
+	}
+	if workspace.Status.Owner == nil || workspace.Status.Owner.User == "" {
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceOwnerMissing)
+		return nil
+	}
+
+	exists, err := c.ownerChecker.OwnerExists(ctx, workspace.Status.Owner)
+	if err != nil {
+		return err
+	}
+	if exists {
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceOwnerMissing)
+		return nil
+	}
+
+	logger := logr.FromContextOrDiscard(ctx)
+	if !conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceOwnerMissing) {
+		conditions.Set(workspace, &conditionsv1alpha1.Condition{
+			Type:               tenancyv1alpha1.WorkspaceOwnerMissing,
+			Status:             corev1.ConditionTrue,
+			Severity:           conditionsv1alpha1.ConditionSeverityWarning,
+			Reason:             tenancyv1alpha1.WorkspaceOwnerMissingReasonUnknownOwner,
+			Message:            fmt.Sprintf("Owner %q is not known anymore, the workspace will be deleted after %s.", workspace.Status.Owner.User, c.ownerGCGracePeriod),
+			LastTransitionTime: metav1.NewTime(c.clock.Now().UTC().Truncate(time.Second)),
+		})
+		logger.Info("Workspace owner is missing", "owner", workspace.Status.Owner.User, "gracePeriod", c.ownerGCGracePeriod)
+		c.event(workspace, corev1.EventTypeWarning, EventReasonOwnerMissing, "Owner %q is not known anymore, deleting after %s", workspace.Status.Owner.User, c.ownerGCGracePeriod)
+	}
+
+	missingSince := conditions.GetLastTransitionTime(workspace, tenancyv1alpha1.WorkspaceOwnerMissing)
+	if remaining := c.ownerGCGracePeriod - c.clock.Since(missingSince.Time); remaining > 0 {
+		c.enqueueAfter(workspace, remaining)
+		return nil
+	}
+
+	logger.Info("Deleting workspace with missing owner", "owner", workspace.Status.Owner.User)
+	uid := workspace.UID
+	if err := c.kcpClient.Cluster(workspace.ClusterName).TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, workspace.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	c.event(workspace, corev1.EventTypeWarning, EventReasonOwnerGarbageCollected, "Deleted since owner %q has been missing for %s", workspace.Status.Owner.User, c.ownerGCGracePeriod)
+	return nil
+}
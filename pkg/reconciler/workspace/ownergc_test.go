@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func deletedWorkspaces(kcpClient *kcpfake.Clientset) []string {
+	var deleted []string
+	for _, action := range kcpClient.Actions() {
+		if deletion, ok := action.(clienttesting.DeleteAction); ok {
+			deleted = append(deleted, deletion.GetName())
+		}
+	}
+	return deleted
+}
+
+func TestReconcileOwnerGC(t *testing.T) {
+	workspace := newWorkspace("steve")
+	workspace.Annotations = map[string]string{
+		tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+		tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+	}
+	kcpClient := kcpfake.NewSimpleClientset(workspace.DeepCopy())
+
+	recorder := record.NewFakeRecorder(10)
+	c := newTestController(t, recorder, []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	c.kcpClient = fakeClusterClient{kcpClient}
+	fakeClock := clocktesting.NewFakeClock(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	c.clock = fakeClock
+	// user-1 was removed from the authenticators
+	c.ownerChecker = NewStaticOwnerChecker([]string{"user-2"}, []string{"team-2"})
+	c.ownerGCGracePeriod = time.Hour
+	queue := &delayRecordingQueue{delays: map[interface{}]time.Duration{}}
+	c.queue = queue
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceOwnerMissing))
+	require.Equal(t, tenancyv1alpha1.WorkspaceOwnerMissingReasonUnknownOwner, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceOwnerMissing))
+	require.Equal(t, time.Hour, queue.delays[key], "expected the workspace to be queued for when the grace period expires")
+	require.Contains(t, drainEvents(recorder), `Warning OwnerMissing Owner "user-1" is not known anymore, deleting after 1h0m0s`)
+	require.Empty(t, deletedWorkspaces(kcpClient))
+
+	fakeClock.Step(40 * time.Minute)
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, 20*time.Minute, queue.delays[key])
+	require.Empty(t, deletedWorkspaces(kcpClient), "the workspace should be kept during the grace period")
+
+	fakeClock.Step(20 * time.Minute)
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, []string{"steve"}, deletedWorkspaces(kcpClient), "expected the workspace to be deleted after the grace period")
+	require.Contains(t, drainEvents(recorder), `Warning OwnerGarbageCollected Deleted since owner "user-1" has been missing for 1h0m0s`)
+}
+
+func TestReconcileOwnerGCKnownOwner(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	c.ownerGCGracePeriod = time.Hour
+	c.ownerChecker = NewStaticOwnerChecker(nil, []string{"team-1"})
+
+	workspace := newWorkspace("steve")
+	workspace.Annotations = map[string]string{
+		tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       "user-1",
+		tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+	}
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceOwnerMissing)
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.False(t, conditions.Has(workspace, tenancyv1alpha1.WorkspaceOwnerMissing), "an owner known through its groups is not missing")
+
+	workspace = newWorkspace("unowned")
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.False(t, conditions.Has(workspace, tenancyv1alpha1.WorkspaceOwnerMissing), "workspaces without owner should not be collected")
+}
+
+func TestNewTokenFileOwnerChecker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.csv")
+	require.NoError(t, ioutil.WriteFile(path, []byte("token-1,user-1,1111\ntoken-2,user-2,2222,\"team-2,team-3\"\n"), 0600))
+
+	checker, err := NewTokenFileOwnerChecker(path)
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		owner    tenancyv1alpha1.ClusterWorkspaceOwner
+		expected bool
+	}{
+		{owner: tenancyv1alpha1.ClusterWorkspaceOwner{User: "user-1"}, expected: true},
+		{owner: tenancyv1alpha1.ClusterWorkspaceOwner{User: "user-4", Groups: []string{"team-3"}}, expected: true},
+		{owner: tenancyv1alpha1.ClusterWorkspaceOwner{User: "user-4", Groups: []string{"team-4"}}, expected: false},
+	} {
+		exists, err := checker.OwnerExists(context.Background(), &tc.owner)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, exists, "unexpected result for %v", tc.owner)
+	}
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("token-1,user-1\n"), 0600))
+	_, err = NewTokenFileOwnerChecker(path)
+	require.Error(t, err, "expected rows without uid to be rejected")
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// defaultQuotaName is the name of the ResourceQuota created in the default namespace of new workspaces.
+const defaultQuotaName = "default"
+
+// parseResourceList parses the quantities of the given resources.
+func parseResourceList(quantities map[string]string) (corev1.ResourceList, error) {
+	names := make([]string, 0, len(quantities))
+	for name := range quantities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make(corev1.ResourceList, len(quantities))
+	for _, name := range names {
+		q, err := resource.ParseQuantity(quantities[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q for resource %q: %w", quantities[name], name, err)
+		}
+		list[corev1.ResourceName(name)] = q
+	}
+	return list, nil
+}
+
+// bootstrapQuota creates the default ResourceQuota in the default namespace of the workspace, unless no
+// default quota is configured, it was created before, or the workspace type already created a ResourceQuota
+// there. It is called once all initializers are done, such that the content of the type is in place. The calls go
+// to the shard of the workspace, through its circuit breaker.
+func (c *Controller) bootstrapQuota(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	if len(c.defaultQuota) == 0 || conditions.Has(workspace, tenancyv1alpha1.WorkspaceQuotaBootstrapped) {
+		return nil
+	}
+	logger := logr.FromContextOrDiscard(ctx)
+
+	logicalCluster, err := tenancyhelper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return err
+	}
+	kubeClient := c.kubeClient.Cluster(logicalCluster)
+
+	var definedByType string
+	if err := c.shardBreakers.call(ctx, workspace.Status.Location.Current, func(ctx context.Context) error {
+		quotas, err := kubeClient.CoreV1().ResourceQuotas(metav1.NamespaceDefault).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		if len(quotas.Items) > 0 {
+			definedByType = quotas.Items[0].Name
+			return nil
+		}
+
+		if _, err := kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceDefault},
+		}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		if _, err := kubeClient.CoreV1().ResourceQuotas(metav1.NamespaceDefault).Create(ctx, &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: defaultQuotaName},
+			Spec: corev1.ResourceQuotaSpec{
+				Hard: c.defaultQuota.DeepCopy(),
+			},
+		}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if definedByType != "" {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceQuotaBootstrapped, tenancyv1alpha1.WorkspaceQuotaBootstrappedReasonDefinedByType, conditionsv1alpha1.ConditionSeverityInfo, "ResourceQuota %q is defined by the workspace type.", definedByType)
+		logger.Info("Not creating the default quota of workspace defining its own", "resourceQuota", definedByType)
+		return nil
+	}
+
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceQuotaBootstrapped)
+	logger.Info("Created the default quota of workspace")
+	return nil
+}
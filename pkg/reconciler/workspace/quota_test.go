@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// fakeKubeClusterClient returns the same fake clientset for every logical cluster.
+type fakeKubeClusterClient struct {
+	*kubefake.Clientset
+}
+
+func (c fakeKubeClusterClient) Cluster(string) kubernetes.Interface {
+	return c.Clientset
+}
+
+func TestBootstrapQuota(t *testing.T) {
+	defaultQuota := corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}
+	typeQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "from-type", Namespace: metav1.NamespaceDefault}}
+
+	for _, tc := range []struct {
+		name          string
+		defaultQuota  corev1.ResourceList
+		existing      []runtime.Object
+		bootstrapped  bool
+		wantCondition *bool
+		wantQuota     bool
+	}{
+		{
+			name: "no default quota configured",
+		},
+		{
+			name:          "default quota created",
+			defaultQuota:  defaultQuota,
+			wantCondition: boolPtr(true),
+			wantQuota:     true,
+		},
+		{
+			name:          "default namespace exists",
+			defaultQuota:  defaultQuota,
+			existing:      []runtime.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceDefault}}},
+			wantCondition: boolPtr(true),
+			wantQuota:     true,
+		},
+		{
+			name:          "quota defined by the workspace type",
+			defaultQuota:  defaultQuota,
+			existing:      []runtime.Object{typeQuota},
+			wantCondition: boolPtr(false),
+		},
+		{
+			name:          "already bootstrapped",
+			defaultQuota:  defaultQuota,
+			bootstrapped:  true,
+			wantCondition: boolPtr(true),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			workspace := newWorkspace("steve")
+			workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseInitializing
+			if tc.bootstrapped {
+				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceQuotaBootstrapped)
+			}
+
+			kubeClient := kubefake.NewSimpleClientset(tc.existing...)
+			c := newTestController(t, record.NewFakeRecorder(10), nil, workspace)
+			c.kubeClient = fakeKubeClusterClient{kubeClient}
+			c.defaultQuota = tc.defaultQuota
+
+			require.NoError(t, c.reconcile(context.Background(), workspace))
+			require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseReady, workspace.Status.Phase)
+
+			if tc.wantCondition == nil {
+				require.False(t, conditions.Has(workspace, tenancyv1alpha1.WorkspaceQuotaBootstrapped))
+			} else {
+				require.Equal(t, *tc.wantCondition, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceQuotaBootstrapped))
+			}
+
+			quota, err := kubeClient.CoreV1().ResourceQuotas(metav1.NamespaceDefault).Get(context.Background(), defaultQuotaName, metav1.GetOptions{})
+			if !tc.wantQuota {
+				require.Error(t, err, "expected no default quota")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, defaultQuota, quota.Spec.Hard)
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// RandomShardSelection schedules workspaces onto a random shard.
+	RandomShardSelection = "random"
+	// RoundRobinShardSelection schedules workspaces onto the shards in turn, in the order of their names.
+	RoundRobinShardSelection = "round-robin"
+	// LeastLoadedShardSelection schedules workspaces onto the shard with the lowest status.currentWorkspaces.
+	LeastLoadedShardSelection = "least-loaded"
+)
+
+// ShardSelector picks the shard a workspace is scheduled or moved onto. It is only handed
+// schedulable shards with free capacity, and is never called without candidates.
+// Implementations must be safe for concurrent use.
+type ShardSelector interface {
+	SelectShard(candidates []*tenancyv1alpha1.WorkspaceShard) *tenancyv1alpha1.WorkspaceShard
+}
+
+var (
+	shardSelectorsLock sync.RWMutex
+	shardSelectors     = map[string]func() ShardSelector{
+		RandomShardSelection:      NewRandomShardSelector,
+		RoundRobinShardSelection:  NewRoundRobinShardSelector,
+		LeastLoadedShardSelection: NewLeastLoadedShardSelector,
+	}
+)
+
+// RegisterShardSelector makes a custom shard selection strategy available under the given
+// name to --shard-selection-strategy. It must be called before the options are validated.
+func RegisterShardSelector(name string, factory func() ShardSelector) {
+	shardSelectorsLock.Lock()
+	defer shardSelectorsLock.Unlock()
+	shardSelectors[name] = factory
+}
+
+// newShardSelector returns a new shard selector for the strategy registered under the given name.
+func newShardSelector(name string) (ShardSelector, error) {
+	shardSelectorsLock.RLock()
+	factory, found := shardSelectors[name]
+	shardSelectorsLock.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unknown shard selection strategy %q, known strategies are: %v", name, shardSelectorNames())
+	}
+	return factory(), nil
+}
+
+// shardSelectorNames returns the sorted names of the registered shard selection strategies.
+func shardSelectorNames() []string {
+	shardSelectorsLock.RLock()
+	defer shardSelectorsLock.RUnlock()
+	names := make([]string, 0, len(shardSelectors))
+	for name := range shardSelectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type randomShardSelector struct{}
+
+// NewRandomShardSelector returns a ShardSelector picking one of the candidates randomly.
+func NewRandomShardSelector() ShardSelector {
+	return randomShardSelector{}
+}
+
+func (randomShardSelector) SelectShard(candidates []*tenancyv1alpha1.WorkspaceShard) *tenancyv1alpha1.WorkspaceShard {
+	return candidates[rand.Intn(len(candidates))]
+}
+
+type roundRobinShardSelector struct {
+	lock sync.Mutex
+	last string
+}
+
+// NewRoundRobinShardSelector returns a ShardSelector picking the candidates in turn, ordered by
+// name. Shards coming and going between calls do not disturb the order of the others.
+func NewRoundRobinShardSelector() ShardSelector {
+	return &roundRobinShardSelector{}
+}
+
+func (s *roundRobinShardSelector) SelectShard(candidates []*tenancyv1alpha1.WorkspaceShard) *tenancyv1alpha1.WorkspaceShard {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// pick the candidate following the last selected one by name, wrapping around to the first
+	var first, next *tenancyv1alpha1.WorkspaceShard
+	for _, shard := range candidates {
+		if first == nil || shard.Name < first.Name {
+			first = shard
+		}
+		if shard.Name > s.last && (next == nil || shard.Name < next.Name) {
+			next = shard
+		}
+	}
+	if next == nil {
+		next = first
+	}
+	s.last = next.Name
+	return next
+}
+
+type leastLoadedShardSelector struct{}
+
+// NewLeastLoadedShardSelector returns a ShardSelector picking the candidate with the fewest
+// workspaces according to status.currentWorkspaces, randomly between equally loaded shards.
+func NewLeastLoadedShardSelector() ShardSelector {
+	return leastLoadedShardSelector{}
+}
+
+func (leastLoadedShardSelector) SelectShard(candidates []*tenancyv1alpha1.WorkspaceShard) *tenancyv1alpha1.WorkspaceShard {
+	var leastLoaded []*tenancyv1alpha1.WorkspaceShard
+	for _, shard := range candidates {
+		switch {
+		case len(leastLoaded) == 0 || shard.Status.CurrentWorkspaces < leastLoaded[0].Status.CurrentWorkspaces:
+			leastLoaded = []*tenancyv1alpha1.WorkspaceShard{shard}
+		case shard.Status.CurrentWorkspaces == leastLoaded[0].Status.CurrentWorkspaces:
+			leastLoaded = append(leastLoaded, shard)
+		}
+	}
+	return leastLoaded[rand.Intn(len(leastLoaded))]
+}
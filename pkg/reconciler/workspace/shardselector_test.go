@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func newCandidates(currentWorkspaces map[string]int32) []*tenancyv1alpha1.WorkspaceShard {
+	// deliberately not sorted by name
+	var shards []*tenancyv1alpha1.WorkspaceShard
+	for _, name := range []string{"paris", "boston", "london"} {
+		shard := newShard(name, "https://"+name+".kcp.dev")
+		shard.Status.CurrentWorkspaces = currentWorkspaces[name]
+		shards = append(shards, shard)
+	}
+	return shards
+}
+
+func TestRandomShardSelector(t *testing.T) {
+	candidates := newCandidates(nil)
+	selector := NewRandomShardSelector()
+
+	selected := map[string]int{}
+	for i := 0; i < 300; i++ {
+		selected[selector.SelectShard(candidates).Name]++
+	}
+	require.Len(t, selected, 3, "expected every candidate to be selected eventually, got %v", selected)
+}
+
+func TestRoundRobinShardSelector(t *testing.T) {
+	candidates := newCandidates(nil)
+	selector := NewRoundRobinShardSelector()
+
+	var selected []string
+	for i := 0; i < 4; i++ {
+		selected = append(selected, selector.SelectShard(candidates).Name)
+	}
+	require.Equal(t, []string{"boston", "london", "paris", "boston"}, selected)
+
+	// london going away does not disturb the order of the others
+	withoutLondon := []*tenancyv1alpha1.WorkspaceShard{candidates[0], candidates[1]}
+	require.Equal(t, "paris", selector.SelectShard(withoutLondon).Name)
+	require.Equal(t, "boston", selector.SelectShard(withoutLondon).Name)
+}
+
+func TestLeastLoadedShardSelector(t *testing.T) {
+	selector := NewLeastLoadedShardSelector()
+
+	candidates := newCandidates(map[string]int32{"paris": 3, "boston": 5, "london": 1})
+	for i := 0; i < 10; i++ {
+		require.Equal(t, "london", selector.SelectShard(candidates).Name)
+	}
+
+	candidates = newCandidates(map[string]int32{"paris": 2, "boston": 2, "london": 4})
+	selected := map[string]int{}
+	for i := 0; i < 100; i++ {
+		selected[selector.SelectShard(candidates).Name]++
+	}
+	require.Len(t, selected, 2, "expected both equally loaded shards to be selected eventually, got %v", selected)
+	require.Zero(t, selected["london"])
+}
+
+func TestNewShardSelector(t *testing.T) {
+	for _, name := range []string{RandomShardSelection, RoundRobinShardSelection, LeastLoadedShardSelection} {
+		selector, err := newShardSelector(name)
+		require.NoError(t, err)
+		require.NotNil(t, selector)
+	}
+
+	_, err := newShardSelector("first-match")
+	require.Error(t, err)
+
+	RegisterShardSelector("first-match", func() ShardSelector { return firstShardSelector{} })
+	selector, err := newShardSelector("first-match")
+	require.NoError(t, err)
+	require.Equal(t, "paris", selector.SelectShard(newCandidates(nil)).Name)
+}
+
+type firstShardSelector struct{}
+
+func (firstShardSelector) SelectShard(candidates []*tenancyv1alpha1.WorkspaceShard) *tenancyv1alpha1.WorkspaceShard {
+	return candidates[0]
+}
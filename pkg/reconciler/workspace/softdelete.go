@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// collectSoftDeleted deletes the workspace once the retention window of its soft-deleted-until annotation is
+// over, and requeues it for then otherwise. Workspaces which are not soft-deleted are left alone, as are those
+// with an unparsable annotation, which are reported through an event.
+func (c *Controller) collectSoftDeleted(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	value, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation]
+	if !found || !workspace.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	logger := logr.FromContextOrDiscard(ctx)
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		logger.Error(err, "Invalid soft-deletion annotation", "value", value)
+		c.event(workspace, corev1.EventTypeWarning, EventReasonSoftDeletionExpired, "Invalid %s annotation %q, not deleting: %v", tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation, value, err)
+		return nil
+	}
+	if remaining := until.Sub(c.clock.Now()); remaining > 0 {
+		c.enqueueAfter(workspace, remaining)
+		return nil
+	}
+
+	logger.Info("Deleting soft-deleted workspace", "deletedUntil", value)
+	uid := workspace.UID
+	if err := c.kcpClient.Cluster(workspace.ClusterName).TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, workspace.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	c.event(workspace, corev1.EventTypeNormal, EventReasonSoftDeletionExpired, "Deleted since its retention window ended at %s", value)
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestReconcileSoftDeleted(t *testing.T) {
+	workspace := newWorkspace("steve")
+	workspace.Annotations = map[string]string{
+		tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation: "2022-03-01T13:00:00Z",
+	}
+	kcpClient := kcpfake.NewSimpleClientset(workspace.DeepCopy())
+
+	recorder := record.NewFakeRecorder(10)
+	c := newTestController(t, recorder, []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	c.kcpClient = fakeClusterClient{kcpClient}
+	fakeClock := clocktesting.NewFakeClock(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	c.clock = fakeClock
+	queue := &delayRecordingQueue{delays: map[interface{}]time.Duration{}}
+	c.queue = queue
+
+	key, err := cache.MetaNamespaceKeyFunc(workspace)
+	require.NoError(t, err)
+
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, time.Hour, queue.delays[key], "expected the workspace to be queued for when the retention window ends")
+	require.Empty(t, deletedWorkspaces(kcpClient), "the workspace should be kept during the retention window")
+
+	fakeClock.Step(time.Hour)
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, []string{"steve"}, deletedWorkspaces(kcpClient), "expected the workspace to be deleted after the retention window")
+	require.Contains(t, drainEvents(recorder), "Normal SoftDeletionExpired Deleted since its retention window ended at 2022-03-01T13:00:00Z")
+}
+
+func TestReconcileSoftDeletedInvalidAnnotation(t *testing.T) {
+	workspace := newWorkspace("steve")
+	workspace.Annotations = map[string]string{
+		tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation: "tomorrow",
+	}
+	kcpClient := kcpfake.NewSimpleClientset(workspace.DeepCopy())
+
+	recorder := record.NewFakeRecorder(10)
+	c := newTestController(t, recorder, []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	c.kcpClient = fakeClusterClient{kcpClient}
+
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Empty(t, deletedWorkspaces(kcpClient))
+	require.Contains(t, strings.Join(drainEvents(recorder), "\n"), `Warning SoftDeletionExpired Invalid tenancy.kcp.dev/soft-deleted-until annotation "tomorrow", not deleting`)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// refreshURLs recomputes the URLs of the workspace from the current address of the given shard it is scheduled
+// onto, without reassigning the shard. The URLs are left untouched when they did not change, such that the
+// status is not updated needlessly. Otherwise the WorkspaceURLRefreshed condition records the previous base URL.
+func (c *Controller) refreshURLs(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.WorkspaceShard) {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	baseURL, internalBaseURL, err := c.workspaceURLs(workspace, shard)
+	if err != nil {
+		logger.Error(err, "Cannot recompute the URLs of the workspace")
+		return
+	}
+	if baseURL == workspace.Status.BaseURL && internalBaseURL == workspace.Status.InternalBaseURL {
+		return
+	}
+
+	previous := workspace.Status.BaseURL
+	workspace.Status.BaseURL = baseURL
+	workspace.Status.InternalBaseURL = internalBaseURL
+	conditions.Set(workspace, &conditionsv1alpha1.Condition{
+		Type:    tenancyv1alpha1.WorkspaceURLRefreshed,
+		Status:  corev1.ConditionTrue,
+		Reason:  tenancyv1alpha1.WorkspaceURLRefreshedReasonShardAddressChanged,
+		Message: fmt.Sprintf("Base URL changed from %q to %q.", previous, baseURL),
+	})
+	logger.Info("Recomputed URLs of workspace", "baseURL", baseURL, "previousBaseURL", previous)
+	c.event(workspace, corev1.EventTypeNormal, EventReasonBaseURLComputed, "Computed base URL %q on shard %q", baseURL, shard.Name)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func newScheduledWorkspace() *tenancyv1alpha1.ClusterWorkspace {
+	workspace := newWorkspace("steve")
+	workspace.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+	workspace.Status.Location.Current = "boston"
+	workspace.Status.BaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	workspace.Status.InternalBaseURL = "https://boston.kcp.dev/clusters/org:steve"
+	workspace.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation: "true"}
+	return workspace
+}
+
+func TestRefreshURL(t *testing.T) {
+	moved := newShard("boston", "https://boston.example.com")
+	paris := newShard("paris", "https://paris.kcp.dev")
+
+	t.Run("refresh after a change of the shard address", func(t *testing.T) {
+		workspace := newScheduledWorkspace()
+		c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{moved, paris}, workspace)
+
+		require.NoError(t, c.reconcile(context.Background(), workspace))
+		require.Equal(t, "boston", workspace.Status.Location.Current, "expected the shard to be kept")
+		require.Equal(t, "https://boston.example.com/clusters/org:steve", workspace.Status.BaseURL)
+		require.Equal(t, "https://boston.example.com/clusters/org:steve", workspace.Status.InternalBaseURL)
+		require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceURLRefreshed))
+		require.Equal(t, tenancyv1alpha1.WorkspaceURLRefreshedReasonShardAddressChanged, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceURLRefreshed))
+		require.Contains(t, conditions.GetMessage(workspace, tenancyv1alpha1.WorkspaceURLRefreshed), `"https://boston.kcp.dev/clusters/org:steve"`)
+		require.NotContains(t, workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation)
+
+		t.Run("refreshing again is a no-op", func(t *testing.T) {
+			refreshed := workspace.DeepCopy()
+			refreshed.Annotations[tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation] = "true"
+			require.NoError(t, c.reconcile(context.Background(), refreshed))
+			require.Equal(t, workspace.Status, refreshed.Status)
+		})
+	})
+
+	t.Run("unchanged address sets no condition", func(t *testing.T) {
+		workspace := newScheduledWorkspace()
+		c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")}, workspace)
+
+		require.NoError(t, c.reconcile(context.Background(), workspace))
+		require.Equal(t, "https://boston.kcp.dev/clusters/org:steve", workspace.Status.BaseURL)
+		require.Nil(t, conditions.Get(workspace, tenancyv1alpha1.WorkspaceURLRefreshed))
+		require.NotContains(t, workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation)
+	})
+
+	t.Run("unreachable shard is only refreshed on request", func(t *testing.T) {
+		unreachable := moved.DeepCopy()
+		conditions.MarkFalse(unreachable, tenancyv1alpha1.ShardReachable, tenancyv1alpha1.ShardReachableReasonUnhealthy, conditionsv1alpha1.ConditionSeverityError, "Health check failed.")
+
+		workspace := newScheduledWorkspace()
+		delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation)
+		c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{unreachable}, workspace)
+		require.NoError(t, c.reconcile(context.Background(), workspace))
+		require.Equal(t, "https://boston.kcp.dev/clusters/org:steve", workspace.Status.BaseURL)
+
+		workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation] = "true"
+		require.NoError(t, c.reconcile(context.Background(), workspace))
+		require.Equal(t, "boston", workspace.Status.Location.Current)
+		require.Equal(t, "https://boston.example.com/clusters/org:steve", workspace.Status.BaseURL)
+		require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceURLRefreshed))
+	})
+}
+
+func TestEnqueueWorkspacesOfShardWithChangedAddress(t *testing.T) {
+	shard := newShard("boston", "https://boston.kcp.dev")
+	workspace := newScheduledWorkspace()
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{shard}, workspace)
+	c.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer c.queue.ShutDown()
+
+	c.enqueueWorkspacesOfChangedShard(shard, shard.DeepCopy())
+	require.Zero(t, c.queue.Len(), "expected no workspace to be queued for an unchanged shard")
+
+	c.enqueueWorkspacesOfChangedShard(shard, newShard("boston", "https://boston.example.com"))
+	require.Equal(t, 1, c.queue.Len(), "expected the workspace scheduled onto the shard to be queued")
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// resolveType resolves spec.type of the workspace to the name of its ClusterWorkspaceType in the same
+// logical cluster, records it in status.type and reflects the outcome in the WorkspaceTypeValid condition.
+// The default type is valid without a ClusterWorkspaceType of that name. It returns whether the type is valid.
+func (c *Controller) resolveType(workspace *tenancyv1alpha1.ClusterWorkspace) (bool, error) {
+	typeName := strings.ToLower(workspace.Spec.Type)
+	if typeName == "" {
+		typeName = c.defaultWorkspaceType
+	}
+
+	if typeName != c.defaultWorkspaceType {
+		_, err := c.workspaceTypeLister.Get(clusters.ToClusterAwareKey(workspace.ClusterName, typeName))
+		if errors.IsNotFound(err) {
+			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceTypeValid, tenancyv1alpha1.WorkspaceTypeValidReasonTypeNotFound, conditionsv1alpha1.ConditionSeverityError, "ClusterWorkspaceType %q does not exist in workspace %q.", typeName, workspace.ClusterName)
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+
+	workspace.Status.Type = typeName
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceTypeValid)
+	return true, nil
+}
+
+// enqueueWorkspacesOfType queues the workspaces of the given ClusterWorkspaceType in its logical cluster,
+// such that they are scheduled once their type exists, and marked with WorkspaceTypeValid false once
+// it is deleted.
+func (c *Controller) enqueueWorkspacesOfType(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cwt, ok := obj.(*tenancyv1alpha1.ClusterWorkspaceType)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("got %T when handling ClusterWorkspaceType", obj))
+		return
+	}
+	workspaces, err := c.workspaceIndexer.ByIndex(clusterNameIndex, cwt.ClusterName)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, obj := range workspaces {
+		workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+		if !ok || strings.ToLower(workspace.Spec.Type) != cwt.Name {
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(workspace)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		c.logger.V(2).Info("Queueing workspace of changed type", "key", key, "type", cwt.Name)
+		c.queue.Add(key)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcileWorkspaceType(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		workspaceType string
+		types         []*tenancyv1alpha1.ClusterWorkspaceType
+		wantType      string
+		wantValid     bool
+	}{
+		{
+			name:      "no type resolves to the default",
+			wantType:  "universal",
+			wantValid: true,
+		},
+		{
+			name:          "default type without ClusterWorkspaceType",
+			workspaceType: "Universal",
+			wantType:      "universal",
+			wantValid:     true,
+		},
+		{
+			name:          "existing type",
+			workspaceType: "Team",
+			types:         []*tenancyv1alpha1.ClusterWorkspaceType{{ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"}}},
+			wantType:      "team",
+			wantValid:     true,
+		},
+		{
+			name:          "type in another workspace",
+			workspaceType: "Team",
+			types:         []*tenancyv1alpha1.ClusterWorkspaceType{{ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:other"}}},
+		},
+		{
+			name:          "missing type",
+			workspaceType: "Team",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+			typeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, cwt := range tc.types {
+				require.NoError(t, typeIndexer.Add(cwt))
+			}
+			c.workspaceTypeLister = tenancylister.NewClusterWorkspaceTypeLister(typeIndexer)
+
+			workspace := newWorkspace("steve")
+			workspace.Spec.Type = tc.workspaceType
+			require.NoError(t, c.reconcile(context.Background(), workspace))
+
+			require.Equal(t, tc.wantType, workspace.Status.Type)
+			if tc.wantValid {
+				require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceTypeValid))
+				require.Equal(t, "boston", workspace.Status.Location.Current)
+				return
+			}
+			require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceTypeValid))
+			require.Equal(t, tenancyv1alpha1.WorkspaceTypeValidReasonTypeNotFound, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceTypeValid))
+			require.Empty(t, workspace.Status.Location.Current, "workspaces of a missing type are not scheduled")
+		})
+	}
+}
+
+func TestReconcileNonDefaultWorkspaceType(t *testing.T) {
+	c := newTestController(t, record.NewFakeRecorder(10), []*tenancyv1alpha1.WorkspaceShard{newShard("boston", "https://boston.kcp.dev")})
+	c.defaultWorkspaceType = "team"
+
+	workspace := newWorkspace("steve")
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.Equal(t, "team", workspace.Status.Type)
+	require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceTypeValid))
+
+	workspace = newWorkspace("joe")
+	workspace.Spec.Type = "Universal"
+	require.NoError(t, c.reconcile(context.Background(), workspace))
+	require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceTypeValid), "universal is not special with another default type")
+	require.Empty(t, workspace.Status.Location.Current)
+}
+
+func TestEnqueueWorkspacesOfType(t *testing.T) {
+	team := newWorkspace("team-ws")
+	team.Spec.Type = "Team"
+	other := newWorkspace("other-ws")
+	other.Spec.Type = "Other"
+	elsewhere := newWorkspace("elsewhere-ws")
+	elsewhere.ClusterName = "root:other"
+	elsewhere.Spec.Type = "Team"
+
+	c := newTestController(t, record.NewFakeRecorder(10), nil, team, other, elsewhere)
+	c.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer c.queue.ShutDown()
+
+	c.enqueueWorkspacesOfType(&tenancyv1alpha1.ClusterWorkspaceType{ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"}})
+	require.Equal(t, 1, c.queue.Len())
+	key, _ := c.queue.Get()
+	require.Equal(t, "root:org#$#team-ws", key)
+}
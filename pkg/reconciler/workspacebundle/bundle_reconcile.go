@@ -0,0 +1,326 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacebundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+const (
+	// downloadTimeout bounds the download of a bundle, to not block the controller.
+	downloadTimeout = 30 * time.Second
+	// maxBundleSize is the maximal size in bytes of a downloaded bundle.
+	maxBundleSize = 10 << 20
+	// maxDecompressedBundleSize is the maximal size in bytes of the tar archive of a gzipped bundle.
+	maxDecompressedBundleSize = 100 << 20
+	// maxRedirects is the maximal number of redirects followed when downloading a bundle.
+	maxRedirects = 10
+)
+
+// reconcile applies the template of the type of an initializing workspace, then its bootstrap bundle, such that
+// the bundle can rely on the namespaces and CRDs of the template.
+func (c *controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing {
+		return nil
+	}
+
+	if hasInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceTemplateInitializer) {
+		if err := c.reconcileTemplate(ctx, workspace); err != nil {
+			return err
+		}
+		if hasInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceTemplateInitializer) {
+			return nil // the bundle waits for the template
+		}
+	}
+	if hasInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer) {
+		return c.reconcileBundle(ctx, workspace)
+	}
+	return nil
+}
+
+func (c *controller) reconcileBundle(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+
+	bundleURL, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation]
+	if !found {
+		// the annotation was removed, there is nothing left to apply
+		removeInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer)
+		return nil
+	}
+
+	u, err := helper.ParseBootstrapBundleURL(bundleURL)
+	if err != nil {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonInvalidBundle, conditionsv1alpha1.ConditionSeverityError, "Invalid bundle URL %q: %v.", bundleURL, err)
+		return nil // the annotations are validated on creation
+	}
+	if !c.allowedHosts.Has(u.Host) {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonHostNotAllowed, conditionsv1alpha1.ConditionSeverityError, "Bundles are not downloaded from host %q.", u.Host)
+		return nil // only the configuration of the workspace scheduler can change that
+	}
+	sum, err := helper.ParseBootstrapBundleSHA256(workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceBootstrapBundleSHA256Annotation])
+	if err != nil {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonChecksumMismatch, conditionsv1alpha1.ConditionSeverityError, "Invalid bundle checksum: %v.", err)
+		return nil
+	}
+
+	downloadCtx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+	bundle, err := c.download(downloadCtx, u.String())
+	if err != nil {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonDownloadFailed, conditionsv1alpha1.ConditionSeverityWarning, "Failed to download bundle %q: %v.", bundleURL, err)
+		return err // requeue
+	}
+	if actual := sha256.Sum256(bundle); !bytes.Equal(actual[:], sum) {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonChecksumMismatch, conditionsv1alpha1.ConditionSeverityError, "Bundle %q has checksum %s, expected %s.", bundleURL, hex.EncodeToString(actual[:]), hex.EncodeToString(sum))
+		return nil // downloading the same bundle again does not help
+	}
+	objs, err := decodeBundle(bundle, maxDecompressedBundleSize)
+	if err != nil {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonInvalidBundle, conditionsv1alpha1.ConditionSeverityError, "Invalid bundle %q: %v.", bundleURL, err)
+		return nil
+	}
+	if err := validateKinds(objs); err != nil {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonKindNotAllowed, conditionsv1alpha1.ConditionSeverityError, "Invalid bundle %q: %v.", bundleURL, err)
+		return nil
+	}
+
+	wsClusterName, err := helper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Applying %d objects of bundle %s to workspace %s|%s, logical cluster %s", len(objs), bundleURL, workspace.ClusterName, workspace.Name, wsClusterName)
+	applyCtx, cancel := context.WithTimeout(ctx, 30*time.Second) // to not block the controller
+	defer cancel()
+	if err := apply(applyCtx, c.clientsFor(wsClusterName), objs); err != nil {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonApplyFailed, conditionsv1alpha1.ConditionSeverityWarning, "Failed to apply bundle %q: %v.", bundleURL, err)
+		return err // requeue
+	}
+
+	// we are done. remove our initializer
+	conditions.Set(workspace, &conditionsv1alpha1.Condition{
+		Type:   tenancyv1alpha1.WorkspaceBootstrapFailed,
+		Status: corev1.ConditionFalse,
+	})
+	removeInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer)
+
+	return nil
+}
+
+func markFailed(workspace *tenancyv1alpha1.ClusterWorkspace, reason string, severity conditionsv1alpha1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) {
+	conditions.Set(workspace, &conditionsv1alpha1.Condition{
+		Type:     tenancyv1alpha1.WorkspaceBootstrapFailed,
+		Status:   corev1.ConditionTrue,
+		Severity: severity,
+		Reason:   reason,
+		Message:  fmt.Sprintf(messageFormat, messageArgs...),
+	})
+}
+
+func hasInitializer(workspace *tenancyv1alpha1.ClusterWorkspace, initializer tenancyv1alpha1.ClusterWorkspaceInitializer) bool {
+	for _, i := range workspace.Status.Initializers {
+		if i == initializer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeInitializer(workspace *tenancyv1alpha1.ClusterWorkspace, initializer tenancyv1alpha1.ClusterWorkspaceInitializer) {
+	newInitializers := make([]tenancyv1alpha1.ClusterWorkspaceInitializer, 0, len(workspace.Status.Initializers))
+	for _, i := range workspace.Status.Initializers {
+		if i != initializer {
+			newInitializers = append(newInitializers, i)
+		}
+	}
+	workspace.Status.Initializers = newInitializers
+}
+
+// checkRedirect only follows redirects to the allowed hosts, such that an allowed host cannot make the controller
+// download from any other host.
+func (c *controller) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to %q: expected http or https scheme", req.URL.Redacted())
+	}
+	if !c.allowedHosts.Has(req.URL.Host) {
+		return fmt.Errorf("redirect to %q: bundles are not downloaded from host %q", req.URL.Redacted(), req.URL.Host)
+	}
+	return nil
+}
+
+// download returns the content at the given URL, failing if it is larger than maxBundleSize.
+func (c *controller) download(ctx context.Context, bundleURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bundleURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	bundle, err := io.ReadAll(io.LimitReader(resp.Body, maxBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(bundle) > maxBundleSize {
+		return nil, fmt.Errorf("bundle is larger than %d bytes", maxBundleSize)
+	}
+	return bundle, nil
+}
+
+// decodeBundle returns the objects of the YAML or JSON files of a tar archive, optionally gzipped, in the
+// order of the archive. Other files, like a README, are ignored. It fails if a gzipped archive decompresses
+// to more than maxDecompressedSize bytes.
+func decodeBundle(bundle []byte, maxDecompressedSize int64) ([]*unstructured.Unstructured, error) {
+	var r io.Reader = bytes.NewReader(bundle)
+	if len(bundle) >= 2 && bundle[0] == 0x1f && bundle[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = &sizeLimitedReader{r: gz, limit: maxDecompressedSize}
+	}
+
+	var objs []*unstructured.Unstructured
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		switch path.Ext(hdr.Name) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		d := kubeyaml.NewYAMLReader(bufio.NewReader(tr))
+		for i := 1; ; i++ {
+			doc, err := d.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+			}
+			if len(bytes.TrimSpace(doc)) == 0 {
+				continue
+			}
+			obj := &unstructured.Unstructured{}
+			if err := kubeyaml.Unmarshal(doc, &obj.Object); err != nil {
+				return nil, fmt.Errorf("%s doc %d: %w", hdr.Name, i, err)
+			}
+			if obj.Object == nil {
+				continue // only comments
+			}
+			if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+				return nil, fmt.Errorf("%s doc %d: apiVersion, kind and metadata.name are required", hdr.Name, i)
+			}
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
+
+// sizeLimitedReader reads from r, failing instead of returning more than limit bytes.
+type sizeLimitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.read >= l.limit {
+		// anything left is beyond the limit
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("decompressed bundle is larger than %d bytes", l.limit)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.limit-l.read {
+		p = p[:l.limit-l.read]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
+
+// validateKinds checks that a bundle only holds the kinds of objects a ClusterWorkspaceType template can create,
+// before any of them is applied.
+func validateKinds(objs []*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		if err := helper.ValidateClusterWorkspaceTemplateKind(obj.GroupVersionKind().GroupKind()); err != nil {
+			return fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// apply creates the given objects in order, namespaced objects without namespace in the default namespace. Objects
+// that already exist are left alone, such that a partially applied bundle can be applied again.
+func apply(ctx context.Context, clients clusterClients, objs []*unstructured.Unstructured) error {
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		m, err := clients.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("could not get REST mapping for %s: %w", gvk, err)
+		}
+		if m.Scope.Name() == meta.RESTScopeNameNamespace && obj.GetNamespace() == "" {
+			obj.SetNamespace(metav1.NamespaceDefault)
+		}
+		if _, err := clients.dynamicClient.Resource(m.Resource).Namespace(obj.GetNamespace()).Create(ctx, obj, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("could not create %s %s: %w", gvk.Kind, obj.GetName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacebundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+var (
+	namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	rolesGVR      = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}
+)
+
+// newBundle returns a gzipped tar archive of the given files.
+func newBundle(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range sets.StringKeySet(files).List() {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func checksum(bundle []byte) string {
+	sum := sha256.Sum256(bundle)
+	return hex.EncodeToString(sum[:])
+}
+
+func newClients() clusterClients {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}, meta.RESTScopeNamespace)
+	return clusterClients{
+		dynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			namespacesGVR: "NamespaceList",
+			rolesGVR:      "RoleList",
+		}),
+		mapper: mapper,
+	}
+}
+
+func newWorkspace(bundleURL, sum string) *tenancyv1alpha1.ClusterWorkspace {
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "team",
+			ClusterName: "root:org",
+			Annotations: map[string]string{
+				tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation:    bundleURL,
+				tenancyv1alpha1.ClusterWorkspaceBootstrapBundleSHA256Annotation: sum,
+			},
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"initializers.tenancy.kcp.dev/universal", tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer},
+		},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	bundle := newBundle(t, map[string]string{
+		"README.md": "# Team bundle",
+		"manifests/namespace.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: payments
+`,
+		"manifests/roles.yaml": `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: settings
+  namespace: payments
+rules:
+- apiGroups: [""]
+  resources: ["configmaps"]
+  verbs: ["get"]
+---
+# defaulted to the default namespace
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: team
+`,
+	})
+	notAllowed := newBundle(t, map[string]string{
+		"manifests/namespace.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: payments
+`,
+		"manifests/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: payments
+`,
+	})
+	// an internal host which is not allowed, serving the same bundle
+	internalHits := 0
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalHits++
+		_, _ = w.Write(bundle)
+	}))
+	defer internal.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/team.tar.gz":
+			_, _ = w.Write(bundle)
+		case "/moved.tar.gz":
+			http.Redirect(w, r, "/team.tar.gz", http.StatusFound)
+		case "/internal.tar.gz":
+			http.Redirect(w, r, internal.URL+"/team.tar.gz", http.StatusFound)
+		case "/configmap.tar.gz":
+			_, _ = w.Write(notAllowed)
+		case "/corrupt.tar.gz":
+			_, _ = w.Write([]byte("not a tar archive"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name           string
+		path           string
+		sum            string
+		allowedHosts   []string
+		expectedReason string
+		expectErr      bool
+	}{
+		{
+			name:         "bundle is applied",
+			path:         "/team.tar.gz",
+			sum:          checksum(bundle),
+			allowedHosts: []string{serverURL.Host},
+		},
+		{
+			name:           "host not allowed",
+			path:           "/team.tar.gz",
+			sum:            checksum(bundle),
+			allowedHosts:   []string{"bundles.example.com"},
+			expectedReason: tenancyv1alpha1.WorkspaceBootstrapFailedReasonHostNotAllowed,
+		},
+		{
+			name:         "redirect to an allowed host is followed",
+			path:         "/moved.tar.gz",
+			sum:          checksum(bundle),
+			allowedHosts: []string{serverURL.Host},
+		},
+		{
+			name:           "redirect to a host not allowed is not followed",
+			path:           "/internal.tar.gz",
+			sum:            checksum(bundle),
+			allowedHosts:   []string{serverURL.Host},
+			expectedReason: tenancyv1alpha1.WorkspaceBootstrapFailedReasonDownloadFailed,
+			expectErr:      true,
+		},
+		{
+			name:           "checksum mismatch",
+			path:           "/team.tar.gz",
+			sum:            checksum([]byte("another bundle")),
+			allowedHosts:   []string{serverURL.Host},
+			expectedReason: tenancyv1alpha1.WorkspaceBootstrapFailedReasonChecksumMismatch,
+		},
+		{
+			name:           "download failure is retried",
+			path:           "/missing.tar.gz",
+			sum:            checksum(bundle),
+			allowedHosts:   []string{serverURL.Host},
+			expectedReason: tenancyv1alpha1.WorkspaceBootstrapFailedReasonDownloadFailed,
+			expectErr:      true,
+		},
+		{
+			name:           "invalid bundle",
+			path:           "/corrupt.tar.gz",
+			sum:            checksum([]byte("not a tar archive")),
+			allowedHosts:   []string{serverURL.Host},
+			expectedReason: tenancyv1alpha1.WorkspaceBootstrapFailedReasonInvalidBundle,
+		},
+		{
+			name:           "kind not allowed",
+			path:           "/configmap.tar.gz",
+			sum:            checksum(notAllowed),
+			allowedHosts:   []string{serverURL.Host},
+			expectedReason: tenancyv1alpha1.WorkspaceBootstrapFailedReasonKindNotAllowed,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clients := newClients()
+			c := &controller{
+				clientsFor: func(clusterName string) clusterClients {
+					require.Equal(t, "org:team", clusterName)
+					return clients
+				},
+				httpClient:   server.Client(),
+				allowedHosts: sets.NewString(tc.allowedHosts...),
+			}
+			c.httpClient.CheckRedirect = c.checkRedirect
+			workspace := newWorkspace(server.URL+tc.path, tc.sum)
+
+			err := c.reconcile(context.Background(), workspace)
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Zero(t, internalHits, "expected the internal host never to be reached")
+
+			if tc.expectedReason != "" {
+				require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceBootstrapFailed))
+				require.Equal(t, tc.expectedReason, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceBootstrapFailed))
+				require.Contains(t, workspace.Status.Initializers, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer)
+				namespaces, err := clients.dynamicClient.Resource(namespacesGVR).List(context.Background(), metav1.ListOptions{})
+				require.NoError(t, err)
+				require.Empty(t, namespaces.Items)
+				return
+			}
+
+			require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceBootstrapFailed))
+			require.Equal(t, []tenancyv1alpha1.ClusterWorkspaceInitializer{"initializers.tenancy.kcp.dev/universal"}, workspace.Status.Initializers)
+
+			ctx := context.Background()
+			_, err = clients.dynamicClient.Resource(namespacesGVR).Get(ctx, "payments", metav1.GetOptions{})
+			require.NoError(t, err)
+			settings, err := clients.dynamicClient.Resource(rolesGVR).Namespace("payments").Get(ctx, "settings", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Len(t, settings.Object["rules"], 1)
+			_, err = clients.dynamicClient.Resource(rolesGVR).Namespace(metav1.NamespaceDefault).Get(ctx, "team", metav1.GetOptions{})
+			require.NoError(t, err)
+
+			// applying the bundle again leaves the existing objects alone
+			workspace.Status.Initializers = append(workspace.Status.Initializers, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer)
+			require.NoError(t, c.reconcile(ctx, workspace))
+		})
+	}
+}
+
+func TestReconcileIgnoresWorkspacesWithoutBundleInitializer(t *testing.T) {
+	workspace := newWorkspace("https://bundles.example.com/team.tar.gz", checksum(nil))
+	workspace.Status.Initializers = nil
+	c := &controller{}
+
+	err := c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Nil(t, conditions.Get(workspace, tenancyv1alpha1.WorkspaceBootstrapFailed))
+}
+
+func TestDecodeBundleLimitsDecompressedSize(t *testing.T) {
+	bundle := newBundle(t, map[string]string{
+		"namespace.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: payments
+`,
+		"padding.bin": strings.Repeat("\x00", 1<<20),
+	})
+	require.Less(t, len(bundle), 10<<10, "expected the padding to compress well")
+
+	objs, err := decodeBundle(bundle, 2<<20)
+	require.NoError(t, err)
+	require.Len(t, objs, 1)
+
+	_, err = decodeBundle(bundle, 1<<20)
+	require.EqualError(t, err, "decompressed bundle is larger than 1048576 bytes")
+}
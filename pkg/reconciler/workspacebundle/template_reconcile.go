@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacebundle
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// reconcileTemplate creates the objects of the template of the ClusterWorkspaceType of the workspace inside of it,
+// and removes the template initializer once they exist.
+func (c *controller) reconcileTemplate(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	cwt, err := c.workspaceTypeLister.Get(clusters.ToClusterAwareKey(workspace.ClusterName, strings.ToLower(workspace.Spec.Type)))
+	if errors.IsNotFound(err) {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonTypeNotFound, conditionsv1alpha1.ConditionSeverityWarning, "ClusterWorkspaceType %q does not exist.", workspace.Spec.Type)
+		return err // requeue, the type might be recreated
+	} else if err != nil {
+		return err
+	}
+
+	objs, err := helper.ClusterWorkspaceTemplateObjects(cwt.Spec.Template)
+	if err != nil {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonInvalidTemplate, conditionsv1alpha1.ConditionSeverityError, "Invalid template of ClusterWorkspaceType %q: %v.", workspace.Spec.Type, err)
+		return nil // the template is validated on admission
+	}
+
+	wsClusterName, err := helper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return err
+	}
+	klog.Infof("Applying %d objects of the template of ClusterWorkspaceType %s to workspace %s|%s, logical cluster %s", len(objs), workspace.Spec.Type, workspace.ClusterName, workspace.Name, wsClusterName)
+	applyCtx, cancel := context.WithTimeout(ctx, 30*time.Second) // to not block the controller
+	defer cancel()
+	if err := apply(applyCtx, c.clientsFor(wsClusterName), objs); err != nil {
+		markFailed(workspace, tenancyv1alpha1.WorkspaceBootstrapFailedReasonApplyFailed, conditionsv1alpha1.ConditionSeverityWarning, "Failed to apply the template of ClusterWorkspaceType %q: %v.", workspace.Spec.Type, err)
+		return err // requeue
+	}
+
+	conditions.Set(workspace, &conditionsv1alpha1.Condition{
+		Type:   tenancyv1alpha1.WorkspaceBootstrapFailed,
+		Status: corev1.ConditionFalse,
+	})
+	removeInitializer(workspace, tenancyv1alpha1.ClusterWorkspaceTemplateInitializer)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacebundle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func newTypeLister(t *testing.T, types ...*tenancyv1alpha1.ClusterWorkspaceType) tenancylister.ClusterWorkspaceTypeLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, cwt := range types {
+		require.NoError(t, indexer.Add(cwt))
+	}
+	return tenancylister.NewClusterWorkspaceTypeLister(indexer)
+}
+
+func newTemplateWorkspace(initializers ...tenancyv1alpha1.ClusterWorkspaceInitializer) *tenancyv1alpha1.ClusterWorkspace {
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "team",
+			ClusterName: "root:org",
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+			Type: "Team",
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			Initializers: initializers,
+		},
+	}
+}
+
+func TestReconcileTemplate(t *testing.T) {
+	teamType := &tenancyv1alpha1.ClusterWorkspaceType{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "team",
+			ClusterName: "root:org",
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+			Template: &tenancyv1alpha1.ClusterWorkspaceTemplate{Objects: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"payments"}}`)},
+				{Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"reports"}}`)},
+			}},
+		},
+	}
+
+	clients := newClients()
+	c := &controller{
+		clientsFor: func(clusterName string) clusterClients {
+			require.Equal(t, "org:team", clusterName)
+			return clients
+		},
+		workspaceTypeLister: newTypeLister(t, teamType),
+	}
+	workspace := newTemplateWorkspace("initializers.tenancy.kcp.dev/universal", tenancyv1alpha1.ClusterWorkspaceTemplateInitializer)
+
+	ctx := context.Background()
+	require.NoError(t, c.reconcile(ctx, workspace))
+	require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceBootstrapFailed))
+	require.Equal(t, []tenancyv1alpha1.ClusterWorkspaceInitializer{"initializers.tenancy.kcp.dev/universal"}, workspace.Status.Initializers)
+	for _, name := range []string{"payments", "reports"} {
+		_, err := clients.dynamicClient.Resource(namespacesGVR).Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+	}
+
+	// applying the template again leaves the existing objects alone
+	workspace.Status.Initializers = append(workspace.Status.Initializers, tenancyv1alpha1.ClusterWorkspaceTemplateInitializer)
+	require.NoError(t, c.reconcile(ctx, workspace))
+}
+
+func TestReconcileTemplateOfDeletedType(t *testing.T) {
+	c := &controller{
+		workspaceTypeLister: newTypeLister(t),
+	}
+	workspace := newTemplateWorkspace(tenancyv1alpha1.ClusterWorkspaceTemplateInitializer, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer)
+
+	require.Error(t, c.reconcile(context.Background(), workspace))
+	require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceBootstrapFailed))
+	require.Equal(t, tenancyv1alpha1.WorkspaceBootstrapFailedReasonTypeNotFound, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceBootstrapFailed))
+	// the bundle waits for the template
+	require.Equal(t, []tenancyv1alpha1.ClusterWorkspaceInitializer{tenancyv1alpha1.ClusterWorkspaceTemplateInitializer, tenancyv1alpha1.ClusterWorkspaceBootstrapBundleInitializer}, workspace.Status.Initializers)
+}
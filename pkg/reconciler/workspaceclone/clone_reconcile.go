@@ -0,0 +1,214 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceclone
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// systemPrefix is the name prefix of RBAC objects bootstrapped by the system. They are never cloned.
+const systemPrefix = "system:"
+
+func (c *controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing {
+		return nil
+	}
+
+	// have we done our work before?
+	found := false
+	for _, i := range workspace.Status.Initializers {
+		if i == tenancyv1alpha1.ClusterWorkspaceCloneInitializer {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	sourceName, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation]
+	if !found {
+		// the annotation was removed, there is nothing left to clone
+		removeCloneInitializer(workspace)
+		return nil
+	}
+
+	source, err := c.workspaceLister.Get(clusters.ToClusterAwareKey(workspace.ClusterName, sourceName))
+	if errors.IsNotFound(err) {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceCloneComplete, tenancyv1alpha1.WorkspaceCloneReasonSourceNotFound, conditionsv1alpha1.ConditionSeverityError, "Source workspace %q not found.", sourceName)
+		return nil // requeued when the source is created
+	} else if err != nil {
+		return err
+	}
+	if source.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceCloneComplete, tenancyv1alpha1.WorkspaceCloneReasonSourceNotReady, conditionsv1alpha1.ConditionSeverityInfo, "Source workspace %q is in phase %q.", sourceName, source.Status.Phase)
+		return nil // requeued when the source changes
+	}
+
+	sourceClusterName, err := helper.EncodeLogicalClusterName(source)
+	if err != nil {
+		return err
+	}
+	wsClusterName, err := helper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return err
+	}
+
+	include := sets.NewString()
+	for _, s := range strings.Split(workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneIncludeAnnotation], ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			include.Insert(s)
+		}
+	}
+
+	klog.Infof("Cloning logical cluster %s into workspace %s|%s, logical cluster %s", sourceClusterName, workspace.ClusterName, workspace.Name, wsClusterName)
+	cloneCtx, cancel := context.WithDeadline(ctx, time.Now().Add(time.Second*30)) // to not block the controller
+	defer cancel()
+	if err := clone(cloneCtx, c.clientsFor(sourceClusterName), c.clientsFor(wsClusterName), include.Has(tenancyv1alpha1.ClusterWorkspaceCloneIncludeSecrets)); err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceCloneComplete, tenancyv1alpha1.WorkspaceCloneReasonCopyFailed, conditionsv1alpha1.ConditionSeverityWarning, "Failed to copy content of source workspace %q: %v.", sourceName, err)
+		return err // requeue
+	}
+
+	// we are done. remove our initializer
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceCloneComplete)
+	removeCloneInitializer(workspace)
+
+	return nil
+}
+
+func removeCloneInitializer(workspace *tenancyv1alpha1.ClusterWorkspace) {
+	newInitializers := make([]tenancyv1alpha1.ClusterWorkspaceInitializer, 0, len(workspace.Status.Initializers))
+	for _, i := range workspace.Status.Initializers {
+		if i != tenancyv1alpha1.ClusterWorkspaceCloneInitializer {
+			newInitializers = append(newInitializers, i)
+		}
+	}
+	workspace.Status.Initializers = newInitializers
+}
+
+// clone copies CustomResourceDefinitions, ClusterRoles and ClusterRoleBindings, and optionally
+// secrets, from one logical cluster to another. Objects that already exist in the target are left alone.
+func clone(ctx context.Context, from, to clusterClients, includeSecrets bool) error {
+	crds, err := from.crdClient.ApiextensionsV1().CustomResourceDefinitions().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, crd := range crds.Items {
+		clone := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: cloneObjectMeta(crd.ObjectMeta),
+			Spec:       crd.Spec,
+		}
+		if _, err := to.crdClient.ApiextensionsV1().CustomResourceDefinitions().Create(ctx, clone, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	roles, err := from.kubeClient.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, role := range roles.Items {
+		if strings.HasPrefix(role.Name, systemPrefix) {
+			continue
+		}
+		clone := &rbacv1.ClusterRole{
+			ObjectMeta:      cloneObjectMeta(role.ObjectMeta),
+			Rules:           role.Rules,
+			AggregationRule: role.AggregationRule,
+		}
+		if _, err := to.kubeClient.RbacV1().ClusterRoles().Create(ctx, clone, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	bindings, err := from.kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, binding := range bindings.Items {
+		if strings.HasPrefix(binding.Name, systemPrefix) {
+			continue
+		}
+		clone := &rbacv1.ClusterRoleBinding{
+			ObjectMeta: cloneObjectMeta(binding.ObjectMeta),
+			Subjects:   binding.Subjects,
+			RoleRef:    binding.RoleRef,
+		}
+		if _, err := to.kubeClient.RbacV1().ClusterRoleBindings().Create(ctx, clone, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	if !includeSecrets {
+		return nil
+	}
+
+	secrets, err := from.kubeClient.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	namespaces := sets.NewString()
+	for _, secret := range secrets.Items {
+		// service account tokens are bound to the service accounts of the source
+		if secret.Type == corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		if !namespaces.Has(secret.Namespace) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: secret.Namespace}}
+			if _, err := to.kubeClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+			namespaces.Insert(secret.Namespace)
+		}
+		clone := &corev1.Secret{
+			ObjectMeta: cloneObjectMeta(secret.ObjectMeta),
+			Type:       secret.Type,
+			Data:       secret.Data,
+		}
+		if _, err := to.kubeClient.CoreV1().Secrets(secret.Namespace).Create(ctx, clone, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cloneObjectMeta returns the parts of the given object meta that are meaningful in another logical cluster.
+func cloneObjectMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
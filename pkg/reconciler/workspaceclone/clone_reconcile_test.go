@@ -0,0 +1,194 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceclone
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func newWorkspace(name string, phase tenancyv1alpha1.ClusterWorkspacePhaseType) *tenancyv1alpha1.ClusterWorkspace {
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			ClusterName: "root:org",
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase: phase,
+		},
+	}
+}
+
+func newClone(name, source string) *tenancyv1alpha1.ClusterWorkspace {
+	workspace := newWorkspace(name, tenancyv1alpha1.ClusterWorkspacePhaseInitializing)
+	workspace.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation: source}
+	workspace.Status.Initializers = []tenancyv1alpha1.ClusterWorkspaceInitializer{"initializers.tenancy.kcp.dev/universal", tenancyv1alpha1.ClusterWorkspaceCloneInitializer}
+	return workspace
+}
+
+func newSourceClients() clusterClients {
+	return clusterClients{
+		crdClient: apiextensionsfake.NewSimpleClientset(
+			&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "cowboys.wildwest.dev", ResourceVersion: "42", UID: "uid"}},
+		),
+		kubeClient: kubefake.NewSimpleClientset([]runtime.Object{
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "cowboy-admin"}},
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "system:controller"}},
+			&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "cowboy-admins"}},
+			&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "system:controller"}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "wild", Name: "credentials"}, Data: map[string][]byte{"token": []byte("secret")}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "wild", Name: "default-token"}, Type: corev1.SecretTypeServiceAccountToken},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "wild", Name: "workload"}},
+		}...),
+	}
+}
+
+func newTestController(t *testing.T, clients map[string]clusterClients, workspaces ...*tenancyv1alpha1.ClusterWorkspace) *controller {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, workspace := range workspaces {
+		require.NoError(t, indexer.Add(workspace))
+	}
+	return &controller{
+		clientsFor: func(clusterName string) clusterClients {
+			c, found := clients[clusterName]
+			require.True(t, found, "unexpected logical cluster %q", clusterName)
+			return c
+		},
+		workspaceIndexer: indexer,
+		workspaceLister:  tenancylister.NewClusterWorkspaceLister(indexer),
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		source         *tenancyv1alpha1.ClusterWorkspace
+		include        string
+		expectedReason string
+		expectCloned   bool
+		expectSecrets  bool
+	}{
+		{
+			name:           "source not found",
+			expectedReason: tenancyv1alpha1.WorkspaceCloneReasonSourceNotFound,
+		},
+		{
+			name:           "source not ready",
+			source:         newWorkspace("source", tenancyv1alpha1.ClusterWorkspacePhaseInitializing),
+			expectedReason: tenancyv1alpha1.WorkspaceCloneReasonSourceNotReady,
+		},
+		{
+			name:         "CRDs and RBAC are cloned, secrets are not",
+			source:       newWorkspace("source", tenancyv1alpha1.ClusterWorkspacePhaseReady),
+			expectCloned: true,
+		},
+		{
+			name:          "secrets are cloned on opt-in",
+			source:        newWorkspace("source", tenancyv1alpha1.ClusterWorkspacePhaseReady),
+			include:       "secrets",
+			expectCloned:  true,
+			expectSecrets: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			source := newSourceClients()
+			target := clusterClients{
+				crdClient:  apiextensionsfake.NewSimpleClientset(),
+				kubeClient: kubefake.NewSimpleClientset(),
+			}
+			workspace := newClone("clone", "source")
+			if tc.include != "" {
+				workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneIncludeAnnotation] = tc.include
+			}
+			workspaces := []*tenancyv1alpha1.ClusterWorkspace{workspace}
+			if tc.source != nil {
+				workspaces = append(workspaces, tc.source)
+			}
+			c := newTestController(t, map[string]clusterClients{"org:source": source, "org:clone": target}, workspaces...)
+
+			err := c.reconcile(context.Background(), workspace)
+			require.NoError(t, err)
+
+			if !tc.expectCloned {
+				require.True(t, conditions.IsFalse(workspace, tenancyv1alpha1.WorkspaceCloneComplete))
+				require.Equal(t, tc.expectedReason, conditions.GetReason(workspace, tenancyv1alpha1.WorkspaceCloneComplete))
+				require.Contains(t, workspace.Status.Initializers, tenancyv1alpha1.ClusterWorkspaceCloneInitializer)
+				return
+			}
+
+			require.True(t, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceCloneComplete))
+			require.Equal(t, []tenancyv1alpha1.ClusterWorkspaceInitializer{"initializers.tenancy.kcp.dev/universal"}, workspace.Status.Initializers)
+
+			ctx := context.Background()
+			crd, err := target.crdClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, "cowboys.wildwest.dev", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Empty(t, crd.ResourceVersion)
+			require.Empty(t, crd.UID)
+
+			roles, err := target.kubeClient.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, roles.Items, 1)
+			require.Equal(t, "cowboy-admin", roles.Items[0].Name)
+
+			bindings, err := target.kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, bindings.Items, 1)
+			require.Equal(t, "cowboy-admins", bindings.Items[0].Name)
+
+			configMaps, err := target.kubeClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Empty(t, configMaps.Items, "workload objects must not be cloned")
+
+			secrets, err := target.kubeClient.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			if !tc.expectSecrets {
+				require.Empty(t, secrets.Items)
+				return
+			}
+			require.Len(t, secrets.Items, 1, "service account tokens must not be cloned")
+			require.Equal(t, "credentials", secrets.Items[0].Name)
+			require.Equal(t, []byte("secret"), secrets.Items[0].Data["token"])
+			_, err = target.kubeClient.CoreV1().Namespaces().Get(ctx, "wild", metav1.GetOptions{})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestReconcileIgnoresWorkspacesWithoutCloneInitializer(t *testing.T) {
+	workspace := newClone("clone", "source")
+	workspace.Status.Initializers = nil
+	c := newTestController(t, nil, workspace)
+
+	err := c.reconcile(context.Background(), workspace)
+	require.NoError(t, err)
+	require.Nil(t, conditions.Get(workspace, tenancyv1alpha1.WorkspaceCloneComplete))
+}
@@ -35,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformer "k8s.io/client-go/informers/core/v1"
 	corelister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clusters"
@@ -59,11 +60,17 @@ func NewController(
 	rootKcpClient kcpclient.Interface,
 	rootSecretInformer coreinformer.SecretInformer,
 	rootWorkspaceShardInformer tenancyinformer.WorkspaceShardInformer,
+	healthCheckOptions Options,
 ) (*Controller, error) {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-workspaceshard")
+	healthQueue := workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(healthCheckOptions.HealthCheckInitialBackoff, healthCheckOptions.HealthCheckInterval), "kcp-workspaceshard-health")
 
 	c := &Controller{
 		queue:                     queue,
+		healthQueue:               healthQueue,
+		healthCheckInterval:       healthCheckOptions.HealthCheckInterval,
+		healthCheckTimeout:        healthCheckOptions.HealthCheckTimeout,
+		checkHealth:               checkHealthz,
 		kcpClient:                 rootKcpClient,
 		rootSecretIndexer:         rootSecretInformer.Informer().GetIndexer(),
 		rootSecretLister:          rootSecretInformer.Lister(),
@@ -78,8 +85,17 @@ func NewController(
 	})
 
 	rootWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
-		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		AddFunc: func(obj interface{}) {
+			c.enqueue(obj)
+			c.enqueueHealthCheck(obj)
+		},
+		UpdateFunc: func(old, obj interface{}) {
+			c.enqueue(obj)
+			if oldShard, newShard := old.(*tenancyv1alpha1.WorkspaceShard), obj.(*tenancyv1alpha1.WorkspaceShard); oldShard.Status.CredentialsHash != newShard.Status.CredentialsHash ||
+				!equality.Semantic.DeepEqual(oldShard.Status.ConnectionInfo, newShard.Status.ConnectionInfo) {
+				c.enqueueHealthCheck(obj)
+			}
+		},
 	})
 	if err := c.rootWorkspaceShardIndexer.AddIndexers(map[string]cache.IndexFunc{
 		secretIndex: func(obj interface{}) ([]string, error) {
@@ -104,9 +120,16 @@ func NewController(
 }
 
 // Controller watches WorkspaceShards and Secrets in order to make sure every WorkspaceShard
-// has its URL exposed when a valid kubeconfig is connected to it.
+// has its URL exposed when a valid kubeconfig is connected to it. It also periodically
+// health-checks the WorkspaceShards and reports the result in their ShardReachable condition.
 type Controller struct {
 	queue workqueue.RateLimitingInterface
+	// healthQueue holds the keys of the WorkspaceShards to health-check. Failed health checks
+	// are retried with an exponential backoff, successful ones are repeated after healthCheckInterval.
+	healthQueue         workqueue.RateLimitingInterface
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+	checkHealth         func(ctx context.Context, cfg *rest.Config) error
 
 	kcpClient         kcpclient.Interface
 	rootSecretIndexer cache.Indexer
@@ -165,12 +188,14 @@ func (c *Controller) enqueueForSecret(obj interface{}) {
 func (c *Controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
 	defer c.queue.ShutDown()
+	defer c.healthQueue.ShutDown()
 
 	klog.Info("Starting WorkspaceShard controller")
 	defer klog.Info("Shutting down WorkspaceShard controller")
 
 	for i := 0; i < numThreads; i++ {
 		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+		go wait.Until(func() { c.startHealthWorker(ctx) }, time.Second, ctx.Done())
 	}
 
 	<-ctx.Done()
@@ -205,7 +230,7 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 }
 
 func (c *Controller) process(ctx context.Context, key string) error {
-	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		klog.Errorf("invalid key: %q: %v", key, err)
 		return nil
@@ -229,35 +254,39 @@ func (c *Controller) process(ctx context.Context, key string) error {
 		return err
 	}
 
-	// If the object being reconciled changed as a result, update it.
-	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
-		oldData, err := json.Marshal(tenancyv1alpha1.WorkspaceShard{
-			Status: previous.Status,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to Marshal old data for workspace shard %s|%s/%s: %w", helper.RootCluster, namespace, name, err)
-		}
+	return c.patchStatus(ctx, previous, obj)
+}
 
-		newData, err := json.Marshal(tenancyv1alpha1.WorkspaceShard{
-			ObjectMeta: metav1.ObjectMeta{
-				UID:             previous.UID,
-				ResourceVersion: previous.ResourceVersion,
-			}, // to ensure they appear in the patch as preconditions
-			Status: obj.Status,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to Marshal new data for workspace shard %s|%s/%s: %w", helper.RootCluster, namespace, name, err)
-		}
+// patchStatus patches the status of the given WorkspaceShard if it changed compared to previous.
+func (c *Controller) patchStatus(ctx context.Context, previous, obj *tenancyv1alpha1.WorkspaceShard) error {
+	if equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		return nil
+	}
 
-		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
-		if err != nil {
-			return fmt.Errorf("failed to create patch for workspace shard %s|%s/%s: %w", helper.RootCluster, namespace, name, err)
-		}
-		_, uerr := c.kcpClient.TenancyV1alpha1().WorkspaceShards().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
-		return uerr
+	oldData, err := json.Marshal(tenancyv1alpha1.WorkspaceShard{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for workspace shard %s|%s: %w", helper.RootCluster, obj.Name, err)
 	}
 
-	return nil
+	newData, err := json.Marshal(tenancyv1alpha1.WorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for workspace shard %s|%s: %w", helper.RootCluster, obj.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for workspace shard %s|%s: %w", helper.RootCluster, obj.Name, err)
+	}
+	_, uerr := c.kcpClient.TenancyV1alpha1().WorkspaceShards().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return uerr
 }
 
 func (c *Controller) reconcile(ctx context.Context, workspaceShard *tenancyv1alpha1.WorkspaceShard) error {
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceshard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsapi "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// unreachableThreshold is the number of consecutive failed health checks after which
+// a WorkspaceShard is reported unreachable. This avoids flapping on transient errors.
+const unreachableThreshold = 3
+
+func (c *Controller) enqueueHealthCheck(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.healthQueue.Add(key)
+}
+
+func (c *Controller) startHealthWorker(ctx context.Context) {
+	for c.processNextHealthCheck(ctx) {
+	}
+}
+
+func (c *Controller) processNextHealthCheck(ctx context.Context) bool {
+	k, quit := c.healthQueue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	defer c.healthQueue.Done(key)
+
+	if err := c.processHealthCheck(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to health-check %q, err: %w", controllerName, key, err))
+		// the failure count is kept, only retry updating the status soon
+		c.healthQueue.AddAfter(key, time.Second)
+	}
+	return true
+}
+
+// processHealthCheck checks the /healthz endpoint of the given WorkspaceShard and updates its
+// ShardReachable condition. It requeues the WorkspaceShard for its next health check.
+func (c *Controller) processHealthCheck(ctx context.Context, key string) error {
+	obj, err := c.rootWorkspaceShardLister.Get(key)
+	if errors.IsNotFound(err) {
+		c.healthQueue.Forget(key)
+		return nil // object deleted before we handled it
+	} else if err != nil {
+		return err
+	}
+
+	// Nothing to check until the credentials are valid. Once they are, the connection info
+	// is updated, which triggers a new health check.
+	if !conditions.IsTrue(obj, tenancyv1alpha1.WorkspaceShardCredentialsValid) || obj.Status.ConnectionInfo == nil {
+		c.healthQueue.Forget(key)
+		return nil
+	}
+	cfg, err := c.restConfigFor(obj)
+	if err != nil {
+		klog.V(2).Infof("Skipping health check of workspace shard %q: %v", key, err)
+		c.healthQueue.Forget(key)
+		return nil
+	}
+	cfg.Timeout = c.healthCheckTimeout
+
+	previous := obj
+	obj = obj.DeepCopy()
+
+	if err := c.checkHealth(ctx, cfg); err != nil {
+		failures := c.healthQueue.NumRequeues(key) + 1
+		klog.Infof("Health check %d of workspace shard %q failed: %v", failures, key, err)
+		if failures >= unreachableThreshold {
+			conditions.MarkFalse(obj, tenancyv1alpha1.ShardReachable, tenancyv1alpha1.ShardReachableReasonUnhealthy, conditionsapi.ConditionSeverityError, "Health check of %s failed: %v.", cfg.Host, err)
+		}
+		c.healthQueue.AddRateLimited(key)
+	} else {
+		conditions.MarkTrue(obj, tenancyv1alpha1.ShardReachable)
+		c.healthQueue.Forget(key)
+		c.healthQueue.AddAfter(key, c.healthCheckInterval)
+	}
+
+	return c.patchStatus(ctx, previous, obj)
+}
+
+// restConfigFor returns the client config of the WorkspaceShard from its credentials secret.
+func (c *Controller) restConfigFor(workspaceShard *tenancyv1alpha1.WorkspaceShard) (*rest.Config, error) {
+	secret, err := c.rootSecretLister.Secrets(workspaceShard.Spec.Credentials.Namespace).Get(clusters.ToClusterAwareKey(workspaceShard.ClusterName, workspaceShard.Spec.Credentials.Name))
+	if err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data[tenancyv1alpha1.WorkspaceShardCredentialsKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no key %s", workspaceShard.Spec.Credentials.Namespace, workspaceShard.Spec.Credentials.Name, tenancyv1alpha1.WorkspaceShardCredentialsKey)
+	}
+	return clientcmd.RESTConfigFromKubeConfig(data)
+}
+
+// checkHealthz issues a GET request against the /healthz endpoint of the given shard.
+func checkHealthz(ctx context.Context, cfg *rest.Config) error {
+	client, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return client.RESTClient().Get().AbsPath("/healthz").Do(ctx).Error()
+}
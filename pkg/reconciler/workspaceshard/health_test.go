@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceshard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/workqueue"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestProcessHealthCheck(t *testing.T) {
+	ctx := context.Background()
+
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters:       map[string]*clientcmdapi.Cluster{"cluster": {Server: "https://boston.kcp.dev"}},
+		Contexts:       map[string]*clientcmdapi.Context{"context": {Cluster: "cluster"}},
+		CurrentContext: "context",
+	})
+	require.NoError(t, err)
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, secretIndexer.Add(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig", Namespace: "credentials", ClusterName: helper.RootCluster},
+		Data:       map[string][]byte{tenancyv1alpha1.WorkspaceShardCredentialsKey: kubeconfig},
+	}))
+
+	shard := &tenancyv1alpha1.WorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{Name: "boston", ClusterName: helper.RootCluster},
+		Spec: tenancyv1alpha1.WorkspaceShardSpec{
+			Credentials: corev1.SecretReference{Namespace: "credentials", Name: "kubeconfig"},
+		},
+		Status: tenancyv1alpha1.WorkspaceShardStatus{
+			ConnectionInfo: &tenancyv1alpha1.ConnectionInfo{Host: "https://boston.kcp.dev"},
+		},
+	}
+	conditions.MarkTrue(shard, tenancyv1alpha1.WorkspaceShardCredentialsValid)
+	shardIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, shardIndexer.Add(shard))
+	key, err := cache.MetaNamespaceKeyFunc(shard)
+	require.NoError(t, err)
+
+	kcpClient := fake.NewSimpleClientset(shard)
+	var healthErr error
+	var checkedHosts []string
+	c := &Controller{
+		healthQueue:              workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Second)),
+		healthCheckInterval:      time.Minute,
+		healthCheckTimeout:       time.Second,
+		kcpClient:                kcpClient,
+		rootSecretLister:         corelister.NewSecretLister(secretIndexer),
+		rootWorkspaceShardLister: tenancylister.NewWorkspaceShardLister(shardIndexer),
+		checkHealth: func(ctx context.Context, cfg *rest.Config) error {
+			checkedHosts = append(checkedHosts, cfg.Host)
+			require.Equal(t, time.Second, cfg.Timeout)
+			return healthErr
+		},
+	}
+	defer c.healthQueue.ShutDown()
+
+	// processHealthCheck reads the shard from the lister, hence propagate the patched shard back into it.
+	check := func() *tenancyv1alpha1.WorkspaceShard {
+		require.NoError(t, c.processHealthCheck(ctx, key))
+		updated, err := kcpClient.TenancyV1alpha1().WorkspaceShards().Get(ctx, shard.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		updated.ClusterName = helper.RootCluster
+		require.NoError(t, shardIndexer.Update(updated))
+		return updated
+	}
+
+	updated := check()
+	require.True(t, conditions.IsTrue(updated, tenancyv1alpha1.ShardReachable), "expected a reachable shard")
+	require.Equal(t, 0, c.healthQueue.NumRequeues(key))
+
+	healthErr = errors.New("connection refused")
+	for i := 1; i < unreachableThreshold; i++ {
+		updated = check()
+		require.True(t, conditions.IsTrue(updated, tenancyv1alpha1.ShardReachable), "expected the shard to be reachable after %d failures", i)
+		require.Equal(t, i, c.healthQueue.NumRequeues(key))
+	}
+
+	updated = check()
+	require.True(t, conditions.IsFalse(updated, tenancyv1alpha1.ShardReachable), "expected an unreachable shard after %d failures", unreachableThreshold)
+	require.Equal(t, tenancyv1alpha1.ShardReachableReasonUnhealthy, conditions.GetReason(updated, tenancyv1alpha1.ShardReachable))
+	require.Contains(t, conditions.GetMessage(updated, tenancyv1alpha1.ShardReachable), "connection refused")
+
+	healthErr = nil
+	updated = check()
+	require.True(t, conditions.IsTrue(updated, tenancyv1alpha1.ShardReachable), "expected the shard to be reachable again")
+	require.Equal(t, 0, c.healthQueue.NumRequeues(key))
+
+	require.Len(t, checkedHosts, unreachableThreshold+2)
+	for _, host := range checkedHosts {
+		require.Equal(t, "https://boston.kcp.dev", host)
+	}
+}
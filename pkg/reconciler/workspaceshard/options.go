@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceshard
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// DefaultOptions are the default options for the workspace shard controller.
+func DefaultOptions() *Options {
+	return &Options{
+		HealthCheckInterval:       30 * time.Second,
+		HealthCheckTimeout:        5 * time.Second,
+		HealthCheckInitialBackoff: time.Second,
+	}
+}
+
+// BindOptions binds the workspace shard controller options to the flag set.
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.DurationVar(&o.HealthCheckInterval, "workspace-shard-health-check-interval", o.HealthCheckInterval, "Interval between two health checks of a reachable workspace shard.")
+	fs.DurationVar(&o.HealthCheckTimeout, "workspace-shard-health-check-timeout", o.HealthCheckTimeout, "Timeout of the /healthz request of a workspace shard health check.")
+	fs.DurationVar(&o.HealthCheckInitialBackoff, "workspace-shard-health-check-initial-backoff", o.HealthCheckInitialBackoff, "Delay before re-checking a workspace shard after a failed health check. The delay doubles with every failure, up to --workspace-shard-health-check-interval.")
+	return o
+}
+
+// Options are the options for the workspace shard controller
+type Options struct {
+	HealthCheckInterval       time.Duration
+	HealthCheckTimeout        time.Duration
+	HealthCheckInitialBackoff time.Duration
+}
+
+func (o *Options) Validate() error {
+	if o.HealthCheckInterval <= 0 {
+		return fmt.Errorf("--workspace-shard-health-check-interval must be positive, got %s", o.HealthCheckInterval)
+	}
+	if o.HealthCheckTimeout <= 0 {
+		return fmt.Errorf("--workspace-shard-health-check-timeout must be positive, got %s", o.HealthCheckTimeout)
+	}
+	if o.HealthCheckInitialBackoff <= 0 || o.HealthCheckInitialBackoff > o.HealthCheckInterval {
+		return fmt.Errorf("--workspace-shard-health-check-initial-backoff must be positive and at most --workspace-shard-health-check-interval, got %s", o.HealthCheckInitialBackoff)
+	}
+	return nil
+}
@@ -34,6 +34,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/controller/clusterroleaggregation"
 	"k8s.io/kubernetes/pkg/controller/namespace"
@@ -46,11 +47,14 @@ import (
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	"github.com/kcp-dev/kcp/pkg/apis/workload"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpscheme "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/scheme"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/gvk"
 	"github.com/kcp-dev/kcp/pkg/reconciler/clusterworkspacetypebootstrap"
 	kcpnamespace "github.com/kcp-dev/kcp/pkg/reconciler/namespace"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workspace"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workspacebundle"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workspaceclone"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workspaceshard"
 )
 
@@ -182,10 +186,43 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, clientConfig cli
 		return err
 	}
 
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(adminConfig)
+	if err != nil {
+		return err
+	}
+
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartStructuredLogging(0)
+	eventBroadcaster.StartRecordingToSink(workspace.NewEventSink(kubeClusterClient))
+
+	// the owners of workspaces are only known for sure with static tokens
+	var ownerChecker workspace.OwnerChecker
+	if s.options.Controllers.WorkspaceScheduler.EnableOwnerGC {
+		tokenFile := s.options.GenericControlPlane.Authentication.TokenFile
+		if tokenFile == nil || tokenFile.TokenFile == "" {
+			return errors.New("--enable-workspace-owner-gc requires --token-auth-file")
+		}
+		if ownerChecker, err = workspace.NewTokenFileOwnerChecker(tokenFile.TokenFile); err != nil {
+			return err
+		}
+	}
+
+	// avoid a typed nil activity source if activity tracking is disabled
+	var activitySource workspace.ActivitySource
+	if s.activityRecorder != nil {
+		activitySource = s.activityRecorder
+	}
+
 	workspaceController, err := workspace.NewController(
 		kcpClusterClient,
+		kubeClusterClient,
 		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
 		s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceShards(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+		eventBroadcaster.NewRecorder(kcpscheme.Scheme, corev1.EventSource{Component: "workspace-scheduler"}),
+		ownerChecker,
+		activitySource,
+		s.options.Controllers.WorkspaceScheduler,
 	)
 	if err != nil {
 		return err
@@ -195,6 +232,7 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, clientConfig cli
 		kcpClusterClient.Cluster(helper.RootCluster),
 		s.rootKubeSharedInformerFactory.Core().V1().Secrets(),
 		s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceShards(),
+		s.options.Controllers.WorkspaceShard,
 	)
 	if err != nil {
 		return err
@@ -224,6 +262,28 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, clientConfig cli
 		return err
 	}
 
+	cloneController, err := workspaceclone.NewController(
+		crdClusterClient,
+		kubeClusterClient,
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+	)
+	if err != nil {
+		return err
+	}
+
+	bundleController, err := workspacebundle.NewController(
+		dynamicClusterClient,
+		kubeClusterClient,
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+		s.options.Controllers.WorkspaceScheduler.BootstrapBundleAllowedHosts,
+	)
+	if err != nil {
+		return err
+	}
+
 	if err := server.AddPostStartHook("kcp-install-workspace-scheduler", func(hookContext genericapiserver.PostStartHookContext) error {
 		if err := s.waitForSync(hookContext.StopCh); err != nil {
 			klog.Errorf("failed to finish post-start-hook kcp-install-workspace-scheduler: %v", err)
@@ -235,6 +295,8 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, clientConfig cli
 		go workspaceShardController.Start(ctx, 2)
 		go organizationController.Start(ctx, 2)
 		go universalController.Start(ctx, 2)
+		go cloneController.Start(ctx, 2)
+		go bundleController.Start(ctx, 2)
 
 		return nil
 	}); err != nil {
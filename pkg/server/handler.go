@@ -39,12 +39,15 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
 	apiserverdiscovery "k8s.io/apiserver/pkg/endpoints/discovery"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/kubernetes/pkg/genericcontrolplane"
 	"k8s.io/kubernetes/pkg/genericcontrolplane/aggregator"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/workspace"
 )
 
 var (
@@ -140,6 +143,20 @@ func WithWildcardListWatchGuard(apiHandler http.Handler) http.HandlerFunc {
 	}
 }
 
+// WithActivityRecording records the requests into logical clusters as activity of the corresponding workspaces.
+// Wildcard requests, requests without cluster and those of kcp itself through the loopback client are ignored.
+func WithActivityRecording(apiHandler http.Handler, recorder *workspace.RequestActivityRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := genericapirequest.ClusterFrom(req.Context())
+		if cluster != nil && !cluster.Wildcard && cluster.Name != genericcontrolplane.LocalAdminCluster {
+			if u, ok := genericapirequest.UserFrom(req.Context()); ok && u.GetName() != user.APIServerUser {
+				recorder.Record(cluster.Name)
+			}
+		}
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
 func mergeCRDsIntoCoreGroup(crdLister v1.CustomResourceDefinitionLister, crdHandler, coreHandler func(res http.ResponseWriter, req *http.Request)) restful.FilterFunction {
 	return func(req *restful.Request, res *restful.Response, chain *restful.FilterChain) {
 		ctx := req.Request.Context()
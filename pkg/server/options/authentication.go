@@ -34,6 +34,9 @@ import (
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/keyutil"
+
+	"github.com/kcp-dev/kcp/pkg/authentication/workspacetoken"
 )
 
 type AdminAuthentication struct {
@@ -135,6 +138,64 @@ func (s *AdminAuthentication) WriteKubeConfig(config *genericapiserver.Config, n
 	return clientcmd.WriteToFile(*externalKubeConfig, s.KubeConfigPath)
 }
 
+// WorkspaceTokenAuthentication configures the authentication of the workspace-scoped tokens
+// minted by the workspaces virtual workspace.
+type WorkspaceTokenAuthentication struct {
+	// KeyFiles are files holding the PEM-encoded public keys used to verify workspace tokens.
+	// Workspace tokens are not accepted if empty.
+	KeyFiles []string
+}
+
+func NewWorkspaceTokenAuthentication() *WorkspaceTokenAuthentication {
+	return &WorkspaceTokenAuthentication{}
+}
+
+func (s *WorkspaceTokenAuthentication) Validate() []error {
+	if s == nil {
+		return nil
+	}
+
+	errs := []error{}
+
+	for _, f := range s.KeyFiles {
+		if _, err := keyutil.PublicKeysFromFile(f); err != nil {
+			errs = append(errs, fmt.Errorf("--authentication-workspace-token-key-file: %w", err))
+		}
+	}
+
+	return errs
+}
+
+func (s *WorkspaceTokenAuthentication) AddFlags(fs *pflag.FlagSet) {
+	if s == nil {
+		return
+	}
+
+	fs.StringSliceVar(&s.KeyFiles, "authentication-workspace-token-key-file", s.KeyFiles,
+		"File containing PEM-encoded public RSA or ECDSA keys used to verify the workspace-scoped tokens issued by the workspaces virtual workspace. "+
+			"The specified file can contain multiple keys, and the flag can be specified multiple times with different files.")
+}
+
+func (s *WorkspaceTokenAuthentication) ApplyTo(config *genericapiserver.Config) error {
+	if s == nil || len(s.KeyFiles) == 0 {
+		return nil
+	}
+
+	var keys []interface{}
+	for _, f := range s.KeyFiles {
+		fileKeys, err := keyutil.PublicKeysFromFile(f)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	newAuthenticator := bearertoken.New(workspacetoken.NewAuthenticator(keys))
+	config.Authentication.Authenticator = authenticatorunion.New(newAuthenticator, config.Authentication.Authenticator)
+
+	return nil
+}
+
 func createKubeConfig(adminUserName, adminBearerToken, baseHost, tlsServerName string, caData []byte) *clientcmdapi.Config {
 	var kubeConfig clientcmdapi.Config
 	//Create Client and Shared
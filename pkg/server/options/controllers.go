@@ -22,6 +22,8 @@ import (
 	"github.com/kcp-dev/kcp/pkg/reconciler/apiresource"
 	"github.com/kcp-dev/kcp/pkg/reconciler/cluster/apiimporter"
 	"github.com/kcp-dev/kcp/pkg/reconciler/cluster/syncer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workspace"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workspaceshard"
 )
 
 type Controllers struct {
@@ -30,11 +32,15 @@ type Controllers struct {
 	ApiImporter         ApiImporterController
 	ApiResource         ApiResourceController
 	Syncer              SyncerController
+	WorkspaceScheduler  WorkspaceSchedulerController
+	WorkspaceShard      WorkspaceShardController
 }
 
 type ApiImporterController = apiimporter.Options
 type ApiResourceController = apiresource.Options
 type SyncerController = syncer.Options
+type WorkspaceSchedulerController = workspace.Options
+type WorkspaceShardController = workspaceshard.Options
 
 func NewControllers() *Controllers {
 	return &Controllers{
@@ -43,6 +49,9 @@ func NewControllers() *Controllers {
 		ApiImporter: *apiimporter.DefaultOptions(),
 		ApiResource: *apiresource.DefaultOptions(),
 		Syncer:      *syncer.DefaultOptions(),
+
+		WorkspaceScheduler: *workspace.DefaultOptions(),
+		WorkspaceShard:     *workspaceshard.DefaultOptions(),
 	}
 }
 
@@ -55,6 +64,8 @@ func (c *Controllers) AddFlags(fs *pflag.FlagSet) {
 	apiimporter.BindOptions(&c.ApiImporter, fs)
 	apiresource.BindOptions(&c.ApiResource, fs)
 	syncer.BindOptions(&c.Syncer, fs)
+	workspace.BindOptions(&c.WorkspaceScheduler, fs)
+	workspaceshard.BindOptions(&c.WorkspaceShard, fs)
 }
 
 func (c *Controllers) Validate() []error {
@@ -69,6 +80,12 @@ func (c *Controllers) Validate() []error {
 	if err := c.Syncer.Validate(); err != nil {
 		errs = append(errs, err)
 	}
+	if err := c.WorkspaceScheduler.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.WorkspaceShard.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 
 	return errs
 }
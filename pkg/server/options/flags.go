@@ -81,8 +81,9 @@ var (
 		"authorization-always-allow-paths", // A list of HTTP paths to skip during authorization, i.e. these are authorized without contacting the 'core' kubernetes server.
 
 		// KCP Admin Authentication flags
-		"authentication-admin-token-path", // Path to which the administrative token hash should be written at startup. If this is relative, it is relative to --root-directory.
-		"kubeconfig-path",                 // Path to which the administrative kubeconfig should be written at startup.
+		"authentication-admin-token-path",         // Path to which the administrative token hash should be written at startup. If this is relative, it is relative to --root-directory.
+		"authentication-workspace-token-key-file", // File containing PEM-encoded public RSA or ECDSA keys used to verify the workspace-scoped tokens issued by the workspaces virtual workspace.
+		"kubeconfig-path",                         // Path to which the administrative kubeconfig should be written at startup.
 
 		// logs flags
 		"logging-format",      // Sets the log format. Permitted formats: "text".
@@ -120,14 +121,36 @@ var (
 		"embedded-etcd-wal-size-bytes", // Size of embedded etcd WAL
 
 		// KCP Controllers flags
-		"auto-publish-apis",                      // If true, the APIs imported from physical clusters will be published automatically as CRDs
-		"apiresource-controller-threads",         // Number of threads to use for the apiresource controller.
-		"pull-mode",                              // Deploy the syncer in registered physical clusters in POD, and have it sync resources from KCP
-		"push-mode",                              // If true, run syncer for each cluster from inside cluster controller
-		"resources-to-sync",                      // Provides the list of resources that should be synced from KCP logical cluster to underlying physical clusters
-		"run-controllers",                        // Run the controllers in-process
-		"syncer-image",                           // Syncer image to install on clusters
-		"unsupported-run-individual-controllers", // Run individual controllers in-process. The controller names can change at any time.
+		"auto-publish-apis",                                   // If true, the APIs imported from physical clusters will be published automatically as CRDs
+		"apiresource-controller-threads",                      // Number of threads to use for the apiresource controller.
+		"external-shard-url-template",                         // URL template under which clients reach a workspace shard, e.g. https://{shard}.kcp.example.com. {shard} is replaced by the shard name. If empty, the shard address is used.
+		"workspace-migration-qps",                             // Maximal number of workspaces per second moved off draining workspace shards.
+		"workspace-scheduler-base-backoff",                    // Delay before the workspace scheduler retries a ClusterWorkspace the first time after a failed reconciliation. The delay doubles with every further failure.
+		"workspace-scheduler-max-backoff",                     // Maximal delay before the workspace scheduler retries a ClusterWorkspace after a failed reconciliation.
+		"shard-selection-strategy",                            // Strategy picking the workspace shard to schedule a workspace onto among the valid ones, one of: least-loaded, random, round-robin.
+		"workspace-init-timeout",                              // Time after which a workspace still initializing is moved to the Failed phase. Set the tenancy.kcp.dev/retry-initialization annotation to retry. Zero disables the timeout.
+		"enable-workspace-owner-gc",                           // Delete the workspaces whose owner is not known to the --token-auth-file anymore, after marking them with the WorkspaceOwnerMissing condition for --workspace-owner-gc-grace-period.
+		"workspace-owner-gc-grace-period",                     // Time a workspace whose owner is missing is kept before being deleted, when --enable-workspace-owner-gc is set.
+		"workspace-activity-probe-interval",                   // Interval at which the last request served in ready workspaces is recorded in status.lastActivityTime of their ClusterWorkspace. Zero disables the activity tracking.
+		"workspace-scheduler-resync-jitter",                   // Maximal random delay of the reconciliation of a ClusterWorkspace on periodic resyncs, spreading them over time. Zero reconciles all workspaces at once.
+		"workspace-shard-circuit-breaker-failures",            // Number of consecutive failed calls of the workspace scheduler to a workspace shard after which further calls are short-circuited for --workspace-shard-circuit-breaker-cooldown, and the shard is marked with the ShardUnavailable condition. Zero disables the circuit breaker.
+		"workspace-shard-circuit-breaker-cooldown",            // Time calls of the workspace scheduler to a workspace shard are short-circuited before a single call is tried again.
+		"workspace-shard-call-timeout",                        // Maximal duration of a call of the workspace scheduler to a workspace shard. Zero disables the timeout.
+		"workspace-scheduler-leader-elect",                    // Elect a leader among the workspace schedulers of replicated control planes through a Lease in the root workspace. Only the leader reconciles ClusterWorkspaces.
+		"workspace-scheduler-leader-election-lease-name",      // Name of the Lease of the workspace scheduler leader election.
+		"workspace-scheduler-leader-election-lease-namespace", // Namespace in the root workspace of the Lease of the workspace scheduler leader election.
+		"workspace-bootstrap-bundle-allowed-hosts",            // Hosts, optionally with a port, from which the bundles of the tenancy.kcp.dev/bootstrap-bundle-url annotation are downloaded. Bundles from other hosts are never downloaded.
+		"default-workspace-quota",                             // Limits of the ResourceQuota created in the default namespace of new workspaces, e.g. pods=100,requests.cpu=10. No quota is created if empty, or if the workspace type creates one.
+		"default-workspace-type",                              // Name of the ClusterWorkspaceType of ClusterWorkspaces created without spec.type. Such workspaces are valid without a ClusterWorkspaceType of that name.
+		"pull-mode",                                           // Deploy the syncer in registered physical clusters in POD, and have it sync resources from KCP
+		"push-mode",                                           // If true, run syncer for each cluster from inside cluster controller
+		"resources-to-sync",                                   // Provides the list of resources that should be synced from KCP logical cluster to underlying physical clusters
+		"run-controllers",                                     // Run the controllers in-process
+		"syncer-image",                                        // Syncer image to install on clusters
+		"unsupported-run-individual-controllers",              // Run individual controllers in-process. The controller names can change at any time.
+		"workspace-shard-health-check-initial-backoff",        // Delay before re-checking a workspace shard after a failed health check. The delay doubles with every failure, up to --workspace-shard-health-check-interval.
+		"workspace-shard-health-check-interval",               // Interval between two health checks of a reachable workspace shard.
+		"workspace-shard-health-check-timeout",                // Timeout of the /healthz request of a workspace shard health check.
 
 		// generic flags
 		"cors-allowed-origins",                 // List of allowed origins for CORS, comma separated.  An allowed origin can be a regular expression to support subdomain matching. If this list is empty CORS will not be enabled.
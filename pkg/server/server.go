@@ -44,6 +44,7 @@ import (
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpexternalversions "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/etcd"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workspace"
 	kcpserveroptions "github.com/kcp-dev/kcp/pkg/server/options"
 	"github.com/kcp-dev/kcp/pkg/sharding"
 )
@@ -77,6 +78,9 @@ type Server struct {
 	kubeSharedInformerFactory          coreexternalversions.SharedInformerFactory
 	rootKubeSharedInformerFactory      coreexternalversions.SharedInformerFactory
 	apiextensionsSharedInformerFactory apiextensionsexternalversions.SharedInformerFactory
+
+	// activityRecorder, if set, records the requests into workspaces for the workspace scheduler.
+	activityRecorder *workspace.RequestActivityRecorder
 }
 
 // NewServer creates a new instance of Server which manages the KCP api-server.
@@ -183,6 +187,9 @@ func (s *Server) Run(ctx context.Context) error {
 	if err := s.options.Authorization.ApplyTo(genericConfig, s.kubeSharedInformerFactory, s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces().Lister()); err != nil {
 		return err
 	}
+	if err := s.options.WorkspaceTokens.ApplyTo(genericConfig); err != nil {
+		return err
+	}
 	newTokenOrEmpty, tokenHash, err := s.options.AdminAuthentication.ApplyTo(genericConfig)
 	if err != nil {
 		return err
@@ -196,6 +203,10 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 
+	if s.options.Controllers.WorkspaceScheduler.ActivityProbeInterval > 0 {
+		s.activityRecorder = workspace.NewRequestActivityRecorder()
+	}
+
 	genericConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, c *genericapiserver.Config) (secure http.Handler) {
 		// we want a request to hit the chain like:
 		// - lcluster handler (this package's ServeHTTP)
@@ -207,6 +218,9 @@ func (s *Server) Run(ctx context.Context) error {
 			clientLoader.Add(s.options.GenericControlPlane.GenericServerRunOptions.ExternalHost, genericConfig.LoopbackClientConfig)
 			apiHandler = sharding.WithSharding(apiHandler, clientLoader)
 		}
+		if s.activityRecorder != nil {
+			apiHandler = WithActivityRecording(apiHandler, s.activityRecorder)
+		}
 		apiHandler = WithWildcardListWatchGuard(apiHandler)
 		apiHandler = WithClusterScope(genericapiserver.DefaultBuildHandlerChain(apiHandler, c))
 
@@ -217,6 +231,7 @@ func (s *Server) Run(ctx context.Context) error {
 		kcpadmissioninitializers.NewKcpInformersInitializer(s.kcpSharedInformerFactory),
 		kcpadmissioninitializers.NewKubeClusterClientInitializer(kubeClusterClient),
 		kcpadmissioninitializers.NewKcpClusterClientInitializer(kcpClusterClient),
+		kcpadmissioninitializers.NewDefaultWorkspaceTypeInitializer(s.options.Controllers.WorkspaceScheduler.DefaultWorkspaceType),
 	}
 
 	apisConfig, err := genericcontrolplane.CreateKubeAPIServerConfig(genericConfig, s.options.GenericControlPlane, s.kubeSharedInformerFactory, admissionPluginInitializers, storageFactory)
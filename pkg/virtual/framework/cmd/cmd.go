@@ -23,6 +23,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -49,6 +50,10 @@ type APIServerOptions struct {
 	SecureServing     *genericapiserveroptions.SecureServingOptionsWithLoopback
 	Authentication    *genericapiserveroptions.DelegatingAuthenticationOptions
 	SubCommandOptions SubCommandOptions
+
+	// ShutdownGracePeriod is the time in-flight requests, including watches, are waited for
+	// once the server is asked to stop, before exiting.
+	ShutdownGracePeriod time.Duration
 }
 
 type SubCommandDescription struct {
@@ -60,9 +65,12 @@ type SubCommandDescription struct {
 
 const SecurePortDefault = 6444
 
+const ShutdownGracePeriodDefault = 30 * time.Second
+
 type SubCommandOptions interface {
 	Description() SubCommandDescription
 	AddFlags(flags *pflag.FlagSet)
+	Complete() error
 	Validate() []error
 	PrepareVirtualWorkspaces() ([]virtualrootapiserver.InformerStart, []framework.VirtualWorkspace, error)
 }
@@ -73,6 +81,8 @@ func APIServerCommand(out, errout io.Writer, stopCh <-chan struct{}, subCommandO
 		SecureServing:     kubeoptions.NewSecureServingOptions(),
 		Authentication:    genericapiserveroptions.NewDelegatingAuthenticationOptions(),
 		SubCommandOptions: subCommandOptions,
+
+		ShutdownGracePeriod: ShutdownGracePeriodDefault,
 	}
 
 	options.SecureServing.ServerCert.CertKey.CertFile = filepath.Join(".", ".kcp", "apiserver.crt")
@@ -84,6 +94,7 @@ func APIServerCommand(out, errout io.Writer, stopCh <-chan struct{}, subCommandO
 		Short: options.SubCommandOptions.Description().Short,
 		Long:  templates.LongDesc(options.SubCommandOptions.Description().Long),
 		Run: func(c *cobra.Command, args []string) {
+			kcmdutil.CheckErr(options.Complete())
 			kcmdutil.CheckErr(options.Validate())
 
 			if err := options.RunAPIServer(stopCh); err != nil {
@@ -112,6 +123,14 @@ func (o *APIServerOptions) AddFlags(flags *pflag.FlagSet) {
 	o.SecureServing.AddFlags(flags)
 	o.Authentication.AddFlags(flags)
 	o.SubCommandOptions.AddFlags(flags)
+
+	flags.DurationVar(&o.ShutdownGracePeriod, "shutdown-grace-period", o.ShutdownGracePeriod, ""+
+		"The time in-flight requests, including watches, are waited for on SIGTERM, after the server stopped\n"+
+		"accepting new connections. Should be shorter than the termination grace period of the pod.")
+}
+
+func (o *APIServerOptions) Complete() error {
+	return o.SubCommandOptions.Complete()
 }
 
 func (o *APIServerOptions) Validate() error {
@@ -119,6 +138,9 @@ func (o *APIServerOptions) Validate() error {
 	errs = append(errs, o.SecureServing.Validate()...)
 	errs = append(errs, o.Authentication.Validate()...)
 	errs = append(errs, o.SubCommandOptions.Validate()...)
+	if o.ShutdownGracePeriod < 0 {
+		errs = append(errs, fmt.Errorf("--shutdown-grace-period must not be negative, got %s", o.ShutdownGracePeriod))
+	}
 	return utilerrors.NewAggregate(errs)
 }
 
@@ -141,6 +163,8 @@ func ReadKubeConfig(kubeConfigFile string) (clientcmd.ClientConfig, error) {
 }
 
 // RunAPIServer takes the options, starts the API server and waits until stopCh is closed or initial listening fails.
+// Once stopCh is closed, the server stops accepting connections, and waits for the in-flight requests, including
+// watches, for at most the shutdown grace period.
 func (o *APIServerOptions) RunAPIServer(stopCh <-chan struct{}) error {
 	informerStarts, virtualWorkspaces, err := o.SubCommandOptions.PrepareVirtualWorkspaces()
 	if err != nil {
@@ -157,6 +181,9 @@ func (o *APIServerOptions) RunAPIServer(stopCh <-chan struct{}) error {
 	if err != nil {
 		return err
 	}
+	// watches are not waited for by the handler chain, but by the shutdown of the HTTP server
+	rootAPIServer.GenericAPIServer.ShutdownTimeout = o.ShutdownGracePeriod
+
 	preparedRootAPIServer := rootAPIServer.GenericAPIServer.PrepareRun()
 
 	// this **must** be done after PrepareRun() as it sets up the openapi endpoints
@@ -166,5 +193,31 @@ func (o *APIServerOptions) RunAPIServer(stopCh <-chan struct{}) error {
 
 	klog.Infof("Starting virtual workspace apiserver on %s (%s)", rootAPIServerConfig.GenericConfig.ExternalAddress, version.Get().String())
 
-	return preparedRootAPIServer.Run(stopCh)
+	return runWithGracePeriod(stopCh, o.ShutdownGracePeriod, preparedRootAPIServer.Run)
+}
+
+// runWithGracePeriod calls run until it returns, or for at most gracePeriod once stopCh is closed, such that
+// requests which do not complete in time cannot hold the process.
+func runWithGracePeriod(stopCh <-chan struct{}, gracePeriod time.Duration, run func(stopCh <-chan struct{}) error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- run(stopCh)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-stopCh:
+	}
+	klog.Infof("Shutting down virtual workspace apiserver, waiting at most %s for in-flight requests", gracePeriod)
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		klog.Warningf("In-flight requests did not complete within %s, exiting", gracePeriod)
+		return nil
+	}
 }
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	genericapiserveroptions "k8s.io/apiserver/pkg/server/options"
+	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualrootapiserver "github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+)
+
+// testVirtualWorkspace serves its handler under /test.
+type testVirtualWorkspace struct {
+	handler http.Handler
+}
+
+func (vw *testVirtualWorkspace) GetName() string { return "test" }
+
+func (vw *testVirtualWorkspace) ResolveRootPath(urlPath string, ctx context.Context) (bool, string, context.Context) {
+	return strings.HasPrefix(urlPath, "/test/"), "/test", ctx
+}
+
+func (vw *testVirtualWorkspace) IsReady() error { return nil }
+
+func (vw *testVirtualWorkspace) Register(rootAPIServerConfig genericapiserver.CompletedConfig, delegateAPIServer genericapiserver.DelegationTarget) (genericapiserver.DelegationTarget, error) {
+	server, err := rootAPIServerConfig.New("test-virtual-workspace", delegateAPIServer)
+	if err != nil {
+		return nil, err
+	}
+	server.Handler.NonGoRestfulMux.HandlePrefix("/apis/", vw.handler)
+	return server, nil
+}
+
+type testSubCommandOptions struct {
+	virtualWorkspace framework.VirtualWorkspace
+}
+
+func (o *testSubCommandOptions) Description() SubCommandDescription { return SubCommandDescription{} }
+func (o *testSubCommandOptions) AddFlags(flags *pflag.FlagSet)      {}
+func (o *testSubCommandOptions) Complete() error                    { return nil }
+func (o *testSubCommandOptions) Validate() []error                  { return nil }
+func (o *testSubCommandOptions) PrepareVirtualWorkspaces() ([]virtualrootapiserver.InformerStart, []framework.VirtualWorkspace, error) {
+	return nil, []framework.VirtualWorkspace{o.virtualWorkspace}, nil
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	requestStarted, releaseRequest := make(chan struct{}), make(chan struct{})
+	watchStarted := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("watch") == "true" {
+			// a watch never ending by itself
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			close(watchStarted)
+			<-req.Context().Done()
+			return
+		}
+		close(requestStarted)
+		<-releaseRequest
+		_, _ = w.Write([]byte("done"))
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	secureServing := kubeoptions.NewSecureServingOptions()
+	secureServing.Listener = listener
+	secureServing.BindPort = listener.Addr().(*net.TCPAddr).Port
+	secureServing.ServerCert.CertDirectory = t.TempDir()
+	require.NoError(t, secureServing.MaybeDefaultWithSelfSignedCerts("localhost", nil, []net.IP{net.ParseIP("127.0.0.1")}))
+	authentication := genericapiserveroptions.NewDelegatingAuthenticationOptions()
+	authentication.RemoteKubeConfigFileOptional = true
+	authentication.SkipInClusterLookup = true
+
+	gracePeriod := 2 * time.Second
+	o := &APIServerOptions{
+		SecureServing:       secureServing,
+		Authentication:      authentication,
+		SubCommandOptions:   &testSubCommandOptions{virtualWorkspace: &testVirtualWorkspace{handler: handler}},
+		ShutdownGracePeriod: gracePeriod,
+	}
+	require.NoError(t, o.Validate())
+
+	// the signal handler can only be set up once per process, like in the virtual-workspaces command
+	stopCh := genericapiserver.SetupSignalHandler()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- o.RunAPIServer(stopCh)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	url := fmt.Sprintf("https://%s/test/apis/test.kcp.dev/v1/things", listener.Addr())
+	require.Eventually(t, func() bool {
+		resp, err := client.Get(fmt.Sprintf("https://%s/readyz", listener.Addr()))
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, wait.ForeverTestTimeout, 100*time.Millisecond, "expected the server to become ready")
+
+	watch, err := client.Get(url + "?watch=true")
+	require.NoError(t, err)
+	defer watch.Body.Close()
+	<-watchStarted
+
+	type response struct {
+		body string
+		err  error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := client.Get(url)
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		responses <- response{body: string(body), err: err}
+	}()
+	<-requestStarted
+
+	shutdownStarted := time.Now()
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	// new connections are refused while the request is in flight
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}, wait.ForeverTestTimeout, 100*time.Millisecond, "expected the server to stop accepting connections")
+
+	close(releaseRequest)
+	select {
+	case r := <-responses:
+		require.NoError(t, r.err)
+		require.Equal(t, "done", r.body)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the in-flight request did not complete")
+	}
+
+	// the watch never ends, the server exits after the grace period
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(shutdownStarted), gracePeriod, "expected the watch to be waited for")
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the server did not exit after the grace period")
+	}
+}
@@ -21,3 +21,34 @@ type virtualWorkspaceNameKeyType string
 // VirtualWorkspaceNameKey is a context key that contains the name of the
 // virtual workspace that should serve a given request according to its URL path.
 const VirtualWorkspaceNameKey virtualWorkspaceNameKeyType = "VirtualWorkspaceName"
+
+type httpHeaderKeyType string
+
+// RequestHeaderKey is a context key that contains the http.Header of the request
+// served by a virtual workspace, e.g. for storages implementing conditional requests.
+const RequestHeaderKey httpHeaderKeyType = "RequestHeader"
+
+// ResponseHeaderKey is a context key that contains the http.Header of the response
+// to the request served by a virtual workspace. Storages can set headers like ETag in it
+// before their result is written.
+const ResponseHeaderKey httpHeaderKeyType = "ResponseHeader"
+
+type responseStatusCodeKeyType string
+
+// ResponseStatusCodeKey is a context key that contains a *int storages can set to replace the status code
+// of the response to a conditional request served by a virtual workspace, e.g. with 304 Not Modified.
+// The response is then written with the headers of the result of the storage, but without body.
+const ResponseStatusCodeKey responseStatusCodeKeyType = "ResponseStatusCode"
+
+type requestQueryKeyType string
+
+// RequestQueryKey is a context key that contains the url.Values of the query of the request
+// served by a virtual workspace, e.g. for storages supporting filters that are not list options.
+const RequestQueryKey requestQueryKeyType = "RequestQuery"
+
+type rootPathErrorKeyType string
+
+// RootPathErrorKey is a context key that contains the error a root path resolver reports for the
+// URL path of a request it accepted, e.g. because the path is malformed. Such requests are answered
+// with the error instead of being served by the virtual workspace.
+const RootPathErrorKey rootPathErrorKeyType = "RootPathError"
@@ -19,7 +19,6 @@ package apiserver
 import (
 	"net/http"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -107,7 +106,7 @@ func (c completedConfig) New(virtualWorkspaceName string, groupManager discovery
 		storage[resource] = restStorage
 	}
 
-	apiGroupInfo := genericapiserver.NewDefaultAPIGroupInfo(c.ExtraConfig.GroupVersion.Group, scheme, metav1.ParameterCodec, codecs)
+	apiGroupInfo := genericapiserver.NewDefaultAPIGroupInfo(c.ExtraConfig.GroupVersion.Group, scheme, runtime.NewParameterCodec(scheme), codecs)
 	if len(apiGroupInfo.PrioritizedVersions) == 0 {
 		apiGroupInfo.PrioritizedVersions = append(apiGroupInfo.PrioritizedVersions, c.ExtraConfig.GroupVersion)
 	}
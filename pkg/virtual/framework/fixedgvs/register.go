@@ -17,6 +17,10 @@ limitations under the License.
 package fixedgvs
 
 import (
+	"reflect"
+	"strings"
+
+	restful "github.com/emicklei/go-restful"
 	openapibuilder "k8s.io/apiextensions-apiserver/pkg/controller/openapi/builder"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -26,7 +30,10 @@ import (
 	restStorage "k8s.io/apiserver/pkg/registry/rest"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/kube-openapi/pkg/builder"
+	"k8s.io/kube-openapi/pkg/builder3"
 	"k8s.io/kube-openapi/pkg/handler"
+	"k8s.io/kube-openapi/pkg/handler3"
+	"k8s.io/kube-openapi/pkg/spec3"
 	"k8s.io/kube-openapi/pkg/validation/spec"
 
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/fixedgvs/apiserver"
@@ -36,6 +43,7 @@ func (vw *FixedGroupVersionsVirtualWorkspace) Register(rootAPIServerConfig gener
 	var vwGroupManager discovery.GroupManager
 	var firstAPIServer *genericapiserver.GenericAPIServer
 	var openAPISpecs []*spec.Swagger
+	openAPIV3Specs := map[string]*spec3.OpenAPI{}
 
 	for _, groupVersionAPISet := range vw.GroupVersionAPISets {
 		restStorageBuilders, err := groupVersionAPISet.BootstrapRestResources(rootAPIServerConfig)
@@ -59,6 +67,7 @@ func (vw *FixedGroupVersionsVirtualWorkspace) Register(rootAPIServerConfig gener
 		if err := groupVersionAPISet.AddToScheme(scheme); err != nil {
 			return nil, err
 		}
+		addExternalTypesAsInternal(scheme, groupVersionAPISet.GroupVersion)
 
 		if groupVersionAPISet.OpenAPIDefinitions != nil {
 			cfg.GenericConfig.OpenAPIConfig = genericapiserver.DefaultOpenAPIConfig(groupVersionAPISet.OpenAPIDefinitions, openapi.NewDefinitionNamer(scheme))
@@ -92,6 +101,18 @@ func (vw *FixedGroupVersionsVirtualWorkspace) Register(rootAPIServerConfig gener
 			}
 			spec.Definitions = handler.PruneDefaults(spec.Definitions)
 			openAPISpecs = append(openAPISpecs, spec)
+
+			// the OpenAPI v3 documents are served per group version, like in kube-apiserver
+			for _, webService := range server.GenericAPIServer.Handler.GoRestfulContainer.RegisteredWebServices() {
+				if webService.RootPath() != "/apis/"+groupVersionAPISet.GroupVersion.String() {
+					continue
+				}
+				v3Spec, err := builder3.BuildOpenAPISpec([]*restful.WebService{webService}, config.GenericConfig.OpenAPIConfig)
+				if err != nil {
+					return nil, err
+				}
+				openAPIV3Specs[strings.TrimPrefix(webService.RootPath(), "/")] = v3Spec
+			}
 		}
 
 		if vwGroupManager == nil && server.GenericAPIServer.DiscoveryGroupManager != nil {
@@ -129,5 +150,35 @@ func (vw *FixedGroupVersionsVirtualWorkspace) Register(rootAPIServerConfig gener
 		}
 	}
 
+	if len(openAPIV3Specs) > 0 && firstAPIServer != nil {
+		openAPIV3Service, err := handler3.NewOpenAPIService(nil)
+		if err != nil {
+			return nil, err
+		}
+		for groupVersionPath, v3Spec := range openAPIV3Specs {
+			if err := openAPIV3Service.UpdateGroupVersion(groupVersionPath, v3Spec); err != nil {
+				return nil, err
+			}
+		}
+		if err := openAPIV3Service.RegisterOpenAPIV3VersionedService("/openapi/v3", firstAPIServer.Handler.NonGoRestfulMux); err != nil {
+			return nil, err
+		}
+	}
+
 	return delegateAPIServer, nil
 }
+
+// addExternalTypesAsInternal registers the types of the group version as the internal version of the group too.
+// The REST storages of virtual workspaces only know external types, but some of the apiserver machinery, like the
+// field manager behind server-side apply, converts objects to the internal version.
+func addExternalTypesAsInternal(scheme *runtime.Scheme, groupVersion schema.GroupVersion) {
+	internalGroupVersion := schema.GroupVersion{Group: groupVersion.Group, Version: runtime.APIVersionInternal}
+	metaPkgPath := reflect.TypeOf(metav1.Status{}).PkgPath()
+	for kind, t := range scheme.KnownTypes(groupVersion) {
+		// the meta types, like WatchEvent, have their own internal types
+		if t.PkgPath() == metaPkgPath || scheme.Recognizes(internalGroupVersion.WithKind(kind)) {
+			continue
+		}
+		scheme.AddKnownTypeWithName(internalGroupVersion.WithKind(kind), reflect.New(t).Interface().(runtime.Object))
+	}
+}
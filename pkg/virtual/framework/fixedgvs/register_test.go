@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fixedgvs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	restStorage "k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/rest"
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	kcpopenapi "github.com/kcp-dev/kcp/pkg/openapi"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+)
+
+// workspaceStorage serves empty Workspaces, enough for their API to be published.
+type workspaceStorage struct{}
+
+var _ restStorage.Getter = workspaceStorage{}
+
+func (workspaceStorage) New() runtime.Object { return &tenancyv1beta1.Workspace{} }
+
+func (workspaceStorage) NamespaceScoped() bool { return false }
+
+func (workspaceStorage) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	return &tenancyv1beta1.Workspace{}, nil
+}
+
+// workspaceListStorage lists fixed Workspaces.
+type workspaceListStorage struct {
+	workspaceStorage
+	restStorage.TableConvertor
+}
+
+var _ restStorage.Lister = workspaceListStorage{}
+
+func (workspaceListStorage) NewList() runtime.Object { return &tenancyv1beta1.WorkspaceList{} }
+
+func (workspaceListStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	return &tenancyv1beta1.WorkspaceList{Items: []tenancyv1beta1.Workspace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "foo"}, Spec: tenancyv1beta1.WorkspaceSpec{Type: "Universal"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "bar"}, Status: tenancyv1beta1.WorkspaceStatus{URL: "https://kcp.dev/clusters/root:bar"}},
+	}}, nil
+}
+
+func TestRegisterServesProtobuf(t *testing.T) {
+	const name = "test"
+	vw := &FixedGroupVersionsVirtualWorkspace{
+		Name: name,
+		GroupVersionAPISets: []GroupVersionAPISet{
+			{
+				GroupVersion:       tenancyv1beta1.SchemeGroupVersion,
+				AddToScheme:        tenancyv1beta1.AddToScheme,
+				OpenAPIDefinitions: kcpopenapi.GetOpenAPIDefinitions,
+				BootstrapRestResources: func(rootAPIServerConfig genericapiserver.CompletedConfig) (map[string]RestStorageBuilder, error) {
+					return map[string]RestStorageBuilder{
+						"workspaces": func(genericapiserver.CompletedConfig) (restStorage.Storage, error) {
+							return workspaceListStorage{TableConvertor: restStorage.NewDefaultTableConvertor(tenancyv1beta1.Resource("workspaces"))}, nil
+						},
+					}, nil
+				},
+			},
+		},
+	}
+
+	config := genericapiserver.NewConfig(legacyscheme.Codecs)
+	config.ExternalAddress = "localhost:6443"
+	config.LoopbackClientConfig = &rest.Config{}
+	server, err := vw.Register(config.Complete(nil), genericapiserver.NewEmptyDelegate())
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/apis/tenancy.kcp.dev/v1beta1/workspaces", nil)
+	req.Header.Set("Accept", runtime.ContentTypeProtobuf)
+	requestInfo, err := (&request.RequestInfoFactory{APIPrefixes: sets.NewString("apis"), GrouplessAPIPrefixes: sets.NewString()}).NewRequestInfo(req)
+	require.NoError(t, err)
+	req = req.WithContext(request.WithRequestInfo(context.WithValue(req.Context(), virtualcontext.VirtualWorkspaceNameKey, name), requestInfo))
+	recorder := httptest.NewRecorder()
+	server.UnprotectedHandler().ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code, "unexpected response: %s", recorder.Body.String())
+	require.Equal(t, runtime.ContentTypeProtobuf, recorder.Header().Get("Content-Type"))
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, tenancyv1beta1.AddToScheme(scheme))
+	decoded, _, err := protobuf.NewSerializer(scheme, scheme).Decode(recorder.Body.Bytes(), nil, nil)
+	require.NoError(t, err)
+	list, ok := decoded.(*tenancyv1beta1.WorkspaceList)
+	require.True(t, ok, "expected a WorkspaceList, got %T", decoded)
+	require.Len(t, list.Items, 2)
+	require.Equal(t, "foo", list.Items[0].Name)
+	require.Equal(t, "Universal", list.Items[0].Spec.Type)
+	require.Equal(t, "https://kcp.dev/clusters/root:bar", list.Items[1].Status.URL)
+}
+
+func TestRegisterPublishesOpenAPI(t *testing.T) {
+	const name = "test"
+	vw := &FixedGroupVersionsVirtualWorkspace{
+		Name: name,
+		GroupVersionAPISets: []GroupVersionAPISet{
+			{
+				GroupVersion:       tenancyv1beta1.SchemeGroupVersion,
+				AddToScheme:        tenancyv1beta1.AddToScheme,
+				OpenAPIDefinitions: kcpopenapi.GetOpenAPIDefinitions,
+				BootstrapRestResources: func(rootAPIServerConfig genericapiserver.CompletedConfig) (map[string]RestStorageBuilder, error) {
+					return map[string]RestStorageBuilder{
+						"workspaces": func(genericapiserver.CompletedConfig) (restStorage.Storage, error) {
+							return workspaceStorage{}, nil
+						},
+					}, nil
+				},
+			},
+		},
+	}
+
+	config := genericapiserver.NewConfig(legacyscheme.Codecs)
+	config.ExternalAddress = "localhost:6443"
+	config.LoopbackClientConfig = &rest.Config{}
+	server, err := vw.Register(config.Complete(nil), genericapiserver.NewEmptyDelegate())
+	require.NoError(t, err)
+
+	get := func(path string) []byte {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), virtualcontext.VirtualWorkspaceNameKey, name))
+		recorder := httptest.NewRecorder()
+		server.UnprotectedHandler().ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code, "unexpected response to %s: %s", path, recorder.Body.String())
+		return recorder.Body.Bytes()
+	}
+
+	var v2 spec.Swagger
+	require.NoError(t, json.Unmarshal(get("/openapi/v2"), &v2))
+	requireWorkspaceStatusPhase(t, v2.Definitions)
+
+	var v3 spec3.OpenAPI
+	require.NoError(t, json.Unmarshal(get("/openapi/v3/apis/tenancy.kcp.dev/v1beta1"), &v3))
+	require.NotNil(t, v3.Components, "expected the OpenAPI v3 document to have components")
+	definitions := spec.Definitions{}
+	for name, schema := range v3.Components.Schemas {
+		definitions[name] = *schema
+	}
+	requireWorkspaceStatusPhase(t, definitions)
+}
+
+// requireWorkspaceStatusPhase checks that the definitions describe the Workspace type and the phase of its status.
+func requireWorkspaceStatusPhase(t *testing.T, definitions spec.Definitions) {
+	definition := func(suffix string) spec.Schema {
+		for name, schema := range definitions {
+			if strings.HasSuffix(name, suffix) {
+				return schema
+			}
+		}
+		require.Failf(t, "missing definition", "expected a definition for %s", suffix)
+		return spec.Schema{}
+	}
+
+	workspace := definition("tenancy.v1beta1.Workspace")
+	require.Contains(t, workspace.Properties, "status")
+	status := definition("tenancy.v1beta1.WorkspaceStatus")
+	require.Contains(t, status.Properties, "phase")
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rootapiserver
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/endpoints/responsewriter"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsSubsystem = "virtual_workspace"
+
+var (
+	// requestDuration observes how long the virtual workspaces take to serve a request. Watches are
+	// not observed, since they last as long as the client keeps them open.
+	requestDuration = metrics.NewHistogramVec(&metrics.HistogramOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "request_duration_seconds",
+		Help:           "Duration of the requests served by virtual workspaces, by verb, resource and HTTP status code. Watches are not observed.",
+		Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"verb", "resource", "code"})
+
+	// requestsTotal counts the requests served by the virtual workspaces.
+	requestsTotal = metrics.NewCounterVec(&metrics.CounterOpts{
+		Subsystem:      metricsSubsystem,
+		Name:           "requests_total",
+		Help:           "Number of requests served by virtual workspaces, by verb, resource and HTTP status code.",
+		StabilityLevel: metrics.ALPHA,
+	}, []string{"verb", "resource", "code"})
+
+	registerMetricsOnce sync.Once
+)
+
+// registerMetrics registers the virtual workspace request metrics with the global registry, which the
+// root API server serves on /metrics. Metrics are only recorded once registered. It is safe to call it
+// multiple times.
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(requestDuration, requestsTotal)
+	})
+}
+
+// withRequestMetrics records the request metrics of the requests served by the handler. The verb and
+// resource come from the RequestInfo of the request context.
+func withRequestMetrics(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		verb, resource := req.Method, ""
+		if requestInfo, ok := genericapirequest.RequestInfoFrom(req.Context()); ok {
+			verb, resource = requestInfo.Verb, requestInfo.Resource
+			if requestInfo.Subresource != "" {
+				resource += "/" + requestInfo.Subresource
+			}
+		}
+
+		start := time.Now()
+		// the flusher of the inner writer must stay reachable for watches to stream
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		handler.ServeHTTP(responsewriter.WrapForHTTP1Or2(recorder), req)
+
+		code := strconv.Itoa(recorder.code)
+		requestsTotal.WithLabelValues(verb, resource, code).Inc()
+		if verb != "watch" {
+			requestDuration.WithLabelValues(verb, resource, code).Observe(time.Since(start).Seconds())
+		}
+	})
+}
+
+// statusRecorder records the status code written to the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+var _ responsewriter.UserProvidedDecorator = &statusRecorder{}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Flush flushes the inner writer, even when it is not one of the HTTP/1.x or HTTP/2 writers WrapForHTTP1Or2 knows.
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
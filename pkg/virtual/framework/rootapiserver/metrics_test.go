@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rootapiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func requestWithInfo(info *genericapirequest.RequestInfo) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/apis/tenancy.kcp.dev/v1beta1/workspaces", nil)
+	return req.WithContext(genericapirequest.WithRequestInfo(req.Context(), info))
+}
+
+func TestRequestMetrics(t *testing.T) {
+	registerMetrics()
+
+	handler := withRequestMetrics(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	counter := requestsTotal.WithLabelValues("list", "workspaces", "200")
+	before, err := testutil.GetCounterMetricValue(counter)
+	require.NoError(t, err)
+
+	handler.ServeHTTP(httptest.NewRecorder(), requestWithInfo(&genericapirequest.RequestInfo{IsResourceRequest: true, Verb: "list", Resource: "workspaces"}))
+
+	after, err := testutil.GetCounterMetricValue(counter)
+	require.NoError(t, err)
+	require.Equal(t, float64(1), after-before, "the list should be counted")
+
+	forbidden := requestsTotal.WithLabelValues("get", "workspaces/kubeconfig", "403")
+	before, err = testutil.GetCounterMetricValue(forbidden)
+	require.NoError(t, err)
+
+	withRequestMetrics(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})).ServeHTTP(httptest.NewRecorder(), requestWithInfo(&genericapirequest.RequestInfo{IsResourceRequest: true, Verb: "get", Resource: "workspaces", Subresource: "kubeconfig"}))
+
+	after, err = testutil.GetCounterMetricValue(forbidden)
+	require.NoError(t, err)
+	require.Equal(t, float64(1), after-before, "the forbidden get should be counted with its status code and subresource")
+}
+
+func TestRequestMetricsKeepStreaming(t *testing.T) {
+	registerMetrics()
+
+	var flushed bool
+	handler := withRequestMetrics(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		require.True(t, ok, "watches need to flush the response")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		flushed = true
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, requestWithInfo(&genericapirequest.RequestInfo{IsResourceRequest: true, Verb: "watch", Resource: "workspaces"}))
+	require.True(t, flushed)
+	require.True(t, recorder.Flushed, "expected the flush to reach the inner response writer")
+
+	count, err := testutil.GetCounterMetricValue(requestsTotal.WithLabelValues("watch", "workspaces", "200"))
+	require.NoError(t, err)
+	require.Equal(t, float64(1), count)
+}
@@ -23,9 +23,11 @@ import (
 	"net/url"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authorization/authorizerfactory"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	genericapiserveroptions "k8s.io/apiserver/pkg/server/options"
@@ -109,6 +111,8 @@ func (c completedConfig) New(delegationTarget genericapiserver.DelegationTarget)
 		readys = append(readys, virtualWorkspace.IsReady)
 	}
 
+	registerMetrics()
+
 	c.GenericConfig.BuildHandlerChainFunc = c.getRootHandlerChain(delegateAPIServer)
 	c.GenericConfig.RequestInfoResolver = c
 	c.GenericConfig.ReadyzChecks = append(c.GenericConfig.ReadyzChecks, asHealthCheck(readys))
@@ -121,6 +125,7 @@ func (c completedConfig) New(delegationTarget genericapiserver.DelegationTarget)
 	s := &RootAPIServer{
 		GenericAPIServer: genericServer,
 	}
+	s.GenericAPIServer.Handler.NonGoRestfulMux.HandleFunc(framework.WhoAmIPath, framework.WhoAmIHandler)
 
 	// register our poststarthooks
 	s.GenericAPIServer.AddPostStartHookOrDie("virtual-workspace-startinformers", func(context genericapiserver.PostStartHookContext) error {
@@ -146,8 +151,24 @@ func (readys asHealthCheck) Check(req *http.Request) error {
 	return nil
 }
 
+// rootServerPaths are the paths served by the root API server itself, which are never
+// resolved against the root paths of the virtual workspaces, even if those overlap them.
+var rootServerPaths = []string{"/healthz", "/livez", "/readyz", "/metrics", framework.WhoAmIPath}
+
+func isRootServerPath(urlPath string) bool {
+	for _, p := range rootServerPaths {
+		if urlPath == p || strings.HasPrefix(urlPath, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 func (c completedConfig) resolveRootPaths(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
 	completedContext = requestContext
+	if isRootServerPath(urlPath) {
+		return
+	}
 	for _, virtualWorkspace := range c.ExtraConfig.VirtualWorkspaces {
 		if accepted, prefixToStrip, completedContext := virtualWorkspace.ResolveRootPath(urlPath, requestContext); accepted {
 			return accepted, prefixToStrip, context.WithValue(completedContext, virtualcontext.VirtualWorkspaceNameKey, virtualWorkspace.GetName())
@@ -160,6 +181,10 @@ func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.
 	return func(apiHandler http.Handler, genericConfig *genericapiserver.Config) http.Handler {
 		return genericapiserver.DefaultBuildHandlerChain(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			if accepted, prefixToStrip, context := c.resolveRootPaths(req.URL.Path, req.Context()); accepted {
+				if err, ok := context.Value(virtualcontext.RootPathErrorKey).(error); ok && err != nil {
+					responsewriters.ErrorNegotiated(err, legacyscheme.Codecs, schema.GroupVersion{}, w, req)
+					return
+				}
 				req.URL.Path = strings.TrimPrefix(req.URL.Path, prefixToStrip)
 				req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, prefixToStrip)
 				// In the current KCP Kubernetes feature branch, some components (e.g.Discovery index)
@@ -171,10 +196,12 @@ func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.
 				if req.URL.Path != "/openapi/v2" {
 					context = genericapirequest.WithCluster(context, genericapirequest.Cluster{Name: "virtual"})
 				}
+				context = contextWithHeaders(context, req.Header, w.Header())
+				context = contextWithQuery(context, req.URL.Query())
 				req = req.WithContext(context)
 				delegatedHandler := delegateAPIServer.UnprotectedHandler()
 				if delegatedHandler != nil {
-					delegatedHandler.ServeHTTP(w, req)
+					withRequestMetrics(withConditionalStatusCode(delegatedHandler)).ServeHTTP(w, req)
 				}
 				return
 			}
@@ -183,6 +210,54 @@ func (c completedConfig) getRootHandlerChain(delegateAPIServer genericapiserver.
 	}
 }
 
+func contextWithHeaders(ctx context.Context, requestHeader, responseHeader http.Header) context.Context {
+	return context.WithValue(context.WithValue(ctx, virtualcontext.RequestHeaderKey, requestHeader), virtualcontext.ResponseHeaderKey, responseHeader)
+}
+
+func contextWithQuery(ctx context.Context, query url.Values) context.Context {
+	return context.WithValue(ctx, virtualcontext.RequestQueryKey, query)
+}
+
+// withConditionalStatusCode lets the storages serving conditional requests replace the status code of their
+// result through the virtualcontext.ResponseStatusCodeKey of the request context.
+func withConditionalStatusCode(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("If-None-Match") == "" {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		statusCodeWriter := &statusCodeResponseWriter{ResponseWriter: w}
+		handler.ServeHTTP(statusCodeWriter, req.WithContext(context.WithValue(req.Context(), virtualcontext.ResponseStatusCodeKey, &statusCodeWriter.statusCode)))
+	})
+}
+
+// statusCodeResponseWriter writes the status code set by the storage serving a conditional request, if any,
+// instead of the one of its result. Responses to conditional requests which were not modified are written
+// without body.
+type statusCodeResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	noBody     bool
+}
+
+func (w *statusCodeResponseWriter) WriteHeader(code int) {
+	if w.statusCode != 0 {
+		code = w.statusCode
+	}
+	if code == http.StatusNotModified {
+		w.noBody = true
+		w.Header().Del("Content-Type")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusCodeResponseWriter) Write(b []byte) (int, error) {
+	if w.noBody {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
 var _ genericapirequest.RequestInfoResolver = (*completedConfig)(nil)
 
 // NewRequestInfo method makes the `completedConfig` an implementation of a RequestInfoResolver.
@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rootapiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+)
+
+func TestConditionalStatusCode(t *testing.T) {
+	handler := withConditionalStatusCode(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if statusCode, ok := req.Context().Value(virtualcontext.ResponseStatusCodeKey).(*int); ok {
+			*statusCode = http.StatusNotModified
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte("body"))
+		require.NoError(t, err)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/apis/tenancy.kcp.dev/v1beta1/workspaces/foo/kubeconfig", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code, "unconditional requests should keep their status code")
+	require.Equal(t, "body", recorder.Body.String())
+
+	req.Header.Set("If-None-Match", `"etag"`)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusNotModified, recorder.Code)
+	require.Empty(t, recorder.Body.String(), "304 responses should not have a body")
+	require.Empty(t, recorder.Header().Get("Content-Type"))
+}
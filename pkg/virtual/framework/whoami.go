@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"net/http"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+)
+
+// WhoAmIPath is the non-resource path at which the root API server of the virtual workspaces
+// returns the identity of the authenticated user.
+const WhoAmIPath = "/whoami"
+
+// WhoAmI is the identity of the user a virtual workspace sees, as returned at WhoAmIPath.
+type WhoAmI struct {
+	Name   string   `json:"name"`
+	UID    string   `json:"uid,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// WhoAmIHandler answers GET requests with the WhoAmI of the authenticated user of the request.
+// Anonymous requests are rejected as unauthorized. The extra fields of the user info are
+// not returned, so that nothing derived from the credentials of the request is echoed.
+func WhoAmIHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		responsewriters.ErrorNegotiated(kerrors.NewMethodNotSupported(schema.GroupResource{Resource: "whoami"}, req.Method), legacyscheme.Codecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	u, ok := genericapirequest.UserFrom(req.Context())
+	if !ok || u.GetName() == "" || u.GetName() == user.Anonymous {
+		responsewriters.ErrorNegotiated(kerrors.NewUnauthorized("authentication is required"), legacyscheme.Codecs, schema.GroupVersion{}, w, req)
+		return
+	}
+	responsewriters.WriteRawJSON(http.StatusOK, WhoAmI{
+		Name:   u.GetName(),
+		UID:    u.GetUID(),
+		Groups: u.GetGroups(),
+	}, w)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestWhoAmIHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		user           user.Info
+		expectedStatus int
+		expected       *WhoAmI
+	}{
+		{
+			name:   "authenticated user",
+			method: http.MethodGet,
+			user: &user.DefaultInfo{
+				Name:   "user-1",
+				UID:    "1111-1111-1111-1111",
+				Groups: []string{"team-1"},
+				Extra:  map[string][]string{"authentication.kubernetes.io/credential-id": {"user-1-token"}},
+			},
+			expectedStatus: http.StatusOK,
+			expected: &WhoAmI{
+				Name:   "user-1",
+				UID:    "1111-1111-1111-1111",
+				Groups: []string{"team-1"},
+			},
+		},
+		{
+			name:           "no user",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "anonymous user",
+			method:         http.MethodGet,
+			user:           &user.DefaultInfo{Name: user.Anonymous, Groups: []string{user.AllUnauthenticated}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "unsupported method",
+			method:         http.MethodPost,
+			user:           &user.DefaultInfo{Name: "user-1"},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, WhoAmIPath, nil)
+			if test.user != nil {
+				req = req.WithContext(genericapirequest.WithUser(req.Context(), test.user))
+			}
+			w := httptest.NewRecorder()
+			WhoAmIHandler(w, req)
+
+			require.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			require.NotContains(t, w.Body.String(), "user-1-token")
+			if test.expected == nil {
+				return
+			}
+			var got WhoAmI
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			require.Equal(t, *test.expected, got)
+		})
+	}
+}
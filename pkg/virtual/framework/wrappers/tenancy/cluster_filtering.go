@@ -54,6 +54,10 @@ func (i *filteredInterface) WorkspaceShards() tenancyinformers.WorkspaceShardInf
 	return FilterWorkspaceShardInformer(i.clusterName, i.informers.WorkspaceShards())
 }
 
+func (i *filteredInterface) WorkspaceQuotas() tenancyinformers.WorkspaceQuotaInformer {
+	return FilterWorkspaceQuotaInformer(i.clusterName, i.informers.WorkspaceQuotas())
+}
+
 func FilterClusterWorkspaceTypeInformer(clusterName string, informer tenancyinformers.ClusterWorkspaceTypeInformer) tenancyinformers.ClusterWorkspaceTypeInformer {
 	return &filteredClusterWorkspaceTypeInformer{
 		clusterName: clusterName,
@@ -266,3 +270,74 @@ func (l *filteredWorkspaceShardLister) GetWithContext(ctx context.Context, name
 	}
 	return l.lister.GetWithContext(ctx, name)
 }
+
+func FilterWorkspaceQuotaInformer(clusterName string, informer tenancyinformers.WorkspaceQuotaInformer) tenancyinformers.WorkspaceQuotaInformer {
+	return &filteredWorkspaceQuotaInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.WorkspaceQuotaInformer = (*filteredWorkspaceQuotaInformer)(nil)
+var _ tenancylisters.WorkspaceQuotaLister = (*filteredWorkspaceQuotaLister)(nil)
+
+type filteredWorkspaceQuotaInformer struct {
+	clusterName string
+	informer    tenancyinformers.WorkspaceQuotaInformer
+}
+
+type filteredWorkspaceQuotaLister struct {
+	clusterName string
+	lister      tenancylisters.WorkspaceQuotaLister
+}
+
+func (i *filteredWorkspaceQuotaInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredWorkspaceQuotaInformer) Lister() tenancylisters.WorkspaceQuotaLister {
+	return &filteredWorkspaceQuotaLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredWorkspaceQuotaLister) List(selector labels.Selector) (ret []*tenancyapis.WorkspaceQuota, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ClusterName == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceQuotaLister) Get(name string) (*tenancyapis.WorkspaceQuota, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName == "" {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func (l *filteredWorkspaceQuotaLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyapis.WorkspaceQuota, err error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ClusterName == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceQuotaLister) GetWithContext(ctx context.Context, name string) (*tenancyapis.WorkspaceQuota, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName == "" {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.GetWithContext(ctx, name)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	workspaceinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	kcpopenapi "github.com/kcp-dev/kcp/pkg/openapi"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/fixedgvs"
+	shardsregistry "github.com/kcp-dev/kcp/pkg/virtual/shards/registry"
+)
+
+const ShardsVirtualWorkspaceName string = "shards"
+const DefaultRootPathPrefix string = "/admin/shards"
+
+// BuildVirtualWorkspace returns the admin-only virtual workspace serving the WorkspaceShards of the root
+// workspace along with their load. Only the members of the given admin groups can access it, requests of
+// other users are forbidden.
+func BuildVirtualWorkspace(rootPathPrefix string, adminGroups []string, rootWorkspaceShards workspaceinformer.WorkspaceShardInformer) framework.VirtualWorkspace {
+	rootPathPrefix = strings.TrimSuffix(rootPathPrefix, "/")
+	admins := sets.NewString(adminGroups...)
+	// get the informer right away, so that it is started with the other informers of its factory
+	rootWorkspaceShardInformer := rootWorkspaceShards.Informer()
+
+	return &fixedgvs.FixedGroupVersionsVirtualWorkspace{
+		Name: ShardsVirtualWorkspaceName,
+		Ready: func() error {
+			if !rootWorkspaceShardInformer.HasSynced() {
+				return errors.New("WorkspaceShard informer is not synced")
+			}
+			return nil
+		},
+		RootPathResolver: func(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+			completedContext = requestContext
+			if urlPath != rootPathPrefix && !strings.HasPrefix(urlPath, rootPathPrefix+"/") {
+				return false, "", completedContext
+			}
+			user, found := genericapirequest.UserFrom(requestContext)
+			if !found || !admins.HasAny(user.GetGroups()...) {
+				err := kerrors.NewForbidden(tenancyv1alpha1.Resource("workspaceshards"), "", errors.New("only members of privileged groups can access the shards virtual workspace"))
+				return true, rootPathPrefix, context.WithValue(requestContext, virtualcontext.RootPathErrorKey, err)
+			}
+			return true, rootPathPrefix, completedContext
+		},
+		GroupVersionAPISets: []fixedgvs.GroupVersionAPISet{
+			{
+				GroupVersion:       tenancyv1alpha1.SchemeGroupVersion,
+				AddToScheme:        tenancyv1alpha1.AddToScheme,
+				OpenAPIDefinitions: kcpopenapi.GetOpenAPIDefinitions,
+				BootstrapRestResources: func(mainConfig genericapiserver.CompletedConfig) (map[string]fixedgvs.RestStorageBuilder, error) {
+					workspaceShardsRest := shardsregistry.NewREST(rootWorkspaceShards.Lister())
+					return map[string]fixedgvs.RestStorageBuilder{
+						"workspaceshards": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return workspaceShardsRest, nil
+						},
+					}, nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+)
+
+func TestResolveRootPath(t *testing.T) {
+	informers := kcpinformer.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), 0)
+	vw := BuildVirtualWorkspace(DefaultRootPathPrefix, []string{kuser.SystemPrivilegedGroup, "shard-admins"}, informers.Tenancy().V1alpha1().WorkspaceShards())
+
+	for _, tc := range []struct {
+		name              string
+		urlPath           string
+		user              kuser.Info
+		expectAccepted    bool
+		expectForbidden   bool
+		expectedStripping string
+	}{
+		{
+			name:              "privileged user",
+			urlPath:           "/admin/shards/apis/tenancy.kcp.dev/v1alpha1/workspaceshards",
+			user:              &kuser.DefaultInfo{Name: "admin", Groups: []string{kuser.SystemPrivilegedGroup}},
+			expectAccepted:    true,
+			expectedStripping: "/admin/shards",
+		},
+		{
+			name:              "member of another admin group",
+			urlPath:           "/admin/shards/apis",
+			user:              &kuser.DefaultInfo{Name: "operator", Groups: []string{"team-1", "shard-admins"}},
+			expectAccepted:    true,
+			expectedStripping: "/admin/shards",
+		},
+		{
+			name:              "normal user",
+			urlPath:           "/admin/shards/apis/tenancy.kcp.dev/v1alpha1/workspaceshards",
+			user:              &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1", kuser.AllAuthenticated}},
+			expectAccepted:    true,
+			expectForbidden:   true,
+			expectedStripping: "/admin/shards",
+		},
+		{
+			name:              "no user",
+			urlPath:           "/admin/shards/apis",
+			expectAccepted:    true,
+			expectForbidden:   true,
+			expectedStripping: "/admin/shards",
+		},
+		{
+			name:    "other path",
+			urlPath: "/admin/shardsandmore/apis",
+			user:    &kuser.DefaultInfo{Name: "admin", Groups: []string{kuser.SystemPrivilegedGroup}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.user != nil {
+				ctx = genericapirequest.WithUser(ctx, tc.user)
+			}
+			accepted, prefixToStrip, completedContext := vw.ResolveRootPath(tc.urlPath, ctx)
+			require.Equal(t, tc.expectAccepted, accepted)
+			require.Equal(t, tc.expectedStripping, prefixToStrip)
+			if !accepted {
+				return
+			}
+			err, _ := completedContext.Value(virtualcontext.RootPathErrorKey).(error)
+			if tc.expectForbidden {
+				require.True(t, kerrors.IsForbidden(err), "expected Forbidden, got %v", err)
+				require.Equal(t, int32(403), err.(kerrors.APIStatus).Status().Code)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
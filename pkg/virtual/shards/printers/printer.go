@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printers
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	kprinters "k8s.io/kubernetes/pkg/printers"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func AddWorkspaceShardPrintHandlers(h kprinters.PrintHandler) {
+	workspaceShardColumnDefinitions := []metav1.TableColumnDefinition{
+		{
+			Name:        "Name",
+			Type:        "string",
+			Format:      "name",
+			Description: metav1.ObjectMeta{}.SwaggerDoc()["name"],
+			Priority:    0,
+		},
+		{
+			Name:        "Capacity",
+			Type:        "string",
+			Description: "Maximal number of workspaces scheduled onto the shard",
+			Priority:    0,
+		},
+		{
+			Name:        "Workspaces",
+			Type:        "integer",
+			Description: "Number of workspaces currently scheduled onto the shard",
+			Priority:    0,
+		},
+		{
+			Name:        "Reachable",
+			Type:        "string",
+			Description: "Whether the health checks of the shard succeed",
+			Priority:    0,
+		},
+		{
+			Name:        "Draining",
+			Type:        "boolean",
+			Description: "Whether the workspaces of the shard are moved to other shards",
+			Priority:    0,
+		},
+		{
+			Name:        "Cordoned",
+			Type:        "boolean",
+			Description: "Whether no new workspaces are scheduled onto the shard",
+			Priority:    0,
+		},
+		{
+			Name:        "Age",
+			Type:        "string",
+			Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"],
+			Priority:    0,
+		},
+	}
+
+	if err := h.TableHandler(workspaceShardColumnDefinitions, printWorkspaceShardList); err != nil {
+		panic(err)
+	}
+	if err := h.TableHandler(workspaceShardColumnDefinitions, printWorkspaceShard); err != nil {
+		panic(err)
+	}
+}
+
+func printWorkspaceShard(shard *tenancyv1alpha1.WorkspaceShard, options kprinters.GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{
+		Object: runtime.RawExtension{Object: shard},
+	}
+
+	capacity := "<unlimited>"
+	if shard.Spec.Capacity != nil {
+		capacity = strconv.Itoa(int(*shard.Spec.Capacity))
+	}
+	reachable := corev1.ConditionUnknown
+	if condition := conditions.Get(shard, tenancyv1alpha1.ShardReachable); condition != nil {
+		reachable = condition.Status
+	}
+	draining := shard.Spec.Draining || conditions.IsTrue(shard, tenancyv1alpha1.WorkspaceShardDraining)
+	cordoned := conditions.IsTrue(shard, tenancyv1alpha1.WorkspaceShardSchedulingDisabled)
+
+	row.Cells = append(row.Cells, shard.Name, capacity, int64(shard.Status.CurrentWorkspaces), string(reachable), draining, cordoned, translateTimestampSince(shard.CreationTimestamp))
+
+	return []metav1.TableRow{row}, nil
+}
+
+func printWorkspaceShardList(list *tenancyv1alpha1.WorkspaceShardList, options kprinters.GenerateOptions) ([]metav1.TableRow, error) {
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printWorkspaceShard(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+// translateTimestampSince returns the elapsed time since timestamp in
+// human-readable approximation.
+func translateTimestampSince(timestamp metav1.Time) string {
+	if timestamp.IsZero() {
+		return "<unknown>"
+	}
+
+	return duration.HumanDuration(time.Since(timestamp.Time))
+}
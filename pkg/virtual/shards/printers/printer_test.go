@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kprinters "k8s.io/kubernetes/pkg/printers"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+func TestPrintWorkspaceShardList(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	capacity := int32(100)
+	list := &tenancyv1alpha1.WorkspaceShardList{
+		Items: []tenancyv1alpha1.WorkspaceShard{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "paris", CreationTimestamp: created},
+				Spec:       tenancyv1alpha1.WorkspaceShardSpec{Capacity: &capacity},
+				Status: tenancyv1alpha1.WorkspaceShardStatus{
+					CurrentWorkspaces: 42,
+					Conditions: conditionsv1alpha1.Conditions{
+						{Type: tenancyv1alpha1.ShardReachable, Status: corev1.ConditionTrue},
+						{Type: tenancyv1alpha1.WorkspaceShardSchedulingDisabled, Status: corev1.ConditionTrue},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "boston"},
+				Spec:       tenancyv1alpha1.WorkspaceShardSpec{Draining: true},
+				Status: tenancyv1alpha1.WorkspaceShardStatus{
+					CurrentWorkspaces: 7,
+					Conditions: conditionsv1alpha1.Conditions{
+						{Type: tenancyv1alpha1.ShardReachable, Status: corev1.ConditionFalse},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "berlin"},
+				Status: tenancyv1alpha1.WorkspaceShardStatus{
+					Conditions: conditionsv1alpha1.Conditions{
+						{Type: tenancyv1alpha1.WorkspaceShardDraining, Status: corev1.ConditionTrue},
+					},
+				},
+			},
+		},
+	}
+
+	table, err := kprinters.NewTableGenerator().With(AddWorkspaceShardPrintHandlers).GenerateTable(list, kprinters.GenerateOptions{})
+	require.NoError(t, err)
+
+	var columns []string
+	for _, column := range table.ColumnDefinitions {
+		columns = append(columns, column.Name)
+	}
+	require.Equal(t, []string{"Name", "Capacity", "Workspaces", "Reachable", "Draining", "Cordoned", "Age"}, columns)
+
+	require.Len(t, table.Rows, 3)
+	require.Equal(t, []interface{}{"berlin", "<unlimited>", int64(0), "Unknown", true, false, "<unknown>"}, table.Rows[0].Cells)
+	require.Equal(t, []interface{}{"boston", "<unlimited>", int64(7), "False", true, false, "<unknown>"}, table.Rows[1].Cells)
+	require.Equal(t, []interface{}{"paris", "100", int64(42), "True", false, true, "120m"}, table.Rows[2].Cells)
+}
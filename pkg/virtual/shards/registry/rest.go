@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"sort"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/kubernetes/pkg/printers"
+	printerstorage "k8s.io/kubernetes/pkg/printers/storage"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	shardprinters "github.com/kcp-dev/kcp/pkg/virtual/shards/printers"
+)
+
+// REST serves the WorkspaceShards of the root workspace read-only, along with their load: capacity,
+// number of scheduled workspaces, reachability, and draining and cordon state.
+type REST struct {
+	// workspaceShardLister lists the WorkspaceShards of the root workspace
+	workspaceShardLister tenancylisters.WorkspaceShardLister
+
+	rest.TableConvertor
+}
+
+var _ rest.Lister = &REST{}
+var _ rest.Getter = &REST{}
+var _ rest.Scoper = &REST{}
+
+// NewREST returns a RESTStorage object that will work against the WorkspaceShards of the given lister.
+func NewREST(workspaceShardLister tenancylisters.WorkspaceShardLister) *REST {
+	return &REST{
+		workspaceShardLister: workspaceShardLister,
+		TableConvertor:       printerstorage.TableConvertor{TableGenerator: printers.NewTableGenerator().With(shardprinters.AddWorkspaceShardPrintHandlers)},
+	}
+}
+
+// New returns a new WorkspaceShard
+func (s *REST) New() runtime.Object {
+	return &tenancyv1alpha1.WorkspaceShard{}
+}
+
+// NewList returns a new WorkspaceShardList
+func (s *REST) NewList() runtime.Object {
+	return &tenancyv1alpha1.WorkspaceShardList{}
+}
+
+func (s *REST) NamespaceScoped() bool {
+	return false
+}
+
+// List retrieves the WorkspaceShards matching the label selector of the options, sorted by name.
+func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (runtime.Object, error) {
+	selector := labels.Everything()
+	if options != nil && options.LabelSelector != nil {
+		selector = options.LabelSelector
+	}
+	shards, err := s.workspaceShardLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &tenancyv1alpha1.WorkspaceShardList{Items: make([]tenancyv1alpha1.WorkspaceShard, 0, len(shards))}
+	for _, shard := range shards {
+		list.Items = append(list.Items, *shard.DeepCopy())
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	return list, nil
+}
+
+// Get retrieves a WorkspaceShard by name
+func (s *REST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	shard, err := s.workspaceShardLister.Get(name)
+	if kerrors.IsNotFound(err) {
+		// the lister reports the cluster-aware key of the shard, not its name
+		return nil, kerrors.NewNotFound(tenancyv1alpha1.Resource("workspaceshards"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return shard.DeepCopy(), nil
+}
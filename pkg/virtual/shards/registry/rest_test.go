@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	tenancywrapper "github.com/kcp-dev/kcp/pkg/virtual/framework/wrappers/tenancy"
+)
+
+func TestListAndGetWorkspaceShards(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, shard := range []*tenancyv1alpha1.WorkspaceShard{
+		{ObjectMeta: metav1.ObjectMeta{Name: "paris", ClusterName: helper.RootCluster, Labels: map[string]string{"region": "eu"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "boston", ClusterName: helper.RootCluster, Labels: map[string]string{"region": "us"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "berlin", ClusterName: helper.RootCluster, Labels: map[string]string{"region": "eu"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", ClusterName: "root:org"}},
+	} {
+		require.NoError(t, indexer.Add(shard))
+	}
+	storage := NewREST(tenancywrapper.FilterWorkspaceShardInformer(helper.RootCluster, staticWorkspaceShardInformer{indexer}).Lister())
+	ctx := context.Background()
+
+	shardNames := func(obj interface{}) []string {
+		var names []string
+		for _, shard := range obj.(*tenancyv1alpha1.WorkspaceShardList).Items {
+			names = append(names, shard.Name)
+		}
+		return names
+	}
+
+	list, err := storage.List(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"berlin", "boston", "paris"}, shardNames(list), "expected all the shards of the root workspace, sorted by name")
+
+	list, err = storage.List(ctx, &metainternal.ListOptions{LabelSelector: labels.SelectorFromSet(labels.Set{"region": "eu"})})
+	require.NoError(t, err)
+	require.Equal(t, []string{"berlin", "paris"}, shardNames(list))
+
+	shard, err := storage.Get(ctx, "boston", &metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "boston", shard.(*tenancyv1alpha1.WorkspaceShard).Name)
+
+	_, err = storage.Get(ctx, "elsewhere", &metav1.GetOptions{})
+	require.True(t, kerrors.IsNotFound(err), "expected the shards of other workspaces to be not found, got %v", err)
+	require.Equal(t, "elsewhere", err.(kerrors.APIStatus).Status().Details.Name)
+}
+
+// staticWorkspaceShardInformer lists the WorkspaceShards of an indexer, like a synced informer.
+type staticWorkspaceShardInformer struct {
+	indexer cache.Indexer
+}
+
+func (i staticWorkspaceShardInformer) Informer() cache.SharedIndexInformer {
+	return nil
+}
+
+func (i staticWorkspaceShardInformer) Lister() tenancylisters.WorkspaceShardLister {
+	return tenancylisters.NewWorkspaceShardLister(i.indexer)
+}
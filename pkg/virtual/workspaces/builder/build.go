@@ -22,7 +22,9 @@ import (
 	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	rbacinformers "k8s.io/client-go/informers/rbac/v1"
@@ -35,6 +37,7 @@ import (
 	workspaceinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	kcpopenapi "github.com/kcp-dev/kcp/pkg/openapi"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework/fixedgvs"
 	frameworkrbac "github.com/kcp-dev/kcp/pkg/virtual/framework/rbac"
 	rbacwrapper "github.com/kcp-dev/kcp/pkg/virtual/framework/wrappers/rbac"
@@ -47,13 +50,17 @@ import (
 const WorkspacesVirtualWorkspaceName string = "workspaces"
 const DefaultRootPathPrefix string = "/services/workspaces"
 
-func BuildVirtualWorkspace(rootPathPrefix string, wildcardsClusterWorkspaces workspaceinformer.ClusterWorkspaceInformer, wildcardsRbacInformers rbacinformers.Interface, rootKcpClient kcpclient.Interface, rootKubeClient kubernetes.Interface, kcpClusterInterface kcpclient.ClusterInterface, kubeClusterInterface kubernetes.ClusterInterface) framework.VirtualWorkspace {
+func BuildVirtualWorkspace(rootPathPrefix, defaultOrg string, wildcardsClusterWorkspaces workspaceinformer.ClusterWorkspaceInformer, wildcardsRbacInformers rbacinformers.Interface, rootKcpClient kcpclient.Interface, rootKubeClient kubernetes.Interface, kcpClusterInterface kcpclient.ClusterInterface, kubeClusterInterface kubernetes.ClusterInterface, options virtualworkspacesregistry.Options) framework.VirtualWorkspace {
 	crbInformer := wildcardsRbacInformers.ClusterRoleBindings()
 	_ = virtualworkspacesregistry.AddNameIndexers(crbInformer)
 
 	if !strings.HasSuffix(rootPathPrefix, "/") {
 		rootPathPrefix += "/"
 	}
+	var defaultOrgClusterName string
+	if defaultOrg != "" {
+		defaultOrgClusterName = helper.EncodeOrganizationAndClusterWorkspace(helper.RootCluster, defaultOrg)
+	}
 	var rootWorkspaceAuthorizationCache *workspaceauth.AuthorizationCache
 	var globalClusterWorkspaceCache *workspacecache.ClusterWorkspaceCache
 	var orgListener *orgListener
@@ -76,29 +83,47 @@ func BuildVirtualWorkspace(rootPathPrefix string, wildcardsClusterWorkspaces wor
 		},
 		RootPathResolver: func(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
 			completedContext = requestContext
-			if path := urlPath; strings.HasPrefix(path, rootPathPrefix) {
-				path = strings.TrimPrefix(path, rootPathPrefix)
-				segments := strings.SplitN(path, "/", 3)
-				if len(segments) < 2 {
-					return
+			accepted, org, scope, prefixToStrip, err := resolveRootPath(urlPath, rootPathPrefix, defaultOrgClusterName, func(org string) bool {
+				// Until the org listener is started, let the readiness check answer the request.
+				if orgListener == nil {
+					return true
 				}
-				org, scope := segments[0], segments[1]
-				if !virtualworkspacesregistry.ScopeSet.Has(scope) {
-					return
+				_, err := orgListener.GetOrg(org)
+				return err == nil
+			}, func(org string) bool {
+				if rootWorkspaceAuthorizationCache == nil {
+					return true
 				}
-
-				// Do not allow the personal scope when accessing orgs as workspaces in the root logical cluster
-				if scope == virtualworkspacesregistry.PersonalScope && org == helper.RootCluster {
-					return
+				user, found := genericapirequest.UserFrom(requestContext)
+				if !found {
+					return false
 				}
-
-				return true, rootPathPrefix + strings.Join(segments[:2], "/"),
-					context.WithValue(
-						context.WithValue(requestContext, virtualworkspacesregistry.WorkspacesScopeKey, scope),
-						virtualworkspacesregistry.WorkspacesOrgKey, org,
-					)
+				_, orgName, err := helper.ParseLogicalClusterName(org)
+				if err != nil {
+					return false
+				}
+				orgs, err := rootWorkspaceAuthorizationCache.List(user, labels.Everything())
+				if err != nil {
+					return false
+				}
+				for i := range orgs.Items {
+					if orgs.Items[i].Name == orgName {
+						return true
+					}
+				}
+				return false
+			})
+			if !accepted {
+				return
+			}
+			if err != nil {
+				return true, prefixToStrip, context.WithValue(requestContext, virtualcontext.RootPathErrorKey, err)
 			}
-			return
+			return true, prefixToStrip,
+				context.WithValue(
+					context.WithValue(requestContext, virtualworkspacesregistry.WorkspacesScopeKey, scope),
+					virtualworkspacesregistry.WorkspacesOrgKey, org,
+				)
 		},
 		GroupVersionAPISets: []fixedgvs.GroupVersionAPISet{
 			{
@@ -128,11 +153,12 @@ func BuildVirtualWorkspace(rootPathPrefix string, wildcardsClusterWorkspaces wor
 						rootRBACInformers,
 					)
 
-					rootOrg := virtualworkspacesregistry.CreateAndStartOrg(rootRBACClient, rootTenancyClient.ClusterWorkspaces(), rootRBACInformers, rbacwrapper.FilterClusterRoleBindingInformer(helper.RootCluster, crbInformer), rootClusterWorkspaceInformer)
+					rootOrg := virtualworkspacesregistry.CreateAndStartOrg(rootRBACClient, rootTenancyClient.ClusterWorkspaces(), rootTenancyClient.WorkspaceQuotas(), rootRBACInformers, rbacwrapper.FilterClusterRoleBindingInformer(helper.RootCluster, crbInformer), rootClusterWorkspaceInformer)
 					orgListener = NewOrgListener(globalClusterWorkspaceCache, rootOrg, func(orgClusterName string) *virtualworkspacesregistry.Org {
 						return virtualworkspacesregistry.CreateAndStartOrg(
 							kubeClusterInterface.Cluster(orgClusterName).RbacV1(),
 							kcpClusterInterface.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces(),
+							kcpClusterInterface.Cluster(orgClusterName).TenancyV1alpha1().WorkspaceQuotas(),
 							rbacwrapper.FilterInformers(orgClusterName, wildcardsRbacInformers),
 							rbacwrapper.FilterClusterRoleBindingInformer(orgClusterName, crbInformer),
 							tenancywrapper.FilterClusterWorkspaceInformer(orgClusterName, wildcardsClusterWorkspaces))
@@ -168,13 +194,53 @@ func BuildVirtualWorkspace(rootPathPrefix string, wildcardsClusterWorkspaces wor
 						return nil, err
 					}
 
-					workspacesRest, kubeconfigSubresourceRest := virtualworkspacesregistry.NewREST(rootKcpClient.TenancyV1alpha1(), rootKubeClient, globalClusterWorkspaceCache, crbInformer, orgListener.GetOrg)
+					storage := virtualworkspacesregistry.NewREST(rootKcpClient.TenancyV1alpha1(), rootKubeClient, globalClusterWorkspaceCache, wildcardsRbacInformers, orgListener.GetOrg, options)
+					workspacesRest := storage.Workspaces
 					return map[string]fixedgvs.RestStorageBuilder{
 						"workspaces": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
 							return workspacesRest, nil
 						},
 						"workspaces/kubeconfig": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
-							return kubeconfigSubresourceRest, nil
+							return storage.Kubeconfig, nil
+						},
+						"workspaces/apiresources": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return storage.APIResources, nil
+						},
+						"workspaces/authorization": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return storage.Authorization, nil
+						},
+						"workspaces/move": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return storage.Move, nil
+						},
+						"workspaces/rename": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return storage.Rename, nil
+						},
+						"workspaces/transfer-ownership": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return storage.TransferOwnership, nil
+						},
+						"workspaces/restore": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return virtualworkspacesregistry.NewRestoreSubresourceREST(workspacesRest), nil
+						},
+						"workspaces/refresh-url": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return virtualworkspacesregistry.NewRefreshURLSubresourceREST(workspacesRest), nil
+						},
+						"workspaces/freeze": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return virtualworkspacesregistry.NewFreezeSubresourceREST(workspacesRest, true), nil
+						},
+						"workspaces/unfreeze": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return virtualworkspacesregistry.NewFreezeSubresourceREST(workspacesRest, false), nil
+						},
+						"workspaces/children": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return virtualworkspacesregistry.NewChildrenSubresourceREST(workspacesRest, kcpClusterInterface, wildcardsRbacInformers), nil
+						},
+						"workspaces/export": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return virtualworkspacesregistry.NewExportSubresourceREST(workspacesRest, rootKubeClient.CoreV1(), rootTenancyClient.WorkspaceShards()), nil
+						},
+						"workspaces/import": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return virtualworkspacesregistry.NewImportSubresourceREST(workspacesRest, rootKubeClient.CoreV1(), rootTenancyClient.WorkspaceShards()), nil
+						},
+						"workspacebatches": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return storage.WorkspaceBatches, nil
 						},
 					}, nil
 				},
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	virtualworkspacesregistry "github.com/kcp-dev/kcp/pkg/virtual/workspaces/registry"
+)
+
+// resolveRootPath parses the URL path of a request to the workspaces virtual workspace, of the form
+// <rootPathPrefix><org>/<scope>[/<rest>], where rootPathPrefix ends with a slash.
+//
+// Paths outside of the root path prefix are not accepted. Paths under the prefix are always accepted,
+// such that a malformed path fails with a BadRequest error, and the path of an org for which orgExists
+// returns false fails with a NotFound error, instead of falling through to other handlers.
+//
+// The AllOrgs org only supports the personal scope, and lists the personal workspaces of the user in all
+// the orgs they can access.
+//
+// If defaultOrg is set, paths of the form <rootPathPrefix>personal[/<rest>], without an org, resolve to the
+// personal scope of the default org, provided isMember returns true for it. Otherwise they fail with a
+// Forbidden error, such that the default org never grants access to users outside of it.
+func resolveRootPath(urlPath, rootPathPrefix, defaultOrg string, orgExists, isMember func(org string) bool) (accepted bool, org, scope, prefixToStrip string, err error) {
+	if !strings.HasPrefix(urlPath, rootPathPrefix) {
+		return false, "", "", "", nil
+	}
+	accepted, prefixToStrip = true, rootPathPrefix
+
+	segments := strings.SplitN(strings.TrimPrefix(urlPath, rootPathPrefix), "/", 3)
+	if defaultOrg != "" && segments[0] == virtualworkspacesregistry.PersonalScope {
+		if !orgExists(defaultOrg) {
+			return accepted, "", "", prefixToStrip, kerrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), defaultOrg)
+		}
+		if !isMember(defaultOrg) {
+			return accepted, "", "", prefixToStrip, kerrors.NewForbidden(tenancyv1alpha1.Resource("clusterworkspaces"), defaultOrg, fmt.Errorf("not a member of the default org, use a path of the form %s<org>/%s", rootPathPrefix, virtualworkspacesregistry.PersonalScope))
+		}
+		return accepted, defaultOrg, virtualworkspacesregistry.PersonalScope, rootPathPrefix + virtualworkspacesregistry.PersonalScope, nil
+	}
+	if len(segments) < 2 || segments[0] == "" || segments[1] == "" {
+		return accepted, "", "", prefixToStrip, kerrors.NewBadRequest(fmt.Sprintf("expected a path of the form %s<org>/<scope>, got %q", rootPathPrefix, urlPath))
+	}
+	org, scope = segments[0], segments[1]
+
+	if org == virtualworkspacesregistry.AllOrgs {
+		if scope != virtualworkspacesregistry.PersonalScope {
+			return accepted, "", "", prefixToStrip, kerrors.NewBadRequest(fmt.Sprintf("scope %q is not supported across all orgs, only %q is", scope, virtualworkspacesregistry.PersonalScope))
+		}
+		return accepted, org, scope, rootPathPrefix + org + "/" + scope, nil
+	}
+	if _, err := helper.ClusterNameToPath(org); err != nil {
+		return accepted, "", "", prefixToStrip, kerrors.NewBadRequest(fmt.Sprintf("invalid org %q: %v", org, err))
+	}
+	if !virtualworkspacesregistry.ScopeSet.Has(scope) {
+		return accepted, "", "", prefixToStrip, kerrors.NewBadRequest(fmt.Sprintf("unknown scope %q, supported scopes are: %s", scope, strings.Join(virtualworkspacesregistry.ScopeSet.List(), ", ")))
+	}
+	// Do not allow the personal and shared scopes when accessing orgs as workspaces in the root logical cluster,
+	// since orgs are not owned by users.
+	if (scope == virtualworkspacesregistry.PersonalScope || scope == virtualworkspacesregistry.SharedScope) && org == helper.RootCluster {
+		return accepted, "", "", prefixToStrip, kerrors.NewBadRequest(fmt.Sprintf("scope %q is not supported for the %s org", scope, helper.RootCluster))
+	}
+	if !orgExists(org) {
+		return accepted, "", "", prefixToStrip, kerrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), org)
+	}
+
+	return accepted, org, scope, rootPathPrefix + org + "/" + scope, nil
+}
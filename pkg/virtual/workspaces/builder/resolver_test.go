@@ -0,0 +1,183 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestResolveRootPath(t *testing.T) {
+	existingOrgs := sets.NewString("root", "root:myorg", "root:otherorg")
+	orgExists := func(org string) bool { return existingOrgs.Has(org) }
+	memberOrgs := sets.NewString("root:myorg")
+	isMember := func(org string) bool { return memberOrgs.Has(org) }
+
+	tests := []struct {
+		name              string
+		path              string
+		wantAccepted      bool
+		wantOrg           string
+		wantScope         string
+		wantPrefixToStrip string
+		defaultOrg        string
+		wantBadRequest    bool
+		wantNotFound      bool
+		wantForbidden     bool
+	}{
+		{
+			name:              "personal scope of an org",
+			path:              "/services/workspaces/root:myorg/personal/apis/tenancy.kcp.dev/v1beta1/workspaces",
+			wantAccepted:      true,
+			wantOrg:           "root:myorg",
+			wantScope:         "personal",
+			wantPrefixToStrip: "/services/workspaces/root:myorg/personal",
+		},
+		{
+			name:              "all scope without a trailing path",
+			path:              "/services/workspaces/root/all",
+			wantAccepted:      true,
+			wantOrg:           "root",
+			wantScope:         "all",
+			wantPrefixToStrip: "/services/workspaces/root/all",
+		},
+		{
+			name:              "personal scope across all orgs",
+			path:              "/services/workspaces/all/personal/apis/tenancy.kcp.dev/v1beta1/workspaces",
+			wantAccepted:      true,
+			wantOrg:           "all",
+			wantScope:         "personal",
+			wantPrefixToStrip: "/services/workspaces/all/personal",
+		},
+		{
+			name:           "shared scope across all orgs",
+			path:           "/services/workspaces/all/shared",
+			wantAccepted:   true,
+			wantBadRequest: true,
+		},
+		{
+			name: "path outside of the prefix",
+			path: "/services/other/root:myorg/personal",
+		},
+		{
+			name:           "missing scope",
+			path:           "/services/workspaces/root:myorg",
+			wantAccepted:   true,
+			wantBadRequest: true,
+		},
+		{
+			name:           "empty org",
+			path:           "/services/workspaces//personal",
+			wantAccepted:   true,
+			wantBadRequest: true,
+		},
+		{
+			name:           "unknown scope",
+			path:           "/services/workspaces/root:myorg/everything",
+			wantAccepted:   true,
+			wantBadRequest: true,
+		},
+		{
+			name:           "invalid org name",
+			path:           "/services/workspaces/root:myorg:nested/personal",
+			wantAccepted:   true,
+			wantBadRequest: true,
+		},
+		{
+			name:           "personal scope of the root org",
+			path:           "/services/workspaces/root/personal",
+			wantAccepted:   true,
+			wantBadRequest: true,
+		},
+		{
+			name:         "unknown org",
+			path:         "/services/workspaces/root:unknown/personal",
+			wantAccepted: true,
+			wantNotFound: true,
+		},
+		{
+			name:           "personal scope without an org and without a default org",
+			path:           "/services/workspaces/personal/apis/tenancy.kcp.dev/v1beta1/workspaces",
+			wantAccepted:   true,
+			wantBadRequest: true,
+		},
+		{
+			name:              "personal scope without an org resolves to the default org",
+			path:              "/services/workspaces/personal/apis/tenancy.kcp.dev/v1beta1/workspaces",
+			defaultOrg:        "root:myorg",
+			wantAccepted:      true,
+			wantOrg:           "root:myorg",
+			wantScope:         "personal",
+			wantPrefixToStrip: "/services/workspaces/personal",
+		},
+		{
+			name:              "bare personal scope resolves to the default org",
+			path:              "/services/workspaces/personal",
+			defaultOrg:        "root:myorg",
+			wantAccepted:      true,
+			wantOrg:           "root:myorg",
+			wantScope:         "personal",
+			wantPrefixToStrip: "/services/workspaces/personal",
+		},
+		{
+			name:              "explicit org with a default org",
+			path:              "/services/workspaces/root/all",
+			defaultOrg:        "root:myorg",
+			wantAccepted:      true,
+			wantOrg:           "root",
+			wantScope:         "all",
+			wantPrefixToStrip: "/services/workspaces/root/all",
+		},
+		{
+			name:          "default org the user is not a member of",
+			path:          "/services/workspaces/personal",
+			defaultOrg:    "root:otherorg",
+			wantAccepted:  true,
+			wantForbidden: true,
+		},
+		{
+			name:         "unknown default org",
+			path:         "/services/workspaces/personal",
+			defaultOrg:   "root:unknown",
+			wantAccepted: true,
+			wantNotFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted, org, scope, prefixToStrip, err := resolveRootPath(tt.path, "/services/workspaces/", tt.defaultOrg, orgExists, isMember)
+			require.Equal(t, tt.wantAccepted, accepted)
+			switch {
+			case tt.wantBadRequest:
+				require.True(t, kerrors.IsBadRequest(err), "expected a BadRequest error, got %v", err)
+			case tt.wantNotFound:
+				require.True(t, kerrors.IsNotFound(err), "expected a NotFound error, got %v", err)
+			case tt.wantForbidden:
+				require.True(t, kerrors.IsForbidden(err), "expected a Forbidden error, got %v", err)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tt.wantOrg, org)
+				require.Equal(t, tt.wantScope, scope)
+				require.Equal(t, tt.wantPrefixToStrip, prefixToStrip)
+			}
+		})
+	}
+}
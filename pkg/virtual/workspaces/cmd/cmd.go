@@ -20,22 +20,32 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"k8s.io/apimachinery/pkg/util/validation"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/keyutil"
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/authentication/workspacetoken"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/virtual/framework"
 	virtualframeworkcmd "github.com/kcp-dev/kcp/pkg/virtual/framework/cmd"
 	rootapiserver "github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
+	tenancywrapper "github.com/kcp-dev/kcp/pkg/virtual/framework/wrappers/tenancy"
+	shardsbuilder "github.com/kcp-dev/kcp/pkg/virtual/shards/builder"
+	"github.com/kcp-dev/kcp/pkg/virtual/workspaces"
 	"github.com/kcp-dev/kcp/pkg/virtual/workspaces/builder"
+	"github.com/kcp-dev/kcp/pkg/virtual/workspaces/registry"
 )
 
 var _ virtualframeworkcmd.SubCommandOptions = (*WorkspacesSubCommandOptions)(nil)
@@ -43,14 +53,76 @@ var _ virtualframeworkcmd.SubCommandOptions = (*WorkspacesSubCommandOptions)(nil
 type WorkspacesSubCommandOptions struct {
 	RootPathPrefix string
 	KubeconfigFile string
+
+	// NameCollisionPolicy defines how personal workspaces are named when their name is already
+	// used by another workspace of the organization: reject, suffix or random.
+	NameCollisionPolicy string
+	// NameGenerator is the name in workspaces.NameGenerators of the generator of the names of workspaces
+	// created with metadata.generateName and of the suffixes chosen by the name collision policy. Empty keeps
+	// random names for metadata.generateName and the suffixes of the name collision policy.
+	NameGenerator string
+
+	// TokenSigningKeyFile is the file holding the PEM-encoded private key used to sign the
+	// workspace-scoped tokens embedded in kubeconfigs on request. Tokens cannot be requested if empty.
+	TokenSigningKeyFile string
+	// TokenTTL is the lifetime of the workspace-scoped tokens. Defaults to one hour if zero.
+	TokenTTL time.Duration
+
+	// CreateRateLimitQPS is the number of workspaces a user can create per second. Zero disables the limit.
+	CreateRateLimitQPS float64
+	// CreateRateLimitBurst is the number of workspaces a user can create at once.
+	CreateRateLimitBurst int
+	// CreateRateLimitGroupOverrides maps group names to <qps>:<burst> limits replacing the default
+	// ones for their members. Members of several groups get the most permissive limit.
+	CreateRateLimitGroupOverrides map[string]string
+
+	// CreatePolicyWebhook is the webhook asked whether a workspace can be created. Disabled if its URL is empty.
+	CreatePolicyWebhook registry.CreatePolicyWebhookConfig
+
+	// SoftDeleteRetention is the time deleted workspaces can be restored before being deleted for good.
+	// Zero deletes workspaces right away.
+	SoftDeleteRetention time.Duration
+
+	// ReservedNames cannot be used as names of workspaces, in addition to the names of their organizations.
+	ReservedNames []string
+
+	// WatchBookmarkInterval is the interval of the Bookmark events sent to workspace watches allowing them.
+	// Zero disables bookmarks.
+	WatchBookmarkInterval time.Duration
+
+	// GroupOrgs maps group names to the ;-separated names of the orgs their members see when listing
+	// workspaces across all orgs.
+	GroupOrgs map[string]string
+
+	// DefaultOrg is the name of the org requests to <root-path-prefix>/personal, without an org, are served
+	// from, for the members of that org. Such requests fail if empty.
+	DefaultOrg string
+
+	// ShardsAdminGroups are the groups whose members can list the WorkspaceShards and their load through the
+	// shards virtual workspace served at /admin/shards. The shards virtual workspace is not served if empty.
+	ShardsAdminGroups []string
 }
 
+const (
+	defaultTokenTTL = time.Hour
+
+	defaultWatchBookmarkInterval = time.Minute
+	// maxTokenTTL bounds the lifetime of workspace-scoped tokens.
+	maxTokenTTL = 24 * time.Hour
+
+	defaultCreateRateLimitBurst = 10
+
+	defaultCreatePolicyWebhookTimeout = 10 * time.Second
+	// maxCreatePolicyWebhookTimeout bounds the time a workspace creation waits for the policy webhook.
+	maxCreatePolicyWebhookTimeout = 30 * time.Second
+)
+
 func (o *WorkspacesSubCommandOptions) Description() virtualframeworkcmd.SubCommandDescription {
 	return virtualframeworkcmd.SubCommandDescription{
 		Name:  "workspaces",
 		Use:   "workspaces",
 		Short: "Launch workspaces virtual workspace apiserver",
-		Long:  "Start a virtual workspace apiserver to managing personal, organizational or global workspaces",
+		Long:  "Start a virtual workspace apiserver to managing personal, shared, organizational or global workspaces",
 	}
 }
 
@@ -66,7 +138,93 @@ func (o *WorkspacesSubCommandOptions) AddFlags(flags *pflag.FlagSet) {
 
 	flags.StringVar(&o.RootPathPrefix, "workspaces:root-path-prefix", builder.DefaultRootPathPrefix, ""+
 		"The prefix of the workspaces API server root path.\n"+
-		"The final workspaces API root path will be of the form:\n    <root-path-prefix>/<org-name>/personal|all")
+		"The final workspaces API root path will be of the form:\n    <root-path-prefix>/<org-name>/personal|shared|all")
+
+	flags.StringVar(&o.NameCollisionPolicy, "workspaces:name-collision-policy", string(registry.NameCollisionSuffix), ""+
+		"How a personal workspace is named when its name is already used by another workspace of the organization.\n"+
+		"One of: reject (fail with a conflict), suffix (append --1, --2, …), random (append a random --<suffix>).")
+
+	flags.StringVar(&o.NameGenerator, "workspaces:name-generator", "", ""+
+		"How the names of workspaces created with metadata.generateName, and the suffixes of the name collision policy, are generated.\n"+
+		"One of: random (a random 5 characters suffix), uuid (a random UUID), sequence (1, 2, …). If unset, names are random\n"+
+		"and suffixes follow the name collision policy.")
+
+	flags.StringVar(&o.TokenSigningKeyFile, "workspaces:token-signing-key-file", "", ""+
+		"File containing the PEM-encoded RSA or ECDSA private key used to sign the workspace-scoped tokens\n"+
+		"returned by the kubeconfig subresource with ?credentials=token. Tokens cannot be requested if unset.\n"+
+		"The kcp server must verify them with the matching public key passed to --authentication-workspace-token-key-file.")
+
+	flags.DurationVar(&o.TokenTTL, "workspaces:token-ttl", defaultTokenTTL, ""+
+		"The lifetime of the workspace-scoped tokens returned by the kubeconfig subresource. At most "+maxTokenTTL.String()+".")
+
+	flags.Float64Var(&o.CreateRateLimitQPS, "workspaces:create-rate-limit-qps", 0, ""+
+		"The number of workspaces a user can create per second. Creations beyond it fail with 429 Too Many Requests.\n"+
+		"Zero disables the limit.")
+
+	flags.IntVar(&o.CreateRateLimitBurst, "workspaces:create-rate-limit-burst", defaultCreateRateLimitBurst, ""+
+		"The number of workspaces a user can create at once when --workspaces:create-rate-limit-qps is set.")
+
+	flags.StringToStringVar(&o.CreateRateLimitGroupOverrides, "workspaces:create-rate-limit-group-overrides", nil, ""+
+		"Workspace creation limits of the members of the given groups, as <group>=<qps>:<burst> pairs, e.g. ci-bots=0.1:2.\n"+
+		"Members of several groups get the most permissive limit. A qps of zero disables the limit for the group.")
+
+	flags.StringVar(&o.CreatePolicyWebhook.URL, "workspaces:create-policy-webhook-url", "", ""+
+		"The https URL of a webhook asked whether a personal workspace can be created. It receives an admission.k8s.io/v1\n"+
+		"AdmissionReview with the pending Workspace and the requesting user, and its denials fail the creation with\n"+
+		"403 Forbidden and the message of the webhook. Creations fail if the webhook cannot be reached. Disabled if unset.")
+
+	flags.StringVar(&o.CreatePolicyWebhook.TLS.CAFile, "workspaces:create-policy-webhook-ca-file", "", ""+
+		"File containing the PEM-encoded CA bundle verifying the serving certificate of the creation policy webhook.\n"+
+		"The system roots are used if unset.")
+
+	flags.StringVar(&o.CreatePolicyWebhook.TLS.CertFile, "workspaces:create-policy-webhook-client-cert-file", "", ""+
+		"File containing the PEM-encoded client certificate presented to the creation policy webhook.")
+
+	flags.StringVar(&o.CreatePolicyWebhook.TLS.KeyFile, "workspaces:create-policy-webhook-client-key-file", "", ""+
+		"File containing the PEM-encoded private key of --workspaces:create-policy-webhook-client-cert-file.")
+
+	flags.DurationVar(&o.CreatePolicyWebhook.Timeout, "workspaces:create-policy-webhook-timeout", defaultCreatePolicyWebhookTimeout, ""+
+		"The time a workspace creation waits for the creation policy webhook. At most "+maxCreatePolicyWebhookTimeout.String()+".")
+
+	flags.DurationVar(&o.SoftDeleteRetention, "workspaces:soft-delete-retention", 0, ""+
+		"The time deleted workspaces are hidden but kept, and can be restored through the restore subresource,\n"+
+		"before being deleted for good. Deleting a soft-deleted workspace deletes it right away. Zero disables soft-deletion.")
+
+	flags.StringSliceVar(&o.ReservedNames, "workspaces:reserved-names", registry.DefaultReservedNames, ""+
+		"The names which cannot be used for workspaces, in addition to the name of their organization.")
+
+	flags.DurationVar(&o.WatchBookmarkInterval, "workspaces:watch-bookmark-interval", defaultWatchBookmarkInterval, ""+
+		"The interval of the bookmark events carrying the latest resource version sent to the workspace watches\n"+
+		"allowing them, such that clients can resume watches without a full relist. Zero disables bookmarks.")
+
+	flags.StringToStringVar(&o.GroupOrgs, "workspaces:group-orgs", nil, ""+
+		"The orgs listed across all orgs for the members of the given groups, as <group>=<org>[;<org>...] pairs,\n"+
+		"e.g. team-1=acme;beta. Members of several groups see the orgs of all of them. Users in none of the groups\n"+
+		"see all the orgs they can get. The mapping never grants access to an org.")
+
+	flags.StringVar(&o.DefaultOrg, "workspaces:default-org", "", ""+
+		"The name of the org serving the personal workspaces of its members at <root-path-prefix>/personal, without\n"+
+		"an org in the path. Users who are not members of the org are forbidden. Disabled if unset.")
+
+	flags.StringSliceVar(&o.ShardsAdminGroups, "workspaces:shards-admin-groups", []string{kuser.SystemPrivilegedGroup}, ""+
+		"The groups whose members can list the workspace shards with their capacity, number of workspaces, reachability,\n"+
+		"and draining and cordon state at "+shardsbuilder.DefaultRootPathPrefix+". Other users are forbidden. The shards virtual\n"+
+		"workspace is not served if empty.")
+}
+
+// Complete normalizes the root path prefix and defaults the token lifetime. Invalid
+// prefixes are left untouched and reported by Validate.
+func (o *WorkspacesSubCommandOptions) Complete() error {
+	if o == nil {
+		return nil
+	}
+	if prefix, err := normalizeRootPathPrefix(o.RootPathPrefix); err == nil {
+		o.RootPathPrefix = prefix
+	}
+	if o.TokenTTL == 0 {
+		o.TokenTTL = defaultTokenTTL
+	}
+	return nil
 }
 
 func (o *WorkspacesSubCommandOptions) Validate() []error {
@@ -79,13 +237,133 @@ func (o *WorkspacesSubCommandOptions) Validate() []error {
 		errs = append(errs, errors.New("--workspaces:kubeconfig is required for this command"))
 	}
 
-	if !strings.HasPrefix(o.RootPathPrefix, "/") {
-		errs = append(errs, fmt.Errorf("--workspaces:root-path-prefix %v should start with /", o.RootPathPrefix))
+	if _, err := normalizeRootPathPrefix(o.RootPathPrefix); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.NameCollisionPolicy != "" && !registry.NameCollisionPolicies.Has(o.NameCollisionPolicy) {
+		errs = append(errs, fmt.Errorf("--workspaces:name-collision-policy must be one of %s, got %q", strings.Join(registry.NameCollisionPolicies.List(), ", "), o.NameCollisionPolicy))
+	}
+	if _, found := workspaces.NameGenerators[o.NameGenerator]; o.NameGenerator != "" && !found {
+		names := make([]string, 0, len(workspaces.NameGenerators))
+		for name := range workspaces.NameGenerators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		errs = append(errs, fmt.Errorf("--workspaces:name-generator must be one of %s, got %q", strings.Join(names, ", "), o.NameGenerator))
+	}
+
+	if o.TokenTTL < 0 || o.TokenTTL > maxTokenTTL {
+		errs = append(errs, fmt.Errorf("--workspaces:token-ttl must be positive and at most %s, got %s", maxTokenTTL, o.TokenTTL))
+	}
+
+	if o.CreateRateLimitQPS < 0 {
+		errs = append(errs, fmt.Errorf("--workspaces:create-rate-limit-qps must not be negative, got %g", o.CreateRateLimitQPS))
+	}
+	if o.CreateRateLimitQPS > 0 && o.CreateRateLimitBurst < 1 {
+		errs = append(errs, fmt.Errorf("--workspaces:create-rate-limit-burst must be at least 1, got %d", o.CreateRateLimitBurst))
+	}
+	if _, err := parseGroupRateLimits(o.CreateRateLimitGroupOverrides); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.CreatePolicyWebhook.URL != "" {
+		if u, err := url.Parse(o.CreatePolicyWebhook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("--workspaces:create-policy-webhook-url must be an https URL with a host, got %q", o.CreatePolicyWebhook.URL))
+		}
+		if o.CreatePolicyWebhook.Timeout <= 0 || o.CreatePolicyWebhook.Timeout > maxCreatePolicyWebhookTimeout {
+			errs = append(errs, fmt.Errorf("--workspaces:create-policy-webhook-timeout must be positive and at most %s, got %s", maxCreatePolicyWebhookTimeout, o.CreatePolicyWebhook.Timeout))
+		}
+		if (o.CreatePolicyWebhook.TLS.CertFile == "") != (o.CreatePolicyWebhook.TLS.KeyFile == "") {
+			errs = append(errs, errors.New("--workspaces:create-policy-webhook-client-cert-file and --workspaces:create-policy-webhook-client-key-file must be set together"))
+		}
+	}
+
+	if _, err := parseGroupOrgs(o.GroupOrgs); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.DefaultOrg != "" {
+		if msgs := validation.IsDNS1123Subdomain(o.DefaultOrg); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("--workspaces:default-org %q must be the name of an org: %s", o.DefaultOrg, strings.Join(msgs, ", ")))
+		}
+	}
+
+	if o.SoftDeleteRetention < 0 {
+		errs = append(errs, fmt.Errorf("--workspaces:soft-delete-retention must not be negative, got %s", o.SoftDeleteRetention))
+	}
+
+	for _, name := range o.ReservedNames {
+		if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("--workspaces:reserved-names must only contain workspace names, got %q: %s", name, strings.Join(msgs, ", ")))
+		}
+	}
+
+	if o.WatchBookmarkInterval < 0 {
+		errs = append(errs, fmt.Errorf("--workspaces:watch-bookmark-interval must not be negative, got %s", o.WatchBookmarkInterval))
 	}
 
 	return errs
 }
 
+// parseGroupRateLimits parses the <qps>:<burst> limits of the groups.
+func parseGroupRateLimits(overrides map[string]string) (map[string]registry.RateLimit, error) {
+	limits := make(map[string]registry.RateLimit, len(overrides))
+	for group, value := range overrides {
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("--workspaces:create-rate-limit-group-overrides value %q of group %q must be of the form <qps>:<burst>", value, group)
+		}
+		limit := registry.RateLimit{}
+		var err error
+		if limit.QPS, err = strconv.ParseFloat(parts[0], 64); err != nil || limit.QPS < 0 {
+			return nil, fmt.Errorf("--workspaces:create-rate-limit-group-overrides qps %q of group %q must be a non-negative number", parts[0], group)
+		}
+		if limit.Burst, err = strconv.Atoi(parts[1]); err != nil || (limit.QPS > 0 && limit.Burst < 1) {
+			return nil, fmt.Errorf("--workspaces:create-rate-limit-group-overrides burst %q of group %q must be a positive integer", parts[1], group)
+		}
+		limits[group] = limit
+	}
+	return limits, nil
+}
+
+// parseGroupOrgs parses the ;-separated org names of the groups.
+func parseGroupOrgs(groupOrgs map[string]string) (map[string][]string, error) {
+	orgs := make(map[string][]string, len(groupOrgs))
+	for group, value := range groupOrgs {
+		for _, org := range strings.Split(value, ";") {
+			org = strings.TrimSpace(org)
+			if msgs := validation.IsDNS1123Subdomain(org); len(msgs) > 0 {
+				return nil, fmt.Errorf("--workspaces:group-orgs org %q of group %q must be the name of an org: %s", org, group, strings.Join(msgs, ", "))
+			}
+			orgs[group] = append(orgs[group], org)
+		}
+	}
+	return orgs, nil
+}
+
+// normalizeRootPathPrefix ensures the prefix starts with a slash, and strips
+// trailing and duplicate slashes. The root path "/" is kept as is.
+func normalizeRootPathPrefix(prefix string) (string, error) {
+	if strings.TrimSpace(prefix) == "" {
+		return "", errors.New("--workspaces:root-path-prefix must not be empty, use / to serve workspaces at the root path")
+	}
+	if strings.ContainsAny(prefix, "?# \t\n") {
+		return "", fmt.Errorf("--workspaces:root-path-prefix %q must be a URL path without query, fragment or whitespace", prefix)
+	}
+	var segments []string
+	for _, segment := range strings.Split(prefix, "/") {
+		switch segment {
+		case "":
+			continue
+		case ".", "..":
+			return "", fmt.Errorf("--workspaces:root-path-prefix %q must not contain relative path segments", prefix)
+		}
+		segments = append(segments, segment)
+	}
+	return "/" + strings.Join(segments, "/"), nil
+}
+
 func (o *WorkspacesSubCommandOptions) PrepareVirtualWorkspaces() ([]rootapiserver.InformerStart, []framework.VirtualWorkspace, error) {
 	kubeConfig, err := virtualframeworkcmd.ReadKubeConfig(o.KubeconfigFile)
 	if err != nil {
@@ -119,9 +397,59 @@ func (o *WorkspacesSubCommandOptions) PrepareVirtualWorkspaces() ([]rootapiserve
 	wildcardKcpInformers := kcpinformer.NewSharedInformerFactory(wildcardKcpClient, 10*time.Minute)
 	rootKcpClient := kcpClusterClient.Cluster(helper.RootCluster)
 
-	virtualWorkspaces := []framework.VirtualWorkspace{
-		builder.BuildVirtualWorkspace(o.RootPathPrefix, wildcardKcpInformers.Tenancy().V1alpha1().ClusterWorkspaces(), wildcardKubeInformers.Rbac().V1(), rootKcpClient, rootKubeClient, kcpClusterClient, kubeClusterClient),
+	var tokenGenerator *workspacetoken.Generator
+	if o.TokenSigningKeyFile != "" {
+		// the key is only read, the tokens signed with it are never written anywhere
+		key, err := keyutil.PrivateKeyFromFile(o.TokenSigningKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read --workspaces:token-signing-key-file: %w", err)
+		}
+		if tokenGenerator, err = workspacetoken.NewGenerator(key); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	groupRateLimits, err := parseGroupRateLimits(o.CreateRateLimitGroupOverrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	var createRateLimiter *registry.CreateRateLimiter
+	if o.CreateRateLimitQPS > 0 || len(groupRateLimits) > 0 {
+		createRateLimiter = registry.NewCreateRateLimiter(registry.RateLimit{QPS: o.CreateRateLimitQPS, Burst: o.CreateRateLimitBurst}, groupRateLimits)
+	}
+
+	groupOrgs, err := parseGroupOrgs(o.GroupOrgs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var createPolicyWebhook *registry.CreatePolicyWebhook
+	if o.CreatePolicyWebhook.URL != "" {
+		if createPolicyWebhook, err = registry.NewCreatePolicyWebhook(o.CreatePolicyWebhook); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var virtualWorkspaces []framework.VirtualWorkspace
+	if len(o.ShardsAdminGroups) > 0 {
+		// served first, so that its prefix is never taken for an org of the workspaces virtual workspace
+		rootWorkspaceShards := tenancywrapper.FilterWorkspaceShardInformer(helper.RootCluster, wildcardKcpInformers.Tenancy().V1alpha1().WorkspaceShards())
+		virtualWorkspaces = append(virtualWorkspaces, shardsbuilder.BuildVirtualWorkspace(shardsbuilder.DefaultRootPathPrefix, o.ShardsAdminGroups, rootWorkspaceShards))
 	}
+	virtualWorkspaces = append(virtualWorkspaces,
+		builder.BuildVirtualWorkspace(o.RootPathPrefix, o.DefaultOrg, wildcardKcpInformers.Tenancy().V1alpha1().ClusterWorkspaces(), wildcardKubeInformers.Rbac().V1(), rootKcpClient, rootKubeClient, kcpClusterClient, kubeClusterClient, registry.Options{
+			NameCollisionPolicy:   registry.NameCollisionPolicy(o.NameCollisionPolicy),
+			NameGenerator:         workspaces.NameGenerators[o.NameGenerator],
+			TokenGenerator:        tokenGenerator,
+			TokenTTL:              o.TokenTTL,
+			CreateRateLimiter:     createRateLimiter,
+			CreatePolicyWebhook:   createPolicyWebhook,
+			SoftDeleteRetention:   o.SoftDeleteRetention,
+			GroupOrgs:             groupOrgs,
+			ReservedNames:         o.ReservedNames,
+			WatchBookmarkInterval: o.WatchBookmarkInterval,
+		}),
+	)
 	informerStarts := []rootapiserver.InformerStart{
 		wildcardKubeInformers.Start,
 		wildcardKcpInformers.Start,
@@ -0,0 +1,367 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/kcp-dev/kcp/pkg/virtual/workspaces/registry"
+)
+
+func TestRootPathPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		prefix      string
+		expected    string
+		expectedErr string
+	}{
+		{prefix: "/services/workspaces", expected: "/services/workspaces"},
+		{prefix: "/", expected: "/"},
+		{prefix: "services/workspaces", expected: "/services/workspaces"},
+		{prefix: "/services/workspaces/", expected: "/services/workspaces"},
+		{prefix: "//services///workspaces//", expected: "/services/workspaces"},
+		{prefix: "///", expected: "/"},
+		{prefix: "", expectedErr: `--workspaces:root-path-prefix must not be empty, use / to serve workspaces at the root path`},
+		{prefix: "  ", expectedErr: `--workspaces:root-path-prefix must not be empty, use / to serve workspaces at the root path`},
+		{prefix: "/services/../workspaces", expectedErr: `--workspaces:root-path-prefix "/services/../workspaces" must not contain relative path segments`},
+		{prefix: "/services/workspaces?foo=bar", expectedErr: `--workspaces:root-path-prefix "/services/workspaces?foo=bar" must be a URL path without query, fragment or whitespace`},
+		{prefix: "/services/work spaces", expectedErr: `--workspaces:root-path-prefix "/services/work spaces" must be a URL path without query, fragment or whitespace`},
+	} {
+		t.Run(tc.prefix, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix: tc.prefix,
+				KubeconfigFile: "kubeconfig",
+			}
+			require.NoError(t, o.Complete())
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.EqualError(t, errs[0], tc.expectedErr)
+				require.Equal(t, tc.prefix, o.RootPathPrefix, "invalid prefixes should be left untouched")
+				return
+			}
+			require.Empty(t, errs)
+			require.Equal(t, tc.expected, o.RootPathPrefix)
+		})
+	}
+}
+
+func TestNameCollisionPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy      string
+		expectedErr string
+	}{
+		{policy: ""},
+		{policy: "reject"},
+		{policy: "suffix"},
+		{policy: "random"},
+		{policy: "rename", expectedErr: `--workspaces:name-collision-policy must be one of random, reject, suffix, got "rename"`},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix:      "/",
+				KubeconfigFile:      "kubeconfig",
+				NameCollisionPolicy: tc.policy,
+			}
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.EqualError(t, errs[0], tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+		})
+	}
+}
+
+func TestNameGenerator(t *testing.T) {
+	for _, tc := range []struct {
+		generator   string
+		expectedErr string
+	}{
+		{generator: ""},
+		{generator: "random"},
+		{generator: "uuid"},
+		{generator: "sequence"},
+		{generator: "slug", expectedErr: `--workspaces:name-generator must be one of random, sequence, uuid, got "slug"`},
+	} {
+		t.Run(tc.generator, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix: "/",
+				KubeconfigFile: "kubeconfig",
+				NameGenerator:  tc.generator,
+			}
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.EqualError(t, errs[0], tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+		})
+	}
+}
+
+func TestTokenTTL(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		ttl         time.Duration
+		expected    time.Duration
+		expectedErr string
+	}{
+		{name: "default", expected: time.Hour},
+		{name: "bounded", ttl: 10 * time.Minute, expected: 10 * time.Minute},
+		{name: "maximum", ttl: 24 * time.Hour, expected: 24 * time.Hour},
+		{name: "too long", ttl: 25 * time.Hour, expectedErr: `--workspaces:token-ttl must be positive and at most 24h0m0s, got 25h0m0s`},
+		{name: "negative", ttl: -time.Minute, expectedErr: `--workspaces:token-ttl must be positive and at most 24h0m0s, got -1m0s`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix: "/",
+				KubeconfigFile: "kubeconfig",
+				TokenTTL:       tc.ttl,
+			}
+			require.NoError(t, o.Complete())
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.EqualError(t, errs[0], tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+			require.Equal(t, tc.expected, o.TokenTTL)
+		})
+	}
+}
+
+func TestCreateRateLimit(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		qps         float64
+		burst       int
+		overrides   map[string]string
+		expectedErr string
+	}{
+		{name: "disabled"},
+		{name: "enabled", qps: 1, burst: 10},
+		{name: "overrides", qps: 1, burst: 10, overrides: map[string]string{"ci": "0.1:2", "system:masters": "0:0"}},
+		{name: "negative qps", qps: -1, burst: 10, expectedErr: `--workspaces:create-rate-limit-qps must not be negative, got -1`},
+		{name: "no burst", qps: 1, expectedErr: `--workspaces:create-rate-limit-burst must be at least 1, got 0`},
+		{name: "override without burst", overrides: map[string]string{"ci": "1"}, expectedErr: `--workspaces:create-rate-limit-group-overrides value "1" of group "ci" must be of the form <qps>:<burst>`},
+		{name: "override with invalid qps", overrides: map[string]string{"ci": "fast:1"}, expectedErr: `--workspaces:create-rate-limit-group-overrides qps "fast" of group "ci" must be a non-negative number`},
+		{name: "override with zero burst", overrides: map[string]string{"ci": "1:0"}, expectedErr: `--workspaces:create-rate-limit-group-overrides burst "0" of group "ci" must be a positive integer`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix:                "/",
+				KubeconfigFile:                "kubeconfig",
+				CreateRateLimitQPS:            tc.qps,
+				CreateRateLimitBurst:          tc.burst,
+				CreateRateLimitGroupOverrides: tc.overrides,
+			}
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.EqualError(t, errs[0], tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+		})
+	}
+}
+
+func TestCreatePolicyWebhook(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		webhook     registry.CreatePolicyWebhookConfig
+		expectedErr string
+	}{
+		{name: "disabled"},
+		{name: "valid", webhook: registry.CreatePolicyWebhookConfig{URL: "https://policy.example.com/workspaces", Timeout: 10 * time.Second}},
+		{name: "client certificate", webhook: registry.CreatePolicyWebhookConfig{URL: "https://policy.example.com", Timeout: time.Second, TLS: rest.TLSClientConfig{CertFile: "tls.crt", KeyFile: "tls.key"}}},
+		{name: "http", webhook: registry.CreatePolicyWebhookConfig{URL: "http://policy.example.com", Timeout: time.Second}, expectedErr: `--workspaces:create-policy-webhook-url must be an https URL with a host, got "http://policy.example.com"`},
+		{name: "no host", webhook: registry.CreatePolicyWebhookConfig{URL: "https:///workspaces", Timeout: time.Second}, expectedErr: `--workspaces:create-policy-webhook-url must be an https URL with a host, got "https:///workspaces"`},
+		{name: "no timeout", webhook: registry.CreatePolicyWebhookConfig{URL: "https://policy.example.com"}, expectedErr: `--workspaces:create-policy-webhook-timeout must be positive and at most 30s, got 0s`},
+		{name: "too long", webhook: registry.CreatePolicyWebhookConfig{URL: "https://policy.example.com", Timeout: time.Minute}, expectedErr: `--workspaces:create-policy-webhook-timeout must be positive and at most 30s, got 1m0s`},
+		{name: "key without certificate", webhook: registry.CreatePolicyWebhookConfig{URL: "https://policy.example.com", Timeout: time.Second, TLS: rest.TLSClientConfig{KeyFile: "tls.key"}}, expectedErr: `--workspaces:create-policy-webhook-client-cert-file and --workspaces:create-policy-webhook-client-key-file must be set together`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix:      "/",
+				KubeconfigFile:      "kubeconfig",
+				CreatePolicyWebhook: tc.webhook,
+			}
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.EqualError(t, errs[0], tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+		})
+	}
+}
+
+func TestSoftDeleteRetention(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		retention   time.Duration
+		expectedErr string
+	}{
+		{name: "disabled"},
+		{name: "enabled", retention: 72 * time.Hour},
+		{name: "negative", retention: -time.Hour, expectedErr: `--workspaces:soft-delete-retention must not be negative, got -1h0m0s`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix:      "/",
+				KubeconfigFile:      "kubeconfig",
+				SoftDeleteRetention: tc.retention,
+			}
+			require.NoError(t, o.Complete())
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.EqualError(t, errs[0], tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+		})
+	}
+}
+
+func TestWatchBookmarkInterval(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		interval    time.Duration
+		expectedErr string
+	}{
+		{name: "disabled"},
+		{name: "enabled", interval: 30 * time.Second},
+		{name: "negative", interval: -time.Minute, expectedErr: `--workspaces:watch-bookmark-interval must not be negative, got -1m0s`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix:        "/",
+				KubeconfigFile:        "kubeconfig",
+				WatchBookmarkInterval: tc.interval,
+			}
+			require.NoError(t, o.Complete())
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.EqualError(t, errs[0], tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+		})
+	}
+}
+
+func TestGroupOrgs(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		groupOrgs   map[string]string
+		expected    map[string][]string
+		expectedErr string
+	}{
+		{name: "none", expected: map[string][]string{}},
+		{name: "one org", groupOrgs: map[string]string{"team-1": "acme"}, expected: map[string][]string{"team-1": {"acme"}}},
+		{name: "several orgs", groupOrgs: map[string]string{"team-1": "acme; beta", "team-2": "beta"}, expected: map[string][]string{"team-1": {"acme", "beta"}, "team-2": {"beta"}}},
+		{name: "empty org", groupOrgs: map[string]string{"team-1": "acme;"}, expectedErr: `--workspaces:group-orgs org "" of group "team-1" must be the name of an org`},
+		{name: "logical cluster name", groupOrgs: map[string]string{"team-1": "root:acme"}, expectedErr: `--workspaces:group-orgs org "root:acme" of group "team-1" must be the name of an org`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix: "/",
+				KubeconfigFile: "kubeconfig",
+				GroupOrgs:      tc.groupOrgs,
+			}
+			require.NoError(t, o.Complete())
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.Contains(t, errs[0].Error(), tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+			groupOrgs, err := parseGroupOrgs(tc.groupOrgs)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, groupOrgs)
+		})
+	}
+}
+
+func TestDefaultOrg(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		defaultOrg  string
+		expectedErr string
+	}{
+		{name: "none"},
+		{name: "org", defaultOrg: "acme"},
+		{name: "logical cluster name", defaultOrg: "root:acme", expectedErr: `--workspaces:default-org "root:acme" must be the name of an org`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix: "/",
+				KubeconfigFile: "kubeconfig",
+				DefaultOrg:     tc.defaultOrg,
+			}
+			require.NoError(t, o.Complete())
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.Contains(t, errs[0].Error(), tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+		})
+	}
+}
+
+func TestReservedNames(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		reservedNames []string
+		expectedErr   string
+	}{
+		{name: "none"},
+		{name: "defaults", reservedNames: registry.DefaultReservedNames},
+		{name: "invalid name", reservedNames: []string{"admin", "Root:org"}, expectedErr: `--workspaces:reserved-names must only contain workspace names, got "Root:org"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &WorkspacesSubCommandOptions{
+				RootPathPrefix: "/",
+				KubeconfigFile: "kubeconfig",
+				ReservedNames:  tc.reservedNames,
+			}
+			require.NoError(t, o.Complete())
+			errs := o.Validate()
+			if tc.expectedErr != "" {
+				require.Len(t, errs, 1)
+				require.Contains(t, errs[0].Error(), tc.expectedErr)
+				return
+			}
+			require.Empty(t, errs)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informers
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+// ConfigFor returns a copy of the config of the workspaces virtual workspace server, pointing to the workspaces
+// of the given scope, e.g. personal, in the given organization. The root path prefix is the one the virtual
+// workspace is served under, builder.DefaultRootPathPrefix by default.
+func ConfigFor(config *rest.Config, rootPathPrefix, orgClusterName, scope string) *rest.Config {
+	scoped := rest.CopyConfig(config)
+	scoped.Host = strings.TrimSuffix(config.Host, "/") + path.Join("/", rootPathPrefix, orgClusterName, scope)
+	return scoped
+}
+
+// NewSharedInformerFactory returns a shared informer factory listing and watching the workspaces virtual workspace
+// the given config points to, e.g. one returned by ConfigFor. Only the Workspace informers of
+// Tenancy().V1beta1() are served by the virtual workspace, and they see the workspaces of the user of the config.
+func NewSharedInformerFactory(config *rest.Config, resync time.Duration, options ...kcpinformers.SharedInformerOption) (kcpinformers.SharedInformerFactory, error) {
+	client, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return kcpinformers.NewSharedInformerFactoryWithOptions(client, resync, options...), nil
+}
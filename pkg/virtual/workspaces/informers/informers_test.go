@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+func TestConfigFor(t *testing.T) {
+	config := ConfigFor(&rest.Config{Host: "https://kcp.example.com:6444/"}, "/services/workspaces", "root:myorg", "personal")
+	require.Equal(t, "https://kcp.example.com:6444/services/workspaces/root:myorg/personal", config.Host)
+}
+
+func TestSharedInformerFactoryObservesCreatedWorkspace(t *testing.T) {
+	stopWatches := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/services/workspaces/root:myorg/personal/apis/tenancy.kcp.dev/v1beta1/workspaces" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") != "true" {
+			fmt.Fprint(w, `{"kind":"WorkspaceList","apiVersion":"tenancy.kcp.dev/v1beta1","metadata":{"resourceVersion":"1"},"items":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"type":"ADDED","object":{"kind":"Workspace","apiVersion":"tenancy.kcp.dev/v1beta1","metadata":{"name":"foo","resourceVersion":"2"},"spec":{"type":"Universal"}}}`+"\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-stopWatches:
+		}
+	}))
+	defer server.Close()
+	defer close(stopWatches) // the server waits for the watches to end when closed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	factory, err := NewSharedInformerFactory(ConfigFor(&rest.Config{Host: server.URL}, "/services/workspaces", "root:myorg", "personal"), 0)
+	require.NoError(t, err)
+	created := make(chan *tenancyv1beta1.Workspace, 1)
+	informer := factory.Tenancy().V1beta1().Workspaces()
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { created <- obj.(*tenancyv1beta1.Workspace) },
+	})
+	factory.Start(ctx.Done())
+
+	select {
+	case workspace := <-created:
+		require.Equal(t, "foo", workspace.Name)
+		require.Equal(t, "Universal", workspace.Spec.Type)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("did not observe the creation of the workspace")
+	}
+	workspace, err := informer.Lister().Get("foo")
+	require.NoError(t, err)
+	require.Equal(t, "2", workspace.ResourceVersion)
+}
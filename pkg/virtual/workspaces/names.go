@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workspaces holds what the workspaces virtual workspace shares with its extensions.
+package workspaces
+
+import (
+	"strconv"
+
+	"github.com/google/uuid"
+
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+// NameGenerator generates the names of personal workspaces created with metadata.generateName, and the internal
+// names of personal workspaces whose name is already used by another workspace of the organization.
+//
+// Generated names must stay valid DNS-1035 labels when appended to a valid base, i.e. suffixes only contain
+// lower case alphanumeric characters or '-', and end with an alphanumeric character.
+type NameGenerator interface {
+	// GenerateName returns the name to try at the given attempt, starting at 1, made of the base and a suffix.
+	// Callers try increasing attempts until the name is not used yet.
+	GenerateName(base string, attempt int) string
+	// MaxSuffixLength returns the length of the longest suffix appended to a base within the given number of attempts.
+	MaxSuffixLength(attempts int) int
+}
+
+const (
+	// RandomNameGeneratorName is the name of the RandomNameGenerator in NameGenerators.
+	RandomNameGeneratorName = "random"
+	// UUIDNameGeneratorName is the name of the UUIDNameGenerator in NameGenerators.
+	UUIDNameGeneratorName = "uuid"
+	// SequenceNameGeneratorName is the name of the SequenceNameGenerator in NameGenerators.
+	SequenceNameGeneratorName = "sequence"
+)
+
+// NameGenerators are the name generators which can be selected by name. Custom implementations can be added
+// before the options of the virtual workspace are validated.
+var NameGenerators = map[string]NameGenerator{
+	RandomNameGeneratorName:   RandomNameGenerator{},
+	UUIDNameGeneratorName:     UUIDNameGenerator{},
+	SequenceNameGeneratorName: SequenceNameGenerator{},
+}
+
+// randomSuffixLength is the length of the suffix of the RandomNameGenerator.
+const randomSuffixLength = 5
+
+// RandomNameGenerator appends a random suffix of 5 characters to the base, like metadata.generateName does.
+type RandomNameGenerator struct{}
+
+func (RandomNameGenerator) GenerateName(base string, attempt int) string {
+	return base + utilrand.String(randomSuffixLength)
+}
+
+func (RandomNameGenerator) MaxSuffixLength(attempts int) int {
+	return randomSuffixLength
+}
+
+// UUIDNameGenerator appends a random UUID to the base.
+type UUIDNameGenerator struct{}
+
+func (UUIDNameGenerator) GenerateName(base string, attempt int) string {
+	return base + uuid.New().String()
+}
+
+func (UUIDNameGenerator) MaxSuffixLength(attempts int) int {
+	return len(uuid.Nil.String())
+}
+
+// SequenceNameGenerator appends the attempt to the base, e.g. 1, then 2, and so on.
+type SequenceNameGenerator struct{}
+
+func (SequenceNameGenerator) GenerateName(base string, attempt int) string {
+	return base + strconv.Itoa(attempt)
+}
+
+func (SequenceNameGenerator) MaxSuffixLength(attempts int) int {
+	return len(strconv.Itoa(attempts))
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaces
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestNameGenerators(t *testing.T) {
+	const attempts = 100
+	for name, generator := range map[string]NameGenerator{
+		RandomNameGeneratorName:   RandomNameGenerator{},
+		UUIDNameGeneratorName:     UUIDNameGenerator{},
+		SequenceNameGeneratorName: SequenceNameGenerator{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, generator, NameGenerators[name], "expected the generator to be selectable by name")
+
+			for _, base := range []string{"ws-", "workspace1--"} {
+				generated := sets.NewString()
+				for attempt := 1; attempt <= attempts; attempt++ {
+					generatedName := generator.GenerateName(base, attempt)
+					require.True(t, strings.HasPrefix(generatedName, base), "expected %q to start with %q", generatedName, base)
+					require.LessOrEqual(t, len(generatedName)-len(base), generator.MaxSuffixLength(attempts), "the suffix of %q is too long", generatedName)
+					require.Empty(t, validation.IsDNS1035Label(generatedName), "expected %q to be a DNS-1035 label", generatedName)
+					require.False(t, generated.Has(generatedName), "expected %q to be generated only once", generatedName)
+					generated.Insert(generatedName)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceNameGenerator(t *testing.T) {
+	generator := SequenceNameGenerator{}
+	require.Equal(t, "ws-1", generator.GenerateName("ws-", 1))
+	require.Equal(t, "ws-10", generator.GenerateName("ws-", 10))
+	require.Equal(t, 1, generator.MaxSuffixLength(9))
+	require.Equal(t, 2, generator.MaxSuffixLength(10))
+}
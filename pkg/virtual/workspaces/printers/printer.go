@@ -18,9 +18,11 @@ package printers
 
 import (
 	"sort"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
 	kprinters "k8s.io/kubernetes/pkg/printers"
 
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
@@ -35,23 +37,47 @@ func AddWorkspacePrintHandlers(h kprinters.PrintHandler) {
 			Description: metav1.ObjectMeta{}.SwaggerDoc()["name"],
 			Priority:    0,
 		},
+		{
+			Name:        "Display Name",
+			Type:        "string",
+			Description: "Human readable name of the workspace",
+			Priority:    0,
+		},
 		{
 			Name:        "Type",
 			Type:        "string",
 			Description: "Workspace type",
-			Priority:    1,
+			Priority:    0,
 		},
 		{
 			Name:        "Phase",
 			Type:        "string",
 			Description: "Workspace phase",
-			Priority:    1,
+			Priority:    0,
 		},
 		{
 			Name:        "URL",
 			Type:        "string",
 			Description: "Workspace API Server URL",
-			Priority:    2,
+			Priority:    1,
+		},
+		{
+			Name:        "Shard",
+			Type:        "string",
+			Description: "Shard hosting the workspace",
+			Priority:    1,
+		},
+		{
+			Name:        "Description",
+			Type:        "string",
+			Description: "Description of the workspace",
+			Priority:    1,
+		},
+		{
+			Name:        "Age",
+			Type:        "string",
+			Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"],
+			Priority:    0,
 		},
 	}
 
@@ -68,7 +94,7 @@ func printWorkspace(workspace *tenancyv1beta1.Workspace, options kprinters.Gener
 		Object: runtime.RawExtension{Object: workspace},
 	}
 
-	row.Cells = append(row.Cells, workspace.Name, workspace.Spec.Type, workspace.Status.Phase, workspace.Status.URL)
+	row.Cells = append(row.Cells, workspace.Name, workspace.Spec.DisplayName, workspace.Spec.Type, workspace.Status.Phase, workspace.Status.URL, workspace.Status.Shard, workspace.Spec.Description, translateTimestampSince(workspace.CreationTimestamp))
 
 	return []metav1.TableRow{row}, nil
 }
@@ -86,6 +112,16 @@ func printWorkspaceList(list *tenancyv1beta1.WorkspaceList, options kprinters.Ge
 	return rows, nil
 }
 
+// translateTimestampSince returns the elapsed time since timestamp in
+// human-readable approximation.
+func translateTimestampSince(timestamp metav1.Time) string {
+	if timestamp.IsZero() {
+		return "<unknown>"
+	}
+
+	return duration.HumanDuration(time.Since(timestamp.Time))
+}
+
 // SortableWorkspaces is a list of workspaces that can be sorted
 type SortableWorkspaces []tenancyv1beta1.Workspace
 
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kprinters "k8s.io/kubernetes/pkg/printers"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+func TestPrintWorkspaceList(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	list := &tenancyv1beta1.WorkspaceList{
+		Items: []tenancyv1beta1.Workspace{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "zoo", CreationTimestamp: created},
+				Spec:       tenancyv1beta1.WorkspaceSpec{Type: "Universal", DisplayName: "The Zoo", Description: "Where the animals live."},
+				Status: tenancyv1beta1.WorkspaceStatus{
+					Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady,
+					URL:   "https://kcp.dev/clusters/org:zoo",
+					Shard: "boston",
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "bar"},
+				Spec:       tenancyv1beta1.WorkspaceSpec{Type: "Organization"},
+				Status: tenancyv1beta1.WorkspaceStatus{
+					Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				},
+			},
+		},
+	}
+
+	table, err := kprinters.NewTableGenerator().With(AddWorkspacePrintHandlers).GenerateTable(list, kprinters.GenerateOptions{})
+	require.NoError(t, err)
+
+	var columns []string
+	for _, column := range table.ColumnDefinitions {
+		columns = append(columns, column.Name)
+	}
+	require.Equal(t, []string{"Name", "Display Name", "Type", "Phase", "Age"}, columns, "the URL, Shard and Description columns should only be shown in wide output")
+
+	require.Len(t, table.Rows, 2)
+	require.Equal(t, []interface{}{"bar", "", "Organization", tenancyv1alpha1.ClusterWorkspacePhaseScheduling, "", "", "", "<unknown>"}, table.Rows[0].Cells)
+	require.Equal(t, []interface{}{"zoo", "The Zoo", "Universal", tenancyv1alpha1.ClusterWorkspacePhaseReady, "https://kcp.dev/clusters/org:zoo", "boston", "Where the animals live.", "120m"}, table.Rows[1].Cells)
+
+	table, err = kprinters.NewTableGenerator().With(AddWorkspacePrintHandlers).GenerateTable(list, kprinters.GenerateOptions{Wide: true})
+	require.NoError(t, err)
+	require.Len(t, table.ColumnDefinitions, 8, "the URL, Shard and Description columns should be shown in wide output")
+}
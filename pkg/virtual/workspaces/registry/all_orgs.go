@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/warning"
+
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// allOrgsListTimeout bounds the time spent listing the workspaces of a single org when listing across all orgs.
+var allOrgsListTimeout = 10 * time.Second
+
+// orgListResult is the outcome of listing the personal workspaces of one org.
+type orgListResult struct {
+	orgClusterName string
+	list           *tenancyv1beta1.WorkspaceList
+	err            error
+}
+
+// listAllOrgs lists the personal workspaces of the user in all the orgs the user can get in the root org, and
+// merges them into a single list, each workspace annotated with its org. If groups of the user are mapped to
+// orgs, only the orgs of these groups are listed.
+//
+// Orgs are listed concurrently, each within allOrgsListTimeout. An org that cannot be listed is left out of the
+// list and reported as a warning, instead of failing the whole list. Pagination is not supported across orgs:
+// the limit and continue options are ignored.
+func (s *REST) listAllOrgs(ctx context.Context, user kuser.Info, options *metainternal.ListOptions) (runtime.Object, error) {
+	rootOrg, err := s.getOrg(tenancyhelper.RootCluster)
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := rootOrg.clusterWorkspaceLister.List(user, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var orgOptions *metainternal.ListOptions
+	if options != nil {
+		orgOptions = options.DeepCopy()
+		orgOptions.Limit, orgOptions.Continue = 0, ""
+	}
+
+	groupOrgs, constrained := s.orgsOfGroups(user)
+	results := make([]orgListResult, 0, len(orgs.Items))
+	for i := range orgs.Items {
+		if constrained && !groupOrgs.Has(orgs.Items[i].Name) {
+			continue
+		}
+		results = append(results, orgListResult{orgClusterName: tenancyhelper.EncodeOrganizationAndClusterWorkspace(tenancyhelper.RootCluster, orgs.Items[i].Name)})
+	}
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(result *orgListResult) {
+			defer wg.Done()
+			result.list, result.err = s.listOrg(ctx, result.orgClusterName, orgOptions)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].orgClusterName < results[j].orgClusterName })
+	workspaceList := &tenancyv1beta1.WorkspaceList{}
+	for _, result := range results {
+		if result.err != nil {
+			warning.AddWarning(ctx, "", fmt.Sprintf("workspaces of org %s are not listed: %v", result.orgClusterName, result.err))
+			continue
+		}
+		for i := range result.list.Items {
+			workspace := result.list.Items[i]
+			if workspace.Annotations == nil {
+				workspace.Annotations = map[string]string{}
+			}
+			workspace.Annotations[OrgAnnotation] = result.orgClusterName
+			workspaceList.Items = append(workspaceList.Items, workspace)
+		}
+	}
+	return workspaceList, nil
+}
+
+// orgsOfGroups returns the names of the orgs the groups of the user are mapped to, and whether any of the
+// groups is mapped at all.
+func (s *REST) orgsOfGroups(user kuser.Info) (sets.String, bool) {
+	orgs := sets.NewString()
+	constrained := false
+	for _, group := range user.GetGroups() {
+		if groupOrgs, found := s.groupOrgs[group]; found {
+			orgs.Insert(groupOrgs...)
+			constrained = true
+		}
+	}
+	return orgs, constrained
+}
+
+// listOrg lists the personal workspaces of the user in the given org, giving up after allOrgsListTimeout.
+func (s *REST) listOrg(ctx context.Context, orgClusterName string, options *metainternal.ListOptions) (*tenancyv1beta1.WorkspaceList, error) {
+	ctx, cancel := context.WithTimeout(ctx, allOrgsListTimeout)
+	defer cancel()
+	ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, orgClusterName)
+	ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, PersonalScope)
+
+	done := make(chan orgListResult, 1)
+	go func() {
+		obj, err := s.List(ctx, options)
+		if err != nil {
+			done <- orgListResult{err: err}
+			return
+		}
+		done <- orgListResult{list: obj.(*tenancyv1beta1.WorkspaceList)}
+	}()
+
+	select {
+	case result := <-done:
+		return result.list, result.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("listing did not complete within %s: %w", allOrgsListTimeout, ctx.Err())
+	}
+}
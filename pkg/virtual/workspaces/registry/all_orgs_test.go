@@ -0,0 +1,216 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/warning"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// blockingLister blocks until unblocked, like the lister of an org that does not answer.
+type blockingLister struct {
+	unblock chan struct{}
+}
+
+func (l *blockingLister) List(user kuser.Info, selector labels.Selector) (*tenancyv1alpha1.ClusterWorkspaceList, error) {
+	<-l.unblock
+	return &tenancyv1alpha1.ClusterWorkspaceList{}, nil
+}
+
+type warningRecorder []string
+
+func (r *warningRecorder) AddWarning(agent, text string) {
+	*r = append(*r, text)
+}
+
+func TestListPersonalWorkspacesInAllOrgs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer func(timeout time.Duration) { allOrgsListTimeout = timeout }(allOrgsListTimeout)
+	allOrgsListTimeout = 100 * time.Millisecond
+
+	user := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1"}}
+	workspace := func(orgClusterName, name string) tenancyv1alpha1.ClusterWorkspace {
+		return tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: orgClusterName, Annotations: ownedBy(user)},
+		}
+	}
+
+	indexClient := fake.NewSimpleClientset(
+		ownerBinding("root:org1", "foo", "foo", user),
+		ownerBinding("root:org2", "bar", "bar-internal", user),
+	)
+	kubeInformers := informers.NewSharedInformerFactory(indexClient, controller.NoResyncPeriodFunc())
+	crbInformer := kubeInformers.Rbac().V1().ClusterRoleBindings()
+	require.NoError(t, AddNameIndexers(crbInformer))
+	kubeInformers.Start(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), crbInformer.Informer().HasSynced)
+
+	slowLister := &blockingLister{unblock: make(chan struct{})}
+	defer close(slowLister.unblock)
+	orgs := map[string]*Org{
+		"root": {clusterWorkspaceLister: &mockLister{workspaces: []tenancyv1alpha1.ClusterWorkspace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "org1", ClusterName: "root"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "org2", ClusterName: "root"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "slow", ClusterName: "root"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "unknown", ClusterName: "root"}},
+		}}},
+		"root:org1": {clusterWorkspaceLister: &mockLister{workspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("root:org1", "foo")}}},
+		"root:org2": {clusterWorkspaceLister: &mockLister{workspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("root:org2", "bar-internal")}}},
+		"root:slow": {clusterWorkspaceLister: slowLister},
+	}
+	storage := &REST{
+		getOrg: func(orgClusterName string) (*Org, error) {
+			if org, ok := orgs[orgClusterName]; ok {
+				return org, nil
+			}
+			return nil, fmt.Errorf("Unknown organization: %s", orgClusterName)
+		},
+		crbInformer: crbInformer,
+	}
+
+	var warnings warningRecorder
+	ctx = warning.WithWarningRecorder(ctx, &warnings)
+	ctx = apirequest.WithUser(ctx, user)
+	ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, PersonalScope)
+	ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, AllOrgs)
+
+	response, err := storage.List(ctx, nil)
+	require.NoError(t, err, "the orgs that cannot be listed should not fail the list")
+	workspaces := response.(*tenancyv1beta1.WorkspaceList)
+	require.Len(t, workspaces.Items, 2)
+	require.Equal(t, "foo", workspaces.Items[0].Name)
+	require.Equal(t, "root:org1", workspaces.Items[0].Annotations[OrgAnnotation])
+	require.Equal(t, "bar", workspaces.Items[1].Name)
+	require.Equal(t, "root:org2", workspaces.Items[1].Annotations[OrgAnnotation])
+
+	require.Len(t, warnings, 2, "expected a warning for each org that cannot be listed: %v", warnings)
+	require.Contains(t, warnings[0], "root:slow")
+	require.Contains(t, warnings[1], "root:unknown")
+
+	_, err = storage.Get(ctx, "foo", &metav1.GetOptions{})
+	require.True(t, kerrors.IsBadRequest(err), "expected only lists to be supported across all orgs, got %v", err)
+}
+
+func TestListPersonalWorkspacesInOrgsOfGroups(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	user := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1", "team-2"}}
+	workspace := func(orgClusterName, name string) tenancyv1alpha1.ClusterWorkspace {
+		return tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: orgClusterName, Annotations: ownedBy(user)},
+		}
+	}
+
+	indexClient := fake.NewSimpleClientset(
+		ownerBinding("root:org1", "foo", "foo", user),
+		ownerBinding("root:org2", "bar", "bar", user),
+		ownerBinding("root:org3", "baz", "baz", user),
+	)
+	kubeInformers := informers.NewSharedInformerFactory(indexClient, controller.NoResyncPeriodFunc())
+	crbInformer := kubeInformers.Rbac().V1().ClusterRoleBindings()
+	require.NoError(t, AddNameIndexers(crbInformer))
+	kubeInformers.Start(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), crbInformer.Informer().HasSynced)
+
+	orgs := map[string]*Org{
+		"root": {clusterWorkspaceLister: &mockLister{workspaces: []tenancyv1alpha1.ClusterWorkspace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "org1", ClusterName: "root"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "org2", ClusterName: "root"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "org3", ClusterName: "root"}},
+		}}},
+		"root:org1": {clusterWorkspaceLister: &mockLister{workspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("root:org1", "foo")}}},
+		"root:org2": {clusterWorkspaceLister: &mockLister{workspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("root:org2", "bar")}}},
+		"root:org3": {clusterWorkspaceLister: &mockLister{workspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("root:org3", "baz")}}},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		groupOrgs map[string][]string
+		expected  []string
+	}{
+		{
+			name:     "no mapping",
+			expected: []string{"root:org1", "root:org2", "root:org3"},
+		},
+		{
+			name:      "one group mapped",
+			groupOrgs: map[string][]string{"team-1": {"org2"}},
+			expected:  []string{"root:org2"},
+		},
+		{
+			name:      "orgs of all the groups",
+			groupOrgs: map[string][]string{"team-1": {"org1"}, "team-2": {"org3"}, "team-3": {"org2"}},
+			expected:  []string{"root:org1", "root:org3"},
+		},
+		{
+			name:      "groups of other users mapped",
+			groupOrgs: map[string][]string{"team-3": {"org2"}},
+			expected:  []string{"root:org1", "root:org2", "root:org3"},
+		},
+		{
+			name:      "org the user cannot get",
+			groupOrgs: map[string][]string{"team-1": {"org1", "other"}},
+			expected:  []string{"root:org1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := &REST{
+				getOrg: func(orgClusterName string) (*Org, error) {
+					if org, ok := orgs[orgClusterName]; ok {
+						return org, nil
+					}
+					return nil, fmt.Errorf("Unknown organization: %s", orgClusterName)
+				},
+				crbInformer: crbInformer,
+				groupOrgs:   tc.groupOrgs,
+			}
+
+			ctx := apirequest.WithUser(ctx, user)
+			ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, PersonalScope)
+			ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, AllOrgs)
+
+			response, err := storage.List(ctx, nil)
+			require.NoError(t, err)
+			var listed []string
+			for _, workspace := range response.(*tenancyv1beta1.WorkspaceList).Items {
+				listed = append(listed, workspace.Annotations[OrgAnnotation])
+			}
+			require.Equal(t, tc.expected, listed)
+		})
+	}
+}
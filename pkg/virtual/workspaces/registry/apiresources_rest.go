@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/discovery"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// apiResourcesCacheTTL is how long the discovery of a workspace is served from the cache
+// before being requested from its shard again.
+const apiResourcesCacheTTL = 10 * time.Second
+
+type APIResourcesSubresourceREST struct {
+	mainRest *REST
+
+	// rootCoreClient is useful to get secrets
+	rootCoreClient corev1client.CoreV1Interface
+	// workspaceShardClient can get KCP workspace shards
+	workspaceShardClient tenancyclient.WorkspaceShardInterface
+
+	// cache holds the recent discovery of workspaces, by logical cluster name
+	cache *utilcache.Expiring
+}
+
+var _ rest.Getter = &APIResourcesSubresourceREST{}
+var _ rest.Scoper = &APIResourcesSubresourceREST{}
+
+// Get returns the API groups and resources served inside of the workspace with the given name,
+// as discovered on the shard hosting it with the credentials of the shard.
+func (s *APIResourcesSubresourceREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	wrapError := func(err error) error {
+		k8sErr := kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces/apiresources").GroupResource(), name)
+		k8sErr.Status().Details.Causes = append(k8sErr.Status().Details.Causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeUnexpectedServerResponse,
+			Message: err.Error(),
+		})
+		return k8sErr
+	}
+
+	workspace, err := s.mainRest.getInternalClusterWorkspace(ctx, name, options)
+	if kerrors.IsNotFound(err) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceShardValid) {
+		return nil, wrapError(errors.New("ClusterWorkspace URL is not valid"))
+	}
+
+	clusterName, err := helper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+	if cached, ok := s.cache.Get(clusterName); ok {
+		return cached.(*tenancyv1beta1.WorkspaceAPIResources).DeepCopy(), nil
+	}
+
+	shardKubeConfig, _, err := workspaceShardKubeconfig(ctx, s.workspaceShardClient, s.rootCoreClient, workspace)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*shardKubeConfig, nil).ClientConfig()
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	groups, resourceLists, err := discovery.ServerGroupsAndResources(discoveryClient)
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, kerrors.NewServiceUnavailable(err.Error())
+		}
+		// the groups that could be discovered are still worth returning
+		klog.Warningf("Partial discovery of workspace %s|%s: %v", workspace.ClusterName, workspace.Name, err)
+	}
+
+	result := &tenancyv1beta1.WorkspaceAPIResources{}
+	for _, group := range groups {
+		result.Groups = append(result.Groups, *group)
+	}
+	for _, resourceList := range resourceLists {
+		result.Resources = append(result.Resources, *resourceList)
+	}
+	if err == nil {
+		s.cache.Set(clusterName, result.DeepCopy(), apiResourcesCacheTTL)
+	}
+	return result, nil
+}
+
+func (s *APIResourcesSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// New creates a new WorkspaceAPIResources object
+func (s *APIResourcesSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceAPIResources{}
+}
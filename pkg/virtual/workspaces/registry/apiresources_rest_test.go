@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+var shardKubeConfigContentWithoutCA string = `
+kind: Config
+apiVersion: v1
+clusters:
+- name: admin
+  cluster:
+    server: ADMIN_SERVER
+users:
+- name: loopback
+  user:
+    token: loopback-token
+contexts:
+- name: admin
+  context:
+    cluster: admin
+    user: loopback
+current-context: admin
+`
+
+// newDiscoveryServer serves the discovery of a workspace with the tenancy API under the given path,
+// counting the requests it receives. It has to be served over TLS for the shard credentials to be sent.
+func newDiscoveryServer(t *testing.T, path string, requests *int32) *httptest.Server {
+	documents := map[string]interface{}{
+		path + "/api": &metav1.APIVersions{
+			Versions: []string{"v1"},
+		},
+		path + "/apis": &metav1.APIGroupList{
+			Groups: []metav1.APIGroup{
+				{
+					Name:             tenancyv1alpha1.SchemeGroupVersion.Group,
+					Versions:         []metav1.GroupVersionForDiscovery{{GroupVersion: tenancyv1alpha1.SchemeGroupVersion.String(), Version: "v1alpha1"}},
+					PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: tenancyv1alpha1.SchemeGroupVersion.String(), Version: "v1alpha1"},
+				},
+			},
+		},
+		path + "/api/v1": &metav1.APIResourceList{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: metav1.Verbs{"get", "list"}}},
+		},
+		path + "/apis/tenancy.kcp.dev/v1alpha1": &metav1.APIResourceList{
+			GroupVersion: tenancyv1alpha1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{{Name: "clusterworkspaces", Kind: "ClusterWorkspace", Verbs: metav1.Verbs{"get", "list"}}},
+		},
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.Header.Get("Authorization") != "Bearer loopback-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		document, found := documents[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(document))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAPIResourcesPersonalWorkspace(t *testing.T) {
+	var requests int32
+	server := newDiscoveryServer(t, "/clusters/root:foo", &requests)
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:  user,
+			scope: "personal",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root", Annotations: ownedBy(user)},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						BaseURL: server.URL + "/clusters/root:foo",
+						Location: tenancyv1alpha1.ClusterWorkspaceLocation{
+							Current: "theOneAndOnlyShard",
+						},
+						Conditions: conditionsv1alpha1.Conditions{
+							{
+								Type:   tenancyv1alpha1.WorkspaceShardValid,
+								Status: corev1.ConditionTrue,
+							},
+						},
+					},
+				},
+			},
+			workspaceShards: []tenancyv1alpha1.WorkspaceShard{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "theOneAndOnlyShard",
+					},
+					Spec: tenancyv1alpha1.WorkspaceShardSpec{
+						CABundle: serverCA,
+						Credentials: corev1.SecretReference{
+							Name:      "kubeconfig",
+							Namespace: "kcp",
+						},
+					},
+				},
+			},
+			secrets: []corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kubeconfig",
+						Namespace: "kcp",
+					},
+					Data: map[string][]byte{
+						"kubeconfig": []byte(shardKubeConfigContentWithoutCA),
+					},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", user),
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			apiResourcesStorage := &APIResourcesSubresourceREST{
+				mainRest:             storage,
+				rootCoreClient:       kubeClient.CoreV1(),
+				workspaceShardClient: kcpClient.TenancyV1alpha1().WorkspaceShards(),
+				cache:                utilcache.NewExpiring(),
+			}
+
+			response, err := apiResourcesStorage.Get(ctx, "foo", &metav1.GetOptions{})
+			require.NoError(t, err)
+			require.IsType(t, &tenancyv1beta1.WorkspaceAPIResources{}, response)
+			apiResources := response.(*tenancyv1beta1.WorkspaceAPIResources)
+
+			var groups []string
+			for _, group := range apiResources.Groups {
+				groups = append(groups, group.Name)
+			}
+			assert.Contains(t, groups, tenancyv1alpha1.SchemeGroupVersion.Group)
+			var resources []string
+			for _, resourceList := range apiResources.Resources {
+				for _, resource := range resourceList.APIResources {
+					resources = append(resources, resourceList.GroupVersion+"/"+resource.Name)
+				}
+			}
+			assert.ElementsMatch(t, []string{"v1/configmaps", "tenancy.kcp.dev/v1alpha1/clusterworkspaces"}, resources)
+
+			discoveryRequests := atomic.LoadInt32(&requests)
+			_, err = apiResourcesStorage.Get(ctx, "foo", &metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, discoveryRequests, atomic.LoadInt32(&requests), "the discovery should have been served from the cache")
+
+			_, err = apiResourcesStorage.Get(ctx, "unknown", &metav1.GetOptions{})
+			require.True(t, kerrors.IsNotFound(err), "expected a not found error for an unknown workspace, got %v", err)
+			require.True(t, strings.Contains(err.Error(), "workspaces"), "unexpected error %v", err)
+		},
+	}
+	applyTest(t, test)
+}
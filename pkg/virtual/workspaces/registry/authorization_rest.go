@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/registry/rest"
+	authorizationapi "k8s.io/kubernetes/pkg/apis/authorization"
+	authorizationv1conversion "k8s.io/kubernetes/pkg/apis/authorization/v1"
+	"k8s.io/kubernetes/pkg/registry/authorization/selfsubjectrulesreview"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+type AuthorizationSubresourceREST struct {
+	mainRest *REST
+
+	// getRuleResolver returns a rule resolver for the rules of users inside of the given logical cluster
+	getRuleResolver func(clusterName string) (authorizer.RuleResolver, error)
+}
+
+var _ rest.Getter = &AuthorizationSubresourceREST{}
+var _ rest.Scoper = &AuthorizationSubresourceREST{}
+
+// Get returns the rules the requesting user has inside of the workspace with the given name.
+// The rules are computed like for a SelfSubjectRulesReview in the default namespace of the workspace.
+func (s *AuthorizationSubresourceREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	workspace, err := s.mainRest.getInternalClusterWorkspace(ctx, name, options)
+	if kerrors.IsNotFound(err) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	clusterName, err := helper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+	ruleResolver, err := s.getRuleResolver(clusterName)
+	if err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+
+	obj, err := selfsubjectrulesreview.NewREST(ruleResolver).Create(ctx, &authorizationapi.SelfSubjectRulesReview{
+		Spec: authorizationapi.SelfSubjectRulesReviewSpec{Namespace: metav1.NamespaceDefault},
+	}, nil, &metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	review, ok := obj.(*authorizationapi.SelfSubjectRulesReview)
+	if !ok {
+		return nil, kerrors.NewInternalError(fmt.Errorf("unexpected SelfSubjectRulesReview type %T", obj))
+	}
+
+	result := &tenancyv1beta1.WorkspaceAuthorization{}
+	if err := authorizationv1conversion.Convert_authorization_SubjectRulesReviewStatus_To_v1_SubjectRulesReviewStatus(&review.Status, &result.Status, nil); err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+	return result, nil
+}
+
+func (s *AuthorizationSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// New creates a new WorkspaceAuthorization object
+func (s *AuthorizationSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceAuthorization{}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/kubernetes/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+type mockRuleResolver struct {
+	checkedUsers []kuser.Info
+}
+
+func (m *mockRuleResolver) RulesFor(user kuser.Info, namespace string) ([]authorizer.ResourceRuleInfo, []authorizer.NonResourceRuleInfo, bool, error) {
+	m.checkedUsers = append(m.checkedUsers, user)
+	return []authorizer.ResourceRuleInfo{
+		&authorizer.DefaultResourceRuleInfo{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+	}, []authorizer.NonResourceRuleInfo{
+		&authorizer.DefaultNonResourceRuleInfo{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}},
+	}, false, nil
+}
+
+func TestAuthorizationPersonalWorkspaceWithPrettyName(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:  user,
+			scope: PersonalScope,
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", ClusterName: "root:orgName", Annotations: ownedBy(user)},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", user),
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			ruleResolver := &mockRuleResolver{}
+			var resolvedClusterName string
+			authorizationSubresourceStorage := &AuthorizationSubresourceREST{
+				mainRest: storage,
+				getRuleResolver: func(clusterName string) (authorizer.RuleResolver, error) {
+					resolvedClusterName = clusterName
+					return ruleResolver, nil
+				},
+			}
+
+			response, err := authorizationSubresourceStorage.Get(ctx, "foo", nil)
+			require.NoError(t, err)
+			require.IsType(t, &tenancyv1beta1.WorkspaceAuthorization{}, response)
+			assert.Equal(t, "orgName:foo--1", resolvedClusterName)
+			assert.Equal(t, []kuser.Info{user}, ruleResolver.checkedUsers)
+			assert.Equal(t, authorizationv1.SubjectRulesReviewStatus{
+				ResourceRules: []authorizationv1.ResourceRule{
+					{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+				},
+				NonResourceRules: []authorizationv1.NonResourceRule{
+					{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}},
+				},
+			}, response.(*tenancyv1beta1.WorkspaceAuthorization).Status)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestAuthorizationFailBecauseWorkspaceNotFound(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:  user,
+			scope: OrganizationScope,
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			authorizationSubresourceStorage := &AuthorizationSubresourceREST{
+				mainRest: storage,
+				getRuleResolver: func(clusterName string) (authorizer.RuleResolver, error) {
+					t.Fatalf("unexpected rule resolution for cluster %q", clusterName)
+					return nil, nil
+				},
+			}
+
+			_, err := authorizationSubresourceStorage.Get(ctx, "foo", nil)
+			assert.EqualError(t, err, "workspaces.tenancy.kcp.dev \"foo\" not found")
+		},
+	}
+	applyTest(t, test)
+}
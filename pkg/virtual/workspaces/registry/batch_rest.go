@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/util/workqueue"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+const (
+	// maxWorkspaceBatchSize bounds the number of workspaces of a single batch.
+	maxWorkspaceBatchSize = 100
+	// workspaceBatchConcurrency bounds the number of workspaces of a batch created in parallel.
+	workspaceBatchConcurrency = 5
+)
+
+// WorkspaceBatchREST creates the workspaces of a WorkspaceBatch, reporting the result of each of them.
+type WorkspaceBatchREST struct {
+	mainRest *REST
+}
+
+var _ rest.Creater = &WorkspaceBatchREST{}
+var _ rest.Scoper = &WorkspaceBatchREST{}
+
+// New returns a new WorkspaceBatch
+func (s *WorkspaceBatchREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceBatch{}
+}
+
+func (s *WorkspaceBatchREST) NamespaceScoped() bool {
+	return false
+}
+
+// Create creates the workspaces of the batch like individual creations would, with a bounded concurrency.
+// Only an invalid batch fails as a whole: the failure to create a workspace is reported in its result.
+func (s *WorkspaceBatchREST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	batch, ok := obj.(*tenancyv1beta1.WorkspaceBatch)
+	if !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceBatch: %T", obj))
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	workspacesPath := field.NewPath("spec", "workspaces")
+	if len(batch.Spec.Workspaces) > maxWorkspaceBatchSize {
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceBatch"), batch.Name, field.ErrorList{
+			field.TooMany(workspacesPath, len(batch.Spec.Workspaces), maxWorkspaceBatchSize),
+		})
+	}
+
+	result := batch.DeepCopy()
+	result.Status.Results = make([]tenancyv1beta1.WorkspaceBatchResult, len(batch.Spec.Workspaces))
+
+	// duplicates would race with each other, reject all but the first deterministically
+	seen := map[string]bool{}
+	var toCreate []int
+	for i := range batch.Spec.Workspaces {
+		name := batch.Spec.Workspaces[i].Name
+		result.Status.Results[i].Name = name
+		if seen[name] {
+			result.Status.Results[i].Error = errorToStatus(kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceBatch"), batch.Name, field.ErrorList{
+				field.Duplicate(workspacesPath.Index(i).Child("metadata", "name"), name),
+			}))
+			continue
+		}
+		seen[name] = true
+		toCreate = append(toCreate, i)
+	}
+
+	workqueue.ParallelizeUntil(ctx, workspaceBatchConcurrency, len(toCreate), func(piece int) {
+		i := toCreate[piece]
+		created, err := s.mainRest.Create(ctx, batch.Spec.Workspaces[i].DeepCopy(), nil, options)
+		if err != nil {
+			result.Status.Results[i].Error = errorToStatus(err)
+			return
+		}
+		workspace, ok := created.(*tenancyv1beta1.Workspace)
+		if !ok {
+			result.Status.Results[i].Error = errorToStatus(fmt.Errorf("unexpected created object type %T", created))
+			return
+		}
+		result.Status.Results[i].Workspace = workspace
+	})
+
+	// the context might have been cancelled before all workspaces were processed
+	for i := range result.Status.Results {
+		if result.Status.Results[i].Workspace == nil && result.Status.Results[i].Error == nil {
+			result.Status.Results[i].Error = errorToStatus(kerrors.NewTimeoutError(fmt.Sprintf("workspace %q was not created: %v", result.Status.Results[i].Name, ctx.Err()), 0))
+		}
+	}
+
+	return result, nil
+}
+
+// errorToStatus returns the API status of the given error, defaulting to an internal error.
+func errorToStatus(err error) *metav1.Status {
+	if status, ok := err.(kerrors.APIStatus); ok {
+		s := status.Status()
+		return &s
+	}
+	s := kerrors.NewInternalError(err).Status()
+	return &s
+}
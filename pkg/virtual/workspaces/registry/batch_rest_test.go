@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestCreateWorkspaceBatch(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{"get": mockReviewer{}},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{ObjectMeta: metav1.ObjectMeta{Name: "workspace1"}},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "workspace1", user),
+						Labels: map[string]string{
+							PrettyNameLabel:   "workspace1",
+							InternalNameLabel: "workspace1",
+						},
+					},
+					Subjects: []rbacv1.Subject{{Kind: "User", Name: user.Name}},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			batchStorage := &WorkspaceBatchREST{mainRest: storage}
+			batch := &tenancyv1beta1.WorkspaceBatch{
+				Spec: tenancyv1beta1.WorkspaceBatchSpec{
+					Workspaces: []tenancyv1beta1.Workspace{
+						{ObjectMeta: metav1.ObjectMeta{Name: "workspace1"}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "workspace2"}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "workspace3"}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "workspace2"}},
+					},
+				},
+			}
+			response, err := batchStorage.Create(ctx, batch, nil, &metav1.CreateOptions{})
+			require.NoError(t, err, "the failure of some workspaces should not fail the batch")
+			results := response.(*tenancyv1beta1.WorkspaceBatch).Status.Results
+			require.Len(t, results, 4)
+
+			assert.Equal(t, "workspace1", results[0].Name)
+			assert.Nil(t, results[0].Workspace)
+			require.NotNil(t, results[0].Error)
+			assert.Equal(t, metav1.StatusReasonAlreadyExists, results[0].Error.Reason, "expected the existing workspace to be reported as already existing")
+
+			for _, i := range []int{1, 2} {
+				assert.Nil(t, results[i].Error, "unexpected error for %s", results[i].Name)
+				require.NotNil(t, results[i].Workspace)
+				assert.Equal(t, results[i].Name, results[i].Workspace.Name)
+				_, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, results[i].Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected the ClusterWorkspace %s to be created", results[i].Name)
+			}
+
+			assert.Equal(t, "workspace2", results[3].Name)
+			assert.Nil(t, results[3].Workspace)
+			require.NotNil(t, results[3].Error)
+			assert.Equal(t, metav1.StatusReasonInvalid, results[3].Error.Reason, "expected the duplicate workspace to be rejected")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceBatchTooLarge(t *testing.T) {
+	test := TestDescription{
+		TestData: TestData{
+			user:    &kuser.DefaultInfo{Name: "test-user"},
+			scope:   PersonalScope,
+			orgName: "orgName",
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			batch := &tenancyv1beta1.WorkspaceBatch{}
+			for i := 0; i <= maxWorkspaceBatchSize; i++ {
+				batch.Spec.Workspaces = append(batch.Spec.Workspaces, tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("workspace%d", i)}})
+			}
+			_, err := (&WorkspaceBatchREST{mainRest: storage}).Create(ctx, batch, nil, &metav1.CreateOptions{})
+			require.Error(t, err)
+			assert.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+			workspaces, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, workspaces.Items, "no workspace of an invalid batch should be created")
+		},
+	}
+	applyTest(t, test)
+}
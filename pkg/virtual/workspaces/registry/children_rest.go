@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	"k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/projection"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	kcpauthorization "github.com/kcp-dev/kcp/pkg/authorization"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+// ChildrenSubresourceREST lists the workspaces created inside of a workspace.
+type ChildrenSubresourceREST struct {
+	mainRest *REST
+
+	// listChildren returns the ClusterWorkspaces of the given logical cluster.
+	listChildren func(ctx context.Context, clusterName string) ([]tenancyv1alpha1.ClusterWorkspace, error)
+	// getRuleResolver returns a rule resolver for the rules of users inside of the given logical cluster
+	getRuleResolver func(clusterName string) (authorizer.RuleResolver, error)
+}
+
+var _ rest.GetterWithOptions = &ChildrenSubresourceREST{}
+var _ rest.Scoper = &ChildrenSubresourceREST{}
+
+// NewChildrenSubresourceREST returns the storage of the children subresource, which lists the ClusterWorkspaces
+// inside of workspaces and authorizes them against the RBAC of the workspace.
+func NewChildrenSubresourceREST(mainRest *REST, kcpClusterClient kcpclient.ClusterInterface, wildcardsRbacInformers rbacinformers.Interface) *ChildrenSubresourceREST {
+	return &ChildrenSubresourceREST{
+		mainRest: mainRest,
+		listChildren: func(ctx context.Context, clusterName string) ([]tenancyv1alpha1.ClusterWorkspace, error) {
+			list, err := kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		},
+		getRuleResolver: func(clusterName string) (authorizer.RuleResolver, error) {
+			return kcpauthorization.NewWorkspaceContentRuleResolver(wildcardsRbacInformers, clusterName)
+		},
+	}
+}
+
+// Get returns the workspaces created inside of the workspace with the given name which the user is allowed to
+// get there, sorted by name and paginated like lists of workspaces.
+func (s *ChildrenSubresourceREST) Get(ctx context.Context, name string, options runtime.Object) (runtime.Object, error) {
+	childrenOptions, ok := options.(*tenancyv1beta1.WorkspaceChildrenOptions)
+	if !ok || childrenOptions == nil {
+		childrenOptions = &tenancyv1beta1.WorkspaceChildrenOptions{}
+	}
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("unable to list the children of a workspace without a user on the context"))
+	}
+
+	workspace, err := s.mainRest.getInternalClusterWorkspace(ctx, name, nil)
+	if kerrors.IsNotFound(err) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("workspace is not ready"))
+	}
+
+	clusterName, err := helper.EncodeLogicalClusterName(workspace)
+	if err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+	ruleResolver, err := s.getRuleResolver(clusterName)
+	if err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+	// like the RBAC authorizer, the rules that could be resolved are used despite errors
+	rules, _, _, _ := ruleResolver.RulesFor(user, metav1.NamespaceNone)
+
+	children, err := s.listChildren(ctx, clusterName)
+	if err != nil {
+		return nil, kerrors.NewServiceUnavailable(fmt.Sprintf("unable to list the children of workspace %s: %v", name, err))
+	}
+
+	list := &tenancyv1beta1.WorkspaceList{Items: make([]tenancyv1beta1.Workspace, 0, len(children))}
+	for i := range children {
+		if !canGetClusterWorkspace(user, rules, children[i].Name) {
+			continue
+		}
+		var child tenancyv1beta1.Workspace
+		projection.ProjectClusterWorkspaceToWorkspace(&children[i], &child)
+		list.Items = append(list.Items, child)
+	}
+	if err := paginateWorkspaceList(list, SortByName, childrenOptions.Limit, childrenOptions.Continue); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// canGetClusterWorkspace returns whether the given rules allow the user to get the ClusterWorkspace with the
+// given name.
+func canGetClusterWorkspace(user kuser.Info, rules []authorizer.ResourceRuleInfo, name string) bool {
+	attributes := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            "get",
+		APIGroup:        tenancyv1alpha1.SchemeGroupVersion.Group,
+		Resource:        "clusterworkspaces",
+		Name:            name,
+		ResourceRequest: true,
+	}
+	for _, rule := range rules {
+		if rbac.RuleAllows(attributes, &rbacv1.PolicyRule{
+			Verbs:         rule.GetVerbs(),
+			APIGroups:     rule.GetAPIGroups(),
+			Resources:     rule.GetResources(),
+			ResourceNames: rule.GetResourceNames(),
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewGetOptions returns the options of the children subresource, decoded from the query parameters.
+func (s *ChildrenSubresourceREST) NewGetOptions() (runtime.Object, bool, string) {
+	return &tenancyv1beta1.WorkspaceChildrenOptions{}, false, ""
+}
+
+func (s *ChildrenSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// New returns a new WorkspaceList
+func (s *ChildrenSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceList{}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/kubernetes/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+type staticRuleResolver []authorizer.ResourceRuleInfo
+
+func (r staticRuleResolver) RulesFor(user kuser.Info, namespace string) ([]authorizer.ResourceRuleInfo, []authorizer.NonResourceRuleInfo, bool, error) {
+	return r, nil, false, nil
+}
+
+func TestChildrenPersonalWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:  user,
+			scope: PersonalScope,
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", ClusterName: "root:orgName", Annotations: ownedBy(user)},
+					Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", user),
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			var listedClusterName, resolvedClusterName string
+			childrenStorage := &ChildrenSubresourceREST{
+				mainRest: storage,
+				listChildren: func(ctx context.Context, clusterName string) ([]tenancyv1alpha1.ClusterWorkspace, error) {
+					listedClusterName = clusterName
+					var children []tenancyv1alpha1.ClusterWorkspace
+					for _, name := range []string{"team-c", "hidden", "team-a", "team-b"} {
+						children = append(children, tenancyv1alpha1.ClusterWorkspace{
+							ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: clusterName},
+							Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+						})
+					}
+					return children, nil
+				},
+				getRuleResolver: func(clusterName string) (authorizer.RuleResolver, error) {
+					resolvedClusterName = clusterName
+					return staticRuleResolver{
+						&authorizer.DefaultResourceRuleInfo{Verbs: []string{"get"}, APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"clusterworkspaces"}, ResourceNames: []string{"team-a", "team-b", "team-c"}},
+						&authorizer.DefaultResourceRuleInfo{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+					}, nil
+				},
+			}
+
+			response, err := childrenStorage.Get(ctx, "foo", &tenancyv1beta1.WorkspaceChildrenOptions{Limit: 2})
+			require.NoError(t, err)
+			assert.Equal(t, "orgName:foo--1", listedClusterName)
+			assert.Equal(t, "orgName:foo--1", resolvedClusterName)
+			page := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, page.Items, 2)
+			assert.Equal(t, "team-a", page.Items[0].Name)
+			assert.Equal(t, "team-b", page.Items[1].Name)
+			require.NotEmpty(t, page.Continue)
+			require.NotNil(t, page.RemainingItemCount)
+			assert.Equal(t, int64(1), *page.RemainingItemCount, "invisible children should not be counted")
+
+			response, err = childrenStorage.Get(ctx, "foo", &tenancyv1beta1.WorkspaceChildrenOptions{Limit: 2, Continue: page.Continue})
+			require.NoError(t, err)
+			page = response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, page.Items, 1)
+			assert.Equal(t, "team-c", page.Items[0].Name)
+			assert.Empty(t, page.Continue)
+
+			_, err = childrenStorage.Get(ctx, "unknown", nil)
+			require.True(t, kerrors.IsNotFound(err), "expected NotFound, got %v", err)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestChildrenFailBecauseWorkspaceNotReady(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:  user,
+			scope: OrganizationScope,
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root:orgName"},
+					Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			childrenStorage := &ChildrenSubresourceREST{
+				mainRest: storage,
+				listChildren: func(ctx context.Context, clusterName string) ([]tenancyv1alpha1.ClusterWorkspace, error) {
+					t.Fatal("the children of a workspace which is not ready should not be listed")
+					return nil, nil
+				},
+				getRuleResolver: func(clusterName string) (authorizer.RuleResolver, error) {
+					return staticRuleResolver{}, nil
+				},
+			}
+
+			_, err := childrenStorage.Get(ctx, "foo", nil)
+			require.True(t, kerrors.IsConflict(err), "expected Conflict, got %v", err)
+		},
+	}
+	applyTest(t, test)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// continueTokenAPIVersion is the version of the continue token format.
+const continueTokenAPIVersion = "tenancy.kcp.dev/v1beta1"
+
+// continueToken is the content of the continue token returned when paging through workspaces.
+// Pages are ordered by the sort field, then by workspace name, and a page starts right after the
+// last-seen workspace. Tokens without sort field were issued for lists sorted by name.
+type continueToken struct {
+	APIVersion      string    `json:"v"`
+	ResourceVersion string    `json:"rv,omitempty"`
+	Start           string    `json:"start"`
+	SortBy          SortField `json:"sortBy,omitempty"`
+	StartTime       string    `json:"startTime,omitempty"`
+}
+
+func encodeContinue(token continueToken) (string, error) {
+	token.APIVersion = continueTokenAPIVersion
+	out, err := json.Marshal(&token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+func decodeContinue(continueValue string) (*continueToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(continueValue)
+	if err != nil {
+		return nil, fmt.Errorf("continue key is not valid: %w", err)
+	}
+	var token continueToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("continue key is not valid: %w", err)
+	}
+	if token.APIVersion != continueTokenAPIVersion {
+		return nil, fmt.Errorf("continue key is not supported: unexpected version %q", token.APIVersion)
+	}
+	if token.Start == "" {
+		return nil, errors.New("continue key is not valid: missing start")
+	}
+	if token.ResourceVersion != "" {
+		if _, err := strconv.ParseUint(token.ResourceVersion, 10, 64); err != nil {
+			return nil, fmt.Errorf("continue key is not valid: invalid resource version %q", token.ResourceVersion)
+		}
+	}
+	if token.SortBy == "" {
+		token.SortBy = SortByName
+	}
+	if token.SortBy == SortByCreationTimestamp {
+		if _, err := time.Parse(time.RFC3339, token.StartTime); err != nil {
+			return nil, fmt.Errorf("continue key is not valid: invalid start time %q", token.StartTime)
+		}
+	}
+	return &token, nil
+}
+
+// paginateWorkspaceList sorts the workspaces by the given field and keeps only
+// the page selected by limit and continueValue. When workspaces remain after the
+// page, the list gets a continue token pointing to the last workspace of the page.
+// A continue token issued for another sort field is rejected.
+func paginateWorkspaceList(list *tenancyv1beta1.WorkspaceList, sortBy SortField, limit int64, continueValue string) error {
+	less := workspaceLess(sortBy)
+	sort.Slice(list.Items, func(i, j int) bool {
+		return less(&list.Items[i], &list.Items[j])
+	})
+
+	if continueValue != "" {
+		token, err := decodeContinue(continueValue)
+		if err == nil && token.SortBy != sortBy {
+			err = fmt.Errorf("continue key is not valid: issued for a list sorted by %s, not by %s", token.SortBy, sortBy)
+		}
+		if err != nil {
+			return kerrors.NewResourceExpired(fmt.Sprintf("the provided continue parameter is invalid or expired: %v", err))
+		}
+		last := &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: token.Start}}
+		if sortBy == SortByCreationTimestamp {
+			startTime, _ := time.Parse(time.RFC3339, token.StartTime)
+			last.CreationTimestamp = metav1.NewTime(startTime)
+		}
+		i := sort.Search(len(list.Items), func(i int) bool {
+			return less(last, &list.Items[i])
+		})
+		list.Items = list.Items[i:]
+	}
+
+	if limit <= 0 || int64(len(list.Items)) <= limit {
+		return nil
+	}
+
+	last := list.Items[limit-1]
+	next := continueToken{ResourceVersion: last.ResourceVersion, Start: last.Name}
+	if sortBy != SortByName {
+		next.SortBy = sortBy
+	}
+	if sortBy == SortByCreationTimestamp {
+		next.StartTime = last.CreationTimestamp.UTC().Format(time.RFC3339)
+	}
+	token, err := encodeContinue(next)
+	if err != nil {
+		return kerrors.NewInternalError(err)
+	}
+	remaining := int64(len(list.Items)) - limit
+	list.Items = list.Items[:limit]
+	list.Continue = token
+	list.RemainingItemCount = &remaining
+	return nil
+}
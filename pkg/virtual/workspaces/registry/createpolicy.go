@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/rest"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// maxCreatePolicyResponseBytes bounds the size of the responses of the workspace creation policy webhook.
+const maxCreatePolicyResponseBytes = 1 << 20
+
+// CreatePolicyWebhookConfig configures the webhook asked whether a workspace can be created.
+type CreatePolicyWebhookConfig struct {
+	// URL is the https URL the AdmissionReviews of workspace creations are POSTed to. The webhook is
+	// disabled if empty.
+	URL string
+	// TLS holds the CA verifying the webhook, and the client certificate presented to it.
+	TLS rest.TLSClientConfig
+	// Timeout bounds the time a creation waits for the webhook.
+	Timeout time.Duration
+}
+
+// CreatePolicyWebhook asks a webhook whether a workspace can be created, e.g. to enforce the policies of
+// an organization. It speaks admission.k8s.io/v1 AdmissionReviews like admission webhooks, such that
+// existing policy engines can serve it. The creation fails if the webhook cannot be reached.
+type CreatePolicyWebhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewCreatePolicyWebhook returns the webhook of the given configuration.
+func NewCreatePolicyWebhook(config CreatePolicyWebhookConfig) (*CreatePolicyWebhook, error) {
+	transport, err := rest.TransportFor(&rest.Config{TLSClientConfig: config.TLS})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the transport of the workspace creation policy webhook: %w", err)
+	}
+	return &CreatePolicyWebhook{
+		url:    config.URL,
+		client: &http.Client{Transport: transport, Timeout: config.Timeout},
+	}, nil
+}
+
+// Admit sends the workspace the user is about to create in the given organization to the webhook. If the
+// webhook denies the creation, it returns a Forbidden error with the message of the webhook.
+func (w *CreatePolicyWebhook) Admit(ctx context.Context, user kuser.Info, orgClusterName string, workspace *tenancyv1beta1.Workspace, dryRun bool) error {
+	if w == nil {
+		return nil
+	}
+
+	// the organization is told through the cluster name of the workspace
+	pending := workspace.DeepCopy()
+	pending.ClusterName = orgClusterName
+	pending.SetGroupVersionKind(tenancyv1beta1.SchemeGroupVersion.WithKind("Workspace"))
+	raw, err := json.Marshal(pending)
+	if err != nil {
+		return kerrors.NewInternalError(err)
+	}
+
+	gvk := metav1.GroupVersionKind{Group: tenancyv1beta1.SchemeGroupVersion.Group, Version: tenancyv1beta1.SchemeGroupVersion.Version, Kind: "Workspace"}
+	gvr := metav1.GroupVersionResource{Group: tenancyv1beta1.SchemeGroupVersion.Group, Version: tenancyv1beta1.SchemeGroupVersion.Version, Resource: "workspaces"}
+	extra := map[string]authenticationv1.ExtraValue{}
+	for key, values := range user.GetExtra() {
+		extra[key] = values
+	}
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:         uuid.NewUUID(),
+			Kind:        gvk,
+			Resource:    gvr,
+			RequestKind: &gvk,
+			Name:        workspace.Name,
+			Operation:   admissionv1.Create,
+			UserInfo: authenticationv1.UserInfo{
+				Username: user.GetName(),
+				UID:      user.GetUID(),
+				Groups:   user.GetGroups(),
+				Extra:    extra,
+			},
+			Object: runtime.RawExtension{Raw: raw},
+			DryRun: &dryRun,
+		},
+	}
+
+	response, err := w.review(ctx, review)
+	if err != nil {
+		return kerrors.NewInternalError(fmt.Errorf("failed calling the workspace creation policy webhook: %w", err))
+	}
+	if response.Allowed {
+		return nil
+	}
+	message := "denied by the workspace creation policy"
+	if response.Result != nil && response.Result.Message != "" {
+		message = response.Result.Message
+	}
+	return kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspace.Name, fmt.Errorf("%s", message))
+}
+
+// review POSTs the given review to the webhook and returns the response to its request.
+func (w *CreatePolicyWebhook) review(ctx context.Context, review *admissionv1.AdmissionReview) (*admissionv1.AdmissionResponse, error) {
+	body, err := json.Marshal(review)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxCreatePolicyResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	result := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("invalid AdmissionReview: %w", err)
+	}
+	if result.Response == nil {
+		return nil, fmt.Errorf("missing response in AdmissionReview")
+	}
+	if result.Response.UID != review.Request.UID {
+		return nil, fmt.Errorf("expected response for request %s, got %s", review.Request.UID, result.Response.UID)
+	}
+	return result.Response, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+// fakeCreatePolicyWebhook denies the creation of workspaces named "forbidden", and records the requests it
+// received.
+type fakeCreatePolicyWebhook struct {
+	lock     sync.Mutex
+	requests []*admissionv1.AdmissionRequest
+}
+
+func (f *fakeCreatePolicyWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+		http.Error(w, "invalid AdmissionReview", http.StatusBadRequest)
+		return
+	}
+	f.lock.Lock()
+	f.requests = append(f.requests, review.Request)
+	f.lock.Unlock()
+
+	workspace := &tenancyv1beta1.Workspace{}
+	if err := json.Unmarshal(review.Request.Object.Raw, workspace); err != nil {
+		http.Error(w, "invalid workspace", http.StatusBadRequest)
+		return
+	}
+	response := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: workspace.Name != "forbidden"}
+	if !response.Allowed {
+		response.Result = &metav1.Status{Message: "workspaces named forbidden are not allowed in " + workspace.ClusterName}
+	}
+	review.Request, review.Response = nil, response
+	_ = json.NewEncoder(w).Encode(review)
+}
+
+func (f *fakeCreatePolicyWebhook) Requests() []*admissionv1.AdmissionRequest {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]*admissionv1.AdmissionRequest(nil), f.requests...)
+}
+
+// newTestCreatePolicyWebhook returns a webhook talking to the given TLS server.
+func newTestCreatePolicyWebhook(t *testing.T, server *httptest.Server) *CreatePolicyWebhook {
+	webhook, err := NewCreatePolicyWebhook(CreatePolicyWebhookConfig{
+		URL: server.URL,
+		TLS: rest.TLSClientConfig{
+			CAData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		},
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+	return webhook
+}
+
+func TestCreateWorkspaceCreatePolicy(t *testing.T) {
+	fakeWebhook := &fakeCreatePolicyWebhook{}
+	server := httptest.NewTLSServer(fakeWebhook)
+	defer server.Close()
+
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:                user,
+			scope:               PersonalScope,
+			orgName:             "orgName",
+			reviewerProvider:    mockReviewerProvider{},
+			createPolicyWebhook: newTestCreatePolicyWebhook(t, server),
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "forbidden"},
+			}, nil, &metav1.CreateOptions{})
+			require.True(t, kerrors.IsForbidden(err), "expected 403, got %v", err)
+			require.Contains(t, err.Error(), "workspaces named forbidden are not allowed in orgName")
+
+			_, err = storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "allowed"},
+			}, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			clusterWorkspaces, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, clusterWorkspaces.Items, 1, "the denied creation should not have created anything")
+			require.Equal(t, "allowed", clusterWorkspaces.Items[0].Name)
+
+			requests := fakeWebhook.Requests()
+			require.Len(t, requests, 2)
+			require.Equal(t, admissionv1.Create, requests[0].Operation)
+			require.Equal(t, "workspaces", requests[0].Resource.Resource)
+			require.Equal(t, "forbidden", requests[0].Name)
+			require.Equal(t, "test-user", requests[0].UserInfo.Username)
+			require.Equal(t, []string{"test-group"}, requests[0].UserInfo.Groups)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreatePolicyWebhookFailsClosed(t *testing.T) {
+	user := &kuser.DefaultInfo{Name: "test-user"}
+	workspace := &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{name: "error status", handler: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}},
+		{name: "missing response", handler: func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview"}`))
+		}},
+		{name: "other request", handler: func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview","response":{"uid":"other","allowed":true}}`))
+		}},
+		{name: "timeout", handler: func(w http.ResponseWriter, r *http.Request) {
+			// the closed connection is only noticed once the body is read
+			_, _ = io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(tc.handler)
+			defer server.Close()
+
+			err := newTestCreatePolicyWebhook(t, server).Admit(context.Background(), user, "root:orgName", workspace, false)
+			require.Error(t, err)
+			require.True(t, kerrors.IsInternalError(err), "expected 500, got %v", err)
+		})
+	}
+
+	var nilWebhook *CreatePolicyWebhook
+	require.NoError(t, nilWebhook.Admit(context.Background(), user, "root:orgName", workspace, false))
+}
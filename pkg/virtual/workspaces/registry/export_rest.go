@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/dynamic"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// contentClientFunc returns a client of the content of the given workspace.
+type contentClientFunc func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (dynamic.Interface, error)
+
+// newShardContentClientFunc returns a contentClientFunc talking to the shard hosting the workspace with the
+// credentials of the shard.
+func newShardContentClientFunc(rootCoreClient corev1client.CoreV1Interface, workspaceShardClient tenancyclient.WorkspaceShardInterface) contentClientFunc {
+	return func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (dynamic.Interface, error) {
+		shardKubeConfig, _, err := workspaceShardKubeconfig(ctx, workspaceShardClient, rootCoreClient, workspace)
+		if err != nil {
+			return nil, err
+		}
+		config, err := clientcmd.NewDefaultClientConfig(*shardKubeConfig, nil).ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		return dynamic.NewForConfig(config)
+	}
+}
+
+// getContentClient returns a client of the content of the workspace with the given name, if the user is allowed
+// to update the workspace.
+func getContentClient(ctx context.Context, mainRest *REST, contentClient contentClientFunc, name string) (dynamic.Interface, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("unable to access the content of a workspace without a user on the context"))
+	}
+	orgClusterName, org, err := mainRest.extractOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace, err := mainRest.getInternalClusterWorkspace(ctx, name, nil)
+	if kerrors.IsNotFound(err) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed, err := isAllowed(org.workspaceReviewerProvider.ForVerb("update"), user, workspace.Name); err != nil {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, err)
+	} else if !allowed {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("User %s doesn't have the permission to update workspace %s in organization %s", user.GetName(), name, orgClusterName))
+	}
+
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady || !conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceShardValid) {
+		return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("workspace is not ready"))
+	}
+
+	client, err := contentClient(ctx, workspace)
+	if err != nil {
+		return nil, kerrors.NewServiceUnavailable(fmt.Sprintf("unable to reach workspace %s: %v", name, err))
+	}
+	return client, nil
+}
+
+// ExportSubresourceREST returns the declarative content of workspaces as a portable manifest.
+type ExportSubresourceREST struct {
+	mainRest *REST
+
+	contentClient contentClientFunc
+}
+
+var _ rest.GetterWithOptions = &ExportSubresourceREST{}
+var _ rest.Scoper = &ExportSubresourceREST{}
+
+// NewExportSubresourceREST returns the storage of the export subresource, which reads the content of workspaces
+// on their shard with the credentials found through the root workspace.
+func NewExportSubresourceREST(mainRest *REST, rootCoreClient corev1client.CoreV1Interface, workspaceShardClient tenancyclient.WorkspaceShardInterface) *ExportSubresourceREST {
+	return &ExportSubresourceREST{
+		mainRest:      mainRest,
+		contentClient: newShardContentClientFunc(rootCoreClient, workspaceShardClient),
+	}
+}
+
+// Get returns the manifest of the CustomResourceDefinitions, RBAC objects, namespaces and ConfigMaps of the
+// workspace with the given name, and of its secrets if requested. Objects bootstrapped by the system are left out.
+// The user must be allowed to update the workspace.
+func (s *ExportSubresourceREST) Get(ctx context.Context, name string, options runtime.Object) (runtime.Object, error) {
+	exportOptions, ok := options.(*tenancyv1beta1.WorkspaceExportOptions)
+	if !ok || exportOptions == nil {
+		exportOptions = &tenancyv1beta1.WorkspaceExportOptions{}
+	}
+
+	client, err := getContentClient(ctx, s.mainRest, s.contentClient, name)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := exportedResources
+	if exportOptions.IncludeSecrets {
+		resources = append(resources[:len(resources):len(resources)], exportedSecrets)
+	}
+	var objs []*unstructured.Unstructured
+	for _, resource := range resources {
+		list, err := client.Resource(resource.GroupVersionResource).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, kerrors.NewServiceUnavailable(fmt.Sprintf("unable to list %s in workspace %s: %v", resource.Resource, name, err))
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if resource.skip(obj) {
+				continue
+			}
+			portable := portableObject(obj)
+			portable.SetAPIVersion(resource.GroupVersion().String())
+			portable.SetKind(resource.kind)
+			objs = append(objs, portable)
+		}
+	}
+
+	manifest, err := encodeManifest(objs)
+	if err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+	return &tenancyv1beta1.WorkspaceExport{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Manifest:   manifest,
+	}, nil
+}
+
+// NewGetOptions returns the options of the export subresource, decoded from the query parameters.
+func (s *ExportSubresourceREST) NewGetOptions() (runtime.Object, bool, string) {
+	return &tenancyv1beta1.WorkspaceExportOptions{}, false, ""
+}
+
+func (s *ExportSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// New returns a new WorkspaceExport
+func (s *ExportSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceExport{}
+}
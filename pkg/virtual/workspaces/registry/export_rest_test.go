@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+)
+
+func newContentObject(gvk schema.GroupVersionKind, namespace, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for k, v := range fields {
+		obj.Object[k] = v
+	}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// newContentClient returns a fake client of the content of a workspace, holding the given objects.
+func newContentClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, resource := range append(exportedResources, exportedSecrets) {
+		listKinds[resource.GroupVersionResource] = resource.kind + "List"
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...)
+}
+
+func TestExportImportWorkspace(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	user := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1"}}
+	allowed := mockReview{users: []string{user.Name}}
+
+	readyStatus := tenancyv1alpha1.ClusterWorkspaceStatus{
+		Phase:      tenancyv1alpha1.ClusterWorkspacePhaseReady,
+		Conditions: conditionsv1alpha1.Conditions{{Type: tenancyv1alpha1.WorkspaceShardValid, Status: corev1.ConditionTrue}},
+	}
+	workspaces := []tenancyv1alpha1.ClusterWorkspace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "source", ClusterName: "root:org", Annotations: ownedBy(user)}, Status: readyStatus},
+		{ObjectMeta: metav1.ObjectMeta{Name: "target", ClusterName: "root:org", Annotations: ownedBy(user)}, Status: readyStatus},
+		{ObjectMeta: metav1.ObjectMeta{Name: "initializing", ClusterName: "root:org", Annotations: ownedBy(user)}, Status: tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing}},
+	}
+	org := &Org{
+		clusterWorkspaceClient:    tenancyv1fake.NewSimpleClientset(&workspaces[0], &workspaces[1], &workspaces[2]).TenancyV1alpha1().ClusterWorkspaces(),
+		clusterWorkspaceLister:    &mockLister{workspaces: workspaces},
+		workspaceReviewerProvider: mockReviewerProvider{"update": mockReviewer{"source": allowed, "target": allowed, "initializing": allowed}},
+	}
+	mainRest := &REST{
+		getOrg: func(orgName string) (*Org, error) {
+			if orgName == "root:org" {
+				return org, nil
+			}
+			return nil, fmt.Errorf("Unknown organization: %s", orgName)
+		},
+	}
+
+	crd := newContentObject(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, "", "cowboys.wildwest.dev", map[string]interface{}{
+		"spec":   map[string]interface{}{"group": "wildwest.dev"},
+		"status": map[string]interface{}{"acceptedNames": map[string]interface{}{"kind": "Cowboy"}},
+	})
+	crd.SetUID("1234")
+	crd.SetResourceVersion("42")
+	sourceClient := newContentClient(
+		crd,
+		newContentObject(corev1.SchemeGroupVersion.WithKind("Namespace"), "", "team", nil),
+		newContentObject(corev1.SchemeGroupVersion.WithKind("Namespace"), "", "kube-system", nil),
+		newContentObject(corev1.SchemeGroupVersion.WithKind("ConfigMap"), "team", "settings", map[string]interface{}{"data": map[string]interface{}{"color": "blue"}}),
+		newContentObject(corev1.SchemeGroupVersion.WithKind("ConfigMap"), "team", "kube-root-ca.crt", nil),
+		newContentObject(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, "", "system:bootstrapped", nil),
+		newContentObject(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, "", "viewer", nil),
+		newContentObject(corev1.SchemeGroupVersion.WithKind("Secret"), "team", "password", map[string]interface{}{"type": "Opaque"}),
+		newContentObject(corev1.SchemeGroupVersion.WithKind("Secret"), "team", "token", map[string]interface{}{"type": string(corev1.SecretTypeServiceAccountToken)}),
+	)
+	targetClient := newContentClient(newContentObject(corev1.SchemeGroupVersion.WithKind("Namespace"), "", "team", nil))
+	contentClient := func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (dynamic.Interface, error) {
+		switch workspace.Name {
+		case "source":
+			return sourceClient, nil
+		case "target":
+			return targetClient, nil
+		}
+		return nil, fmt.Errorf("unexpected workspace %s", workspace.Name)
+	}
+	exportStorage := &ExportSubresourceREST{mainRest: mainRest, contentClient: contentClient}
+	importStorage := &ImportSubresourceREST{mainRest: mainRest, contentClient: contentClient}
+
+	ctx = apirequest.WithUser(ctx, user)
+	ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, OrganizationScope)
+	ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, "root:org")
+
+	obj, err := exportStorage.Get(ctx, "source", &tenancyv1beta1.WorkspaceExportOptions{})
+	require.NoError(t, err)
+	export, ok := obj.(*tenancyv1beta1.WorkspaceExport)
+	require.True(t, ok, "expected a WorkspaceExport, got %T", obj)
+	require.Contains(t, export.Manifest, workspaceManifestHeaderPrefix+workspaceManifestVersion+"\n")
+	require.NotContains(t, export.Manifest, "acceptedNames", "the status should not be exported")
+	require.NotContains(t, export.Manifest, "resourceVersion", "the metadata set by the system should not be exported")
+	require.NotContains(t, export.Manifest, "password", "secrets should not be exported by default")
+
+	objs, err := decodeManifest(export.Manifest)
+	require.NoError(t, err)
+	var exported []string
+	for _, obj := range objs {
+		resource, _ := exportedResourceFor(obj)
+		exported = append(exported, resource.reference(obj))
+	}
+	require.Equal(t, []string{
+		"customresourcedefinitions.apiextensions.k8s.io/cowboys.wildwest.dev",
+		"namespaces/team",
+		"clusterroles.rbac.authorization.k8s.io/viewer",
+		"configmaps/team/settings",
+	}, exported, "expected the objects in import order, without those bootstrapped by the system")
+
+	obj, err = exportStorage.Get(ctx, "source", &tenancyv1beta1.WorkspaceExportOptions{IncludeSecrets: true})
+	require.NoError(t, err)
+	require.Contains(t, obj.(*tenancyv1beta1.WorkspaceExport).Manifest, "password", "secrets should be exported on request")
+	require.NotContains(t, obj.(*tenancyv1beta1.WorkspaceExport).Manifest, "name: token", "service account tokens should never be exported")
+
+	obj, err = importStorage.Create(ctx, "target", &tenancyv1beta1.WorkspaceImport{Spec: tenancyv1beta1.WorkspaceImportSpec{Manifest: export.Manifest}}, nil, &metav1.CreateOptions{})
+	require.NoError(t, err)
+	imported, ok := obj.(*tenancyv1beta1.WorkspaceImport)
+	require.True(t, ok, "expected a WorkspaceImport, got %T", obj)
+	require.Equal(t, []string{
+		"customresourcedefinitions.apiextensions.k8s.io/cowboys.wildwest.dev",
+		"clusterroles.rbac.authorization.k8s.io/viewer",
+		"configmaps/team/settings",
+	}, imported.Status.Created)
+	require.Equal(t, []string{"namespaces/team"}, imported.Status.Existing)
+
+	crdResource := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	importedCRD, err := targetClient.Resource(crdResource).Get(ctx, "cowboys.wildwest.dev", metav1.GetOptions{})
+	require.NoError(t, err)
+	group, _, _ := unstructured.NestedString(importedCRD.Object, "spec", "group")
+	require.Equal(t, "wildwest.dev", group)
+	require.Empty(t, importedCRD.GetUID())
+
+	_, err = importStorage.Create(ctx, "target", &tenancyv1beta1.WorkspaceImport{Spec: tenancyv1beta1.WorkspaceImportSpec{Manifest: "kind: ConfigMap\n"}}, nil, &metav1.CreateOptions{})
+	require.True(t, kerrors.IsBadRequest(err), "expected a bad request for a manifest without header, got %v", err)
+
+	_, err = exportStorage.Get(ctx, "initializing", &tenancyv1beta1.WorkspaceExportOptions{})
+	require.True(t, kerrors.IsConflict(err), "expected a conflict for a workspace which is not ready, got %v", err)
+
+	org.workspaceReviewerProvider = mockReviewerProvider{"update": mockReviewer{}}
+	_, err = exportStorage.Get(ctx, "source", &tenancyv1beta1.WorkspaceExportOptions{})
+	require.True(t, kerrors.IsForbidden(err), "expected a forbidden error without update permission, got %v", err)
+	_, err = importStorage.Create(ctx, "target", &tenancyv1beta1.WorkspaceImport{Spec: tenancyv1beta1.WorkspaceImportSpec{Manifest: export.Manifest}}, nil, &metav1.CreateOptions{})
+	require.True(t, kerrors.IsForbidden(err), "expected a forbidden error without update permission, got %v", err)
+}
+
+func TestDecodeManifest(t *testing.T) {
+	header := workspaceManifestHeaderPrefix + workspaceManifestVersion + "\n"
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  bool
+		want     int
+	}{
+		{name: "empty manifest", manifest: header},
+		{name: "one object", manifest: header + "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n  namespace: bar\n", want: 1},
+		{name: "missing header", manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n", wantErr: true},
+		{name: "unsupported version", manifest: workspaceManifestHeaderPrefix + "v0\n", wantErr: true},
+		{name: "unsupported kind", manifest: header + "---\napiVersion: v1\nkind: Pod\nmetadata:\n  name: foo\n", wantErr: true},
+		{name: "missing name", manifest: header + "---\napiVersion: v1\nkind: Namespace\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := decodeManifest(tt.manifest)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, objs, tt.want)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// frozenReasonDefault is the value of the frozen annotation when no reason is given.
+const frozenReasonDefault = "true"
+
+// FreezeSubresourceREST freezes workspaces, i.e. makes their content read-only, or unfreezes them.
+type FreezeSubresourceREST struct {
+	mainRest *REST
+	freeze   bool
+}
+
+var _ rest.NamedCreater = &FreezeSubresourceREST{}
+var _ rest.Scoper = &FreezeSubresourceREST{}
+
+// NewFreezeSubresourceREST returns the storage of the freeze subresource if freeze is true, and of the
+// unfreeze subresource otherwise.
+func NewFreezeSubresourceREST(mainRest *REST, freeze bool) *FreezeSubresourceREST {
+	return &FreezeSubresourceREST{mainRest: mainRest, freeze: freeze}
+}
+
+// New returns a new WorkspaceFreeze
+func (s *FreezeSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceFreeze{}
+}
+
+func (s *FreezeSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// Create sets the frozen annotation on the ClusterWorkspace of the workspace with the given name, or removes it
+// when unfreezing, and returns the workspace. The user must be allowed to update the workspace. While frozen,
+// mutating requests inside the workspace are rejected by the tenancy.kcp.dev/FrozenWorkspace admission plugin.
+//
+// Freezing a frozen workspace updates the reason, unfreezing a workspace that is not frozen is a no-op.
+func (s *FreezeSubresourceREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("unable to freeze a workspace without a user on the context"))
+	}
+
+	freeze, ok := obj.(*tenancyv1beta1.WorkspaceFreeze)
+	if !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceFreeze: %T", obj))
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	orgClusterName, org, err := s.mainRest.extractOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterWorkspace, err := s.mainRest.getInternalClusterWorkspace(ctx, name, nil)
+	if kerrors.IsNotFound(err) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed, err := isAllowed(org.workspaceReviewerProvider.ForVerb("update"), user, clusterWorkspace.Name); err != nil {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, err)
+	} else if !allowed {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("User %s doesn't have the permission to update workspace %s in organization %s", user.GetName(), name, orgClusterName))
+	}
+
+	reason := freeze.Spec.Reason
+	if reason == "" {
+		reason = frozenReasonDefault
+	}
+	current, frozen := clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation]
+	if (s.freeze && frozen && current == reason) || (!s.freeze && !frozen) {
+		return projectCreatedWorkspace(clusterWorkspace, name, "", false), nil
+	}
+
+	updated := clusterWorkspace.DeepCopy()
+	updated.Annotations = make(map[string]string, len(clusterWorkspace.Annotations)+1)
+	for k, v := range clusterWorkspace.Annotations {
+		updated.Annotations[k] = v
+	}
+	if s.freeze {
+		updated.Annotations[tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation] = reason
+	} else {
+		delete(updated.Annotations, tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation)
+	}
+	if updated, err = org.clusterWorkspaceClient.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		if kerrors.IsConflict(err) {
+			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, err)
+		}
+		return nil, err
+	}
+
+	return projectCreatedWorkspace(updated, name, "", false), nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestFreezeWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1"}}
+	allowed := mockReview{users: []string{user.Name}}
+
+	frozenAnnotations := ownedBy(user)
+	frozenAnnotations[tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation] = "audit"
+
+	tests := []struct {
+		name           string
+		freeze         bool
+		reason         string
+		annotations    map[string]string
+		reviewer       mockReviewerProvider
+		expectedFrozen string
+		expectedErr    func(error) bool
+	}{
+		{
+			name:           "freeze",
+			freeze:         true,
+			reason:         "audit",
+			annotations:    ownedBy(user),
+			reviewer:       mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+			expectedFrozen: "audit",
+		},
+		{
+			name:           "freeze without reason",
+			freeze:         true,
+			annotations:    ownedBy(user),
+			reviewer:       mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+			expectedFrozen: frozenReasonDefault,
+		},
+		{
+			name:           "freeze a frozen workspace",
+			freeze:         true,
+			reason:         "audit",
+			annotations:    frozenAnnotations,
+			reviewer:       mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+			expectedFrozen: "audit",
+		},
+		{
+			name:        "unfreeze",
+			annotations: frozenAnnotations,
+			reviewer:    mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+		},
+		{
+			name:        "unfreeze a workspace that is not frozen",
+			annotations: ownedBy(user),
+			reviewer:    mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+		},
+		{
+			name:        "not allowed to update the workspace",
+			freeze:      true,
+			annotations: ownedBy(user),
+			reviewer:    mockReviewerProvider{"update": mockReviewer{}},
+			expectedErr: kerrors.IsForbidden,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			indexClient := fake.NewSimpleClientset(ownerBinding("root:org", "foo", "foo", user))
+			kubeInformers := informers.NewSharedInformerFactory(indexClient, controller.NoResyncPeriodFunc())
+			crbInformer := kubeInformers.Rbac().V1().ClusterRoleBindings()
+			require.NoError(t, AddNameIndexers(crbInformer))
+			kubeInformers.Start(ctx.Done())
+			cache.WaitForCacheSync(ctx.Done(), crbInformer.Informer().HasSynced)
+
+			workspaces := []tenancyv1alpha1.ClusterWorkspace{{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root:org", Annotations: test.annotations},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:   tenancyv1alpha1.ClusterWorkspacePhaseReady,
+					BaseURL: "https://shard/clusters/root:org:foo",
+				},
+			}}
+			kcpClient := tenancyv1fake.NewSimpleClientset(&tenancyv1alpha1.ClusterWorkspaceList{Items: workspaces})
+			org := &Org{
+				rbacClient:                fake.NewSimpleClientset().RbacV1(),
+				crbInformer:               crbInformer,
+				clusterWorkspaceClient:    kcpClient.TenancyV1alpha1().ClusterWorkspaces(),
+				clusterWorkspaceLister:    &mockLister{workspaces: workspaces},
+				workspaceReviewerProvider: test.reviewer,
+			}
+			storage := NewFreezeSubresourceREST(&REST{
+				getOrg: func(orgName string) (*Org, error) {
+					if orgName == "root:org" {
+						return org, nil
+					}
+					return nil, fmt.Errorf("Unknown organization: %s", orgName)
+				},
+				crbInformer: crbInformer,
+			}, test.freeze)
+
+			ctx = apirequest.WithUser(ctx, user)
+			ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, PersonalScope)
+			ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, "root:org")
+
+			obj, err := storage.Create(ctx, "foo", &tenancyv1beta1.WorkspaceFreeze{Spec: tenancyv1beta1.WorkspaceFreezeSpec{Reason: test.reason}}, nil, &metav1.CreateOptions{})
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				require.True(t, test.expectedErr(err), "unexpected error: %v", err)
+				clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+				require.NoError(t, err)
+				require.NotContains(t, clusterWorkspace.Annotations, tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation)
+				return
+			}
+			require.NoError(t, err)
+
+			workspace, ok := obj.(*tenancyv1beta1.Workspace)
+			require.True(t, ok, "expected a Workspace, got %T", obj)
+			require.Equal(t, "foo", workspace.Name)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, user.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation], "expected the other annotations to be kept")
+			if test.expectedFrozen == "" {
+				require.NotContains(t, clusterWorkspace.Annotations, tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation)
+				require.NotContains(t, workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation)
+				return
+			}
+			require.Equal(t, test.expectedFrozen, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation])
+			require.Equal(t, test.expectedFrozen, workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation])
+		})
+	}
+}
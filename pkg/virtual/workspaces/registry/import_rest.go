@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+)
+
+// ImportSubresourceREST creates the objects of manifests returned by the export subresource in workspaces.
+type ImportSubresourceREST struct {
+	mainRest *REST
+
+	contentClient contentClientFunc
+}
+
+var _ rest.NamedCreater = &ImportSubresourceREST{}
+var _ rest.Scoper = &ImportSubresourceREST{}
+
+// NewImportSubresourceREST returns the storage of the import subresource, which creates the content of workspaces
+// on their shard with the credentials found through the root workspace.
+func NewImportSubresourceREST(mainRest *REST, rootCoreClient corev1client.CoreV1Interface, workspaceShardClient tenancyclient.WorkspaceShardInterface) *ImportSubresourceREST {
+	return &ImportSubresourceREST{
+		mainRest:      mainRest,
+		contentClient: newShardContentClientFunc(rootCoreClient, workspaceShardClient),
+	}
+}
+
+// New returns a new WorkspaceImport
+func (s *ImportSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceImport{}
+}
+
+func (s *ImportSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// Create creates the objects of the manifest in the workspace with the given name, in the order of the manifest.
+// Objects which already exist are left alone. The user must be allowed to update the workspace.
+//
+// The import stops at the first object which cannot be created, leaving the objects created before in place.
+// Importing the same manifest again is safe.
+func (s *ImportSubresourceREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	workspaceImport, ok := obj.(*tenancyv1beta1.WorkspaceImport)
+	if !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceImport: %T", obj))
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := getContentClient(ctx, s.mainRest, s.contentClient, name)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := decodeManifest(workspaceImport.Spec.Manifest)
+	if err != nil {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("invalid manifest: %v", err))
+	}
+
+	result := workspaceImport.DeepCopy()
+	result.Name = name
+	result.Status = tenancyv1beta1.WorkspaceImportStatus{}
+	createOptions := metav1.CreateOptions{}
+	if options != nil {
+		createOptions.DryRun = options.DryRun
+	}
+	for _, obj := range objs {
+		resource, _ := exportedResourceFor(obj)
+		_, err := client.Resource(resource.GroupVersionResource).Namespace(obj.GetNamespace()).Create(ctx, obj, createOptions)
+		switch {
+		case kerrors.IsAlreadyExists(err):
+			result.Status.Existing = append(result.Status.Existing, resource.reference(obj))
+		case err != nil:
+			return nil, kerrors.NewServiceUnavailable(fmt.Sprintf("unable to create %s in workspace %s: %v", resource.reference(obj), name, err))
+		default:
+			result.Status.Created = append(result.Status.Created, resource.reference(obj))
+		}
+	}
+	return result, nil
+}
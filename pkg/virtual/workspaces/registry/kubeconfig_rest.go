@@ -18,23 +18,34 @@ package registry
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/authentication/workspacetoken"
 	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
 
@@ -45,13 +56,40 @@ type KubeconfigSubresourceREST struct {
 	rootCoreClient corev1client.CoreV1Interface
 	// workspaceShardClient can get KCP workspace shards
 	workspaceShardClient tenancyclient.WorkspaceShardInterface
+
+	// tokenGenerator mints the tokens embedded on request in the returned kubeconfigs.
+	// Tokens cannot be requested if nil.
+	tokenGenerator *workspacetoken.Generator
+	// tokenTTL is the lifetime of the minted tokens.
+	tokenTTL time.Duration
+
+	// getClusterWorkspace returns the ClusterWorkspace with the given name inside of the given logical cluster.
+	// It is used to resolve the children in nested workspace paths.
+	getClusterWorkspace func(clusterName, name string) (*tenancyv1alpha1.ClusterWorkspace, error)
+	// getRuleResolver returns a rule resolver for the rules of users inside of the given logical cluster
+	getRuleResolver func(clusterName string) (authorizer.RuleResolver, error)
 }
 
-var _ rest.Getter = &KubeconfigSubresourceREST{}
+var _ rest.GetterWithOptions = &KubeconfigSubresourceREST{}
 var _ rest.Scoper = &KubeconfigSubresourceREST{}
 
-// Get retrieves a ClusterWorkspace KubeConfig by workspace name
-func (s *KubeconfigSubresourceREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+// Get retrieves a ClusterWorkspace KubeConfig by workspace name, or by the path of a nested workspace
+// of the form <workspace>:<child>[:<child>...].
+func (s *KubeconfigSubresourceREST) Get(ctx context.Context, name string, options runtime.Object) (runtime.Object, error) {
+	kubeconfigOptions, ok := options.(*tenancyv1beta1.WorkspaceKubeconfigOptions)
+	if !ok || kubeconfigOptions == nil {
+		kubeconfigOptions = &tenancyv1beta1.WorkspaceKubeconfigOptions{}
+	}
+	switch kubeconfigOptions.Credentials {
+	case "":
+	case tenancyv1beta1.WorkspaceKubeconfigCredentialsToken:
+		if s.tokenGenerator == nil {
+			return nil, kerrors.NewBadRequest("credentials=token is not enabled on this server")
+		}
+	default:
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("unsupported credentials %q, only %q is supported", kubeconfigOptions.Credentials, tenancyv1beta1.WorkspaceKubeconfigCredentialsToken))
+	}
+
 	wrapError := func(err error) error {
 		k8sErr := kerrors.NewNotFound(tenancyv1alpha1.SchemeGroupVersion.WithResource("workspaces/kubeconfig").GroupResource(), name)
 		k8sErr.Status().Details.Causes = append(k8sErr.Status().Details.Causes, metav1.StatusCause{
@@ -61,10 +99,7 @@ func (s *KubeconfigSubresourceREST) Get(ctx context.Context, name string, option
 		return k8sErr
 	}
 
-	workspace, err := s.mainRest.getClusterWorkspace(ctx, name, options)
-	if kerrors.IsNotFound(err) {
-		return nil, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
-	}
+	workspace, err := s.resolveWorkspacePath(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -72,48 +107,194 @@ func (s *KubeconfigSubresourceREST) Get(ctx context.Context, name string, option
 	if !conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceShardValid) {
 		return nil, wrapError(errors.New("ClusterWorkspace URL is not valid"))
 	}
-	shard, err := s.workspaceShardClient.Get(ctx, workspace.Status.Location.Current, metav1.GetOptions{})
+	_, currentCluster, err := workspaceShardKubeconfig(ctx, s.workspaceShardClient, s.rootCoreClient, workspace)
 	if err != nil {
 		return nil, wrapError(err)
 	}
-	secret, err := s.rootCoreClient.Secrets(shard.Spec.Credentials.Namespace).Get(ctx, shard.Spec.Credentials.Name, metav1.GetOptions{})
+
+	// Kubeconfigs with a token are never the same, only the plain ones support conditional requests.
+	if kubeconfigOptions.Credentials == "" {
+		etag := kubeconfigETag(workspace, currentCluster)
+		if header, ok := ctx.Value(virtualcontext.ResponseHeaderKey).(http.Header); ok {
+			header.Set("ETag", etag)
+		}
+		header, _ := ctx.Value(virtualcontext.RequestHeaderKey).(http.Header)
+		statusCode, ok := ctx.Value(virtualcontext.ResponseStatusCodeKey).(*int)
+		if ok && etagMatches(header.Get("If-None-Match"), etag) {
+			// the ETag header is all the response holds
+			*statusCode = http.StatusNotModified
+			return KubeConfig(""), nil
+		}
+	}
+
+	// the workspace keeps its internal name, the context is named after the name the user sees
+	workspaceContextName := scope + "/" + name
+
+	// the context tells users which type of workspace they got
+	extension, err := json.Marshal(tenancyv1beta1.WorkspaceKubeconfigExtension{Type: workspace.Spec.Type})
+	if err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
+
+	// by default, return a kubeconfig that lacks the user and its credentials,
+	// i.e. it's only the cluster definition with its CA cert and URL, etc ...
+	workspaceConfig := &api.Config{
+		APIVersion: "v1",
+		Clusters:   map[string]*api.Cluster{workspaceContextName: currentCluster},
+		Contexts: map[string]*api.Context{workspaceContextName: {
+			Cluster: workspaceContextName,
+			Extensions: map[string]runtime.Object{
+				tenancyv1beta1.WorkspaceKubeconfigExtensionName: &runtime.Unknown{Raw: extension, ContentType: runtime.ContentTypeJSON},
+			},
+		}},
+		CurrentContext: workspaceContextName,
+	}
+
+	if kubeconfigOptions.Credentials == tenancyv1beta1.WorkspaceKubeconfigCredentialsToken {
+		user, _ := apirequest.UserFrom(ctx)
+		clusterName, err := helper.EncodeLogicalClusterName(workspace)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		// the token is only part of the response, it is never persisted
+		token, _, err := s.tokenGenerator.GenerateToken(user, clusterName, s.tokenTTL)
+		if err != nil {
+			return nil, kerrors.NewInternalError(err)
+		}
+		workspaceConfig.AuthInfos = map[string]*api.AuthInfo{workspaceContextName: {Token: token}}
+		workspaceConfig.Contexts[workspaceContextName].AuthInfo = workspaceContextName
+	}
+	dataToReturn, err := clientcmd.Write(*workspaceConfig)
 	if err != nil {
 		return nil, wrapError(err)
 	}
+	return KubeConfig(string(dataToReturn)), nil
+}
+
+// resolveWorkspacePath returns the ClusterWorkspace of the workspace with the given path. The first segment
+// is a workspace of the user in the current scope, and each following one is a child created inside of
+// the previous workspace, which must be ready. Like for the children subresource, the user must be allowed
+// by the RBAC of the parent to get each child, otherwise the path is not found.
+func (s *KubeconfigSubresourceREST) resolveWorkspacePath(ctx context.Context, path string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	notFound := kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), path)
+
+	segments := strings.Split(path, ":")
+	workspace, err := s.mainRest.getInternalClusterWorkspace(ctx, segments[0], &metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 1 {
+		return workspace, nil
+	}
+
+	if s.getClusterWorkspace == nil || s.getRuleResolver == nil {
+		return nil, kerrors.NewBadRequest("nested workspace paths are not supported by this server")
+	}
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), path, fmt.Errorf("unable to resolve a nested workspace without a user on the context"))
+	}
+	for i, childName := range segments[1:] {
+		if childName == "" {
+			return nil, notFound
+		}
+		if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+			parentPath := strings.Join(segments[:i+1], ":")
+			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), path, fmt.Errorf("workspace %s is not ready", parentPath))
+		}
+		clusterName, err := helper.EncodeLogicalClusterName(workspace)
+		if err != nil {
+			return nil, kerrors.NewInternalError(err)
+		}
+		ruleResolver, err := s.getRuleResolver(clusterName)
+		if err != nil {
+			return nil, kerrors.NewInternalError(err)
+		}
+		// like the RBAC authorizer, the rules that could be resolved are used despite errors
+		rules, _, _, _ := ruleResolver.RulesFor(user, metav1.NamespaceNone)
+		if !canGetClusterWorkspace(user, rules, childName) {
+			return nil, notFound
+		}
+		child, err := s.getClusterWorkspace(clusterName, childName)
+		if kerrors.IsNotFound(err) {
+			return nil, notFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		workspace = child
+	}
+	return workspace, nil
+}
+
+// workspaceShardKubeconfig loads the kubeconfig of the credentials of the shard hosting the given workspace,
+// and points its current cluster to the workspace, trusting the CA bundle of the shard if it has one.
+// It returns the kubeconfig along with its current cluster.
+func workspaceShardKubeconfig(ctx context.Context, workspaceShardClient tenancyclient.WorkspaceShardInterface, rootCoreClient corev1client.CoreV1Interface, workspace *tenancyv1alpha1.ClusterWorkspace) (*api.Config, *api.Cluster, error) {
+	shard, err := workspaceShardClient.Get(ctx, workspace.Status.Location.Current, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, err := rootCoreClient.Secrets(shard.Spec.Credentials.Namespace).Get(ctx, shard.Spec.Credentials.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
 	data, ok := secret.Data[tenancyv1alpha1.WorkspaceShardCredentialsKey]
 	if !ok {
-		return nil, wrapError(fmt.Errorf("Key '%s' not found in workspace shard Kubeconfig secret", tenancyv1alpha1.WorkspaceShardCredentialsKey))
+		return nil, nil, fmt.Errorf("Key '%s' not found in workspace shard Kubeconfig secret", tenancyv1alpha1.WorkspaceShardCredentialsKey)
 	}
 	shardKubeConfig, err := clientcmd.Load(data)
 	if err != nil {
-		return nil, wrapError(fmt.Errorf("ClusterWorkspace shard Kubeconfig is invalid: %w", err))
+		return nil, nil, fmt.Errorf("ClusterWorkspace shard Kubeconfig is invalid: %w", err)
 	}
 
 	currentContext := shardKubeConfig.Contexts[shardKubeConfig.CurrentContext]
 	if currentContext == nil {
-		return nil, wrapError(errors.New("Workspace shard Kubeconfig has no current context"))
+		return nil, nil, errors.New("Workspace shard Kubeconfig has no current context")
 	}
 	currentCluster := shardKubeConfig.Clusters[currentContext.Cluster]
 	if currentCluster == nil {
-		return nil, wrapError(fmt.Errorf("ClusterWorkspace shard Kubeconfig has no cluster corresponding to the current context cluster key: %s", currentContext.Cluster))
+		return nil, nil, fmt.Errorf("ClusterWorkspace shard Kubeconfig has no cluster corresponding to the current context cluster key: %s", currentContext.Cluster)
 	}
 	currentCluster.Server = workspace.Status.BaseURL
+	if len(shard.Spec.CABundle) > 0 {
+		currentCluster.CertificateAuthority = ""
+		currentCluster.CertificateAuthorityData = shard.Spec.CABundle
+	} else {
+		klog.Warningf("WorkspaceShard %q has no CA bundle, using the CA of its credentials in the kubeconfig of workspace %s|%s", shard.Name, workspace.ClusterName, workspace.Name)
+	}
+	return shardKubeConfig, currentCluster, nil
+}
 
-	workspaceContextName := scope + "/" + workspace.Name
-
-	// return a kubeconfig that lacks the user and its credentials,
-	// i.e. it's only the cluster definition with its CA cert and URL, etc ...
-	workspaceConfig := &api.Config{
-		APIVersion:     "v1",
-		Clusters:       map[string]*api.Cluster{workspaceContextName: currentCluster},
-		Contexts:       map[string]*api.Context{workspaceContextName: {Cluster: workspaceContextName}},
-		CurrentContext: workspaceContextName,
+// kubeconfigETag returns the entity tag of the kubeconfig of the given workspace. It changes whenever the
+// ClusterWorkspace changes, and with the server or the CA of the cluster, which the shard credentials can change.
+func kubeconfigETag(workspace *tenancyv1alpha1.ClusterWorkspace, cluster *api.Cluster) string {
+	hash := sha256.New()
+	for _, s := range []string{workspace.ResourceVersion, workspace.Status.BaseURL, cluster.Server, cluster.TLSServerName, cluster.CertificateAuthority} {
+		hash.Write([]byte(s))
+		hash.Write([]byte{0})
 	}
-	dataToReturn, err := clientcmd.Write(*workspaceConfig)
-	if err != nil {
-		return nil, wrapError(err)
+	hash.Write(cluster.CertificateAuthorityData)
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+}
+
+// etagMatches returns whether the value of an If-None-Match header matches the given entity tag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
 	}
-	return KubeConfig(string(dataToReturn)), nil
+	return false
+}
+
+// NewGetOptions returns the options of the kubeconfig subresource, decoded from the query parameters.
+func (s *KubeconfigSubresourceREST) NewGetOptions() (runtime.Object, bool, string) {
+	return &tenancyv1beta1.WorkspaceKubeconfigOptions{}, false, ""
 }
 
 func (s *KubeconfigSubresourceREST) NamespaceScoped() bool {
@@ -18,8 +18,15 @@ package registry
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,11 +35,19 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/authentication/workspacetoken"
 	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 )
 
@@ -123,6 +138,10 @@ contexts:
   context:
     cluster: ` + contextName + `
     user: ''
+    extensions:
+    - name: tenancy.kcp.dev/workspace
+      extension:
+        type: Universal
 current-context: ` + contextName + `
 users:
 preferences: {}
@@ -145,7 +164,8 @@ func TestKubeconfigPersonalWorkspaceWithPrettyName(t *testing.T) {
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo--1"},
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", Annotations: ownedBy(user)},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
 					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
 						BaseURL: "THE_RIGHT_SERVER_URL",
 						Location: tenancyv1alpha1.ClusterWorkspaceLocation{
@@ -213,6 +233,216 @@ func TestKubeconfigPersonalWorkspaceWithPrettyName(t *testing.T) {
 	applyTest(t, test)
 }
 
+func kubeconfigTestData(user kuser.Info) TestData {
+	return TestData{
+		user:  user,
+		scope: "personal",
+		reviewerProvider: mockReviewerProvider{
+			"get":    mockReviewer{},
+			"delete": mockReviewer{},
+		},
+		clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo--1", ClusterName: "root:orgName", Annotations: ownedBy(user)},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					BaseURL: "THE_RIGHT_SERVER_URL",
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{
+						Current: "theOneAndOnlyShard",
+					},
+					Conditions: conditionsv1alpha1.Conditions{
+						{
+							Type:   tenancyv1alpha1.WorkspaceShardValid,
+							Status: corev1.ConditionTrue,
+						},
+					},
+				},
+			},
+		},
+		workspaceShards: []tenancyv1alpha1.WorkspaceShard{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "theOneAndOnlyShard",
+				},
+				Spec: tenancyv1alpha1.WorkspaceShardSpec{
+					Credentials: corev1.SecretReference{
+						Name:      "kubeconfig",
+						Namespace: "kcp",
+					},
+				},
+			},
+		},
+		secrets: []corev1.Secret{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kubeconfig",
+					Namespace: "kcp",
+				},
+				Data: map[string][]byte{
+					"kubeconfig": []byte(shardKubeConfigContent),
+				},
+			},
+		},
+		clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: getRoleBindingName(OwnerRoleType, "foo", user),
+					Labels: map[string]string{
+						PrettyNameLabel:   "foo",
+						InternalNameLabel: "foo--1",
+					},
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						Kind: "User",
+						Name: user.GetName(),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestKubeconfigUsesShardCABundle(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	testData := kubeconfigTestData(user)
+	testData.workspaceShards[0].Spec.CABundle = []byte("THE_ONE_AND_ONLY_SHARD_CA")
+
+	otherShard := *testData.workspaceShards[0].DeepCopy()
+	otherShard.Name = "theOtherShard"
+	otherShard.Spec.CABundle = []byte("THE_OTHER_SHARD_CA")
+	testData.workspaceShards = append(testData.workspaceShards, otherShard)
+
+	bar := *testData.clusterWorkspaces[0].DeepCopy()
+	bar.Name = "bar--1"
+	bar.Status.Location.Current = "theOtherShard"
+	testData.clusterWorkspaces = append(testData.clusterWorkspaces, bar)
+
+	barBinding := *testData.clusterRoleBindings[0].DeepCopy()
+	barBinding.Name = getRoleBindingName(OwnerRoleType, "bar", user)
+	barBinding.Labels = map[string]string{
+		PrettyNameLabel:   "bar",
+		InternalNameLabel: "bar--1",
+	}
+	testData.clusterRoleBindings = append(testData.clusterRoleBindings, barBinding)
+
+	test := TestDescription{
+		TestData: testData,
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			for name, expectedCA := range map[string]string{
+				"foo": "THE_ONE_AND_ONLY_SHARD_CA",
+				"bar": "THE_OTHER_SHARD_CA",
+			} {
+				response, err := kubeconfigSubResourceStorage.Get(ctx, name, nil)
+				require.NoError(t, err)
+				require.IsType(t, KubeConfig(""), response)
+
+				config, err := clientcmd.Load([]byte(response.(KubeConfig)))
+				require.NoError(t, err)
+				cluster := config.Clusters["personal/"+name]
+				require.NotNil(t, cluster, "expected a cluster for workspace %q", name)
+				require.Equal(t, expectedCA, string(cluster.CertificateAuthorityData), "expected the CA bundle of the shard of workspace %q", name)
+				require.Equal(t, "THE_RIGHT_SERVER_URL", cluster.Server)
+			}
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestKubeconfigShowsWorkspaceType(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	testData := kubeconfigTestData(user)
+	testData.clusterWorkspaces[0].Spec.Type = "Team"
+	test := TestDescription{
+		TestData: testData,
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := kubeconfigSubResourceStorage.Get(ctx, "foo", nil)
+			require.NoError(t, err)
+			require.IsType(t, KubeConfig(""), response)
+
+			config, err := clientcmd.Load([]byte(response.(KubeConfig)))
+			require.NoError(t, err)
+			extension, ok := config.Contexts["personal/foo"].Extensions[tenancyv1beta1.WorkspaceKubeconfigExtensionName].(*runtime.Unknown)
+			require.True(t, ok, "expected the workspace extension in the context")
+			var workspace tenancyv1beta1.WorkspaceKubeconfigExtension
+			require.NoError(t, json.Unmarshal(extension.Raw, &workspace))
+			require.Equal(t, "Team", workspace.Type)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestKubeconfigWithToken(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: kubeconfigTestData(user),
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+			kubeconfigSubResourceStorage.tokenGenerator, err = workspacetoken.NewGenerator(key)
+			require.NoError(t, err)
+			kubeconfigSubResourceStorage.tokenTTL = time.Hour
+
+			response, err := kubeconfigSubResourceStorage.Get(ctx, "foo", &tenancyv1beta1.WorkspaceKubeconfigOptions{Credentials: "token"})
+			require.NoError(t, err)
+			require.IsType(t, KubeConfig(""), response)
+
+			config, err := clientcmd.Load([]byte(response.(KubeConfig)))
+			require.NoError(t, err)
+			require.Equal(t, "personal/foo", config.CurrentContext)
+			authInfoName := config.Contexts["personal/foo"].AuthInfo
+			require.NotEmpty(t, authInfoName)
+			require.Contains(t, config.AuthInfos, authInfoName)
+			token := config.AuthInfos[authInfoName].Token
+			require.NotEmpty(t, token)
+
+			authenticator := workspacetoken.NewAuthenticator([]interface{}{key.Public()})
+			resp, ok, err := authenticator.AuthenticateToken(apirequest.WithCluster(ctx, apirequest.Cluster{Name: "orgName:foo--1"}), token)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, user, resp.User)
+
+			_, ok, err = authenticator.AuthenticateToken(apirequest.WithCluster(ctx, apirequest.Cluster{Name: "orgName:foo"}), token)
+			require.Error(t, err, "the token audience should be scoped to the logical cluster of the workspace")
+			require.False(t, ok)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestKubeconfigWithTokenFailBecauseNotEnabled(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: kubeconfigTestData(user),
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			_, err := kubeconfigSubResourceStorage.Get(ctx, "foo", &tenancyv1beta1.WorkspaceKubeconfigOptions{Credentials: "token"})
+			require.Error(t, err)
+			require.True(t, kerrors.IsBadRequest(err))
+
+			_, err = kubeconfigSubResourceStorage.Get(ctx, "foo", &tenancyv1beta1.WorkspaceKubeconfigOptions{Credentials: "password"})
+			require.Error(t, err)
+			require.True(t, kerrors.IsBadRequest(err))
+		},
+	}
+	applyTest(t, test)
+}
+
 func TestKubeconfigPersonalWorkspace(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
@@ -229,7 +459,8 @@ func TestKubeconfigPersonalWorkspace(t *testing.T) {
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
 					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
 						BaseURL: "THE_RIGHT_SERVER_URL",
 						Location: tenancyv1alpha1.ClusterWorkspaceLocation{
@@ -314,6 +545,7 @@ func TestKubeconfigOrganizationWorkspace(t *testing.T) {
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
 					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
 					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
 						BaseURL: "THE_RIGHT_SERVER_URL",
 						Location: tenancyv1alpha1.ClusterWorkspaceLocation{
@@ -885,3 +1117,123 @@ func TestKubeconfigFailBecauseWorkspaceNotFound(t *testing.T) {
 	}
 	applyTest(t, test)
 }
+
+func TestKubeconfigConditionalGet(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: kubeconfigTestData(user),
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			get := func(ifNoneMatch string) (string, int, string) {
+				requestHeader, responseHeader := http.Header{}, http.Header{}
+				var statusCode int
+				ctx := context.WithValue(context.WithValue(ctx, virtualcontext.RequestHeaderKey, requestHeader), virtualcontext.ResponseHeaderKey, responseHeader)
+				if ifNoneMatch != "" {
+					requestHeader.Set("If-None-Match", ifNoneMatch)
+					ctx = context.WithValue(ctx, virtualcontext.ResponseStatusCodeKey, &statusCode)
+				}
+				obj, err := kubeconfigSubResourceStorage.Get(ctx, "foo", nil)
+				require.NoError(t, err)
+				stream, _, _, err := obj.(rest.ResourceStreamer).InputStream(ctx, "", "")
+				require.NoError(t, err)
+				body, err := io.ReadAll(stream)
+				require.NoError(t, err)
+				return responseHeader.Get("ETag"), statusCode, string(body)
+			}
+
+			etag, statusCode, body := get("")
+			require.NotEmpty(t, etag)
+			require.Zero(t, statusCode)
+			require.NotEmpty(t, body)
+
+			notModified, statusCode, body := get(etag)
+			require.Equal(t, http.StatusNotModified, statusCode)
+			require.Empty(t, body, "a not modified response has no body")
+			require.Equal(t, etag, notModified)
+
+			workspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo--1", metav1.GetOptions{})
+			require.NoError(t, err)
+			workspace.Status.BaseURL = "THE_NEW_SERVER_URL"
+			_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Update(ctx, workspace, metav1.UpdateOptions{})
+			require.NoError(t, err)
+
+			modified, statusCode, body := get(etag)
+			require.Zero(t, statusCode, "expected the kubeconfig to be returned after the base URL changed")
+			require.NotEmpty(t, body)
+			require.NotEqual(t, etag, modified)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestKubeconfigNestedWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	testData := kubeconfigTestData(user)
+	testData.clusterWorkspaces[0].Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+
+	nestedWorkspace := func(clusterName, name, baseURL string, phase tenancyv1alpha1.ClusterWorkspacePhaseType) *tenancyv1alpha1.ClusterWorkspace {
+		workspace := testData.clusterWorkspaces[0].DeepCopy()
+		workspace.ObjectMeta = metav1.ObjectMeta{Name: name, ClusterName: clusterName}
+		workspace.Status.BaseURL = baseURL
+		workspace.Status.Phase = phase
+		return workspace
+	}
+	nestedWorkspaces := map[string]*tenancyv1alpha1.ClusterWorkspace{
+		"orgName:foo--1|child":        nestedWorkspace("orgName:foo--1", "child", "THE_CHILD_SERVER_URL", tenancyv1alpha1.ClusterWorkspacePhaseReady),
+		"orgName:foo--1|hidden":       nestedWorkspace("orgName:foo--1", "hidden", "THE_HIDDEN_SERVER_URL", tenancyv1alpha1.ClusterWorkspacePhaseReady),
+		"orgName:foo--1|initializing": nestedWorkspace("orgName:foo--1", "initializing", "THE_INITIALIZING_SERVER_URL", tenancyv1alpha1.ClusterWorkspacePhaseInitializing),
+		"foo--1:child|grandchild":     nestedWorkspace("foo--1:child", "grandchild", "THE_GRANDCHILD_SERVER_URL", tenancyv1alpha1.ClusterWorkspacePhaseReady),
+	}
+	visibleWorkspaces := map[string][]string{
+		"orgName:foo--1": {"child", "initializing"},
+		"foo--1:child":   {"grandchild"},
+	}
+
+	test := TestDescription{
+		TestData: testData,
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			kubeconfigSubResourceStorage.getClusterWorkspace = func(clusterName, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+				workspace, found := nestedWorkspaces[clusterName+"|"+name]
+				if !found {
+					return nil, kerrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+				}
+				return workspace.DeepCopy(), nil
+			}
+			kubeconfigSubResourceStorage.getRuleResolver = func(clusterName string) (authorizer.RuleResolver, error) {
+				return staticRuleResolver{
+					&authorizer.DefaultResourceRuleInfo{Verbs: []string{"get"}, APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"clusterworkspaces"}, ResourceNames: visibleWorkspaces[clusterName]},
+				}, nil
+			}
+
+			for path, expectedServer := range map[string]string{
+				"foo:child":            "THE_CHILD_SERVER_URL",
+				"foo:child:grandchild": "THE_GRANDCHILD_SERVER_URL",
+			} {
+				response, err := kubeconfigSubResourceStorage.Get(ctx, path, nil)
+				require.NoError(t, err, "failed to get the kubeconfig of %s", path)
+				config, err := clientcmd.Load([]byte(response.(KubeConfig)))
+				require.NoError(t, err)
+				require.Equal(t, "personal/"+path, config.CurrentContext)
+				cluster := config.Clusters["personal/"+path]
+				require.NotNil(t, cluster, "expected a cluster for %s", path)
+				require.Equal(t, expectedServer, cluster.Server)
+			}
+
+			for _, path := range []string{"foo:hidden", "foo:unknown", "foo:child:hidden", "foo:", "unknown:child"} {
+				_, err := kubeconfigSubResourceStorage.Get(ctx, path, nil)
+				require.True(t, kerrors.IsNotFound(err), "expected %s to be not found, got %v", path, err)
+			}
+
+			_, err := kubeconfigSubResourceStorage.Get(ctx, "foo:initializing:child", nil)
+			require.True(t, kerrors.IsConflict(err), "expected Conflict for a child of a workspace which is not ready, got %v", err)
+		},
+	}
+	applyTest(t, test)
+}
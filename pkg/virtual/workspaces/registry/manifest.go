@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// workspaceManifestHeaderPrefix starts the first line of the manifests of the export subresource.
+	workspaceManifestHeaderPrefix = "# tenancy.kcp.dev/workspace-manifest: "
+	// workspaceManifestVersion is the version of the manifest format. It changes with incompatible changes
+	// of the format, and the import subresource rejects the manifests of other versions.
+	workspaceManifestVersion = "v1"
+
+	// systemPrefix is the name prefix of RBAC objects bootstrapped by the system. They are never exported.
+	systemPrefix = "system:"
+)
+
+// exportedResource is a resource whose objects are part of the manifest of a workspace.
+type exportedResource struct {
+	schema.GroupVersionResource
+	kind string
+
+	// skip tells whether an object is left out of the manifest, e.g. because it is bootstrapped by the system.
+	skip func(obj *unstructured.Unstructured) bool
+}
+
+// reference returns how the given object of the resource is referenced in the status of an import.
+func (r exportedResource) reference(obj *unstructured.Unstructured) string {
+	ref := r.Resource
+	if r.Group != "" {
+		ref += "." + r.Group
+	}
+	if obj.GetNamespace() != "" {
+		return ref + "/" + obj.GetNamespace() + "/" + obj.GetName()
+	}
+	return ref + "/" + obj.GetName()
+}
+
+func hasSystemPrefix(obj *unstructured.Unstructured) bool {
+	return strings.HasPrefix(obj.GetName(), systemPrefix) || isInSystemNamespace(obj)
+}
+
+func isInSystemNamespace(obj *unstructured.Unstructured) bool {
+	return strings.HasPrefix(obj.GetNamespace(), "kube-")
+}
+
+// exportedResources are the resources of the manifest of a workspace, in the order they are imported.
+// CustomResourceDefinitions and namespaces come first, such that the objects depending on them can be created.
+var exportedResources = []exportedResource{
+	{
+		GroupVersionResource: schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+		kind:                 "CustomResourceDefinition",
+		skip:                 func(*unstructured.Unstructured) bool { return false },
+	},
+	{
+		GroupVersionResource: corev1.SchemeGroupVersion.WithResource("namespaces"),
+		kind:                 "Namespace",
+		skip: func(obj *unstructured.Unstructured) bool {
+			return strings.HasPrefix(obj.GetName(), "kube-")
+		},
+	},
+	{
+		GroupVersionResource: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+		kind:                 "ClusterRole",
+		skip:                 hasSystemPrefix,
+	},
+	{
+		GroupVersionResource: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+		kind:                 "ClusterRoleBinding",
+		skip:                 hasSystemPrefix,
+	},
+	{
+		GroupVersionResource: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"},
+		kind:                 "Role",
+		skip:                 hasSystemPrefix,
+	},
+	{
+		GroupVersionResource: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+		kind:                 "RoleBinding",
+		skip:                 hasSystemPrefix,
+	},
+	{
+		GroupVersionResource: corev1.SchemeGroupVersion.WithResource("configmaps"),
+		kind:                 "ConfigMap",
+		skip: func(obj *unstructured.Unstructured) bool {
+			// the root CA is published into every namespace by the system
+			return obj.GetName() == "kube-root-ca.crt" || isInSystemNamespace(obj)
+		},
+	},
+}
+
+// exportedSecrets is the resource of secrets, which are only exported on request.
+var exportedSecrets = exportedResource{
+	GroupVersionResource: corev1.SchemeGroupVersion.WithResource("secrets"),
+	kind:                 "Secret",
+	skip: func(obj *unstructured.Unstructured) bool {
+		// service account tokens are bound to the service accounts of the exported workspace
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return corev1.SecretType(secretType) == corev1.SecretTypeServiceAccountToken || isInSystemNamespace(obj)
+	},
+}
+
+// exportedResourceFor returns the exported resource of the given object, if any.
+func exportedResourceFor(obj *unstructured.Unstructured) (exportedResource, bool) {
+	gvk := obj.GroupVersionKind()
+	for _, resource := range exportedResources {
+		if resource.GroupVersion() == gvk.GroupVersion() && resource.kind == gvk.Kind {
+			return resource, true
+		}
+	}
+	if exportedSecrets.GroupVersion() == gvk.GroupVersion() && exportedSecrets.kind == gvk.Kind {
+		return exportedSecrets, true
+	}
+	return exportedResource{}, false
+}
+
+// portableObject returns the parts of the given object that are meaningful in another workspace, i.e. without
+// status and without the metadata set by the system.
+func portableObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	portable := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for key, value := range obj.Object {
+		if key == "metadata" || key == "status" {
+			continue
+		}
+		portable.Object[key] = runtime.DeepCopyJSONValue(value)
+	}
+	portable.SetName(obj.GetName())
+	portable.SetNamespace(obj.GetNamespace())
+	portable.SetLabels(obj.GetLabels())
+	portable.SetAnnotations(obj.GetAnnotations())
+	return portable
+}
+
+// encodeManifest returns the YAML stream of the given objects, headed by the version of the manifest format.
+func encodeManifest(objs []*unstructured.Unstructured) (string, error) {
+	var manifest strings.Builder
+	manifest.WriteString(workspaceManifestHeaderPrefix + workspaceManifestVersion + "\n")
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return "", err
+		}
+		manifest.WriteString("---\n")
+		manifest.Write(data)
+	}
+	return manifest.String(), nil
+}
+
+// decodeManifest returns the portable objects of the given manifest. It fails if the manifest is not of the
+// supported version, or if it holds objects of other resources than the exported ones.
+func decodeManifest(manifest string) ([]*unstructured.Unstructured, error) {
+	header := strings.TrimSpace(strings.SplitN(manifest, "\n", 2)[0])
+	if !strings.HasPrefix(header, workspaceManifestHeaderPrefix) {
+		return nil, fmt.Errorf("missing %q header with the version of the manifest", strings.TrimSpace(workspaceManifestHeaderPrefix))
+	}
+	if version := strings.TrimPrefix(header, workspaceManifestHeaderPrefix); version != workspaceManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %q, expected %q", version, workspaceManifestVersion)
+	}
+
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	for i := 0; ; i++ {
+		var content map[string]interface{}
+		if err := decoder.Decode(&content); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid document %d: %w", i, err)
+		}
+		if len(content) == 0 {
+			continue // e.g. the header
+		}
+		obj := &unstructured.Unstructured{Object: content}
+		if _, ok := exportedResourceFor(obj); !ok {
+			return nil, fmt.Errorf("unsupported kind %q of document %d", obj.GroupVersionKind(), i)
+		}
+		if obj.GetName() == "" {
+			return nil, fmt.Errorf("missing name of document %d", i)
+		}
+		objs = append(objs, portableObject(obj))
+	}
+	return objs, nil
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/kube-openapi/pkg/util/sets"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	workspaceauth "github.com/kcp-dev/kcp/pkg/virtual/workspaces/auth"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// MoveSubresourceREST moves workspaces of the personal scope to another organization.
+type MoveSubresourceREST struct {
+	mainRest *REST
+}
+
+var _ rest.NamedCreater = &MoveSubresourceREST{}
+var _ rest.Scoper = &MoveSubresourceREST{}
+
+// New returns a new WorkspaceMove
+func (s *MoveSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceMove{}
+}
+
+func (s *MoveSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// Create moves the workspace with the given name to the target organization of the WorkspaceMove, and returns
+// the moved workspace. The user must be allowed to delete the workspace in its current organization, and to
+// create workspaces in the target organization.
+//
+// The ClusterWorkspace is recreated in the target organization with the same internal name, labels and owner
+// annotations, together with the RBAC objects that make it part of the personal scope of the user there.
+// The source ClusterWorkspace is marked with the WorkspaceMoving condition first, and deleted last with the
+// Orphan propagation policy, such that the content it holds is not removed. The content is not moved.
+//
+// Each step tolerates having been done before, so a failed move can be retried. Retrying a completed move
+// returns the moved workspace.
+func (s *MoveSubresourceREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("unable to move a workspace without a user on the context"))
+	}
+	if scope := ctx.Value(WorkspacesScopeKey); scope != PersonalScope {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("moving a workspace is only possible in the personal workspaces scope"))
+	}
+
+	move, ok := obj.(*tenancyv1beta1.WorkspaceMove)
+	if !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceMove: %T", obj))
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	orgClusterName, org, err := s.mainRest.extractOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	targetOrgPath := field.NewPath("spec", "targetOrg")
+	targetOrgClusterName := move.Spec.TargetOrg
+	switch {
+	case targetOrgClusterName == "":
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceMove"), name, field.ErrorList{field.Required(targetOrgPath, "")})
+	case targetOrgClusterName == orgClusterName:
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceMove"), name, field.ErrorList{field.Invalid(targetOrgPath, targetOrgClusterName, "the workspace is already in this organization")})
+	}
+	targetOrg, err := s.mainRest.getOrg(targetOrgClusterName)
+	if err != nil {
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceMove"), name, field.ErrorList{field.NotFound(targetOrgPath, targetOrgClusterName)})
+	}
+
+	clusterWorkspace, err := s.mainRest.getInternalClusterWorkspace(ctx, name, nil)
+	if kerrors.IsNotFound(err) {
+		// a previous attempt might have completed the move already
+		if moved, err := s.movedClusterWorkspace(ctx, user, targetOrgClusterName, targetOrg, name); err == nil {
+			return projectCreatedWorkspace(moved, name, "", false), nil
+		}
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed, err := isAllowed(org.workspaceReviewerProvider.ForVerb("delete"), user, clusterWorkspace.Name); err != nil {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, err)
+	} else if !allowed {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("User %s doesn't have the permission to delete workspace %s in organization %s", user.GetName(), name, orgClusterName))
+	}
+	if allowed, err := isAllowed(targetOrg.workspaceReviewerProvider.ForVerb("create"), user, ""); err != nil {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, err)
+	} else if !allowed {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("User %s doesn't have the permission to create workspaces in organization %s", user.GetName(), targetOrgClusterName))
+	}
+
+	moved, err := targetOrg.clusterWorkspaceClient.Get(ctx, clusterWorkspace.Name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		if err := s.mainRest.checkWorkspaceQuota(ctx, targetOrgClusterName, targetOrg, user, name); err != nil {
+			return nil, err
+		}
+		moved = nil
+	} else if err != nil {
+		return nil, err
+	} else if !isUserOwner(user, moved) {
+		return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("another workspace named %s already exists in organization %s", clusterWorkspace.Name, targetOrgClusterName))
+	}
+
+	message := fmt.Sprintf("Moving to organization %q.", targetOrgClusterName)
+	if !conditions.IsTrue(clusterWorkspace, tenancyv1alpha1.WorkspaceMoving) || conditions.GetMessage(clusterWorkspace, tenancyv1alpha1.WorkspaceMoving) != message {
+		conditions.Set(clusterWorkspace, &conditionsv1alpha1.Condition{
+			Type:     tenancyv1alpha1.WorkspaceMoving,
+			Status:   corev1.ConditionTrue,
+			Severity: conditionsv1alpha1.ConditionSeverityInfo,
+			Reason:   tenancyv1alpha1.WorkspaceMovingReasonMoveRequested,
+			Message:  message,
+		})
+		if clusterWorkspace, err = org.clusterWorkspaceClient.UpdateStatus(ctx, clusterWorkspace, metav1.UpdateOptions{}); err != nil {
+			if kerrors.IsConflict(err) {
+				return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, err)
+			}
+			return nil, err
+		}
+	}
+
+	if moved == nil {
+		// the clone annotations name siblings in the source organization, which must not be cloned from in the target one
+		annotations := make(map[string]string, len(clusterWorkspace.Annotations))
+		for k, v := range clusterWorkspace.Annotations {
+			if k == tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation || k == tenancyv1alpha1.ClusterWorkspaceCloneIncludeAnnotation {
+				continue
+			}
+			annotations[k] = v
+		}
+		moved, err = targetOrg.clusterWorkspaceClient.Create(ctx, &tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        clusterWorkspace.Name,
+				Labels:      clusterWorkspace.Labels,
+				Annotations: annotations,
+			},
+			Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+				Type: clusterWorkspace.Spec.Type,
+				// the system tags cannot be dropped by moving the workspace
+				Tags:         clusterWorkspace.Spec.Tags,
+				FeatureGates: clusterWorkspace.Spec.FeatureGates,
+			},
+		}, metav1.CreateOptions{})
+		if kerrors.IsAlreadyExists(err) {
+			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("another workspace named %s was concurrently created in organization %s", clusterWorkspace.Name, targetOrgClusterName))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := createOwnerRBAC(ctx, targetOrg, user, name, moved.Name); err != nil {
+		return nil, err
+	}
+
+	orphan := metav1.DeletePropagationOrphan
+	if _, _, err := s.mainRest.Delete(ctx, name, nil, &metav1.DeleteOptions{PropagationPolicy: &orphan}); err != nil && !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	return projectCreatedWorkspace(moved, name, "", false), nil
+}
+
+// movedClusterWorkspace returns the ClusterWorkspace the user owns with the given pretty name in the target org.
+func (s *MoveSubresourceREST) movedClusterWorkspace(ctx context.Context, user kuser.Info, targetOrgClusterName string, targetOrg *Org, prettyName string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	internalName, err := s.mainRest.getInternalNameFromPrettyName(user, targetOrgClusterName, prettyName)
+	if err != nil {
+		return nil, err
+	}
+	clusterWorkspace, err := targetOrg.clusterWorkspaceClient.Get(ctx, internalName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !isUserOwner(user, clusterWorkspace) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), prettyName)
+	}
+	return clusterWorkspace, nil
+}
+
+// isAllowed returns whether the review of the given workspace name allows the user. An evaluation error
+// of the review is returned as error.
+func isAllowed(reviewer workspaceauth.Reviewer, user kuser.Info, workspaceName string) (bool, error) {
+	review, err := reviewer.Review(workspaceName)
+	if err != nil {
+		return false, err
+	}
+	if review.EvaluationError() != "" {
+		return false, errors.New(review.EvaluationError())
+	}
+	return sets.NewString(user.GetGroups()...).HasAny(review.Groups()...) ||
+		sets.NewString(review.Users()...).Has(user.GetName()), nil
+}
+
+// createOwnerRBAC creates the owner and lister ClusterRoles of the workspace with the given internal name in the org,
+// and binds the owner one to the user, labeled like they are when the workspace is created in the org. Existing
+// objects are left untouched.
+func createOwnerRBAC(ctx context.Context, org *Org, user kuser.Info, prettyName, internalName string) error {
+	ownerRoleBindingName := getRoleBindingName(OwnerRoleType, prettyName, user)
+	listerRoleBindingName := getRoleBindingName(ListerRoleType, prettyName, user)
+	for _, clusterRole := range []*rbacv1.ClusterRole{
+		createClusterRole(ownerRoleBindingName, internalName, OwnerRoleType),
+		createClusterRole(listerRoleBindingName, internalName, ListerRoleType),
+	} {
+		clusterRole.Labels[InternalNameLabel] = internalName
+		if _, err := org.rbacClient.ClusterRoles().Create(ctx, clusterRole, metav1.CreateOptions{}); err != nil && !kerrors.IsAlreadyExists(err) {
+			return kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), prettyName, err)
+		}
+	}
+
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ownerRoleBindingName,
+			Labels: map[string]string{
+				InternalNameLabel: internalName,
+				PrettyNameLabel:   prettyName,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "ClusterRole",
+			APIGroup: "rbac.authorization.k8s.io",
+			Name:     ownerRoleBindingName,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind: "User",
+				Name: user.GetName(),
+			},
+		},
+	}
+	if _, err := org.rbacClient.ClusterRoleBindings().Create(ctx, clusterRoleBinding, metav1.CreateOptions{}); err != nil && !kerrors.IsAlreadyExists(err) {
+		return kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), prettyName, err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func ownerBinding(orgName, prettyName, internalName string, user kuser.Info) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        getRoleBindingName(OwnerRoleType, prettyName, user),
+			ClusterName: orgName,
+			Labels: map[string]string{
+				PrettyNameLabel:   prettyName,
+				InternalNameLabel: internalName,
+			},
+		},
+		Subjects: []rbacv1.Subject{{Kind: "User", Name: user.GetName()}},
+	}
+}
+
+func TestMoveWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1"}}
+	otherUser := &kuser.DefaultInfo{Name: "user-2"}
+	allowed := mockReview{users: []string{user.Name}}
+
+	source := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			ClusterName: "root:source",
+			Labels:      map[string]string{"team": "a"},
+			Annotations: map[string]string{
+				tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       user.Name,
+				tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+				tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation:   "bar",
+			},
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+	}
+
+	tests := []struct {
+		name             string
+		targetOrg        string
+		sourceWorkspaces []tenancyv1alpha1.ClusterWorkspace
+		targetWorkspaces []tenancyv1alpha1.ClusterWorkspace
+		bindings         []rbacv1.ClusterRoleBinding
+		sourceReviewer   mockReviewerProvider
+		targetReviewer   mockReviewerProvider
+		expectedErr      func(error) bool
+	}{
+		{
+			name:             "move to another org",
+			targetOrg:        "root:target",
+			sourceWorkspaces: []tenancyv1alpha1.ClusterWorkspace{*source},
+			bindings:         []rbacv1.ClusterRoleBinding{*ownerBinding("root:source", "foo", "foo", user)},
+			sourceReviewer:   mockReviewerProvider{"delete": mockReviewer{"foo": allowed}},
+			targetReviewer:   mockReviewerProvider{"create": mockReviewer{"": allowed}},
+		},
+		{
+			name:      "retry a completed move",
+			targetOrg: "root:target",
+			targetWorkspaces: []tenancyv1alpha1.ClusterWorkspace{{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root:target", Annotations: ownedBy(user)},
+			}},
+			bindings:       []rbacv1.ClusterRoleBinding{*ownerBinding("root:target", "foo", "foo", user)},
+			sourceReviewer: mockReviewerProvider{"delete": mockReviewer{"foo": allowed}},
+			targetReviewer: mockReviewerProvider{"create": mockReviewer{"": allowed}},
+		},
+		{
+			name:             "retry a move interrupted after the copy",
+			targetOrg:        "root:target",
+			sourceWorkspaces: []tenancyv1alpha1.ClusterWorkspace{*source},
+			targetWorkspaces: []tenancyv1alpha1.ClusterWorkspace{{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root:target", Labels: source.Labels, Annotations: ownedBy(user)},
+			}},
+			bindings:       []rbacv1.ClusterRoleBinding{*ownerBinding("root:source", "foo", "foo", user)},
+			sourceReviewer: mockReviewerProvider{"delete": mockReviewer{"foo": allowed}},
+			targetReviewer: mockReviewerProvider{"create": mockReviewer{"": allowed}},
+		},
+		{
+			name:             "not allowed to create workspaces in the target org",
+			targetOrg:        "root:target",
+			sourceWorkspaces: []tenancyv1alpha1.ClusterWorkspace{*source},
+			bindings:         []rbacv1.ClusterRoleBinding{*ownerBinding("root:source", "foo", "foo", user)},
+			sourceReviewer:   mockReviewerProvider{"delete": mockReviewer{"foo": allowed}},
+			targetReviewer:   mockReviewerProvider{"create": mockReviewer{}},
+			expectedErr:      kerrors.IsForbidden,
+		},
+		{
+			name:             "not allowed to delete the workspace in the source org",
+			targetOrg:        "root:target",
+			sourceWorkspaces: []tenancyv1alpha1.ClusterWorkspace{*source},
+			bindings:         []rbacv1.ClusterRoleBinding{*ownerBinding("root:source", "foo", "foo", user)},
+			sourceReviewer:   mockReviewerProvider{"delete": mockReviewer{}},
+			targetReviewer:   mockReviewerProvider{"create": mockReviewer{"": allowed}},
+			expectedErr:      kerrors.IsForbidden,
+		},
+		{
+			name:             "workspace of another user with the same name in the target org",
+			targetOrg:        "root:target",
+			sourceWorkspaces: []tenancyv1alpha1.ClusterWorkspace{*source},
+			targetWorkspaces: []tenancyv1alpha1.ClusterWorkspace{{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root:target", Annotations: ownedBy(otherUser)},
+			}},
+			bindings:       []rbacv1.ClusterRoleBinding{*ownerBinding("root:source", "foo", "foo", user)},
+			sourceReviewer: mockReviewerProvider{"delete": mockReviewer{"foo": allowed}},
+			targetReviewer: mockReviewerProvider{"create": mockReviewer{"": allowed}},
+			expectedErr:    kerrors.IsConflict,
+		},
+		{
+			name:           "unknown workspace",
+			targetOrg:      "root:target",
+			sourceReviewer: mockReviewerProvider{"delete": mockReviewer{"foo": allowed}},
+			targetReviewer: mockReviewerProvider{"create": mockReviewer{"": allowed}},
+			expectedErr:    kerrors.IsNotFound,
+		},
+		{
+			name:             "same org",
+			targetOrg:        "root:source",
+			sourceWorkspaces: []tenancyv1alpha1.ClusterWorkspace{*source},
+			bindings:         []rbacv1.ClusterRoleBinding{*ownerBinding("root:source", "foo", "foo", user)},
+			expectedErr:      kerrors.IsInvalid,
+		},
+		{
+			name:             "unknown org",
+			targetOrg:        "root:unknown",
+			sourceWorkspaces: []tenancyv1alpha1.ClusterWorkspace{*source},
+			bindings:         []rbacv1.ClusterRoleBinding{*ownerBinding("root:source", "foo", "foo", user)},
+			expectedErr:      kerrors.IsInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var bindings []runtime.Object
+			for i := range test.bindings {
+				bindings = append(bindings, &test.bindings[i])
+			}
+			indexClient := fake.NewSimpleClientset(bindings...)
+			kubeInformers := informers.NewSharedInformerFactory(indexClient, controller.NoResyncPeriodFunc())
+			crbInformer := kubeInformers.Rbac().V1().ClusterRoleBindings()
+			require.NoError(t, AddNameIndexers(crbInformer))
+			kubeInformers.Start(ctx.Done())
+			cache.WaitForCacheSync(ctx.Done(), crbInformer.Informer().HasSynced)
+
+			sourceKcpClient := tenancyv1fake.NewSimpleClientset(&tenancyv1alpha1.ClusterWorkspaceList{Items: test.sourceWorkspaces})
+			targetKcpClient := tenancyv1fake.NewSimpleClientset(&tenancyv1alpha1.ClusterWorkspaceList{Items: test.targetWorkspaces})
+			sourceKubeClient := fake.NewSimpleClientset()
+			targetKubeClient := fake.NewSimpleClientset()
+			orgs := map[string]*Org{
+				"root:source": {
+					rbacClient:                sourceKubeClient.RbacV1(),
+					crbInformer:               crbInformer,
+					clusterWorkspaceClient:    sourceKcpClient.TenancyV1alpha1().ClusterWorkspaces(),
+					workspaceQuotaClient:      sourceKcpClient.TenancyV1alpha1().WorkspaceQuotas(),
+					clusterWorkspaceLister:    &mockLister{workspaces: test.sourceWorkspaces},
+					workspaceReviewerProvider: test.sourceReviewer,
+				},
+				"root:target": {
+					rbacClient:                targetKubeClient.RbacV1(),
+					crbInformer:               crbInformer,
+					clusterWorkspaceClient:    targetKcpClient.TenancyV1alpha1().ClusterWorkspaces(),
+					workspaceQuotaClient:      targetKcpClient.TenancyV1alpha1().WorkspaceQuotas(),
+					clusterWorkspaceLister:    &mockLister{workspaces: test.targetWorkspaces},
+					workspaceReviewerProvider: test.targetReviewer,
+				},
+			}
+			storage := &MoveSubresourceREST{
+				mainRest: &REST{
+					getOrg: func(orgName string) (*Org, error) {
+						if org, found := orgs[orgName]; found {
+							return org, nil
+						}
+						return nil, fmt.Errorf("Unknown organization: %s", orgName)
+					},
+					crbInformer: crbInformer,
+				},
+			}
+
+			ctx = apirequest.WithUser(ctx, user)
+			ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, PersonalScope)
+			ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, "root:source")
+
+			obj, err := storage.Create(ctx, "foo", &tenancyv1beta1.WorkspaceMove{Spec: tenancyv1beta1.WorkspaceMoveSpec{TargetOrg: test.targetOrg}}, nil, &metav1.CreateOptions{})
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				require.True(t, test.expectedErr(err), "unexpected error: %v", err)
+				if len(test.sourceWorkspaces) > 0 {
+					_, err := sourceKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+					require.NoError(t, err, "expected the source workspace to be kept")
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			workspace, ok := obj.(*tenancyv1beta1.Workspace)
+			require.True(t, ok, "expected a Workspace, got %T", obj)
+			require.Equal(t, "foo", workspace.Name)
+			require.Equal(t, "foo", workspace.Annotations[InternalNameAnnotation])
+
+			moved, err := targetKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, user.Name, moved.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+			require.NotContains(t, moved.Annotations, tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation)
+
+			_, err = sourceKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.True(t, kerrors.IsNotFound(err), "expected the source workspace to be deleted, got %v", err)
+
+			if len(test.sourceWorkspaces) == 0 {
+				require.Empty(t, targetKubeClient.Actions(), "expected a completed move not to create anything")
+				return
+			}
+			require.Equal(t, source.Labels, moved.Labels)
+			binding, err := targetKubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "foo", user), metav1.GetOptions{})
+			require.NoError(t, err, "expected the owner binding in the target org")
+			require.Equal(t, "foo", binding.Labels[PrettyNameLabel])
+			require.Equal(t, "foo", binding.Labels[InternalNameLabel])
+			_, err = targetKubeClient.RbacV1().ClusterRoles().Get(ctx, getRoleBindingName(ListerRoleType, "foo", user), metav1.GetOptions{})
+			require.NoError(t, err, "expected the lister role in the target org")
+
+			var markedMoving bool
+			for _, action := range sourceKcpClient.Actions() {
+				update, ok := action.(clienttesting.UpdateAction)
+				if !ok || update.GetSubresource() != "status" {
+					continue
+				}
+				if marked := update.GetObject().(*tenancyv1alpha1.ClusterWorkspace); conditions.IsTrue(marked, tenancyv1alpha1.WorkspaceMoving) {
+					require.Contains(t, conditions.GetMessage(marked, tenancyv1alpha1.WorkspaceMoving), "root:target")
+					markedMoving = true
+				}
+			}
+			require.True(t, markedMoving, "expected the source workspace to be marked moving")
+
+			var deleted bool
+			for _, action := range sourceKcpClient.Actions() {
+				if action.GetVerb() == "delete" && action.GetResource().Resource == "clusterworkspaces" {
+					deleted = true
+				}
+			}
+			require.True(t, deleted, "expected the source workspace to be deleted")
+		})
+	}
+}
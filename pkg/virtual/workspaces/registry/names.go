@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/kube-openapi/pkg/util/sets"
+
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/virtual/workspaces"
+)
+
+// NameCollisionPolicy defines how the internal name of a personal workspace is chosen
+// when its pretty name is already used by another ClusterWorkspace of the organization.
+type NameCollisionPolicy string
+
+const (
+	// NameCollisionReject fails the creation with an AlreadyExists error.
+	NameCollisionReject NameCollisionPolicy = "reject"
+	// NameCollisionSuffix appends an increasing --<N> suffix to the pretty name, e.g. workspace1--1, unless
+	// another name generator is configured.
+	NameCollisionSuffix NameCollisionPolicy = "suffix"
+	// NameCollisionRandom appends a random --<suffix> to the pretty name, like metadata.generateName does,
+	// unless another name generator is configured.
+	NameCollisionRandom NameCollisionPolicy = "random"
+)
+
+// NameCollisionPolicies are the supported name collision policies.
+var NameCollisionPolicies sets.String = sets.NewString(string(NameCollisionReject), string(NameCollisionSuffix), string(NameCollisionRandom))
+
+// DefaultReservedNames are the names which cannot be used for workspaces by default, because they are
+// used by the system.
+var DefaultReservedNames = []string{tenancyhelper.RootCluster, "org", "system", "admin"}
+
+// maxNameCollisionAttempts bounds the number of internal names tried for a pretty name, and of names
+// generated for a metadata.generateName.
+const maxNameCollisionAttempts = 10
+
+// collisionNameGenerator returns the generator of the --<suffix> of internal names: the configured one if any,
+// or else the one of the policy.
+func collisionNameGenerator(policy NameCollisionPolicy, generator workspaces.NameGenerator) workspaces.NameGenerator {
+	if generator != nil {
+		return generator
+	}
+	if policy == NameCollisionRandom {
+		return workspaces.RandomNameGenerator{}
+	}
+	return workspaces.SequenceNameGenerator{}
+}
+
+// generateNameGenerator returns the generator of the names of workspaces created with metadata.generateName:
+// the configured one if any, or else a random one.
+func generateNameGenerator(generator workspaces.NameGenerator) workspaces.NameGenerator {
+	if generator != nil {
+		return generator
+	}
+	return workspaces.RandomNameGenerator{}
+}
+
+// internalNameCandidate returns the internal name to try for the given pretty name at the given attempt.
+// The first attempt always uses the pretty name itself.
+func internalNameCandidate(generator workspaces.NameGenerator, prettyName string, attempt int) string {
+	if attempt == 0 {
+		return prettyName
+	}
+	return generator.GenerateName(prettyName+"--", attempt)
+}
+
+// maxSuffixLength returns the length of the longest --<suffix> the policy may append to a pretty name.
+func maxSuffixLength(policy NameCollisionPolicy, generator workspaces.NameGenerator) int {
+	if policy == NameCollisionReject {
+		return 0
+	}
+	return len("--") + collisionNameGenerator(policy, generator).MaxSuffixLength(maxNameCollisionAttempts-1)
+}
+
+// withNamePrefix returns the name with the mandatory prefix of the organization, if not already there.
+func withNamePrefix(prefix, name string) string {
+	if strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
+
+// validateWorkspaceName checks that the pretty name of a personal workspace is a valid
+// DNS-1035 label, since it ends up as the name of the ClusterWorkspace and in its BaseURL,
+// and that it stays one once the name collision policy appended a suffix of up to suffixLength characters.
+func validateWorkspaceName(suffixLength int, name string) field.ErrorList {
+	var errs field.ErrorList
+	fldPath := field.NewPath("metadata", "name")
+
+	if maxLength := validation.DNS1035LabelMaxLength - suffixLength; len(name) > maxLength {
+		errs = append(errs, field.TooLong(fldPath, name, maxLength))
+	}
+	for _, msg := range validation.IsDNS1035Label(name) {
+		if msg == validation.MaxLenError(validation.DNS1035LabelMaxLength) {
+			// already reported above, taking the suffix into account
+			continue
+		}
+		errs = append(errs, field.Invalid(fldPath, name, msg))
+	}
+	return errs
+}
+
+// validateReservedName checks that the pretty name of a workspace is neither reserved nor the name of its
+// organization. It is checked before the name collision policy applies, such that a reserved name is never
+// turned into an internal name with a suffix.
+func validateReservedName(reservedNames sets.String, orgClusterName, name string, fldPath *field.Path) field.ErrorList {
+	if reservedNames.Has(name) {
+		return field.ErrorList{field.Forbidden(fldPath, fmt.Sprintf("%q is a reserved workspace name", name))}
+	}
+	if _, orgName, err := tenancyhelper.ParseLogicalClusterName(orgClusterName); err == nil && name == orgName {
+		return field.ErrorList{field.Forbidden(fldPath, fmt.Sprintf("%q is the name of the organization", name))}
+	}
+	return nil
+}
@@ -31,7 +31,7 @@ import (
 
 // CreateAndStartOrg creates an Org struct that contains all the required clients and caches to retrieve user workspaces inside an org
 // As part of an Org, a WorkspaceAuthCache is created and ensured to be started.
-func CreateAndStartOrg(orgRBACClient rbacv1client.RbacV1Interface, orgClusteWorkspaceClient tenancyclient.ClusterWorkspaceInterface, orgRBACInformers rbacinformers.Interface, orgCRBInformer rbacinformers.ClusterRoleBindingInformer, orgClusterWorkspaceInformer workspaceinformer.ClusterWorkspaceInformer) *Org {
+func CreateAndStartOrg(orgRBACClient rbacv1client.RbacV1Interface, orgClusteWorkspaceClient tenancyclient.ClusterWorkspaceInterface, orgWorkspaceQuotaClient tenancyclient.WorkspaceQuotaInterface, orgRBACInformers rbacinformers.Interface, orgCRBInformer rbacinformers.ClusterRoleBindingInformer, orgClusterWorkspaceInformer workspaceinformer.ClusterWorkspaceInformer) *Org {
 	orgSubjectLocator := frameworkrbac.NewSubjectLocator(orgRBACInformers)
 	orgReviewerProvider := workspaceauth.NewAuthorizerReviewerProvider(orgSubjectLocator)
 
@@ -48,6 +48,7 @@ func CreateAndStartOrg(orgRBACClient rbacv1client.RbacV1Interface, orgClusteWork
 		crbLister:                 orgCRBInformer.Lister(),
 		workspaceReviewerProvider: orgReviewerProvider,
 		clusterWorkspaceClient:    orgClusteWorkspaceClient,
+		workspaceQuotaClient:      orgWorkspaceQuotaClient,
 		clusterWorkspaceLister:    orgWorkspaceAuthorizationCache,
 		stopCh:                    make(chan struct{}),
 		authCache:                 orgWorkspaceAuthorizationCache,
@@ -63,6 +64,8 @@ type Org struct {
 	crbInformer            rbacinformers.ClusterRoleBindingInformer
 	crbLister              rbacv1listers.ClusterRoleBindingLister
 	clusterWorkspaceClient tenancyclient.ClusterWorkspaceInterface
+	// workspaceQuotaClient allows listing the WorkspaceQuotas limiting the workspaces users can own in the org.
+	workspaceQuotaClient tenancyclient.WorkspaceQuotaInterface
 
 	// workspaceReviewerProvider allow getting a reviewer that checks
 	// permissions for a given verb to workspaces
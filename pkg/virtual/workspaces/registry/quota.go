@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/kube-openapi/pkg/util/sets"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// workspaceQuotaLimit returns the maximum number of workspaces the user can own according to
+// the given quotas, and whether any of them applies to the user at all. A quota applies to a user
+// listed in it, or member of any of its groups. The highest limit of the applying quotas wins.
+func workspaceQuotaLimit(quotas []tenancyv1alpha1.WorkspaceQuota, user kuser.Info) (int32, bool) {
+	userGroups := sets.NewString(user.GetGroups()...)
+	var limit int32
+	found := false
+	for _, quota := range quotas {
+		if !sets.NewString(quota.Spec.Users...).Has(user.GetName()) && !userGroups.HasAny(quota.Spec.Groups...) {
+			continue
+		}
+		if !found || quota.Spec.MaxWorkspaces > limit {
+			limit = quota.Spec.MaxWorkspaces
+		}
+		found = true
+	}
+	return limit, found
+}
+
+// ownedWorkspaceCount returns the number of ClusterWorkspaces of the org the user is the owner of.
+func (s *REST) ownedWorkspaceCount(org *Org, user kuser.Info) (int, error) {
+	clusterWorkspaceList, err := org.clusterWorkspaceLister.List(withoutGroupsWhenPersonal(user, PersonalScope), labels.Everything())
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range clusterWorkspaceList.Items {
+		if isUserOwner(user, &clusterWorkspaceList.Items[i]) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// checkWorkspaceQuota returns a Forbidden error if the user already owns as many workspaces in the org
+// as the WorkspaceQuotas of the org allow. Workspaces being created concurrently are not accounted for,
+// so the quota might be slightly exceeded by parallel creations.
+func (s *REST) checkWorkspaceQuota(ctx context.Context, orgClusterName string, org *Org, user kuser.Info, workspaceName string) error {
+	quotas, err := org.workspaceQuotaClient.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspaceName, fmt.Errorf("unable to check the workspace quotas: %w", err))
+	}
+	limit, found := workspaceQuotaLimit(quotas.Items, user)
+	if !found {
+		return nil
+	}
+	count, err := s.ownedWorkspaceCount(org, user)
+	if err != nil {
+		return kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspaceName, fmt.Errorf("unable to check the workspace quotas: %w", err))
+	}
+	if count >= int(limit) {
+		return kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspaceName, fmt.Errorf("user %q already owns %d workspaces, which reaches the quota of %d workspaces", user.GetName(), count, limit))
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestWorkspaceQuotaLimit(t *testing.T) {
+	quota := func(max int32, users, groups []string) tenancyv1alpha1.WorkspaceQuota {
+		return tenancyv1alpha1.WorkspaceQuota{Spec: tenancyv1alpha1.WorkspaceQuotaSpec{Users: users, Groups: groups, MaxWorkspaces: max}}
+	}
+	user := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1", "team-2"}}
+
+	for _, tc := range []struct {
+		name          string
+		quotas        []tenancyv1alpha1.WorkspaceQuota
+		expectedLimit int32
+		expectedFound bool
+	}{
+		{
+			name: "no quota",
+		},
+		{
+			name:   "quotas of other users and groups don't apply",
+			quotas: []tenancyv1alpha1.WorkspaceQuota{quota(1, []string{"user-2"}, []string{"team-3"})},
+		},
+		{
+			name:          "user quota",
+			quotas:        []tenancyv1alpha1.WorkspaceQuota{quota(2, []string{"user-2", "user-1"}, nil)},
+			expectedLimit: 2,
+			expectedFound: true,
+		},
+		{
+			name:          "zero quota forbids any workspace",
+			quotas:        []tenancyv1alpha1.WorkspaceQuota{quota(0, []string{"user-1"}, nil)},
+			expectedLimit: 0,
+			expectedFound: true,
+		},
+		{
+			name:          "group quota",
+			quotas:        []tenancyv1alpha1.WorkspaceQuota{quota(3, nil, []string{"team-2"})},
+			expectedLimit: 3,
+			expectedFound: true,
+		},
+		{
+			name: "highest limit of the quotas of the user and all its groups wins",
+			quotas: []tenancyv1alpha1.WorkspaceQuota{
+				quota(2, []string{"user-1"}, nil),
+				quota(5, nil, []string{"team-1"}),
+				quota(4, nil, []string{"team-2"}),
+				quota(10, nil, []string{"team-3"}),
+			},
+			expectedLimit: 5,
+			expectedFound: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limit, found := workspaceQuotaLimit(tc.quotas, user)
+			assert.Equal(t, tc.expectedFound, found)
+			assert.Equal(t, tc.expectedLimit, limit)
+		})
+	}
+}
+
+func ownedWorkspacesTestData(user kuser.Info, quotas []tenancyv1alpha1.WorkspaceQuota) TestData {
+	testData := TestData{
+		user:             user,
+		scope:            PersonalScope,
+		orgName:          "orgName",
+		reviewerProvider: mockReviewerProvider{"get": mockReviewer{}},
+		workspaceQuotas:  quotas,
+		clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "orgName", Annotations: ownedBy(user)}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "bar", ClusterName: "orgName", Annotations: ownedBy(user)}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "shared", ClusterName: "orgName"}},
+		},
+	}
+	for _, name := range []string{"foo", "bar"} {
+		testData.clusterRoleBindings = append(testData.clusterRoleBindings, rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        getRoleBindingName(OwnerRoleType, name, user),
+				ClusterName: "orgName",
+				Labels: map[string]string{
+					PrettyNameLabel:   name,
+					InternalNameLabel: name,
+				},
+			},
+			Subjects: []rbacv1.Subject{{Kind: "User", Name: user.GetName()}},
+		})
+	}
+	// the user can only list the shared workspace, it doesn't count in its quota.
+	testData.clusterRoleBindings = append(testData.clusterRoleBindings, rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        getRoleBindingName(ListerRoleType, "shared", user),
+			ClusterName: "orgName",
+			Labels: map[string]string{
+				PrettyNameLabel:   "shared",
+				InternalNameLabel: "shared",
+			},
+		},
+		Subjects: []rbacv1.Subject{{Kind: "User", Name: user.GetName()}},
+	})
+	return testData
+}
+
+func TestCreateWorkspaceQuotaReached(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: ownedWorkspacesTestData(user, []tenancyv1alpha1.WorkspaceQuota{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-group-quota"},
+				Spec:       tenancyv1alpha1.WorkspaceQuotaSpec{Groups: []string{"test-group"}, MaxWorkspaces: 2},
+			},
+		}),
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "baz"}}
+			_, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
+			require.Error(t, err)
+			assert.True(t, kerrors.IsForbidden(err), "expected a forbidden error, got %v", err)
+
+			_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "baz", metav1.GetOptions{})
+			assert.True(t, kerrors.IsNotFound(err), "the ClusterWorkspace should not have been created")
+			_, err = kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "baz", user), metav1.GetOptions{})
+			assert.True(t, kerrors.IsNotFound(err), "the owner ClusterRoleBinding should not have been created")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceUnderQuota(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: ownedWorkspacesTestData(user, []tenancyv1alpha1.WorkspaceQuota{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user-quota"},
+				Spec:       tenancyv1alpha1.WorkspaceQuotaSpec{Users: []string{"test-user"}, MaxWorkspaces: 2},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-group-quota"},
+				Spec:       tenancyv1alpha1.WorkspaceQuotaSpec{Groups: []string{"test-group"}, MaxWorkspaces: 3},
+			},
+		}),
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "baz"}}
+			_, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
+			require.NoError(t, err, "the group quota should allow a third workspace")
+
+			_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "baz", metav1.GetOptions{})
+			require.NoError(t, err)
+		},
+	}
+	applyTest(t, test)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+)
+
+// RateLimit is the token bucket limiting the workspace creations of a user.
+type RateLimit struct {
+	// QPS is the sustained number of creations per second. Zero disables the limit.
+	QPS float64
+	// Burst is the number of creations that can be done at once.
+	Burst int
+}
+
+func (l RateLimit) limit() rate.Limit {
+	if l.QPS <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(l.QPS)
+}
+
+// CreateRateLimiter limits the rate of workspace creations with a token bucket per user name.
+// The limit of a user is the most permissive of the limits of their groups, or the default
+// limit if none of their groups has one.
+type CreateRateLimiter struct {
+	defaultLimit RateLimit
+	groupLimits  map[string]RateLimit
+
+	lock     sync.Mutex
+	limiters map[string]*rate.Limiter
+
+	// now is time.Now, overridden in tests.
+	now func() time.Time
+}
+
+// NewCreateRateLimiter returns a CreateRateLimiter with the given default limit and per-group limits.
+func NewCreateRateLimiter(defaultLimit RateLimit, groupLimits map[string]RateLimit) *CreateRateLimiter {
+	return &CreateRateLimiter{
+		defaultLimit: defaultLimit,
+		groupLimits:  groupLimits,
+		limiters:     map[string]*rate.Limiter{},
+		now:          time.Now,
+	}
+}
+
+// limitFor returns the limit of the user.
+func (l *CreateRateLimiter) limitFor(user kuser.Info) RateLimit {
+	limit, found := RateLimit{}, false
+	for _, group := range user.GetGroups() {
+		groupLimit, ok := l.groupLimits[group]
+		if !ok {
+			continue
+		}
+		if !found || groupLimit.limit() > limit.limit() || (groupLimit.limit() == limit.limit() && groupLimit.Burst > limit.Burst) {
+			limit = groupLimit
+		}
+		found = true
+	}
+	if !found {
+		return l.defaultLimit
+	}
+	return limit
+}
+
+// Allow takes a token from the bucket of the user. If none is left, it returns a TooManyRequests error
+// telling when to retry.
+func (l *CreateRateLimiter) Allow(user kuser.Info) error {
+	if l == nil {
+		return nil
+	}
+	limit := l.limitFor(user)
+	if limit.QPS <= 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	limiter, found := l.limiters[user.GetName()]
+	if !found {
+		limiter = rate.NewLimiter(limit.limit(), limit.Burst)
+		l.limiters[user.GetName()] = limiter
+	} else if limiter.Limit() != limit.limit() || limiter.Burst() != limit.Burst {
+		// the groups of the user changed
+		limiter.SetLimitAt(now, limit.limit())
+		limiter.SetBurstAt(now, limit.Burst)
+	}
+
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return kerrors.NewTooManyRequests(fmt.Sprintf("user %q is not allowed to create workspaces", user.GetName()), 0)
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return kerrors.NewTooManyRequests(fmt.Sprintf("user %q exceeded the rate of %g workspace creations per second", user.GetName(), limit.QPS), int(math.Ceil(delay.Seconds())))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestCreateRateLimiter(t *testing.T) {
+	alice := &kuser.DefaultInfo{Name: "alice", Groups: []string{"team"}}
+	bob := &kuser.DefaultInfo{Name: "bob", Groups: []string{"team", "ci"}}
+	admin := &kuser.DefaultInfo{Name: "admin", Groups: []string{"team", "admins"}}
+
+	limiter := NewCreateRateLimiter(RateLimit{QPS: 0.1, Burst: 2}, map[string]RateLimit{
+		"ci":     {QPS: 1, Burst: 5},
+		"admins": {},
+	})
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	t.Run("burst then retry after", func(t *testing.T) {
+		require.NoError(t, limiter.Allow(alice))
+		require.NoError(t, limiter.Allow(alice))
+		err := limiter.Allow(alice)
+		require.True(t, kerrors.IsTooManyRequests(err), "expected 429, got %v", err)
+		retryAfter, ok := kerrors.SuggestsClientDelay(err)
+		require.True(t, ok)
+		require.Equal(t, 10, retryAfter)
+
+		now = now.Add(5 * time.Second)
+		err = limiter.Allow(alice)
+		require.True(t, kerrors.IsTooManyRequests(err), "denied creations should not consume tokens")
+		retryAfter, _ = kerrors.SuggestsClientDelay(err)
+		require.Equal(t, 5, retryAfter)
+
+		now = now.Add(5 * time.Second)
+		require.NoError(t, limiter.Allow(alice))
+	})
+
+	t.Run("users have their own bucket and group overrides", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			require.NoError(t, limiter.Allow(bob), "creation %d", i)
+		}
+		err := limiter.Allow(bob)
+		require.True(t, kerrors.IsTooManyRequests(err), "expected 429, got %v", err)
+		retryAfter, _ := kerrors.SuggestsClientDelay(err)
+		require.Equal(t, 1, retryAfter)
+	})
+
+	t.Run("zero qps disables the limit", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			require.NoError(t, limiter.Allow(admin))
+		}
+	})
+
+	t.Run("nil limiter", func(t *testing.T) {
+		var limiter *CreateRateLimiter
+		require.NoError(t, limiter.Allow(alice))
+	})
+}
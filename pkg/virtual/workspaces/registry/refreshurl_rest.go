@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// RefreshURLSubresourceREST requests the URL of workspaces to be recomputed from the current address of their shard.
+type RefreshURLSubresourceREST struct {
+	mainRest *REST
+}
+
+var _ rest.NamedCreater = &RefreshURLSubresourceREST{}
+var _ rest.Scoper = &RefreshURLSubresourceREST{}
+
+// NewRefreshURLSubresourceREST returns the storage of the refresh-url subresource.
+func NewRefreshURLSubresourceREST(mainRest *REST) *RefreshURLSubresourceREST {
+	return &RefreshURLSubresourceREST{mainRest: mainRest}
+}
+
+// New returns a new WorkspaceURLRefresh
+func (s *RefreshURLSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceURLRefresh{}
+}
+
+func (s *RefreshURLSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// Create sets the refresh-url annotation on the ClusterWorkspace of the workspace with the given name and returns
+// the workspace. The user must be allowed to update the workspace. The workspace scheduler then recomputes the URL
+// of the workspace without reassigning its shard, and sets the WorkspaceURLRefreshed condition if it changed.
+//
+// Refreshing a workspace whose refresh is still pending is a no-op. Workspaces which are not scheduled yet have
+// no URL to refresh.
+func (s *RefreshURLSubresourceREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("unable to refresh the URL of a workspace without a user on the context"))
+	}
+
+	if _, ok := obj.(*tenancyv1beta1.WorkspaceURLRefresh); !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceURLRefresh: %T", obj))
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	orgClusterName, org, err := s.mainRest.extractOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterWorkspace, err := s.mainRest.getInternalClusterWorkspace(ctx, name, nil)
+	if kerrors.IsNotFound(err) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed, err := isAllowed(org.workspaceReviewerProvider.ForVerb("update"), user, clusterWorkspace.Name); err != nil {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, err)
+	} else if !allowed {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("User %s doesn't have the permission to update workspace %s in organization %s", user.GetName(), name, orgClusterName))
+	}
+
+	if clusterWorkspace.Status.Location.Current == "" {
+		return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("workspace is not scheduled onto a shard yet"))
+	}
+	if _, pending := clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation]; pending {
+		return projectCreatedWorkspace(clusterWorkspace, name, "", false), nil
+	}
+
+	updated := clusterWorkspace.DeepCopy()
+	updated.Annotations = make(map[string]string, len(clusterWorkspace.Annotations)+1)
+	for k, v := range clusterWorkspace.Annotations {
+		updated.Annotations[k] = v
+	}
+	updated.Annotations[tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation] = "true"
+	if updated, err = org.clusterWorkspaceClient.Update(ctx, updated, metav1.UpdateOptions{DryRun: options.DryRun}); err != nil {
+		if kerrors.IsConflict(err) {
+			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, err)
+		}
+		return nil, err
+	}
+
+	return projectCreatedWorkspace(updated, name, "", false), nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestRefreshWorkspaceURL(t *testing.T) {
+	user := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1"}}
+	allowed := mockReview{users: []string{user.Name}}
+
+	pendingAnnotations := func() map[string]string {
+		annotations := ownedBy(user)
+		annotations[tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation] = "true"
+		return annotations
+	}
+
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		shard        string
+		reviewer     mockReviewerProvider
+		expectUpdate bool
+		expectedErr  func(error) bool
+	}{
+		{
+			name:         "refresh",
+			annotations:  ownedBy(user),
+			shard:        "boston",
+			reviewer:     mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+			expectUpdate: true,
+		},
+		{
+			name:        "refresh already pending",
+			annotations: pendingAnnotations(),
+			shard:       "boston",
+			reviewer:    mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+		},
+		{
+			name:        "not scheduled",
+			annotations: ownedBy(user),
+			reviewer:    mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+			expectedErr: kerrors.IsConflict,
+		},
+		{
+			name:        "not allowed to update the workspace",
+			annotations: ownedBy(user),
+			shard:       "boston",
+			reviewer:    mockReviewerProvider{"update": mockReviewer{}},
+			expectedErr: kerrors.IsForbidden,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			indexClient := fake.NewSimpleClientset(ownerBinding("root:org", "foo", "foo", user))
+			kubeInformers := informers.NewSharedInformerFactory(indexClient, controller.NoResyncPeriodFunc())
+			crbInformer := kubeInformers.Rbac().V1().ClusterRoleBindings()
+			require.NoError(t, AddNameIndexers(crbInformer))
+			kubeInformers.Start(ctx.Done())
+			cache.WaitForCacheSync(ctx.Done(), crbInformer.Informer().HasSynced)
+
+			workspaces := []tenancyv1alpha1.ClusterWorkspace{{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root:org", Annotations: test.annotations},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+					BaseURL:  "https://shard/clusters/root:org:foo",
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: test.shard},
+				},
+			}}
+			kcpClient := tenancyv1fake.NewSimpleClientset(&tenancyv1alpha1.ClusterWorkspaceList{Items: workspaces})
+			org := &Org{
+				rbacClient:                fake.NewSimpleClientset().RbacV1(),
+				crbInformer:               crbInformer,
+				clusterWorkspaceClient:    kcpClient.TenancyV1alpha1().ClusterWorkspaces(),
+				clusterWorkspaceLister:    &mockLister{workspaces: workspaces},
+				workspaceReviewerProvider: test.reviewer,
+			}
+			storage := NewRefreshURLSubresourceREST(&REST{
+				getOrg: func(orgName string) (*Org, error) {
+					if orgName == "root:org" {
+						return org, nil
+					}
+					return nil, fmt.Errorf("Unknown organization: %s", orgName)
+				},
+				crbInformer: crbInformer,
+			})
+
+			ctx = apirequest.WithUser(ctx, user)
+			ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, PersonalScope)
+			ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, "root:org")
+
+			obj, err := storage.Create(ctx, "foo", &tenancyv1beta1.WorkspaceURLRefresh{}, nil, &metav1.CreateOptions{})
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				require.True(t, test.expectedErr(err), "unexpected error: %v", err)
+				require.Empty(t, kcpClient.Actions()[1:], "expected the ClusterWorkspace not to be changed")
+				return
+			}
+			require.NoError(t, err)
+
+			workspace, ok := obj.(*tenancyv1beta1.Workspace)
+			require.True(t, ok, "expected a Workspace, got %T", obj)
+			require.Equal(t, "foo", workspace.Name)
+
+			if !test.expectUpdate {
+				require.Empty(t, kcpClient.Actions()[1:], "expected the ClusterWorkspace not to be changed")
+			}
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, user.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation], "expected the other annotations to be kept")
+			require.Contains(t, clusterWorkspace.Annotations, tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// RenameSubresourceREST renames workspaces of the personal scope.
+type RenameSubresourceREST struct {
+	mainRest *REST
+}
+
+var _ rest.NamedCreater = &RenameSubresourceREST{}
+var _ rest.Scoper = &RenameSubresourceREST{}
+
+// New returns a new WorkspaceRename
+func (s *RenameSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceRename{}
+}
+
+func (s *RenameSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// Create gives the workspace with the given name the new name of the WorkspaceRename, and returns the renamed
+// workspace. The user must be allowed to update the workspace. The new name is validated like the name of a
+// created workspace, including the name prefix of the organization, and is rejected if the user already has
+// a workspace with that name.
+//
+// Only the pretty name changes: the owner RBAC objects are recreated for the new name, and those of the old name
+// are deleted. The backing ClusterWorkspace keeps its internal name, so its URL, owner annotations, conditions
+// and content are untouched.
+//
+// Retrying a completed rename returns the renamed workspace.
+func (s *RenameSubresourceREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("unable to rename a workspace without a user on the context"))
+	}
+	if scope := ctx.Value(WorkspacesScopeKey); scope != PersonalScope {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("renaming a workspace is only possible in the personal workspaces scope"))
+	}
+
+	rename, ok := obj.(*tenancyv1beta1.WorkspaceRename)
+	if !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceRename: %T", obj))
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	orgClusterName, org, err := s.mainRest.extractOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := s.mainRest.namePrefix(orgClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	newNamePath := field.NewPath("spec", "newName")
+	newName := rename.Spec.NewName
+	if newName != "" {
+		newName = withNamePrefix(prefix, newName)
+	}
+	switch {
+	case newName == "":
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceRename"), name, field.ErrorList{field.Required(newNamePath, "")})
+	case newName == name:
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceRename"), name, field.ErrorList{field.Invalid(newNamePath, newName, "the workspace already has this name")})
+	}
+	errs := validateWorkspaceName(maxSuffixLength(s.mainRest.nameCollisionPolicy, s.mainRest.nameGenerator), newName)
+	errs = append(errs, validateReservedName(s.mainRest.reservedNames, orgClusterName, newName, newNamePath)...)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			err.Field = newNamePath.String()
+		}
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceRename"), name, errs)
+	}
+
+	clusterWorkspace, err := s.mainRest.getInternalClusterWorkspace(ctx, name, nil)
+	if kerrors.IsNotFound(err) {
+		// a previous attempt might have completed the rename already
+		if renamed, err := s.mainRest.getInternalClusterWorkspace(ctx, newName, nil); err == nil {
+			return projectCreatedWorkspace(renamed, newName, "", false), nil
+		}
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed, err := isAllowed(org.workspaceReviewerProvider.ForVerb("update"), user, clusterWorkspace.Name); err != nil {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, err)
+	} else if !allowed {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("User %s doesn't have the permission to update workspace %s in organization %s", user.GetName(), name, orgClusterName))
+	}
+
+	if internalName, err := s.mainRest.getInternalNameFromPrettyName(user, orgClusterName, newName); err == nil && internalName != clusterWorkspace.Name {
+		return nil, kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), newName)
+	} else if err != nil && !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+	// the informer might not have seen a concurrently created workspace yet
+	newOwnerRoleBindingName := getRoleBindingName(OwnerRoleType, newName, user)
+	if crb, err := org.rbacClient.ClusterRoleBindings().Get(ctx, newOwnerRoleBindingName, metav1.GetOptions{}); err == nil && crb.Labels[InternalNameLabel] != clusterWorkspace.Name {
+		return nil, kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), newName)
+	} else if err != nil && !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err := createOwnerRBAC(ctx, org, user, newName, clusterWorkspace.Name); err != nil {
+		return nil, err
+	}
+
+	// the lister role of the old name must go too, a workspace created later with that name would rewrite it otherwise
+	oldOwnerRoleBindingName := getRoleBindingName(OwnerRoleType, name, user)
+	if err := org.rbacClient.ClusterRoleBindings().Delete(ctx, oldOwnerRoleBindingName, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+	for _, clusterRoleName := range []string{oldOwnerRoleBindingName, getRoleBindingName(ListerRoleType, name, user)} {
+		if err := org.rbacClient.ClusterRoles().Delete(ctx, clusterRoleName, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return projectCreatedWorkspace(clusterWorkspace, newName, "", false), nil
+}
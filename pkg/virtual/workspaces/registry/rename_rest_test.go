@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kube-openapi/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestRenameWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1"}}
+	allowed := mockReview{users: []string{user.Name}}
+
+	workspace := tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root:org", Annotations: ownedBy(user)},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase:   tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			BaseURL: "https://shard/clusters/root:org:foo",
+		},
+	}
+	oldRBAC := []runtime.Object{
+		ownerBinding("root:org", "foo", "foo", user),
+		createClusterRole(getRoleBindingName(OwnerRoleType, "foo", user), "foo", OwnerRoleType),
+		createClusterRole(getRoleBindingName(ListerRoleType, "foo", user), "foo", ListerRoleType),
+	}
+
+	tests := []struct {
+		name        string
+		newName     string
+		bindings    []rbacv1.ClusterRoleBinding
+		rbac        []runtime.Object
+		reviewer    mockReviewerProvider
+		expectedErr func(error) bool
+	}{
+		{
+			name:     "rename",
+			newName:  "bar",
+			bindings: []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", user)},
+			rbac:     oldRBAC,
+			reviewer: mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+		},
+		{
+			name:     "retry a completed rename",
+			newName:  "bar",
+			bindings: []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "bar", "foo", user)},
+			reviewer: mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+		},
+		{
+			name:    "another workspace of the user has the new name",
+			newName: "bar",
+			bindings: []rbacv1.ClusterRoleBinding{
+				*ownerBinding("root:org", "foo", "foo", user),
+				*ownerBinding("root:org", "bar", "bar", user),
+			},
+			rbac:        oldRBAC,
+			reviewer:    mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+			expectedErr: kerrors.IsAlreadyExists,
+		},
+		{
+			name:        "workspace with the new name created concurrently",
+			newName:     "bar",
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", user)},
+			rbac:        append([]runtime.Object{ownerBinding("root:org", "bar", "bar", user)}, oldRBAC...),
+			reviewer:    mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+			expectedErr: kerrors.IsAlreadyExists,
+		},
+		{
+			name:        "not allowed to update the workspace",
+			newName:     "bar",
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", user)},
+			rbac:        oldRBAC,
+			reviewer:    mockReviewerProvider{"update": mockReviewer{}},
+			expectedErr: kerrors.IsForbidden,
+		},
+		{
+			name:        "invalid name",
+			newName:     "Bar_",
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", user)},
+			rbac:        oldRBAC,
+			expectedErr: kerrors.IsInvalid,
+		},
+		{
+			name:        "reserved name",
+			newName:     "admin",
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", user)},
+			rbac:        oldRBAC,
+			expectedErr: kerrors.IsInvalid,
+		},
+		{
+			name:        "name of the organization",
+			newName:     "org",
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", user)},
+			rbac:        oldRBAC,
+			expectedErr: kerrors.IsInvalid,
+		},
+		{
+			name:        "same name",
+			newName:     "foo",
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", user)},
+			rbac:        oldRBAC,
+			expectedErr: kerrors.IsInvalid,
+		},
+		{
+			name:        "missing name",
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", user)},
+			rbac:        oldRBAC,
+			expectedErr: kerrors.IsInvalid,
+		},
+		{
+			name:        "unknown workspace",
+			newName:     "bar",
+			reviewer:    mockReviewerProvider{"update": mockReviewer{"foo": allowed}},
+			expectedErr: kerrors.IsNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var bindings []runtime.Object
+			for i := range test.bindings {
+				bindings = append(bindings, &test.bindings[i])
+			}
+			indexClient := fake.NewSimpleClientset(bindings...)
+			kubeInformers := informers.NewSharedInformerFactory(indexClient, controller.NoResyncPeriodFunc())
+			crbInformer := kubeInformers.Rbac().V1().ClusterRoleBindings()
+			require.NoError(t, AddNameIndexers(crbInformer))
+			kubeInformers.Start(ctx.Done())
+			cache.WaitForCacheSync(ctx.Done(), crbInformer.Informer().HasSynced)
+
+			var workspaces []tenancyv1alpha1.ClusterWorkspace
+			if len(test.bindings) > 0 {
+				workspaces = []tenancyv1alpha1.ClusterWorkspace{workspace}
+			}
+			kcpClient := tenancyv1fake.NewSimpleClientset(&tenancyv1alpha1.ClusterWorkspaceList{Items: workspaces})
+			kubeClient := fake.NewSimpleClientset(test.rbac...)
+			org := &Org{
+				rbacClient:                kubeClient.RbacV1(),
+				crbInformer:               crbInformer,
+				clusterWorkspaceClient:    kcpClient.TenancyV1alpha1().ClusterWorkspaces(),
+				clusterWorkspaceLister:    &mockLister{workspaces: workspaces},
+				workspaceReviewerProvider: test.reviewer,
+			}
+			storage := &RenameSubresourceREST{
+				mainRest: &REST{
+					getOrg: func(orgName string) (*Org, error) {
+						if orgName == "root:org" {
+							return org, nil
+						}
+						return nil, fmt.Errorf("Unknown organization: %s", orgName)
+					},
+					crbInformer:   crbInformer,
+					reservedNames: sets.NewString("admin"),
+				},
+			}
+
+			ctx = apirequest.WithUser(ctx, user)
+			ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, PersonalScope)
+			ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, "root:org")
+
+			obj, err := storage.Create(ctx, "foo", &tenancyv1beta1.WorkspaceRename{Spec: tenancyv1beta1.WorkspaceRenameSpec{NewName: test.newName}}, nil, &metav1.CreateOptions{})
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				require.True(t, test.expectedErr(err), "unexpected error: %v", err)
+				if len(test.rbac) > 0 {
+					_, err := kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "foo", user), metav1.GetOptions{})
+					require.NoError(t, err, "expected the owner binding of the old name to be kept")
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			renamed, ok := obj.(*tenancyv1beta1.Workspace)
+			require.True(t, ok, "expected a Workspace, got %T", obj)
+			require.Equal(t, "bar", renamed.Name)
+			require.Equal(t, "foo", renamed.Annotations[InternalNameAnnotation])
+			require.Equal(t, workspace.Status.BaseURL, renamed.Status.URL)
+
+			require.Empty(t, kcpClient.Actions()[1:], "expected the ClusterWorkspace not to be changed")
+
+			if len(test.rbac) == 0 {
+				require.Empty(t, kubeClient.Actions(), "expected a completed rename not to change anything")
+				return
+			}
+			binding, err := kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "bar", user), metav1.GetOptions{})
+			require.NoError(t, err, "expected the owner binding of the new name")
+			require.Equal(t, "bar", binding.Labels[PrettyNameLabel])
+			require.Equal(t, "foo", binding.Labels[InternalNameLabel])
+			for _, roleType := range []RoleType{OwnerRoleType, ListerRoleType} {
+				role, err := kubeClient.RbacV1().ClusterRoles().Get(ctx, getRoleBindingName(roleType, "bar", user), metav1.GetOptions{})
+				require.NoError(t, err, "expected the %s role of the new name", roleType)
+				require.Equal(t, []string{"foo"}, role.Rules[0].ResourceNames)
+
+				_, err = kubeClient.RbacV1().ClusterRoles().Get(ctx, getRoleBindingName(roleType, "foo", user), metav1.GetOptions{})
+				require.True(t, kerrors.IsNotFound(err), "expected the %s role of the old name to be deleted, got %v", roleType, err)
+			}
+			_, err = kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "foo", user), metav1.GetOptions{})
+			require.True(t, kerrors.IsNotFound(err), "expected the owner binding of the old name to be deleted, got %v", err)
+		})
+	}
+}
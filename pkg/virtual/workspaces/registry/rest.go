@@ -20,18 +20,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	rbacv1 "k8s.io/api/rbac/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/authentication/user"
 	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 	rbacinformers "k8s.io/client-go/informers/rbac/v1"
@@ -44,24 +51,58 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/projection"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/authentication/workspacetoken"
+	kcpauthorization "github.com/kcp-dev/kcp/pkg/authorization"
 	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+	"github.com/kcp-dev/kcp/pkg/virtual/workspaces"
 	workspaceauth "github.com/kcp-dev/kcp/pkg/virtual/workspaces/auth"
 	workspacecache "github.com/kcp-dev/kcp/pkg/virtual/workspaces/cache"
 	workspaceprinters "github.com/kcp-dev/kcp/pkg/virtual/workspaces/printers"
 	workspaceutil "github.com/kcp-dev/kcp/pkg/virtual/workspaces/util"
 )
 
+// IdleSinceParameter is the query parameter restricting lists of workspaces to those without activity
+// since the given RFC 3339 time, e.g. ?idle-since=2022-03-01T00:00:00Z.
+const IdleSinceParameter = "idle-since"
+
+// IncludeDeletedParameter is the query parameter adding soft-deleted workspaces to lists of workspaces,
+// e.g. ?include-deleted=true.
+const IncludeDeletedParameter = "include-deleted"
+
 const (
 	OrganizationScope string = "all"
 	PersonalScope     string = "personal"
+	SharedScope       string = "shared"
 	PrettyNameLabel   string = "workspaces.kcp.dev/pretty-name"
 	InternalNameLabel string = "workspaces.kcp.dev/internal-name"
 	PrettyNameIndex   string = "workspace-pretty-name"
 	InternalNameIndex string = "workspace-internal-name"
+	GroupOwnerIndex   string = "workspace-group-owner"
+
+	// InternalNameAnnotation is set on created personal workspaces to the name of the backing ClusterWorkspace.
+	InternalNameAnnotation string = "workspaces.kcp.dev/internal-name"
+
+	// AllOrgs is the org of the path listing the personal workspaces of the user in all the orgs they can access.
+	AllOrgs string = "all"
+	// OrgAnnotation is set on the workspaces listed across all orgs to the logical cluster name of their org.
+	OrgAnnotation string = "workspaces.kcp.dev/org"
 )
 
-var ScopeSet sets.String = sets.NewString(PersonalScope, OrganizationScope)
+var ScopeSet sets.String = sets.NewString(PersonalScope, SharedScope, OrganizationScope)
+
+// scopePredicates tell whether a workspace the user can access is visible in the scope, given whether the user owns it
+// according to the owner annotation of the workspace.
+// The organization scope shows all the workspaces the user can access.
+var scopePredicates = map[string]func(owned bool) bool{
+	PersonalScope: func(owned bool) bool { return owned },
+	SharedScope:   func(owned bool) bool { return !owned },
+}
+
+// selectableFields are the fields Workspaces can be filtered on with a field selector.
+var selectableFields sets.String = sets.NewString("metadata.name", "status.phase")
 
 type WorkspacesScopeKeyType string
 
@@ -81,6 +122,36 @@ type REST struct {
 	// clusterWorkspaceCache is a global cache of cluster workspaces (for all orgs) used by the watcher.
 	clusterWorkspaceCache *workspacecache.ClusterWorkspaceCache
 
+	// nameCollisionPolicy defines the internal name of personal workspaces whose
+	// pretty name is already used by another ClusterWorkspace.
+	nameCollisionPolicy NameCollisionPolicy
+	// nameGenerator generates the names of workspaces created with metadata.generateName, and the suffix of the
+	// internal names chosen by the name collision policy. Nil means the default generators.
+	nameGenerator workspaces.NameGenerator
+
+	// createRateLimiter limits the rate of workspace creations per user. Nil means no limit.
+	createRateLimiter *CreateRateLimiter
+
+	// createPolicyWebhook decides whether workspaces can be created. Nil means every creation is allowed.
+	createPolicyWebhook *CreatePolicyWebhook
+
+	// softDeleteRetention is the time deleted workspaces are kept and can be restored before being deleted
+	// for good. Zero deletes workspaces right away.
+	softDeleteRetention time.Duration
+	// now returns the current time. Defaults to time.Now if nil.
+	now func() time.Time
+
+	// groupOrgs maps group names to the names of the orgs their members see when listing workspaces across all
+	// orgs. Users in none of the groups see all the orgs they can get.
+	groupOrgs map[string][]string
+
+	// reservedNames cannot be used as names of workspaces, like the name of their organization.
+	reservedNames sets.String
+
+	// watchBookmarkInterval is the interval of the Bookmark events of watches allowing them. Zero disables
+	// bookmarks.
+	watchBookmarkInterval time.Duration
+
 	// Allows extended behavior during creation, required
 	createStrategy rest.RESTCreateStrategy
 	// Allows extended behavior during updates, required
@@ -103,6 +174,14 @@ func AddNameIndexers(crbInformer rbacinformers.ClusterRoleBindingInformer) error
 				return []string{lclusterAwareIndexValue(crb.ClusterName, crb.Labels[InternalNameLabel])}, nil
 			}
 
+			return []string{}, nil
+		},
+		GroupOwnerIndex: func(obj interface{}) ([]string, error) {
+			if crb, isCRB := obj.(*rbacv1.ClusterRoleBinding); isCRB &&
+				len(crb.Subjects) == 1 && crb.Subjects[0].Kind == rbacv1.GroupKind && crb.Labels[PrettyNameLabel] != "" {
+				return []string{lclusterAwareIndexValue(crb.ClusterName, crb.Subjects[0].Name)}, nil
+			}
+
 			return []string{}, nil
 		},
 	})
@@ -116,15 +195,64 @@ var _ rest.Lister = &REST{}
 var _ rest.Watcher = &REST{}
 var _ rest.Scoper = &REST{}
 var _ rest.Creater = &REST{}
+var _ rest.Updater = &REST{}
 var _ rest.GracefulDeleter = &REST{}
+var _ rest.CollectionDeleter = &REST{}
+
+// Options configures the workspaces REST storage.
+type Options struct {
+	// NameCollisionPolicy defines the internal name of personal workspaces whose pretty name is already taken.
+	NameCollisionPolicy NameCollisionPolicy
+	// NameGenerator generates the names of workspaces created with metadata.generateName, and the suffix of the
+	// internal names chosen by the name collision policy. Nil means the default generators.
+	NameGenerator workspaces.NameGenerator
+	// TokenGenerator issues the tokens of kubeconfigs requested with credentials=token. Nil rejects such requests.
+	TokenGenerator *workspacetoken.Generator
+	// TokenTTL is the lifetime of the tokens issued by the TokenGenerator.
+	TokenTTL time.Duration
+	// CreateRateLimiter limits the rate of workspace creations per user. Nil means no limit.
+	CreateRateLimiter *CreateRateLimiter
+	// CreatePolicyWebhook decides whether workspaces can be created. Nil means every creation is allowed.
+	CreatePolicyWebhook *CreatePolicyWebhook
+	// SoftDeleteRetention is the time deleted workspaces can be restored before being deleted for good. Zero
+	// deletes workspaces right away.
+	SoftDeleteRetention time.Duration
+	// GroupOrgs maps group names to the names of the orgs their members see when listing workspaces across all orgs.
+	GroupOrgs map[string][]string
+	// ReservedNames cannot be used as names of workspaces.
+	ReservedNames []string
+	// WatchBookmarkInterval is the interval of the Bookmark events of watches allowing them. Zero disables bookmarks.
+	WatchBookmarkInterval time.Duration
+}
+
+// Storage holds the REST storage of workspaces, of their subresources and of workspace batches.
+type Storage struct {
+	Workspaces        *REST
+	Kubeconfig        *KubeconfigSubresourceREST
+	APIResources      *APIResourcesSubresourceREST
+	Authorization     *AuthorizationSubresourceREST
+	Move              *MoveSubresourceREST
+	Rename            *RenameSubresourceREST
+	TransferOwnership *TransferOwnershipSubresourceREST
+	WorkspaceBatches  *WorkspaceBatchREST
+}
 
 // NewREST returns a RESTStorage object that will work against ClusterWorkspace resources in
 // org workspaces, projecting them to the Workspace type.
-func NewREST(rootTenancyClient tenancyclient.TenancyV1alpha1Interface, rootKubeClient kubernetes.Interface, clusterWorkspaceCache *workspacecache.ClusterWorkspaceCache, wilcardsCRBInformer rbacinformers.ClusterRoleBindingInformer, getOrg func(orgClusterName string) (*Org, error)) (*REST, *KubeconfigSubresourceREST) {
+func NewREST(rootTenancyClient tenancyclient.TenancyV1alpha1Interface, rootKubeClient kubernetes.Interface, clusterWorkspaceCache *workspacecache.ClusterWorkspaceCache, wildcardsRbacInformers rbacinformers.Interface, getOrg func(orgClusterName string) (*Org, error), options Options) *Storage {
 	mainRest := &REST{
-		getOrg: getOrg,
-
-		crbInformer:           wilcardsCRBInformer,
+		getOrg:                getOrg,
+		nameCollisionPolicy:   options.NameCollisionPolicy,
+		nameGenerator:         options.NameGenerator,
+		createRateLimiter:     options.CreateRateLimiter,
+		createPolicyWebhook:   options.CreatePolicyWebhook,
+		softDeleteRetention:   options.SoftDeleteRetention,
+		now:                   time.Now,
+		groupOrgs:             options.GroupOrgs,
+		reservedNames:         sets.NewString(options.ReservedNames...),
+		watchBookmarkInterval: options.WatchBookmarkInterval,
+
+		crbInformer:           wildcardsRbacInformers.ClusterRoleBindings(),
 		clusterWorkspaceCache: clusterWorkspaceCache,
 
 		createStrategy: Strategy,
@@ -132,12 +260,51 @@ func NewREST(rootTenancyClient tenancyclient.TenancyV1alpha1Interface, rootKubeC
 
 		TableConvertor: printerstorage.TableConvertor{TableGenerator: printers.NewTableGenerator().With(workspaceprinters.AddWorkspacePrintHandlers)},
 	}
-	return mainRest,
-		&KubeconfigSubresourceREST{
+	return &Storage{
+		Workspaces: mainRest,
+		Kubeconfig: &KubeconfigSubresourceREST{
 			mainRest:             mainRest,
 			rootCoreClient:       rootKubeClient.CoreV1(),
 			workspaceShardClient: rootTenancyClient.WorkspaceShards(),
-		}
+			tokenGenerator:       options.TokenGenerator,
+			tokenTTL:             options.TokenTTL,
+			getClusterWorkspace: func(clusterName, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+				workspace, err := clusterWorkspaceCache.GetWorkspace(clusterName, name)
+				if err != nil {
+					// the cache does not tell a missing workspace apart from other failures
+					return nil, kerrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+				}
+				return workspace, nil
+			},
+			getRuleResolver: func(clusterName string) (authorizer.RuleResolver, error) {
+				return kcpauthorization.NewWorkspaceContentRuleResolver(wildcardsRbacInformers, clusterName)
+			},
+		},
+		APIResources: &APIResourcesSubresourceREST{
+			mainRest:             mainRest,
+			rootCoreClient:       rootKubeClient.CoreV1(),
+			workspaceShardClient: rootTenancyClient.WorkspaceShards(),
+			cache:                utilcache.NewExpiring(),
+		},
+		Authorization: &AuthorizationSubresourceREST{
+			mainRest: mainRest,
+			getRuleResolver: func(clusterName string) (authorizer.RuleResolver, error) {
+				return kcpauthorization.NewWorkspaceContentRuleResolver(wildcardsRbacInformers, clusterName)
+			},
+		},
+		Move: &MoveSubresourceREST{
+			mainRest: mainRest,
+		},
+		Rename: &RenameSubresourceREST{
+			mainRest: mainRest,
+		},
+		TransferOwnership: &TransferOwnershipSubresourceREST{
+			mainRest: mainRest,
+		},
+		WorkspaceBatches: &WorkspaceBatchREST{
+			mainRest: mainRest,
+		},
+	}
 }
 
 // New returns a new ClusterWorkspace
@@ -155,31 +322,69 @@ func (s *REST) NamespaceScoped() bool {
 }
 
 func (s *REST) getPrettyNameFromInternalName(user kuser.Info, orgClusterName, internalName string) (string, error) {
-	list, err := s.crbInformer.Informer().GetIndexer().ByIndex(InternalNameIndex, lclusterAwareIndexValue(orgClusterName, internalName))
+	crb, err := s.getOwnBinding(user, InternalNameIndex, orgClusterName, internalName)
 	if err != nil {
 		return "", err
 	}
-	for _, el := range list {
-		if crb, isCRB := el.(*rbacv1.ClusterRoleBinding); isCRB &&
-			len(crb.Subjects) == 1 && crb.Subjects[0].Name == user.GetName() {
-			return crb.Labels[PrettyNameLabel], nil
-		}
-	}
-	return "", kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), internalName)
+	return crb.Labels[PrettyNameLabel], nil
 }
 
 func (s *REST) getInternalNameFromPrettyName(user kuser.Info, orgClusterName, prettyName string) (string, error) {
-	list, err := s.crbInformer.Informer().GetIndexer().ByIndex(PrettyNameIndex, lclusterAwareIndexValue(orgClusterName, prettyName))
+	crb, err := s.getOwnBinding(user, PrettyNameIndex, orgClusterName, prettyName)
 	if err != nil {
 		return "", err
 	}
+	return crb.Labels[InternalNameLabel], nil
+}
+
+// getOwnBinding returns the binding of a workspace with the given name in the given index whose single subject is
+// the user, or else one of the groups of the user, for the workspaces owned by a group.
+func (s *REST) getOwnBinding(user kuser.Info, index, orgClusterName, name string) (*rbacv1.ClusterRoleBinding, error) {
+	list, err := s.crbInformer.Informer().GetIndexer().ByIndex(index, lclusterAwareIndexValue(orgClusterName, name))
+	if err != nil {
+		return nil, err
+	}
+	groups := sets.NewString(user.GetGroups()...)
+	var groupBinding *rbacv1.ClusterRoleBinding
 	for _, el := range list {
-		if crb, isCRB := el.(*rbacv1.ClusterRoleBinding); isCRB &&
-			len(crb.Subjects) == 1 && crb.Subjects[0].Name == user.GetName() {
-			return crb.Labels[InternalNameLabel], nil
+		crb, isCRB := el.(*rbacv1.ClusterRoleBinding)
+		if !isCRB || len(crb.Subjects) != 1 {
+			continue
+		}
+		if subject := crb.Subjects[0]; subject.Kind == rbacv1.GroupKind {
+			if groupBinding == nil && groups.Has(subject.Name) {
+				groupBinding = crb
+			}
+		} else if subject.Name == user.GetName() {
+			return crb, nil
 		}
 	}
-	return "", kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), prettyName)
+	if groupBinding != nil {
+		return groupBinding, nil
+	}
+	return nil, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
+}
+
+// generateWorkspaceName returns a name made of generateName and a generated suffix which is neither the pretty nor
+// the internal name of a workspace of the organization yet.
+func (s *REST) generateWorkspaceName(orgClusterName, generateName string) (string, error) {
+	indexer := s.crbInformer.Informer().GetIndexer()
+	generator := generateNameGenerator(s.nameGenerator)
+	for attempt := 1; attempt <= maxNameCollisionAttempts; attempt++ {
+		name := generator.GenerateName(generateName, attempt)
+		used := false
+		for _, index := range []string{PrettyNameIndex, InternalNameIndex} {
+			list, err := indexer.ByIndex(index, lclusterAwareIndexValue(orgClusterName, name))
+			if err != nil {
+				return "", err
+			}
+			used = used || len(list) > 0
+		}
+		if !used {
+			return name, nil
+		}
+	}
+	return "", kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), generateName)
 }
 
 func withoutGroupsWhenPersonal(user user.Info, scope string) user.Info {
@@ -194,18 +399,114 @@ func withoutGroupsWhenPersonal(user user.Info, scope string) user.Info {
 	return user
 }
 
+// listAccessible returns the ClusterWorkspaces of the org the user can access in the given scope. In the personal
+// scope, only the access granted to the user counts, or to the groups of the user owning the workspace.
+func (s *REST) listAccessible(org *Org, user kuser.Info, orgClusterName, scope string, selector labels.Selector) (*tenancyv1alpha1.ClusterWorkspaceList, error) {
+	list, err := org.clusterWorkspaceLister.List(withoutGroupsWhenPersonal(user, scope), selector)
+	if err != nil || scope != PersonalScope {
+		return list, err
+	}
+	if owning, err := s.ownsWorkspacesThroughGroups(user, orgClusterName); err != nil || !owning {
+		return list, err
+	}
+	withGroups, err := org.clusterWorkspaceLister.List(user, selector)
+	if err != nil {
+		return nil, err
+	}
+	listed := sets.NewString()
+	for i := range list.Items {
+		listed.Insert(list.Items[i].Name)
+	}
+	for i := range withGroups.Items {
+		if !listed.Has(withGroups.Items[i].Name) && isGroupOwner(user, &withGroups.Items[i]) {
+			list.Items = append(list.Items, withGroups.Items[i])
+		}
+	}
+	return list, nil
+}
+
+// ownsWorkspacesThroughGroups returns whether one of the groups of the user owns workspaces in the org.
+func (s *REST) ownsWorkspacesThroughGroups(user kuser.Info, orgClusterName string) (bool, error) {
+	for _, group := range user.GetGroups() {
+		bindings, err := s.crbInformer.Informer().GetIndexer().ByIndex(GroupOwnerIndex, lclusterAwareIndexValue(orgClusterName, group))
+		if err != nil {
+			return false, err
+		}
+		if len(bindings) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isOwner returns whether the user is the owner recorded in the owner annotation of the object, or a member of
+// one of the groups recorded in its owner groups annotation.
+func isOwner(user kuser.Info, obj metav1.Object) bool {
+	return isUserOwner(user, obj) || isGroupOwner(user, obj)
+}
+
+// isUserOwner returns whether the user is the owner recorded in the owner annotation of the object.
+func isUserOwner(user kuser.Info, obj metav1.Object) bool {
+	return obj.GetAnnotations()[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation] == user.GetName()
+}
+
+// isGroupOwner returns whether the user is a member of one of the groups owning the object.
+func isGroupOwner(user kuser.Info, obj metav1.Object) bool {
+	return sets.NewString(user.GetGroups()...).HasAny(groupOwners(obj)...)
+}
+
+// groupOwners returns the groups recorded in the owner groups annotation of the object.
+func groupOwners(obj metav1.Object) []string {
+	var groups []string
+	for _, group := range strings.Split(obj.GetAnnotations()[tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation], ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// isPrivileged returns whether the user is a member of the system:masters group. The ClusterWorkspaces are
+// written with the identity of the virtual workspace, such that their admission cannot tell users apart.
+func isPrivileged(user kuser.Info) bool {
+	return sets.NewString(user.GetGroups()...).Has(kuser.SystemPrivilegedGroup)
+}
+
 func (s *REST) extractOrg(ctx context.Context) (orgClusterName string, org *Org, err error) {
 	orgClusterName = ctx.Value(WorkspacesOrgKey).(string)
+	if orgClusterName == AllOrgs {
+		return "", nil, kerrors.NewBadRequest("only listing workspaces is supported across all orgs")
+	}
 	org, err = s.getOrg(orgClusterName)
 	return
 }
 
+// namePrefix returns the ClusterWorkspaceNamePrefixAnnotation of the ClusterWorkspace of the organization,
+// or an empty prefix for the root organization.
+func (s *REST) namePrefix(orgClusterName string) (string, error) {
+	if orgClusterName == tenancyhelper.RootCluster || s.clusterWorkspaceCache == nil {
+		return "", nil
+	}
+	parent, name, err := tenancyhelper.ParseLogicalClusterName(orgClusterName)
+	if err != nil {
+		return "", err
+	}
+	orgClusterWorkspace, err := s.clusterWorkspaceCache.GetWorkspace(parent, name)
+	if err != nil {
+		return "", err
+	}
+	return orgClusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceNamePrefixAnnotation], nil
+}
+
 // List retrieves a list of Workspaces that match label.
 func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (runtime.Object, error) {
 	user, ok := apirequest.UserFrom(ctx)
 	if !ok {
 		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), "", fmt.Errorf("unable to list workspaces without a user on the context"))
 	}
+	if ctx.Value(WorkspacesOrgKey) == AllOrgs {
+		return s.listAllOrgs(ctx, user, options)
+	}
 	orgClusterName, org, err := s.extractOrg(ctx)
 	if err != nil {
 		return nil, err
@@ -218,29 +519,76 @@ func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (run
 	// It breaks the API guarantees of lists.
 	// To make it correct we have to know the latest RV of the org workspace shard,
 	// and then wait for freshness relative to that RV of the lister.
-	labelSelector, _ := InternalListOptionsToSelectors(options)
-	clusterWorkspaceList, err := org.clusterWorkspaceLister.List(withoutGroupsWhenPersonal(user, scope), labelSelector)
+	labelSelector, fieldSelector := InternalListOptionsToSelectors(options)
+	if err := validateFieldSelector(fieldSelector); err != nil {
+		return nil, err
+	}
+	idleSince, err := idleSinceFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	includeDeleted, err := includeDeletedFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sortBy, err := sortByFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusterWorkspaceList, err := s.listAccessible(org, user, orgClusterName, scope, labelSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	if scope == PersonalScope {
-		for i, workspace := range clusterWorkspaceList.Items {
-			var err error
-			clusterWorkspaceList.Items[i].Name, err = s.getPrettyNameFromInternalName(user, orgClusterName, workspace.Name)
-			if err != nil {
-				return nil, err
+	if inScope, found := scopePredicates[scope]; found {
+		items := clusterWorkspaceList.Items[:0]
+		for _, workspace := range clusterWorkspaceList.Items {
+			if !inScope(isOwner(user, &workspace)) {
+				continue
+			}
+			if scope == PersonalScope {
+				prettyName, err := s.getPrettyNameFromInternalName(user, orgClusterName, workspace.Name)
+				if kerrors.IsNotFound(err) {
+					continue // still being created, the bindings of the owner are not labeled yet
+				} else if err != nil {
+					return nil, err
+				}
+				workspace.Name = prettyName
 			}
+			items = append(items, workspace)
 		}
+		clusterWorkspaceList.Items = items
 	}
 
 	workspaceList := &tenancyv1beta1.WorkspaceList{
 		ListMeta: clusterWorkspaceList.ListMeta,
-		Items:    make([]tenancyv1beta1.Workspace, len(clusterWorkspaceList.Items)),
+		Items:    make([]tenancyv1beta1.Workspace, 0, len(clusterWorkspaceList.Items)),
 	}
 
-	for i, cws := range clusterWorkspaceList.Items {
-		projection.ProjectClusterWorkspaceToWorkspace(&cws, &workspaceList.Items[i])
+	// Field selectors are matched against the projected Workspace, such that
+	// metadata.name refers to the pretty name in the personal scope.
+	m := workspaceutil.MatchWorkspace(labels.Everything(), fieldSelector)
+	for i := range clusterWorkspaceList.Items {
+		if idleSince != nil && !isIdleSince(&clusterWorkspaceList.Items[i], *idleSince) {
+			continue
+		}
+		if _, deleted := clusterWorkspaceList.Items[i].Annotations[tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation]; deleted && !includeDeleted {
+			continue
+		}
+		var workspace tenancyv1beta1.Workspace
+		projection.ProjectClusterWorkspaceToWorkspace(&clusterWorkspaceList.Items[i], &workspace)
+		if matches, err := m.Matches(&workspace); err != nil {
+			return nil, err
+		} else if !matches {
+			continue
+		}
+		workspaceList.Items = append(workspaceList.Items, workspace)
+	}
+
+	if options != nil {
+		if err := paginateWorkspaceList(workspaceList, sortBy, options.Limit, options.Continue); err != nil {
+			return nil, err
+		}
 	}
 
 	return workspaceList, nil
@@ -262,12 +610,79 @@ func (s *REST) Watch(ctx context.Context, options *metainternal.ListOptions) (wa
 
 	includeAllExistingProjects := (options != nil) && options.ResourceVersion == "0"
 
-	m := workspaceutil.MatchWorkspace(InternalListOptionsToSelectors(options))
+	labelSelector, fieldSelector := InternalListOptionsToSelectors(options)
+	if err := validateFieldSelector(fieldSelector); err != nil {
+		return nil, err
+	}
+	scope := ctx.Value(WorkspacesScopeKey).(string)
+
+	// In the personal scope, the watcher only knows the internal names: field selectors are
+	// matched after renaming the workspaces to their pretty names in scopeWatch.
+	watcherFieldSelector := fieldSelector
+	if scope == PersonalScope {
+		watcherFieldSelector = fields.Everything()
+	}
+	m := workspaceutil.MatchWorkspace(labelSelector, watcherFieldSelector)
 	watcher := workspaceauth.NewUserWorkspaceWatcher(userInfo, orgClusterName, s.clusterWorkspaceCache, org.authCache, includeAllExistingProjects, m)
 	org.authCache.AddWatcher(watcher)
 
 	go watcher.Watch()
-	return watcher, nil
+
+	result := s.scopeWatch(userInfo, orgClusterName, scope, fieldSelector, watcher)
+	if options != nil && options.AllowWatchBookmarks && s.watchBookmarkInterval > 0 {
+		result = newBookmarkWatcher(result, options.ResourceVersion, s.watchBookmarkInterval)
+	}
+	return result, nil
+}
+
+// scopeWatch filters and renames the events of the given watch of the workspaces the user can access
+// according to the scope. A watch of a single workspace, i.e. with a metadata.name field selector,
+// ends after the workspace got deleted.
+func (s *REST) scopeWatch(user kuser.Info, orgClusterName, scope string, fieldSelector fields.Selector, watcher watch.Interface) watch.Interface {
+	inScope, hasScope := scopePredicates[scope]
+	m := workspaceutil.MatchWorkspace(labels.Everything(), fieldSelector)
+
+	// prettyNames remembers the pretty names of the watched workspaces, such that the deletion of
+	// a workspace is reported under its pretty name even after its bindings are gone.
+	prettyNames := map[string]string{}
+
+	var result watch.Interface = watch.Filter(watcher, func(event watch.Event) (watch.Event, bool) {
+		workspace, ok := event.Object.(*tenancyv1beta1.Workspace)
+		if !ok {
+			return event, true
+		}
+		if hasScope && event.Type != watch.Deleted && !inScope(isOwner(user, workspace)) {
+			return event, false
+		}
+		if scope != PersonalScope {
+			return event, true
+		}
+
+		prettyName, found := prettyNames[workspace.Name]
+		if !found {
+			var err error
+			if prettyName, err = s.getPrettyNameFromInternalName(user, orgClusterName, workspace.Name); err != nil {
+				// still being created, the bindings of the owner are not labeled yet, or already gone
+				return event, false
+			}
+		}
+		if event.Type == watch.Deleted {
+			delete(prettyNames, workspace.Name)
+		} else {
+			prettyNames[workspace.Name] = prettyName
+		}
+		workspace.Name = prettyName
+
+		if matches, err := m.Matches(workspace); err != nil || !matches {
+			return event, false
+		}
+		return event, true
+	})
+
+	if _, found := fieldSelector.RequiresExactMatch("metadata.name"); found {
+		result = newUntilDeletedWatcher(result)
+	}
+	return result
 }
 
 var _ = rest.Getter(&REST{})
@@ -285,6 +700,39 @@ func (s *REST) Get(ctx context.Context, name string, options *metav1.GetOptions)
 }
 
 func (s *REST) getClusterWorkspace(ctx context.Context, name string, options *metav1.GetOptions) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	workspace, err := s.getInternalClusterWorkspace(ctx, name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope := ctx.Value(WorkspacesScopeKey).(string); scope == PersonalScope {
+		user, _ := apirequest.UserFrom(ctx)
+		orgClusterName, _, err := s.extractOrg(ctx)
+		if err != nil {
+			return nil, err
+		}
+		workspace.Name, err = s.getPrettyNameFromInternalName(user, orgClusterName, workspace.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return workspace, nil
+}
+
+// getInternalClusterWorkspace returns the ClusterWorkspace the user sees with the given name,
+// keeping its internal name in the personal scope. Soft-deleted workspaces are not found, unless
+// the request asks for them with the include-deleted parameter.
+func (s *REST) getInternalClusterWorkspace(ctx context.Context, name string, options *metav1.GetOptions) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	includeDeleted, err := includeDeletedFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.getAccessibleClusterWorkspace(ctx, name, options, includeDeleted)
+}
+
+// getAccessibleClusterWorkspace is getInternalClusterWorkspace, finding soft-deleted workspaces
+// only if includeDeleted is set.
+func (s *REST) getAccessibleClusterWorkspace(ctx context.Context, name string, options *metav1.GetOptions, includeDeleted bool) (*tenancyv1alpha1.ClusterWorkspace, error) {
 	opts := metav1.GetOptions{}
 	if options != nil {
 		opts = *options
@@ -318,7 +766,7 @@ func (s *REST) getClusterWorkspace(ctx context.Context, name string, options *me
 	// Filtering by applying the lister operation might not be necessary anymore
 	// when using a semi-delegated authorizer in the workspaces virtual workspace that would
 	// delegate this authorization to the main KCP instance hosting the workspaces and RBAC rules
-	obj, err := org.clusterWorkspaceLister.List(withoutGroupsWhenPersonal(user, scope), labels.Everything())
+	obj, err := s.listAccessible(org, user, orgClusterName, scope, labels.Everything())
 	if err != nil {
 		return nil, err
 	}
@@ -334,11 +782,11 @@ func (s *REST) getClusterWorkspace(ctx context.Context, name string, options *me
 		return nil, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
 	}
 
-	if scope == PersonalScope {
-		existingClusterWorkspace.Name, err = s.getPrettyNameFromInternalName(user, orgClusterName, existingClusterWorkspace.Name)
-		if err != nil {
-			return nil, err
-		}
+	if inScope, found := scopePredicates[scope]; found && !inScope(isOwner(user, existingClusterWorkspace)) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
+	}
+	if _, deleted := existingClusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation]; deleted && !includeDeleted {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
 	}
 	return existingClusterWorkspace, nil
 }
@@ -363,7 +811,7 @@ var roleRules map[RoleType][]rbacv1.PolicyRule = map[RoleType][]rbacv1.PolicyRul
 	},
 	OwnerRoleType: {
 		{
-			Verbs:     []string{"get", "delete"},
+			Verbs:     []string{"get", "update", "delete"},
 			Resources: []string{"workspaces"},
 		},
 		{
@@ -393,6 +841,10 @@ func getRoleBindingName(roleType RoleType, workspacePrettyName string, user kuse
 	return string(roleType) + "-workspace-" + workspacePrettyName + "-" + user.GetName()
 }
 
+func getGroupRoleBindingName(roleType RoleType, workspacePrettyName string, group string) string {
+	return string(roleType) + "-workspace-" + workspacePrettyName + "-group-" + group
+}
+
 func InternalListOptionsToSelectors(options *metainternal.ListOptions) (labels.Selector, fields.Selector) {
 	label := labels.Everything()
 	if options != nil && options.LabelSelector != nil {
@@ -405,6 +857,69 @@ func InternalListOptionsToSelectors(options *metainternal.ListOptions) (labels.S
 	return label, field
 }
 
+// idleSinceFrom returns the time of the idle-since query parameter of the request, if any.
+func idleSinceFrom(ctx context.Context) (*time.Time, error) {
+	query, ok := ctx.Value(virtualcontext.RequestQueryKey).(url.Values)
+	if !ok || query.Get(IdleSinceParameter) == "" {
+		return nil, nil
+	}
+	idleSince, err := time.Parse(time.RFC3339, query.Get(IdleSinceParameter))
+	if err != nil {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("invalid %s parameter %q, expected an RFC 3339 time", IdleSinceParameter, query.Get(IdleSinceParameter)))
+	}
+	return &idleSince, nil
+}
+
+// includeDeletedFrom returns whether soft-deleted workspaces are requested in the query of the request.
+func includeDeletedFrom(ctx context.Context) (bool, error) {
+	query, ok := ctx.Value(virtualcontext.RequestQueryKey).(url.Values)
+	if !ok || query.Get(IncludeDeletedParameter) == "" {
+		return false, nil
+	}
+	includeDeleted, err := strconv.ParseBool(query.Get(IncludeDeletedParameter))
+	if err != nil {
+		return false, kerrors.NewBadRequest(fmt.Sprintf("invalid %s parameter %q, expected a boolean", IncludeDeletedParameter, query.Get(IncludeDeletedParameter)))
+	}
+	return includeDeleted, nil
+}
+
+// isIdleSince tells whether there was no activity in the workspace since the given time. Workspaces without
+// recorded activity are idle since their creation.
+func isIdleSince(workspace *tenancyv1alpha1.ClusterWorkspace, since time.Time) bool {
+	last := workspace.CreationTimestamp
+	if workspace.Status.LastActivityTime != nil && workspace.Status.LastActivityTime.After(last.Time) {
+		last = *workspace.Status.LastActivityTime
+	}
+	return last.Time.Before(since)
+}
+
+// validateFieldSelector rejects field selectors on anything else than the selectable fields of Workspaces.
+func validateFieldSelector(fieldSelector fields.Selector) error {
+	for _, requirement := range fieldSelector.Requirements() {
+		if !selectableFields.Has(requirement.Field) {
+			return kerrors.NewBadRequest(fmt.Sprintf("field selector %q is not supported for workspaces, supported fields are: %s", requirement.Field, strings.Join(selectableFields.List(), ", ")))
+		}
+		if requirement.Field == "status.phase" && !isKnownPhase(requirement.Value) {
+			phases := make([]string, 0, len(tenancyhelper.Phases()))
+			for _, phase := range tenancyhelper.Phases() {
+				phases = append(phases, string(phase))
+			}
+			return kerrors.NewBadRequest(fmt.Sprintf("unknown workspace phase %q in field selector, known phases are: %s", requirement.Value, strings.Join(phases, ", ")))
+		}
+	}
+	return nil
+}
+
+// isKnownPhase returns whether the given value is one of the phases a workspace can be in.
+func isKnownPhase(value string) bool {
+	for _, phase := range tenancyhelper.Phases() {
+		if string(phase) == value {
+			return true
+		}
+	}
+	return false
+}
+
 var _ = rest.Creater(&REST{})
 
 // Create creates a new workspace
@@ -427,19 +942,39 @@ var _ = rest.Creater(&REST{})
 //
 // If this fails, then my-app already exists for the user A => conflict error.
 //
+// If the tenancy.kcp.dev/owner-groups annotation lists groups of User-A, a ClusterRoleBinding
+// owner-workspace-my-app-group-<group> also links the owner ClusterRole with each group, such that all their
+// members manage my-app in their personal scope. If one fails, then my-app already exists for the group.
+//
 //   2. create ClusterRoleBinding owner-workspace-my-app-user-A
 //      create ClusterRole owner-workspace-my-app-user-A
 //      create ClusterRole lister-workspace-my-app-user-A  (in order to later allow sharing)
 //
 //   3. create ClusterWorkspace my-app
 //
-// If this conflicts, create my-app--1, then my-app--2, …
+// If this conflicts, the name collision policy either rejects the creation, or
+// creates my-app--1, then my-app--2, … (my-app--<random suffix> with the random policy).
 //
 //   4. update RoleBinding user-A-my-app to point to my-app-2 instead of my-app.
 //
 //   5. update ClusterRole owner-workspace-my-app-user-A to point to the internal workspace name
 //      update the internalName and pretty annotation on cluster roles and cluster role bindings.
 //
+// With dry-run, the creations of steps 1 to 3 are done in dry-run mode too, such that admission,
+// the pretty name uniqueness check and the name collision policy still apply, but nothing is
+// persisted. Step 4 and 5 are skipped, and the workspace that would have been created is returned.
+//
+// Before any of this, the creation takes a token from the rate limiter of the user, if any. Without one left,
+// it fails with 429 Too Many Requests and a Retry-After header. An invalid workspace fails with 422 Unprocessable
+// Entity listing all of its invalid fields at once. Once the workspace is validated, the creation policy webhook,
+// if any, is asked whether the user may create it, and fails with 403 Forbidden if it denies.
+//
+// If the ClusterWorkspace of the organization has the ClusterWorkspaceNamePrefixAnnotation, the prefix is
+// added to the requested name first, and all of the above applies to the prefixed name.
+//
+// Without a name, metadata.generateName is used as the prefix of a random name which is not used by any
+// workspace of the organization yet. That name is then used as the pretty name.
+//
 func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
 	var zero int64
 	user, ok := apirequest.UserFrom(ctx)
@@ -447,7 +982,7 @@ func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspace"), "", fmt.Errorf("unable to create a workspace without a user on the context"))
 	}
 
-	_, org, err := s.extractOrg(ctx)
+	orgClusterName, org, err := s.extractOrg(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -456,10 +991,98 @@ func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspace"), "", fmt.Errorf("creating a workspace in only possible in the personal workspaces scope for now"))
 	}
 
+	if err := s.createRateLimiter.Allow(user); err != nil {
+		return nil, err
+	}
+
 	workspace, isWorkspace := obj.(*tenancyv1beta1.Workspace)
 	if !isWorkspace {
 		return nil, kerrors.NewInvalid(tenancyv1beta1.SchemeGroupVersion.WithKind("Workspace").GroupKind(), obj.GetObjectKind().GroupVersionKind().String(), []*field.Error{})
 	}
+
+	prefix, err := s.namePrefix(orgClusterName)
+	if err != nil {
+		return nil, err
+	}
+	if workspace.Name == "" && workspace.GenerateName != "" {
+		// The generated name is both the pretty and the internal name, unless a concurrent
+		// creation takes it first, in which case the name collision policy applies.
+		generatedName, err := s.generateWorkspaceName(orgClusterName, withNamePrefix(prefix, workspace.GenerateName))
+		if err != nil {
+			return nil, err
+		}
+		workspace = workspace.DeepCopy()
+		workspace.Name = generatedName
+	}
+	if prefixed := withNamePrefix(prefix, workspace.Name); prefixed != workspace.Name {
+		workspace = workspace.DeepCopy()
+		workspace.Name = prefixed
+	}
+
+	errs := validateWorkspace(maxSuffixLength(s.nameCollisionPolicy, s.nameGenerator), workspace)
+	errs = append(errs, validateReservedName(s.reservedNames, orgClusterName, workspace.Name, field.NewPath("metadata", "name"))...)
+	if !isPrivileged(user) {
+		errs = append(errs, tenancyhelper.ValidateSystemTagsUnchanged(nil, workspace.Spec.Tags, field.NewPath("spec", "tags"))...)
+	}
+	errs = append(errs, validateGroupOwners(user, workspace, field.NewPath("metadata", "annotations").Key(tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation))...)
+	if len(errs) > 0 {
+		return nil, kerrors.NewInvalid(tenancyv1beta1.SchemeGroupVersion.WithKind("Workspace").GroupKind(), workspace.Name, errs)
+	}
+
+	if err := s.checkWorkspaceQuota(ctx, orgClusterName, org, user, workspace.Name); err != nil {
+		return nil, err
+	}
+
+	if err := s.createPolicyWebhook.Admit(ctx, user, orgClusterName, workspace, options != nil && len(options.DryRun) > 0); err != nil {
+		return nil, err
+	}
+
+	// The workspace to clone from is given by its pretty name. Resolve it to the internal name,
+	// which also checks that the user has access to it.
+	annotations := workspace.Annotations
+	cloneFrom, isClone := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation]
+	if isClone {
+		source, err := s.getInternalClusterWorkspace(ctx, cloneFrom, nil)
+		if kerrors.IsNotFound(err) {
+			return nil, kerrors.NewInvalid(tenancyv1beta1.SchemeGroupVersion.WithKind("Workspace").GroupKind(), workspace.Name, field.ErrorList{
+				field.NotFound(field.NewPath("metadata", "annotations").Key(tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation), cloneFrom),
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+		annotations = make(map[string]string, len(workspace.Annotations))
+		for k, v := range workspace.Annotations {
+			annotations[k] = v
+		}
+		annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation] = source.Name
+	}
+	if include, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneIncludeAnnotation]; found {
+		for _, s := range strings.Split(include, ",") {
+			if s = strings.TrimSpace(s); s != tenancyv1alpha1.ClusterWorkspaceCloneIncludeSecrets {
+				return nil, kerrors.NewInvalid(tenancyv1beta1.SchemeGroupVersion.WithKind("Workspace").GroupKind(), workspace.Name, field.ErrorList{
+					field.NotSupported(field.NewPath("metadata", "annotations").Key(tenancyv1alpha1.ClusterWorkspaceCloneIncludeAnnotation), s, []string{tenancyv1alpha1.ClusterWorkspaceCloneIncludeSecrets}),
+				})
+			}
+		}
+	}
+
+	// Record the requesting user as the owner and the creator, overriding any owner annotation given by the user.
+	// The ClusterWorkspace is created by a privileged client, hence admission keeps the created-by annotation.
+	ownedAnnotations := make(map[string]string, len(annotations)+2)
+	for k, v := range annotations {
+		ownedAnnotations[k] = v
+	}
+	ownedAnnotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation] = user.GetName()
+	ownedAnnotations[tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation] = user.GetName()
+	annotations = ownedAnnotations
+
+	var createOptions metav1.CreateOptions
+	if options != nil {
+		createOptions.DryRun = options.DryRun
+	}
+	dryRun := len(createOptions.DryRun) > 0
+
 	ownerRoleBindingName := getRoleBindingName(OwnerRoleType, workspace.Name, user)
 	listerRoleBindingName := getRoleBindingName(ListerRoleType, workspace.Name, user)
 
@@ -484,60 +1107,107 @@ func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 			},
 		},
 	}
-	if _, err := org.rbacClient.ClusterRoleBindings().Create(ctx, &clusterRoleBinding, metav1.CreateOptions{}); err != nil {
+	if _, err := org.rbacClient.ClusterRoleBindings().Create(ctx, &clusterRoleBinding, createOptions); err != nil {
 		if kerrors.IsAlreadyExists(err) {
 			return nil, kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), workspace.Name)
 		}
 		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
 	}
 
+	// Then link the owner cluster role with each of the groups owning the workspace along with the user,
+	// so that the pretty name is also unique inside the personal scope of the members of the groups.
+	var groupRoleBindings []*rbacv1.ClusterRoleBinding
+
+	// rollbackRBAC deletes the bindings and the roles created for the workspace so far, when its creation fails.
+	rollbackRBAC := func() {
+		if dryRun {
+			return
+		}
+		_ = org.rbacClient.ClusterRoleBindings().Delete(ctx, clusterRoleBinding.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+		for _, crb := range groupRoleBindings {
+			_ = org.rbacClient.ClusterRoleBindings().Delete(ctx, crb.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+		}
+		_ = org.rbacClient.ClusterRoles().Delete(ctx, ownerRoleBindingName, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+		_ = org.rbacClient.ClusterRoles().Delete(ctx, listerRoleBindingName, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+	}
+	for _, group := range groupOwners(workspace) {
+		groupRoleBinding := clusterRoleBinding.DeepCopy()
+		groupRoleBinding.Name = getGroupRoleBindingName(OwnerRoleType, workspace.Name, group)
+		groupRoleBinding.Subjects = []rbacv1.Subject{
+			{
+				Kind: rbacv1.GroupKind,
+				Name: group,
+			},
+		}
+		if _, err := org.rbacClient.ClusterRoleBindings().Create(ctx, groupRoleBinding, createOptions); err != nil {
+			rollbackRBAC()
+			if kerrors.IsAlreadyExists(err) {
+				return nil, kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), workspace.Name)
+			}
+			return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
+		}
+		groupRoleBindings = append(groupRoleBindings, groupRoleBinding)
+	}
+
 	// Then create the owner and lister roles related to the given workspace.
 	// Note that ResourceNames contains the workspace pretty name for now.
 	// It will be updated later on when the internal name of the workspace is known.
 	ownerClusterRole := createClusterRole(ownerRoleBindingName, workspace.Name, OwnerRoleType)
-	if _, err := org.rbacClient.ClusterRoles().Create(ctx, ownerClusterRole, metav1.CreateOptions{}); err != nil && !kerrors.IsAlreadyExists(err) {
+	if _, err := org.rbacClient.ClusterRoles().Create(ctx, ownerClusterRole, createOptions); err != nil && !kerrors.IsAlreadyExists(err) {
+		rollbackRBAC()
 		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
 	}
 
 	listerClusterRole := createClusterRole(listerRoleBindingName, workspace.Name, ListerRoleType)
-	if _, err := org.rbacClient.ClusterRoles().Create(ctx, listerClusterRole, metav1.CreateOptions{}); err != nil && !kerrors.IsAlreadyExists(err) {
-		_ = org.rbacClient.ClusterRoles().Delete(ctx, ownerClusterRole.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+	if _, err := org.rbacClient.ClusterRoles().Create(ctx, listerClusterRole, createOptions); err != nil && !kerrors.IsAlreadyExists(err) {
+		rollbackRBAC()
 		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
 	}
 
 	// Then try to create the workspace object itself, first with the pretty name,
-	// retrying with increasing suffixes until a workspace with the same name
-	// doesn't already exist.
-	// The suffixed name based on the pretty name will be the internal name
+	// retrying with names chosen by the name collision policy until a workspace with
+	// the same name doesn't already exist.
+	// The name the workspace got created with will be the internal name.
+	// Only the name, labels, annotations and spec are propagated to the ClusterWorkspace.
 	clusterWorkspace := &tenancyv1alpha1.ClusterWorkspace{
-		ObjectMeta: workspace.ObjectMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        workspace.Name,
+			Labels:      workspace.Labels,
+			Annotations: annotations,
+		},
 		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
-			Type: workspace.Spec.Type,
+			Type:         workspace.Spec.Type,
+			DisplayName:  workspace.Spec.DisplayName,
+			Description:  workspace.Spec.Description,
+			Tags:         workspace.Spec.Tags,
+			FeatureGates: workspace.Spec.FeatureGates,
 		},
 	}
+	if err := projection.ProjectWorkspaceManagedFields(workspace, clusterWorkspace); err != nil {
+		return nil, err
+	}
 	prettyName := workspace.Name
 	var createdClusterWorkspace *tenancyv1alpha1.ClusterWorkspace
-	var nameSuffix string
-	i := 0
-	for i < 10 {
-		if i > 0 {
-			nameSuffix = fmt.Sprintf("-%d", i)
-			clusterWorkspace.Name = fmt.Sprintf("%s-%s", prettyName, nameSuffix)
-		}
-		createdClusterWorkspace, err = org.clusterWorkspaceClient.Create(ctx, clusterWorkspace, metav1.CreateOptions{})
-		if err == nil {
+	for i := 0; i < maxNameCollisionAttempts; i++ {
+		clusterWorkspace.Name = internalNameCandidate(collisionNameGenerator(s.nameCollisionPolicy, s.nameGenerator), prettyName, i)
+		createdClusterWorkspace, err = org.clusterWorkspaceClient.Create(ctx, clusterWorkspace, createOptions)
+		if err == nil || !kerrors.IsAlreadyExists(err) || s.nameCollisionPolicy == NameCollisionReject {
 			break
 		}
-		if !kerrors.IsAlreadyExists(err) {
-			return nil, err
-		}
-		i++
 	}
 
 	if err != nil {
+		rollbackRBAC()
+		if kerrors.IsAlreadyExists(err) {
+			return nil, kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), prettyName)
+		}
 		return nil, err
 	}
 
+	if dryRun {
+		return projectCreatedWorkspace(createdClusterWorkspace, prettyName, cloneFrom, isClone), nil
+	}
+
 	// Update the cluster roles with the new workspace internal name, and also
 	// add the internal name as a label, to allow searching with it later on.
 	for i := range ownerClusterRole.Rules {
@@ -545,8 +1215,7 @@ func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	}
 	ownerClusterRole.Labels[InternalNameLabel] = createdClusterWorkspace.Name
 	if _, err := org.rbacClient.ClusterRoles().Update(ctx, ownerClusterRole, metav1.UpdateOptions{}); err != nil {
-		_ = org.rbacClient.ClusterRoles().Delete(ctx, ownerClusterRole.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
-		_ = org.rbacClient.ClusterRoles().Delete(ctx, listerClusterRole.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+		rollbackRBAC()
 		_, _, _ = s.Delete(ctx, createdClusterWorkspace.Name, nil, &metav1.DeleteOptions{GracePeriodSeconds: &zero})
 		if kerrors.IsConflict(err) {
 			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
@@ -559,8 +1228,7 @@ func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	}
 	listerClusterRole.Labels[InternalNameLabel] = createdClusterWorkspace.Name
 	if _, err := org.rbacClient.ClusterRoles().Update(ctx, listerClusterRole, metav1.UpdateOptions{}); err != nil {
-		_ = org.rbacClient.ClusterRoles().Delete(ctx, ownerClusterRole.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
-		_ = org.rbacClient.ClusterRoles().Delete(ctx, listerClusterRole.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+		rollbackRBAC()
 		_, _, _ = s.Delete(ctx, createdClusterWorkspace.Name, nil, &metav1.DeleteOptions{GracePeriodSeconds: &zero})
 		if kerrors.IsConflict(err) {
 			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
@@ -573,26 +1241,207 @@ func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	clusterRoleBinding.Labels[InternalNameLabel] = createdClusterWorkspace.Name
 	clusterRoleBinding.Labels[PrettyNameLabel] = prettyName
 	if _, err := org.rbacClient.ClusterRoleBindings().Update(ctx, &clusterRoleBinding, metav1.UpdateOptions{}); err != nil {
-		var zero int64
-		_ = org.rbacClient.ClusterRoleBindings().Delete(ctx, clusterRoleBinding.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+		rollbackRBAC()
 		_, _, _ = s.Delete(ctx, createdClusterWorkspace.Name, nil, &metav1.DeleteOptions{GracePeriodSeconds: &zero})
 		if kerrors.IsConflict(err) {
 			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
 		}
 		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
 	}
+	for _, groupRoleBinding := range groupRoleBindings {
+		groupRoleBinding.Labels[InternalNameLabel] = createdClusterWorkspace.Name
+		groupRoleBinding.Labels[PrettyNameLabel] = prettyName
+		if _, err := org.rbacClient.ClusterRoleBindings().Update(ctx, groupRoleBinding, metav1.UpdateOptions{}); err != nil {
+			rollbackRBAC()
+			_, _, _ = s.Delete(ctx, createdClusterWorkspace.Name, nil, &metav1.DeleteOptions{GracePeriodSeconds: &zero})
+			if kerrors.IsConflict(err) {
+				return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
+			}
+			return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), workspace.Name, err)
+		}
+	}
 
+	return projectCreatedWorkspace(createdClusterWorkspace, prettyName, cloneFrom, isClone), nil
+}
+
+// projectCreatedWorkspace returns the workspace to reply to a creation with. The workspace has been
+// created with the internal name in KCP, but will be returned to the user (in personal scope) with
+// the pretty name. The internal name is reported as an annotation.
+func projectCreatedWorkspace(createdClusterWorkspace *tenancyv1alpha1.ClusterWorkspace, prettyName, cloneFrom string, isClone bool) *tenancyv1beta1.Workspace {
 	var createdWorkspace tenancyv1beta1.Workspace
 	projection.ProjectClusterWorkspaceToWorkspace(createdClusterWorkspace, &createdWorkspace)
 
-	// The workspace has been created with the internal name in KCP,
-	// but will be returned to the user (in personal scope) with the pretty name.
 	createdWorkspace.Name = prettyName
-	return &createdWorkspace, nil
+	if createdWorkspace.Annotations == nil {
+		createdWorkspace.Annotations = map[string]string{}
+	}
+	createdWorkspace.Annotations[InternalNameAnnotation] = createdClusterWorkspace.Name
+	if isClone {
+		createdWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation] = cloneFrom
+	}
+	return &createdWorkspace
+}
+
+var _ = rest.Updater(&REST{})
+
+// reservedAnnotations are managed by kcp. They cannot be changed through the virtual workspace, and are
+// kept on the ClusterWorkspace when an updated workspace omits them.
+var reservedAnnotations = sets.NewString(
+	tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation,
+	tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation,
+	tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation,
+	tenancyv1alpha1.ClusterWorkspaceCreatedAtAnnotation,
+	tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation,
+	tenancyv1alpha1.ClusterWorkspaceCloneIncludeAnnotation,
+	tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation,
+	tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation,
+	tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation,
+)
+
+// Update propagates the labels, annotations, display name and description of the updated workspace down to the
+// backing ClusterWorkspace, which also serves patches. Propagation is one-way: they replace the ones of the
+// ClusterWorkspace, except for the reserved annotations. Changing the type of the workspace is rejected, and any
+// other change is ignored.
+//
+// The managed fields of the workspace are recorded on the ClusterWorkspace too, such that server-side apply
+// tracks the field managers of workspaces across requests. A workspace applied before it exists is created.
+func (s *REST) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, false, kerrors.NewForbidden(tenancyv1beta1.Resource("workspace"), "", fmt.Errorf("unable to update a workspace without a user on the context"))
+	}
+
+	_, org, err := s.extractOrg(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	clusterWorkspace, err := s.getInternalClusterWorkspace(ctx, name, nil)
+	if kerrors.IsNotFound(err) {
+		if forceAllowCreate {
+			return s.createOnUpdate(ctx, objInfo, createValidation, options)
+		}
+		return nil, false, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// the owners of a workspace may update it. Owner ClusterRoles created before workspaces could be updated
+	// grant get and delete only, so this is the same check as on deletion.
+	review, err := org.workspaceReviewerProvider.ForVerb("delete").Review(clusterWorkspace.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	if review.EvaluationError() != "" {
+		return nil, false, kerrors.NewForbidden(tenancyv1beta1.Resource("workspace"), "", errors.New(review.EvaluationError()))
+	}
+	if !sets.NewString(user.GetGroups()...).HasAny(review.Groups()...) &&
+		!sets.NewString(review.Users()...).Has(user.GetName()) {
+		return nil, false, kerrors.NewForbidden(tenancyv1beta1.Resource("workspace"), "", fmt.Errorf("User %s doesn't have the permission to update workspace %s", user.GetName(), name))
+	}
+
+	var oldWorkspace tenancyv1beta1.Workspace
+	projection.ProjectClusterWorkspaceToWorkspace(clusterWorkspace, &oldWorkspace)
+	oldWorkspace.Name = name
+
+	obj, err := objInfo.UpdatedObject(ctx, &oldWorkspace)
+	if err != nil {
+		return nil, false, err
+	}
+	workspace, isWorkspace := obj.(*tenancyv1beta1.Workspace)
+	if !isWorkspace {
+		return nil, false, kerrors.NewInvalid(tenancyv1beta1.SchemeGroupVersion.WithKind("Workspace").GroupKind(), obj.GetObjectKind().GroupVersionKind().String(), []*field.Error{})
+	}
+	if updateValidation != nil {
+		if err := updateValidation(ctx, workspace, &oldWorkspace); err != nil {
+			return nil, false, err
+		}
+	}
+
+	var errs field.ErrorList
+	if workspace.Spec.Type != "" {
+		errs = append(errs, apivalidation.ValidateImmutableField(workspace.Spec.Type, oldWorkspace.Spec.Type, field.NewPath("spec", "type"))...)
+	}
+	errs = append(errs, tenancyhelper.ValidateDisplayMetadata(workspace.Spec.DisplayName, workspace.Spec.Description, field.NewPath("spec"))...)
+	errs = append(errs, tenancyhelper.ValidateTags(workspace.Spec.Tags, field.NewPath("spec", "tags"))...)
+	if !isPrivileged(user) {
+		errs = append(errs, tenancyhelper.ValidateSystemTagsUnchanged(oldWorkspace.Spec.Tags, workspace.Spec.Tags, field.NewPath("spec", "tags"))...)
+	}
+	annotations := make(map[string]string, len(workspace.Annotations))
+	for k, v := range workspace.Annotations {
+		if k == InternalNameAnnotation || k == tenancyv1alpha1.ClusterWorkspaceManagedFieldsAnnotation {
+			continue
+		}
+		if reservedAnnotations.Has(k) {
+			if old, found := clusterWorkspace.Annotations[k]; !found || old != v {
+				errs = append(errs, field.Forbidden(field.NewPath("metadata", "annotations").Key(k), "reserved annotation cannot be changed"))
+			}
+		}
+		annotations[k] = v
+	}
+	if len(errs) > 0 {
+		return nil, false, kerrors.NewInvalid(tenancyv1beta1.SchemeGroupVersion.WithKind("Workspace").GroupKind(), name, errs)
+	}
+	for k, v := range clusterWorkspace.Annotations {
+		if reservedAnnotations.Has(k) {
+			annotations[k] = v
+		}
+	}
+
+	clusterWorkspace.Labels = workspace.Labels
+	clusterWorkspace.Annotations = annotations
+	clusterWorkspace.Spec.DisplayName = workspace.Spec.DisplayName
+	clusterWorkspace.Spec.Description = workspace.Spec.Description
+	clusterWorkspace.Spec.Tags = workspace.Spec.Tags
+	clusterWorkspace.Spec.FeatureGates = workspace.Spec.FeatureGates
+	if err := projection.ProjectWorkspaceManagedFields(workspace, clusterWorkspace); err != nil {
+		return nil, false, err
+	}
+	if workspace.ResourceVersion != "" {
+		clusterWorkspace.ResourceVersion = workspace.ResourceVersion
+	}
+	updatedClusterWorkspace, err := org.clusterWorkspaceClient.Update(ctx, clusterWorkspace, metav1.UpdateOptions{})
+	if err != nil {
+		if kerrors.IsConflict(err) {
+			return nil, false, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, err)
+		}
+		return nil, false, err
+	}
+
+	var updatedWorkspace tenancyv1beta1.Workspace
+	projection.ProjectClusterWorkspaceToWorkspace(updatedClusterWorkspace, &updatedWorkspace)
+	updatedWorkspace.Name = name
+	return &updatedWorkspace, false, nil
+}
+
+// createOnUpdate creates the workspace resulting from an update of a workspace which does not exist, as
+// server-side apply does.
+func (s *REST) createOnUpdate(ctx context.Context, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	obj, err := objInfo.UpdatedObject(ctx, &tenancyv1beta1.Workspace{})
+	if err != nil {
+		return nil, false, err
+	}
+	var createOptions metav1.CreateOptions
+	if options != nil {
+		createOptions.DryRun, createOptions.FieldManager = options.DryRun, options.FieldManager
+	}
+	created, err := s.Create(ctx, obj, createValidation, &createOptions)
+	if err != nil {
+		return nil, false, err
+	}
+	return created, true, nil
 }
 
 var _ = rest.GracefulDeleter(&REST{})
 
+// Delete deletes the ClusterWorkspace backing the workspace, together with the RBAC objects created for it.
+// Workspaces the user cannot see are reported as not found, such that their existence is not leaked.
+// The propagation policy is passed down to the ClusterWorkspace: with the Orphan policy, the content of the
+// workspace is not removed by the workspace scheduler.
+//
+// With a soft-delete retention, the ClusterWorkspace is only marked with the soft-deleted-until annotation and
+// returned, and the workspace can be restored until then. Deleting a soft-deleted workspace deletes it for good.
 func (s *REST) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
 	user, ok := apirequest.UserFrom(ctx)
 	if !ok {
@@ -604,6 +1453,11 @@ func (s *REST) Delete(ctx context.Context, name string, deleteValidation rest.Va
 		return nil, false, err
 	}
 
+	deleteOptions := metav1.DeleteOptions{}
+	if options != nil {
+		deleteOptions = *options
+	}
+
 	internalName := name
 	if scope := ctx.Value(WorkspacesScopeKey); scope == PersonalScope {
 		var err error
@@ -614,6 +1468,16 @@ func (s *REST) Delete(ctx context.Context, name string, deleteValidation rest.Va
 			}
 			return nil, false, err
 		}
+	} else {
+		// outside of the personal scope, the name does not prove that the user can see the workspace
+		review, err := org.workspaceReviewerProvider.ForVerb("get").Review(internalName)
+		if err != nil {
+			return nil, false, err
+		}
+		if !sets.NewString(user.GetGroups()...).HasAny(review.Groups()...) &&
+			!sets.NewString(review.Users()...).Has(user.GetName()) {
+			return nil, false, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
+		}
 	}
 
 	review, err := org.workspaceReviewerProvider.ForVerb("delete").Review(internalName)
@@ -628,21 +1492,133 @@ func (s *REST) Delete(ctx context.Context, name string, deleteValidation rest.Va
 		return nil, false, kerrors.NewForbidden(tenancyv1beta1.Resource("workspace"), "", fmt.Errorf("User %s doesn't have the permission to delete workspace %s", user.GetName(), name))
 	}
 
-	errorToReturn := org.clusterWorkspaceClient.Delete(ctx, internalName, *options)
-	if err != nil && !kerrors.IsNotFound(errorToReturn) {
+	if s.softDeleteRetention > 0 {
+		clusterWorkspace, err := org.clusterWorkspaceClient.Get(ctx, internalName, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			return nil, false, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
+		} else if err != nil {
+			return nil, false, err
+		}
+		if _, deleted := clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation]; !deleted {
+			return s.softDelete(ctx, org, clusterWorkspace, name, deleteOptions)
+		}
+	}
+
+	if err := org.clusterWorkspaceClient.Delete(ctx, internalName, deleteOptions); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, false, kerrors.NewNotFound(tenancyv1beta1.SchemeGroupVersion.WithResource("workspaces").GroupResource(), name)
+		}
 		return nil, false, err
 	}
+
+	// preconditions apply to the ClusterWorkspace only
+	rbacDeleteOptions := metav1.DeleteOptions{
+		DryRun:            deleteOptions.DryRun,
+		PropagationPolicy: deleteOptions.PropagationPolicy,
+	}
 	internalNameLabelSelector := fmt.Sprintf("%s=%s", InternalNameLabel, internalName)
-	if err := org.rbacClient.ClusterRoleBindings().DeleteCollection(ctx, *options, metav1.ListOptions{
+	if err := org.rbacClient.ClusterRoleBindings().DeleteCollection(ctx, rbacDeleteOptions, metav1.ListOptions{
 		LabelSelector: internalNameLabelSelector,
 	}); err != nil {
 		klog.Error(err)
 	}
-	if err := org.rbacClient.ClusterRoles().DeleteCollection(ctx, *options, metav1.ListOptions{
+	if err := org.rbacClient.ClusterRoles().DeleteCollection(ctx, rbacDeleteOptions, metav1.ListOptions{
 		LabelSelector: internalNameLabelSelector,
 	}); err != nil {
 		klog.Error(err)
 	}
 
-	return nil, false, errorToReturn
+	return nil, false, nil
+}
+
+var _ = rest.CollectionDeleter(&REST{})
+
+// DeleteCollection deletes the workspaces listed with the given options, like the label and field selectors, that
+// are owned by the user. Other matching workspaces are skipped. Each workspace is deleted like by Delete, with the
+// same delete options, and the outcome is aggregated into one status: if any deletion failed, the status of the
+// first failure is returned with a cause per failed workspace, whose message starts with the workspace name.
+func (s *REST) DeleteCollection(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *metainternal.ListOptions) (runtime.Object, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), "", fmt.Errorf("unable to delete workspaces without a user on the context"))
+	}
+	if _, _, err := s.extractOrg(ctx); err != nil {
+		return nil, err
+	}
+
+	listed, err := s.List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted, skipped []string
+	var failed []metav1.StatusCause
+	var firstFailure kerrors.APIStatus
+	for _, workspace := range listed.(*tenancyv1beta1.WorkspaceList).Items {
+		if !isOwner(user, &workspace) {
+			skipped = append(skipped, workspace.Name)
+			continue
+		}
+		if _, _, err := s.Delete(ctx, workspace.Name, deleteValidation, options); err != nil {
+			status, ok := err.(kerrors.APIStatus)
+			if !ok {
+				status = kerrors.NewInternalError(err)
+			}
+			if firstFailure == nil {
+				firstFailure = status
+			}
+			failed = append(failed, metav1.StatusCause{
+				Type:    metav1.CauseType(status.Status().Reason),
+				Message: fmt.Sprintf("%s: %s", workspace.Name, status.Status().Message),
+				Field:   "metadata.name",
+			})
+			continue
+		}
+		deleted = append(deleted, workspace.Name)
+	}
+
+	details := &metav1.StatusDetails{Group: tenancyv1beta1.SchemeGroupVersion.Group, Kind: "workspaces", Causes: failed}
+	if firstFailure != nil {
+		status := firstFailure.Status()
+		status.Message = fmt.Sprintf("failed to delete %d of %d workspaces: %s", len(failed), len(failed)+len(deleted), status.Message)
+		status.Details = details
+		return nil, &kerrors.StatusError{ErrStatus: status}
+	}
+	return &metav1.Status{
+		Status:  metav1.StatusSuccess,
+		Message: fmt.Sprintf("deleted %d workspaces, skipped %d workspaces not owned by %s", len(deleted), len(skipped), user.GetName()),
+		Details: details,
+	}, nil
+}
+
+// softDelete marks the given ClusterWorkspace as soft-deleted until the end of the retention window and returns
+// the workspace with the given name. The RBAC objects of the workspace are kept such that it can be restored.
+func (s *REST) softDelete(ctx context.Context, org *Org, clusterWorkspace *tenancyv1alpha1.ClusterWorkspace, name string, options metav1.DeleteOptions) (runtime.Object, bool, error) {
+	if preconditions := options.Preconditions; preconditions != nil {
+		if preconditions.UID != nil && *preconditions.UID != clusterWorkspace.UID {
+			return nil, false, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("the UID in the precondition (%s) does not match the UID in record (%s)", *preconditions.UID, clusterWorkspace.UID))
+		}
+		if preconditions.ResourceVersion != nil && *preconditions.ResourceVersion != clusterWorkspace.ResourceVersion {
+			return nil, false, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("the ResourceVersion in the precondition (%s) does not match the ResourceVersion in record (%s)", *preconditions.ResourceVersion, clusterWorkspace.ResourceVersion))
+		}
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	deleted := clusterWorkspace.DeepCopy()
+	deleted.Annotations = make(map[string]string, len(clusterWorkspace.Annotations)+1)
+	for k, v := range clusterWorkspace.Annotations {
+		deleted.Annotations[k] = v
+	}
+	deleted.Annotations[tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation] = now().Add(s.softDeleteRetention).UTC().Format(time.RFC3339)
+	deleted, err := org.clusterWorkspaceClient.Update(ctx, deleted, metav1.UpdateOptions{DryRun: options.DryRun})
+	if err != nil {
+		if kerrors.IsConflict(err) {
+			return nil, false, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, err)
+		}
+		return nil, false, err
+	}
+	return projectCreatedWorkspace(deleted, name, "", false), false, nil
 }
@@ -18,34 +18,72 @@ package registry
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/handlers/fieldmanager"
+	openapinamer "k8s.io/apiserver/pkg/endpoints/openapi"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	utilopenapi "k8s.io/apiserver/pkg/util/openapi"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
+	openapibuilder "k8s.io/kube-openapi/pkg/builder"
+	openapiutil "k8s.io/kube-openapi/pkg/util"
+	kubeopenapisets "k8s.io/kube-openapi/pkg/util/sets"
 	"k8s.io/kubernetes/pkg/controller"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpopenapi "github.com/kcp-dev/kcp/pkg/openapi"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+	"github.com/kcp-dev/kcp/pkg/virtual/workspaces"
 	workspaceauth "github.com/kcp-dev/kcp/pkg/virtual/workspaces/auth"
+	workspacecache "github.com/kcp-dev/kcp/pkg/virtual/workspaces/cache"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
 
 // mockLister returns the workspaces in the list
+// ownedBy returns the annotations of a ClusterWorkspace owned by the given user.
+func ownedBy(user kuser.Info) map[string]string {
+	return map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation: user.GetName()}
+}
+
 type mockLister struct {
 	checkedUsers []kuser.Info
 	workspaces   []tenancyv1alpha1.ClusterWorkspace
@@ -57,9 +95,18 @@ func (m *mockLister) CheckedUsers() []kuser.Info {
 
 func (ml *mockLister) List(user kuser.Info, selector labels.Selector) (*tenancyv1alpha1.ClusterWorkspaceList, error) {
 	ml.checkedUsers = append(ml.checkedUsers, user)
-	return &tenancyv1alpha1.ClusterWorkspaceList{
-		Items: ml.workspaces,
-	}, nil
+	if selector == nil || selector.Empty() {
+		return &tenancyv1alpha1.ClusterWorkspaceList{
+			Items: ml.workspaces,
+		}, nil
+	}
+	list := &tenancyv1alpha1.ClusterWorkspaceList{}
+	for _, workspace := range ml.workspaces {
+		if selector.Matches(labels.Set(workspace.Labels)) {
+			list.Items = append(list.Items, workspace)
+		}
+	}
+	return list, nil
 }
 
 var _ workspaceauth.Review = mockReview{}
@@ -101,12 +148,18 @@ type TestData struct {
 	clusterRoleBindings []rbacv1.ClusterRoleBinding
 	clusterWorkspaces   []tenancyv1alpha1.ClusterWorkspace
 	workspaceShards     []tenancyv1alpha1.WorkspaceShard
+	workspaceQuotas     []tenancyv1alpha1.WorkspaceQuota
 	secrets             []corev1.Secret
 	workspaceLister     *mockLister
 	user                kuser.Info
 	scope               string
 	reviewerProvider    workspaceauth.ReviewerProvider
 	orgName             string
+	nameCollisionPolicy NameCollisionPolicy
+	nameGenerator       workspaces.NameGenerator
+	createRateLimiter   *CreateRateLimiter
+	createPolicyWebhook *CreatePolicyWebhook
+	reservedNames       []string
 }
 
 type TestDescription struct {
@@ -135,7 +188,10 @@ func applyTest(t *testing.T, test TestDescription) {
 	secretList := corev1.SecretList{
 		Items: test.secrets,
 	}
-	mockKCPClient := tenancyv1fake.NewSimpleClientset(&workspaceList, &workspaceShardList)
+	workspaceQuotaList := tenancyv1alpha1.WorkspaceQuotaList{
+		Items: test.workspaceQuotas,
+	}
+	mockKCPClient := tenancyv1fake.NewSimpleClientset(&workspaceList, &workspaceShardList, &workspaceQuotaList)
 	mockKubeClient := fake.NewSimpleClientset(&crbList, &crList, &secretList)
 	mockKubeClient.PrependWatchReactor("*", func(action clienttesting.Action) (handled bool, ret watch.Interface, err error) {
 		gvr := action.GetResource()
@@ -213,6 +269,7 @@ func applyTest(t *testing.T, test TestDescription) {
 				rbacClient:                mockKubeClient.RbacV1(),
 				crbInformer:               crbInformer,
 				clusterWorkspaceClient:    mockKCPClient.TenancyV1alpha1().ClusterWorkspaces(),
+				workspaceQuotaClient:      mockKCPClient.TenancyV1alpha1().WorkspaceQuotas(),
 				crbLister:                 kubeInformers.Rbac().V1().ClusterRoleBindings().Lister(),
 				clusterWorkspaceLister:    clusterWorkspaceLister,
 				workspaceReviewerProvider: test.reviewerProvider,
@@ -220,6 +277,11 @@ func applyTest(t *testing.T, test TestDescription) {
 		},
 		crbInformer:           crbInformer,
 		clusterWorkspaceCache: nil,
+		nameCollisionPolicy:   test.nameCollisionPolicy,
+		nameGenerator:         test.nameGenerator,
+		createRateLimiter:     test.createRateLimiter,
+		createPolicyWebhook:   test.createPolicyWebhook,
+		reservedNames:         kubeopenapisets.NewString(test.reservedNames...),
 	}
 	kubeconfigSubresourceStorage := KubeconfigSubresourceREST{
 		mainRest:             &storage,
@@ -384,7 +446,7 @@ func TestListPersonalWorkspaces(t *testing.T) {
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
 				},
 			},
 			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
@@ -445,7 +507,7 @@ func TestListPersonalWorkspacesWithPrettyName(t *testing.T) {
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo--1"},
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", Annotations: ownedBy(user)},
 				},
 			},
 			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
@@ -607,7 +669,7 @@ func TestListOrganizationWorkspacesWithPrettyName(t *testing.T) {
 	applyTest(t, test)
 }
 
-func TestGetPersonalWorkspace(t *testing.T) {
+func TestListSharedWorkspaces(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
@@ -616,7 +678,7 @@ func TestGetPersonalWorkspace(t *testing.T) {
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   SharedScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
 				"get":    mockReviewer{},
@@ -624,7 +686,10 @@ func TestGetPersonalWorkspace(t *testing.T) {
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "bar"},
 				},
 			},
 			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
@@ -647,27 +712,20 @@ func TestGetPersonalWorkspace(t *testing.T) {
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			response, err := storage.Get(ctx, "foo", nil)
+			response, err := storage.List(ctx, nil)
 			require.NoError(t, err)
-			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
-			responseWorkspace := response.(*tenancyv1beta1.Workspace)
-			assert.Equal(t, "foo", responseWorkspace.Name)
+			workspaces := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "workspaces.Items should only have the workspace the user doesn't own")
+			assert.Equal(t, "bar", workspaces.Items[0].Name)
 			checkedUsers := listerCheckedUsers()
 			require.Len(t, checkedUsers, 1, "The workspaceLister should have checked only 1 user")
-			assert.Equal(t,
-				&kuser.DefaultInfo{
-					Name:   user.Name,
-					UID:    user.UID,
-					Groups: []string{},
-				},
-				checkedUsers[0],
-				"The workspaceLister should have checked the user without its groups")
+			assert.Equal(t, user, checkedUsers[0], "The workspaceLister should have checked the user with its groups")
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestGetPersonalWorkspaceWithPrettyName(t *testing.T) {
+func TestListPersonalWorkspacesWithoutSharedWorkspaces(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
@@ -684,7 +742,10 @@ func TestGetPersonalWorkspaceWithPrettyName(t *testing.T) {
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo--1"},
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "bar"},
 				},
 			},
 			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
@@ -694,7 +755,7 @@ func TestGetPersonalWorkspaceWithPrettyName(t *testing.T) {
 						ClusterName: "orgName",
 						Labels: map[string]string{
 							PrettyNameLabel:   "foo",
-							InternalNameLabel: "foo--1",
+							InternalNameLabel: "foo",
 						},
 					},
 					Subjects: []rbacv1.Subject{
@@ -707,27 +768,17 @@ func TestGetPersonalWorkspaceWithPrettyName(t *testing.T) {
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			response, err := storage.Get(ctx, "foo", nil)
+			response, err := storage.List(ctx, nil)
 			require.NoError(t, err)
-			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
-			responseWorkspace := response.(*tenancyv1beta1.Workspace)
-			assert.Equal(t, "foo", responseWorkspace.Name)
-			checkedUsers := listerCheckedUsers()
-			require.Len(t, checkedUsers, 1, "The workspaceLister should have checked only 1 user")
-			assert.Equal(t,
-				&kuser.DefaultInfo{
-					Name:   user.Name,
-					UID:    user.UID,
-					Groups: []string{},
-				},
-				checkedUsers[0],
-				"The workspaceLister should have checked the user without its groups")
+			workspaces := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "workspaces.Items should only have the workspace the user owns")
+			assert.Equal(t, "foo", workspaces.Items[0].Name)
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestGetPersonalWorkspaceNotFoundNoPermission(t *testing.T) {
+func TestListWorkspacesWithFieldSelector(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
@@ -736,7 +787,7 @@ func TestGetPersonalWorkspaceNotFoundNoPermission(t *testing.T) {
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   OrganizationScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
 				"get":    mockReviewer{},
@@ -745,17 +796,53 @@ func TestGetPersonalWorkspaceNotFoundNoPermission(t *testing.T) {
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
 					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
 				},
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo2"},
+					ObjectMeta: metav1.ObjectMeta{Name: "bar"},
+					Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling},
 				},
 			},
-			workspaceLister: &mockLister{
-				workspaces: []tenancyv1alpha1.ClusterWorkspace{
-					{
-						ObjectMeta: metav1.ObjectMeta{Name: "foo2"},
-					},
-				},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.List(ctx, &metainternal.ListOptions{FieldSelector: fields.OneTermEqualSelector("status.phase", "Ready")})
+			require.NoError(t, err)
+			workspaces := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "workspaces.Items should have len 1")
+			assert.Equal(t, "foo", workspaces.Items[0].Name)
+
+			response, err = storage.List(ctx, &metainternal.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", "bar")})
+			require.NoError(t, err)
+			workspaces = response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "workspaces.Items should have len 1")
+			assert.Equal(t, "bar", workspaces.Items[0].Name)
+
+			_, err = storage.List(ctx, &metainternal.ListOptions{FieldSelector: fields.OneTermEqualSelector("spec.type", "Universal")})
+			require.Error(t, err)
+			assert.True(t, kerrors.IsBadRequest(err), "expected a bad request error, got %v", err)
+
+			_, err = storage.List(ctx, &metainternal.ListOptions{FieldSelector: fields.OneTermEqualSelector("status.phase", "Active")})
+			require.Error(t, err)
+			assert.True(t, kerrors.IsBadRequest(err), "expected a bad request error, got %v", err)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestWatchPersonalWorkspaceUntilReady(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
 			},
 			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
 				{
@@ -764,43 +851,68 @@ func TestGetPersonalWorkspaceNotFoundNoPermission(t *testing.T) {
 						ClusterName: "orgName",
 						Labels: map[string]string{
 							PrettyNameLabel:   "foo",
-							InternalNameLabel: "foo",
+							InternalNameLabel: "foo--1",
 						},
 					},
-					Subjects: []rbacv1.Subject{
-						{
-							Kind: "User",
-							Name: user.Name,
+					Subjects: []rbacv1.Subject{{Kind: "User", Name: user.Name}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "bar", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "bar",
+							InternalNameLabel: "bar",
 						},
 					},
+					Subjects: []rbacv1.Subject{{Kind: "User", Name: user.Name}},
 				},
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			response, err := storage.Get(ctx, "foo", nil)
-			require.Error(t, err)
-			require.Nil(t, response)
-			checkedUsers := listerCheckedUsers()
-			require.Len(t, checkedUsers, 1, "The workspaceLister should have checked only 1 user")
-			assert.Equal(t,
-				&kuser.DefaultInfo{
-					Name:   user.Name,
-					UID:    user.UID,
-					Groups: []string{},
-				},
-				checkedUsers[0],
-				"The workspaceLister should have checked the user without its groups")
+			workspace := func(name string, phase tenancyv1alpha1.ClusterWorkspacePhaseType) *tenancyv1beta1.Workspace {
+				return &tenancyv1beta1.Workspace{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: ownedBy(user)},
+					Status:     tenancyv1beta1.WorkspaceStatus{Phase: phase},
+				}
+			}
+
+			source := watch.NewFake()
+			w := storage.scopeWatch(user, "orgName", PersonalScope, fields.OneTermEqualSelector("metadata.name", "foo"), source)
+			defer w.Stop()
+
+			go func() {
+				source.Add(workspace("foo--1", tenancyv1alpha1.ClusterWorkspacePhaseScheduling))
+				source.Add(workspace("bar", tenancyv1alpha1.ClusterWorkspacePhaseReady))
+				source.Modify(workspace("foo--1", tenancyv1alpha1.ClusterWorkspacePhaseInitializing))
+				source.Modify(workspace("foo--1", tenancyv1alpha1.ClusterWorkspacePhaseReady))
+				source.Delete(&tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "foo--1"}})
+			}()
+
+			var events []string
+			for event := range w.ResultChan() {
+				ws := event.Object.(*tenancyv1beta1.Workspace)
+				events = append(events, fmt.Sprintf("%s %s %s", event.Type, ws.Name, ws.Status.Phase))
+			}
+			require.Equal(t, []string{
+				"ADDED foo Scheduling",
+				"MODIFIED foo Initializing",
+				"MODIFIED foo Ready",
+				"DELETED foo ",
+			}, events, "expected the phase transitions of foo under its pretty name, and the stream to end after its deletion")
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestCreateWorkspaceInOrganizationNotAllowed(t *testing.T) {
+func TestListIdleWorkspaces(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
 		Groups: []string{"test-group"},
 	}
+	created := metav1.NewTime(time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC))
+	active := metav1.NewTime(time.Date(2022, 3, 10, 0, 0, 0, 0, time.UTC))
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
@@ -810,24 +922,40 @@ func TestCreateWorkspaceInOrganizationNotAllowed(t *testing.T) {
 				"get":    mockReviewer{},
 				"delete": mockReviewer{},
 			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "idle", CreationTimestamp: created},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "active", CreationTimestamp: created},
+					Status:     tenancyv1alpha1.ClusterWorkspaceStatus{LastActivityTime: &active},
+				},
+			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			newWorkspace := tenancyv1alpha1.ClusterWorkspace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "foo",
-				},
-			}
-			response, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
-			require.EqualError(t, err, "workspace.tenancy.kcp.dev is forbidden: creating a workspace in only possible in the personal workspaces scope for now")
-			require.Nil(t, response)
-			checkedUsers := listerCheckedUsers()
-			require.Len(t, checkedUsers, 0, "The workspaceLister shouldn't have checked any user")
+			response, err := storage.List(context.WithValue(ctx, virtualcontext.RequestQueryKey, url.Values{IdleSinceParameter: []string{"2022-03-05T00:00:00Z"}}), &metainternal.ListOptions{})
+			require.NoError(t, err)
+			workspaces := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "workspaces.Items should have len 1")
+			assert.Equal(t, "idle", workspaces.Items[0].Name)
+
+			response, err = storage.List(context.WithValue(ctx, virtualcontext.RequestQueryKey, url.Values{IdleSinceParameter: []string{"2022-03-20T00:00:00Z"}}), &metainternal.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, response.(*tenancyv1beta1.WorkspaceList).Items, 2, "both workspaces should be idle")
+
+			response, err = storage.List(ctx, &metainternal.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, response.(*tenancyv1beta1.WorkspaceList).Items, 2, "workspaces should not be filtered without idle-since")
+
+			_, err = storage.List(context.WithValue(ctx, virtualcontext.RequestQueryKey, url.Values{IdleSinceParameter: []string{"yesterday"}}), &metainternal.ListOptions{})
+			require.Error(t, err)
+			assert.True(t, kerrors.IsBadRequest(err), "expected a bad request error, got %v", err)
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestCreateWorkspace(t *testing.T) {
+func TestListWorkspacesWithLabelSelector(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
@@ -836,133 +964,201 @@ func TestCreateWorkspace(t *testing.T) {
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   OrganizationScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
 				"get":    mockReviewer{},
 				"delete": mockReviewer{},
 			},
-		},
-		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			newWorkspace := tenancyv1beta1.Workspace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "foo",
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "staging"}},
 				},
-			}
-			response, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
-			require.NoError(t, err)
-			require.NotNil(t, response)
-			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
-			workspace := response.(*tenancyv1beta1.Workspace)
-			assert.Equal(t, "foo", workspace.Name)
-			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
-			require.NoError(t, err)
-			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
-			assert.ElementsMatch(t, crbs.Items, append(testData.clusterRoleBindings,
-				rbacv1.ClusterRoleBinding{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "owner-workspace-foo-test-user",
-						Labels: map[string]string{
-							PrettyNameLabel:   "foo",
-							InternalNameLabel: "foo",
-						},
-					},
-					RoleRef: rbacv1.RoleRef{
-						APIGroup: "rbac.authorization.k8s.io",
-						Kind:     "ClusterRole",
-						Name:     "owner-workspace-foo-test-user",
-					},
-					Subjects: []rbacv1.Subject{
-						{
-							Kind: "User",
-							Name: "test-user",
-						},
-					},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "bar", Labels: map[string]string{"env": "prod"}},
 				},
-			))
-			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.List(ctx, &metainternal.ListOptions{LabelSelector: labels.SelectorFromSet(labels.Set{"env": "staging"})})
 			require.NoError(t, err)
-			crs := crList.(*rbacv1.ClusterRoleList)
-			assert.ElementsMatch(t, crs.Items, append(testData.clusterRoles,
-				rbacv1.ClusterRole{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "lister-workspace-foo-test-user",
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-						{
-							Verbs:         []string{"view"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"clusterworkspaces/content"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
-				},
-				rbacv1.ClusterRole{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "owner-workspace-foo-test-user",
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get", "delete"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-						{
-							Verbs:         []string{"view", "edit"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"clusterworkspaces/content"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
-				},
-			))
+			workspaces := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "workspaces.Items should have len 1")
+			assert.Equal(t, "foo", workspaces.Items[0].Name)
+			assert.Equal(t, map[string]string{"env": "staging"}, workspaces.Items[0].Labels)
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestCreateWorkspaceWithPrettyName(t *testing.T) {
-	anotherUser := &kuser.DefaultInfo{
-		Name:   "another-user",
-		UID:    "another-uid",
-		Groups: []string{},
-	}
+func TestListWorkspacesWithPagination(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
 		Groups: []string{"test-group"},
 	}
+	var clusterWorkspaces []tenancyv1alpha1.ClusterWorkspace
+	for _, i := range rand.Perm(25) {
+		clusterWorkspaces = append(clusterWorkspaces, tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("ws-%02d", i), ResourceVersion: strconv.Itoa(100 + i)},
+		})
+	}
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   OrganizationScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
 				"get":    mockReviewer{},
 				"delete": mockReviewer{},
 			},
-			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
-				},
-			},
-			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
-				{
+			clusterWorkspaces: clusterWorkspaces,
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			var names []string
+			var pageSizes []int
+			continueValue := ""
+			for {
+				response, err := storage.List(ctx, &metainternal.ListOptions{Limit: 10, Continue: continueValue})
+				require.NoError(t, err)
+				workspaces := response.(*tenancyv1beta1.WorkspaceList)
+				pageSizes = append(pageSizes, len(workspaces.Items))
+				for _, workspace := range workspaces.Items {
+					names = append(names, workspace.Name)
+				}
+				if workspaces.Continue == "" {
+					assert.Nil(t, workspaces.RemainingItemCount)
+					break
+				}
+				require.NotNil(t, workspaces.RemainingItemCount)
+				assert.Equal(t, int64(25-len(names)), *workspaces.RemainingItemCount)
+				continueValue = workspaces.Continue
+			}
+
+			assert.Equal(t, []int{10, 10, 5}, pageSizes)
+			var expected []string
+			for i := 0; i < 25; i++ {
+				expected = append(expected, fmt.Sprintf("ws-%02d", i))
+			}
+			assert.Equal(t, expected, names, "expected all workspaces in order, without duplicates or gaps")
+
+			for _, invalid := range []string{"not-base64!", base64.RawURLEncoding.EncodeToString([]byte("{}")), base64.RawURLEncoding.EncodeToString([]byte(`{"v":"tenancy.kcp.dev/v1beta1","rv":"abc","start":"ws-03"}`))} {
+				_, err := storage.List(ctx, &metainternal.ListOptions{Limit: 10, Continue: invalid})
+				require.Error(t, err)
+				assert.True(t, kerrors.IsResourceExpired(err), "expected a resource expired error for %q, got %v", invalid, err)
+			}
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestListWorkspacesSortedBy(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	var clusterWorkspaces []tenancyv1alpha1.ClusterWorkspace
+	for i, name := range []string{"echo", "alpha", "delta", "charlie", "bravo", "foxtrot"} {
+		clusterWorkspaces = append(clusterWorkspaces, tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				ResourceVersion:   strconv.Itoa(100 + i),
+				CreationTimestamp: metav1.NewTime(created.Add(time.Duration(i/2) * time.Minute)),
+			},
+		})
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   OrganizationScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: clusterWorkspaces,
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			list := func(sortBy string, limit int64) ([]string, error) {
+				listCtx := ctx
+				if sortBy != "" {
+					listCtx = context.WithValue(ctx, virtualcontext.RequestQueryKey, url.Values{SortByParameter: []string{sortBy}})
+				}
+				var names []string
+				continueValue := ""
+				for {
+					response, err := storage.List(listCtx, &metainternal.ListOptions{Limit: limit, Continue: continueValue})
+					if err != nil {
+						return nil, err
+					}
+					workspaces := response.(*tenancyv1beta1.WorkspaceList)
+					for _, workspace := range workspaces.Items {
+						names = append(names, workspace.Name)
+					}
+					if workspaces.Continue == "" {
+						return names, nil
+					}
+					continueValue = workspaces.Continue
+				}
+			}
+
+			names, err := list("", 0)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot"}, names, "expected the workspaces sorted by name by default")
+
+			names, err = list("creationTimestamp", 0)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"alpha", "echo", "charlie", "delta", "bravo", "foxtrot"}, names, "expected the workspaces sorted by creation timestamp, then by name")
+
+			names, err = list("creationTimestamp", 2)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"alpha", "echo", "charlie", "delta", "bravo", "foxtrot"}, names, "expected the pages to follow the creation timestamp order")
+
+			names, err = list("name", 4)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot"}, names)
+
+			_, err = list("size", 0)
+			require.Error(t, err)
+			assert.True(t, kerrors.IsBadRequest(err), "expected a bad request error, got %v", err)
+
+			response, err := storage.List(ctx, &metainternal.ListOptions{Limit: 2})
+			require.NoError(t, err)
+			byNameContinue := response.(*tenancyv1beta1.WorkspaceList).Continue
+			_, err = storage.List(context.WithValue(ctx, virtualcontext.RequestQueryKey, url.Values{SortByParameter: []string{"creationTimestamp"}}), &metainternal.ListOptions{Limit: 2, Continue: byNameContinue})
+			require.Error(t, err)
+			assert.True(t, kerrors.IsResourceExpired(err), "expected a resource expired error for a continue token of another order, got %v", err)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestGetPersonalWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:        getRoleBindingName(OwnerRoleType, "foo", anotherUser),
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
 						ClusterName: "orgName",
 						Labels: map[string]string{
 							PrettyNameLabel:   "foo",
@@ -972,161 +1168,3008 @@ func TestCreateWorkspaceWithPrettyName(t *testing.T) {
 					Subjects: []rbacv1.Subject{
 						{
 							Kind: "User",
-							Name: anotherUser.Name,
+							Name: user.Name,
 						},
 					},
 				},
 			},
-			clusterRoles: []rbacv1.ClusterRole{
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.Get(ctx, "foo", nil)
+			require.NoError(t, err)
+			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
+			responseWorkspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, "foo", responseWorkspace.Name)
+			checkedUsers := listerCheckedUsers()
+			require.Len(t, checkedUsers, 1, "The workspaceLister should have checked only 1 user")
+			assert.Equal(t,
+				&kuser.DefaultInfo{
+					Name:   user.Name,
+					UID:    user.UID,
+					Groups: []string{},
+				},
+				checkedUsers[0],
+				"The workspaceLister should have checked the user without its groups")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestGetPersonalWorkspaceNotScheduled(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	unscheduled := tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+		Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling},
+	}
+	conditions.MarkFalse(&unscheduled, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No available shards to schedule the workspace.")
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{unscheduled},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
 				{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(OwnerRoleType, "foo", anotherUser),
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
 						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
 							InternalNameLabel: "foo",
 						},
 					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get", "delete"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
+					Subjects: []rbacv1.Subject{
 						{
-							Verbs:         []string{"view", "edit"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"clusterworkspaces/content"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
+							Kind: "User",
+							Name: user.Name,
 						},
 					},
 				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.Get(ctx, "foo", nil)
+			require.NoError(t, err)
+			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
+			responseWorkspace := response.(*tenancyv1beta1.Workspace)
+			require.True(t, conditions.IsFalse(responseWorkspace, conditionsv1alpha1.ReadyCondition), "the workspace should not be ready")
+			assert.Equal(t, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditions.GetReason(responseWorkspace, conditionsv1alpha1.ReadyCondition))
+			assert.Equal(t, "No available shards to schedule the workspace.", conditions.GetMessage(responseWorkspace, conditionsv1alpha1.ReadyCondition))
+			assert.Len(t, responseWorkspace.Status.Conditions, 1, "only the Ready condition should be surfaced")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestGetPersonalWorkspaceShard(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						Phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+						BaseURL:  "https://boston.kcp.dev/clusters/orgName:foo",
+						Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "boston"},
+					},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
 				{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(ListerRoleType, "foo", anotherUser),
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
 						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
 							InternalNameLabel: "foo",
 						},
 					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-						{
-							Verbs:         []string{"view"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"clusterworkspaces/content"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
+					Subjects: []rbacv1.Subject{{Kind: "User", Name: user.Name}},
 				},
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			newWorkspace := tenancyv1beta1.Workspace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "foo",
-				},
-			}
-			response, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
+			response, err := storage.Get(ctx, "foo", nil)
 			require.NoError(t, err)
-			require.NotNil(t, response)
-			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
-			workspace := response.(*tenancyv1beta1.Workspace)
-			assert.Equal(t, "foo", workspace.Name)
-			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
+			responseWorkspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseReady, responseWorkspace.Status.Phase)
+			assert.Equal(t, "boston", responseWorkspace.Status.Shard, "the shard hosting the ready workspace should be shown")
+			assert.Equal(t, "https://boston.kcp.dev/clusters/orgName:foo", responseWorkspace.Status.URL)
+
+			response, err = storage.List(ctx, nil)
 			require.NoError(t, err)
-			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
-			assert.ElementsMatch(t, crbs.Items, append(testData.clusterRoleBindings,
-				rbacv1.ClusterRoleBinding{
+			responseList := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, responseList.Items, 1)
+			assert.Equal(t, "boston", responseList.Items[0].Status.Shard, "the shard should be shown in lists too")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestGetPersonalWorkspaceWithPrettyName(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", Annotations: ownedBy(user)},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "owner-workspace-foo-test-user",
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
 						Labels: map[string]string{
 							PrettyNameLabel:   "foo",
 							InternalNameLabel: "foo--1",
 						},
 					},
-					RoleRef: rbacv1.RoleRef{
-						APIGroup: "rbac.authorization.k8s.io",
-						Kind:     "ClusterRole",
-						Name:     "owner-workspace-foo-test-user",
-					},
 					Subjects: []rbacv1.Subject{
 						{
 							Kind: "User",
-							Name: "test-user",
+							Name: user.Name,
 						},
 					},
 				},
-			))
-			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
-			require.NoError(t, err)
-			crs := crList.(*rbacv1.ClusterRoleList)
-			assert.ElementsMatch(t, crs.Items, append(testData.clusterRoles,
-				rbacv1.ClusterRole{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "lister-workspace-foo-test-user",
-						Labels: map[string]string{
-							InternalNameLabel: "foo--1",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get"},
-							ResourceNames: []string{"foo--1"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-						{
-							Verbs:         []string{"view"},
-							ResourceNames: []string{"foo--1"},
-							Resources:     []string{"clusterworkspaces/content"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.Get(ctx, "foo", nil)
+			require.NoError(t, err)
+			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
+			responseWorkspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, "foo", responseWorkspace.Name)
+			checkedUsers := listerCheckedUsers()
+			require.Len(t, checkedUsers, 1, "The workspaceLister should have checked only 1 user")
+			assert.Equal(t,
+				&kuser.DefaultInfo{
+					Name:   user.Name,
+					UID:    user.UID,
+					Groups: []string{},
+				},
+				checkedUsers[0],
+				"The workspaceLister should have checked the user without its groups")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestGetPersonalWorkspaceNotFoundNoPermission(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo2"},
+				},
+			},
+			workspaceLister: &mockLister{
+				workspaces: []tenancyv1alpha1.ClusterWorkspace{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo2"},
+					},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.Get(ctx, "foo", nil)
+			require.Error(t, err)
+			require.Nil(t, response)
+			checkedUsers := listerCheckedUsers()
+			require.Len(t, checkedUsers, 1, "The workspaceLister should have checked only 1 user")
+			assert.Equal(t,
+				&kuser.DefaultInfo{
+					Name:   user.Name,
+					UID:    user.UID,
+					Groups: []string{},
+				},
+				checkedUsers[0],
+				"The workspaceLister should have checked the user without its groups")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestGetSharedWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   SharedScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "bar"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.Get(ctx, "bar", nil)
+			require.NoError(t, err)
+			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
+			assert.Equal(t, "bar", response.(*tenancyv1beta1.Workspace).Name)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestGetOwnedWorkspaceInSharedScopeNotFound(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   SharedScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "bar"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.Get(ctx, "foo", nil)
+			require.Error(t, err)
+			require.True(t, kerrors.IsNotFound(err), "expected a NotFound error, got %v", err)
+			require.Nil(t, response)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceInOrganizationNotAllowed(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   OrganizationScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+			}
+			response, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
+			require.EqualError(t, err, "workspace.tenancy.kcp.dev is forbidden: creating a workspace in only possible in the personal workspaces scope for now")
+			require.Nil(t, response)
+			checkedUsers := listerCheckedUsers()
+			require.Len(t, checkedUsers, 0, "The workspaceLister shouldn't have checked any user")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+			}
+			response, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+			require.NotNil(t, response)
+			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
+			workspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, "foo", workspace.Name)
+			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
+			require.NoError(t, err)
+			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
+			assert.ElementsMatch(t, crbs.Items, append(testData.clusterRoleBindings,
+				rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "owner-workspace-foo-test-user",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					RoleRef: rbacv1.RoleRef{
+						APIGroup: "rbac.authorization.k8s.io",
+						Kind:     "ClusterRole",
+						Name:     "owner-workspace-foo-test-user",
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: "test-user",
+						},
+					},
+				},
+			))
+			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			require.NoError(t, err)
+			crs := crList.(*rbacv1.ClusterRoleList)
+			assert.ElementsMatch(t, crs.Items, append(testData.clusterRoles,
+				rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "lister-workspace-foo-test-user",
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+						{
+							Verbs:         []string{"view"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"clusterworkspaces/content"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+				rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "owner-workspace-foo-test-user",
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get", "update", "delete"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+						{
+							Verbs:         []string{"view", "edit"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"clusterworkspaces/content"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+			))
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceWithPrettyName(t *testing.T) {
+	anotherUser := &kuser.DefaultInfo{
+		Name:   "another-user",
+		UID:    "another-uid",
+		Groups: []string{},
+	}
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", anotherUser),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: anotherUser.Name,
+						},
+					},
+				},
+			},
+			clusterRoles: []rbacv1.ClusterRole{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", anotherUser),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get", "delete"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+						{
+							Verbs:         []string{"view", "edit"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"clusterworkspaces/content"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(ListerRoleType, "foo", anotherUser),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+						{
+							Verbs:         []string{"view"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"clusterworkspaces/content"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+			}
+			response, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+			require.NotNil(t, response)
+			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
+			workspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, "foo", workspace.Name)
+			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
+			require.NoError(t, err)
+			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
+			assert.ElementsMatch(t, crbs.Items, append(testData.clusterRoleBindings,
+				rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "owner-workspace-foo-test-user",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo--1",
+						},
+					},
+					RoleRef: rbacv1.RoleRef{
+						APIGroup: "rbac.authorization.k8s.io",
+						Kind:     "ClusterRole",
+						Name:     "owner-workspace-foo-test-user",
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: "test-user",
+						},
+					},
+				},
+			))
+			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			require.NoError(t, err)
+			crs := crList.(*rbacv1.ClusterRoleList)
+			assert.ElementsMatch(t, crs.Items, append(testData.clusterRoles,
+				rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "lister-workspace-foo-test-user",
+						Labels: map[string]string{
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get"},
+							ResourceNames: []string{"foo--1"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+						{
+							Verbs:         []string{"view"},
+							ResourceNames: []string{"foo--1"},
+							Resources:     []string{"clusterworkspaces/content"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+				rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "owner-workspace-foo-test-user",
+						Labels: map[string]string{
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get", "update", "delete"},
+							ResourceNames: []string{"foo--1"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+						{
+							Verbs:         []string{"view", "edit"},
+							ResourceNames: []string{"foo--1"},
+							Resources:     []string{"clusterworkspaces/content"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+			))
+
+			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			require.NoError(t, err)
+			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
+			assert.ElementsMatch(t, wsList.Items, append(testData.clusterWorkspaces,
+				tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "foo--1",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:     "test-user",
+							tenancyv1alpha1.ClusterWorkspaceCreatedByAnnotation: "test-user",
+						},
+					},
+				},
+			))
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspacePrettyNameAlreadyExists(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+			clusterRoles: []rbacv1.ClusterRole{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(ListerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+						{
+							Verbs:         []string{"view"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"clusterworkspaces/content"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get", "delete"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+						{
+							Verbs:         []string{"view", "edit"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"clusterworkspaces/content"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+			}
+			response, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
+			require.EqualError(t, err, "workspaces.tenancy.kcp.dev \"foo\" already exists")
+			require.Nil(t, response)
+
+			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
+			require.NoError(t, err)
+			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
+			assert.ElementsMatch(t, crbs.Items, testData.clusterRoleBindings)
+			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			require.NoError(t, err)
+			crs := crList.(*rbacv1.ClusterRoleList)
+			assert.ElementsMatch(t, crs.Items, testData.clusterRoles)
+			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			require.NoError(t, err)
+			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
+			assert.ElementsMatch(t, wsList.Items, testData.clusterWorkspaces)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestDeleteWorkspaceNotFound(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+			clusterRoles: []rbacv1.ClusterRole{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get", "delete"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(ListerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, deletedNow, err := storage.Delete(ctx, "foo-with-does-not-exist", nil, &metav1.DeleteOptions{})
+			assert.EqualError(t, err, "workspaces.tenancy.kcp.dev \"foo-with-does-not-exist\" not found")
+			assert.Nil(t, response)
+			assert.False(t, deletedNow)
+			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
+			require.NoError(t, err)
+			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
+			assert.ElementsMatch(t, crbs.Items, testData.clusterRoleBindings)
+			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			require.NoError(t, err)
+			crs := crList.(*rbacv1.ClusterRoleList)
+			assert.ElementsMatch(t, crs.Items, testData.clusterRoles)
+			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			require.NoError(t, err)
+			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
+			assert.ElementsMatch(t, wsList.Items, testData.clusterWorkspaces)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestDeleteWorkspaceForbidden(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+			clusterRoles: []rbacv1.ClusterRole{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get", "delete"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(ListerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, deletedNow, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
+			assert.EqualError(t, err, "workspace.tenancy.kcp.dev is forbidden: User test-user doesn't have the permission to delete workspace foo")
+			assert.Nil(t, response)
+			assert.False(t, deletedNow)
+			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
+			require.NoError(t, err)
+			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
+			assert.ElementsMatch(t, crbs.Items, testData.clusterRoleBindings)
+			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			require.NoError(t, err)
+			crs := crList.(*rbacv1.ClusterRoleList)
+			assert.ElementsMatch(t, crs.Items, testData.clusterRoles)
+			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			require.NoError(t, err)
+			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
+			assert.ElementsMatch(t, wsList.Items, testData.clusterWorkspaces)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestDeleteWorkspaceNotVisible(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   OrganizationScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{},
+				"delete": mockReviewer{
+					"foo": mockReview{
+						users: []string{"test-user"},
+					},
+				},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, deletedNow, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
+			assert.EqualError(t, err, "workspaces.tenancy.kcp.dev \"foo\" not found")
+			assert.Nil(t, response)
+			assert.False(t, deletedNow)
+			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			require.NoError(t, err)
+			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
+			assert.ElementsMatch(t, wsList.Items, testData.clusterWorkspaces)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestDeleteWorkspaceWithPropagationPolicy(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   OrganizationScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{
+					"foo": mockReview{
+						users: []string{"test-user"},
+					},
+				},
+				"delete": mockReviewer{
+					"foo": mockReview{
+						users: []string{"test-user"},
+					},
+				},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			orphan := metav1.DeletePropagationOrphan
+			_, _, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{PropagationPolicy: &orphan})
+			require.NoError(t, err)
+			var deleteActions []clienttesting.DeleteActionImpl
+			for _, action := range kcpClient.Actions() {
+				if deleteAction, ok := action.(clienttesting.DeleteActionImpl); ok {
+					deleteActions = append(deleteActions, deleteAction)
+				}
+			}
+			require.Len(t, deleteActions, 1)
+			assert.Equal(t, "foo", deleteActions[0].GetName())
+			assert.Equal(t, &orphan, deleteActions[0].GetDeleteOptions().PropagationPolicy)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestDeletePersonalWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{
+					"foo": mockReview{
+						users:  []string{"test-user"},
+						groups: []string{""},
+					},
+				},
+				"delete": mockReviewer{
+					"foo": mockReview{
+						users:  []string{"test-user"},
+						groups: []string{""},
+					},
+				},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+			clusterRoles: []rbacv1.ClusterRole{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get", "delete"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(ListerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get"},
+							ResourceNames: []string{"foo"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, deletedNow, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
+			assert.NoError(t, err)
+			assert.Nil(t, response)
+			assert.False(t, deletedNow)
+			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
+			require.NoError(t, err)
+			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
+			assert.Empty(t, crbs.Items)
+			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			require.NoError(t, err)
+			crs := crList.(*rbacv1.ClusterRoleList)
+			assert.Empty(t, crs.Items)
+			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			require.NoError(t, err)
+			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
+			assert.Empty(t, wsList.Items)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestDeletePersonalWorkspaceWithPrettyName(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{
+					"foo--1": mockReview{
+						users:  []string{"test-user"},
+						groups: []string{""},
+					},
+				},
+				"delete": mockReviewer{
+					"foo--1": mockReview{
+						users:  []string{"test-user"},
+						groups: []string{""},
+					},
+				},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+			clusterRoles: []rbacv1.ClusterRole{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get", "delete"},
+							ResourceNames: []string{"foo--1"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(ListerRoleType, "foo", user),
+						Labels: map[string]string{
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Rules: []rbacv1.PolicyRule{
+						{
+							Verbs:         []string{"get"},
+							ResourceNames: []string{"foo--1"},
+							Resources:     []string{"workspaces"},
+							APIGroups:     []string{"tenancy.kcp.dev"},
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, deletedNow, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
+			assert.NoError(t, err)
+			assert.Nil(t, response)
+			assert.False(t, deletedNow)
+			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
+			require.NoError(t, err)
+			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
+			assert.Empty(t, crbs.Items)
+			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			require.NoError(t, err)
+			crs := crList.(*rbacv1.ClusterRoleList)
+			assert.Empty(t, crs.Items)
+			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			require.NoError(t, err)
+			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
+			assert.Empty(t, wsList.Items)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestUpdatePersonalWorkspaceLabelsWithPrettyName(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	review := mockReviewer{
+		"foo--1": mockReview{
+			users: []string{"test-user"},
+		},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    review,
+				"delete": review,
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", Labels: map[string]string{"env": "staging"}, Annotations: ownedBy(user)},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "prod"}, Annotations: map[string]string{"note": "hello"}},
+				Spec:       tenancyv1beta1.WorkspaceSpec{Type: "Universal"},
+				Status:     tenancyv1beta1.WorkspaceStatus{URL: "https://example.com"},
+			}
+			response, created, err := storage.Update(ctx, "foo", rest.DefaultUpdatedObjectInfo(newWorkspace), nil, nil, false, &metav1.UpdateOptions{})
+			require.NoError(t, err)
+			assert.False(t, created)
+			workspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, "foo", workspace.Name)
+			assert.Equal(t, map[string]string{"env": "prod"}, workspace.Labels)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo--1", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"env": "prod"}, clusterWorkspace.Labels)
+			expectedAnnotations := ownedBy(user)
+			expectedAnnotations["note"] = "hello"
+			assert.Equal(t, expectedAnnotations, clusterWorkspace.Annotations, "reserved annotations should be kept")
+			assert.Empty(t, clusterWorkspace.Status.BaseURL, "only labels and annotations should be propagated")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestUpdateWorkspaceWithOwnerRoleWithoutUpdate(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	// owner ClusterRoles created before workspaces could be updated only grant get and delete
+	review := mockReviewer{
+		"foo": mockReview{
+			users: []string{"test-user"},
+		},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   OrganizationScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    review,
+				"delete": review,
+				"update": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "staging"}},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "prod"}},
+			}
+			_, _, err := storage.Update(ctx, "foo", rest.DefaultUpdatedObjectInfo(newWorkspace), nil, nil, false, &metav1.UpdateOptions{})
+			require.NoError(t, err)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"env": "prod"}, clusterWorkspace.Labels)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestUpdateWorkspaceRejectsImmutableChanges(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	review := mockReviewer{
+		"foo": mockReview{
+			users: []string{"test-user"},
+		},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   OrganizationScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    review,
+				"delete": review,
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "staging"}, Annotations: ownedBy(user)},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			for _, newWorkspace := range []*tenancyv1beta1.Workspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "prod"}},
+					Spec:       tenancyv1beta1.WorkspaceSpec{Type: "Organization"},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation: "someone-else"}},
+				},
+			} {
+				_, _, err := storage.Update(ctx, "foo", rest.DefaultUpdatedObjectInfo(newWorkspace), nil, nil, false, &metav1.UpdateOptions{})
+				require.Error(t, err)
+				assert.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+			}
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, testData.clusterWorkspaces[0], *clusterWorkspace)
+		},
+	}
+	applyTest(t, test)
+}
+
+// mergePatchObjectInfo applies a JSON merge patch to the current object, like the patch handler of the apiserver.
+type mergePatchObjectInfo []byte
+
+func (mergePatchObjectInfo) Preconditions() *metav1.Preconditions { return nil }
+
+func (p mergePatchObjectInfo) UpdatedObject(ctx context.Context, oldObj runtime.Object) (runtime.Object, error) {
+	oldData, err := json.Marshal(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := jsonpatch.MergePatch(oldData, p)
+	if err != nil {
+		return nil, err
+	}
+	var workspace tenancyv1beta1.Workspace
+	if err := json.Unmarshal(newData, &workspace); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func TestPatchPersonalWorkspaceLabel(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	review := mockReviewer{
+		"foo--1": mockReview{
+			users: []string{"test-user"},
+		},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    review,
+				"delete": review,
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", Labels: map[string]string{"env": "staging", "team": "a"}, Annotations: ownedBy(user)},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			patch := mergePatchObjectInfo(`{"metadata":{"labels":{"env":"prod"},"annotations":{"note":"hello"}}}`)
+			response, _, err := storage.Update(ctx, "foo", patch, nil, nil, false, &metav1.UpdateOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"env": "prod", "team": "a"}, response.(*tenancyv1beta1.Workspace).Labels)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo--1", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"env": "prod", "team": "a"}, clusterWorkspace.Labels)
+			assert.Equal(t, "hello", clusterWorkspace.Annotations["note"])
+			assert.Equal(t, user.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+		},
+	}
+	applyTest(t, test)
+}
+
+// applyObjectInfo applies a Workspace with the given field manager, like the patch handler of the apiserver
+// does for server-side apply.
+type applyObjectInfo struct {
+	fieldManager *fieldmanager.FieldManager
+	manager      string
+	applied      *tenancyv1beta1.Workspace
+}
+
+func (applyObjectInfo) Preconditions() *metav1.Preconditions { return nil }
+
+func (i applyObjectInfo) UpdatedObject(ctx context.Context, oldObj runtime.Object) (runtime.Object, error) {
+	applied := i.applied.DeepCopy()
+	applied.TypeMeta = metav1.TypeMeta{APIVersion: tenancyv1beta1.SchemeGroupVersion.String(), Kind: "Workspace"}
+	return i.fieldManager.Apply(oldObj, applied, i.manager, false)
+}
+
+// newWorkspaceFieldManager returns the field manager the virtual workspace apiserver uses for Workspaces.
+func newWorkspaceFieldManager(t *testing.T) *fieldmanager.FieldManager {
+	scheme := runtime.NewScheme()
+	metav1.AddToGroupVersion(scheme, schema.GroupVersion{Version: "v1"})
+	require.NoError(t, tenancyv1beta1.AddToScheme(scheme))
+	// like in the virtual workspace apiserver, the external type stands in for the internal one
+	scheme.AddKnownTypes(schema.GroupVersion{Group: tenancyv1beta1.SchemeGroupVersion.Group, Version: runtime.APIVersionInternal}, &tenancyv1beta1.Workspace{})
+
+	config := genericapiserver.DefaultOpenAPIConfig(kcpopenapi.GetOpenAPIDefinitions, openapinamer.NewDefinitionNamer(scheme))
+	config.Info.Version = "test"
+	openAPISpec, err := openapibuilder.BuildOpenAPIDefinitionsForResources(config, openapiutil.GetCanonicalTypeName(&tenancyv1beta1.Workspace{}))
+	require.NoError(t, err)
+	models, err := utilopenapi.ToProtoModels(openAPISpec)
+	require.NoError(t, err)
+	typeConverter, err := fieldmanager.NewTypeConverter(models, false)
+	require.NoError(t, err)
+
+	kind := tenancyv1beta1.SchemeGroupVersion.WithKind("Workspace")
+	fieldManager, err := fieldmanager.NewDefaultFieldManager(typeConverter, runtime.UnsafeObjectConvertor(scheme), scheme, scheme, kind, schema.GroupVersion{Group: kind.Group, Version: runtime.APIVersionInternal}, "", nil)
+	require.NoError(t, err)
+	return fieldManager
+}
+
+func TestApplyPersonalWorkspaceWithTwoManagers(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	review := mockReviewer{
+		"foo--1": mockReview{
+			users: []string{"test-user"},
+		},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    review,
+				"delete": review,
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", Annotations: ownedBy(user)},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{*ownerBinding("orgName", "foo", "foo--1", user)},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			fieldManager := newWorkspaceFieldManager(t)
+			apply := func(manager string, labels map[string]string) (*tenancyv1beta1.Workspace, error) {
+				applied := &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: labels}}
+				response, _, err := storage.Update(ctx, "foo", applyObjectInfo{fieldManager: fieldManager, manager: manager, applied: applied}, nil, nil, true, &metav1.UpdateOptions{FieldManager: manager})
+				if err != nil {
+					return nil, err
+				}
+				return response.(*tenancyv1beta1.Workspace), nil
+			}
+
+			_, err := apply("gitops-a", map[string]string{"team": "a"})
+			require.NoError(t, err)
+			workspace, err := apply("gitops-b", map[string]string{"env": "prod"})
+			require.NoError(t, err, "applying other fields with another manager should not conflict")
+			require.Equal(t, map[string]string{"team": "a", "env": "prod"}, workspace.Labels)
+			var appliers []string
+			for _, entry := range workspace.ManagedFields {
+				if entry.Operation == metav1.ManagedFieldsOperationApply {
+					appliers = append(appliers, entry.Manager)
+				}
+			}
+			require.ElementsMatch(t, []string{"gitops-a", "gitops-b"}, appliers)
+			_, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceManagedFieldsAnnotation]
+			require.False(t, found, "the managed fields annotation should not be projected")
+
+			_, err = apply("gitops-a", map[string]string{"team": "a"})
+			require.NoError(t, err, "applying the same configuration again should not conflict")
+
+			_, err = apply("gitops-b", map[string]string{"env": "prod", "team": "b"})
+			require.True(t, kerrors.IsConflict(err), "expected a conflict on a field owned by another manager, got %v", err)
+			require.Contains(t, err.Error(), "gitops-a")
+
+			workspace, err = apply("gitops-a", nil)
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"env": "prod"}, workspace.Labels, "expected only the labels no longer applied by gitops-a to be removed")
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo--1", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"env": "prod"}, clusterWorkspace.Labels)
+			require.Equal(t, user.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestUpdateWorkspaceForbidden(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   OrganizationScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{
+					"foo": mockReview{
+						users: []string{"test-user"},
+					},
+				},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "staging"}},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "prod"}},
+			}
+			_, _, err := storage.Update(ctx, "foo", rest.DefaultUpdatedObjectInfo(newWorkspace), nil, nil, false, &metav1.UpdateOptions{})
+			require.Error(t, err)
+			assert.True(t, kerrors.IsForbidden(err), "expected a forbidden error, got %v", err)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"env": "staging"}, clusterWorkspace.Labels)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceCloneFromPrettyName(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get": mockReviewer{
+					"foo--1": mockReview{
+						users: []string{"test-user"},
+					},
+				},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo--1", Annotations: ownedBy(user)},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo--1",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: "User",
+							Name: user.Name,
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "bar",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation: "foo"},
+				},
+			}
+			response, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+			workspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, "foo", workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation], "the pretty name of the source should be returned")
+			assert.Equal(t, "foo", newWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation], "the request object should not be mutated")
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "bar", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "foo--1", clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation], "the source should be referenced by its internal name")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceOwnerAnnotations(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group", "other-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation: "someone-else"},
+				},
+			}
+			_, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "test-user", clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation], "the requesting user should be the owner")
+			assert.NotContains(t, clusterWorkspace.Annotations, tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation, "the groups of the user should not own the workspace unless requested")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateGroupOwnedWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "user-1",
+		UID:    "user-1-uid",
+		Groups: []string{"team-1", "system:authenticated"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			for _, groups := range []string{"team-2", "system:authenticated", "team-1,"} {
+				_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "foo",
+						Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: groups},
+					},
+				}, nil, &metav1.CreateOptions{})
+				require.True(t, kerrors.IsInvalid(err), "expected Invalid for owner groups %q, got %v", groups, err)
+			}
+
+			_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1"},
+				},
+			}, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation], "the requesting user should still be the owner")
+			assert.Equal(t, "team-1", clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation])
+
+			crb, err := kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, "owner-workspace-foo-group-team-1", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{PrettyNameLabel: "foo", InternalNameLabel: "foo"}, crb.Labels)
+			assert.Equal(t, "owner-workspace-foo-user-1", crb.RoleRef.Name)
+			assert.Equal(t, []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "team-1"}}, crb.Subjects)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateGroupOwnedWorkspaceRollsBackRBAC(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		verb     string
+		resource string
+	}{
+		{name: "owner role creation fails", verb: "create", resource: "clusterroles"},
+		{name: "owner binding update fails", verb: "update", resource: "clusterrolebindings"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			user := &kuser.DefaultInfo{
+				Name:   "user-1",
+				UID:    "user-1-uid",
+				Groups: []string{"team-1", "system:authenticated"},
+			}
+			test := TestDescription{
+				TestData: TestData{
+					user:             user,
+					scope:            PersonalScope,
+					orgName:          "orgName",
+					reviewerProvider: mockReviewerProvider{},
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					kubeClient.PrependReactor(tc.verb, tc.resource, func(action clienttesting.Action) (bool, runtime.Object, error) {
+						return true, nil, fmt.Errorf("injected failure")
+					})
+
+					_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "foo",
+							Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1"},
+						},
+					}, nil, &metav1.CreateOptions{})
+					require.Error(t, err)
+
+					crbs, err := kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+					require.NoError(t, err)
+					assert.Empty(t, crbs.Items, "the user and group bindings should be deleted")
+					crs, err := kubeClient.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+					require.NoError(t, err)
+					assert.Empty(t, crs.Items, "the owner and lister roles should be deleted")
+				},
+			}
+			applyTest(t, test)
+		})
+	}
+}
+
+func TestListAndGetGroupOwnedWorkspace(t *testing.T) {
+	owner := &kuser.DefaultInfo{Name: "user-1"}
+	user := &kuser.DefaultInfo{
+		Name:   "user-2",
+		UID:    "user-2-uid",
+		Groups: []string{"team-1", "system:authenticated"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			scope:   PersonalScope,
+			orgName: "orgName",
+			reviewerProvider: mockReviewerProvider{
+				"get":    mockReviewer{},
+				"delete": mockReviewer{},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "foo",
+						Annotations: map[string]string{
+							tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       owner.Name,
+							tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "bar", Annotations: ownedBy(owner)},
+				},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getGroupRoleBindingName(OwnerRoleType, "foo", "team-1"),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "foo",
+							InternalNameLabel: "foo",
+						},
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							Kind: rbacv1.GroupKind,
+							Name: "team-1",
+						},
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			response, err := storage.List(ctx, nil)
+			require.NoError(t, err)
+			workspaces := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "workspaces.Items should only have the workspace owned by the group of the user")
+			assert.Equal(t, "foo", workspaces.Items[0].Name)
+
+			response, err = storage.Get(ctx, "foo", nil)
+			require.NoError(t, err)
+			assert.Equal(t, "foo", response.(*tenancyv1beta1.Workspace).Name)
+
+			_, err = storage.Get(ctx, "bar", nil)
+			require.True(t, kerrors.IsNotFound(err), "expected NotFound, got %v", err)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceWithDisplayName(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	workspaceLister := &mockLister{}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{},
+			workspaceLister:  workspaceLister,
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec: tenancyv1beta1.WorkspaceSpec{
+					DisplayName: "My Workspace",
+					Description: "Where my things live",
+				},
+			}
+			response, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "My Workspace", response.(*tenancyv1beta1.Workspace).Spec.DisplayName)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "My Workspace", clusterWorkspace.Spec.DisplayName)
+			assert.Equal(t, "Where my things live", clusterWorkspace.Spec.Description)
+
+			// make the new workspace visible to the user, in the organization the fake client doesn't record
+			workspaceLister.workspaces = append(workspaceLister.workspaces, *clusterWorkspace)
+			ownerBinding, err := kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "foo", user), metav1.GetOptions{})
+			require.NoError(t, err)
+			ownerBinding.ClusterName = "orgName"
+			_, err = kubeClient.RbacV1().ClusterRoleBindings().Update(ctx, ownerBinding, metav1.UpdateOptions{})
+			require.NoError(t, err)
+
+			var got runtime.Object
+			require.Eventually(t, func() bool {
+				// the owner binding has to reach the informer first
+				got, err = storage.Get(ctx, "foo", &metav1.GetOptions{})
+				return err == nil
+			}, wait.ForeverTestTimeout, 100*time.Millisecond)
+			assert.Equal(t, "My Workspace", got.(*tenancyv1beta1.Workspace).Spec.DisplayName)
+			assert.Equal(t, "Where my things live", got.(*tenancyv1beta1.Workspace).Spec.Description)
+
+			list, err := storage.List(ctx, nil)
+			require.NoError(t, err)
+			workspaces := list.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1)
+			assert.Equal(t, "My Workspace", workspaces.Items[0].Spec.DisplayName)
+			assert.Equal(t, "Where my things live", workspaces.Items[0].Spec.Description)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceDisplayNameTooLong(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec: tenancyv1beta1.WorkspaceSpec{
+					DisplayName: strings.Repeat("a", tenancyv1alpha1.ClusterWorkspaceDisplayNameMaxLength+1),
+				},
+			}
+			_, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+			require.Error(t, err)
+			assert.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+
+			_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			assert.True(t, kerrors.IsNotFound(err), "the workspace should not have been created")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceWithTags(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       tenancyv1beta1.WorkspaceSpec{Tags: map[string]string{"cost-center": "cc-42"}},
+			}, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"cost-center": "cc-42"}, clusterWorkspace.Spec.Tags)
+
+			_, err = storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "bar"},
+				Spec:       tenancyv1beta1.WorkspaceSpec{Tags: map[string]string{tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"}},
+			}, nil, &metav1.CreateOptions{})
+			require.True(t, kerrors.IsInvalid(err), "expected a system tag set by a normal user to be invalid, got %v", err)
+			_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "bar", metav1.GetOptions{})
+			assert.True(t, kerrors.IsNotFound(err), "the workspace should not have been created")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceWithFeatureGates(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       tenancyv1beta1.WorkspaceSpec{FeatureGates: map[string]bool{"ServerSideApply": false}},
+			}, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]bool{"ServerSideApply": false}, clusterWorkspace.Spec.FeatureGates)
+
+			_, err = storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "bar"},
+				Spec:       tenancyv1beta1.WorkspaceSpec{FeatureGates: map[string]bool{"Teleportation": true}},
+			}, nil, &metav1.CreateOptions{})
+			require.True(t, kerrors.IsInvalid(err), "expected an unknown feature gate to be invalid, got %v", err)
+			_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "bar", metav1.GetOptions{})
+			assert.True(t, kerrors.IsNotFound(err), "the workspace should not have been created")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestUpdateWorkspaceTags(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		groups    []string
+		tags      map[string]string
+		wantError bool
+	}{
+		{
+			name: "normal user changes a user tag",
+			tags: map[string]string{"cost-center": "cc-43", tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"},
+		},
+		{
+			name:      "normal user changes a system tag",
+			tags:      map[string]string{"cost-center": "cc-42", tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "platinum"},
+			wantError: true,
+		},
+		{
+			name:      "normal user removes a system tag",
+			tags:      map[string]string{"cost-center": "cc-42"},
+			wantError: true,
+		},
+		{
+			name:   "privileged user changes a system tag",
+			groups: []string{kuser.SystemPrivilegedGroup},
+			tags:   map[string]string{"cost-center": "cc-42", tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "platinum"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			user := &kuser.DefaultInfo{
+				Name:   "test-user",
+				UID:    "test-uid",
+				Groups: append([]string{"test-group"}, tc.groups...),
+			}
+			review := mockReviewer{
+				"foo": mockReview{
+					users: []string{"test-user"},
+				},
+			}
+			oldTags := map[string]string{"cost-center": "cc-42", tenancyv1alpha1.ClusterWorkspaceSystemTagPrefix + "compliance-tier": "gold"}
+			test := TestDescription{
+				TestData: TestData{
+					user:    user,
+					scope:   PersonalScope,
+					orgName: "orgName",
+					reviewerProvider: mockReviewerProvider{
+						"get":    review,
+						"delete": review,
+					},
+					clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(user)},
+							Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal", Tags: oldTags},
+						},
+					},
+					clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+						{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:        getRoleBindingName(OwnerRoleType, "foo", user),
+								ClusterName: "orgName",
+								Labels: map[string]string{
+									PrettyNameLabel:   "foo",
+									InternalNameLabel: "foo",
+								},
+							},
+							Subjects: []rbacv1.Subject{
+								{
+									Kind: "User",
+									Name: user.Name,
+								},
+							},
+						},
+					},
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					_, _, err := storage.Update(ctx, "foo", rest.DefaultUpdatedObjectInfo(&tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+						Spec:       tenancyv1beta1.WorkspaceSpec{Type: "Universal", Tags: tc.tags},
+					}), nil, nil, false, &metav1.UpdateOptions{})
+
+					clusterWorkspace, getErr := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+					require.NoError(t, getErr)
+					if tc.wantError {
+						require.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+						assert.Equal(t, oldTags, clusterWorkspace.Spec.Tags)
+						return
+					}
+					require.NoError(t, err)
+					assert.Equal(t, tc.tags, clusterWorkspace.Spec.Tags)
+				},
+			}
+			applyTest(t, test)
+		})
+	}
+}
+
+// dryRunCreateReactor emulates server-side dry-run creation, which the fake clientsets don't support:
+// creations fail if the object already exists, but are never persisted.
+func dryRunCreateReactor(tracker clienttesting.ObjectTracker) clienttesting.ReactionFunc {
+	return func(action clienttesting.Action) (bool, runtime.Object, error) {
+		obj := action.(clienttesting.CreateAction).GetObject()
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		if _, err := tracker.Get(action.GetResource(), action.GetNamespace(), objMeta.GetName()); err == nil {
+			return true, nil, kerrors.NewAlreadyExists(action.GetResource().GroupResource(), objMeta.GetName())
+		}
+		return true, obj.DeepCopyObject(), nil
+	}
+}
+
+func TestCreateWorkspaceDryRun(t *testing.T) {
+	anotherUser := &kuser.DefaultInfo{
+		Name:   "another-user",
+		UID:    "another-uid",
+		Groups: []string{},
+	}
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: ownedBy(anotherUser)},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			kubeClient.PrependReactor("create", "*", dryRunCreateReactor(kubeClient.Tracker()))
+			kcpClient.PrependReactor("create", "*", dryRunCreateReactor(kcpClient.Tracker()))
+
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			}
+			response, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+			require.NoError(t, err)
+			require.IsType(t, &tenancyv1beta1.Workspace{}, response)
+			workspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, "foo", workspace.Name)
+			assert.Equal(t, "foo--1", workspace.Annotations[InternalNameAnnotation], "the disambiguated name should be returned")
+			assert.Equal(t, "test-user", workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+
+			clusterWorkspaces, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, testData.clusterWorkspaces, clusterWorkspaces.Items, "no workspace should have been created")
+			crbs, err := kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, crbs.Items, "no role binding should have been created")
+			crs, err := kubeClient.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, crs.Items, "no role should have been created")
+			for _, action := range append(kubeClient.Actions(), kcpClient.Actions()...) {
+				assert.NotContains(t, []string{"update", "patch", "delete"}, action.GetVerb(), "no %s of %s expected", action.GetVerb(), action.GetResource().Resource)
+			}
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceInvalidClone(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+	}{
+		{
+			name:        "source not visible to the user",
+			annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation: "foo"},
+		},
+		{
+			name: "unsupported included resource",
+			annotations: map[string]string{
+				tenancyv1alpha1.ClusterWorkspaceCloneIncludeAnnotation: "secrets,deployments",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := TestDescription{
+				TestData: TestData{
+					user:    user,
+					scope:   PersonalScope,
+					orgName: "orgName",
+					reviewerProvider: mockReviewerProvider{
+						"get": mockReviewer{},
+					},
+					clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+						},
+					},
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					newWorkspace := &tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{Name: "bar", Annotations: tc.annotations},
+					}
+					_, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+					require.Error(t, err)
+					assert.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+
+					_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "bar", metav1.GetOptions{})
+					assert.True(t, kerrors.IsNotFound(err), "no workspace should have been created")
+					crbs, err := kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+					require.NoError(t, err)
+					assert.Empty(t, crbs.Items, "no role binding should have been created")
+				},
+			}
+			applyTest(t, test)
+		})
+	}
+}
+
+func TestCreateWorkspaceNameCollision(t *testing.T) {
+	anotherUser := &kuser.DefaultInfo{
+		Name:   "another-user",
+		UID:    "another-uid",
+		Groups: []string{},
+	}
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	for _, tc := range []struct {
+		name                 string
+		policy               NameCollisionPolicy
+		generator            workspaces.NameGenerator
+		existingWorkspaces   []string
+		expectedInternalName *regexp.Regexp
+		expectAlreadyExists  bool
+	}{
+		{
+			name:                 "default policy appends a suffix",
+			existingWorkspaces:   []string{"workspace1"},
+			expectedInternalName: regexp.MustCompile(`^workspace1--1$`),
+		},
+		{
+			name:                 "suffix policy appends a suffix",
+			policy:               NameCollisionSuffix,
+			existingWorkspaces:   []string{"workspace1"},
+			expectedInternalName: regexp.MustCompile(`^workspace1--1$`),
+		},
+		{
+			name:                 "suffix policy increments the suffix",
+			policy:               NameCollisionSuffix,
+			existingWorkspaces:   []string{"workspace1", "workspace1--1"},
+			expectedInternalName: regexp.MustCompile(`^workspace1--2$`),
+		},
+		{
+			name:                 "random policy appends a random suffix",
+			policy:               NameCollisionRandom,
+			existingWorkspaces:   []string{"workspace1"},
+			expectedInternalName: regexp.MustCompile(`^workspace1--[a-z0-9]{5}$`),
+		},
+		{
+			name:                 "uuid generator appends a UUID",
+			policy:               NameCollisionSuffix,
+			generator:            workspaces.UUIDNameGenerator{},
+			existingWorkspaces:   []string{"workspace1"},
+			expectedInternalName: regexp.MustCompile(`^workspace1--[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`),
+		},
+		{
+			name:                 "sequence generator replaces the suffix of the random policy",
+			policy:               NameCollisionRandom,
+			generator:            workspaces.SequenceNameGenerator{},
+			existingWorkspaces:   []string{"workspace1", "workspace1--1"},
+			expectedInternalName: regexp.MustCompile(`^workspace1--2$`),
+		},
+		{
+			name:                 "no collision keeps the name under the reject policy",
+			policy:               NameCollisionReject,
+			expectedInternalName: regexp.MustCompile(`^workspace1$`),
+		},
+		{
+			name:                "reject policy fails with a conflict",
+			policy:              NameCollisionReject,
+			existingWorkspaces:  []string{"workspace1"},
+			expectAlreadyExists: true,
+		},
+		{
+			name:                "reject policy fails with a conflict instead of incrementing the suffix",
+			policy:              NameCollisionReject,
+			existingWorkspaces:  []string{"workspace1", "workspace1--1"},
+			expectAlreadyExists: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var clusterWorkspaces []tenancyv1alpha1.ClusterWorkspace
+			var clusterRoleBindings []rbacv1.ClusterRoleBinding
+			for _, name := range tc.existingWorkspaces {
+				clusterWorkspaces = append(clusterWorkspaces, tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+				clusterRoleBindings = append(clusterRoleBindings, rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, name, anotherUser),
+						Labels: map[string]string{
+							PrettyNameLabel:   name,
+							InternalNameLabel: name,
+						},
+					},
+					Subjects: []rbacv1.Subject{{Kind: "User", Name: anotherUser.Name}},
+				})
+			}
+			test := TestDescription{
+				TestData: TestData{
+					user:                user,
+					scope:               PersonalScope,
+					orgName:             "orgName",
+					reviewerProvider:    mockReviewerProvider{"get": mockReviewer{}},
+					nameCollisionPolicy: tc.policy,
+					nameGenerator:       tc.generator,
+					clusterWorkspaces:   clusterWorkspaces,
+					clusterRoleBindings: clusterRoleBindings,
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					newWorkspace := &tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{Name: "workspace1"},
+					}
+					response, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+
+					if tc.expectAlreadyExists {
+						require.Error(t, err)
+						assert.True(t, kerrors.IsAlreadyExists(err), "expected an already exists error, got %v", err)
+						assert.Contains(t, err.Error(), `"workspace1"`, "the error should name the conflicting workspace")
+						crbs, err := kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+						require.NoError(t, err)
+						assert.ElementsMatch(t, testData.clusterRoleBindings, crbs.Items, "the role binding of the rejected workspace should be removed")
+						crs, err := kubeClient.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+						require.NoError(t, err)
+						assert.Empty(t, crs.Items, "the roles of the rejected workspace should be removed")
+						return
+					}
+
+					require.NoError(t, err)
+					workspace := response.(*tenancyv1beta1.Workspace)
+					assert.Equal(t, "workspace1", workspace.Name, "the pretty name should be returned")
+					internalName := workspace.Annotations[InternalNameAnnotation]
+					assert.Regexp(t, tc.expectedInternalName, internalName, "unexpected internal name")
+
+					_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, internalName, metav1.GetOptions{})
+					require.NoError(t, err, "expected the ClusterWorkspace to be created with the internal name")
+				},
+			}
+			applyTest(t, test)
+		})
+	}
+}
+
+func TestCreateWorkspaceReservedNames(t *testing.T) {
+	anotherUser := &kuser.DefaultInfo{
+		Name:   "another-user",
+		UID:    "another-uid",
+		Groups: []string{},
+	}
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	for _, tc := range []struct {
+		name               string
+		orgName            string
+		reservedNames      []string
+		existingWorkspaces []string
+		workspaceName      string
+		expectInvalid      bool
+	}{
+		{
+			name:          "reserved name is rejected",
+			orgName:       "orgName",
+			reservedNames: DefaultReservedNames,
+			workspaceName: "root",
+			expectInvalid: true,
+		},
+		{
+			name:          "name of the organization is rejected",
+			orgName:       "root:acme",
+			workspaceName: "acme",
+			expectInvalid: true,
+		},
+		{
+			name:               "reserved name is rejected instead of being suffixed",
+			orgName:            "orgName",
+			reservedNames:      DefaultReservedNames,
+			existingWorkspaces: []string{"admin"},
+			workspaceName:      "admin",
+			expectInvalid:      true,
+		},
+		{
+			name:          "configured reserved names replace the defaults",
+			orgName:       "orgName",
+			reservedNames: []string{"staging"},
+			workspaceName: "admin",
+		},
+		{
+			name:          "name that is not reserved is accepted",
+			orgName:       "orgName",
+			reservedNames: DefaultReservedNames,
+			workspaceName: "workspace1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var clusterWorkspaces []tenancyv1alpha1.ClusterWorkspace
+			var clusterRoleBindings []rbacv1.ClusterRoleBinding
+			for _, name := range tc.existingWorkspaces {
+				clusterWorkspaces = append(clusterWorkspaces, tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+				clusterRoleBindings = append(clusterRoleBindings, rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: getRoleBindingName(OwnerRoleType, name, anotherUser),
+						Labels: map[string]string{
+							PrettyNameLabel:   name,
+							InternalNameLabel: name,
+						},
+					},
+					Subjects: []rbacv1.Subject{{Kind: "User", Name: anotherUser.Name}},
+				})
+			}
+			test := TestDescription{
+				TestData: TestData{
+					user:                user,
+					scope:               PersonalScope,
+					orgName:             tc.orgName,
+					reviewerProvider:    mockReviewerProvider{"get": mockReviewer{}},
+					nameCollisionPolicy: NameCollisionSuffix,
+					reservedNames:       tc.reservedNames,
+					clusterWorkspaces:   clusterWorkspaces,
+					clusterRoleBindings: clusterRoleBindings,
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					newWorkspace := &tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{Name: tc.workspaceName},
+					}
+					_, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+
+					if !tc.expectInvalid {
+						require.NoError(t, err)
+						return
+					}
+					require.Error(t, err)
+					require.True(t, kerrors.IsInvalid(err), "expected Invalid, got %v", err)
+					causes := err.(kerrors.APIStatus).Status().Details.Causes
+					require.Len(t, causes, 1)
+					assert.Equal(t, "metadata.name", causes[0].Field)
+					assert.Equal(t, metav1.CauseType(field.ErrorTypeForbidden), causes[0].Type)
+
+					workspaces, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+					require.NoError(t, err)
+					assert.ElementsMatch(t, testData.clusterWorkspaces, workspaces.Items, "no ClusterWorkspace should be created for a reserved name")
+					crbs, err := kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+					require.NoError(t, err)
+					assert.ElementsMatch(t, testData.clusterRoleBindings, crbs.Items, "no role binding should be created for a reserved name")
+				},
+			}
+			applyTest(t, test)
+		})
+	}
+}
+
+func TestCreateWorkspaceWithGenerateName(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{"get": mockReviewer{}},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			generatedNames := sets.NewString()
+			for i := 0; i < 3; i++ {
+				newWorkspace := &tenancyv1beta1.Workspace{
+					ObjectMeta: metav1.ObjectMeta{GenerateName: "ws-"},
+				}
+				response, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+				require.NoError(t, err)
+				workspace := response.(*tenancyv1beta1.Workspace)
+				assert.Regexp(t, regexp.MustCompile(`^ws-[a-z0-9]{5}$`), workspace.Name, "unexpected generated name")
+				assert.Empty(t, validation.IsDNS1035Label(workspace.Name), "the generated name should be a DNS-1035 label")
+				assert.False(t, generatedNames.Has(workspace.Name), "the generated name %s should be unique", workspace.Name)
+				generatedNames.Insert(workspace.Name)
+
+				assert.Equal(t, workspace.Name, workspace.Annotations[InternalNameAnnotation], "the generated name should be the internal name")
+				_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected the ClusterWorkspace to be created with the generated name")
+			}
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceWithGenerateNameAndSequenceGenerator(t *testing.T) {
+	anotherUser := &kuser.DefaultInfo{
+		Name:   "another-user",
+		UID:    "another-uid",
+		Groups: []string{},
+	}
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{"get": mockReviewer{}},
+			nameGenerator:    workspaces.SequenceNameGenerator{},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{ObjectMeta: metav1.ObjectMeta{Name: "ws-1"}},
+			},
+			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        getRoleBindingName(OwnerRoleType, "ws-1", anotherUser),
+						ClusterName: "orgName",
+						Labels: map[string]string{
+							PrettyNameLabel:   "ws-1",
+							InternalNameLabel: "ws-1",
+						},
+					},
+					Subjects: []rbacv1.Subject{{Kind: "User", Name: anotherUser.Name}},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			newWorkspace := &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: "ws-"},
+			}
+			response, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+			require.NoError(t, err)
+			workspace := response.(*tenancyv1beta1.Workspace)
+			assert.Equal(t, "ws-2", workspace.Name, "the generated name should be the first one of the sequence not used yet")
+			assert.Equal(t, "ws-2", workspace.Annotations[InternalNameAnnotation], "the generated name should be the internal name")
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestCreateWorkspaceInvalidName(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	for _, tc := range []struct {
+		name          string
+		policy        NameCollisionPolicy
+		workspaceName string
+		expectedCause metav1.CauseType
+	}{
+		{
+			name:          "uppercase letters",
+			workspaceName: "MyWorkspace",
+			expectedCause: metav1.CauseTypeFieldValueInvalid,
+		},
+		{
+			name:          "underscores",
+			workspaceName: "my_workspace",
+			expectedCause: metav1.CauseTypeFieldValueInvalid,
+		},
+		{
+			name:          "leading digit",
+			workspaceName: "1workspace",
+			expectedCause: metav1.CauseTypeFieldValueInvalid,
+		},
+		{
+			name:          "too long for the suffix of the default policy",
+			workspaceName: strings.Repeat("a", 61),
+			expectedCause: metav1.CauseType(field.ErrorTypeTooLong),
+		},
+		{
+			name:          "longest name for the suffix of the default policy",
+			workspaceName: strings.Repeat("a", 60),
+		},
+		{
+			name:          "too long for the suffix of the random policy",
+			policy:        NameCollisionRandom,
+			workspaceName: strings.Repeat("a", 57),
+			expectedCause: metav1.CauseType(field.ErrorTypeTooLong),
+		},
+		{
+			name:          "no suffix to account for with the reject policy",
+			policy:        NameCollisionReject,
+			workspaceName: strings.Repeat("a", 63),
+		},
+		{
+			name:          "too long for a DNS label with the reject policy",
+			policy:        NameCollisionReject,
+			workspaceName: strings.Repeat("a", 64),
+			expectedCause: metav1.CauseType(field.ErrorTypeTooLong),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := TestDescription{
+				TestData: TestData{
+					user:                user,
+					scope:               PersonalScope,
+					orgName:             "orgName",
+					reviewerProvider:    mockReviewerProvider{"get": mockReviewer{}},
+					nameCollisionPolicy: tc.policy,
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					newWorkspace := &tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{Name: tc.workspaceName},
+					}
+					_, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+					if tc.expectedCause == "" {
+						require.NoError(t, err)
+						return
+					}
+
+					require.Error(t, err)
+					require.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+					causes := err.(kerrors.APIStatus).Status().Details.Causes
+					require.Len(t, causes, 1, "expected a single violated rule, got %v", causes)
+					assert.Equal(t, tc.expectedCause, causes[0].Type)
+					assert.Equal(t, "metadata.name", causes[0].Field)
+
+					workspaces, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+					require.NoError(t, err)
+					assert.Empty(t, workspaces.Items, "no workspace should have been created")
+					crbs, err := kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+					require.NoError(t, err)
+					assert.Empty(t, crbs.Items, "no role binding should have been created")
+				},
+			}
+			applyTest(t, test)
+		})
+	}
+}
+
+func TestCreateWorkspaceWithNamePrefix(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	for _, tc := range []struct {
+		name          string
+		workspaceName string
+		expectedName  string
+		expectInvalid bool
+	}{
+		{
+			name:          "name without the prefix",
+			workspaceName: "foo",
+			expectedName:  "team-a-foo",
+		},
+		{
+			name:          "name with the prefix already",
+			workspaceName: "team-a-foo",
+			expectedName:  "team-a-foo",
+		},
+		{
+			name:          "longest name once prefixed",
+			workspaceName: strings.Repeat("a", 60-len("team-a-")),
+			expectedName:  "team-a-" + strings.Repeat("a", 60-len("team-a-")),
+		},
+		{
+			name:          "too long once prefixed",
+			workspaceName: strings.Repeat("a", 61-len("team-a-")),
+			expectInvalid: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := TestDescription{
+				TestData: TestData{
+					user:             user,
+					scope:            PersonalScope,
+					orgName:          "root:org",
+					reviewerProvider: mockReviewerProvider{"get": mockReviewer{}},
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					store := workspacecache.NewCacheStore(cache.MetaNamespaceKeyFunc)
+					require.NoError(t, store.Add(&tenancyv1alpha1.ClusterWorkspace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "org",
+							ClusterName: "root",
+							Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceNamePrefixAnnotation: "team-a-"},
 						},
-					},
+					}))
+					storage.clusterWorkspaceCache = &workspacecache.ClusterWorkspaceCache{Store: store}
+
+					newWorkspace := &tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{Name: tc.workspaceName},
+					}
+					obj, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+					if tc.expectInvalid {
+						require.Error(t, err)
+						require.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+						return
+					}
+					require.NoError(t, err)
+					assert.Equal(t, tc.expectedName, obj.(*tenancyv1beta1.Workspace).Name)
+					assert.Equal(t, tc.workspaceName, newWorkspace.Name, "the requested workspace should not be modified")
+
+					_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, tc.expectedName, metav1.GetOptions{})
+					require.NoError(t, err, "expected the ClusterWorkspace to have the prefixed name")
 				},
-				rbacv1.ClusterRole{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "owner-workspace-foo-test-user",
-						Labels: map[string]string{
-							InternalNameLabel: "foo--1",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get", "delete"},
-							ResourceNames: []string{"foo--1"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
+			}
+			applyTest(t, test)
+		})
+	}
+}
+
+func TestCreateWorkspaceInitialMembers(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	for _, tc := range []struct {
+		name        string
+		members     string
+		expectedErr string
+	}{
+		{
+			name:    "valid",
+			members: "user:user-2=edit,group:team-1=view",
+		},
+		{
+			name:        "role out of the allowlist",
+			members:     "user:user-2=cluster-admin",
+			expectedErr: `entry "user:user-2=cluster-admin" has an unsupported role "cluster-admin", must be one of admin, edit, view`,
+		},
+		{
+			name:        "malformed",
+			members:     "user-2",
+			expectedErr: `entry "user-2" must be of the form user:<name>=<role> or group:<name>=<role>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := TestDescription{
+				TestData: TestData{
+					user:             user,
+					scope:            PersonalScope,
+					orgName:          "orgName",
+					reviewerProvider: mockReviewerProvider{},
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					newWorkspace := &tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "foo",
+							Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation: tc.members},
 						},
-						{
-							Verbs:         []string{"view", "edit"},
-							ResourceNames: []string{"foo--1"},
-							Resources:     []string{"clusterworkspaces/content"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
+					}
+					_, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+					workspaces, listErr := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+					require.NoError(t, listErr)
+					if tc.expectedErr == "" {
+						require.NoError(t, err)
+						require.Len(t, workspaces.Items, 1)
+						assert.Equal(t, tc.members, workspaces.Items[0].Annotations[tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation])
+						return
+					}
+
+					require.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+					causes := err.(kerrors.APIStatus).Status().Details.Causes
+					require.Len(t, causes, 1, "expected a single violated rule, got %v", causes)
+					assert.Equal(t, "metadata.annotations[tenancy.kcp.dev/initial-members]", causes[0].Field)
+					assert.Contains(t, causes[0].Message, tc.expectedErr)
+					assert.Empty(t, workspaces.Items, "no workspace should have been created")
+					crbs, err := kubeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+					require.NoError(t, err)
+					assert.Empty(t, crbs.Items, "no role binding should have been created")
+				},
+			}
+			applyTest(t, test)
+		})
+	}
+}
+
+func TestCreateWorkspaceDefaultNamespaceLabels(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	for _, tc := range []struct {
+		name        string
+		labels      string
+		expectedErr string
+	}{
+		{
+			name:   "valid",
+			labels: "team=payments,network.example.com/policy=strict",
+		},
+		{
+			name:        "malformed",
+			labels:      "team",
+			expectedErr: `entry "team" must be of the form <key>=<value>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			test := TestDescription{
+				TestData: TestData{
+					user:             user,
+					scope:            PersonalScope,
+					orgName:          "orgName",
+					reviewerProvider: mockReviewerProvider{},
+				},
+				apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					newWorkspace := &tenancyv1beta1.Workspace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "foo",
+							Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: tc.labels},
 						},
-					},
+					}
+					_, err := storage.Create(ctx, newWorkspace, nil, &metav1.CreateOptions{})
+					workspaces, listErr := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+					require.NoError(t, listErr)
+					if tc.expectedErr == "" {
+						require.NoError(t, err)
+						require.Len(t, workspaces.Items, 1)
+						assert.Equal(t, tc.labels, workspaces.Items[0].Annotations[tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation])
+						return
+					}
+
+					require.True(t, kerrors.IsInvalid(err), "expected an invalid error, got %v", err)
+					causes := err.(kerrors.APIStatus).Status().Details.Causes
+					require.Len(t, causes, 1, "expected a single violated rule, got %v", causes)
+					assert.Equal(t, "metadata.annotations[tenancy.kcp.dev/default-namespace-labels]", causes[0].Field)
+					assert.Contains(t, causes[0].Message, tc.expectedErr)
+					assert.Empty(t, workspaces.Items, "no workspace should have been created")
 				},
-			))
+			}
+			applyTest(t, test)
+		})
+	}
+}
 
-			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+func TestCreateWorkspaceRateLimited(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:              user,
+			scope:             PersonalScope,
+			orgName:           "orgName",
+			reviewerProvider:  mockReviewerProvider{},
+			createRateLimiter: NewCreateRateLimiter(RateLimit{QPS: 0.01, Burst: 2}, nil),
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			for i := 0; i < 2; i++ {
+				_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+					ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("foo-%d", i)},
+				}, nil, &metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-2"},
+			}, nil, &metav1.CreateOptions{})
+			require.True(t, kerrors.IsTooManyRequests(err), "expected 429, got %v", err)
+			retryAfter, ok := kerrors.SuggestsClientDelay(err)
+			require.True(t, ok, "expected a Retry-After")
+			require.Greater(t, retryAfter, 0)
+
+			clusterWorkspaces, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
 			require.NoError(t, err)
-			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
-			assert.ElementsMatch(t, wsList.Items, append(testData.clusterWorkspaces,
-				tenancyv1alpha1.ClusterWorkspace{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "foo--1",
-					},
-				},
-			))
+			require.Len(t, clusterWorkspaces.Items, 2, "the rate limited creation should not have created anything")
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestCreateWorkspacePrettyNameAlreadyExists(t *testing.T) {
+func TestSoftDeleteWorkspace(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
@@ -1135,110 +4178,52 @@ func TestCreateWorkspacePrettyNameAlreadyExists(t *testing.T) {
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   OrganizationScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
-				"get":    mockReviewer{},
-				"delete": mockReviewer{},
-			},
-			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
-				},
-			},
-			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
-						ClusterName: "orgName",
-						Labels: map[string]string{
-							PrettyNameLabel:   "foo",
-							InternalNameLabel: "foo",
-						},
-					},
-					Subjects: []rbacv1.Subject{
-						{
-							Kind: "User",
-							Name: user.Name,
-						},
+				"get": mockReviewer{
+					"foo": mockReview{
+						users: []string{"test-user"},
 					},
 				},
-			},
-			clusterRoles: []rbacv1.ClusterRole{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(ListerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-						{
-							Verbs:         []string{"view"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"clusterworkspaces/content"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
+				"delete": mockReviewer{
+					"foo": mockReview{
+						users: []string{"test-user"},
 					},
 				},
+			},
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(OwnerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get", "delete"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-						{
-							Verbs:         []string{"view", "edit"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"clusterworkspaces/content"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "foo-uid"},
 				},
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			newWorkspace := tenancyv1beta1.Workspace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "foo",
-				},
-			}
-			response, err := storage.Create(ctx, &newWorkspace, nil, &metav1.CreateOptions{})
-			require.EqualError(t, err, "workspaces.tenancy.kcp.dev \"foo\" already exists")
-			require.Nil(t, response)
+			storage.softDeleteRetention = time.Hour
+			storage.now = func() time.Time { return time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC) }
 
-			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
-			require.NoError(t, err)
-			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
-			assert.ElementsMatch(t, crbs.Items, testData.clusterRoleBindings)
-			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			otherUID := types.UID("other-uid")
+			_, _, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &otherUID}})
+			require.True(t, kerrors.IsConflict(err), "expected Conflict, got %v", err)
+
+			obj, deleted, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
 			require.NoError(t, err)
-			crs := crList.(*rbacv1.ClusterRoleList)
-			assert.ElementsMatch(t, crs.Items, testData.clusterRoles)
-			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			assert.False(t, deleted)
+			assert.Equal(t, "2022-03-01T13:00:00Z", obj.(*tenancyv1beta1.Workspace).Annotations[tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation])
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err, "the workspace should be kept during the retention window")
+			assert.Equal(t, "2022-03-01T13:00:00Z", clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation])
+
+			_, _, err = storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
 			require.NoError(t, err)
-			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
-			assert.ElementsMatch(t, wsList.Items, testData.clusterWorkspaces)
+			_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.True(t, kerrors.IsNotFound(err), "deleting a soft-deleted workspace should delete it for good, got %v", err)
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestDeleteWorkspaceNotFound(t *testing.T) {
+func TestListSoftDeletedWorkspaces(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
@@ -1247,7 +4232,7 @@ func TestDeleteWorkspaceNotFound(t *testing.T) {
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   OrganizationScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
 				"get":    mockReviewer{},
@@ -1255,384 +4240,169 @@ func TestDeleteWorkspaceNotFound(t *testing.T) {
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
-				},
-			},
-			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
-						ClusterName: "orgName",
-						Labels: map[string]string{
-							PrettyNameLabel:   "foo",
-							InternalNameLabel: "foo",
-						},
-					},
-					Subjects: []rbacv1.Subject{
-						{
-							Kind: "User",
-							Name: user.Name,
-						},
-					},
-				},
-			},
-			clusterRoles: []rbacv1.ClusterRole{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(OwnerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get", "delete"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
+					ObjectMeta: metav1.ObjectMeta{Name: "kept"},
 				},
 				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(ListerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
+					ObjectMeta: metav1.ObjectMeta{Name: "deleted", Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation: "2022-03-01T13:00:00Z",
+					}},
 				},
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			response, deletedNow, err := storage.Delete(ctx, "foo-with-does-not-exist", nil, &metav1.DeleteOptions{})
-			assert.EqualError(t, err, "workspaces.tenancy.kcp.dev \"foo-with-does-not-exist\" not found")
-			assert.Nil(t, response)
-			assert.False(t, deletedNow)
-			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
-			require.NoError(t, err)
-			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
-			assert.ElementsMatch(t, crbs.Items, testData.clusterRoleBindings)
-			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			response, err := storage.List(ctx, &metainternal.ListOptions{})
 			require.NoError(t, err)
-			crs := crList.(*rbacv1.ClusterRoleList)
-			assert.ElementsMatch(t, crs.Items, testData.clusterRoles)
-			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			workspaces := response.(*tenancyv1beta1.WorkspaceList)
+			require.Len(t, workspaces.Items, 1, "soft-deleted workspaces should be hidden")
+			assert.Equal(t, "kept", workspaces.Items[0].Name)
+
+			response, err = storage.List(context.WithValue(ctx, virtualcontext.RequestQueryKey, url.Values{IncludeDeletedParameter: []string{"true"}}), &metainternal.ListOptions{})
 			require.NoError(t, err)
-			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
-			assert.ElementsMatch(t, wsList.Items, testData.clusterWorkspaces)
+			require.Len(t, response.(*tenancyv1beta1.WorkspaceList).Items, 2, "soft-deleted workspaces should be listed with include-deleted")
+
+			_, err = storage.List(context.WithValue(ctx, virtualcontext.RequestQueryKey, url.Values{IncludeDeletedParameter: []string{"maybe"}}), &metainternal.ListOptions{})
+			require.True(t, kerrors.IsBadRequest(err), "expected a bad request error, got %v", err)
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestDeleteWorkspaceForbidden(t *testing.T) {
+func TestGetAndUpdateSoftDeletedWorkspace(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
 		Groups: []string{"test-group"},
 	}
+	review := mockReviewer{
+		"foo": mockReview{
+			users: []string{"test-user"},
+		},
+	}
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   OrganizationScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
-				"get":    mockReviewer{},
-				"delete": mockReviewer{},
+				"get":    review,
+				"delete": review,
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
 				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
-				},
-			},
-			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
-						ClusterName: "orgName",
-						Labels: map[string]string{
-							PrettyNameLabel:   "foo",
-							InternalNameLabel: "foo",
-						},
-					},
-					Subjects: []rbacv1.Subject{
-						{
-							Kind: "User",
-							Name: user.Name,
-						},
-					},
-				},
-			},
-			clusterRoles: []rbacv1.ClusterRole{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(OwnerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get", "delete"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(ListerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "staging"}, Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation: "2022-03-01T13:00:00Z",
+					}},
 				},
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			response, deletedNow, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
-			assert.EqualError(t, err, "workspace.tenancy.kcp.dev is forbidden: User test-user doesn't have the permission to delete workspace foo")
-			assert.Nil(t, response)
-			assert.False(t, deletedNow)
-			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
-			require.NoError(t, err)
-			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
-			assert.ElementsMatch(t, crbs.Items, testData.clusterRoleBindings)
-			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
-			require.NoError(t, err)
-			crs := crList.(*rbacv1.ClusterRoleList)
-			assert.ElementsMatch(t, crs.Items, testData.clusterRoles)
-			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			_, err := storage.Get(ctx, "foo", &metav1.GetOptions{})
+			require.True(t, kerrors.IsNotFound(err), "expected NotFound for a soft-deleted workspace, got %v", err)
+
+			response, err := storage.Get(context.WithValue(ctx, virtualcontext.RequestQueryKey, url.Values{IncludeDeletedParameter: []string{"true"}}), "foo", &metav1.GetOptions{})
+			require.NoError(t, err, "soft-deleted workspaces should be found with include-deleted")
+			assert.Equal(t, "foo", response.(*tenancyv1beta1.Workspace).Name)
+
+			_, _, err = storage.Update(ctx, "foo", rest.DefaultUpdatedObjectInfo(&tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"env": "prod"}},
+			}), nil, nil, false, &metav1.UpdateOptions{})
+			require.True(t, kerrors.IsNotFound(err), "expected NotFound for a soft-deleted workspace, got %v", err)
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
 			require.NoError(t, err)
-			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
-			assert.ElementsMatch(t, wsList.Items, testData.clusterWorkspaces)
+			assert.Equal(t, map[string]string{"env": "staging"}, clusterWorkspace.Labels)
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestDeletePersonalWorkspace(t *testing.T) {
+func TestDeleteCollectionOfWorkspaces(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
 		Groups: []string{"test-group"},
 	}
+	owned := map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation: "test-user"}
+	notOwned := map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation: "other-user"}
+	allowed := mockReviewer{
+		"foo":   mockReview{users: []string{"test-user"}},
+		"bar":   mockReview{users: []string{"test-user"}},
+		"baz":   mockReview{users: []string{"test-user"}},
+		"other": mockReview{users: []string{"test-user"}},
+	}
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   OrganizationScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
-				"get": mockReviewer{
-					"foo": mockReview{
-						users:  []string{"test-user"},
-						groups: []string{""},
-					},
-				},
-				"delete": mockReviewer{
-					"foo": mockReview{
-						users:  []string{"test-user"},
-						groups: []string{""},
-					},
-				},
+				"get":    allowed,
+				"delete": allowed,
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
-				},
-			},
-			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
-						ClusterName: "orgName",
-						Labels: map[string]string{
-							PrettyNameLabel:   "foo",
-							InternalNameLabel: "foo",
-						},
-					},
-					Subjects: []rbacv1.Subject{
-						{
-							Kind: "User",
-							Name: user.Name,
-						},
-					},
-				},
-			},
-			clusterRoles: []rbacv1.ClusterRole{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(OwnerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get", "delete"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(ListerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get"},
-							ResourceNames: []string{"foo"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
-				},
+				{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"cleanup": "true"}, Annotations: owned}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "bar", Labels: map[string]string{"cleanup": "true"}, Annotations: owned}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "baz", Annotations: owned}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{"cleanup": "true"}, Annotations: notOwned}},
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			response, deletedNow, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
-			assert.NoError(t, err)
-			assert.Nil(t, response)
-			assert.False(t, deletedNow)
-			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
-			require.NoError(t, err)
-			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
-			assert.Empty(t, crbs.Items)
-			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
+			obj, err := storage.DeleteCollection(ctx, nil, &metav1.DeleteOptions{}, &metainternal.ListOptions{
+				LabelSelector: labels.SelectorFromSet(labels.Set{"cleanup": "true"}),
+			})
 			require.NoError(t, err)
-			crs := crList.(*rbacv1.ClusterRoleList)
-			assert.Empty(t, crs.Items)
-			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			status := obj.(*metav1.Status)
+			assert.Equal(t, metav1.StatusSuccess, status.Status)
+			assert.Equal(t, "deleted 2 workspaces, skipped 1 workspaces not owned by test-user", status.Message)
+
+			clusterWorkspaces, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
 			require.NoError(t, err)
-			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
-			assert.Empty(t, wsList.Items)
+			var names []string
+			for _, clusterWorkspace := range clusterWorkspaces.Items {
+				names = append(names, clusterWorkspace.Name)
+			}
+			assert.ElementsMatch(t, []string{"baz", "other"}, names, "only the selected workspaces owned by the user should be deleted")
 		},
 	}
 	applyTest(t, test)
 }
 
-func TestDeletePersonalWorkspaceWithPrettyName(t *testing.T) {
+func TestDeleteCollectionOfWorkspacesAggregatesFailures(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
 		UID:    "test-uid",
 		Groups: []string{"test-group"},
 	}
+	owned := map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation: "test-user"}
 	test := TestDescription{
 		TestData: TestData{
 			user:    user,
-			scope:   PersonalScope,
+			scope:   OrganizationScope,
 			orgName: "orgName",
 			reviewerProvider: mockReviewerProvider{
 				"get": mockReviewer{
-					"foo--1": mockReview{
-						users:  []string{"test-user"},
-						groups: []string{""},
-					},
+					"foo": mockReview{users: []string{"test-user"}},
+					"bar": mockReview{users: []string{"test-user"}},
 				},
 				"delete": mockReviewer{
-					"foo--1": mockReview{
-						users:  []string{"test-user"},
-						groups: []string{""},
-					},
+					"foo": mockReview{users: []string{"test-user"}},
 				},
 			},
 			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "foo--1"},
-				},
-			},
-			clusterRoleBindings: []rbacv1.ClusterRoleBinding{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:        getRoleBindingName(OwnerRoleType, "foo", user),
-						ClusterName: "orgName",
-						Labels: map[string]string{
-							PrettyNameLabel:   "foo",
-							InternalNameLabel: "foo--1",
-						},
-					},
-					Subjects: []rbacv1.Subject{
-						{
-							Kind: "User",
-							Name: user.Name,
-						},
-					},
-				},
-			},
-			clusterRoles: []rbacv1.ClusterRole{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(OwnerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo--1",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get", "delete"},
-							ResourceNames: []string{"foo--1"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: getRoleBindingName(ListerRoleType, "foo", user),
-						Labels: map[string]string{
-							InternalNameLabel: "foo--1",
-						},
-					},
-					Rules: []rbacv1.PolicyRule{
-						{
-							Verbs:         []string{"get"},
-							ResourceNames: []string{"foo--1"},
-							Resources:     []string{"workspaces"},
-							APIGroups:     []string{"tenancy.kcp.dev"},
-						},
-					},
-				},
+				{ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: owned}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "bar", Annotations: owned}},
 			},
 		},
 		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
-			response, deletedNow, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
-			assert.NoError(t, err)
-			assert.Nil(t, response)
-			assert.False(t, deletedNow)
-			crbList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings"), rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"), "")
-			require.NoError(t, err)
-			crbs := crbList.(*rbacv1.ClusterRoleBindingList)
-			assert.Empty(t, crbs.Items)
-			crList, err := kubeClient.Tracker().List(rbacv1.SchemeGroupVersion.WithResource("clusterroles"), rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), "")
-			require.NoError(t, err)
-			crs := crList.(*rbacv1.ClusterRoleList)
-			assert.Empty(t, crs.Items)
-			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
-			require.NoError(t, err)
-			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
-			assert.Empty(t, wsList.Items)
+			_, err := storage.DeleteCollection(ctx, nil, &metav1.DeleteOptions{}, &metainternal.ListOptions{})
+			require.True(t, kerrors.IsForbidden(err), "expected Forbidden, got %v", err)
+			causes := err.(kerrors.APIStatus).Status().Details.Causes
+			require.Len(t, causes, 1)
+			assert.Equal(t, "metadata.name", causes[0].Field)
+			assert.Regexp(t, "^bar: ", causes[0].Message)
+
+			_, err = kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			assert.True(t, kerrors.IsNotFound(err), "the deletion of the other workspaces should go on, got %v", err)
 		},
 	}
 	applyTest(t, test)
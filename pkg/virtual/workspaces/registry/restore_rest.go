@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// RestoreSubresourceREST restores soft-deleted workspaces.
+type RestoreSubresourceREST struct {
+	mainRest *REST
+}
+
+var _ rest.NamedCreater = &RestoreSubresourceREST{}
+var _ rest.Scoper = &RestoreSubresourceREST{}
+
+// NewRestoreSubresourceREST returns the storage of the restore subresource.
+func NewRestoreSubresourceREST(mainRest *REST) *RestoreSubresourceREST {
+	return &RestoreSubresourceREST{mainRest: mainRest}
+}
+
+// New returns a new WorkspaceRestore
+func (s *RestoreSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceRestore{}
+}
+
+func (s *RestoreSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// Create removes the soft-deleted-until annotation from the ClusterWorkspace of the workspace with the given
+// name and returns the workspace. The user must be allowed to delete the workspace. Workspaces which are not
+// soft-deleted, or whose retention window is over, cannot be restored.
+func (s *RestoreSubresourceREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("unable to restore a workspace without a user on the context"))
+	}
+
+	if _, ok := obj.(*tenancyv1beta1.WorkspaceRestore); !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceRestore: %T", obj))
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	orgClusterName, org, err := s.mainRest.extractOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// restoring is the one operation on soft-deleted workspaces
+	clusterWorkspace, err := s.mainRest.getAccessibleClusterWorkspace(ctx, name, nil, true)
+	if kerrors.IsNotFound(err) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed, err := isAllowed(org.workspaceReviewerProvider.ForVerb("delete"), user, clusterWorkspace.Name); err != nil {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, err)
+	} else if !allowed {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("User %s doesn't have the permission to restore workspace %s in organization %s", user.GetName(), name, orgClusterName))
+	}
+
+	deletedUntil, deleted := clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation]
+	if !deleted {
+		return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("workspace is not deleted"))
+	}
+	now := time.Now
+	if s.mainRest.now != nil {
+		now = s.mainRest.now
+	}
+	if until, err := time.Parse(time.RFC3339, deletedUntil); err == nil && !now().Before(until) {
+		return nil, kerrors.NewGone(fmt.Sprintf("workspace %s was deleted and its retention window ended at %s", name, deletedUntil))
+	}
+
+	restored := clusterWorkspace.DeepCopy()
+	restored.Annotations = make(map[string]string, len(clusterWorkspace.Annotations))
+	for k, v := range clusterWorkspace.Annotations {
+		restored.Annotations[k] = v
+	}
+	delete(restored.Annotations, tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation)
+	if restored, err = org.clusterWorkspaceClient.Update(ctx, restored, metav1.UpdateOptions{DryRun: options.DryRun}); err != nil {
+		if kerrors.IsConflict(err) {
+			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, err)
+		}
+		return nil, err
+	}
+
+	return projectCreatedWorkspace(restored, name, "", false), nil
+}
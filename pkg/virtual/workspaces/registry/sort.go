@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	virtualcontext "github.com/kcp-dev/kcp/pkg/virtual/framework/context"
+)
+
+// SortByParameter is the query parameter choosing the field lists of workspaces are sorted by,
+// e.g. ?sortBy=creationTimestamp. Lists are sorted by name by default.
+const SortByParameter = "sortBy"
+
+// SortField is a field lists of workspaces can be sorted by, in ascending order.
+type SortField string
+
+const (
+	SortByName              SortField = "name"
+	SortByCreationTimestamp SortField = "creationTimestamp"
+)
+
+// sortByFrom returns the field the list is requested to be sorted by in the query of the request.
+func sortByFrom(ctx context.Context) (SortField, error) {
+	query, ok := ctx.Value(virtualcontext.RequestQueryKey).(url.Values)
+	if !ok || query.Get(SortByParameter) == "" {
+		return SortByName, nil
+	}
+	switch sortBy := SortField(query.Get(SortByParameter)); sortBy {
+	case SortByName, SortByCreationTimestamp:
+		return sortBy, nil
+	default:
+		return "", kerrors.NewBadRequest(fmt.Sprintf("invalid %s parameter %q, expected %q or %q", SortByParameter, sortBy, SortByName, SortByCreationTimestamp))
+	}
+}
+
+// workspaceLess returns the order of workspaces by the given field. Workspaces with the same value are
+// ordered by name, such that the order is total and pages are stable.
+func workspaceLess(sortBy SortField) func(a, b *tenancyv1beta1.Workspace) bool {
+	if sortBy == SortByCreationTimestamp {
+		return func(a, b *tenancyv1beta1.Workspace) bool {
+			at, bt := a.CreationTimestamp.Rfc3339Copy(), b.CreationTimestamp.Rfc3339Copy()
+			if !at.Equal(&bt) {
+				return at.Before(&bt)
+			}
+			return a.Name < b.Name
+		}
+	}
+	return func(a, b *tenancyv1beta1.Workspace) bool {
+		return a.Name < b.Name
+	}
+}
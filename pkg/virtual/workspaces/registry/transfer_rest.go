@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// TransferOwnershipSubresourceREST gives workspaces another owner.
+type TransferOwnershipSubresourceREST struct {
+	mainRest *REST
+}
+
+var _ rest.NamedCreater = &TransferOwnershipSubresourceREST{}
+var _ rest.Scoper = &TransferOwnershipSubresourceREST{}
+
+// New returns a new WorkspaceOwnershipTransfer
+func (s *TransferOwnershipSubresourceREST) New() runtime.Object {
+	return &tenancyv1beta1.WorkspaceOwnershipTransfer{}
+}
+
+func (s *TransferOwnershipSubresourceREST) NamespaceScoped() bool {
+	return false
+}
+
+// Create transfers the ownership of the workspace with the given name to the user of the WorkspaceOwnershipTransfer,
+// and returns the workspace as the new owner sees it. Only the owner of the workspace, or an administrator of the
+// organization, i.e. a user allowed to update all the workspaces of the organization, can transfer it. The latter
+// name workspaces by their internal name in the organization scope.
+//
+// The workspace keeps its name and its owner groups: the owner RBAC objects are created for the new owner, the owner
+// annotation of the ClusterWorkspace is updated, and the owner RBAC objects of the previous owner are deleted, such
+// that the workspace moves from the personal scope of the previous owner to the one of the new owner. The transfer is
+// rejected if the new owner already has a workspace with that name, or has reached their workspace quota.
+func (s *TransferOwnershipSubresourceREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	user, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("unable to transfer a workspace without a user on the context"))
+	}
+	scope := ctx.Value(WorkspacesScopeKey)
+	if scope != PersonalScope && scope != OrganizationScope {
+		return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("transferring the ownership of a workspace is only possible in the personal and organization workspaces scopes"))
+	}
+
+	transfer, ok := obj.(*tenancyv1beta1.WorkspaceOwnershipTransfer)
+	if !ok {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("not a WorkspaceOwnershipTransfer: %T", obj))
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+
+	orgClusterName, org, err := s.mainRest.extractOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userPath := field.NewPath("spec", "user")
+	if transfer.Spec.User == "" {
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceOwnershipTransfer"), name, field.ErrorList{field.Required(userPath, "")})
+	}
+	newOwner := &kuser.DefaultInfo{Name: transfer.Spec.User, Groups: transfer.Spec.Groups}
+
+	clusterWorkspace, err := s.mainRest.getInternalClusterWorkspace(ctx, name, nil)
+	if kerrors.IsNotFound(err) {
+		return nil, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isUserOwner(newOwner, clusterWorkspace) {
+		return nil, kerrors.NewInvalid(tenancyv1beta1.Kind("WorkspaceOwnershipTransfer"), name, field.ErrorList{field.Invalid(userPath, newOwner.Name, "the workspace is already owned by this user")})
+	}
+
+	if !isOwner(user, clusterWorkspace) {
+		if allowed, err := isAllowed(org.workspaceReviewerProvider.ForVerb("update"), user, ""); err != nil {
+			return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, err)
+		} else if !allowed {
+			return nil, kerrors.NewForbidden(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("only the owner of workspace %s or an administrator of organization %s can transfer its ownership", name, orgClusterName))
+		}
+	}
+
+	var previousOwner kuser.Info
+	prettyName := name
+	if owner := clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation]; owner != "" {
+		previousOwner = &kuser.DefaultInfo{Name: owner}
+		if scope == OrganizationScope {
+			// the workspace keeps the name its previous owner gave it, if any
+			if previousName, err := s.mainRest.getPrettyNameFromInternalName(previousOwner, orgClusterName, clusterWorkspace.Name); err == nil {
+				prettyName = previousName
+			} else if !kerrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+	}
+
+	if internalName, err := s.mainRest.getInternalNameFromPrettyName(newOwner, orgClusterName, prettyName); err == nil && internalName != clusterWorkspace.Name {
+		return nil, kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), prettyName)
+	} else if err != nil && !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+	// the informer might not have seen a concurrently created workspace yet
+	newOwnerRoleBindingName := getRoleBindingName(OwnerRoleType, prettyName, newOwner)
+	if crb, err := org.rbacClient.ClusterRoleBindings().Get(ctx, newOwnerRoleBindingName, metav1.GetOptions{}); err == nil && crb.Labels[InternalNameLabel] != clusterWorkspace.Name {
+		return nil, kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), prettyName)
+	} else if err != nil && !kerrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err := s.mainRest.checkWorkspaceQuota(ctx, orgClusterName, org, newOwner, prettyName); err != nil {
+		return nil, err
+	}
+
+	// the new owner gets access first, such that a failed transfer never leaves the workspace without owner
+	if err := createOwnerRBAC(ctx, org, newOwner, prettyName, clusterWorkspace.Name); err != nil {
+		return nil, err
+	}
+
+	transferred := clusterWorkspace.DeepCopy()
+	transferred.Annotations = make(map[string]string, len(clusterWorkspace.Annotations)+1)
+	for k, v := range clusterWorkspace.Annotations {
+		transferred.Annotations[k] = v
+	}
+	transferred.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation] = newOwner.GetName()
+	if transferred, err = org.clusterWorkspaceClient.Update(ctx, transferred, metav1.UpdateOptions{}); err != nil {
+		if kerrors.IsConflict(err) {
+			return nil, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, err)
+		}
+		return nil, err
+	}
+
+	if previousOwner != nil {
+		previousOwnerRoleBindingName := getRoleBindingName(OwnerRoleType, prettyName, previousOwner)
+		if err := org.rbacClient.ClusterRoleBindings().Delete(ctx, previousOwnerRoleBindingName, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return nil, err
+		}
+		for _, clusterRoleName := range []string{previousOwnerRoleBindingName, getRoleBindingName(ListerRoleType, prettyName, previousOwner)} {
+			if err := org.rbacClient.ClusterRoles().Delete(ctx, clusterRoleName, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+	}
+
+	return projectCreatedWorkspace(transferred, prettyName, "", false), nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/controller"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestTransferWorkspaceOwnership(t *testing.T) {
+	owner := &kuser.DefaultInfo{Name: "user-1", Groups: []string{"team-1"}}
+	newOwner := &kuser.DefaultInfo{Name: "user-2", Groups: []string{"team-2"}}
+	admin := &kuser.DefaultInfo{Name: "admin"}
+
+	workspace := tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", ClusterName: "root:org", Annotations: map[string]string{
+			tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation:       owner.Name,
+			tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1",
+		}},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase:   tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			BaseURL: "https://shard/clusters/root:org:foo",
+		},
+	}
+	ownerRBAC := []runtime.Object{
+		ownerBinding("root:org", "foo", "foo", owner),
+		createClusterRole(getRoleBindingName(OwnerRoleType, "foo", owner), "foo", OwnerRoleType),
+		createClusterRole(getRoleBindingName(ListerRoleType, "foo", owner), "foo", ListerRoleType),
+	}
+
+	tests := []struct {
+		name        string
+		user        kuser.Info
+		scope       string
+		workspace   string
+		newOwner    string
+		bindings    []rbacv1.ClusterRoleBinding
+		quotas      []tenancyv1alpha1.WorkspaceQuota
+		reviewer    mockReviewerProvider
+		expectedErr func(error) bool
+	}{
+		{
+			name:      "transfer by the owner",
+			user:      owner,
+			scope:     PersonalScope,
+			workspace: "foo",
+			newOwner:  newOwner.Name,
+			bindings:  []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", owner)},
+		},
+		{
+			name:      "transfer by an administrator of the organization",
+			user:      admin,
+			scope:     OrganizationScope,
+			workspace: "foo",
+			newOwner:  newOwner.Name,
+			bindings:  []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", owner)},
+			reviewer:  mockReviewerProvider{"update": mockReviewer{"": mockReview{users: []string{admin.Name}}}},
+		},
+		{
+			name:        "transfer by another user",
+			user:        admin,
+			scope:       OrganizationScope,
+			workspace:   "foo",
+			newOwner:    newOwner.Name,
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", owner)},
+			reviewer:    mockReviewerProvider{"update": mockReviewer{}},
+			expectedErr: kerrors.IsForbidden,
+		},
+		{
+			name:      "the new owner has another workspace with the name",
+			user:      owner,
+			scope:     PersonalScope,
+			workspace: "foo",
+			newOwner:  newOwner.Name,
+			bindings: []rbacv1.ClusterRoleBinding{
+				*ownerBinding("root:org", "foo", "foo", owner),
+				*ownerBinding("root:org", "foo", "foo--1", newOwner),
+			},
+			expectedErr: kerrors.IsAlreadyExists,
+		},
+		{
+			name:      "the new owner reached their quota",
+			user:      owner,
+			scope:     PersonalScope,
+			workspace: "foo",
+			newOwner:  newOwner.Name,
+			bindings:  []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", owner)},
+			quotas: []tenancyv1alpha1.WorkspaceQuota{{
+				ObjectMeta: metav1.ObjectMeta{Name: "none"},
+				Spec:       tenancyv1alpha1.WorkspaceQuotaSpec{Users: []string{newOwner.Name}, MaxWorkspaces: 0},
+			}},
+			expectedErr: kerrors.IsForbidden,
+		},
+		{
+			name:        "already owned by the user",
+			user:        owner,
+			scope:       PersonalScope,
+			workspace:   "foo",
+			newOwner:    owner.Name,
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", owner)},
+			expectedErr: kerrors.IsInvalid,
+		},
+		{
+			name:        "missing user",
+			user:        owner,
+			scope:       PersonalScope,
+			workspace:   "foo",
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", owner)},
+			expectedErr: kerrors.IsInvalid,
+		},
+		{
+			name:        "shared scope",
+			user:        owner,
+			scope:       SharedScope,
+			workspace:   "foo",
+			newOwner:    newOwner.Name,
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", owner)},
+			expectedErr: kerrors.IsForbidden,
+		},
+		{
+			name:        "unknown workspace",
+			user:        owner,
+			scope:       PersonalScope,
+			workspace:   "bar",
+			newOwner:    newOwner.Name,
+			bindings:    []rbacv1.ClusterRoleBinding{*ownerBinding("root:org", "foo", "foo", owner)},
+			expectedErr: kerrors.IsNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var bindings []runtime.Object
+			for i := range test.bindings {
+				bindings = append(bindings, &test.bindings[i])
+			}
+			indexClient := fake.NewSimpleClientset(bindings...)
+			kubeInformers := informers.NewSharedInformerFactory(indexClient, controller.NoResyncPeriodFunc())
+			crbInformer := kubeInformers.Rbac().V1().ClusterRoleBindings()
+			require.NoError(t, AddNameIndexers(crbInformer))
+			kubeInformers.Start(ctx.Done())
+			cache.WaitForCacheSync(ctx.Done(), crbInformer.Informer().HasSynced)
+
+			workspaces := []tenancyv1alpha1.ClusterWorkspace{workspace}
+			kcpClient := tenancyv1fake.NewSimpleClientset(&tenancyv1alpha1.ClusterWorkspaceList{Items: workspaces}, &tenancyv1alpha1.WorkspaceQuotaList{Items: test.quotas})
+			kubeClient := fake.NewSimpleClientset(ownerRBAC...)
+			org := &Org{
+				rbacClient:                kubeClient.RbacV1(),
+				crbInformer:               crbInformer,
+				clusterWorkspaceClient:    kcpClient.TenancyV1alpha1().ClusterWorkspaces(),
+				workspaceQuotaClient:      kcpClient.TenancyV1alpha1().WorkspaceQuotas(),
+				clusterWorkspaceLister:    &mockLister{workspaces: workspaces},
+				workspaceReviewerProvider: test.reviewer,
+			}
+			storage := &TransferOwnershipSubresourceREST{
+				mainRest: &REST{
+					getOrg: func(orgName string) (*Org, error) {
+						if orgName == "root:org" {
+							return org, nil
+						}
+						return nil, fmt.Errorf("Unknown organization: %s", orgName)
+					},
+					crbInformer: crbInformer,
+				},
+			}
+
+			ctx = apirequest.WithUser(ctx, test.user)
+			ctx = apirequest.WithValue(ctx, WorkspacesScopeKey, test.scope)
+			ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, "root:org")
+
+			transfer := &tenancyv1beta1.WorkspaceOwnershipTransfer{Spec: tenancyv1beta1.WorkspaceOwnershipTransferSpec{User: test.newOwner, Groups: newOwner.Groups}}
+			obj, err := storage.Create(ctx, test.workspace, transfer, nil, &metav1.CreateOptions{})
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				require.True(t, test.expectedErr(err), "unexpected error: %v", err)
+				clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, owner.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation], "expected the owner to be kept")
+				_, err = kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "foo", owner), metav1.GetOptions{})
+				require.NoError(t, err, "expected the owner binding of the owner to be kept")
+				return
+			}
+			require.NoError(t, err)
+
+			transferred, ok := obj.(*tenancyv1beta1.Workspace)
+			require.True(t, ok, "expected a Workspace, got %T", obj)
+			require.Equal(t, "foo", transferred.Name)
+			require.Equal(t, newOwner.Name, transferred.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+
+			clusterWorkspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, newOwner.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+			require.Equal(t, "team-1", clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation], "the owner groups should be kept")
+
+			binding, err := kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "foo", newOwner), metav1.GetOptions{})
+			require.NoError(t, err, "expected the owner binding of the new owner")
+			require.Equal(t, []rbacv1.Subject{{Kind: "User", Name: newOwner.Name}}, binding.Subjects)
+			require.Equal(t, "foo", binding.Labels[InternalNameLabel])
+			for _, roleType := range []RoleType{OwnerRoleType, ListerRoleType} {
+				role, err := kubeClient.RbacV1().ClusterRoles().Get(ctx, getRoleBindingName(roleType, "foo", newOwner), metav1.GetOptions{})
+				require.NoError(t, err, "expected the %s role of the new owner", roleType)
+				require.Equal(t, []string{"foo"}, role.Rules[0].ResourceNames)
+
+				_, err = kubeClient.RbacV1().ClusterRoles().Get(ctx, getRoleBindingName(roleType, "foo", owner), metav1.GetOptions{})
+				require.True(t, kerrors.IsNotFound(err), "expected the %s role of the previous owner to be deleted, got %v", roleType, err)
+			}
+			_, err = kubeClient.RbacV1().ClusterRoleBindings().Get(ctx, getRoleBindingName(OwnerRoleType, "foo", owner), metav1.GetOptions{})
+			require.True(t, kerrors.IsNotFound(err), "expected the owner binding of the previous owner to be deleted, got %v", err)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/features"
+)
+
+// validateWorkspace returns all the reasons why the given workspace cannot be created, with the path of the
+// offending field, instead of stopping at the first one. The name must leave room for a suffix of up to
+// suffixLength characters.
+func validateWorkspace(suffixLength int, workspace *tenancyv1beta1.Workspace) field.ErrorList {
+	errs := validateWorkspaceName(suffixLength, workspace.Name)
+
+	specPath := field.NewPath("spec")
+	if workspace.Spec.Type != "" {
+		// the type references the ClusterWorkspaceType with the lower-cased name
+		for _, msg := range validation.IsDNS1123Subdomain(strings.ToLower(workspace.Spec.Type)) {
+			errs = append(errs, field.Invalid(specPath.Child("type"), workspace.Spec.Type, msg))
+		}
+	}
+	errs = append(errs, tenancyhelper.ValidateDisplayMetadata(workspace.Spec.DisplayName, workspace.Spec.Description, specPath)...)
+	errs = append(errs, tenancyhelper.ValidateTags(workspace.Spec.Tags, specPath.Child("tags"))...)
+	errs = append(errs, tenancyhelper.ValidateFeatureGates(workspace.Spec.FeatureGates, features.WorkspaceFeatureGates(), specPath.Child("featureGates"))...)
+
+	annotationsPath := field.NewPath("metadata", "annotations")
+	if members, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation]; found {
+		if _, err := tenancyhelper.ParseInitialMembers(members); err != nil {
+			errs = append(errs, field.Invalid(annotationsPath.Key(tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation), members, err.Error()))
+		}
+	}
+	if namespaceLabels, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation]; found {
+		if _, err := tenancyhelper.ParseDefaultNamespaceLabels(namespaceLabels); err != nil {
+			errs = append(errs, field.Invalid(annotationsPath.Key(tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation), namespaceLabels, err.Error()))
+		}
+	}
+	if cloneFrom, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation]; found && cloneFrom == "" {
+		errs = append(errs, field.Required(annotationsPath.Key(tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation), "the name of the workspace to clone from is required"))
+	}
+	errs = append(errs, tenancyhelper.ValidateBootstrapBundle(workspace.Annotations, annotationsPath)...)
+	return errs
+}
+
+// validateGroupOwners checks that the user only requests groups it is a member of to own the workspace. System groups
+// cannot own workspaces, as they are shared by all the users.
+func validateGroupOwners(user kuser.Info, workspace *tenancyv1beta1.Workspace, fldPath *field.Path) field.ErrorList {
+	value, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation]
+	if !found {
+		return nil
+	}
+	var errs field.ErrorList
+	memberOf := sets.NewString(user.GetGroups()...)
+	for _, group := range strings.Split(value, ",") {
+		switch group = strings.TrimSpace(group); {
+		case group == "":
+			errs = append(errs, field.Invalid(fldPath, value, "group names must not be empty"))
+		case strings.HasPrefix(group, "system:"):
+			errs = append(errs, field.Invalid(fldPath, value, fmt.Sprintf("system group %q cannot own a workspace", group)))
+		case !memberOf.Has(group):
+			errs = append(errs, field.Invalid(fldPath, value, fmt.Sprintf("user is not a member of group %q", group)))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyv1fake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestValidateWorkspace(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		workspace *tenancyv1beta1.Workspace
+		expected  []string
+	}{
+		{
+			name: "valid",
+			workspace: &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation:         "user:alice=admin",
+						tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team=payments",
+					},
+				},
+				Spec: tenancyv1beta1.WorkspaceSpec{Type: "Universal", DisplayName: "Foo"},
+			},
+		},
+		{
+			name:      "invalid name",
+			workspace: &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "Foo"}},
+			expected:  []string{"metadata.name"},
+		},
+		{
+			name: "invalid type",
+			workspace: &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       tenancyv1beta1.WorkspaceSpec{Type: "not a type"},
+			},
+			expected: []string{"spec.type"},
+		},
+		{
+			name: "empty clone source",
+			workspace: &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation: ""},
+				},
+			},
+			expected: []string{"metadata.annotations[tenancy.kcp.dev/clone-from]"},
+		},
+		{
+			name: "bootstrap bundle without checksum",
+			workspace: &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceBootstrapBundleURLAnnotation: "https://bundles.example.com/team.tar.gz"},
+				},
+			},
+			expected: []string{"metadata.annotations[" + tenancyv1alpha1.ClusterWorkspaceBootstrapBundleSHA256Annotation + "]"},
+		},
+		{
+			name: "unknown feature gate",
+			workspace: &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       tenancyv1beta1.WorkspaceSpec{FeatureGates: map[string]bool{"ServerSideApply": true, "Teleportation": true}},
+			},
+			expected: []string{"spec.featureGates"},
+		},
+		{
+			name: "everything invalid at once",
+			workspace: &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo_bar",
+					Annotations: map[string]string{
+						tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation:         "alice",
+						tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team",
+					},
+				},
+				Spec: tenancyv1beta1.WorkspaceSpec{
+					Type:        "_universal",
+					DisplayName: strings.Repeat("a", 129),
+					Description: strings.Repeat("a", 1025),
+				},
+			},
+			expected: []string{
+				"metadata.name",
+				"spec.type",
+				"spec.displayName",
+				"spec.description",
+				"metadata.annotations[" + tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation + "]",
+				"metadata.annotations[" + tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation + "]",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var paths []string
+			for _, err := range validateWorkspace(maxSuffixLength(NameCollisionSuffix, nil), tc.workspace) {
+				paths = append(paths, err.Field)
+			}
+			require.Equal(t, tc.expected, paths)
+		})
+	}
+}
+
+func TestCreateWorkspaceReportsAllValidationErrors(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:             user,
+			scope:            PersonalScope,
+			orgName:          "orgName",
+			reviewerProvider: mockReviewerProvider{},
+		},
+		apply: func(t *testing.T, storage *REST, kubeconfigSubResourceStorage *KubeconfigSubresourceREST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			_, err := storage.Create(ctx, &tenancyv1beta1.Workspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "Foo",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team"},
+				},
+				Spec: tenancyv1beta1.WorkspaceSpec{Type: "not a type"},
+			}, nil, &metav1.CreateOptions{})
+			require.True(t, kerrors.IsInvalid(err), "expected Invalid, got %v", err)
+
+			causes := map[string]metav1.CauseType{}
+			for _, cause := range err.(kerrors.APIStatus).Status().Details.Causes {
+				if _, found := causes[cause.Field]; !found {
+					causes[cause.Field] = cause.Type
+				}
+			}
+			require.Equal(t, map[string]metav1.CauseType{
+				"metadata.name": metav1.CauseType(field.ErrorTypeInvalid),
+				"spec.type":     metav1.CauseType(field.ErrorTypeInvalid),
+				"metadata.annotations[" + tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation + "]": metav1.CauseType(field.ErrorTypeInvalid),
+			}, causes)
+
+			workspaces, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			require.Empty(t, workspaces.Items)
+		},
+	}
+	applyTest(t, test)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+// untilDeletedWatcher forwards the events of a watch of a single workspace until the workspace
+// got deleted. It then stops the source watch and closes its result channel, such that clients
+// see the stream terminate cleanly.
+type untilDeletedWatcher struct {
+	source   watch.Interface
+	result   chan watch.Event
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newUntilDeletedWatcher(source watch.Interface) watch.Interface {
+	w := &untilDeletedWatcher{
+		source: source,
+		result: make(chan watch.Event),
+		stop:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *untilDeletedWatcher) run() {
+	defer close(w.result)
+	defer w.source.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case event, ok := <-w.source.ResultChan():
+			if !ok {
+				return
+			}
+			select {
+			case w.result <- event:
+			case <-w.stop:
+				return
+			}
+			if event.Type == watch.Deleted {
+				return
+			}
+		}
+	}
+}
+
+// ResultChan implements watch.Interface.
+func (w *untilDeletedWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements watch.Interface.
+func (w *untilDeletedWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// bookmarkWatcher forwards the events of a watch of workspaces, and emits a Bookmark event carrying the
+// latest resourceVersion seen every interval, such that long-lived clients can resume the watch from there
+// without a full relist. No bookmark is emitted before a resourceVersion is known.
+type bookmarkWatcher struct {
+	source          watch.Interface
+	interval        time.Duration
+	resourceVersion string
+	result          chan watch.Event
+	stop            chan struct{}
+	stopOnce        sync.Once
+}
+
+// newBookmarkWatcher returns a watch emitting bookmarks every interval, starting from the given
+// resourceVersion of the watch request. The resourceVersion "0" is not a known one.
+func newBookmarkWatcher(source watch.Interface, resourceVersion string, interval time.Duration) watch.Interface {
+	if resourceVersion == "0" {
+		resourceVersion = ""
+	}
+	w := &bookmarkWatcher{
+		source:          source,
+		interval:        interval,
+		resourceVersion: resourceVersion,
+		result:          make(chan watch.Event),
+		stop:            make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *bookmarkWatcher) run() {
+	defer close(w.result)
+	defer w.source.Stop()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		var event watch.Event
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if w.resourceVersion == "" {
+				continue
+			}
+			event = watch.Event{
+				Type: watch.Bookmark,
+				Object: &tenancyv1beta1.Workspace{
+					ObjectMeta: metav1.ObjectMeta{ResourceVersion: w.resourceVersion},
+				},
+			}
+		case sourceEvent, ok := <-w.source.ResultChan():
+			if !ok {
+				return
+			}
+			if accessor, err := meta.Accessor(sourceEvent.Object); err == nil && accessor.GetResourceVersion() != "" {
+				w.resourceVersion = accessor.GetResourceVersion()
+			}
+			event = sourceEvent
+		}
+		select {
+		case w.result <- event:
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// ResultChan implements watch.Interface.
+func (w *bookmarkWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements watch.Interface.
+func (w *bookmarkWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+)
+
+func TestBookmarkWatcher(t *testing.T) {
+	source := watch.NewFake()
+	w := newBookmarkWatcher(source, "0", 10*time.Millisecond)
+	defer w.Stop()
+
+	go source.Add(&tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "foo", ResourceVersion: "42"}})
+
+	event := <-w.ResultChan()
+	require.Equal(t, watch.Added, event.Type)
+	require.Equal(t, "foo", event.Object.(*tenancyv1beta1.Workspace).Name)
+
+	select {
+	case event := <-w.ResultChan():
+		require.Equal(t, watch.Bookmark, event.Type, "expected a bookmark while idle")
+		require.Equal(t, "42", event.Object.(*tenancyv1beta1.Workspace).ResourceVersion)
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected a bookmark while idle")
+	}
+
+	w.Stop()
+	for range w.ResultChan() {
+	}
+	require.True(t, source.IsStopped(), "expected the source watch to be stopped")
+}
+
+func TestBookmarkWatcherWithoutResourceVersion(t *testing.T) {
+	source := watch.NewFake()
+	w := newBookmarkWatcher(source, "", 10*time.Millisecond)
+	defer w.Stop()
+
+	select {
+	case event := <-w.ResultChan():
+		t.Fatalf("expected no bookmark before a resourceVersion is known, got %v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	source.Stop()
+	_, ok := <-w.ResultChan()
+	require.False(t, ok, "expected the watch to end with its source")
+}
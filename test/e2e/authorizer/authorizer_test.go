@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery/cached/memory"
@@ -215,3 +216,76 @@ func TestAuthorizer(t *testing.T) {
 	)
 	require.Error(t, err)
 }
+
+func TestOrganizationFixtureRBAC(t *testing.T) {
+	t.Parallel()
+
+	usersKCPArgs, err := framework.Users([]framework.User{
+		{
+			Name:   "user-1",
+			UID:    "1111-1111-1111-1111",
+			Token:  "user-1-token",
+			Groups: []string{"team-1"},
+		},
+		{
+			Name:   "user-2",
+			UID:    "2222-2222-2222-2222",
+			Token:  "user-2-token",
+			Groups: []string{"team-2"},
+		},
+	}).ArgsForKCP(t)
+	require.NoError(t, err)
+
+	f := framework.NewKcpFixture(t, framework.KcpConfig{
+		Name: "main",
+		Args: usersKCPArgs,
+	})
+
+	ctx := context.Background()
+	if deadline, ok := t.Deadline(); ok {
+		withDeadline, cancel := context.WithDeadline(ctx, deadline)
+		t.Cleanup(cancel)
+		ctx = withDeadline
+	}
+	require.Equal(t, len(f.Servers), 1, "incorrect number of servers")
+
+	server := f.Servers["main"]
+
+	kcpCfg, err := server.DefaultConfig()
+	require.NoError(t, err)
+
+	orgClusterName := framework.NewOrganizationFixture(t, server, framework.WithRBAC(
+		[]rbacv1.ClusterRole{{
+			ObjectMeta: metav1.ObjectMeta{Name: "workspaces-editor"},
+			Rules: []rbacv1.PolicyRule{{
+				APIGroups: []string{tenancyv1alpha1.SchemeGroupVersion.Group},
+				Resources: []string{"clusterworkspaces/content"},
+				Verbs:     []string{"edit", "view"},
+			}},
+		}},
+		rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "user-2-workspaces-editor"},
+			Subjects:   []rbacv1.Subject{{Kind: "User", Name: "user-2", APIGroup: rbacv1.GroupName}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "workspaces-editor", APIGroup: rbacv1.GroupName},
+		},
+	))
+	clusterName, _ := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+
+	t.Logf("Expect user-2 to be granted the edit role by the organization fixture")
+	user2Client := newUserClient(t, "user-2", clusterName, kcpCfg)
+	require.Eventually(t, func() bool {
+		_, err := user2Client.KubeClient.CoreV1().Namespaces().Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}, metav1.CreateOptions{})
+		if err != nil {
+			klog.Errorf("failed to create namespace as user-2: %v", err)
+			return false
+		}
+		return true
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "user-2 failed to create a namespace")
+	_, err = user2Client.KubeClient.CoreV1().ConfigMaps("default").Create(ctx, &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Expect user-1 to be denied without a role")
+	user1Client := newUserClient(t, "user-1", clusterName, kcpCfg)
+	_, err = user1Client.KubeClient.CoreV1().ConfigMaps("default").Get(ctx, "foo", metav1.GetOptions{})
+	require.Error(t, err)
+}
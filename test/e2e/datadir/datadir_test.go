@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datadir
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestPersistentDataDir(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if deadline, ok := t.Deadline(); ok {
+		withDeadline, cancel := context.WithDeadline(ctx, deadline)
+		t.Cleanup(cancel)
+		ctx = withDeadline
+	}
+
+	const serverName = "main"
+	dataDir := t.TempDir()
+
+	newClient := func(t *testing.T) kcpclientset.ClusterInterface {
+		f := framework.NewKcpFixture(t,
+			framework.KcpConfig{
+				Name:    serverName,
+				DataDir: dataDir,
+			},
+		)
+		require.Equal(t, 1, len(f.Servers), "incorrect number of servers")
+		cfg, err := f.Servers[serverName].DefaultConfig()
+		require.NoError(t, err)
+		kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
+		require.NoError(t, err, "failed to construct client for server")
+		return kcpClusterClient
+	}
+
+	var workspaceName string
+	t.Run("first server creates a workspace", func(t *testing.T) {
+		kcpClusterClient := newClient(t)
+
+		// not an organization fixture, which would be deleted at the end of the subtest
+		workspace, err := kcpClusterClient.Cluster(helper.RootCluster).TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "e2e-datadir-"},
+			Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Organization"},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err, "failed to create workspace")
+		workspaceName = workspace.Name
+	})
+	require.NotEmpty(t, workspaceName, "the first server failed")
+
+	t.Run("second server sees the workspace", func(t *testing.T) {
+		kcpClusterClient := newClient(t)
+
+		_, err := kcpClusterClient.Cluster(helper.RootCluster).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspaceName, metav1.GetOptions{})
+		require.NoError(t, err, "workspace %s created by the first server should exist", workspaceName)
+	})
+}
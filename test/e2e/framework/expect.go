@@ -27,8 +27,10 @@ import (
 	"github.com/google/uuid"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -320,6 +322,108 @@ func ExpectWorkspaceListPolling(ctx context.Context, t *testing.T, client kcpcli
 	}, nil
 }
 
+// ExpectWorkspaceListWatching sets up an Expecter in order to allow registering expectations in tests with minimal setup.
+// In contrast to ExpectWorkspaceListPolling, expectations are evaluated on every event of a watch on workspaces. The
+// watch is resumed from the last seen resourceVersion when it closes, and workspaces are re-listed if that
+// resourceVersion is too old.
+func ExpectWorkspaceListWatching(ctx context.Context, t *testing.T, client kcpclientset.Interface) (RegisterWorkspaceListExpectation, error) {
+	// stop watching when the test is done, otherwise the open watch delays the server shutdown
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	expecter := &expectationController{
+		expectations: map[uuid.UUID]expectationRecord{},
+		lock:         sync.RWMutex{},
+	}
+
+	var lock sync.RWMutex
+	current := &tenancyv1beta1.WorkspaceList{}
+	relist := func() (string, error) {
+		list, err := client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return "", err
+		}
+		lock.Lock()
+		current = list
+		lock.Unlock()
+		expecter.triggerExpectations()
+		return list.ResourceVersion, nil
+	}
+	update := func(eventType watch.EventType, workspace *tenancyv1beta1.Workspace) {
+		lock.Lock()
+		items := make([]tenancyv1beta1.Workspace, 0, len(current.Items)+1)
+		for _, item := range current.Items {
+			if item.Name != workspace.Name {
+				items = append(items, item)
+			}
+		}
+		if eventType != watch.Deleted {
+			items = append(items, *workspace)
+		}
+		current = &tenancyv1beta1.WorkspaceList{ListMeta: current.ListMeta, Items: items}
+		current.ResourceVersion = workspace.ResourceVersion
+		lock.Unlock()
+		expecter.triggerExpectations()
+	}
+
+	resourceVersion, err := relist()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for ctx.Err() == nil {
+			w, err := client.TenancyV1beta1().Workspaces().Watch(ctx, metav1.ListOptions{ResourceVersion: resourceVersion, AllowWatchBookmarks: true})
+			if apierrors.IsGone(err) || apierrors.IsResourceExpired(err) {
+				if resourceVersion, err = relist(); err != nil && ctx.Err() == nil {
+					t.Logf("failed to re-list workspaces: %v", err)
+					time.Sleep(100 * time.Millisecond)
+				}
+				continue
+			} else if err != nil {
+				if ctx.Err() == nil {
+					t.Logf("failed to watch workspaces: %v", err)
+					time.Sleep(100 * time.Millisecond)
+				}
+				continue
+			}
+
+			for event := range w.ResultChan() {
+				switch event.Type {
+				case watch.Added, watch.Modified, watch.Deleted:
+					workspace, ok := event.Object.(*tenancyv1beta1.Workspace)
+					if !ok {
+						continue
+					}
+					resourceVersion = workspace.ResourceVersion
+					update(event.Type, workspace)
+				case watch.Bookmark:
+					if accessor, err := meta.Accessor(event.Object); err == nil {
+						resourceVersion = accessor.GetResourceVersion()
+					}
+				case watch.Error:
+					if err := apierrors.FromObject(event.Object); apierrors.IsGone(err) || apierrors.IsResourceExpired(err) {
+						if resourceVersion, err = relist(); err != nil && ctx.Err() == nil {
+							t.Logf("failed to re-list workspaces: %v", err)
+						}
+					}
+				}
+			}
+			w.Stop()
+		}
+	}()
+
+	return func(expectation WorkspaceListExpectation) error {
+		return expecter.ExpectBefore(ctx, func(ctx context.Context) (done bool, err error) {
+			lock.RLock()
+			list := current.DeepCopy()
+			lock.RUnlock()
+			expectErr := expectation(list)
+			return expectErr == nil, expectErr
+		}, 30*time.Second)
+	}, nil
+}
+
 // RegisterWorkspaceShardExpectation registers an expectation about the future state of the seed.
 type RegisterWorkspaceShardExpectation func(seed *tenancyv1alpha1.WorkspaceShard, expectation WorkspaceShardExpectation) error
 
@@ -26,14 +26,20 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
 
 // KcpFixture manages the lifecycle of a set of kcp servers.
@@ -124,6 +130,15 @@ func NewKcpFixture(t *testing.T, cfgs ...KcpConfig) *KcpFixture {
 
 	t.Logf("Started kcp servers after %s", time.Since(start))
 
+	for _, cfg := range cfgs {
+		if cfg.ShardOf == "" {
+			continue
+		}
+		root, found := f.Servers[cfg.ShardOf]
+		require.Truef(t, found, "kcp server %s is a shard of the unknown kcp server %s", cfg.Name, cfg.ShardOf)
+		RegisterWorkspaceShard(t, root, cfg.Name, f.Servers[cfg.Name])
+	}
+
 	return f
 }
 
@@ -132,10 +147,35 @@ func InProcessEnvSet() bool {
 	return inProcess
 }
 
-func NewOrganizationFixture(t *testing.T, server RunningServer) (orgClusterName string) {
+type organizationOptions struct {
+	clusterRoles        []rbacv1.ClusterRole
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+}
+
+// OrganizationOption customizes the organization created by NewOrganizationFixture.
+type OrganizationOption func(o *organizationOptions)
+
+// WithRBAC bootstraps the given ClusterRoles and ClusterRoleBindings into the organization once it is ready,
+// e.g. to give the users of a test access to it.
+func WithRBAC(clusterRoles []rbacv1.ClusterRole, clusterRoleBindings ...rbacv1.ClusterRoleBinding) OrganizationOption {
+	return func(o *organizationOptions) {
+		o.clusterRoles = append(o.clusterRoles, clusterRoles...)
+		o.clusterRoleBindings = append(o.clusterRoleBindings, clusterRoleBindings...)
+	}
+}
+
+// NewOrganizationFixture creates an organization ClusterWorkspace in the root logical cluster, waits for it to be
+// ready and for the RBAC objects passed with WithRBAC to be applied in it. It returns the logical cluster name of
+// the organization, which is deleted on cleanup.
+func NewOrganizationFixture(t *testing.T, server RunningServer, opts ...OrganizationOption) (orgClusterName string) {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	t.Cleanup(cancelFunc)
 
+	var options organizationOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	cfg, err := server.DefaultConfig()
 	require.NoError(t, err, "failed to get kcp server config")
 
@@ -170,16 +210,58 @@ func NewOrganizationFixture(t *testing.T, server RunningServer) (orgClusterName
 		return ws.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady
 	}, wait.ForeverTestTimeout, time.Millisecond*100, "failed to wait for organization workspace %s to become ready", org.Name)
 
-	return helper.EncodeOrganizationAndClusterWorkspace(helper.RootCluster, org.Name)
+	orgClusterName = helper.EncodeOrganizationAndClusterWorkspace(helper.RootCluster, org.Name)
+	if len(options.clusterRoles) > 0 || len(options.clusterRoleBindings) > 0 {
+		bootstrapRBAC(ctx, t, server, orgClusterName, options.clusterRoles, options.clusterRoleBindings)
+	}
+	return orgClusterName
 }
 
-func NewWorkspaceFixture(t *testing.T, server RunningServer, orgClusterName string, workspaceType string) (clusterName string) {
+// bootstrapRBAC creates the given ClusterRoles and ClusterRoleBindings in the logical cluster, and waits until
+// they are all served back.
+func bootstrapRBAC(ctx context.Context, t *testing.T, server RunningServer, clusterName string, clusterRoles []rbacv1.ClusterRole, clusterRoleBindings []rbacv1.ClusterRoleBinding) {
+	cfg, err := server.DefaultConfig()
+	require.NoError(t, err, "failed to get kcp server config")
+
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to create kube cluster client")
+	rbacClient := kubeClusterClient.Cluster(clusterName).RbacV1()
+
+	for i := range clusterRoles {
+		_, err := rbacClient.ClusterRoles().Create(ctx, &clusterRoles[i], metav1.CreateOptions{})
+		require.NoErrorf(t, err, "failed to create cluster role %s in %s", clusterRoles[i].Name, clusterName)
+	}
+	for i := range clusterRoleBindings {
+		_, err := rbacClient.ClusterRoleBindings().Create(ctx, &clusterRoleBindings[i], metav1.CreateOptions{})
+		require.NoErrorf(t, err, "failed to create cluster role binding %s in %s", clusterRoleBindings[i].Name, clusterName)
+	}
+
+	require.Eventuallyf(t, func() bool {
+		for _, clusterRole := range clusterRoles {
+			if _, err := rbacClient.ClusterRoles().Get(ctx, clusterRole.Name, metav1.GetOptions{}); err != nil {
+				klog.Errorf("failed to get cluster role %s in %s: %v", clusterRole.Name, clusterName, err)
+				return false
+			}
+		}
+		for _, clusterRoleBinding := range clusterRoleBindings {
+			if _, err := rbacClient.ClusterRoleBindings().Get(ctx, clusterRoleBinding.Name, metav1.GetOptions{}); err != nil {
+				klog.Errorf("failed to get cluster role binding %s in %s: %v", clusterRoleBinding.Name, clusterName, err)
+				return false
+			}
+		}
+		return true
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "failed to wait for the RBAC of %s to be applied", clusterName)
+}
+
+// NewWorkspaceFixture creates a ClusterWorkspace of the given type in the parent logical cluster, i.e. in an
+// organization or, for nested tenancy, in another workspace, and waits for it to be ready. It returns the logical
+// cluster name of the workspace, and a cleanup hook to delete it before the test ends.
+func NewWorkspaceFixture(t *testing.T, server RunningServer, parentClusterName string, workspaceType string) (clusterName string, cleanup func()) {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	t.Cleanup(cancelFunc)
 
-	rootOrg, orgName, err := helper.ParseLogicalClusterName(orgClusterName)
-	require.NoErrorf(t, err, "failed to parse organization cluster name %q", orgClusterName)
-	require.Equalf(t, rootOrg, helper.RootCluster, "expected an org cluster name, i.e. with \"%s:\" prefix", helper.RootCluster)
+	_, parentName, err := helper.ParseLogicalClusterName(parentClusterName)
+	require.NoErrorf(t, err, "failed to parse parent cluster name %q", parentClusterName)
 
 	cfg, err := server.DefaultConfig()
 	require.NoError(t, err)
@@ -187,7 +269,7 @@ func NewWorkspaceFixture(t *testing.T, server RunningServer, orgClusterName stri
 	clusterClient, err := kcpclientset.NewClusterForConfig(cfg)
 	require.NoError(t, err, "failed to construct client for server")
 
-	ws, err := clusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{
+	ws, err := clusterClient.Cluster(parentClusterName).TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "e2e-workspace-",
 		},
@@ -197,23 +279,87 @@ func NewWorkspaceFixture(t *testing.T, server RunningServer, orgClusterName stri
 	}, metav1.CreateOptions{})
 	require.NoError(t, err, "failed to create workspace")
 
-	t.Cleanup(func() {
-		err := clusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, ws.Name, metav1.DeleteOptions{})
+	cleanup = func() {
+		err := clusterClient.Cluster(parentClusterName).TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, ws.Name, metav1.DeleteOptions{})
 		if apierrors.IsNotFound(err) {
 			return // ignore not found error
 		}
 		require.NoErrorf(t, err, "failed to delete workspace %s", ws.Name)
-	})
+	}
+	t.Cleanup(cleanup)
 
 	require.Eventuallyf(t, func() bool {
-		ws, err := clusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, ws.Name, metav1.GetOptions{})
+		ws, err := clusterClient.Cluster(parentClusterName).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, ws.Name, metav1.GetOptions{})
 		require.Falsef(t, apierrors.IsNotFound(err), "workspace %s was deleted", ws.Name)
 		if err != nil {
 			klog.Errorf("failed to get workspace %s: %v", ws.Name, err)
 			return false
 		}
 		return ws.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady
-	}, wait.ForeverTestTimeout, time.Millisecond*100, "failed to wait for workspace %s:%s to become ready", orgName, ws.Name)
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "failed to wait for workspace %s:%s to become ready", parentClusterName, ws.Name)
+
+	return helper.EncodeOrganizationAndClusterWorkspace(parentName, ws.Name), cleanup
+}
+
+// RegisterWorkspaceShard registers the shard server with the root server as a WorkspaceShard of the given name,
+// and waits for its credentials to be valid. The credentials are the admin kubeconfig of the shard server,
+// stored in a secret of the root logical cluster like the ones of the root shard. Both are deleted on cleanup.
+func RegisterWorkspaceShard(t *testing.T, root RunningServer, name string, shard RunningServer) *tenancyv1alpha1.WorkspaceShard {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	shardRawConfig, err := shard.RawConfig()
+	require.NoError(t, err, "failed to get kcp server raw config")
+	shardRawConfig.CurrentContext = "system:admin"
+	require.NoError(t, clientcmdapi.MinifyConfig(&shardRawConfig), "failed to minify the kubeconfig of kcp server %s", shard.Name())
+	kubeconfig, err := clientcmd.Write(shardRawConfig)
+	require.NoError(t, err, "failed to serialize the kubeconfig of kcp server %s", shard.Name())
+
+	cfg, err := root.DefaultConfig()
+	require.NoError(t, err, "failed to get kcp server config")
+	kubeClient, err := kubernetes.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to create kube cluster client")
+	clusterClient, err := kcpclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to create kcp cluster client")
+	secretClient := kubeClient.Cluster(helper.RootCluster).CoreV1().Secrets("default")
+	shardClient := clusterClient.Cluster(helper.RootCluster).TenancyV1alpha1().WorkspaceShards()
+
+	secret, err := secretClient.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "shard-" + name + "-kubeconfig"},
+		Data:       map[string][]byte{tenancyv1alpha1.WorkspaceShardCredentialsKey: kubeconfig},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err, "failed to create the credentials of workspace shard %s", name)
+	t.Cleanup(func() {
+		err := secretClient.Delete(ctx, secret.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return // ignore not found error
+		}
+		require.NoErrorf(t, err, "failed to delete the credentials of workspace shard %s", name)
+	})
+
+	workspaceShard, err := shardClient.Create(ctx, &tenancyv1alpha1.WorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: tenancyv1alpha1.WorkspaceShardSpec{
+			Credentials: corev1.SecretReference{Namespace: secret.Namespace, Name: secret.Name},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err, "failed to create workspace shard %s", name)
+	t.Cleanup(func() {
+		err := shardClient.Delete(ctx, workspaceShard.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return // ignore not found error
+		}
+		require.NoErrorf(t, err, "failed to delete workspace shard %s", name)
+	})
+
+	require.Eventuallyf(t, func() bool {
+		workspaceShard, err = shardClient.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			klog.Errorf("failed to get workspace shard %s: %v", name, err)
+			return false
+		}
+		return conditions.IsTrue(workspaceShard, tenancyv1alpha1.WorkspaceShardCredentialsValid)
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "failed to wait for the credentials of workspace shard %s to be valid", name)
 
-	return helper.EncodeOrganizationAndClusterWorkspace(orgName, ws.Name)
+	return workspaceShard
 }
@@ -59,6 +59,10 @@ type kcpServer struct {
 	ctx         context.Context
 	dataDir     string
 	artifactDir string
+	// persistent is true if dataDir is provided by the test and may hold the state of a previous run.
+	persistent bool
+	// started is when the server was last run.
+	started time.Time
 
 	lock           *sync.Mutex
 	cfg            clientcmd.ClientConfig
@@ -95,6 +99,10 @@ func newKcpServer(t *testing.T, cfg KcpConfig, artifactDir, dataDir string) (*kc
 		return nil, fmt.Errorf("could not create artifact dir: %w", err)
 	}
 	dataDir = filepath.Join(dataDir, "kcp", cfg.Name)
+	if cfg.DataDir != "" {
+		dataDir = cfg.DataDir
+		t.Logf("kcp server %s uses the persistent data dir %q, which is kept after the test", cfg.Name, dataDir)
+	}
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, fmt.Errorf("could not create data dir: %w", err)
 	}
@@ -112,6 +120,7 @@ func newKcpServer(t *testing.T, cfg KcpConfig, artifactDir, dataDir string) (*kc
 			cfg.Args...),
 		dataDir:     dataDir,
 		artifactDir: artifactDir,
+		persistent:  cfg.DataDir != "",
 		ctx:         ctx,
 		t:           t,
 		lock:        &sync.Mutex{},
@@ -152,6 +161,8 @@ func (c *kcpServer) Run(parentCtx context.Context, opts ...RunOption) error {
 		c.t.Cleanup(deadlinedCancel) // this does not really matter but govet is upset
 	}
 	c.ctx = ctx
+	// file modification times may have a one second granularity
+	c.started = time.Now().Truncate(time.Second)
 	cleanupCtx, cleanupCancel := context.WithCancel(context.Background())
 	c.t.Cleanup(func() {
 		c.t.Log("cleanup: ending kcp server")
@@ -286,6 +297,11 @@ func (c *kcpServer) DefaultConfig() (*rest.Config, error) {
 	return config.ClientConfig()
 }
 
+// ImpersonatingConfig exposes a copy of the admin client config for this server, impersonating the given user.
+func (c *kcpServer) ImpersonatingConfig(user User) (*rest.Config, error) {
+	return impersonatingConfig(c, user)
+}
+
 // RawConfig exposes a copy of the client config for this server.
 func (c *kcpServer) RawConfig() (clientcmdapi.Config, error) {
 	c.lock.Lock()
@@ -344,6 +360,12 @@ func (c *kcpServer) loadCfg() error {
 	var lastError error
 	if err := wait.PollImmediateWithContext(c.ctx, 100*time.Millisecond, 1*time.Minute, func(ctx context.Context) (bool, error) {
 		c.kubeconfigPath = filepath.Join(c.dataDir, "admin.kubeconfig")
+		if c.persistent {
+			// wait for the kubeconfig left by a previous run to be rewritten with the new port
+			if fi, err := os.Stat(c.kubeconfigPath); err == nil && fi.ModTime().Before(c.started) {
+				return false, nil
+			}
+		}
 		config, err := loadKubeConfig(c.kubeconfigPath)
 		if err != nil {
 			// A missing file is likely caused by the server not
@@ -474,6 +496,10 @@ func (s *unmanagedKCPServer) DefaultConfig() (*rest.Config, error) {
 	return config.ClientConfig()
 }
 
+func (s *unmanagedKCPServer) ImpersonatingConfig(user User) (*rest.Config, error) {
+	return impersonatingConfig(s, user)
+}
+
 func (s *unmanagedKCPServer) Artifact(t *testing.T, producer func() (runtime.Object, error)) {
 	artifact(t, s, producer)
 }
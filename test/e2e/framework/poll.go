@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// pollInitialInterval is the time PollWithBackoff waits after the first failed call to its condition.
+	pollInitialInterval = 50 * time.Millisecond
+	// pollMaxInterval caps the time PollWithBackoff waits between calls, up to its jitter.
+	pollMaxInterval = 2 * time.Second
+)
+
+// pollBackoff returns the jittered exponential backoff between the calls of PollWithBackoff.
+func pollBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: pollInitialInterval,
+		Factor:   2,
+		Jitter:   0.2,
+		Steps:    math.MaxInt32,
+		Cap:      pollMaxInterval,
+	}
+}
+
+// PollWithBackoff calls condition until it returns nil, waiting longer and longer between the calls with a jittered
+// exponential backoff. It gives up when ctx is done, which e2e tests bound to t.Deadline(), and at the latest after
+// wait.ForeverTestTimeout, and then returns an error wrapping the last error of condition.
+func PollWithBackoff(ctx context.Context, condition func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, wait.ForeverTestTimeout)
+	defer cancel()
+
+	backoff := pollBackoff()
+	for {
+		err := condition(ctx)
+		if err == nil {
+			return nil
+		}
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("condition not met before %v: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
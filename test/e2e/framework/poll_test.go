@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollBackoffGrowsUpToItsCap(t *testing.T) {
+	backoff := pollBackoff()
+	var previous time.Duration
+	for i := 0; i < 10; i++ {
+		interval := backoff.Step()
+		maxInterval := time.Duration(float64(pollMaxInterval) * (1 + backoff.Jitter))
+		require.LessOrEqual(t, interval, maxInterval, "interval %d should be capped", i)
+		if previous < pollMaxInterval/2 {
+			require.Greater(t, interval, previous, "interval %d should grow until the cap", i)
+		} else {
+			require.GreaterOrEqual(t, interval, pollMaxInterval, "interval %d should stay at the cap", i)
+		}
+		previous = interval
+	}
+}
+
+func TestPollWithBackoff(t *testing.T) {
+	calls := 0
+	err := PollWithBackoff(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+}
+
+func TestPollWithBackoffRespectsTheDeadline(t *testing.T) {
+	notYet := errors.New("not yet")
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var calls []time.Time
+	start := time.Now()
+	err := PollWithBackoff(ctx, func(ctx context.Context) error {
+		calls = append(calls, time.Now())
+		return notYet
+	})
+	elapsed := time.Since(start)
+
+	require.ErrorIs(t, err, notYet, "expected the last error of the condition")
+	require.GreaterOrEqual(t, elapsed, 500*time.Millisecond)
+	require.Less(t, elapsed, time.Second, "expected to give up at the deadline instead of waiting for the next call")
+
+	// 50ms, 100ms, 200ms... with up to 20% of jitter fit 3 to 4 calls in 500ms, a fixed 100ms interval 5
+	require.GreaterOrEqual(t, len(calls), 3)
+	require.LessOrEqual(t, len(calls), 4)
+	for i := 2; i < len(calls); i++ {
+		require.Greater(t, calls[i].Sub(calls[i-1]), calls[i-1].Sub(calls[i-2]), "expected the interval before call %d to grow", i)
+	}
+}
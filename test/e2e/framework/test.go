@@ -29,6 +29,9 @@ type RunningServer interface {
 	KubeconfigPath() string
 	RawConfig() (clientcmdapi.Config, error)
 	DefaultConfig() (*rest.Config, error)
+	// ImpersonatingConfig returns a copy of the admin client config for this server, impersonating the given
+	// user with its groups. It is a cheap alternative to authenticating the user with a token.
+	ImpersonatingConfig(user User) (*rest.Config, error)
 	Artifact(t *testing.T, producer func() (runtime.Object, error))
 }
 
@@ -37,6 +40,15 @@ type KcpConfig struct {
 	Name string
 	Args []string
 
+	// DataDir is the root directory of the server, holding its embedded etcd data. If set, it is used
+	// as is and kept after the test, such that its state can be inspected or reused by another fixture.
+	// By default, an ephemeral directory specific to the test is used.
+	DataDir string
+
+	// ShardOf is the name of another server of the same fixture. If set, this server is registered with that
+	// one as an additional WorkspaceShard named after this server, once both are ready.
+	ShardOf string
+
 	LogToConsole bool
 	RunInProcess bool
 }
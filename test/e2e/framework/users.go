@@ -17,11 +17,24 @@ limitations under the License.
 package framework
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/keyutil"
 )
 
 type User struct {
@@ -29,6 +42,12 @@ type User struct {
 	Token  string
 	UID    string
 	Groups []string
+
+	// ClientCertificateData and ClientKeyData, if set, are a client certificate and its key issued by
+	// ClientCA authenticating the user instead of the token.
+	ClientCertificateData []byte
+	ClientKeyData         []byte
+	ClientCA              *ClientCA
 }
 
 var LoopbackUser User = User{
@@ -39,19 +58,153 @@ func (u User) String() string {
 	return fmt.Sprintf("%s,%s,%s,\"%s\"", u.Token, u.Name, u.UID, strings.Join(u.Groups, ","))
 }
 
+// AuthInfo returns the kubeconfig user authenticating as u, with its client certificate if it has one
+// and with its token otherwise.
+func (u User) AuthInfo() *clientcmdapi.AuthInfo {
+	if len(u.ClientCertificateData) > 0 {
+		return &clientcmdapi.AuthInfo{
+			ClientCertificateData: u.ClientCertificateData,
+			ClientKeyData:         u.ClientKeyData,
+		}
+	}
+	return &clientcmdapi.AuthInfo{Token: u.Token}
+}
+
+// impersonatingConfig returns the admin client config of the server with Impersonate-* headers for the user,
+// its uid and its groups.
+func impersonatingConfig(server RunningServer, user User) (*rest.Config, error) {
+	if user.Name == "" {
+		return nil, fmt.Errorf("a user name is required to impersonate")
+	}
+	cfg, err := server.DefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Impersonate = rest.ImpersonationConfig{
+		UserName: user.Name,
+		UID:      user.UID,
+		Groups:   append([]string(nil), user.Groups...),
+	}
+	return cfg, nil
+}
+
 type Users []User
 
+// ArgsForKCP returns the arguments of kcp authenticating the users: those with a token through a token file,
+// and those with a client certificate through the bundle of their client CAs.
 func (us Users) ArgsForKCP(t *testing.T) ([]string, error) {
-	kcpTokensPath := path.Join(t.TempDir(), "kcp-tokens")
+	dir := t.TempDir()
+	kcpTokensPath := path.Join(dir, "kcp-tokens")
 	kcpTokens, err := os.Create(kcpTokensPath)
 	if err != nil {
 		return nil, err
 	}
 	defer kcpTokens.Close()
 	for _, user := range us {
+		if user.Token == "" {
+			continue
+		}
 		if _, err := kcpTokens.WriteString(user.String() + "\n"); err != nil {
 			return nil, err
 		}
 	}
-	return []string{"--token-auth-file", kcpTokensPath}, nil
+	args := []string{"--token-auth-file", kcpTokensPath}
+
+	if bundle := us.ClientCABundle(); len(bundle) > 0 {
+		clientCAPath := path.Join(dir, "client-ca.crt")
+		if err := os.WriteFile(clientCAPath, bundle, 0600); err != nil {
+			return nil, err
+		}
+		args = append(args, "--client-ca-file", clientCAPath)
+	}
+	return args, nil
+}
+
+// ClientCABundle returns the PEM encoded certificates of the client CAs of the users, or nil if none of
+// them has a client certificate.
+func (us Users) ClientCABundle() []byte {
+	var bundle bytes.Buffer
+	seen := map[*ClientCA]bool{}
+	for _, user := range us {
+		if user.ClientCA == nil || seen[user.ClientCA] {
+			continue
+		}
+		seen[user.ClientCA] = true
+		bundle.Write(user.ClientCA.CertData)
+	}
+	return bundle.Bytes()
+}
+
+// ClientCA is a certificate authority issuing client certificates to test users.
+type ClientCA struct {
+	// CertData is the PEM encoded certificate of the CA.
+	CertData []byte
+
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// NewClientCA returns a new self-signed client CA.
+func NewClientCA() (*ClientCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kcp-e2e-client-ca"},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientCA{
+		CertData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		cert:     cert,
+		key:      key,
+	}, nil
+}
+
+// IssueClientCert returns a copy of the user authenticated by a client certificate issued by the CA, with
+// the name of the user as common name and its groups as organizations.
+func (ca *ClientCA) IssueClientCert(user User) (User, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return User{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if err != nil {
+		return User{}, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: user.Name, Organization: user.Groups},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		return User{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return User{}, err
+	}
+	user.ClientCertificateData = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	user.ClientKeyData = pem.EncodeToMemory(&pem.Block{Type: keyutil.ECPrivateKeyBlockType, Bytes: keyDER})
+	user.ClientCA = ca
+	return user, nil
 }
@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueClientCert(t *testing.T) {
+	ca, err := NewClientCA()
+	require.NoError(t, err)
+
+	user, err := ca.IssueClientCert(User{Name: "user-1", Groups: []string{"team-1", "team-2"}})
+	require.NoError(t, err)
+	require.Empty(t, user.Token)
+	require.Equal(t, user.ClientCertificateData, user.AuthInfo().ClientCertificateData)
+	require.Equal(t, user.ClientKeyData, user.AuthInfo().ClientKeyData)
+
+	block, _ := pem.Decode(user.ClientCertificateData)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", cert.Subject.CommonName)
+	require.Equal(t, []string{"team-1", "team-2"}, cert.Subject.Organization)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(ca.CertData))
+	_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	require.NoError(t, err, "expected the client certificate to be issued by the CA")
+}
+
+func TestArgsForKCP(t *testing.T) {
+	ca, err := NewClientCA()
+	require.NoError(t, err)
+	certUser, err := ca.IssueClientCert(User{Name: "user-2"})
+	require.NoError(t, err)
+
+	args, err := Users{{Name: "user-1", Token: "user-1-token"}}.ArgsForKCP(t)
+	require.NoError(t, err)
+	require.Len(t, args, 2)
+	require.Equal(t, "--token-auth-file", args[0])
+
+	args, err = Users{{Name: "user-1", Token: "user-1-token"}, certUser, certUser}.ArgsForKCP(t)
+	require.NoError(t, err)
+	require.Len(t, args, 4)
+	require.Equal(t, "--client-ca-file", args[2])
+	bundle, err := os.ReadFile(args[3])
+	require.NoError(t, err)
+	require.Equal(t, ca.CertData, bundle, "expected the CA once in the bundle")
+
+	tokens, err := os.ReadFile(args[1])
+	require.NoError(t, err)
+	require.Equal(t, "user-1-token,user-1,,\"\"\n", string(tokens), "expected only users with a token in the token file")
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
@@ -51,6 +52,7 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
 
@@ -300,6 +302,34 @@ func CreateClusterAndWait(t *testing.T, ctx context.Context, artifacts ArtifactF
 	return cluster, nil
 }
 
+// ExpectClusterWorkspaceCondition waits until the condition of the given type of the ClusterWorkspace with the given
+// name has the given status, and returns the ClusterWorkspace. The test fails with the current conditions of the
+// ClusterWorkspace if the condition does not reach the status in time.
+func ExpectClusterWorkspaceCondition(ctx context.Context, t *testing.T, client kcpclientset.Interface, name string, conditionType conditionsv1alpha1.ConditionType, status corev1.ConditionStatus) *tenancyv1alpha1.ClusterWorkspace {
+	var workspace *tenancyv1alpha1.ClusterWorkspace
+	var lastErr error
+	err := wait.PollImmediateWithContext(ctx, time.Millisecond*100, wait.ForeverTestTimeout, func(ctx context.Context) (done bool, err error) {
+		workspace, lastErr = client.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, name, metav1.GetOptions{})
+		if lastErr != nil {
+			return false, nil
+		}
+		condition := conditions.Get(workspace, conditionType)
+		return condition != nil && condition.Status == status, nil
+	})
+	if err == nil {
+		return workspace
+	}
+	if lastErr != nil {
+		require.NoError(t, err, "ClusterWorkspace %s did not get condition %s=%s: %v", name, conditionType, status, lastErr)
+	}
+	current := make([]string, 0, len(workspace.Status.Conditions))
+	for _, condition := range workspace.Status.Conditions {
+		current = append(current, fmt.Sprintf("%s=%s (%s: %s)", condition.Type, condition.Status, condition.Reason, condition.Message))
+	}
+	require.NoError(t, err, "ClusterWorkspace %s did not get condition %s=%s, current conditions: [%s]", name, conditionType, status, strings.Join(current, ", "))
+	return nil
+}
+
 func RequireDiff(t *testing.T, x, y interface{}, msgAndArgs ...interface{}) {
 	diff := cmp.Diff(x, y)
 	require.NotEmpty(t, diff, msgAndArgs...)
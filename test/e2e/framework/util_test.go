@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestExpectClusterWorkspaceCondition(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	workspace := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "steve"},
+		Status:     tenancyv1alpha1.ClusterWorkspaceStatus{BaseURL: "https://boston.kcp.dev/clusters/org:steve"},
+	}
+	conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceShardValid, tenancyv1alpha1.WorkspaceShardValidReasonUnreachable, conditionsv1alpha1.ConditionSeverityError, "WorkspaceShard is unreachable.")
+	client := kcpfake.NewSimpleClientset(workspace.DeepCopy())
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		valid := workspace.DeepCopy()
+		conditions.MarkTrue(valid, tenancyv1alpha1.WorkspaceShardValid)
+		_, _ = client.TenancyV1alpha1().ClusterWorkspaces().UpdateStatus(ctx, valid, metav1.UpdateOptions{})
+	}()
+
+	valid := ExpectClusterWorkspaceCondition(ctx, t, client, "steve", tenancyv1alpha1.WorkspaceShardValid, corev1.ConditionTrue)
+	require.True(t, conditions.IsTrue(valid, tenancyv1alpha1.WorkspaceShardValid))
+	require.Equal(t, "https://boston.kcp.dev/clusters/org:steve", valid.Status.BaseURL)
+}
@@ -175,7 +175,7 @@ func TestClusterController(t *testing.T) {
 			source, sink := f.Servers[sourceClusterName], f.Servers[sinkClusterName]
 
 			t.Log("Creating a workspace")
-			wsClusterName := framework.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
+			wsClusterName, _ := framework.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
 
 			// clients
 			sourceConfig, err := source.DefaultConfig()
@@ -160,7 +160,7 @@ func TestIngressController(t *testing.T) {
 			require.Equal(t, 2, len(f.Servers), "incorrect number of servers")
 
 			orgClusterName := framework.NewOrganizationFixture(t, source)
-			clusterName := framework.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
+			clusterName, _ := framework.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
 
 			// clients
 			sourceConfig, err := source.DefaultConfig()
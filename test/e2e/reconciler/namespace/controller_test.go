@@ -192,7 +192,7 @@ func TestNamespaceScheduler(t *testing.T) {
 			require.NoError(t, err, "failed to construct client for server")
 
 			orgClusterName := framework.NewOrganizationFixture(t, server)
-			clusterName := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+			clusterName, _ := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
 			err = crds.Create(ctx, apiextensionClusterClient.Cluster(clusterName).ApiextensionsV1().CustomResourceDefinitions(),
 				metav1.GroupResource{Group: apiresource.GroupName, Resource: "apiresourceimports"},
 				metav1.GroupResource{Group: apiresource.GroupName, Resource: "negotiatedapiresources"},
@@ -20,16 +20,21 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	kubernetesclientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -169,6 +174,378 @@ func TestWorkspaceController(t *testing.T) {
 				require.NoError(t, err, "did not see WorkspaceShardValid condition to turn false")
 			},
 		},
+		{
+			name: "fill up the capacity of a shard, expect workspaces to be unschedulable until capacity is raised",
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				t.Logf("Set the capacity of the root shard to zero")
+				_, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Patch(ctx, "root", types.MergePatchType, []byte(`{"spec":{"capacity":0}}`), metav1.PatchOptions{})
+				require.NoError(t, err, "failed to set capacity of the root shard")
+
+				t.Logf("Create a workspace")
+				workspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "steve"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Expect workspace to be unschedulable because the shard is full")
+				err = server.orgExpect(workspace, func(current *tenancyv1alpha1.ClusterWorkspace) error {
+					if err := unschedulable(current); err != nil {
+						return err
+					}
+					if reason := utilconditions.GetReason(current, tenancyv1alpha1.WorkspaceShardValid); reason != tenancyv1alpha1.WorkspaceShardValidReasonNoCapacity {
+						return fmt.Errorf("expected WorkspaceShardValid reason %q, got %q", tenancyv1alpha1.WorkspaceShardValidReasonNoCapacity, reason)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace marked unschedulable")
+
+				t.Logf("Remove the capacity limit of the root shard")
+				rootShard, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Patch(ctx, "root", types.MergePatchType, []byte(`{"spec":{"capacity":null}}`), metav1.PatchOptions{})
+				require.NoError(t, err, "failed to remove capacity of the root shard")
+
+				t.Logf("Expect workspace to be scheduled to the root shard")
+				err = server.orgExpect(workspace, scheduled("root"))
+				require.NoError(t, err, "did not see workspace scheduled")
+
+				t.Logf("Expect the root shard to count the workspace")
+				err = server.rootExpectShard(rootShard, func(shard *tenancyv1alpha1.WorkspaceShard) error {
+					if shard.Status.CurrentWorkspaces < 2 {
+						return fmt.Errorf("expected at least the organization and the workspace on the root shard, got %d", shard.Status.CurrentWorkspaces)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspaces counted on the root shard")
+			},
+		},
+		{
+			name: "create a workspace with a shard selector, expect it to be scheduled only onto a matching shard",
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				t.Logf("Create a workspace selecting a shard that does not exist")
+				workspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{
+					Name:        "steve",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardSelectorAnnotation: "region=eu"},
+				}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Expect workspace to be unschedulable because no shard matches")
+				err = server.orgExpect(workspace, func(current *tenancyv1alpha1.ClusterWorkspace) error {
+					if err := unschedulable(current); err != nil {
+						return err
+					}
+					if reason := utilconditions.GetReason(current, tenancyv1alpha1.WorkspaceShardValid); reason != tenancyv1alpha1.WorkspaceShardValidReasonNoMatchingShard {
+						return fmt.Errorf("expected WorkspaceShardValid reason %q, got %q", tenancyv1alpha1.WorkspaceShardValidReasonNoMatchingShard, reason)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace marked unschedulable")
+
+				t.Logf("Label the root shard to match the selector")
+				_, err = server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Patch(ctx, "root", types.MergePatchType, []byte(`{"metadata":{"labels":{"region":"eu"}}}`), metav1.PatchOptions{})
+				require.NoError(t, err, "failed to label the root shard")
+
+				t.Logf("Expect workspace to be scheduled to the root shard")
+				err = server.orgExpect(workspace, scheduled("root"))
+				require.NoError(t, err, "did not see workspace scheduled")
+			},
+		},
+		{
+			name: "add an unreachable shard, expect it to be marked unreachable and new workspaces to avoid it",
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				t.Logf("Create a kubeconfig secret pointing to an address nothing listens on")
+				_, err := server.rootKubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "credentials"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create credentials namespace")
+				rawCfg := clientcmdapi.Config{
+					Clusters:       map[string]*clientcmdapi.Cluster{"cluster": {Server: "https://127.0.0.1:1"}},
+					Contexts:       map[string]*clientcmdapi.Context{"context": {Cluster: "cluster"}},
+					CurrentContext: "context",
+				}
+				rawBytes, err := clientcmd.Write(rawCfg)
+				require.NoError(t, err, "could not serialize raw config")
+				_, err = server.rootKubeClient.CoreV1().Secrets("credentials").Create(ctx, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig"},
+					Data: map[string][]byte{
+						"kubeconfig": rawBytes,
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create credentials secret")
+
+				t.Logf("Add an empty shard, pointing to the credentials/kubeconfig secret")
+				unreachableShard, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Create(ctx, &tenancyv1alpha1.WorkspaceShard{
+					ObjectMeta: metav1.ObjectMeta{Name: "unreachable"},
+					Spec: tenancyv1alpha1.WorkspaceShardSpec{Credentials: corev1.SecretReference{
+						Name:      "kubeconfig",
+						Namespace: "credentials",
+					}},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace shard")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Get(ctx, unreachableShard.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Expect the shard to be marked unreachable")
+				err = server.rootExpectShard(unreachableShard, func(shard *tenancyv1alpha1.WorkspaceShard) error {
+					if !utilconditions.IsFalse(shard, tenancyv1alpha1.ShardReachable) {
+						return fmt.Errorf("expected an unreachable shard, got status.conditions: %#v", shard.Status.Conditions)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the shard marked unreachable")
+
+				t.Logf("Expect the root shard to be reachable")
+				rootShard, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Get(ctx, "root", metav1.GetOptions{})
+				require.NoError(t, err, "failed to get the root shard")
+				err = server.rootExpectShard(rootShard, func(shard *tenancyv1alpha1.WorkspaceShard) error {
+					if !utilconditions.IsTrue(shard, tenancyv1alpha1.ShardReachable) {
+						return fmt.Errorf("expected a reachable shard, got status.conditions: %#v", shard.Status.Conditions)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the root shard marked reachable")
+
+				t.Logf("Create a workspace, expect it to be scheduled to the root shard although the unreachable shard is empty")
+				workspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "steve"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+				})
+				err = server.orgExpect(workspace, scheduled("root"))
+				require.NoError(t, err, "did not see workspace scheduled")
+			},
+		},
+		{
+			name: "delete an organization containing a workspace, expect the whole tree to be removed",
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				t.Logf("Create a workspace inside of the organization")
+				workspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "steve"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace")
+
+				t.Logf("Expect the cleanup finalizer on the workspace")
+				err = server.orgExpect(workspace, func(current *tenancyv1alpha1.ClusterWorkspace) error {
+					for _, f := range current.Finalizers {
+						if f == tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer {
+							return nil
+						}
+					}
+					return fmt.Errorf("expected finalizer %q, got %v", tenancyv1alpha1.ClusterWorkspaceCleanupFinalizer, current.Finalizers)
+				})
+				require.NoError(t, err, "did not see the cleanup finalizer on the workspace")
+
+				t.Logf("Delete the organization")
+				_, orgName, err := helper.ParseLogicalClusterName(workspace.ClusterName)
+				require.NoError(t, err, "failed to parse logical cluster name of workspace")
+				err = server.rootKcpClient.TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, orgName, metav1.DeleteOptions{})
+				require.NoError(t, err, "failed to delete organization")
+
+				t.Logf("Expect the organization and the workspace to disappear")
+				require.Eventually(t, func() bool {
+					_, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+					if !apierrors.IsNotFound(err) {
+						klog.Infof("Workspace %s not deleted yet: %v", workspace.Name, err)
+						return false
+					}
+					_, err = server.rootKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, orgName, metav1.GetOptions{})
+					if !apierrors.IsNotFound(err) {
+						klog.Infof("Organization %s not deleted yet: %v", orgName, err)
+						return false
+					}
+					return true
+				}, wait.ForeverTestTimeout, time.Millisecond*100, "did not see the workspace tree removed")
+			},
+		},
+		{
+			name: "drain a shard, expect its workspaces to be moved to another shard",
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				t.Logf("Add a second shard using the credentials of the root shard")
+				rootShard, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Get(ctx, "root", metav1.GetOptions{})
+				require.NoError(t, err, "failed to get the root shard")
+				bostonShard, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Create(ctx, &tenancyv1alpha1.WorkspaceShard{
+					ObjectMeta: metav1.ObjectMeta{Name: "boston"},
+					Spec:       tenancyv1alpha1.WorkspaceShardSpec{Credentials: rootShard.Spec.Credentials},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace shard")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Get(ctx, bostonShard.Name, metav1.GetOptions{})
+				})
+				err = server.rootExpectShard(bostonShard, func(shard *tenancyv1alpha1.WorkspaceShard) error {
+					if !utilconditions.IsTrue(shard, tenancyv1alpha1.WorkspaceShardCredentialsValid) || !utilconditions.IsTrue(shard, tenancyv1alpha1.ShardReachable) {
+						return fmt.Errorf("expected a valid and reachable shard, got status.conditions: %#v", shard.Status.Conditions)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the second shard become valid")
+
+				t.Logf("Create workspaces and expect them to become ready")
+				var workspaces []*tenancyv1alpha1.ClusterWorkspace
+				for _, name := range []string{"steve", "bob", "alice"} {
+					workspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{})
+					require.NoError(t, err, "failed to create workspace %s", name)
+					server.Artifact(t, func() (runtime.Object, error) {
+						return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+					})
+					err = server.orgExpect(workspace, func(workspace *tenancyv1alpha1.ClusterWorkspace) error {
+						if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+							return fmt.Errorf("expected workspace to be ready, got phase %q", workspace.Status.Phase)
+						}
+						return nil
+					})
+					require.NoError(t, err, "did not see workspace %s become ready", name)
+					workspaces = append(workspaces, workspace)
+				}
+
+				t.Logf("Drain the root shard")
+				_, err = server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Patch(ctx, "root", types.MergePatchType, []byte(`{"spec":{"draining":true}}`), metav1.PatchOptions{})
+				require.NoError(t, err, "failed to drain the root shard")
+
+				t.Logf("Expect all workspaces to be moved to the second shard")
+				for _, workspace := range workspaces {
+					err = server.orgExpect(workspace, func(workspace *tenancyv1alpha1.ClusterWorkspace) error {
+						if err := scheduled(bostonShard.Name)(workspace); err != nil {
+							return err
+						}
+						if workspace.Status.Location.Target != "" {
+							return fmt.Errorf("expected workspace.status.location.target to be empty, got %q", workspace.Status.Location.Target)
+						}
+						if condition := utilconditions.Get(workspace, tenancyv1alpha1.WorkspaceMigrating); condition != nil {
+							return fmt.Errorf("expected no WorkspaceMigrating condition after the move, got %#v", condition)
+						}
+						if workspace.Status.BaseURL == "" {
+							return fmt.Errorf("expected workspace.status.baseURL to be set")
+						}
+						return nil
+					})
+					require.NoError(t, err, "did not see workspace %s moved", workspace.Name)
+				}
+
+				t.Logf("Expect the root shard to be empty")
+				err = server.rootExpectShard(rootShard, func(shard *tenancyv1alpha1.WorkspaceShard) error {
+					if shard.Status.CurrentWorkspaces != 0 {
+						return fmt.Errorf("expected no workspaces on the draining shard, got %d", shard.Status.CurrentWorkspaces)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the root shard emptied")
+
+				t.Logf("Expect new workspaces to avoid the draining shard")
+				workspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "eve"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace")
+				err = server.orgExpect(workspace, scheduled(bostonShard.Name))
+				require.NoError(t, err, "did not see workspace scheduled onto the second shard")
+			},
+		},
+		{
+			name: "delete a shard with ready workspaces, expect them to be rescheduled onto another shard",
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				t.Logf("Create a workspace and expect it to become ready on the root shard")
+				workspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "steve"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+				})
+				err = server.orgExpect(workspace, func(workspace *tenancyv1alpha1.ClusterWorkspace) error {
+					if err := scheduled("root")(workspace); err != nil {
+						return err
+					}
+					if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+						return fmt.Errorf("expected workspace to be ready, got phase %q", workspace.Status.Phase)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace become ready on the root shard")
+
+				t.Logf("Add a second shard using the credentials of the root shard")
+				rootShard, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Get(ctx, "root", metav1.GetOptions{})
+				require.NoError(t, err, "failed to get the root shard")
+				bostonShard, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Create(ctx, &tenancyv1alpha1.WorkspaceShard{
+					ObjectMeta: metav1.ObjectMeta{Name: "boston"},
+					Spec:       tenancyv1alpha1.WorkspaceShardSpec{Credentials: rootShard.Spec.Credentials},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace shard")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Get(ctx, bostonShard.Name, metav1.GetOptions{})
+				})
+				err = server.rootExpectShard(bostonShard, func(shard *tenancyv1alpha1.WorkspaceShard) error {
+					if !utilconditions.IsTrue(shard, tenancyv1alpha1.WorkspaceShardCredentialsValid) || !utilconditions.IsTrue(shard, tenancyv1alpha1.ShardReachable) {
+						return fmt.Errorf("expected a valid and reachable shard, got status.conditions: %#v", shard.Status.Conditions)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the second shard become valid")
+
+				t.Logf("Delete the root shard")
+				err = server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().Delete(ctx, rootShard.Name, metav1.DeleteOptions{})
+				require.NoError(t, err, "failed to delete workspace shard")
+
+				t.Logf("Expect the workspace to be rescheduled onto the second shard")
+				err = server.orgExpect(workspace, func(workspace *tenancyv1alpha1.ClusterWorkspace) error {
+					if err := scheduled(bostonShard.Name)(workspace); err != nil {
+						return err
+					}
+					if !utilconditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceShardValid) {
+						return fmt.Errorf("expected a valid shard, got status.conditions: %#v", workspace.Status.Conditions)
+					}
+					if workspace.Status.BaseURL == "" {
+						return fmt.Errorf("expected workspace.status.baseURL to be set")
+					}
+					if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+						return fmt.Errorf("expected workspace to stay ready, got phase %q", workspace.Status.Phase)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace rescheduled onto the second shard")
+			},
+		},
+		{
+			name: "update spec and status of a workspace separately, expect each update to preserve the other",
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				t.Logf("Create a workspace")
+				workspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "steve"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Expect workspace to be scheduled")
+				err = server.orgExpect(workspace, scheduledAnywhere)
+				require.NoError(t, err, "did not see workspace scheduled")
+
+				t.Logf("Update the spec and try to change the status in the same request")
+				var updated *tenancyv1alpha1.ClusterWorkspace
+				var baseURL string
+				err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					current, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					baseURL = current.Status.BaseURL
+					current.Spec.ReadOnly = true
+					current.Status.BaseURL = "https://elsewhere.kcp.dev/clusters/steve"
+					updated, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Update(ctx, current, metav1.UpdateOptions{})
+					return err
+				})
+				require.NoError(t, err, "failed to update workspace")
+				require.True(t, updated.Spec.ReadOnly, "expected the spec to be updated")
+				require.Equal(t, baseURL, updated.Status.BaseURL, "expected the status to be preserved by a spec update")
+
+				t.Logf("Update the status and try to change the spec in the same request")
+				err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					current, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace.Name, metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					current.Spec.ReadOnly = false
+					utilconditions.MarkTrue(current, "E2EStatusUpdated")
+					updated, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().UpdateStatus(ctx, current, metav1.UpdateOptions{})
+					return err
+				})
+				require.NoError(t, err, "failed to update workspace status")
+				require.True(t, utilconditions.IsTrue(updated, "E2EStatusUpdated"), "expected the status to be updated")
+				require.True(t, updated.Spec.ReadOnly, "expected the spec to be preserved by a status update")
+			},
+		},
 	}
 
 	for i := range testCases {
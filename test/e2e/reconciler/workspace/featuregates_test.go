@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesclientset "k8s.io/client-go/kubernetes"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+	utilconditions "github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestWorkspaceFeatureGates(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if deadline, ok := t.Deadline(); ok {
+		withDeadline, cancel := context.WithDeadline(ctx, deadline)
+		t.Cleanup(cancel)
+		ctx = withDeadline
+	}
+
+	server := framework.SharedKcpServer(t)
+	cfg, err := server.DefaultConfig()
+	require.NoError(t, err)
+
+	kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to construct client for server")
+	kubeClusterClient, err := kubernetesclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to construct kube client for server")
+
+	orgClusterName := framework.NewOrganizationFixture(t, server)
+	_, orgName, err := helper.ParseLogicalClusterName(orgClusterName)
+	require.NoError(t, err)
+	workspaces := kcpClusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces()
+
+	t.Logf("Create a workspace with an unknown feature gate, expect it to be rejected")
+	_, err = workspaces.Create(ctx, &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "teleporting"},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{FeatureGates: map[string]bool{"Teleportation": true}},
+	}, metav1.CreateOptions{})
+	require.True(t, apierrors.IsForbidden(err), "expected an unknown feature gate to be forbidden, got %v", err)
+
+	t.Logf("Create a workspace with a known feature gate, expect it to be propagated once ready")
+	workspace, err := workspaces.Create(ctx, &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "gated"},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{FeatureGates: map[string]bool{"ServerSideApply": false}},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err, "failed to create workspace")
+	t.Cleanup(func() {
+		_ = workspaces.Delete(context.Background(), workspace.Name, metav1.DeleteOptions{})
+	})
+	expectFeatureGates := func(expected map[string]bool) *tenancyv1alpha1.ClusterWorkspace {
+		var current *tenancyv1alpha1.ClusterWorkspace
+		err := framework.PollWithBackoff(ctx, func(ctx context.Context) error {
+			var err error
+			if current, err = workspaces.Get(ctx, workspace.Name, metav1.GetOptions{}); err != nil {
+				return err
+			}
+			if current.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+				return fmt.Errorf("workspace is %q", current.Status.Phase)
+			}
+			if !equality.Semantic.DeepEqual(expected, current.Status.FeatureGates) || utilconditions.Has(current, tenancyv1alpha1.WorkspaceFeaturesChanging) {
+				return fmt.Errorf("feature gates %v not propagated yet, got status.featureGates %v", expected, current.Status.FeatureGates)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return current
+	}
+	workspace = expectFeatureGates(map[string]bool{"ServerSideApply": false})
+
+	clusterName := helper.EncodeOrganizationAndClusterWorkspace(orgName, workspace.Name)
+	configMap, err := kubeClusterClient.Cluster(clusterName).CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(ctx, "workspace-feature-gates", metav1.GetOptions{})
+	require.NoError(t, err, "failed to get the feature gates of workspace %s", clusterName)
+	require.Equal(t, map[string]string{"ServerSideApply": "false"}, configMap.Data)
+
+	t.Logf("Enable the feature gate, expect the change to be propagated")
+	workspace.Spec.FeatureGates = map[string]bool{"ServerSideApply": true}
+	_, err = workspaces.Update(ctx, workspace, metav1.UpdateOptions{})
+	require.NoError(t, err, "failed to update workspace")
+	expectFeatureGates(map[string]bool{"ServerSideApply": true})
+}
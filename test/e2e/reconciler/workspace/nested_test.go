@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestNestedWorkspaces(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if deadline, ok := t.Deadline(); ok {
+		withDeadline, cancel := context.WithDeadline(ctx, deadline)
+		t.Cleanup(cancel)
+		ctx = withDeadline
+	}
+
+	const serverName = "main"
+	f := framework.NewKcpFixture(t,
+		framework.KcpConfig{
+			Name: serverName,
+		},
+	)
+	require.Equal(t, 1, len(f.Servers), "incorrect number of servers")
+	server := f.Servers[serverName]
+	cfg, err := server.DefaultConfig()
+	require.NoError(t, err)
+
+	kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to construct client for server")
+
+	orgClusterName := framework.NewOrganizationFixture(t, server)
+
+	t.Logf("Create a workspace in the org, and a child workspace in that workspace")
+	parentClusterName, deleteParent := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+	framework.NewWorkspaceFixture(t, server, parentClusterName, "Universal")
+
+	children, err := kcpClusterClient.Cluster(parentClusterName).TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err, "failed to list the workspaces in %s", parentClusterName)
+	require.Len(t, children.Items, 1, "expected a single child workspace in %s", parentClusterName)
+	require.Equal(t, tenancyv1alpha1.ClusterWorkspacePhaseReady, children.Items[0].Status.Phase)
+
+	t.Logf("Delete the parent workspace, expect the child workspace to be deleted too")
+	deleteParent()
+	err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+		children, err := kcpClusterClient.Cluster(parentClusterName).TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		if len(children.Items) != 0 {
+			t.Logf("Waiting for %d child workspaces to be deleted", len(children.Items))
+			return false, nil
+		}
+		return true, nil
+	})
+	require.NoError(t, err, "did not see the child workspace deleted")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesclientset "k8s.io/client-go/kubernetes"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+	utilconditions "github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestWorkspaceDefaultQuota(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if deadline, ok := t.Deadline(); ok {
+		withDeadline, cancel := context.WithDeadline(ctx, deadline)
+		t.Cleanup(cancel)
+		ctx = withDeadline
+	}
+
+	const serverName = "main"
+	f := framework.NewKcpFixture(t,
+		framework.KcpConfig{
+			Name: serverName,
+			Args: []string{
+				"--default-workspace-quota=pods=10,configmaps=20",
+			},
+		},
+	)
+	require.Equal(t, 1, len(f.Servers), "incorrect number of servers")
+	server := f.Servers[serverName]
+	cfg, err := server.DefaultConfig()
+	require.NoError(t, err)
+
+	kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to construct client for server")
+	kubeClusterClient, err := kubernetesclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to construct kube client for server")
+
+	orgClusterName := framework.NewOrganizationFixture(t, server)
+
+	t.Logf("Create a workspace, expect the default quota in its default namespace once it is ready")
+	clusterName, _ := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+
+	quota, err := kubeClusterClient.Cluster(clusterName).CoreV1().ResourceQuotas(metav1.NamespaceDefault).Get(ctx, "default", metav1.GetOptions{})
+	require.NoError(t, err, "failed to get the default quota of workspace %s", clusterName)
+	require.Equal(t, corev1.ResourceList{
+		corev1.ResourcePods:       resource.MustParse("10"),
+		corev1.ResourceConfigMaps: resource.MustParse("20"),
+	}, quota.Spec.Hard)
+
+	_, workspaceName, err := helper.ParseLogicalClusterName(clusterName)
+	require.NoError(t, err)
+	workspace, err := kcpClusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspaceName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, utilconditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceQuotaBootstrapped), "expected the quota to be reported as bootstrapped, got status.conditions: %#v", workspace.Status.Conditions)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestWorkspaceSchedulingAcrossShards(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if deadline, ok := t.Deadline(); ok {
+		withDeadline, cancel := context.WithDeadline(ctx, deadline)
+		t.Cleanup(cancel)
+		ctx = withDeadline
+	}
+
+	const serverName, shardName = "main", "second"
+	f := framework.NewKcpFixture(t,
+		framework.KcpConfig{
+			Name: serverName,
+		},
+		framework.KcpConfig{
+			Name:    shardName,
+			ShardOf: serverName,
+		},
+	)
+	require.Equal(t, 2, len(f.Servers), "incorrect number of servers")
+	server := f.Servers[serverName]
+	cfg, err := server.DefaultConfig()
+	require.NoError(t, err)
+
+	kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err, "failed to construct client for server")
+
+	orgClusterName := framework.NewOrganizationFixture(t, server)
+	orgKcpClient := kcpClusterClient.Cluster(orgClusterName)
+	orgExpect, err := framework.ExpectClusterWorkspaces(ctx, t, orgKcpClient)
+	require.NoError(t, err, "failed to start expecter")
+
+	t.Logf("Create workspaces, expect them to be spread over the root shard and the %s shard", shardName)
+	shards := sets.NewString()
+	for i := 0; i < 8; i++ {
+		workspace, err := orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("steve-%d", i)}}, metav1.CreateOptions{})
+		require.NoError(t, err, "failed to create workspace")
+		err = orgExpect(workspace, func(current *tenancyv1alpha1.ClusterWorkspace) error {
+			if err := scheduledAnywhere(current); err != nil {
+				return err
+			}
+			shards.Insert(current.Status.Location.Current)
+			return nil
+		})
+		require.NoError(t, err, "did not see workspace %s scheduled", workspace.Name)
+	}
+	require.Equal(t, []string{"root", shardName}, shards.List(), "expected workspaces on both shards")
+}
@@ -56,6 +56,10 @@ func (o *CompositeSubCommandOptions) AddFlags(flags *pflag.FlagSet) {
 	}
 }
 
+func (o *CompositeSubCommandOptions) Complete() error {
+	return nil
+}
+
 func (o *CompositeSubCommandOptions) Validate() []error {
 	if o == nil {
 		return nil
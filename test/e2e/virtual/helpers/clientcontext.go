@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	virtualworkspacesregistry "github.com/kcp-dev/kcp/pkg/virtual/workspaces/registry"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+// ClientContextBuilder builds the VirtualWorkspaceClientContext of a user accessing the workspaces
+// virtual workspace of an organization, e.g.
+//
+//	NewClientContext(user).WithOrg("root:default").WithType("personal").Build()
+type ClientContextBuilder struct {
+	user  framework.User
+	org   string
+	scope string
+}
+
+// NewClientContext returns a ClientContextBuilder for the given user.
+func NewClientContext(user framework.User) *ClientContextBuilder {
+	return &ClientContextBuilder{user: user}
+}
+
+// WithOrg sets the logical cluster name of the organization, i.e. root or root:<org>.
+func (b *ClientContextBuilder) WithOrg(org string) *ClientContextBuilder {
+	b.org = org
+	return b
+}
+
+// WithType sets the scope of the workspaces virtual workspace, one of personal, shared or all.
+func (b *ClientContextBuilder) WithType(scope string) *ClientContextBuilder {
+	b.scope = scope
+	return b
+}
+
+// Build returns the client context with the prefix /<org>/<type>, or an error if the user, the organization
+// or the type is missing or invalid.
+func (b *ClientContextBuilder) Build() (VirtualWorkspaceClientContext, error) {
+	if b.user.Name == "" {
+		return VirtualWorkspaceClientContext{}, fmt.Errorf("a user name is required")
+	}
+	if err := validateOrg(b.org); err != nil {
+		return VirtualWorkspaceClientContext{}, err
+	}
+	if b.scope == "" {
+		return VirtualWorkspaceClientContext{}, fmt.Errorf("a type is required, one of %s", strings.Join(virtualworkspacesregistry.ScopeSet.List(), ", "))
+	}
+	if !virtualworkspacesregistry.ScopeSet.Has(b.scope) {
+		return VirtualWorkspaceClientContext{}, fmt.Errorf("invalid type %q, must be one of %s", b.scope, strings.Join(virtualworkspacesregistry.ScopeSet.List(), ", "))
+	}
+	return VirtualWorkspaceClientContext{
+		User:   b.user,
+		Prefix: "/" + b.org + "/" + b.scope,
+	}, nil
+}
+
+// validateOrg returns an error unless org is the root logical cluster or an organization below it.
+func validateOrg(org string) error {
+	if org == "" {
+		return fmt.Errorf("an organization is required")
+	}
+	if org == helper.RootCluster {
+		return nil
+	}
+	parent, name, err := helper.ParseLogicalClusterName(org)
+	if err != nil {
+		return fmt.Errorf("invalid organization %q: %w", org, err)
+	}
+	if parent != helper.RootCluster || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid organization %q, must be %s or %s:<name>", org, helper.RootCluster, helper.RootCluster)
+	}
+	return nil
+}
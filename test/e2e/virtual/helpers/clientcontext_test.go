@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+)
+
+func TestClientContextBuilder(t *testing.T) {
+	user := framework.User{Name: "user-1", Token: "user-1-token"}
+
+	tests := []struct {
+		name           string
+		builder        *ClientContextBuilder
+		expectedPrefix string
+		wantErr        bool
+	}{
+		{
+			name:           "personal workspaces of an organization",
+			builder:        NewClientContext(user).WithOrg("root:default").WithType("personal"),
+			expectedPrefix: "/root:default/personal",
+		},
+		{
+			name:           "shared workspaces of an organization",
+			builder:        NewClientContext(user).WithOrg("root:default").WithType("shared"),
+			expectedPrefix: "/root:default/shared",
+		},
+		{
+			name:           "all organizations",
+			builder:        NewClientContext(user).WithOrg("root").WithType("all"),
+			expectedPrefix: "/root/all",
+		},
+		{
+			name:    "missing user",
+			builder: NewClientContext(framework.User{}).WithOrg("root:default").WithType("personal"),
+			wantErr: true,
+		},
+		{
+			name:    "missing organization",
+			builder: NewClientContext(user).WithType("personal"),
+			wantErr: true,
+		},
+		{
+			name:    "missing type",
+			builder: NewClientContext(user).WithOrg("root:default"),
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			builder: NewClientContext(user).WithOrg("root:default").WithType("private"),
+			wantErr: true,
+		},
+		{
+			name:    "organization not below root",
+			builder: NewClientContext(user).WithOrg("default").WithType("personal"),
+			wantErr: true,
+		},
+		{
+			name:    "nested organization",
+			builder: NewClientContext(user).WithOrg("root:default:team").WithType("personal"),
+			wantErr: true,
+		},
+		{
+			name:    "organization with a slash",
+			builder: NewClientContext(user).WithOrg("root:default/personal").WithType("personal"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientContext, err := tt.builder.Build()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedPrefix, clientContext.Prefix)
+			require.Equal(t, user, clientContext.User)
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
 
 	virtualcmd "github.com/kcp-dev/kcp/pkg/virtual/framework/cmd"
+	workspacescmd "github.com/kcp-dev/kcp/pkg/virtual/workspaces/cmd"
 	"github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest/client/clientset/versioned/scheme"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 )
@@ -46,6 +48,9 @@ type VirtualWorkspaceClientContext struct {
 type VirtualWorkspace struct {
 	BuildSubCommandOptions func(kcpServer framework.RunningServer) virtualcmd.SubCommandOptions
 	ClientContexts         []VirtualWorkspaceClientContext
+	// GroupOrgs maps group names to the names of the orgs their members see through client contexts listing
+	// workspaces across all orgs. Only supported by the workspaces virtual workspace.
+	GroupOrgs map[string][]string
 }
 
 func (vw VirtualWorkspace) Setup(t *testing.T, ctx context.Context, kcpServer framework.RunningServer) ([]*rest.Config, error) {
@@ -72,11 +77,34 @@ func (vw VirtualWorkspace) Setup(t *testing.T, ctx context.Context, kcpServer fr
 	authenticationOptions := options.NewDelegatingAuthenticationOptions()
 	authenticationOptions.RemoteKubeConfigFile = kcpKubeconfigPath
 	authenticationOptions.SkipInClusterLookup = true
+	users := make(framework.Users, 0, len(vw.ClientContexts))
+	for _, vwClientContext := range vw.ClientContexts {
+		users = append(users, vwClientContext.User)
+	}
+	if bundle := users.ClientCABundle(); len(bundle) > 0 {
+		// client certificates are verified by the virtual workspace itself, tokens are reviewed by kcp
+		clientCAFile := filepath.Join(t.TempDir(), "client-ca.crt")
+		if err := os.WriteFile(clientCAFile, bundle, 0600); err != nil {
+			return nil, err
+		}
+		authenticationOptions.ClientCert.ClientCA = clientCAFile
+	}
+	subCommandOptions := vw.BuildSubCommandOptions(kcpServer)
+	if len(vw.GroupOrgs) > 0 {
+		workspacesOptions, ok := subCommandOptions.(*workspacescmd.WorkspacesSubCommandOptions)
+		if !ok {
+			return nil, fmt.Errorf("group orgs are only supported by the workspaces virtual workspace, got %T", subCommandOptions)
+		}
+		workspacesOptions.GroupOrgs = groupOrgsFlagValue(vw.GroupOrgs)
+	}
 	vwOptions := virtualcmd.APIServerOptions{
 		Output:            os.Stdout,
 		SecureServing:     secureOptions,
 		Authentication:    authenticationOptions,
-		SubCommandOptions: vw.BuildSubCommandOptions(kcpServer),
+		SubCommandOptions: subCommandOptions,
+	}
+	if err := vwOptions.Complete(); err != nil {
+		return nil, err
 	}
 
 	virtualWorkspaceContext, stopVirtualWorkspace := context.WithCancel(ctx)
@@ -140,12 +168,24 @@ func (vw VirtualWorkspace) Setup(t *testing.T, ctx context.Context, kcpServer fr
 	for _, vwClientContext := range vw.ClientContexts {
 		vwCfg := rest.CopyConfig(mainRestConfig)
 		vwCfg.Host = "https://" + vwCfg.Host + vwClientContext.Prefix
-		if authInfo, exists := kcpRawConfig.AuthInfos[vwClientContext.User.Name]; exists && vwClientContext.User.Token == "" {
+		if authInfo, exists := kcpRawConfig.AuthInfos[vwClientContext.User.Name]; exists && vwClientContext.User.Token == "" && len(vwClientContext.User.ClientCertificateData) == 0 {
 			vwCfg.BearerToken = authInfo.Token
 		} else {
-			vwCfg.BearerToken = vwClientContext.User.Token
+			authInfo := vwClientContext.User.AuthInfo()
+			vwCfg.BearerToken = authInfo.Token
+			vwCfg.CertData = authInfo.ClientCertificateData
+			vwCfg.KeyData = authInfo.ClientKeyData
 		}
 		virtualWorkspaceConfigs = append(virtualWorkspaceConfigs, vwCfg)
 	}
 	return virtualWorkspaceConfigs, nil
 }
+
+// groupOrgsFlagValue returns the value of --workspaces:group-orgs mapping the groups to the given orgs.
+func groupOrgsFlagValue(groupOrgs map[string][]string) map[string]string {
+	value := make(map[string]string, len(groupOrgs))
+	for group, orgs := range groupOrgs {
+		value[group] = strings.Join(orgs, ";")
+	}
+	return value
+}
@@ -17,29 +17,58 @@ limitations under the License.
 package workspaces
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net/http"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	kuser "k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/client-go/util/retry"
 
+	configcrds "github.com/kcp-dev/kcp/config/crds"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 	clientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpscheme "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/scheme"
+	virtualframework "github.com/kcp-dev/kcp/pkg/virtual/framework"
 	virtualcmd "github.com/kcp-dev/kcp/pkg/virtual/framework/cmd"
+	shardsbuilder "github.com/kcp-dev/kcp/pkg/virtual/shards/builder"
 	workspacescmd "github.com/kcp-dev/kcp/pkg/virtual/workspaces/cmd"
+	workspaceinformers "github.com/kcp-dev/kcp/pkg/virtual/workspaces/informers"
+	virtualworkspacesregistry "github.com/kcp-dev/kcp/pkg/virtual/workspaces/registry"
+	fixturewildwest "github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest"
+	"github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest/apis/wildwest"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 	"github.com/kcp-dev/kcp/test/e2e/virtual/helpers"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
@@ -47,6 +76,7 @@ import (
 
 type testDataType struct {
 	user1, user2, user3                                                      framework.User
+	team1Member                                                              framework.User
 	workspace1, workspace1Disambiguited, workspace2, workspace2Disambiguited *tenancyv1beta1.Workspace
 }
 
@@ -69,135 +99,2041 @@ var testData = testDataType{
 		Token:  "user-3-token",
 		Groups: []string{"team-3"},
 	},
+	team1Member: framework.User{
+		Name:   "user-5",
+		UID:    "5555-5555-5555-5555",
+		Token:  "user-5-token",
+		Groups: []string{"team-1"},
+	},
 	workspace1:              &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "workspace1"}},
 	workspace1Disambiguited: &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "workspace1--1"}},
 	workspace2:              &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "workspace2"}},
 	workspace2Disambiguited: &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "workspace2--1"}},
 }
 
-func TestWorkspacesVirtualWorkspaces(t *testing.T) {
-	t.Parallel()
+func TestWorkspacesVirtualWorkspaces(t *testing.T) {
+	t.Parallel()
+
+	clientCA, err := framework.NewClientCA()
+	require.NoError(t, err)
+	certUser, err := clientCA.IssueClientCert(framework.User{
+		Name:   "user-4",
+		Groups: []string{"team-4"},
+	})
+	require.NoError(t, err)
+
+	type runningServer struct {
+		framework.RunningServer
+		orgClusterName                 string
+		orgKubeClient                  kubernetes.Interface
+		crdClusterClient               apiextensionsclient.ClusterInterface
+		orgKcpClient, rootKcpClient    clientset.Interface
+		kcpClusterClient               clientset.ClusterInterface
+		kubeClusterClient              kubernetes.ClusterInterface
+		virtualWorkspaceClientContexts []helpers.VirtualWorkspaceClientContext
+		virtualWorkspaceConfigs        []*rest.Config
+		virtualWorkspaceClients        []clientset.Interface
+		virtualWorkspaceExpectations   []framework.RegisterWorkspaceListExpectation
+	}
+	var testCases = []struct {
+		name                           string
+		virtualWorkspaceClientContexts func(orgName string) []helpers.VirtualWorkspaceClientContext
+		// softDeleteRetention enables the soft-deletion of workspaces in the virtual workspace
+		softDeleteRetention time.Duration
+		// groupOrgs maps groups to the orgs their members see across all orgs
+		groupOrgs func(orgName string) map[string][]string
+		work      func(ctx context.Context, t *testing.T, server runningServer)
+	}{
+		{
+			name: "create a workspace in personal virtual workspace and have only its owner list it",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user2,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+				vwUser2Client := server.virtualWorkspaceClients[1]
+
+				t.Logf("Create Workspace workspace1 in the virtual workspace")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+
+				t.Logf("Verify that the Workspace results in a ClusterWorkspace of the same name in the org workspace")
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected to see workspace1 as ClusterWorkspace")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Create Workspace workspace2 in the virtual workspace")
+				workspace2, err := vwUser2Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+
+				t.Logf("Verify that the Workspace results in a ClusterWorkspace of the same name in the org workspace")
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace2.Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected to see workspace2 as ClusterWorkspace")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace2.Name, metav1.GetOptions{})
+				})
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspace created in personal virtual workspace")
+				err = server.virtualWorkspaceExpectations[1](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace2.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace2.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace2 created in personal virtual workspace")
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace and see its owner recorded and protected",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1, owned by team-1 too")
+				workspace1 := testData.workspace1.DeepCopy()
+				workspace1.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-1"}
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, workspace1, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Verify that the owner is recorded on the ClusterWorkspace")
+				cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected to see workspace1 as ClusterWorkspace")
+				require.Equal(t, testData.user1.Name, cw.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+				require.Equal(t, "team-1", cw.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation])
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					if err != nil {
+						return false, err
+					}
+					return cw.Status.Owner != nil && cw.Status.Owner.User == testData.user1.Name && sets.NewString(cw.Status.Owner.Groups...).Has("team-1"), nil
+				})
+				require.NoError(t, err, "did not see the owner of workspace1 in its status")
+
+				t.Logf("Verify that another user cannot clear the owner")
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, workspace1.Name, types.MergePatchType,
+					[]byte(`{"metadata":{"annotations":{"`+tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation+`":null}}}`), metav1.PatchOptions{})
+				require.True(t, apierrors.IsForbidden(err), "expected clearing the owner of workspace1 to be forbidden, got %v", err)
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, workspace1.Name, types.MergePatchType,
+					[]byte(`{"metadata":{"annotations":{"`+tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation+`":"`+testData.user2.Name+`"}}}`), metav1.PatchOptions{})
+				require.True(t, apierrors.IsForbidden(err), "expected changing the owner of workspace1 to be forbidden, got %v", err)
+
+				t.Logf("Verify that labeling the workspace through the virtual workspace preserves the owner")
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace1 created in personal virtual workspace")
+				err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					current, err := vwUser1Client.TenancyV1beta1().Workspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					current.Labels = map[string]string{"env": "prod"}
+					_, err = vwUser1Client.TenancyV1beta1().Workspaces().Update(ctx, current, metav1.UpdateOptions{})
+					return err
+				})
+				require.NoError(t, err, "failed to update workspace1")
+				cw, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, testData.user1.Name, cw.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+			},
+		},
+		{
+			name: "delete a workspace in personal virtual workspace as its owner only",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user2,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+				vwUser2Client := server.virtualWorkspaceClients[1]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace1 created in personal virtual workspace")
+
+				t.Logf("Verify that user-2 cannot delete workspace1, nor learn that it exists")
+				err = vwUser2Client.TenancyV1beta1().Workspaces().Delete(ctx, workspace1.Name, metav1.DeleteOptions{})
+				require.True(t, apierrors.IsNotFound(err), "expected workspace1 to be not found for user-2, got %v", err)
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected workspace1 to still exist as ClusterWorkspace")
+
+				t.Logf("Delete workspace1 as user-1")
+				background := metav1.DeletePropagationBackground
+				err = vwUser1Client.TenancyV1beta1().Workspaces().Delete(ctx, workspace1.Name, metav1.DeleteOptions{PropagationPolicy: &background})
+				require.NoError(t, err, "failed to delete workspace1")
+
+				t.Logf("Verify that the ClusterWorkspace is removed")
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					if apierrors.IsNotFound(err) {
+						return true, nil
+					}
+					return false, err
+				})
+				require.NoError(t, err, "did not see the ClusterWorkspace of workspace1 removed")
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 0 {
+						return fmt.Errorf("expected no workspace, got %#v", w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "still saw workspace1 in personal virtual workspace")
+			},
+		},
+		{
+			name: "patch the labels of a workspace in personal virtual workspace and see them on the ClusterWorkspace",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace1 created in personal virtual workspace")
+
+				t.Logf("Patch a label and an annotation of workspace1 with a JSON merge patch and a strategic merge patch")
+				_, err = vwUser1Client.TenancyV1beta1().Workspaces().Patch(ctx, workspace1.Name, types.MergePatchType,
+					[]byte(`{"metadata":{"labels":{"env":"prod"}}}`), metav1.PatchOptions{})
+				require.NoError(t, err, "failed to merge patch workspace1")
+				_, err = vwUser1Client.TenancyV1beta1().Workspaces().Patch(ctx, workspace1.Name, types.StrategicMergePatchType,
+					[]byte(`{"metadata":{"annotations":{"note":"hello"}}}`), metav1.PatchOptions{})
+				require.NoError(t, err, "failed to strategic merge patch workspace1")
+
+				cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, "prod", cw.Labels["env"])
+				require.Equal(t, "hello", cw.Annotations["note"])
+				require.Equal(t, testData.user1.Name, cw.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+
+				t.Logf("Verify that the type of workspace1 cannot be changed")
+				_, err = vwUser1Client.TenancyV1beta1().Workspaces().Patch(ctx, workspace1.Name, types.MergePatchType,
+					[]byte(`{"spec":{"type":"Organization"}}`), metav1.PatchOptions{})
+				require.True(t, apierrors.IsInvalid(err), "expected changing the type of workspace1 to be invalid, got %v", err)
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace in dry-run mode and see nothing persisted",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1 in dry-run mode")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+				require.NoError(t, err, "failed to create workspace1 in dry-run mode")
+				require.Equal(t, testData.workspace1.Name, workspace1.Name)
+				require.Equal(t, testData.workspace1.Name, workspace1.Annotations[virtualworkspacesregistry.InternalNameAnnotation])
+
+				t.Logf("Verify that no ClusterWorkspace has been created")
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				require.True(t, apierrors.IsNotFound(err), "expected no ClusterWorkspace for workspace1, got %v", err)
+
+				t.Logf("Create Workspace workspace1 as user-1 for real")
+				_, err = vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Verify that a dry-run creation of workspace1 conflicts now")
+				_, err = vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+				require.True(t, apierrors.IsAlreadyExists(err), "expected workspace1 to already exist, got %v", err)
+			},
+		},
+		{
+			name: "grant a user access to a workspace of another user and see it in their shared virtual workspace only",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user2,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user2,
+						Prefix: "/" + orgName + "/shared",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+				vwUser2PersonalClient := server.virtualWorkspaceClients[1]
+				vwUser2SharedClient := server.virtualWorkspaceClients[2]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace1 created in the personal virtual workspace of user-1")
+
+				t.Logf("Grant user-2 access to workspace1")
+				internalName := workspace1.Annotations[virtualworkspacesregistry.InternalNameAnnotation]
+				_, err = server.orgKubeClient.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{Name: "workspace1-viewer"},
+					Rules: []rbacv1.PolicyRule{
+						{
+							APIGroups:     []string{tenancyv1beta1.SchemeGroupVersion.Group},
+							Resources:     []string{"workspaces"},
+							ResourceNames: []string{internalName},
+							Verbs:         []string{"get"},
+						},
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create the cluster role giving access to workspace1")
+				_, err = server.orgKubeClient.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "workspace1-viewer-user-2"},
+					RoleRef: rbacv1.RoleRef{
+						APIGroup: rbacv1.GroupName,
+						Kind:     "ClusterRole",
+						Name:     "workspace1-viewer",
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							APIGroup: rbacv1.GroupName,
+							Kind:     rbacv1.UserKind,
+							Name:     testData.user2.Name,
+						},
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to bind user-2 to the cluster role giving access to workspace1")
+
+				err = server.virtualWorkspaceExpectations[2](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != internalName {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", internalName, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace1 in the shared virtual workspace of user-2")
+				_, err = vwUser2SharedClient.TenancyV1beta1().Workspaces().Get(ctx, internalName, metav1.GetOptions{})
+				require.NoError(t, err, "failed to get workspace1 in the shared virtual workspace of user-2")
+
+				t.Logf("Verify that user-2 doesn't see workspace1 in their personal virtual workspace")
+				list, err := vwUser2PersonalClient.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+				require.NoError(t, err, "failed to list the personal workspaces of user-2")
+				require.Empty(t, list.Items, "expected no workspace in the personal virtual workspace of user-2")
+				_, err = vwUser2PersonalClient.TenancyV1beta1().Workspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.True(t, apierrors.IsNotFound(err), "expected workspace1 not to be found in the personal virtual workspace of user-2, got %v", err)
+
+				t.Logf("Verify that user-1 still only sees workspace1 in their personal virtual workspace")
+				list, err = vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+				require.NoError(t, err, "failed to list the personal workspaces of user-1")
+				require.Len(t, list.Items, 1, "expected only workspace1 in the personal virtual workspace of user-1")
+			},
+		},
+		{
+			name: "create a workspace owned by a group and have the other members of the group list it in their personal virtual workspace",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.team1Member,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user2,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+				vwTeam1MemberClient := server.virtualWorkspaceClients[1]
+				vwUser2Client := server.virtualWorkspaceClients[2]
+
+				t.Logf("Verify that user-1 cannot make workspace1 owned by a group it is not a member of")
+				workspace1 := testData.workspace1.DeepCopy()
+				workspace1.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation: "team-2"}
+				_, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, workspace1, metav1.CreateOptions{})
+				require.True(t, apierrors.IsInvalid(err), "expected workspace1 owned by team-2 to be invalid, got %v", err)
+
+				t.Logf("Create Workspace workspace1 owned by team-1 as user-1")
+				workspace1.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation] = "team-1"
+				workspace1, err = vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, workspace1, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+				clusterWorkspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Annotations[virtualworkspacesregistry.InternalNameAnnotation], metav1.GetOptions{})
+				require.NoError(t, err, "failed to get the ClusterWorkspace of workspace1")
+				require.Equal(t, testData.user1.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation], "expected user-1 to own workspace1")
+				require.Equal(t, "team-1", clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerGroupsAnnotation], "expected team-1 to own workspace1")
+
+				for i, user := range []framework.User{testData.user1, testData.team1Member} {
+					err = server.virtualWorkspaceExpectations[i](func(w *tenancyv1beta1.WorkspaceList) error {
+						if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+							return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+						}
+						return nil
+					})
+					require.NoError(t, err, "did not see workspace1 in the personal virtual workspace of %s", user.Name)
+				}
+
+				t.Logf("Update the labels of workspace1 as %s", testData.team1Member.Name)
+				workspace, err := vwTeam1MemberClient.TenancyV1beta1().Workspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "failed to get workspace1 in the personal virtual workspace of %s", testData.team1Member.Name)
+				workspace.Labels = map[string]string{"team": "team-1"}
+				_, err = vwTeam1MemberClient.TenancyV1beta1().Workspaces().Update(ctx, workspace, metav1.UpdateOptions{})
+				require.NoError(t, err, "failed to update workspace1 as %s", testData.team1Member.Name)
+
+				t.Logf("Verify that user-2 doesn't see workspace1 in their personal virtual workspace")
+				list, err := vwUser2Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+				require.NoError(t, err, "failed to list the personal workspaces of user-2")
+				require.Empty(t, list.Items, "expected no workspace in the personal virtual workspace of user-2")
+			},
+		},
+		{
+			name: "transfer the ownership of a workspace in personal virtual workspace to another user",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user2,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+				vwUser2Client := server.virtualWorkspaceClients[1]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace1 created in the personal virtual workspace of user-1")
+
+				t.Logf("Verify that user-2 cannot transfer workspace1")
+				transfer := &tenancyv1beta1.WorkspaceOwnershipTransfer{Spec: tenancyv1beta1.WorkspaceOwnershipTransferSpec{User: testData.user2.Name, Groups: testData.user2.Groups}}
+				err = vwUser2Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace1.Name).SubResource("transfer-ownership").Body(transfer).Do(ctx).Error()
+				require.True(t, apierrors.IsNotFound(err), "expected workspace1 not to be found by user-2, got %v", err)
+
+				t.Logf("Transfer workspace1 to user-2 as user-1")
+				var transferred tenancyv1beta1.Workspace
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace1.Name).SubResource("transfer-ownership").Body(transfer).Do(ctx).Into(&transferred)
+				require.NoError(t, err, "failed to transfer workspace1 to user-2")
+				require.Equal(t, workspace1.Name, transferred.Name)
+
+				clusterWorkspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Annotations[virtualworkspacesregistry.InternalNameAnnotation], metav1.GetOptions{})
+				require.NoError(t, err, "failed to get the ClusterWorkspace of workspace1")
+				require.Equal(t, testData.user2.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation], "expected user-2 to own workspace1")
+
+				t.Logf("Verify that workspace1 moved from the personal virtual workspace of user-1 to the one of user-2")
+				err = server.virtualWorkspaceExpectations[1](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace1 in the personal virtual workspace of user-2")
+				_, err = vwUser2Client.TenancyV1beta1().Workspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "failed to get workspace1 in the personal virtual workspace of user-2")
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 0 {
+						return fmt.Errorf("expected no workspace, got %#v", w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "still saw workspace1 in the personal virtual workspace of user-1")
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace for an organization and don't see it in another organization",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user1,
+						Prefix: "/root:default/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				testOrgClient := server.virtualWorkspaceClients[0]
+				defaultOrgClient := server.virtualWorkspaceClients[1]
+
+				t.Logf("Create Workspace workspace1 in test org")
+				workspace1, err := testOrgClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+
+				t.Logf("Verify that the Workspace results in a ClusterWorkspace of the same name in the org workspace")
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected to see workspace1 as ClusterWorkspace")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Create Workspace workspace2 in the virtual workspace")
+				workspace2, err := defaultOrgClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspace1 created in test org")
+				err = server.virtualWorkspaceExpectations[1](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace2.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace2.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see workspace2 created in test org")
+			},
+		},
+		{
+			name: "create workspaces in personal virtual workspaces of two organizations and list both across all organizations",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user1,
+						Prefix: "/root:default/personal",
+					},
+					{
+						User:   testData.user1,
+						Prefix: "/" + virtualworkspacesregistry.AllOrgs + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				testOrgClient := server.virtualWorkspaceClients[0]
+				defaultOrgClient := server.virtualWorkspaceClients[1]
+
+				t.Logf("Create Workspace workspace1 in test org and workspace2 in default org")
+				workspace1, err := testOrgClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				workspace2, err := defaultOrgClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+
+				t.Logf("Allow user-1 to get the test org and the default org")
+				_, testOrgName, err := helper.ParseLogicalClusterName(server.orgClusterName)
+				require.NoError(t, err)
+				rootKubeClient := server.kubeClusterClient.Cluster(helper.RootCluster)
+				_, err = rootKubeClient.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{Name: "org-member"},
+					Rules: []rbacv1.PolicyRule{
+						{
+							APIGroups:     []string{tenancyv1beta1.SchemeGroupVersion.Group},
+							Resources:     []string{"workspaces"},
+							ResourceNames: []string{testOrgName, "default"},
+							Verbs:         []string{"get"},
+						},
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create the cluster role allowing to get the orgs")
+				_, err = rootKubeClient.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "org-member-user-1"},
+					RoleRef: rbacv1.RoleRef{
+						APIGroup: rbacv1.GroupName,
+						Kind:     "ClusterRole",
+						Name:     "org-member",
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							APIGroup: rbacv1.GroupName,
+							Kind:     rbacv1.UserKind,
+							Name:     testData.user1.Name,
+						},
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to bind user-1 to the cluster role allowing to get the orgs")
+
+				t.Logf("Verify that user-1 lists both workspaces across all organizations, annotated with their org")
+				err = server.virtualWorkspaceExpectations[2](func(w *tenancyv1beta1.WorkspaceList) error {
+					orgs := map[string]string{}
+					for _, workspace := range w.Items {
+						orgs[workspace.Name] = workspace.Annotations[virtualworkspacesregistry.OrgAnnotation]
+					}
+					if orgs[workspace1.Name] != server.orgClusterName || orgs[workspace2.Name] != "root:default" {
+						return fmt.Errorf("expected %s in %s and %s in root:default, got %v", workspace1.Name, server.orgClusterName, workspace2.Name, orgs)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspaces of both organizations")
+			},
+		},
+		{
+			name: "create workspaces in personal virtual workspaces of two organizations and list only the org of the group of the user across all organizations",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user1,
+						Prefix: "/root:default/personal",
+					},
+					{
+						User:   testData.user1,
+						Prefix: "/" + virtualworkspacesregistry.AllOrgs + "/personal",
+					},
+				}
+			},
+			groupOrgs: func(orgName string) map[string][]string {
+				_, testOrgName, _ := helper.ParseLogicalClusterName(orgName)
+				return map[string][]string{testData.user1.Groups[0]: {testOrgName}}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				testOrgClient := server.virtualWorkspaceClients[0]
+				defaultOrgClient := server.virtualWorkspaceClients[1]
+				allOrgsClient := server.virtualWorkspaceClients[2]
+
+				t.Logf("Create Workspace workspace1 in test org and workspace2 in default org")
+				workspace1, err := testOrgClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				_, err = defaultOrgClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+
+				t.Logf("Allow user-1 to get the test org and the default org")
+				_, testOrgName, err := helper.ParseLogicalClusterName(server.orgClusterName)
+				require.NoError(t, err)
+				rootKubeClient := server.kubeClusterClient.Cluster(helper.RootCluster)
+				_, err = rootKubeClient.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{Name: "org-member"},
+					Rules: []rbacv1.PolicyRule{
+						{
+							APIGroups:     []string{tenancyv1beta1.SchemeGroupVersion.Group},
+							Resources:     []string{"workspaces"},
+							ResourceNames: []string{testOrgName, "default"},
+							Verbs:         []string{"get"},
+						},
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create the cluster role allowing to get the orgs")
+				_, err = rootKubeClient.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "org-member-user-1"},
+					RoleRef: rbacv1.RoleRef{
+						APIGroup: rbacv1.GroupName,
+						Kind:     "ClusterRole",
+						Name:     "org-member",
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							APIGroup: rbacv1.GroupName,
+							Kind:     rbacv1.UserKind,
+							Name:     testData.user1.Name,
+						},
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to bind user-1 to the cluster role allowing to get the orgs")
+
+				t.Logf("Verify that user-1 only lists the workspace of the org of team-1 across all organizations")
+				var lastErr error
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					list, err := allOrgsClient.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+					if err != nil {
+						lastErr = err
+						return false, nil
+					}
+					orgs := map[string]string{}
+					for _, workspace := range list.Items {
+						orgs[workspace.Name] = workspace.Annotations[virtualworkspacesregistry.OrgAnnotation]
+					}
+					if len(orgs) != 1 || orgs[workspace1.Name] != server.orgClusterName {
+						lastErr = fmt.Errorf("expected only %s in %s, got %v", workspace1.Name, server.orgClusterName, orgs)
+						return false, nil
+					}
+					return true, nil
+				})
+				require.NoError(t, err, "did not see only the workspaces of the org of team-1: %v", lastErr)
+			},
+		},
+		{
+			name: "create workspaces in personal virtual workspace and list them with field selectors",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspaces workspace1 and workspace2 in the virtual workspace")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				workspace2, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 2 {
+						return fmt.Errorf("expected two workspaces, got %#v", w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspaces created in personal virtual workspace")
+
+				t.Logf("List the workspaces selecting on metadata.name")
+				list, err := vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{FieldSelector: "metadata.name=" + workspace2.Name})
+				require.NoError(t, err, "failed to list workspaces by name")
+				require.Len(t, list.Items, 1, "expected only workspace2")
+				require.Equal(t, workspace2.Name, list.Items[0].Name)
+
+				t.Logf("List the workspaces selecting on status.phase")
+				current, err := vwUser1Client.TenancyV1beta1().Workspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "failed to get workspace1")
+				list, err = vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{FieldSelector: "status.phase=" + string(current.Status.Phase)})
+				require.NoError(t, err, "failed to list workspaces by phase")
+				var names []string
+				for _, ws := range list.Items {
+					require.Equal(t, current.Status.Phase, ws.Status.Phase, "expected only workspaces in phase %q", current.Status.Phase)
+					names = append(names, ws.Name)
+				}
+				require.Contains(t, names, workspace1.Name)
+
+				t.Logf("Expect unsupported field selectors to be rejected")
+				_, err = vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{FieldSelector: "spec.type=Universal"})
+				require.True(t, apierrors.IsBadRequest(err), "expected a bad request error, got %v", err)
+			},
+		},
+		{
+			name: "create labeled workspaces in personal virtual workspace and list them with label selectors",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspaces workspace1 labeled env=staging and workspace2 labeled env=prod in the virtual workspace")
+				workspace1 := testData.workspace1.DeepCopy()
+				workspace1.Labels = map[string]string{"env": "staging"}
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, workspace1, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				workspace2 := testData.workspace2.DeepCopy()
+				workspace2.Labels = map[string]string{"env": "prod"}
+				workspace2, err = vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, workspace2, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 2 {
+						return fmt.Errorf("expected two workspaces, got %#v", w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspaces created in personal virtual workspace")
+
+				t.Logf("List the workspaces selecting on env=staging")
+				list, err := vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{LabelSelector: "env=staging"})
+				require.NoError(t, err, "failed to list workspaces by label")
+				require.Len(t, list.Items, 1, "expected only workspace1")
+				require.Equal(t, workspace1.Name, list.Items[0].Name)
+
+				t.Logf("Relabel workspace2 with env=staging")
+				err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					current, err := vwUser1Client.TenancyV1beta1().Workspaces().Get(ctx, workspace2.Name, metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					current.Labels["env"] = "staging"
+					_, err = vwUser1Client.TenancyV1beta1().Workspaces().Update(ctx, current, metav1.UpdateOptions{})
+					return err
+				})
+				require.NoError(t, err, "failed to update workspace2")
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					for _, ws := range w.Items {
+						if ws.Labels["env"] != "staging" {
+							return fmt.Errorf("expected workspace %s to be labeled env=staging, got %v", ws.Name, ws.Labels)
+						}
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the label of workspace2 updated in personal virtual workspace")
+
+				list, err = vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{LabelSelector: "env=staging"})
+				require.NoError(t, err, "failed to list workspaces by label")
+				require.Len(t, list.Items, 2, "expected workspace1 and workspace2")
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace and retrieve its kubeconfig",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+				_, err := server.orgKubeClient.CoreV1().Namespaces().Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create namespace")
+
+				kcpServerKubeconfig, err := server.RunningServer.RawConfig()
+				require.NoError(t, err, "failed to get KCP Kubeconfig")
+
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				cw := framework.ExpectClusterWorkspaceCondition(ctx, t, server.orgKcpClient, workspace1.Name, tenancyv1alpha1.WorkspaceShardValid, v1.ConditionTrue)
+				workspaceURL := cw.Status.BaseURL
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspace created in personal virtual workspace")
+
+				req := vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name).SubResource("kubeconfig").Do(ctx)
+				require.Nil(t, req.Error(), "error retrieving the kubeconfig for workspace %s: %v", workspace1.Name, err)
+
+				kcpConfigCurrentContextName := kcpServerKubeconfig.CurrentContext
+				kcpConfigCurrentContext := kcpServerKubeconfig.Contexts[kcpConfigCurrentContextName]
+				require.NotNil(t, kcpConfigCurrentContext, "kcp Kubeconfig is invalid")
+
+				kcpConfigCurrentCluster := kcpServerKubeconfig.Clusters[kcpConfigCurrentContext.Cluster]
+				require.NotNil(t, kcpConfigCurrentCluster, "kcp Kubeconfig is invalid")
+
+				expectedKubeconfigCluster := kcpConfigCurrentCluster.DeepCopy()
+				expectedKubeconfigCluster.Server = workspaceURL
+				expectedKubeconfig := &clientcmdapi.Config{
+					CurrentContext: "personal/" + workspace1.Name,
+					Contexts: map[string]*clientcmdapi.Context{
+						"personal/" + workspace1.Name: {
+							Cluster: "personal/" + workspace1.Name,
+						},
+					},
+					Clusters: map[string]*clientcmdapi.Cluster{
+						"personal/" + workspace1.Name: expectedKubeconfigCluster,
+					},
+				}
+				expectedKubeconfigContent, err := clientcmd.Write(*expectedKubeconfig)
+				require.NoError(t, err, "error writing the content of the expected kubeconfig for workspace %s", workspace1.Name)
+
+				workspaceKubeconfigContent, err := req.Raw()
+				require.NoError(t, err, "error retrieving the content of the kubeconfig for workspace %s", workspace1.Name)
+
+				require.YAMLEq(t, string(expectedKubeconfigContent), string(workspaceKubeconfigContent))
+			},
+		},
+		{
+			name: "retrieve the kubeconfig of a workspace in personal virtual workspace conditionally",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				framework.ExpectClusterWorkspaceCondition(ctx, t, server.orgKcpClient, workspace1.Name, tenancyv1alpha1.WorkspaceShardValid, v1.ConditionTrue)
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspace created in personal virtual workspace")
+
+				restClient, ok := vwUser1Client.TenancyV1beta1().RESTClient().(*rest.RESTClient)
+				require.True(t, ok, "expected a REST client")
+				getKubeconfig := func(ifNoneMatch string) (int, string) {
+					u := restClient.Get().Resource("workspaces").Name(workspace1.Name).SubResource("kubeconfig").URL()
+					req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+					require.NoError(t, err)
+					if ifNoneMatch != "" {
+						req.Header.Set("If-None-Match", ifNoneMatch)
+					}
+					resp, err := restClient.Client.Do(req)
+					require.NoError(t, err, "error retrieving the kubeconfig for workspace %s", workspace1.Name)
+					defer resp.Body.Close()
+					return resp.StatusCode, resp.Header.Get("ETag")
+				}
+
+				t.Logf("Fetch the kubeconfig, then fetch it again with its ETag")
+				code, etag := getKubeconfig("")
+				require.Equal(t, http.StatusOK, code)
+				require.NotEmpty(t, etag, "expected an ETag on the kubeconfig")
+				code, _ = getKubeconfig(etag)
+				require.Equal(t, http.StatusNotModified, code, "expected the unchanged kubeconfig not to be returned again")
+
+				t.Logf("Change the base URL of the workspace, expect the kubeconfig to be returned again")
+				err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					cw.Status.BaseURL += "/moved"
+					_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().UpdateStatus(ctx, cw, metav1.UpdateOptions{})
+					return err
+				})
+				require.NoError(t, err, "failed to change the base URL of workspace %s", workspace1.Name)
+				err = framework.PollWithBackoff(ctx, func(ctx context.Context) error {
+					if code, newETag := getKubeconfig(etag); code != http.StatusOK || newETag == etag {
+						return fmt.Errorf("got status code %d and ETag %s", code, newETag)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the kubeconfig change with the base URL")
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace and retrieve its kubeconfig with an embedded token",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				workspace2, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				framework.ExpectClusterWorkspaceCondition(ctx, t, server.orgKcpClient, workspace1.Name, tenancyv1alpha1.WorkspaceShardValid, v1.ConditionTrue)
+				workspace2URL := framework.ExpectClusterWorkspaceCondition(ctx, t, server.orgKcpClient, workspace2.Name, tenancyv1alpha1.WorkspaceShardValid, v1.ConditionTrue).Status.BaseURL
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 2 {
+						return fmt.Errorf("expected two workspaces, got %#v", w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspaces created in personal virtual workspace")
+
+				workspaceKubeconfigContent, err := vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name).SubResource("kubeconfig").Param("credentials", "token").Do(ctx).Raw()
+				require.NoError(t, err, "error retrieving the kubeconfig with a token for workspace %s", workspace1.Name)
+
+				workspaceKubeconfig, err := clientcmd.Load(workspaceKubeconfigContent)
+				require.NoError(t, err, "error loading the kubeconfig of workspace %s", workspace1.Name)
+				currentContext := workspaceKubeconfig.Contexts[workspaceKubeconfig.CurrentContext]
+				require.NotNil(t, currentContext, "kubeconfig of workspace %s has no current context", workspace1.Name)
+				authInfo := workspaceKubeconfig.AuthInfos[currentContext.AuthInfo]
+				require.NotNil(t, authInfo, "kubeconfig of workspace %s has no user", workspace1.Name)
+				require.NotEmpty(t, authInfo.Token, "kubeconfig of workspace %s has no token", workspace1.Name)
+
+				workspace1Config, err := clientcmd.NewDefaultClientConfig(*workspaceKubeconfig, nil).ClientConfig()
+				require.NoError(t, err)
+				workspace1KubeClient, err := kubernetes.NewForConfig(workspace1Config)
+				require.NoError(t, err)
+				_, err = workspace1KubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+				require.NoError(t, err, "expected the token to be usable in workspace %s", workspace1.Name)
+
+				workspace2Config := rest.CopyConfig(workspace1Config)
+				workspace2Config.Host = workspace2URL
+				workspace2KubeClient, err := kubernetes.NewForConfig(workspace2Config)
+				require.NoError(t, err)
+				_, err = workspace2KubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+				require.True(t, apierrors.IsUnauthorized(err), "expected the token of workspace %s to be rejected in workspace %s, got %v", workspace1.Name, workspace2.Name, err)
+
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name).SubResource("kubeconfig").Param("credentials", "password").Do(ctx).Error()
+				require.True(t, apierrors.IsBadRequest(err), "expected unsupported credentials to be rejected, got %v", err)
+			},
+		},
+		{
+			name: "create a batch of workspaces in personal virtual workspace and see per-workspace results",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspace created in personal virtual workspace")
+
+				batch := &tenancyv1beta1.WorkspaceBatch{}
+				for i := 1; i <= 5; i++ {
+					batch.Spec.Workspaces = append(batch.Spec.Workspaces, tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("workspace%d", i)}})
+				}
+				var result tenancyv1beta1.WorkspaceBatch
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspacebatches").Body(batch).Do(ctx).Into(&result)
+				require.NoError(t, err, "the collision of one workspace should not fail the batch")
+				require.Len(t, result.Status.Results, 5)
+
+				require.Equal(t, workspace1.Name, result.Status.Results[0].Name)
+				require.NotNil(t, result.Status.Results[0].Error, "expected %s to collide with the existing workspace", workspace1.Name)
+				require.Equal(t, metav1.StatusReasonAlreadyExists, result.Status.Results[0].Error.Reason)
+				expected := sets.NewString(workspace1.Name)
+				for _, r := range result.Status.Results[1:] {
+					require.Nil(t, r.Error, "failed to create %s in the batch", r.Name)
+					require.NotNil(t, r.Workspace)
+					require.Equal(t, r.Name, r.Workspace.Name)
+					expected.Insert(r.Name)
+				}
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					names := sets.NewString()
+					for _, workspace := range w.Items {
+						names.Insert(workspace.Name)
+					}
+					if !names.Equal(expected) {
+						return fmt.Errorf("expected workspaces %v, got %v", expected.List(), names.List())
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspaces of the batch in personal virtual workspace")
+			},
+		},
+		{
+			name: "create workspaces in personal virtual workspace until the user reaches its workspace quota",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Limit user-1 to 2 workspaces in the org")
+				_, err := server.orgKcpClient.TenancyV1alpha1().WorkspaceQuotas().Create(ctx, &tenancyv1alpha1.WorkspaceQuota{
+					ObjectMeta: metav1.ObjectMeta{Name: "user-1-quota"},
+					Spec: tenancyv1alpha1.WorkspaceQuotaSpec{
+						Users:         []string{testData.user1.Name},
+						MaxWorkspaces: 2,
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create the workspace quota")
+
+				t.Logf("Create Workspaces workspace1 and workspace2 in the virtual workspace")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				workspace2, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					expected := sets.NewString(workspace1.Name, workspace2.Name)
+					names := sets.NewString()
+					for _, workspace := range w.Items {
+						names.Insert(workspace.Name)
+					}
+					if !names.Equal(expected) {
+						return fmt.Errorf("expected workspaces %v, got %v", expected.List(), names.List())
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspaces created in personal virtual workspace")
+
+				t.Logf("Verify that creating a third workspace is forbidden")
+				_, err = vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "workspace3"}}, metav1.CreateOptions{})
+				require.Error(t, err, "expected the third workspace to be rejected")
+				require.True(t, apierrors.IsForbidden(err), "expected a forbidden error, got %v", err)
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "workspace3", metav1.GetOptions{})
+				require.True(t, apierrors.IsNotFound(err), "expected workspace3 not to exist as ClusterWorkspace")
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace and retrieve its authorization",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspace created in personal virtual workspace")
+
+				var authorization tenancyv1beta1.WorkspaceAuthorization
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name).SubResource("authorization").Do(ctx).Into(&authorization)
+				require.NoError(t, err, "error retrieving the authorization for workspace %s", workspace1.Name)
+
+				require.False(t, authorization.Status.Incomplete, "expected complete rules, got evaluation error %q", authorization.Status.EvaluationError)
+				var allowedEverything bool
+				for _, rule := range authorization.Status.ResourceRules {
+					if sets.NewString(rule.Verbs...).Has("*") && sets.NewString(rule.APIGroups...).Has("*") && sets.NewString(rule.Resources...).Has("*") {
+						allowedEverything = true
+					}
+				}
+				require.True(t, allowedEverything, "expected the owner of the workspace to be allowed everything, got %#v", authorization.Status.ResourceRules)
+				require.NotEmpty(t, authorization.Status.NonResourceRules, "expected non-resource rules for the owner of the workspace")
+
+				_, err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name("unknown").SubResource("authorization").Do(ctx).Get()
+				require.True(t, apierrors.IsNotFound(err), "expected a not found error for an unknown workspace, got %v", err)
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace and retrieve its API resources",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				var apiResources tenancyv1beta1.WorkspaceAPIResources
+				require.Eventually(t, func() bool {
+					// the workspace has to be scheduled onto a shard first
+					err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name).SubResource("apiresources").Do(ctx).Into(&apiResources)
+					return err == nil
+				}, wait.ForeverTestTimeout, 100*time.Millisecond, "could not retrieve the API resources of workspace %s", workspace1.Name)
+
+				var groups []string
+				for _, group := range apiResources.Groups {
+					groups = append(groups, group.Name)
+				}
+				require.Contains(t, groups, tenancyv1alpha1.SchemeGroupVersion.Group, "expected the tenancy group to be served in workspace %s", workspace1.Name)
+
+				_, err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name("unknown").SubResource("apiresources").Do(ctx).Get()
+				require.True(t, apierrors.IsNotFound(err), "expected a not found error for an unknown workspace, got %v", err)
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace and see the shard hosting it once ready",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+				})
+
+				require.Eventually(t, func() bool {
+					workspace1, err = vwUser1Client.TenancyV1beta1().Workspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					return err == nil && workspace1.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady
+				}, wait.ForeverTestTimeout, 100*time.Millisecond, "workspace %s did not become ready", testData.workspace1.Name)
+
+				clusterWorkspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.NotEmpty(t, workspace1.Status.Shard, "expected the shard of workspace %s to be shown", workspace1.Name)
+				require.Equal(t, clusterWorkspace.Status.Location.Current, workspace1.Status.Shard)
+				require.Equal(t, clusterWorkspace.Status.BaseURL, workspace1.Status.URL)
+
+				list, err := vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+				require.NoError(t, err)
+				require.Len(t, list.Items, 1)
+				require.Equal(t, workspace1.Status.Shard, list.Items[0].Status.Shard, "expected the shard to be listed")
+			},
+		},
+		{
+			name: "move a workspace in personal virtual workspace to another organization",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				internalName := workspace1.Annotations[virtualworkspacesregistry.InternalNameAnnotation]
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, internalName, metav1.GetOptions{})
+				})
+
+				t.Logf("Create a target organization")
+				targetOrgClusterName := framework.NewOrganizationFixture(t, server)
+				targetOrgKcpClient := server.kcpClusterClient.Cluster(targetOrgClusterName)
+				move := &tenancyv1beta1.WorkspaceMove{Spec: tenancyv1beta1.WorkspaceMoveSpec{TargetOrg: targetOrgClusterName}}
+				moveRequest := func() (*tenancyv1beta1.Workspace, error) {
+					var moved tenancyv1beta1.Workspace
+					err := vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace1.Name).SubResource("move").Body(move).Do(ctx).Into(&moved)
+					return &moved, err
+				}
+
+				t.Logf("Verify that user-1 cannot move workspace1 without the permission to create workspaces in the target organization")
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					_, err = moveRequest()
+					return apierrors.IsForbidden(err), nil
+				})
+				require.NoError(t, err, "expected the move to be forbidden")
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, internalName, metav1.GetOptions{})
+				require.NoError(t, err, "expected workspace1 to still exist in the source organization")
+
+				t.Logf("Allow user-1 to create workspaces in the target organization")
+				targetOrgKubeClient := server.kubeClusterClient.Cluster(targetOrgClusterName)
+				_, err = targetOrgKubeClient.RbacV1().ClusterRoles().Create(ctx, &rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{Name: "workspace-creator"},
+					Rules: []rbacv1.PolicyRule{
+						{
+							APIGroups: []string{tenancyv1beta1.SchemeGroupVersion.Group},
+							Resources: []string{"workspaces"},
+							Verbs:     []string{"create"},
+						},
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create the cluster role allowing to create workspaces")
+				_, err = targetOrgKubeClient.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "workspace-creator-user-1"},
+					RoleRef: rbacv1.RoleRef{
+						APIGroup: rbacv1.GroupName,
+						Kind:     "ClusterRole",
+						Name:     "workspace-creator",
+					},
+					Subjects: []rbacv1.Subject{
+						{
+							APIGroup: rbacv1.GroupName,
+							Kind:     rbacv1.UserKind,
+							Name:     testData.user1.Name,
+						},
+					},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to bind user-1 to the cluster role allowing to create workspaces")
+
+				t.Logf("Move workspace1 to the target organization")
+				var moved *tenancyv1beta1.Workspace
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					moved, err = moveRequest()
+					if apierrors.IsForbidden(err) {
+						return false, nil
+					}
+					return err == nil, err
+				})
+				require.NoError(t, err, "failed to move workspace1")
+				require.Equal(t, workspace1.Name, moved.Name)
+
+				t.Logf("Verify that the ClusterWorkspace is recreated in the target organization with its owner")
+				movedClusterWorkspace, err := targetOrgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, internalName, metav1.GetOptions{})
+				require.NoError(t, err, "expected workspace1 in the target organization")
+				require.Equal(t, testData.user1.Name, movedClusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+
+				t.Logf("Verify that the ClusterWorkspace is removed from the source organization")
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, internalName, metav1.GetOptions{})
+					if apierrors.IsNotFound(err) {
+						return true, nil
+					}
+					return false, err
+				})
+				require.NoError(t, err, "did not see workspace1 removed from the source organization")
+
+				t.Logf("Verify that retrying the move succeeds")
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					moved, err = moveRequest()
+					if apierrors.IsNotFound(err) {
+						// the owner binding in the target organization might not be known yet
+						return false, nil
+					}
+					return err == nil, err
+				})
+				require.NoError(t, err, "failed to retry the move of workspace1")
+				require.Equal(t, workspace1.Name, moved.Name)
+			},
+		},
+		{
+			name: "rename a workspace in personal virtual workspace and see it under its new name only",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				internalName := workspace1.Annotations[virtualworkspacesregistry.InternalNameAnnotation]
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, internalName, metav1.GetOptions{})
+				})
+
+				t.Logf("Rename workspace1 to workspace1-renamed")
+				rename := &tenancyv1beta1.WorkspaceRename{Spec: tenancyv1beta1.WorkspaceRenameSpec{NewName: "workspace1-renamed"}}
+				var renamed tenancyv1beta1.Workspace
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					err = vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace1.Name).SubResource("rename").Body(rename).Do(ctx).Into(&renamed)
+					if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+						// the owner binding might not be known yet
+						return false, nil
+					}
+					return err == nil, err
+				})
+				require.NoError(t, err, "failed to rename workspace1")
+				require.Equal(t, "workspace1-renamed", renamed.Name)
+				require.Equal(t, internalName, renamed.Annotations[virtualworkspacesregistry.InternalNameAnnotation], "expected the internal name to be kept")
+
+				t.Logf("Verify that workspace1 is only found under its new name")
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					_, err = vwUser1Client.TenancyV1beta1().Workspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					if !apierrors.IsNotFound(err) {
+						return false, nil
+					}
+					ws, err := vwUser1Client.TenancyV1beta1().Workspaces().Get(ctx, "workspace1-renamed", metav1.GetOptions{})
+					if apierrors.IsNotFound(err) {
+						return false, nil
+					}
+					return err == nil && ws.Annotations[virtualworkspacesregistry.InternalNameAnnotation] == internalName, err
+				})
+				require.NoError(t, err, "did not see workspace1 renamed")
+
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, internalName, metav1.GetOptions{})
+				require.NoError(t, err, "expected the ClusterWorkspace to keep its name")
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace of an organization with a name prefix and see the prefixed name",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Set the workspace name prefix of the organization to team-a-")
+				_, orgName, err := helper.ParseLogicalClusterName(server.orgClusterName)
+				require.NoError(t, err)
+				patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:"team-a-"}}}`, tenancyv1alpha1.ClusterWorkspaceNamePrefixAnnotation)
+				_, err = server.rootKcpClient.TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, orgName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+				require.NoError(t, err, "failed to annotate the organization")
+
+				t.Logf("Wait for the virtual workspace to see the prefix")
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					workspace, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+					if err != nil {
+						return false, err
+					}
+					return workspace.Name == "team-a-workspace1", nil
+				})
+				require.NoError(t, err, "did not see the prefix applied to workspace names")
+
+				t.Logf("Create Workspace workspace1 as user-1 and see it prefixed")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				require.Equal(t, "team-a-workspace1", workspace1.Name)
+				internalName := workspace1.Annotations[virtualworkspacesregistry.InternalNameAnnotation]
+				require.Equal(t, "team-a-workspace1", internalName)
+				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, internalName, metav1.GetOptions{})
+				require.NoError(t, err, "expected the ClusterWorkspace to have the prefixed name")
+
+				t.Logf("Create a workspace with the prefix already in its name and see it not prefixed twice")
+				workspace2, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "team-a-workspace2"}}, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+				require.Equal(t, "team-a-workspace2", workspace2.Name)
+			},
+		},
+		{
+			name: "query the identity seen by the virtual workspace",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Query %s as %s", virtualframework.WhoAmIPath, testData.user1.Name)
+				raw, err := vwUser1Client.Discovery().RESTClient().Get().RequestURI(virtualframework.WhoAmIPath).DoRaw(ctx)
+				require.NoError(t, err, "failed to query %s", virtualframework.WhoAmIPath)
+				require.NotContains(t, string(raw), testData.user1.Token, "expected the token not to be echoed")
+
+				var whoAmI virtualframework.WhoAmI
+				require.NoError(t, json.Unmarshal(raw, &whoAmI), "failed to decode %s", string(raw))
+				require.Equal(t, testData.user1.Name, whoAmI.Name)
+				require.Equal(t, testData.user1.UID, whoAmI.UID)
+				// the authenticator of kcp adds every authenticated user to system:authenticated
+				require.Equal(t, []string{"team-1"}, sets.NewString(whoAmI.Groups...).Delete("system:authenticated").List())
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace as a user authenticated by a client certificate and list it",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   certUser,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwCertUserClient := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as %s", certUser.Name)
+				workspace1, err := vwCertUserClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				})
+
+				t.Logf("Verify that the ClusterWorkspace is owned by %s", certUser.Name)
+				clusterWorkspace, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected to see workspace1 as ClusterWorkspace")
+				require.Equal(t, certUser.Name, clusterWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceOwnerAnnotation])
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspace listed in personal virtual workspace")
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace with initial members and see them operate in it",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user2,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Verify that invalid initial members are rejected")
+				invalid := testData.workspace1.DeepCopy()
+				invalid.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation: "user:" + testData.user2.Name + "=owner"}
+				_, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, invalid, metav1.CreateOptions{})
+				require.True(t, apierrors.IsInvalid(err), "expected an unsupported role to be rejected, got %v", err)
+
+				t.Logf("Create Workspace workspace1 as user-1 with user-2 as initial editor")
+				workspace1 := testData.workspace1.DeepCopy()
+				workspace1.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceInitialMembersAnnotation: "user:" + testData.user2.Name + "=edit"}
+				workspace1, err = vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, workspace1, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				})
+
+				var workspace1ClusterName string
+				var lastErr error
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					if err != nil {
+						lastErr = err
+						return false, nil
+					}
+					if !conditions.IsTrue(cw, tenancyv1alpha1.WorkspaceInitialMembersBootstrapped) {
+						lastErr = fmt.Errorf("initial members of ClusterWorkspace %s are not bootstrapped: %s", cw.Name, conditions.GetMessage(cw, tenancyv1alpha1.WorkspaceInitialMembersBootstrapped))
+						return false, nil
+					}
+					workspace1ClusterName, err = helper.EncodeLogicalClusterName(cw)
+					return err == nil, err
+				})
+				require.NoError(t, err, "did not see the initial members of workspace1 bootstrapped: %v", lastErr)
+
+				t.Logf("Create a ConfigMap in workspace1 as user-2")
+				kcpCfg, err := server.DefaultConfig()
+				require.NoError(t, err)
+				user2Cfg := rest.CopyConfig(kcpCfg)
+				user2Cfg.BearerToken = testData.user2.Token
+				user2KubeClusterClient, err := kubernetes.NewClusterForConfig(user2Cfg)
+				require.NoError(t, err)
+				configMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "initial-member"}}
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					_, err = user2KubeClusterClient.Cluster(workspace1ClusterName).CoreV1().ConfigMaps("default").Create(ctx, configMap, metav1.CreateOptions{})
+					if apierrors.IsForbidden(err) {
+						// the authorizer might not have seen the bindings yet
+						lastErr = err
+						return false, nil
+					}
+					return err == nil, err
+				})
+				require.NoError(t, err, "expected user-2 to be able to create a ConfigMap in workspace1: %v", lastErr)
+
+			},
+		},
+		{
+			name: "clone a workspace with a CRD in personal virtual workspace and see the CRD in the clone",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				waitForReady := func(name string) *tenancyv1alpha1.ClusterWorkspace {
+					var ready *tenancyv1alpha1.ClusterWorkspace
+					var lastErr error
+					err := wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+						cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, name, metav1.GetOptions{})
+						if err != nil {
+							lastErr = err
+							return false, nil
+						}
+						if cw.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+							lastErr = fmt.Errorf("ClusterWorkspace %s is in phase %q", name, cw.Status.Phase)
+							return false, nil
+						}
+						ready = cw
+						return true, nil
+					})
+					require.NoError(t, err, "did not see the workspace %s ready: %v", name, lastErr)
+					return ready
+				}
+
+				source, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, source.Name, metav1.GetOptions{})
+				})
+
+				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != source.Name {
+						return fmt.Errorf("expected only one workspace (%s), got %#v", source.Name, w)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see the workspace created in personal virtual workspace")
+
+				sourceClusterName, err := helper.EncodeLogicalClusterName(waitForReady(source.Name))
+				require.NoError(t, err)
+				fixturewildwest.Create(t, server.crdClusterClient.Cluster(sourceClusterName).ApiextensionsV1().CustomResourceDefinitions(), metav1.GroupResource{Group: wildwest.GroupName, Resource: "cowboys"})
+
+				clone := testData.workspace2.DeepCopy()
+				clone.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceCloneFromAnnotation: source.Name}
+				clone, err = vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, clone, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2 as a clone of workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, clone.Name, metav1.GetOptions{})
+				})
+
+				cloned := waitForReady(clone.Name)
+				require.True(t, conditions.IsTrue(cloned, tenancyv1alpha1.WorkspaceCloneComplete), "expected the clone to be complete")
+
+				cloneClusterName, err := helper.EncodeLogicalClusterName(cloned)
+				require.NoError(t, err)
+				_, err = server.crdClusterClient.Cluster(cloneClusterName).ApiextensionsV1().CustomResourceDefinitions().Get(ctx, "cowboys."+wildwest.GroupName, metav1.GetOptions{})
+				require.NoError(t, err, "expected the CRD to be cloned into workspace2")
+			},
+		},
+		{
+			name: "list workspaces in personal virtual workspace while impersonating a user and get their view",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+					{
+						User:   testData.user2,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+				vwUser2Client := server.virtualWorkspaceClients[1]
+
+				t.Logf("Create Workspace workspace1 as user-1 and workspace2 as user-2")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				_, err = vwUser2Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
+
+				t.Logf("List the workspaces as the admin impersonating user-1")
+				impersonatingConfig, err := server.ImpersonatingConfig(testData.user1)
+				require.NoError(t, err)
+				require.Equal(t, testData.user1.Name, impersonatingConfig.Impersonate.UserName)
+				require.Equal(t, testData.user1.Groups, impersonatingConfig.Impersonate.Groups)
+				// talk to the virtual workspace with the admin credentials
+				impersonatingConfig.Host = server.virtualWorkspaceConfigs[0].Host
+				impersonatingConfig.TLSClientConfig.Insecure = true
+				impersonatingConfig.TLSClientConfig.CAData = nil
+				impersonatingConfig.TLSClientConfig.CAFile = ""
+				impersonatingClient, err := clientset.NewForConfig(impersonatingConfig)
+				require.NoError(t, err)
+
+				var lastErr error
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					list, err := impersonatingClient.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+					if err != nil {
+						lastErr = err
+						return false, nil
+					}
+					if len(list.Items) != 1 || list.Items[0].Name != workspace1.Name {
+						lastErr = fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, list)
+						return false, nil
+					}
+					return true, nil
+				})
+				require.NoError(t, err, "did not see the view of user-1 while impersonating them: %v", lastErr)
+			},
+		},
+		{
+			name: "freeze a workspace in personal virtual workspace and see writes denied while reads work",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				})
+				cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				workspace1ClusterName, err := helper.EncodeLogicalClusterName(cw)
+				require.NoError(t, err)
+				configMaps := server.kubeClusterClient.Cluster(workspace1ClusterName).CoreV1().ConfigMaps("default")
+
+				t.Logf("Create a ConfigMap in workspace1 before freezing it")
+				var lastErr error
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					_, err = configMaps.Create(ctx, &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "before-freeze"}}, metav1.CreateOptions{})
+					if err != nil {
+						lastErr = err
+						return false, nil
+					}
+					return true, nil
+				})
+				require.NoError(t, err, "failed to create a ConfigMap in workspace1: %v", lastErr)
+
+				t.Logf("Freeze workspace1")
+				var frozen tenancyv1beta1.Workspace
+				freeze := &tenancyv1beta1.WorkspaceFreeze{Spec: tenancyv1beta1.WorkspaceFreezeSpec{Reason: "compliance snapshot"}}
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace1.Name).SubResource("freeze").Body(freeze).Do(ctx).Into(&frozen)
+				require.NoError(t, err, "failed to freeze workspace1")
+				require.Equal(t, "compliance snapshot", frozen.Annotations[tenancyv1alpha1.ClusterWorkspaceFrozenAnnotation])
+
+				t.Logf("Verify that creating a ConfigMap in workspace1 is denied")
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					_, err = configMaps.Create(ctx, &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "while-frozen"}}, metav1.CreateOptions{})
+					if apierrors.IsForbidden(err) {
+						return true, nil
+					}
+					if err == nil {
+						// admission might not have seen the annotation yet
+						err = configMaps.Delete(ctx, "while-frozen", metav1.DeleteOptions{})
+					}
+					lastErr = fmt.Errorf("expected the creation to be forbidden, got %v", err)
+					return false, nil
+				})
+				require.NoError(t, err, "expected writes to frozen workspace1 to be denied: %v", lastErr)
+
+				t.Logf("Verify that reading a ConfigMap in workspace1 still works")
+				_, err = configMaps.Get(ctx, "before-freeze", metav1.GetOptions{})
+				require.NoError(t, err, "expected reads of frozen workspace1 to work")
+
+				t.Logf("Unfreeze workspace1 and verify that a ConfigMap can be created again")
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace1.Name).SubResource("unfreeze").Body(&tenancyv1beta1.WorkspaceFreeze{}).Do(ctx).Error()
+				require.NoError(t, err, "failed to unfreeze workspace1")
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					_, err = configMaps.Create(ctx, &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "after-unfreeze"}}, metav1.CreateOptions{})
+					if err != nil {
+						lastErr = err
+						return false, nil
+					}
+					return true, nil
+				})
+				require.NoError(t, err, "expected writes to unfrozen workspace1 to work: %v", lastErr)
+			},
+		},
+		{
+			name: "delete a workspace in personal virtual workspace and restore it within the retention window",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			softDeleteRetention: time.Hour,
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				})
+				require.NoError(t, server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only workspace1, got %#v", w)
+					}
+					return nil
+				}), "did not see workspace1")
+
+				t.Logf("Delete workspace1 and verify that it is hidden but kept")
+				err = vwUser1Client.TenancyV1beta1().Workspaces().Delete(ctx, workspace1.Name, metav1.DeleteOptions{})
+				require.NoError(t, err, "failed to delete workspace1")
+				cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err, "expected the ClusterWorkspace of workspace1 to be kept")
+				require.Contains(t, cw.Annotations, tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation)
+				var lastErr error
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					list, err := vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+					if err != nil {
+						lastErr = err
+						return false, nil
+					}
+					if len(list.Items) != 0 {
+						lastErr = fmt.Errorf("expected no workspace, got %d", len(list.Items))
+						return false, nil
+					}
+					return true, nil
+				})
+				require.NoError(t, err, "expected workspace1 to be hidden: %v", lastErr)
+
+				var deleted tenancyv1beta1.WorkspaceList
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Param(virtualworkspacesregistry.IncludeDeletedParameter, "true").Do(ctx).Into(&deleted)
+				require.NoError(t, err, "failed to list deleted workspaces")
+				require.Len(t, deleted.Items, 1, "expected workspace1 to be listed with include-deleted")
 
-	type runningServer struct {
-		framework.RunningServer
-		orgKubeClient                  kubernetes.Interface
-		orgKcpClient, rootKcpClient    clientset.Interface
-		virtualWorkspaceClientContexts []helpers.VirtualWorkspaceClientContext
-		virtualWorkspaceClients        []clientset.Interface
-		virtualWorkspaceExpectations   []framework.RegisterWorkspaceListExpectation
-	}
-	var testCases = []struct {
-		name                           string
-		virtualWorkspaceClientContexts func(orgName string) []helpers.VirtualWorkspaceClientContext
-		work                           func(ctx context.Context, t *testing.T, server runningServer)
-	}{
+				t.Logf("Restore workspace1 and verify that it is listed again")
+				var restored tenancyv1beta1.Workspace
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace1.Name).SubResource("restore").Body(&tenancyv1beta1.WorkspaceRestore{}).Do(ctx).Into(&restored)
+				require.NoError(t, err, "failed to restore workspace1")
+				require.NotContains(t, restored.Annotations, tenancyv1alpha1.ClusterWorkspaceSoftDeletedUntilAnnotation)
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					list, err := vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+					if err != nil {
+						lastErr = err
+						return false, nil
+					}
+					if len(list.Items) != 1 || list.Items[0].Name != workspace1.Name {
+						lastErr = fmt.Errorf("expected only workspace1, got %d workspaces", len(list.Items))
+						return false, nil
+					}
+					return true, nil
+				})
+				require.NoError(t, err, "expected workspace1 to be listed again: %v", lastErr)
+			},
+		},
 		{
-			name: "create a workspace in personal virtual workspace and have only its owner list it",
+			name: "refresh the URL of a workspace in personal virtual workspace",
 			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
 				return []helpers.VirtualWorkspaceClientContext{
 					{
 						User:   testData.user1,
 						Prefix: "/" + orgName + "/personal",
 					},
-					{
-						User:   testData.user2,
-						Prefix: "/" + orgName + "/personal",
-					},
 				}
 			},
 			work: func(ctx context.Context, t *testing.T, server runningServer) {
 				vwUser1Client := server.virtualWorkspaceClients[0]
-				vwUser2Client := server.virtualWorkspaceClients[1]
 
-				t.Logf("Create Workspace workspace1 in the virtual workspace")
+				t.Logf("Create workspace1 and wait for it to be scheduled")
 				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
 				require.NoError(t, err, "failed to create workspace1")
-
-				t.Logf("Verify that the Workspace results in a ClusterWorkspace of the same name in the org workspace")
-				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
-				require.NoError(t, err, "expected to see workspace1 as ClusterWorkspace")
-				server.Artifact(t, func() (runtime.Object, error) {
-					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
-				})
-
-				t.Logf("Create Workspace workspace2 in the virtual workspace")
-				workspace2, err := vwUser2Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
-				require.NoError(t, err, "failed to create workspace2")
-
-				t.Logf("Verify that the Workspace results in a ClusterWorkspace of the same name in the org workspace")
-				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace2.Name, metav1.GetOptions{})
-				require.NoError(t, err, "expected to see workspace2 as ClusterWorkspace")
 				server.Artifact(t, func() (runtime.Object, error) {
-					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace2.Name, metav1.GetOptions{})
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
 				})
-
-				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
-					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
-						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+				var baseURL string
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					if err != nil {
+						return false, nil
 					}
-					return nil
+					baseURL = cw.Status.BaseURL
+					return cw.Status.Location.Current != "" && baseURL != "", nil
 				})
-				require.NoError(t, err, "did not see the workspace created in personal virtual workspace")
-				err = server.virtualWorkspaceExpectations[1](func(w *tenancyv1beta1.WorkspaceList) error {
-					if len(w.Items) != 1 || w.Items[0].Name != workspace2.Name {
-						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace2.Name, w)
+				require.NoError(t, err, "expected workspace1 to be scheduled")
+
+				t.Logf("Refresh the URL of workspace1 and verify that it is kept with an unchanged shard address")
+				var refreshed tenancyv1beta1.Workspace
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace1.Name).SubResource("refresh-url").Body(&tenancyv1beta1.WorkspaceURLRefresh{}).Do(ctx).Into(&refreshed)
+				require.NoError(t, err, "failed to refresh the URL of workspace1")
+				require.Equal(t, workspace1.Name, refreshed.Name)
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+					if err != nil {
+						return false, nil
 					}
-					return nil
+					_, pending := cw.Annotations[tenancyv1alpha1.ClusterWorkspaceRefreshURLAnnotation]
+					return !pending, nil
 				})
-				require.NoError(t, err, "did not see workspace2 created in personal virtual workspace")
+				require.NoError(t, err, "expected the refresh of workspace1 to be processed")
+				cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, baseURL, cw.Status.BaseURL)
+				require.False(t, conditions.Has(cw, tenancyv1alpha1.WorkspaceURLRefreshed), "expected no refresh condition for an unchanged URL")
 			},
 		},
 		{
-			name: "create a workspace in personal virtual workspace for an organization and don't see it in another organization",
+			name: "list workspaces in personal virtual workspace as protobuf",
 			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
 				return []helpers.VirtualWorkspaceClientContext{
 					{
 						User:   testData.user1,
 						Prefix: "/" + orgName + "/personal",
 					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				require.NoError(t, server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
+					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
+						return fmt.Errorf("expected only workspace1, got %#v", w)
+					}
+					return nil
+				}), "did not see workspace1")
+
+				t.Logf("List workspaces with the protobuf accept header and decode the response")
+				raw, err := vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").SetHeader("Accept", runtime.ContentTypeProtobuf).DoRaw(ctx)
+				require.NoError(t, err, "failed to list workspaces as protobuf")
+				require.True(t, bytes.HasPrefix(raw, []byte("k8s\x00")), "expected a protobuf response, got %q", raw)
+				decoded, _, err := protobuf.NewSerializer(kcpscheme.Scheme, kcpscheme.Scheme).Decode(raw, nil, nil)
+				require.NoError(t, err, "failed to decode the protobuf response")
+				list, ok := decoded.(*tenancyv1beta1.WorkspaceList)
+				require.True(t, ok, "expected a WorkspaceList, got %T", decoded)
+				require.Len(t, list.Items, 1)
+				require.Equal(t, workspace1.Name, list.Items[0].Name)
+				require.Equal(t, workspace1.Spec.Type, list.Items[0].Spec.Type)
+
+				t.Logf("List workspaces with a client negotiating protobuf")
+				protobufConfig := rest.CopyConfig(server.virtualWorkspaceConfigs[0])
+				protobufConfig.ContentType = runtime.ContentTypeProtobuf
+				protobufConfig.AcceptContentTypes = runtime.ContentTypeProtobuf
+				protobufClient, err := clientset.NewForConfig(protobufConfig)
+				require.NoError(t, err)
+				workspaces, err := protobufClient.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+				require.NoError(t, err, "failed to list workspaces with the protobuf client")
+				require.Len(t, workspaces.Items, 1)
+				require.Equal(t, workspace1.Name, workspaces.Items[0].Name)
+			},
+		},
+		{
+			name: "create a workspace in personal virtual workspace with default namespace labels and see them on new namespaces",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
 					{
 						User:   testData.user1,
-						Prefix: "/root:default/personal",
+						Prefix: "/" + orgName + "/personal",
 					},
 				}
 			},
 			work: func(ctx context.Context, t *testing.T, server runningServer) {
-				testOrgClient := server.virtualWorkspaceClients[0]
-				defaultOrgClient := server.virtualWorkspaceClients[1]
+				vwUser1Client := server.virtualWorkspaceClients[0]
 
-				t.Logf("Create Workspace workspace1 in test org")
-				workspace1, err := testOrgClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
-				require.NoError(t, err, "failed to create workspace1")
+				t.Logf("Verify that a workspace with invalid default namespace labels is rejected")
+				invalid := testData.workspace2.DeepCopy()
+				invalid.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team"}
+				_, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, invalid, metav1.CreateOptions{})
+				require.True(t, apierrors.IsInvalid(err), "expected the workspace with invalid default namespace labels to be rejected, got %v", err)
 
-				t.Logf("Verify that the Workspace results in a ClusterWorkspace of the same name in the org workspace")
-				_, err = server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
-				require.NoError(t, err, "expected to see workspace1 as ClusterWorkspace")
+				t.Logf("Create Workspace workspace1 as user-1 with default namespace labels")
+				workspace1 := testData.workspace1.DeepCopy()
+				workspace1.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceDefaultNamespaceLabelsAnnotation: "team=payments"}
+				workspace1, err = vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, workspace1, metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
 				server.Artifact(t, func() (runtime.Object, error) {
-					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
 				})
+				cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				workspace1ClusterName, err := helper.EncodeLogicalClusterName(cw)
+				require.NoError(t, err)
 
-				t.Logf("Create Workspace workspace2 in the virtual workspace")
-				workspace2, err := defaultOrgClient.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
-				require.NoError(t, err, "failed to create workspace2")
-
-				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
-					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
-						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
-					}
-					return nil
-				})
-				require.NoError(t, err, "did not see the workspace1 created in test org")
-				err = server.virtualWorkspaceExpectations[1](func(w *tenancyv1beta1.WorkspaceList) error {
-					if len(w.Items) != 1 || w.Items[0].Name != workspace2.Name {
-						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace2.Name, w)
+				t.Logf("Create a namespace in workspace1 and verify that it has the default labels")
+				var namespace *v1.Namespace
+				var lastErr error
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					namespace, err = server.kubeClusterClient.Cluster(workspace1ClusterName).CoreV1().Namespaces().Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-namespace"}}, metav1.CreateOptions{})
+					if err != nil {
+						lastErr = err
+						return false, nil
 					}
-					return nil
+					return true, nil
 				})
-				require.NoError(t, err, "did not see workspace2 created in test org")
+				require.NoError(t, err, "failed to create a namespace in workspace1: %v", lastErr)
+				require.Equal(t, "payments", namespace.Labels["team"], "expected the default namespace label on the new namespace")
 			},
 		},
 		{
-			name: "create a workspace in personal virtual workspace and retrieve its kubeconfig",
+			name: "export a workspace with a CRD in personal virtual workspace and import it into a new workspace",
 			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
 				return []helpers.VirtualWorkspaceClientContext{
 					{
@@ -208,78 +2144,320 @@ func TestWorkspacesVirtualWorkspaces(t *testing.T) {
 			},
 			work: func(ctx context.Context, t *testing.T, server runningServer) {
 				vwUser1Client := server.virtualWorkspaceClients[0]
-				_, err := server.orgKubeClient.CoreV1().Namespaces().Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}, metav1.CreateOptions{})
-				require.NoError(t, err, "failed to create namespace")
 
-				kcpServerKubeconfig, err := server.RunningServer.RawConfig()
-				require.NoError(t, err, "failed to get KCP Kubeconfig")
+				waitForReady := func(name string) *tenancyv1alpha1.ClusterWorkspace {
+					var ready *tenancyv1alpha1.ClusterWorkspace
+					var lastErr error
+					err := wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+						cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, name, metav1.GetOptions{})
+						if err != nil {
+							lastErr = err
+							return false, nil
+						}
+						if cw.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+							lastErr = fmt.Errorf("ClusterWorkspace %s is in phase %q", name, cw.Status.Phase)
+							return false, nil
+						}
+						ready = cw
+						return true, nil
+					})
+					require.NoError(t, err, "did not see the workspace %s ready: %v", name, lastErr)
+					return ready
+				}
 
+				t.Logf("Create Workspace workspace1 as user-1 with the cowboys CRD")
 				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
 				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
+				})
+				workspace1ClusterName, err := helper.EncodeLogicalClusterName(waitForReady(workspace1.Name))
+				require.NoError(t, err)
+				fixturewildwest.Create(t, server.crdClusterClient.Cluster(workspace1ClusterName).ApiextensionsV1().CustomResourceDefinitions(), metav1.GroupResource{Group: wildwest.GroupName, Resource: "cowboys"})
 
+				t.Logf("Export workspace1")
+				var export tenancyv1beta1.WorkspaceExport
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name).SubResource("export").Do(ctx).Into(&export)
+				require.NoError(t, err, "failed to export workspace1")
+				require.Contains(t, export.Manifest, "cowboys."+wildwest.GroupName, "expected the CRD in the manifest of workspace1")
+
+				t.Logf("Create Workspace workspace2 as user-1 and import the manifest of workspace1")
+				workspace2, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace2.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace2")
 				server.Artifact(t, func() (runtime.Object, error) {
-					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, testData.workspace1.Name, metav1.GetOptions{})
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace2.Name, metav1.GetOptions{})
+				})
+				workspace2ClusterName, err := helper.EncodeLogicalClusterName(waitForReady(workspace2.Name))
+				require.NoError(t, err)
+
+				var imported tenancyv1beta1.WorkspaceImport
+				workspaceImport := &tenancyv1beta1.WorkspaceImport{Spec: tenancyv1beta1.WorkspaceImportSpec{Manifest: export.Manifest}}
+				err = vwUser1Client.TenancyV1beta1().RESTClient().Post().Resource("workspaces").Name(workspace2.Name).SubResource("import").Body(workspaceImport).Do(ctx).Into(&imported)
+				require.NoError(t, err, "failed to import the manifest of workspace1 into workspace2")
+				require.Contains(t, imported.Status.Created, "customresourcedefinitions.apiextensions.k8s.io/cowboys."+wildwest.GroupName)
+
+				_, err = server.crdClusterClient.Cluster(workspace2ClusterName).ApiextensionsV1().CustomResourceDefinitions().Get(ctx, "cowboys."+wildwest.GroupName, metav1.GetOptions{})
+				require.NoError(t, err, "expected the CRD to be imported into workspace2")
+			},
+		},
+		{
+			name: "create child workspaces inside a workspace in personal virtual workspace and list them through its children",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create Workspace workspace1 as user-1")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				server.Artifact(t, func() (runtime.Object, error) {
+					return server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
 				})
 
-				workspaceURL := ""
+				var workspace1ClusterName string
 				var lastErr error
 				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
-					defer func() {
-						lastErr = err
-					}()
-
 					cw, err := server.orgKcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, workspace1.Name, metav1.GetOptions{})
 					if err != nil {
-						if apierrors.IsNotFound(err) {
+						lastErr = err
+						return false, nil
+					}
+					if cw.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+						lastErr = fmt.Errorf("ClusterWorkspace %s is in phase %q", cw.Name, cw.Status.Phase)
+						return false, nil
+					}
+					workspace1ClusterName, err = helper.EncodeLogicalClusterName(cw)
+					return err == nil, err
+				})
+				require.NoError(t, err, "did not see workspace1 ready: %v", lastErr)
+
+				t.Logf("Create the child workspaces child-a and child-b inside of workspace1")
+				err = configcrds.Create(ctx, server.crdClusterClient.Cluster(workspace1ClusterName).ApiextensionsV1().CustomResourceDefinitions(),
+					metav1.GroupResource{Group: tenancy.GroupName, Resource: "clusterworkspaces"},
+				)
+				require.NoError(t, err, "failed to install the ClusterWorkspace CRD in workspace1")
+				for _, name := range []string{"child-b", "child-a"} {
+					child := &tenancyv1alpha1.ClusterWorkspace{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+					}
+					err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+						_, err = server.kcpClusterClient.Cluster(workspace1ClusterName).TenancyV1alpha1().ClusterWorkspaces().Create(ctx, child, metav1.CreateOptions{})
+						if err != nil && !apierrors.IsAlreadyExists(err) {
+							// the CRD might not be served yet
+							lastErr = err
 							return false, nil
 						}
-						return false, err
-					} else if !conditions.IsTrue(cw, tenancyv1alpha1.WorkspaceShardValid) {
-						return false, fmt.Errorf("ClusterWorkspace %s is not valid: %s", cw.Name, conditions.GetMessage(cw, tenancyv1alpha1.WorkspaceShardValid))
+						return true, nil
+					})
+					require.NoError(t, err, "failed to create the child workspace %s: %v", name, lastErr)
+				}
+
+				t.Logf("List the children of workspace1 as user-1, one page at a time")
+				var children []string
+				err = wait.PollImmediate(time.Millisecond*100, wait.ForeverTestTimeout, func() (done bool, err error) {
+					children = nil
+					continueToken := ""
+					for {
+						var page tenancyv1beta1.WorkspaceList
+						req := vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name).SubResource("children").Param("limit", "1")
+						if continueToken != "" {
+							req = req.Param("continue", continueToken)
+						}
+						if err := req.Do(ctx).Into(&page); err != nil {
+							lastErr = err
+							return false, nil
+						}
+						if len(page.Items) > 1 {
+							return false, fmt.Errorf("expected pages of at most 1 child, got %d", len(page.Items))
+						}
+						for _, child := range page.Items {
+							children = append(children, child.Name)
+						}
+						if page.Continue == "" {
+							break
+						}
+						continueToken = page.Continue
 					}
-					workspaceURL = cw.Status.BaseURL
-					return true, nil
+					lastErr = fmt.Errorf("got children %v", children)
+					return len(children) == 2, nil
 				})
-				require.NoError(t, err, "did not see the workspace created and valid in KCP: %v", lastErr)
+				require.NoError(t, err, "did not see the children of workspace1: %v", lastErr)
+				require.Equal(t, []string{"child-a", "child-b"}, children)
 
-				err = server.virtualWorkspaceExpectations[0](func(w *tenancyv1beta1.WorkspaceList) error {
-					if len(w.Items) != 1 || w.Items[0].Name != workspace1.Name {
-						return fmt.Errorf("expected only one workspace (%s), got %#v", workspace1.Name, w)
+				_, err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name("unknown").SubResource("children").Do(ctx).Get()
+				require.True(t, apierrors.IsNotFound(err), "expected the children of an unknown workspace to be not found, got %v", err)
+
+				t.Logf("Retrieve the kubeconfig of the child workspace child-a through its path as user-1")
+				childPath := workspace1.Name + ":child-a"
+				var childKubeconfig *clientcmdapi.Config
+				err = framework.PollWithBackoff(ctx, func(ctx context.Context) error {
+					// the child is only valid once it has been scheduled onto a shard
+					content, err := vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(childPath).SubResource("kubeconfig").Do(ctx).Raw()
+					if err != nil {
+						return err
 					}
-					return nil
+					childKubeconfig, err = clientcmd.Load(content)
+					return err
 				})
-				require.NoError(t, err, "did not see the workspace created in personal virtual workspace")
+				require.NoError(t, err, "failed to get the kubeconfig of %s", childPath)
 
-				req := vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name).SubResource("kubeconfig").Do(ctx)
-				require.Nil(t, req.Error(), "error retrieving the kubeconfig for workspace %s: %v", workspace1.Name, err)
+				childContextName := "personal/" + childPath
+				require.Equal(t, childContextName, childKubeconfig.CurrentContext)
+				require.Contains(t, childKubeconfig.Clusters, childContextName)
+				childA, err := server.kcpClusterClient.Cluster(workspace1ClusterName).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, "child-a", metav1.GetOptions{})
+				require.NoError(t, err, "failed to get the child workspace child-a")
+				childClusterName, err := helper.EncodeLogicalClusterName(childA)
+				require.NoError(t, err)
+				require.Equal(t, childA.Status.BaseURL, childKubeconfig.Clusters[childContextName].Server)
+				require.True(t, strings.HasSuffix(childKubeconfig.Clusters[childContextName].Server, "/clusters/"+childClusterName), "expected the server of the kubeconfig to point at the child workspace, got %s", childKubeconfig.Clusters[childContextName].Server)
 
-				kcpConfigCurrentContextName := kcpServerKubeconfig.CurrentContext
-				kcpConfigCurrentContext := kcpServerKubeconfig.Contexts[kcpConfigCurrentContextName]
-				require.NotNil(t, kcpConfigCurrentContext, "kcp Kubeconfig is invalid")
+				_, err = vwUser1Client.TenancyV1beta1().RESTClient().Get().Resource("workspaces").Name(workspace1.Name + ":unknown").SubResource("kubeconfig").Do(ctx).Raw()
+				require.True(t, apierrors.IsNotFound(err), "expected the kubeconfig of an unknown child workspace to be not found, got %v", err)
+			},
+		}, {
+			name: "list the workspace shards with their load in the admin shards virtual workspace",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
+					},
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				// the shards virtual workspace is served by the same server, at its own prefix
+				shardsConfig := rest.CopyConfig(server.virtualWorkspaceConfigs[0])
+				shardsConfig.Host = strings.TrimSuffix(shardsConfig.Host, server.virtualWorkspaceClientContexts[0].Prefix) + shardsbuilder.DefaultRootPathPrefix
+				kcpAdminConfig, err := server.RunningServer.RawConfig()
+				require.NoError(t, err)
+				adminShardsConfig := rest.CopyConfig(shardsConfig)
+				adminShardsConfig.BearerToken = kcpAdminConfig.AuthInfos["admin"].Token
+				adminShardsClient, err := clientset.NewForConfig(adminShardsConfig)
+				require.NoError(t, err)
+				user1ShardsClient, err := clientset.NewForConfig(shardsConfig)
+				require.NoError(t, err)
 
-				kcpConfigCurrentCluster := kcpServerKubeconfig.Clusters[kcpConfigCurrentContext.Cluster]
-				require.NotNil(t, kcpConfigCurrentCluster, "kcp Kubeconfig is invalid")
+				expectedShards, err := server.rootKcpClient.TenancyV1alpha1().WorkspaceShards().List(ctx, metav1.ListOptions{})
+				require.NoError(t, err)
+				require.NotEmpty(t, expectedShards.Items, "expected the root workspace to have shards")
+				var expectedShardNames []string
+				for _, shard := range expectedShards.Items {
+					expectedShardNames = append(expectedShardNames, shard.Name)
+				}
+				sort.Strings(expectedShardNames)
 
-				expectedKubeconfigCluster := kcpConfigCurrentCluster.DeepCopy()
-				expectedKubeconfigCluster.Server = workspaceURL
-				expectedKubeconfig := &clientcmdapi.Config{
-					CurrentContext: "personal/" + workspace1.Name,
-					Contexts: map[string]*clientcmdapi.Context{
-						"personal/" + workspace1.Name: {
-							Cluster: "personal/" + workspace1.Name,
-						},
+				t.Logf("List the workspace shards as the kcp admin, a member of %s", kuser.SystemPrivilegedGroup)
+				var shardNames []string
+				err = framework.PollWithBackoff(ctx, func(ctx context.Context) error {
+					shards, err := adminShardsClient.TenancyV1alpha1().WorkspaceShards().List(ctx, metav1.ListOptions{})
+					if err != nil {
+						return err
+					}
+					shardNames = nil
+					for _, shard := range shards.Items {
+						shardNames = append(shardNames, shard.Name)
+					}
+					if len(shardNames) != len(expectedShardNames) {
+						return fmt.Errorf("got shards %v", shardNames)
+					}
+					return nil
+				})
+				require.NoError(t, err, "did not see all the workspace shards")
+				require.Equal(t, expectedShardNames, shardNames)
+
+				t.Logf("Fail to list the workspace shards as user-1")
+				_, err = user1ShardsClient.TenancyV1alpha1().WorkspaceShards().List(ctx, metav1.ListOptions{})
+				require.True(t, apierrors.IsForbidden(err), "expected user-1 to be forbidden, got %v", err)
+			},
+		}, {
+			name: "delete a collection of labeled workspaces in personal virtual workspace with a selector",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
 					},
-					Clusters: map[string]*clientcmdapi.Cluster{
-						"personal/" + workspace1.Name: expectedKubeconfigCluster,
+				}
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
+
+				t.Logf("Create three labeled workspaces as user-1")
+				for name, cleanup := range map[string]string{"cleanup-a": "true", "cleanup-b": "true", "keep": "false"} {
+					workspace := &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"cleanup": cleanup}}}
+					_, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, workspace, metav1.CreateOptions{})
+					require.NoError(t, err, "failed to create workspace %s", name)
+				}
+				require.Eventually(t, func() bool {
+					workspaces, err := vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+					return err == nil && len(workspaces.Items) == 3
+				}, wait.ForeverTestTimeout, time.Millisecond*100, "did not see the three workspaces")
+
+				t.Logf("Delete the workspaces labeled for cleanup with a selector")
+				err := vwUser1Client.TenancyV1beta1().Workspaces().DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: "cleanup=true"})
+				require.NoError(t, err, "failed to delete the workspaces labeled for cleanup")
+
+				var names []string
+				require.Eventually(t, func() bool {
+					workspaces, err := vwUser1Client.TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+					if err != nil {
+						return false
+					}
+					names = nil
+					for _, workspace := range workspaces.Items {
+						names = append(names, workspace.Name)
+					}
+					return len(names) == 1
+				}, wait.ForeverTestTimeout, time.Millisecond*100, "expected only one workspace to be left")
+				require.Equal(t, []string{"keep"}, names)
+			},
+		}, {
+			name: "observe the creation of a workspace in personal virtual workspace through a shared informer",
+			virtualWorkspaceClientContexts: func(orgName string) []helpers.VirtualWorkspaceClientContext {
+				return []helpers.VirtualWorkspaceClientContext{
+					{
+						User:   testData.user1,
+						Prefix: "/" + orgName + "/personal",
 					},
 				}
-				expectedKubeconfigContent, err := clientcmd.Write(*expectedKubeconfig)
-				require.NoError(t, err, "error writing the content of the expected kubeconfig for workspace %s", workspace1.Name)
+			},
+			work: func(ctx context.Context, t *testing.T, server runningServer) {
+				vwUser1Client := server.virtualWorkspaceClients[0]
 
-				workspaceKubeconfigContent, err := req.Raw()
-				require.NoError(t, err, "error retrieving the content of the kubeconfig for workspace %s", workspace1.Name)
+				t.Logf("Start a shared informer of the workspaces of user-1")
+				factory, err := workspaceinformers.NewSharedInformerFactory(server.virtualWorkspaceConfigs[0], 0)
+				require.NoError(t, err)
+				created := make(chan string, 10)
+				informer := factory.Tenancy().V1beta1().Workspaces()
+				informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+					AddFunc: func(obj interface{}) { created <- obj.(*tenancyv1beta1.Workspace).Name },
+				})
+				factory.Start(ctx.Done())
+				factory.WaitForCacheSync(ctx.Done())
 
-				require.YAMLEq(t, string(expectedKubeconfigContent), string(workspaceKubeconfigContent))
+				t.Logf("Create Workspace workspace1 as user-1 and observe it through the informer")
+				workspace1, err := vwUser1Client.TenancyV1beta1().Workspaces().Create(ctx, testData.workspace1.DeepCopy(), metav1.CreateOptions{})
+				require.NoError(t, err, "failed to create workspace1")
+				timeout := time.After(wait.ForeverTestTimeout)
+				for observed := false; !observed; {
+					select {
+					case name := <-created:
+						observed = name == workspace1.Name
+					case <-timeout:
+						t.Fatal("did not observe the creation of workspace1")
+					}
+				}
+				require.Eventually(t, func() bool {
+					_, err := informer.Lister().Get(workspace1.Name)
+					return err == nil
+				}, wait.ForeverTestTimeout, time.Millisecond*100, "did not find workspace1 in the lister")
 			},
 		},
 	}
@@ -298,6 +2476,18 @@ func TestWorkspacesVirtualWorkspaces(t *testing.T) {
 			usersKCPArgs, err := framework.Users(users).ArgsForKCP(t)
 			require.NoError(t, err)
 
+			// the virtual workspace signs workspace tokens that kcp verifies
+			tokenKeyDir := t.TempDir()
+			tokenSigningKeyFile, tokenKeyFile := filepath.Join(tokenKeyDir, "token-signing.key"), filepath.Join(tokenKeyDir, "token.pub")
+			tokenSigningKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+			tokenSigningKeyDER, err := x509.MarshalECPrivateKey(tokenSigningKey)
+			require.NoError(t, err)
+			require.NoError(t, keyutil.WriteKey(tokenSigningKeyFile, pem.EncodeToMemory(&pem.Block{Type: keyutil.ECPrivateKeyBlockType, Bytes: tokenSigningKeyDER})))
+			tokenKeyDER, err := x509.MarshalPKIXPublicKey(tokenSigningKey.Public())
+			require.NoError(t, err)
+			require.NoError(t, keyutil.WriteKey(tokenKeyFile, pem.EncodeToMemory(&pem.Block{Type: keyutil.PublicKeyBlockType, Bytes: tokenKeyDER})))
+
 			// TODO(marun) Can fixture be shared for this test?
 			f := framework.NewKcpFixture(t,
 				framework.KcpConfig{
@@ -305,6 +2495,7 @@ func TestWorkspacesVirtualWorkspaces(t *testing.T) {
 					Args: append([]string{
 						"--run-controllers=false",
 						"--unsupported-run-individual-controllers=workspace-scheduler",
+						"--authentication-workspace-token-key-file=" + tokenKeyFile,
 					}, usersKCPArgs...),
 				},
 			)
@@ -349,25 +2540,35 @@ func TestWorkspacesVirtualWorkspaces(t *testing.T) {
 					require.NoError(t, err)
 
 					return &workspacescmd.WorkspacesSubCommandOptions{
-						KubeconfigFile: cfgPath,
-						RootPathPrefix: "/",
+						KubeconfigFile:      cfgPath,
+						RootPathPrefix:      "/",
+						TokenSigningKeyFile: tokenSigningKeyFile,
+						SoftDeleteRetention: testCase.softDeleteRetention,
 					}
 				},
 				ClientContexts: clientContexts,
 			}
+			if testCase.groupOrgs != nil {
+				vw.GroupOrgs = testCase.groupOrgs(orgClusterName)
+			}
 
 			vwConfigs, err := vw.Setup(t, ctx, server)
 			require.NoError(t, err)
 
 			virtualWorkspaceClients := []clientset.Interface{}
 			virtualWorkspaceExpectations := []framework.RegisterWorkspaceListExpectation{}
-			for _, vwConfig := range vwConfigs {
+			for i, vwConfig := range vwConfigs {
 				vwClients, err := clientset.NewForConfig(vwConfig)
 				require.NoError(t, err, "failed to construct client for server")
 
 				virtualWorkspaceClients = append(virtualWorkspaceClients, vwClients)
 
-				expecter, err := framework.ExpectWorkspaceListPolling(ctx, t, vwClients)
+				expectWorkspaceList := framework.ExpectWorkspaceListWatching
+				if strings.HasPrefix(clientContexts[i].Prefix, "/"+virtualworkspacesregistry.AllOrgs+"/") {
+					// workspaces can only be listed across all organizations, not watched
+					expectWorkspaceList = framework.ExpectWorkspaceListPolling
+				}
+				expecter, err := expectWorkspaceList(ctx, t, vwClients)
 				require.NoError(t, err, "failed to start expecter")
 
 				virtualWorkspaceExpectations = append(virtualWorkspaceExpectations, expecter)
@@ -382,12 +2583,20 @@ func TestWorkspacesVirtualWorkspaces(t *testing.T) {
 			kcpClusterClient, err := clientset.NewClusterForConfig(kcpCfg)
 			require.NoError(t, err, "failed to construct client for server")
 
+			crdClusterClient, err := apiextensionsclient.NewClusterForConfig(kcpCfg)
+			require.NoError(t, err, "failed to construct client for server")
+
 			testCase.work(ctx, t, runningServer{
 				RunningServer:                  server,
+				orgClusterName:                 orgClusterName,
 				orgKubeClient:                  kubeClusterClient.Cluster(orgClusterName),
+				crdClusterClient:               crdClusterClient,
 				orgKcpClient:                   kcpClusterClient.Cluster(orgClusterName),
 				rootKcpClient:                  kcpClusterClient.Cluster(helper.RootCluster),
+				kcpClusterClient:               kcpClusterClient,
+				kubeClusterClient:              kubeClusterClient,
 				virtualWorkspaceClientContexts: clientContexts,
+				virtualWorkspaceConfigs:        vwConfigs,
 				virtualWorkspaceClients:        virtualWorkspaceClients,
 				virtualWorkspaceExpectations:   virtualWorkspaceExpectations,
 			})